@@ -0,0 +1,128 @@
+"""
+CLI Key Bindings
+Declarative keymaps for the interactive views, with a help bar and a `?` listing of every shortcut
+"""
+
+from dataclasses import dataclass
+from typing import Callable, Dict, List, Optional, Tuple, Any
+
+HELP_KEY = "?"
+
+
+@dataclass(frozen=True)
+class Binding:
+    """Keys that trigger an action; accepts, when set, matches free input such as an id instead"""
+    keys: Tuple[str, ...]
+    action: str
+    help: str
+    accepts: Optional[Callable[[str], bool]] = None
+
+    def matches(self, answer: str) -> bool:
+        if self.accepts is not None:
+            return self.accepts(answer)
+        return answer in self.keys
+
+    @property
+    def label(self) -> str:
+        return "/".join(key or "enter" for key in self.keys)
+
+
+class KeyMap:
+    """One view's bindings; the first binding with the empty key is what Enter does"""
+
+    def __init__(self, view: str, bindings: List[Binding], quit_action: str = "quit"):
+        self.view = view
+        self.bindings = bindings
+        self.quit_action = quit_action
+
+    def help_bar(self) -> str:
+        return ", ".join(f"{b.label} {b.action}" for b in self.bindings) + f", {HELP_KEY} help"
+
+    def help_text(self) -> str:
+        width = max(len(b.label) for b in self.bindings)
+        lines = [f"{self.view} keys:"]
+        lines += [f"  {b.label.ljust(width)}  {b.help}" for b in self.bindings]
+        lines.append(f"  {HELP_KEY.ljust(width)}  Show this help")
+        return "\n".join(lines)
+
+    def lookup(self, answer: str) -> Optional[str]:
+        for binding in self.bindings:
+            if binding.matches(answer):
+                return binding.action
+        return None
+
+    def read(self, prompt: str = "") -> Tuple[str, str]:
+        """Prompt until a bound key is entered, returning its action and the raw answer
+
+        `?` prints the full listing and asks again. End of input or Ctrl-C
+        counts as the quit action.
+        """
+        while True:
+            try:
+                answer = input(f"{prompt}[{self.help_bar()}] ").strip().lower()
+            except (EOFError, KeyboardInterrupt):
+                print()
+                return self.quit_action, ""
+            if answer == HELP_KEY:
+                print(self.help_text())
+                continue
+            action = self.lookup(answer)
+            if action is not None:
+                return action, answer
+            print(f"  unknown key '{answer}', {HELP_KEY} for help")
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "view": self.view,
+            "bindings": [{"keys": b.label, "action": b.action, "help": b.help} for b in self.bindings]
+        }
+
+
+PAGER_KEYS = KeyMap("list", [
+    Binding(("", "y"), "more", "Load the next page"),
+    Binding(("n", "q"), "quit", "Stop listing"),
+])
+
+WIZARD_MENU_KEYS = KeyMap("wizard menu", [
+    Binding(("<id>",), "add", "Add the menu item with this id to the order", accepts=str.isdigit),
+    Binding(("n",), "next", "Next page"),
+    Binding(("p",), "previous", "Previous page"),
+    Binding(("/",), "search", "Show one cuisine only"),
+    Binding(("a",), "available", "Toggle hiding 86'd items"),
+    Binding(("", "d"), "done", "Done adding items"),
+    Binding(("q",), "quit", "Leave without submitting anything"),
+])
+
+WIZARD_REVIEW_KEYS = KeyMap("wizard review", [
+    Binding(("", "s"), "submit", "Submit the order as reviewed"),
+    Binding(("m",), "menu", "Back to the menu to add items"),
+    Binding(("r",), "remove", "Take a line off the order"),
+    Binding(("e",), "edit", "Change the order type, restrictions or priority, then review again"),
+    Binding(("q",), "quit", "Leave without submitting anything"),
+])
+
+TRANSCRIPT_BROWSER_KEYS = KeyMap("transcript browser", [
+    Binding(("n",), "next", "Next page"),
+    Binding(("p",), "previous", "Previous page"),
+    Binding(("<id>",), "open", "Open the transcript with this id", accepts=str.isdigit),
+    Binding(("q",), "quit", "Leave the browser"),
+])
+
+TRANSCRIPT_KEYS = KeyMap("transcript", [
+    Binding(("",), "back", "Back to the list"),
+    Binding(("q",), "quit", "Leave the browser"),
+])
+
+ORDERS_KEYS = KeyMap("orders", [
+    Binding(("/",), "search", "Search task ids, types, agents, tables, guest notes and annotations"),
+    Binding(("s",), "status", "Toggle a status filter"),
+    Binding(("r",), "priority", "Toggle a priority filter (expedite, reassign)"),
+    Binding(("t",), "type", "Toggle a task type filter"),
+    Binding(("c",), "clear", "Clear the search and every filter"),
+    Binding(("o",), "open", "Open an order with its notes"),
+    Binding(("n",), "next", "Next page"),
+    Binding(("b",), "previous", "Previous page"),
+    Binding(("q",), "quit", "Leave the orders view"),
+])
+
+KEYMAPS = [PAGER_KEYS, WIZARD_MENU_KEYS, WIZARD_REVIEW_KEYS, TRANSCRIPT_BROWSER_KEYS, TRANSCRIPT_KEYS, ORDERS_KEYS]
@@ -0,0 +1,67 @@
+"""
+CLI Layout
+Terminal size detection and fitting text and table columns to the width available
+"""
+
+import re
+import shutil
+from typing import Dict, Optional, TextIO, Tuple
+
+ANSI_ESCAPE = re.compile(r"\x1b\[[0-9;]*m")
+
+ELLIPSIS = "~"
+MIN_COLUMN_WIDTH = 6
+COLUMN_GAP = 2
+
+
+def visible_len(text: str) -> int:
+    """Length as printed, without color codes"""
+    return len(ANSI_ESCAPE.sub("", text))
+
+
+def terminal_size(out: TextIO) -> Optional[Tuple[int, int]]:
+    """(columns, lines) of the terminal out writes to, or None for pipes and files
+
+    Read afresh on every call, so a resized window is picked up by the next draw.
+    """
+    isatty = getattr(out, "isatty", None)
+    if not (isatty and isatty()):
+        return None
+    size = shutil.get_terminal_size()
+    return size.columns, size.lines
+
+
+def clip(text: str, width: int) -> str:
+    """Cut text to width printed characters, keeping its color codes and marking the cut"""
+    if visible_len(text) <= width:
+        return text
+    keep = max(width - len(ELLIPSIS), 0)
+    out, shown, position = [], 0, 0
+    for match in ANSI_ESCAPE.finditer(text):
+        plain = text[position:match.start()]
+        out.append(plain[:keep - shown])
+        shown += min(len(plain), keep - shown)
+        out.append(match.group())
+        position = match.end()
+    out.append(text[position:][:keep - shown])
+    colored = "\x1b[" in text
+    return "".join(out) + ("\x1b[0m" if colored else "") + ELLIPSIS[:width]
+
+
+def fit_columns(widths: Dict[str, int], available: Optional[int]) -> Dict[str, int]:
+    """Shrink the widest columns, one character at a time, until the row fits
+
+    Columns don't go below MIN_COLUMN_WIDTH (or their own width if smaller),
+    so a very narrow terminal still wraps rather than losing every column.
+    """
+    if available is None:
+        return widths
+    fitted = dict(widths)
+    floor = {col: min(width, MIN_COLUMN_WIDTH) for col, width in widths.items()}
+    budget = available - COLUMN_GAP * (len(widths) - 1)
+    while sum(fitted.values()) > budget:
+        col = max(fitted, key=lambda c: fitted[c] - floor[c])
+        if fitted[col] <= floor[col]:
+            break
+        fitted[col] -= 1
+    return fitted
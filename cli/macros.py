@@ -0,0 +1,49 @@
+"""
+CLI Macros
+Named, replayable sequences of CLI commands for demos and reproducible test setups
+"""
+
+import json
+from pathlib import Path
+from typing import Dict, List, Optional
+
+from client import DEFAULT_CONFIG_DIR
+
+DEFAULT_MACRO_PATH = DEFAULT_CONFIG_DIR / "macros.json"
+
+
+class MacroStore:
+    """JSON file of macro name -> list of argv steps"""
+
+    def __init__(self, path: Optional[Path] = None):
+        self.path = Path(path) if path else DEFAULT_MACRO_PATH
+        self.macros: Dict[str, List[List[str]]] = {}
+        if self.path.exists():
+            with open(self.path, 'r', encoding='utf-8') as f:
+                self.macros = json.load(f)
+
+    def save(self):
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        with open(self.path, 'w') as f:
+            json.dump(self.macros, f, indent=2)
+
+    def record(self, name: str, step: List[str], repeat: int = 1):
+        """Append a step (CLI argv without the program name) to a macro"""
+        if not step:
+            raise ValueError("Macro step is empty")
+        if step[0] == "macro":
+            raise ValueError("Macros cannot record other macro commands")
+
+        self.macros.setdefault(name, []).extend([list(step)] * repeat)
+        self.save()
+
+    def get(self, name: str) -> List[List[str]]:
+        if name not in self.macros:
+            raise KeyError(f"No macro named '{name}'")
+        return self.macros[name]
+
+    def delete(self, name: str) -> bool:
+        if self.macros.pop(name, None) is None:
+            return False
+        self.save()
+        return True
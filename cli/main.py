@@ -0,0 +1,2960 @@
+"""
+ChefBench Command Line Interface
+Scriptable subcommands over the REST API, with --json output for CI pipelines
+"""
+
+import argparse
+import base64
+import io
+import json
+import os
+import sys
+import time
+import zipfile
+from datetime import datetime
+from pathlib import Path
+from typing import Dict, List, Optional, Any
+
+from client import APIError, ChefBenchClient, ChefBenchClientError, MOCK_MODEL, SETTINGS, THEMES, SettingsStore, ThrottledError
+from .keymap import (
+    KEYMAPS, ORDERS_KEYS, PAGER_KEYS, TRANSCRIPT_BROWSER_KEYS, TRANSCRIPT_KEYS, WIZARD_MENU_KEYS, WIZARD_REVIEW_KEYS
+)
+from .macros import MacroStore
+from .notify import CRITICAL_EVENTS, NOTIFY_METHODS, notify
+from .progress import follow
+from .report import REPORT_FORMATS, build_report, render_report
+from .layout import clip, fit_columns, terminal_size, visible_len
+from .theme import load_theme
+
+
+SCENARIO_FIELDS = {
+    "scenario_type", "duration_seconds", "num_tasks",
+    "use_dataset", "assignment_policy", "seed", "simulate_equipment", "scoring_profile",
+    "dietary_restrictions", "quality_rubric", "judge_transcripts", "judge_model", "seed_profile",
+    "simulate_guests", "simulate_customers", "customer_demand", "plan_pacing", "expeditor",
+    "prompt_overrides", "max_tokens", "max_cost"
+}
+
+
+def _print_json(data: Any):
+    print(json.dumps(data, indent=2, default=str))
+
+
+def _print_table(rows: List[Dict[str, Any]], columns: List[str]):
+    """Print rows as a table, its columns narrowed to fit the terminal"""
+    if not rows:
+        print("(none)")
+        return
+
+    def pad(value: Any, width: int) -> str:
+        # Colored cells are padded by their printed width
+        text = clip(str(value), width)
+        return text + " " * (width - visible_len(text))
+
+    widths = {
+        col: max(len(col), *(visible_len(str(row.get(col, ""))) for row in rows))
+        for col in columns
+    }
+    # On a terminal, the widest columns give way so rows don't wrap
+    size = terminal_size(sys.stdout)
+    widths = fit_columns(widths, size[0] if size else None)
+    print("  ".join(pad(col.upper(), widths[col]) for col in columns))
+    for row in rows:
+        print("  ".join(pad(row.get(col, ""), widths[col]) for col in columns))
+
+
+def _format_float(value: Any) -> str:
+    return f"{value:.3f}" if isinstance(value, (int, float)) else str(value)
+
+
+def _load_scenario_file(path: str) -> Dict[str, Any]:
+    """Load scenario parameters from a JSON or YAML file"""
+    file_path = Path(path)
+    with open(file_path, 'r', encoding='utf-8') as f:
+        if file_path.suffix in (".yaml", ".yml"):
+            try:
+                import yaml
+            except ImportError:
+                raise SystemExit("PyYAML is required for YAML scenario files (pip install pyyaml)")
+            return yaml.safe_load(f) or {}
+        return json.load(f)
+
+
+# Command handlers
+
+def cmd_status(api: ChefBenchClient, args) -> Any:
+    info = api.ping()
+    if args.json:
+        return {**info, "client_status": api.status, "base_url": api.base_url}
+
+    print(f"{info['name']} {info['version']} at {api.base_url}: {args.theme.status(info['status'])} ({api.status})")
+    for component, state in info.get("components", {}).items():
+        print(f"  {component}: {args.theme.status(state)}")
+
+
+def cmd_health(api: ChefBenchClient, args) -> Any:
+    report = api.readyz(args.check)
+    if args.json:
+        _print_json(report)
+    else:
+        print(f"Readiness: {args.theme.status(report['status'])}")
+        _print_table(
+            [
+                {
+                    "dependency": name,
+                    "status": args.theme.status(dep["status"]),
+                    "required": "yes" if dep["required"] else "no",
+                    "latency_ms": dep["latency_ms"],
+                    "detail": dep.get("error") or dep.get("detail", "")
+                }
+                for name, dep in report["dependencies"].items()
+            ],
+            ["dependency", "status", "required", "latency_ms", "detail"]
+        )
+    if not report["ready"]:
+        raise SystemExit(1)
+
+
+def cmd_seed(api: ChefBenchClient, args) -> Any:
+    if args.profile is None:
+        data = api.get_seed_profiles()
+        if args.json:
+            return data
+        _print_table(data["profiles"], ["name", "description"])
+        return None
+
+    data = api.apply_seed_profile(args.profile)
+    if args.json:
+        return data
+    created = ", ".join(f"{count} from {seeder}" for seeder, count in data["created"].items()) or "nothing"
+    print(f"Seeded '{data['profile']}': {created}")
+
+
+def _print_pages(fetch, rows_of, columns: List[str], offset: int = 0):
+    """Print a list page by page, offering to load more when there is a terminal to ask on"""
+    while True:
+        data = fetch(offset)
+        _print_table(rows_of(data), columns)
+        if data.get("next_offset") is None:
+            return
+        offset = data["next_offset"]
+        remaining = data["total"] - offset
+        if not sys.stdin.isatty():
+            print(f"... {remaining} more (--offset {offset})")
+            return
+        action, _ = PAGER_KEYS.read(f"-- {remaining} more ")
+        if action == "quit":
+            return
+
+
+def cmd_agents_list(api: ChefBenchClient, args) -> Any:
+    def fetch(offset: int) -> Dict[str, Any]:
+        return api.list_agents(role=args.role, model=args.model, sort=args.sort, limit=args.limit, offset=offset)
+
+    if args.json:
+        return fetch(args.offset)
+
+    def rows_of(data: Dict[str, Any]) -> List[Dict[str, Any]]:
+        return [
+            {
+                "name": agent["name"],
+                "role": agent["role"],
+                "model": agent["model"],
+                "tasks": agent["metrics"].get("tasks_completed", 0),
+                "success": _format_float(agent["metrics"].get("success_rate", 0)),
+                "quality": _format_float(agent["metrics"].get("avg_quality", 0))
+            }
+            for agent in data["agents"]
+        ]
+
+    _print_pages(fetch, rows_of, ["name", "role", "model", "tasks", "success", "quality"], args.offset)
+
+
+def _agent_model(args) -> str:
+    """--model if given, else the mock model in mock mode, else the default model setting"""
+    if args.model:
+        return args.model
+    return MOCK_MODEL if args.settings.get("mock_mode") else args.settings.get("default_model")
+
+
+def cmd_agents_create(api: ChefBenchClient, args) -> Any:
+    data = api.create_agent(args.name, args.role, args.device, _agent_model(args), args.fallback)
+    if args.json:
+        return data
+    agent = data["agent"]
+    print(f"Created {agent['name']} ({agent['role']}, {agent['model']})")
+
+
+def cmd_agents_memory(api: ChefBenchClient, args) -> Any:
+    data = api.get_agent_memory(args.name)
+    if args.json:
+        return data
+    settings = data["settings"]
+    print(f"{data['name']}: {len(data['recent'])} recent memories (window {settings['window']}), "
+          f"{data['summarized_events']} summarized in {data['compactions']} compactions")
+    for summary in data["summaries"]:
+        print(f"  [{summary['events']} events, {summary['summarizer']}] {summary['text']}")
+    for line in data["recent"]:
+        print(f"  {line}")
+
+
+def cmd_agents_skills(api: ChefBenchClient, args) -> Any:
+    if args.set or args.certifications is not None:
+        skills = {}
+        for item in args.set or []:
+            task, _, value = item.partition("=")
+            try:
+                skills[task] = float(value)
+            except ValueError:
+                skills[task] = value
+        data = api.update_agent_skills(args.name, skills, args.certifications)
+    else:
+        data = api.get_agent_skills(args.name)
+    if args.json:
+        return data
+
+    level = f", level {data['level']} trainee" if data["trainee"] else ""
+    print(f"{data['name']} ({data['role']}{level})")
+    print(f"  certifications: {', '.join(data['certifications']) or '-'}")
+    rows = [
+        {"task": task, "skill": f"{skill:.2f}", "proficiency": data["proficiency"][task]}
+        for task, skill in sorted(data["skills"].items())
+    ]
+    if rows:
+        _print_table(rows, ["task", "skill", "proficiency"])
+    else:
+        print("  no individual skills set")
+
+
+def cmd_teams_create(api: ChefBenchClient, args) -> Any:
+    roles = args.roles.split(",") if args.roles else None
+    data = api.create_uniform_team(_agent_model(args), args.size, roles, args.fallback)
+    if args.json:
+        return data
+    _print_table(data["agents"], ["name", "role", "model"])
+
+
+def cmd_teams_routed(api: ChefBenchClient, args) -> Any:
+    roles = args.roles.split(",") if args.roles else None
+    data = api.create_routed_team(args.size, roles, args.fallback, args.default_model)
+    if args.json:
+        return data
+    _print_table(data["agents"], ["name", "role", "model"])
+
+
+def cmd_teams_routing(api: ChefBenchClient, args) -> Any:
+    roles = {}
+    for spec in args.set or []:
+        role, sep, model = spec.partition("=")
+        if not sep:
+            raise SystemExit(f"Expected ROLE=MODEL, got '{spec}'")
+        roles[role.strip().upper()] = model.strip() or None
+    if roles or args.default:
+        api.set_model_routing(roles or None, args.default)
+    data = api.get_model_routing()
+    if args.json:
+        return data
+    routing = data["routing"]
+    rows = [{"role": role, "model": model} for role, model in routing["roles"].items()]
+    rows.append({"role": "(default)", "model": routing["default"] or "-"})
+    _print_table(rows, ["role", "model"])
+    if data["team"]["by_role"]:
+        print("\nCurrent team")
+        _print_table([
+            {"role": role, "model": model if isinstance(model, str) else ", ".join(model)}
+            for role, model in data["team"]["by_role"].items()
+        ], ["role", "model"])
+
+
+def cmd_bench_run(api: ChefBenchClient, args) -> Any:
+    params = _load_scenario_file(args.scenario_file) if args.scenario_file else {}
+    unknown = set(params) - SCENARIO_FIELDS
+    if unknown:
+        raise SystemExit(f"Unknown scenario fields: {', '.join(sorted(unknown))}")
+
+    for key in ("scenario_type", "duration_seconds", "num_tasks", "assignment_policy", "seed",
+                "simulate_equipment", "scoring_profile", "dietary_restrictions", "quality_rubric",
+                "judge_transcripts", "judge_model", "seed_profile", "simulate_guests",
+                "simulate_customers", "plan_pacing", "expeditor", "max_tokens", "max_cost"):
+        value = getattr(args, key)
+        if value is not None:
+            params[key] = value
+    if args.prompts:
+        params["prompt_overrides"] = {**params.get("prompt_overrides", {}), **_read_prompts(args.prompts)}
+
+    return _start_run(api, params, args)
+
+
+def _read_prompts(specs: List[str]) -> Dict[str, str]:
+    """NAME=FILE pairs into template overrides"""
+    overrides = {}
+    for spec in specs:
+        name, sep, path = spec.partition("=")
+        if not sep:
+            raise SystemExit(f"Expected NAME=FILE, got '{spec}'")
+        try:
+            overrides[name] = Path(path).read_text(encoding="utf-8")
+        except OSError as e:
+            raise SystemExit(f"Can't read prompt template {path}: {e}")
+    return overrides
+
+
+def _start_run(api: ChefBenchClient, params: Dict[str, Any], args) -> Any:
+    """Start a scenario and, with --wait, follow it to completion"""
+    started = api.execute_scenario(**params)
+    evaluation_id = started["evaluation_id"]
+
+    if not args.wait:
+        if args.json:
+            return started
+        print(f"Started {evaluation_id} (seed {started.get('seed')})")
+        return None
+
+    if args.live and not args.json:
+        follow(evaluation_id, api.stream_run_progress(evaluation_id), theme=args.theme)
+        status = api.get_scenario_status(evaluation_id)
+    else:
+        if not args.json:
+            print(f"Running {evaluation_id} (seed {started.get('seed')})...")
+        status = api.wait_for_scenario(
+            evaluation_id,
+            poll_interval=args.poll_interval,
+            on_poll=_critical_event_alerts(api, evaluation_id, args.notify) if args.notify else None
+        )
+    if args.notify:
+        notify(f"Escoffier run {status['status']}", f"{evaluation_id[:8]} ({params.get('scenario_type', 'standard')})", args.notify)
+    if status["status"] != "completed":
+        raise ChefBenchClientError(f"Evaluation {evaluation_id} {status['status']}")
+
+    results = api.get_scenario_results(evaluation_id)
+    if args.json:
+        return {"evaluation_id": evaluation_id, **results}
+    _print_run_summary(evaluation_id, results)
+
+
+def _ask(prompt: str, default: Any = None, parse=str, choices: Optional[List[str]] = None) -> Any:
+    """Prompt until the answer validates; an empty answer takes the default"""
+    suffix = f" [{default}]" if default is not None else ""
+    while True:
+        try:
+            answer = input(f"{prompt}{suffix}: ").strip()
+        except (EOFError, KeyboardInterrupt):
+            print()
+            raise SystemExit("Cancelled")
+        if not answer and default is not None:
+            answer = str(default)
+        if choices:
+            if answer.isdigit() and 1 <= int(answer) <= len(choices):
+                return choices[int(answer) - 1]
+            if answer in choices:
+                return answer
+            print(f"  choose 1-{len(choices)} or one of: {', '.join(choices)}")
+            continue
+        try:
+            return parse(answer)
+        except ValueError as e:
+            print(f"  invalid value: {e}")
+
+
+def _whole_number(value: str) -> int:
+    if not value.isdigit():
+        raise ValueError(f"'{value}' is not a whole number")
+    return int(value)
+
+
+def _bounded_int(bounds: Dict[str, int]):
+    def parse(value: str) -> int:
+        number = _whole_number(value)
+        if not bounds["min"] <= number <= bounds["max"]:
+            raise ValueError(f"must be between {bounds['min']} and {bounds['max']}")
+        return number
+    return parse
+
+
+ORDER_TYPES = {"dine-in": "For a table that's dining", "takeaway": "Not for a table"}
+DINING_STATUSES = ("seated", "fired", "dessert")
+COURSES = ("starter", "main", "dessert")
+ORDER_PRIORITIES = ("normal", "expedite", "reassign")
+MENU_PAGE_SIZE = 10
+
+
+def _note(value: str) -> str:
+    if len(value) > 200:
+        raise ValueError("keep notes to 200 characters")
+    return value
+
+
+def _restrictions(value: str) -> List[str]:
+    return [r.strip() for r in value.split(",") if r.strip()]
+
+
+def _wizard_order_type(api: ChefBenchClient, order: Dict[str, Any]):
+    """Ask whether the order is for a dining table or to take away, and which table"""
+    print("Order type")
+    for i, (name, description) in enumerate(ORDER_TYPES.items(), 1):
+        print(f"  {i}. {name:<10} {description}")
+    while True:
+        kind = _ask("Choice", "dine-in" if order["table"] is not None else "takeaway", choices=list(ORDER_TYPES))
+        if kind == "takeaway":
+            order["table"] = None
+            return
+        dining = [t for t in api.list_tables()["tables"] if t["status"] in DINING_STATUSES]
+        if dining:
+            break
+        print("  no table is dining; seat a party with 'tables seat' first, or take the order away")
+    for table in dining:
+        print(f"  table {table['number']}: {table['party_size']} guests, {table['status']}")
+    numbers = [str(t["number"]) for t in dining]
+    current = str(order["table"]) if str(order["table"]) in numbers else numbers[0]
+    order["table"] = int(_ask("Table", current, choices=numbers))
+
+
+def _wizard_item(menu_item: Dict[str, Any]) -> Dict[str, Any]:
+    """Ask how many of a menu item, for which course, and any notes for the cook"""
+    print(menu_item["dish"])
+    item = {
+        "recipe_id": menu_item["recipe_id"],
+        "dish": menu_item["dish"],
+        "quantity": _ask("Quantity (1-20)", 1, _bounded_int({"min": 1, "max": 20})),
+        "course": _ask("Course", "main", choices=list(COURSES)),
+        "notes": []
+    }
+    while len(item["notes"]) < 10:
+        note = _ask("Note for the cook, e.g. no onions (blank when done)", parse=_note)
+        if not note:
+            break
+        item["notes"].append(note)
+    return item
+
+
+def _wizard_menu(api: ChefBenchClient, order: Dict[str, Any], view: Dict[str, Any]) -> bool:
+    """Browse the menu a page at a time, adding items to the order; False if the user quit"""
+    while True:
+        page = api.get_menu(
+            cuisine=view["cuisine"], available=True if view["available_only"] else None,
+            limit=MENU_PAGE_SIZE, offset=view["offset"]
+        )
+        print()
+        _print_table([
+            {
+                "id": m["recipe_id"],
+                "dish": m["dish"],
+                "ingredients": ", ".join(m["ingredients"]),
+                "available": "yes" if m["available"] else "86'd: " + "; ".join(m["eighty_sixed"])
+            }
+            for m in page["items"]
+        ], ["id", "dish", "ingredients", "available"])
+        shown = f"{page['offset'] + 1}-{page['offset'] + len(page['items'])}" if page["items"] else "0"
+        print(f"  {shown} of {page['total']}"
+              + (f" {view['cuisine']}" if view["cuisine"] else "")
+              + (" available" if view["available_only"] else "")
+              + f" items; {sum(i['quantity'] for i in order['items'])} portions on the order")
+
+        action, answer = WIZARD_MENU_KEYS.read()
+        if action == "quit":
+            return False
+        if action == "done":
+            if order["items"]:
+                return True
+            print("  add at least one item first")
+        elif action == "next":
+            if page["next_offset"] is None:
+                print("(last page)")
+            else:
+                view["offset"] = page["next_offset"]
+        elif action == "previous":
+            view["offset"] = max(0, view["offset"] - MENU_PAGE_SIZE)
+        elif action == "search":
+            view["cuisine"] = _ask("Cuisine (blank shows all)") or None
+            view["offset"] = 0
+        elif action == "available":
+            view["available_only"] = not view["available_only"]
+            view["offset"] = 0
+        elif action == "add":
+            menu_item = next((m for m in page["items"] if m["recipe_id"] == int(answer)), None)
+            if menu_item is None:
+                print(f"  recipe {answer} isn't on this page")
+            elif not menu_item["available"]:
+                print(f"  {menu_item['dish']} is 86'd: {'; '.join(menu_item['eighty_sixed'])}")
+            else:
+                order["items"].append(_wizard_item(menu_item))
+
+
+def _print_order_refusal(error: APIError):
+    """Show why the server refused an order: field by field, by line of its plan, or how long to wait"""
+    if isinstance(error.details, list) and error.details:
+        for detail in error.details:
+            print(f"  error: {detail['field'] + ': ' if detail.get('field') else ''}{detail['message']}")
+    elif isinstance(error.details, dict) and "items" in error.details:
+        _print_order_plan(error.details)
+    elif isinstance(error, ThrottledError) and error.wait_estimate is not None:
+        print(f"  the kitchen is too busy to take it; try again in ~{error.wait_estimate:.0f}s")
+    else:
+        print(f"  error: {error.detail}")
+
+
+def cmd_bench_wizard(api: ChefBenchClient, args) -> Any:
+    order: Dict[str, Any] = {"items": [], "table": None, "dietary_restrictions": [], "priority": "normal"}
+    _wizard_order_type(api, order)
+    order["dietary_restrictions"] = _ask("Guest restrictions, e.g. peanuts, vegan (blank for none)", parse=_restrictions)
+    view = {"cuisine": None, "available_only": False, "offset": 0}
+    if not _wizard_menu(api, order, view):
+        raise SystemExit("Cancelled, no order submitted")
+    order["priority"] = _ask("Priority", order["priority"], choices=list(ORDER_PRIORITIES))
+
+    # Review the kitchen's plan, letting the user fix the order before anything is submitted
+    while True:
+        print("\nReview")
+        print(f"  {'table ' + str(order['table']) if order['table'] is not None else 'takeaway'}, "
+              f"{order['priority']} priority"
+              + (f", restrictions: {', '.join(order['dietary_restrictions'])}" if order["dietary_restrictions"] else ""))
+        for line, item in enumerate(order["items"]):
+            notes = f" ({'; '.join(item['notes'])})" if item["notes"] else ""
+            print(f"  {line}. {item['quantity']}x {item['dish']}, {item['course']}{notes}")
+        plan = None
+        try:
+            plan = api.submit_order(**order, dry_run=True)
+        except APIError as e:
+            _print_order_refusal(e)
+        if plan is not None:
+            _print_order_plan(plan)
+            if not plan["admission"]["admitted"]:
+                print(f"  warning: the kitchen is too busy to take it now: {'; '.join(plan['admission']['reasons'])}")
+            if plan["evaluation_id"] is None:
+                print("  warning: no run is executing, so the order can't be submitted yet")
+
+        action, _ = WIZARD_REVIEW_KEYS.read()
+        if action == "quit":
+            raise SystemExit("Cancelled, no order submitted")
+        if action == "submit":
+            if plan is None or not plan["feasible"]:
+                print("  fix the errors above before submitting")
+                continue
+            try:
+                data = api.submit_order(**order)
+            except APIError as e:
+                _print_order_refusal(e)
+                continue
+            if args.json:
+                return data
+            print(f"Queued {', '.join(data['task_ids'])} in {data['evaluation_id']}")
+            return None
+        if action == "menu" and not _wizard_menu(api, order, view):
+            raise SystemExit("Cancelled, no order submitted")
+        elif action == "remove":
+            line = _ask("Line", choices=[str(i) for i in range(len(order["items"]))])
+            order["items"].pop(int(line))
+            if not order["items"] and not _wizard_menu(api, order, view):
+                raise SystemExit("Cancelled, no order submitted")
+        elif action == "edit":
+            field = _ask("Field", choices=["order type", "restrictions", "priority"])
+            if field == "order type":
+                _wizard_order_type(api, order)
+            elif field == "restrictions":
+                order["dietary_restrictions"] = _ask("Guest restrictions (blank for none)", parse=_restrictions)
+            else:
+                order["priority"] = _ask("Priority", order["priority"], choices=list(ORDER_PRIORITIES))
+
+
+def _print_run_summary(evaluation_id: str, results: Dict[str, Any]):
+    team = results.get("agent_metrics", {}).get("team", {})
+    print(f"Evaluation {evaluation_id}")
+    print(f"  tasks completed: {results.get('tasks_completed')}/{results.get('total_tasks')}")
+    print(f"  duration: {results.get('duration', 0):.1f}s")
+    by_role = (results.get("model_routing") or {}).get("by_role")
+    if by_role and len({str(m) for m in by_role.values()}) > 1:
+        print("  models: " + ", ".join(
+            f"{role} {model if isinstance(model, str) else '/'.join(model)}" for role, model in by_role.items()
+        ))
+    for key in ("overall_success_rate", "average_quality", "hierarchy_compliance", "role_coherence", "memory_consistency"):
+        if key in team:
+            print(f"  {key}: {_format_float(team[key])}")
+    if "invalid_action_rate" in team:
+        print(f"  invalid actions: {team['invalid_actions']} ({_format_float(team['invalid_action_rate'])} per task)")
+    if team.get("restriction_violations"):
+        print(f"  dietary restriction violations: {team['restriction_violations']}")
+    if "reliability" in team:
+        print(f"  reliability: {_format_float(team['reliability'])} ({team['task_errors']} task errors)")
+    handoffs = results.get("agent_metrics", {}).get("handoffs")
+    if handoffs and handoffs["total"]:
+        print(f"  coordination: {_format_float(handoffs['coordination_score'])} ({handoffs['rerouted']} re-routed, "
+              f"{handoffs['unrouted']} unrouted hand-offs)")
+    pacing = results.get("agent_metrics", {}).get("pacing")
+    if pacing:
+        print(f"  pacing ({pacing['decision']}): average ticket {pacing['baseline_ticket_seconds']:.0f}s -> "
+              f"{pacing['planned_ticket_seconds']:.0f}s, efficiency {_format_float(pacing['efficiency'])}")
+    if results.get("scores"):
+        scores = results["scores"]
+        verdict = ""
+        if (scores.get("scoring") or {}).get("thresholds"):
+            verdict = ", passed" if scores["passed"] else ", failed"
+        print(f"  score ({scores['profile']}): {_format_float(scores['score'])}{verdict}")
+        for failed in scores.get("failed_thresholds") or []:
+            print(f"    below threshold: {failed['metric']} {_format_float(failed['actual'])} < {failed['minimum']}")
+    judged = (results.get("judgement") or {}).get("team")
+    if judged:
+        print("  judge: " + ", ".join(f"{name} {_format_float(score)}" for name, score in judged.items()))
+    assertions = results.get("assertions")
+    if assertions:
+        print(f"  assertions: {assertions['total'] - assertions['failed']}/{assertions['total']} passed")
+        for check in assertions["checks"]:
+            if not check["passed"]:
+                print(f"    failed: {check['metric']} {check['op']} {check['expected']} (got {_format_float(check['actual'])})")
+
+
+def _critical_event_alerts(api: ChefBenchClient, evaluation_id: str, method: str):
+    """Poll callback that notifies once per new critical event of a run"""
+    last_id = 0
+
+    def check(status: Dict[str, Any]):
+        nonlocal last_id
+        events = api.list_events(run_id=evaluation_id, after_id=last_id)["events"]
+        for event in events:
+            last_id = event["event_id"]
+            if event["event_type"] in CRITICAL_EVENTS:
+                payload = event["payload"]
+                detail = payload.get("equipment") or payload.get("reason") or payload.get("error", "")
+                notify(f"Escoffier: {event['event_type'].replace('_', ' ')}", f"{evaluation_id[:8]} {detail}", method)
+
+    return check
+
+
+def cmd_bench_compare(api: ChefBenchClient, args) -> Any:
+    scenario = _load_scenario_file(args.scenario_file) if args.scenario_file else None
+    if scenario is not None:
+        unknown = set(scenario) - SCENARIO_FIELDS
+        if unknown:
+            raise SystemExit(f"Unknown scenario fields: {', '.join(sorted(unknown))}")
+
+    started = api.start_model_comparison(
+        args.models,
+        scenario=scenario,
+        evaluation_id=args.evaluation_id,
+        team_size=args.size,
+        roles=args.roles.split(",") if args.roles else None,
+        max_parallel=args.parallel
+    )
+    comparison_id = started["comparison_id"]
+    if not args.wait:
+        if args.json:
+            return started
+        print(f"Started comparison {comparison_id} (seed {started['seed']})")
+        return None
+
+    if not args.json:
+        print(f"Comparing {len(args.models)} models (seed {started['seed']}), running up to {args.parallel} at once...")
+    comparison = api.wait_for_model_comparison(comparison_id, poll_interval=args.poll_interval)
+    if args.json:
+        return comparison
+
+    by_model = {r["model"]: r for r in comparison["runs"]}
+    rows = [
+        {
+            "rank": entry["rank"],
+            "model": entry["model"],
+            "score": _format_float(entry["score"]),
+            "success": _format_float(by_model[entry["model"]]["success_rate"]),
+            "quality": _format_float(by_model[entry["model"]]["average_quality"]),
+            "labor_cost": f"{by_model[entry['model']]['labor_cost']:.2f}",
+            "invalid": _format_float(by_model[entry["model"]].get("invalid_action_rate", 0.0)),
+            "tokens": (by_model[entry["model"]].get("usage") or {}).get("total_tokens", 0),
+            "llm_cost": f"{(by_model[entry['model']].get('usage') or {}).get('cost', 0):.4f}"
+        }
+        for entry in comparison["ranking"]
+    ]
+    _print_table(rows, ["rank", "model", "score", "success", "quality", "labor_cost", "invalid", "tokens", "llm_cost"])
+    for run in comparison["runs"]:
+        if run["status"] == "failed":
+            print(f"  {run['model']} failed: {run.get('error')}")
+
+
+def cmd_bench_ab(api: ChefBenchClient, args) -> Any:
+    scenario = _load_scenario_file(args.scenario_file) if args.scenario_file else None
+    if scenario is not None:
+        unknown = set(scenario) - SCENARIO_FIELDS
+        if unknown:
+            raise SystemExit(f"Unknown scenario fields: {', '.join(sorted(unknown))}")
+
+    started = api.start_prompt_experiment(
+        _read_prompts(args.variant_b),
+        variant_a=_read_prompts(args.variant_a or []),
+        scenario=scenario,
+        name_a=args.name_a,
+        name_b=args.name_b,
+        runs=args.runs,
+        primary_metric=args.metric,
+        alpha=args.alpha
+    )
+    experiment_id = started["experiment_id"]
+    if not args.wait:
+        if args.json:
+            return started
+        print(f"Started prompt experiment {experiment_id} (seed {started['seed']})")
+        return None
+
+    if not args.json:
+        print(f"Running {args.name_a} and {args.name_b} on {args.runs} seeds each (seed {started['seed']})...")
+    experiment = api.wait_for_prompt_experiment(experiment_id, poll_interval=args.poll_interval)
+    if args.json:
+        return experiment
+    report = experiment.get("report")
+    if not report:
+        raise ChefBenchClientError(f"Experiment {experiment_id} {experiment['status']}: {experiment.get('error')}")
+
+    rows = [
+        {
+            "metric": metric,
+            args.name_a: _format_float(c["mean"][args.name_a]),
+            args.name_b: _format_float(c["mean"][args.name_b]),
+            "difference": _format_float(c["mean_difference"]),
+            "p_value": f"{c['p_value']:.3f}",
+            "better": (c["better"] or "-") + (" *" if c["significant"] else "")
+        }
+        for metric, c in report["comparisons"].items()
+    ]
+    _print_table(rows, ["metric", args.name_a, args.name_b, "difference", "p_value", "better"])
+    print(f"* significant at {report['alpha']}")
+    print(report["conclusion"])
+
+
+def cmd_bench_list(api: ChefBenchClient, args) -> Any:
+    def fetch(offset: int) -> Dict[str, Any]:
+        return api.list_scenarios(
+            status=args.status, scenario_type=args.scenario_type, started_after=args.since,
+            sort=args.sort, limit=args.limit, offset=offset
+        )
+
+    if args.json:
+        return fetch(args.offset)
+    _print_pages(
+        fetch,
+        lambda data: [{**run, "status": args.theme.status(run["status"])} for run in data["evaluations"]],
+        ["evaluation_id", "status", "scenario_type", "num_tasks", "started_at", "seed"],
+        args.offset
+    )
+
+
+def cmd_bench_scenarios(api: ChefBenchClient, args) -> Any:
+    if args.reload:
+        api.reload_scenario_bundles()
+    if args.name:
+        data = api.get_scenario_bundle(args.name)
+        if args.json:
+            return data
+        print(f"{data['name']} (v{data['version']}): {data['description']}")
+        print(f"  from {data['path']}")
+        print("  tasks: " + ", ".join(f"{t['type'].lower()} x{t['weight']}" for t in data["tasks"]))
+        for key, value in data["defaults"].items():
+            print(f"  {key}: {value}")
+        if data["seed"]:
+            print("  seed: " + ", ".join(step["seeder"] for step in data["seed"]))
+        for a in data["expect"]:
+            print(f"  expect {a['metric']} {a['op']} {a['value']}" + (f"  ({a['description']})" if a["description"] else ""))
+        return None
+
+    data = api.list_scenario_bundles()
+    if args.json:
+        return data
+    rows = [
+        {"name": b["name"], "tasks": sum(t["weight"] for t in b["tasks"]), "assertions": len(b["expect"]),
+         "description": b["description"]}
+        for b in data["bundles"]
+    ]
+    if rows:
+        _print_table(rows, ["name", "tasks", "assertions", "description"])
+    else:
+        print(f"No scenario bundles in {data['root']}")
+    for name, error in data["errors"].items():
+        print(f"{args.theme.status('failed', name)}: {error}")
+
+
+def cmd_bench_golden(api: ChefBenchClient, args) -> Any:
+    from kitchen.golden import GOLDEN_DIR, GoldenRun
+
+    golden = GoldenRun.from_dict(api.get_golden_run(args.evaluation_id, args.name))
+    path = golden.save(args.output or os.path.join(GOLDEN_DIR, f"{golden.name}.json"))
+    if args.json:
+        return {"name": golden.name, "path": str(path), "metrics": golden.metrics}
+    print(f"Recorded golden run '{golden.name}' to {path}")
+    print(f"  {len(golden.tasks)} tasks, {len(golden.team)} agents, {len(golden.responses)} cached responses, "
+          f"{len(golden.metrics)} metrics")
+
+
+def cmd_bench_verify(api: Optional[ChefBenchClient], args) -> Any:
+    import asyncio
+    from kitchen.golden import GoldenRun
+
+    try:
+        goldens = [GoldenRun.load(path) for path in args.golden]
+    except OSError as e:
+        raise SystemExit(f"error: {e}")
+    reports = [asyncio.run(golden.verify(args.tolerance)) for golden in goldens]
+    if args.json:
+        _print_json(reports)
+    else:
+        for report in reports:
+            responses = report["responses"]
+            print(f"{args.theme.status('completed' if report['passed'] else 'failed', report['name'])}: "
+                  f"{report['total'] - report['failed']}/{report['total']} metrics within tolerance "
+                  f"({responses['hits']} cached responses, {responses['drift']} drifted prompts, "
+                  f"{responses['misses']} misses)")
+            rows = [
+                {**c, "actual": _format_float(c["actual"]), "expected": _format_float(c["expected"]),
+                 "delta": _format_float(c["delta"])}
+                for c in report["checks"] if args.verbose or not c["passed"]
+            ]
+            if rows:
+                _print_table(rows, ["metric", "expected", "actual", "delta", "tolerance"])
+    if not all(r["passed"] for r in reports):
+        raise SystemExit(1)
+
+
+def cmd_bench_status(api: ChefBenchClient, args) -> Any:
+    data = api.get_scenario_status(args.evaluation_id)
+    if args.json:
+        return data
+    print(f"{data['evaluation_id']}: {args.theme.status(data['status'])} (started {data['started_at']})")
+    if data.get("eta_remaining_seconds") is not None:
+        print(f"  ETA: ~{data['eta_remaining_seconds']:.0f}s remaining "
+              f"(predicted {data['eta']['predicted_seconds']:.0f}s total)")
+    customers = data.get("customers")
+    if customers:
+        print(f"  Customers: {customers['orders']} orders, {customers['covers']} covers on a "
+              f"{customers['demand']['day']}, busiest at {customers['busiest_hour']}:00 "
+              f"({customers['special_requests']} special requests)")
+
+
+def cmd_bench_results(api: ChefBenchClient, args) -> Any:
+    data = api.get_scenario_results(args.evaluation_id)
+    if args.json:
+        return data
+    _print_run_summary(args.evaluation_id, data)
+
+
+def cmd_bench_watch(api: ChefBenchClient, args) -> Any:
+    if args.json:
+        # One JSON object per line, as the frames arrive
+        for frame in api.stream_run_progress(args.evaluation_id):
+            print(json.dumps(frame, default=str), flush=True)
+        return None
+    status = follow(args.evaluation_id, api.stream_run_progress(args.evaluation_id), theme=args.theme)
+    if status not in (None, "completed"):
+        raise SystemExit(1)
+
+
+def cmd_bench_chaos(api: ChefBenchClient, args) -> Any:
+    if args.action is None:
+        data = api.get_chaos()
+        if args.json:
+            return data
+        _print_table([{"action": a, "description": d} for a, d in data["actions"].items()], ["action", "description"])
+        for injection in data["injections"]:
+            print(f"  task {injection['at_task']}: {injection['action']} {injection['params']}")
+        return None
+
+    params = {
+        "agent": args.agent,
+        "equipment": args.equipment,
+        "count": args.count,
+        "ingredients": args.ingredients,
+        "delay_seconds": args.delay,
+        "tasks": args.tasks,
+    }
+    data = api.inject_chaos(args.action, **{k: v for k, v in params.items() if v is not None})
+    if args.json:
+        return data
+    print(f"{data['evaluation_id']}: {data['action']} injected after task {data['at_task']}")
+
+
+def _print_order_plan(plan: Dict[str, Any]):
+    ready = plan["ready_in_seconds"]
+    print(f"Order {'can' if plan['feasible'] else 'cannot'} be cooked"
+          + (f", ready in {ready:.0f}s ({plan['queued_seconds']:.0f}s of work ahead)" if ready is not None else ""))
+    if plan.get("complexity"):
+        print(f"  complexity: {plan['complexity']['level']} ({plan['complexity']['score']:.2f})")
+    for error in plan["errors"]:
+        print(f"  error: {error}")
+    for item in plan["items"]:
+        dish = item["dish"] or ", ".join(item["ingredients"]) or item["task_type"]
+        where = f" at {item['station']} by {item['agent']}" if item["agent"] else ""
+        print(f"  {item['quantity']}x {dish} ({item['task_type']}, {item.get('course', 'main')}){where}")
+        for note in item.get("notes") or []:
+            print(f"    note: {note}")
+        for error in item["errors"]:
+            print(f"    error: {error}")
+        for warning in item["warnings"]:
+            print(f"    warning: {warning}")
+
+
+def cmd_bench_submit(api: ChefBenchClient, args) -> Any:
+    item = {
+        "task_type": args.type,
+        "recipe_id": args.recipe,
+        "dish": args.dish,
+        "ingredients": args.ingredients,
+        "quantity": args.quantity,
+        "course": args.course,
+        "notes": args.notes,
+    }
+    order = {
+        "items": [{k: v for k, v in item.items() if v is not None}],
+        "table": args.table,
+        "dietary_restrictions": args.dietary_restrictions,
+        "time_limit": args.time_limit,
+        "priority": args.priority,
+    }
+    # Always preview first, so an order that can't be cooked is explained rather than just refused
+    plan = api.submit_order(**order, dry_run=True)
+    if args.dry_run or not plan["feasible"]:
+        if args.json:
+            return plan
+        _print_order_plan(plan)
+        if not plan["feasible"]:
+            raise SystemExit(1)
+        return None
+    data = api.submit_order(**order)
+    if args.json:
+        return data
+    _print_order_plan(data)
+    print(f"Queued {', '.join(data['task_ids'])} in {data['evaluation_id']}")
+
+
+def _read_orders(path: str) -> List[Dict[str, Any]]:
+    """Orders from a JSON array, or one JSON object per line; - reads stdin"""
+    text = sys.stdin.read() if path == "-" else Path(path).read_text()
+    try:
+        orders = json.loads(text)
+    except ValueError:
+        orders = [json.loads(line) for line in text.splitlines() if line.strip()]
+    if isinstance(orders, dict):
+        orders = [orders]  # a file of one line
+    if not isinstance(orders, list):
+        raise SystemExit(f"{path}: expected a JSON array of orders or one order per line")
+    return orders
+
+
+def _print_batch(data: Dict[str, Any]):
+    counts = ", ".join(f"{n} {status}" for status, n in sorted(data["counts"].items()))
+    print(f"Batch {data['batch_id']} in {data['evaluation_id']}: {counts}")
+    _print_table([
+        {
+            "index": o["index"],
+            "arrive_at": f"{o['arrive_at']:.0f}s",
+            "table": o["table"] if o["table"] is not None else "-",
+            "status": o["status"],
+            "tasks": ", ".join(o["task_ids"]) or "; ".join(o["errors"]) or "-"
+        }
+        for o in data["orders"]
+    ], ["index", "arrive_at", "table", "status", "tasks"])
+
+
+def cmd_bench_bulk(api: ChefBenchClient, args) -> Any:
+    data = api.submit_orders_bulk(_read_orders(args.file), dry_run=args.dry_run)
+    if args.json:
+        return data
+    if args.dry_run:
+        for order in data["orders"]:
+            print(f"Order {order['index']}, arriving at {order['arrive_at']:.0f}s:")
+            _print_order_plan(order)
+        return None
+    _print_batch(data)
+
+
+def cmd_bench_batch(api: ChefBenchClient, args) -> Any:
+    data = api.get_order_batch(args.batch_id)
+    if args.json:
+        return data
+    _print_batch(data)
+
+
+def cmd_bench_modify(api: ChefBenchClient, args) -> Any:
+    add_items = None
+    if args.recipe is not None or args.dish or args.ingredients:
+        item = {
+            "task_type": args.type,
+            "recipe_id": args.recipe,
+            "dish": args.dish,
+            "ingredients": args.ingredients,
+            "quantity": args.quantity,
+        }
+        add_items = [{k: v for k, v in item.items() if v is not None}]
+    quantities = {}
+    for setting in args.quantities or []:
+        line, _, quantity = setting.partition("=")
+        if not (line.isdigit() and quantity.isdigit()):
+            raise SystemExit(f"--set takes LINE=QUANTITY, not {setting!r}")
+        quantities[int(line)] = int(quantity)
+    data = api.modify_order(
+        args.task_id,
+        add_items=add_items,
+        remove_items=args.remove,
+        quantities=quantities or None,
+        void=args.void,
+        note=args.note,
+        priority=args.priority,
+        reason=args.reason
+    )
+    if args.json:
+        return data
+    for change in data["changes"]:
+        detail = change.get("modification") or change.get("priority") or change.get("reason") or ""
+        print(f"{change['task_id']}: {change['change'].replace('_', ' ')}" + (f" ({detail})" if detail else ""))
+    complexity = data["complexity"]
+    print(f"Ticket {data['ticket']}" + (f", {complexity['level']} ({complexity['score']:.2f})" if complexity else ""))
+    for line in data["lines"]:
+        dish = line["dish"] or ", ".join(line["ingredients"]) or line["task_type"]
+        states = ", ".join(f"{p['task_id']} {p['state']}" for p in line["portions"])
+        print(f"  {line['line']}: {line['quantity']}x {dish} [{states}]")
+
+
+def cmd_bench_cancel(api: ChefBenchClient, args) -> Any:
+    data = api.cancel_order(args.task_id, args.reason)
+    if args.json:
+        return data
+    if data["status"] == "revoking":
+        print(f"{data['task_id']}: {data['agent_name']} stops at the next safe point")
+    else:
+        print(f"{data['task_id']}: cancelled before {data['agent_name']} started it")
+
+
+def _menu_flags(item: Dict[str, Any]) -> str:
+    return ", ".join(flag for flag in ("specialty", "seasonal") if item.get(flag))
+
+
+def cmd_bench_menu(api: ChefBenchClient, args) -> Any:
+    if args.plan:
+        data = api.plan_menu([
+            {"recipe_id": recipe_id, "specialty": recipe_id in args.specialty, "seasonal": recipe_id in args.seasonal}
+            for recipe_id in args.plan
+        ])
+        if args.json:
+            return data
+        print(f"Menu planned with {data['count']} items")
+        _print_table([{**item, "flags": _menu_flags(item)} for item in data["items"]], ["recipe_id", "flags"])
+        return None
+    if args.specialty or args.seasonal or args.plain or args.price:
+        prices = {int(recipe_id): price for recipe_id, price in args.price}
+        updated = []
+        for recipe_id in dict.fromkeys(args.specialty + args.seasonal + args.plain + list(prices)):
+            updated.append(api.update_menu_item(
+                recipe_id,
+                specialty=True if recipe_id in args.specialty else (False if recipe_id in args.plain else None),
+                seasonal=True if recipe_id in args.seasonal else (False if recipe_id in args.plain else None),
+                price=prices.get(recipe_id)
+            ))
+        if args.json:
+            return {"items": updated}
+        for item in updated:
+            print(f"{item['dish']}: {_menu_flags(item) or 'plain'}, ${item['price']:.2f}")
+        return None
+    if args.clear_plan:
+        data = api.clear_menu()
+        if args.json:
+            return data
+        print(f"Menu plan dropped; all {data['count']} loaded recipes are on")
+        return None
+    if args.specialties:
+        data = api.get_specialties()
+        if args.json:
+            return data
+        print(f"{data['count']} specialties")
+        _print_table([
+            {**item, "status": "available" if item["available"] else "86: " + "; ".join(item["eighty_sixed"])}
+            for item in data["items"]
+        ], ["recipe_id", "dish", "status"])
+        return None
+    if args.eighty_six is not None:
+        data = api.eighty_six(args.eighty_six, args.reason)
+        if args.json:
+            return data
+        print(f"86'd {data['dish']}: {'; '.join(data['reasons'])}"
+              + (" (the team has been told)" if data["announced"] else ""))
+        return None
+    if args.restore is not None:
+        data = api.restore_menu_item(args.restore)
+        if args.json:
+            return data
+        print(f"{data['dish']} is back on" if data["available"]
+              else f"{data['dish']} is still 86'd: {'; '.join(data['eighty_sixed'])}")
+        return None
+    if args.eighty_sixed:
+        data = api.get_eighty_six_list()
+        if args.json:
+            return data
+        print(f"{data['count']} items 86'd")
+        _print_table([{**item, "reasons": "; ".join(item["reasons"])} for item in data["items"]],
+                     ["recipe_id", "dish", "reasons"])
+        return None
+    data = api.get_menu(cuisine=args.cuisine, limit=args.limit)
+    if args.json:
+        return data
+    _print_table([
+        {**item, "ingredients": ", ".join(item["ingredients"]), "flags": _menu_flags(item),
+         "status": "available" if item["available"] else "86: " + "; ".join(item["eighty_sixed"])}
+        for item in data["items"]
+    ], ["recipe_id", "dish", "ingredients", "flags", "price", "status"])
+    unplanned = "" if data.get("planned") else " (no menu planned, so every loaded recipe is on)"
+    print(f"{len(data['items'])} of {data['total']} items{unplanned}")
+
+
+def cmd_bench_prompts(api: ChefBenchClient, args) -> Any:
+    data = api.get_prompt_templates()
+    if args.name:
+        template = next((t for t in data["templates"] if t["name"] == args.name), None)
+        if template is None:
+            raise SystemExit(f"No prompt template '{args.name}'")
+        if args.json:
+            return template
+        print(template["text"])
+        return None
+    if args.json:
+        return data
+    print(f"Templates in {data['directory']}" + (" (hot reload)" if data["hot_reload"] else ""))
+    _print_table(data["templates"], ["name", "version", "source"])
+    for kind, names in data["variables"].items():
+        print(f"  {kind}: {', '.join('$' + n for n in names)}")
+
+
+def cmd_bench_escalation(api: ChefBenchClient, args) -> Any:
+    thresholds = {
+        "enabled": args.enabled,
+        "scan_interval": args.interval,
+        "expedite_after": args.expedite_after,
+        "reassign_after": args.reassign_after,
+    }
+    thresholds = {k: v for k, v in thresholds.items() if v is not None}
+    if thresholds:
+        api.configure_escalation(**thresholds)
+    data = api.get_escalation()
+    if args.json:
+        return data
+    t = data["thresholds"]
+    print(f"Escalation {'on' if t['enabled'] else 'off'}: scan every {t['scan_interval']:g}s, expedite after "
+          f"{t['expedite_after']:g}x and reassign after {t['reassign_after']:g}x a task's time limit")
+    if data["escalations"]:
+        _print_table(data["escalations"], ["simulated_time", "task_id", "level", "agent_name",
+                                           "reassigned_to", "waited_seconds", "time_limit"])
+
+
+def cmd_bench_admission(api: ChefBenchClient, args) -> Any:
+    limits = {
+        "enabled": args.enabled,
+        "max_active_orders": args.max_active_orders,
+        "max_station_load": args.max_station_load,
+        "max_wait_seconds": args.max_wait,
+    }
+    limits = {k: v for k, v in limits.items() if v is not None}
+    if limits:
+        api.configure_admission(**limits)
+    data = api.get_admission()
+    if args.json:
+        return data
+    p, decision = data["policy"], data["decision"]
+    print(f"Admission {'on' if p['enabled'] else 'off'}: up to {p['max_active_orders']} orders, "
+          f"{p['max_station_load']}x a station's slots and {p['max_wait_seconds']}s expected wait")
+    if decision["admitted"]:
+        print(f"Taking orders ({decision['active_orders']} in the kitchen)")
+    else:
+        wait = decision["wait_estimate"]
+        print(f"Refusing orders{f' for about {wait:.0f}s' if wait is not None else ''}: {'; '.join(decision['reasons'])}")
+    if data["throttled"]:
+        _print_table(data["throttled"], ["simulated_time", "portions", "wait_estimate", "reasons"])
+
+
+def cmd_bench_course(api: ChefBenchClient, args) -> Any:
+    call = api.hold_course if args.call == "hold" else api.fire_course
+    data = call(args.course, table=args.table, author=args.author)
+    if args.json:
+        return data
+    if not data["changed"]:
+        print(f"{data['hold'].capitalize()} {'already held' if args.call == 'hold' else 'not held'}")
+    else:
+        done = "held" if args.call == "hold" else "fired"
+        print(f"{data['call'].capitalize()} {data['hold']}: {len(data['task_ids'])} portions {done}")
+    _print_holds(data["holding"])
+
+
+def _print_holds(holding: List[Dict[str, Any]]):
+    if not holding:
+        print("Nothing held")
+        return
+    _print_table([
+        {**h, "table": h["table"] if h["table"] is not None else "all",
+         "fired_at": ", ".join(map(str, h["fired_at"])) or "-"}
+        for h in holding
+    ], ["hold", "course", "table", "fired_at"])
+
+
+def cmd_bench_holds(api: ChefBenchClient, args) -> Any:
+    data = api.get_holds()
+    if args.json:
+        return data
+    _print_holds(data["holding"])
+    if data["violations"]:
+        print("\nHeld portions fired anyway")
+        _print_table(data["violations"], ["simulated_time", "task_id", "agent_name", "hold"])
+
+
+def cmd_bench_handoffs(api: ChefBenchClient, args) -> Any:
+    settings = {
+        "enabled": args.enabled,
+        "capacity": args.capacity,
+        "min_skill": args.min_skill,
+        "ack_seconds": args.ack_seconds,
+    }
+    settings = {k: v for k, v in settings.items() if v is not None}
+    if settings:
+        api.configure_handoffs(**settings)
+    data = api.get_handoffs()
+    if args.json:
+        return data
+    s = data["settings"]
+    capacity = "no capacity limit" if s["capacity"] is None else f"capacity {s['capacity']} tasks"
+    print(f"Hand-offs {'on' if s['enabled'] else 'off'}: {capacity}, reject below skill {s['min_skill']:g}, "
+          f"{s['ack_seconds']:g}s per answer")
+    if data["total"]:
+        print(f"  {data['total']} hand-offs: {data['accepted_first']} accepted first time, {data['rerouted']} re-routed, "
+              f"{data['unrouted']} unrouted; coordination {_format_float(data['coordination_score'])}")
+        rows = [
+            {
+                **h,
+                "offers": ", ".join(o["recipient"] + ("" if o["status"] == "accepted" else f" ({o['reason']})")
+                                    for o in h["offers"])
+            }
+            for h in data["handoffs"]
+        ]
+        _print_table(rows, ["task_id", "task_type", "delegator", "assigned_to", "offers", "latency_seconds"])
+
+
+def cmd_bench_pause(api: ChefBenchClient, args) -> Any:
+    data = api.pause_run(args.evaluation_id)
+    if args.json:
+        return data
+    print(f"{args.evaluation_id}: paused before its next task")
+
+
+def cmd_bench_resume(api: ChefBenchClient, args) -> Any:
+    data = api.resume_run(args.evaluation_id)
+    if args.json:
+        return data
+    print(f"{args.evaluation_id}: resumed")
+
+
+def cmd_bench_budget(api: ChefBenchClient, args) -> Any:
+    if args.max_tokens is not None or args.max_cost is not None or args.warn_at is not None:
+        data = api.set_budget(args.evaluation_id, args.max_tokens, args.max_cost, args.warn_at)
+    else:
+        data = api.get_budget(args.evaluation_id)
+    if args.json:
+        return data
+    budget = data["budget"]
+    if not budget:
+        print(f"{args.evaluation_id}: no budget")
+        return
+    limits = []
+    if budget["max_tokens"] is not None:
+        limits.append(f"{budget['tokens']}/{budget['max_tokens']} tokens")
+    if budget["max_cost"] is not None:
+        limits.append(f"${budget['cost']:.4f}/${budget['max_cost']}")
+    print(f"{args.evaluation_id}: {', '.join(limits)} ({budget['used']:.0%} used) "
+          f"{args.theme.status(budget['state'])}")
+    if data.get("resumed"):
+        print("  resumed: the run fits its budget again")
+
+
+def cmd_bench_export(api: ChefBenchClient, args) -> Any:
+    """Download a run's tables and unpack them into a directory of their own"""
+    data = api.export_run(args.evaluation_id, args.format, timeout=args.timeout)
+    directory = Path(args.output_dir) / args.evaluation_id
+    if args.zip:
+        path = Path(args.output_dir) / f"{args.evaluation_id}-{args.format}.zip"
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_bytes(data)
+        files = [path]
+    else:
+        directory.mkdir(parents=True, exist_ok=True)
+        with zipfile.ZipFile(io.BytesIO(data)) as archive:
+            archive.extractall(directory)
+            files = [directory / name for name in archive.namelist()]
+    if args.json:
+        return {"evaluation_id": args.evaluation_id, "format": args.format, "files": [str(f) for f in files]}
+    for path in files:
+        print(f"{path}  ({path.stat().st_size} bytes)")
+    return None
+
+
+def cmd_bench_trends(api: ChefBenchClient, args) -> Any:
+    data = api.get_trends(
+        args.metric, args.group_by, args.interval, args.window, args.since, args.until, args.model, args.scenario,
+        timeout=args.timeout
+    )
+    if args.json:
+        return data
+    if not data["series"]:
+        print("No recorded runs match")
+        return
+    metrics = data["metrics"]
+    for series in data["series"]:
+        group = ", ".join(f"{k}={v}" for k, v in series["group"].items()) or "all runs"
+        change = ", ".join(
+            f"{metric} {value:+.3f}" for metric, value in series["change"].items() if value is not None
+        )
+        print(f"{group}: {series['runs']} runs, {series['first'][:10]} to {series['last'][:10]}"
+              + (f" ({change})" if change else ""))
+        rolling = {m: f"{m} ({data['window']})" for m in metrics}
+        rows = [
+            {
+                "period": point["period"],
+                "runs": point["runs"],
+                **{m: _format_float(point[m]) if point[m] is not None else "-" for m in metrics},
+                **{
+                    rolling[m]: _format_float(point[f"{m}_rolling"]) if point[f"{m}_rolling"] is not None else "-"
+                    for m in metrics
+                }
+            }
+            for point in series["points"]
+        ]
+        _print_table(rows, ["period", "runs", *metrics, *rolling.values()])
+        print()
+
+
+def cmd_bench_judge(api: ChefBenchClient, args) -> Any:
+    data = api.judge_scenario(args.evaluation_id, args.model, timeout=args.timeout)
+    if args.json:
+        return data
+    if data.get("error"):
+        raise SystemExit(f"Judging failed: {data['error']}")
+    print(f"{args.evaluation_id}: judged by {data['judge']['model']} "
+          f"({data['judge']['failures']} of {data['judge']['calls']} calls unusable)")
+    rows = [
+        {"agent": name, **{k: _format_float(v) for k, v in verdict.items() if k not in ("rationale", "transcripts")}}
+        for name, verdict in data["agents"].items()
+    ]
+    _print_table(rows, ["agent", *data["criteria"], "overall"])
+    for name, verdict in data["agents"].items():
+        if verdict.get("rationale"):
+            print(f"  {name}: {verdict['rationale']}")
+
+
+def cmd_bench_usage(api: ChefBenchClient, args) -> Any:
+    data = api.get_scenario_usage(args.evaluation_id)
+    if args.json:
+        return data
+    if not data["total"]:
+        print(f"{args.evaluation_id}: no LLM calls recorded")
+        return None
+
+    total = data["total"]
+    print(f"{args.evaluation_id}: {total['total_tokens']} tokens over {total['calls']} calls, "
+          f"est. ${total['cost']:.4f}")
+    rows = [
+        {"agent": name, **usage, "cost": f"{usage['cost']:.4f}"}
+        for name, usage in data["by_agent"].items()
+    ]
+    _print_table(rows, ["agent", "calls", "prompt_tokens", "completion_tokens", "latency_seconds", "cost"])
+
+
+def cmd_bench_report(api: ChefBenchClient, args) -> Any:
+    report = build_report(args.evaluation_id, api.get_scenario_results(args.evaluation_id))
+    if args.json:
+        return report
+    if args.file:
+        Path(args.file).write_text(render_report(report, args.output, args.theme) + "\n", encoding="utf-8")
+        print(f"Wrote {args.output} report to {args.file}")
+        return None
+    size = terminal_size(sys.stdout)
+    print(render_report(report, args.output, args.theme, size[0] if size else None))
+
+
+def cmd_bench_replay(api: ChefBenchClient, args) -> Any:
+    data = api.replay_scenario(args.evaluation_id, at=args.at)
+    if args.json:
+        return data
+
+    print(f"Run {data['run_id']} ({data['status']}), {data['events_applied']} events applied")
+    rows = [{"agent": name, **state} for name, state in data["agents"].items()]
+    _print_table(rows, ["agent", "tasks_completed", "tasks_failed", "last_task", "messages_sent"])
+
+
+ORDER_FILTERS = {"status": "status", "priority": "priority", "type": "task_type"}
+NOTE_SEVERITIES = ("info", "warning", "critical")
+# An order's way to the guests, and the events that move it along, as the server folds them
+ORDER_STAGES = ("received", "prep", "cook", "plate", "served")
+STAGE_EVENTS = {"order_placed", "task_assigned", "task_started", "task_executed", "order_served",
+                "order_cancelled", "task_revoked"}
+
+
+def _print_orders(data: Dict[str, Any], filters: Dict[str, Any], theme):
+    matched = f" matching '{filters['q']}'" if filters["q"] else ""
+    print(f"Orders of {data['evaluation_id'][:8]}: {data['total']}{matched}")
+    # Selected chips are bracketed; counts are of the search matches
+    for field in ("status", "priority", "task_type"):
+        chips = [
+            f"[{value} {count}]" if value in filters[field] else f"{value} {count}"
+            for value, count in data["facets"][field].items()
+        ]
+        print(f"  {field}: {'  '.join(chips) or '-'}")
+    _print_table([
+        {
+            **order,
+            "agent_name": order["agent_name"] or "-",
+            "status": theme.status(order["status"]),
+            "priority": "-" if order["priority"] == "normal" else theme.mark(order["priority"], "warning"),
+            "table": order["table"] if order["table"] is not None else "-",
+            "quality": _format_float(order["quality_score"]) if order["quality_score"] is not None else "-",
+            "notes": "; ".join(order["notes"]) or order["cancel_reason"] or "-",
+            "flags": _flags(order["annotations"], theme)
+        }
+        for order in data["orders"]
+    ], ["task_id", "task_type", "agent_name", "status", "priority", "table", "quality", "flags", "notes"])
+
+
+def _flags(annotations: List[Dict[str, Any]], theme) -> str:
+    """Count of an order's notes, marked with the worst severity among them"""
+    if not annotations:
+        return "-"
+    worst = max((a["severity"] for a in annotations), key=NOTE_SEVERITIES.index)
+    return theme.status(worst, str(len(annotations)))
+
+
+def _print_order(order: Dict[str, Any], theme):
+    print(f"Order {order['task_id']} ({order['task_type'] or '-'}): {theme.status(order['status'])}")
+    print(f"  agent: {order['agent_name'] or '-'}   priority: {order['priority']}   "
+          f"table: {order['table'] if order['table'] is not None else '-'}   "
+          f"complexity: {order.get('complexity') or '-'}   "
+          f"quality: {_format_float(order['quality_score']) if order['quality_score'] is not None else '-'}")
+    for note in order["notes"]:
+        print(f"  guest: {note}")
+    if order["cancel_reason"]:
+        print(f"  cancelled: {order['cancel_reason']}")
+    print(f"Notes ({len(order['annotations'])}):" if order["annotations"] else "No notes")
+    for note in order["annotations"]:
+        when = datetime.fromtimestamp(note["timestamp"]).strftime("%H:%M:%S")
+        print(f"  {when}  {theme.status(note['severity'])}  {note['author']} ({note['source']}): {note['reason']}")
+
+
+def cmd_bench_order(api: ChefBenchClient, args) -> Any:
+    data = api.get_order(args.evaluation_id, args.task_id)
+    if args.json:
+        return data
+    _print_order(data, args.theme)
+
+
+def _print_timeline(order: Dict[str, Any], theme):
+    """One line per stage: when the order reached it, how long after it was received, and how long it lasted"""
+    print(f"Order {order['task_id']} ({order['task_type'] or '-'}): {theme.status(order['status'])}")
+    reached = {entry["stage"]: entry for entry in order["timeline"]}
+    for stage in ORDER_STAGES:
+        entry = reached.get(stage)
+        if entry is None:
+            print(f"  {stage:<9} -")
+            continue
+        when = datetime.fromtimestamp(entry["at"]).strftime("%H:%M:%S")
+        lasted = entry["duration_seconds"]
+        lasted = f"{lasted:.1f}s" if lasted is not None else ("" if stage == "served" else "...")
+        elapsed = f"+{entry['elapsed_seconds']:.1f}s"
+        print(f"  {stage:<9} {when}  {elapsed:>9}  {lasted}".rstrip())
+    if order["cancel_reason"]:
+        print(f"  cancelled: {order['cancel_reason']}")
+
+
+def _order_finished(order: Dict[str, Any]) -> bool:
+    return order["status"] in ("failed", "cancelled") or any(e["stage"] == "served" for e in order["timeline"])
+
+
+def cmd_bench_timeline(api: ChefBenchClient, args) -> Any:
+    """Show an order's way from received to served; --follow redraws it from the event stream as it moves"""
+    data = api.get_order(args.evaluation_id, args.task_id)
+    if args.json and not args.follow:
+        return data
+
+    def show(order: Dict[str, Any]):
+        if args.json:
+            print(json.dumps(order, default=str), flush=True)
+        else:
+            _print_timeline(order, args.theme)
+
+    show(data)
+    if not args.follow or _order_finished(data):
+        return None
+    for frame in api.stream_events(args.evaluation_id):
+        event = frame.get("data") or {}
+        if event.get("task_id") != args.task_id or frame.get("event") not in STAGE_EVENTS:
+            continue
+        order = api.get_order(args.evaluation_id, args.task_id)
+        stages = [e["stage"] for e in order["timeline"]]
+        if stages == [e["stage"] for e in data["timeline"]] and order["status"] == data["status"]:
+            continue  # an event already shown, replayed from the start of the stream
+        data = order
+        if not args.json:
+            print()
+        show(data)
+        if _order_finished(data):
+            break
+    return None
+
+
+def cmd_bench_note(api: ChefBenchClient, args) -> Any:
+    data = api.add_order_note(args.task_id, args.author, args.reason, args.severity)
+    if args.json:
+        return data
+    print(f"{data['task_id']}: {args.theme.status(data['severity'])} noted by {data['author']}")
+
+
+def cmd_bench_orders(api: ChefBenchClient, args) -> Any:
+    """List a run's orders; --browse toggles search and filter chips interactively"""
+    filters = {
+        "q": args.search,
+        "status": args.status or [],
+        "priority": args.priority or [],
+        "task_type": args.task_type or []
+    }
+
+    def fetch(offset: int) -> Dict[str, Any]:
+        return api.list_orders(args.evaluation_id, **filters, sort=args.sort, limit=args.limit, offset=offset)
+
+    if args.json:
+        return fetch(args.offset)
+    offset = args.offset
+    while True:
+        data = fetch(offset)
+        _print_orders(data, filters, args.theme)
+        if not args.browse:
+            if data["next_offset"] is not None:
+                print(f"... {data['total'] - data['next_offset']} more (--offset {data['next_offset']}, or --browse)")
+            return None
+
+        action, _ = ORDERS_KEYS.read("\n")
+        if action == "quit":
+            return None
+        if action == "search":
+            try:
+                filters["q"] = input("Search (empty clears): ").strip() or None
+            except (EOFError, KeyboardInterrupt):
+                print()
+                return None
+            offset = 0
+        elif action in ORDER_FILTERS:
+            field = ORDER_FILTERS[action]
+            choices = sorted(set(data["facets"][field]) | set(filters[field]))
+            if not choices:
+                print(f"  no {field} to filter on")
+                continue
+            value = _ask(f"Toggle {field}", choices=choices)
+            if value in filters[field]:
+                filters[field].remove(value)
+            else:
+                filters[field].append(value)
+            offset = 0
+        elif action == "clear":
+            filters = {"q": None, "status": [], "priority": [], "task_type": []}
+            offset = 0
+        elif action == "open":
+            try:
+                task_id = input("Task id: ").strip()
+            except (EOFError, KeyboardInterrupt):
+                print()
+                return None
+            order = next((o for o in data["orders"] if o["task_id"] == task_id), None)
+            if order is None:
+                print(f"  no order '{task_id}' on this page")
+            else:
+                _print_order(order, args.theme)
+        elif action == "next":
+            if data["next_offset"] is None:
+                print("(last page)")
+            else:
+                offset = data["next_offset"]
+        elif action == "previous":
+            offset = max(0, offset - args.limit)
+
+
+def cmd_tables_list(api: ChefBenchClient, args) -> Any:
+    data = api.list_tables()
+    if args.json:
+        return data
+    _print_table(data["tables"], ["number", "capacity", "status", "party_size"])
+    if data["upcoming_reservations"]:
+        print("\nReservations:")
+        rows = [
+            {**r, "time": datetime.fromtimestamp(r["time"]).strftime("%Y-%m-%d %H:%M")}
+            for r in data["upcoming_reservations"]
+        ]
+        _print_table(rows, ["reservation_id", "name", "party_size", "time", "table"])
+    if data["pacing"]:
+        print("\nPacing:")
+        _print_table(data["pacing"], ["table", "status", "seated_minutes", "stage_minutes", "target_minutes", "pace"])
+
+
+def cmd_tables_add(api: ChefBenchClient, args) -> Any:
+    data = api.add_table(args.number, args.capacity)
+    if args.json:
+        return data
+    print(f"Table {data['number']} added, seats {data['capacity']}")
+
+
+def cmd_tables_reserve(api: ChefBenchClient, args) -> Any:
+    data = api.reserve_table(args.name, args.party_size, args.time)
+    if args.json:
+        return data
+    held = f", holding table {data['table']}" if data["table"] is not None else ""
+    print(f"Reservation {data['reservation_id']} for {data['name']} ({data['party_size']}){held}")
+
+
+def cmd_tables_cancel(api: ChefBenchClient, args) -> Any:
+    data = api.cancel_reservation(args.reservation_id)
+    if args.json:
+        return data
+    print(f"Reservation {data['reservation_id']} cancelled")
+
+
+def cmd_tables_seat(api: ChefBenchClient, args) -> Any:
+    if not args.reservation and not args.party_size:
+        raise SystemExit("Give --reservation or --party-size")
+    data = api.seat_party(args.reservation, args.party_size)
+    if args.json:
+        return data
+    print(f"Party of {data['party_size']} seated at table {data['number']}")
+
+
+def cmd_tables_status(api: ChefBenchClient, args) -> Any:
+    data = api.set_table_status(args.number, args.status)
+    if args.json:
+        return data
+    print(f"Table {data['number']}: {data['status']}")
+
+
+def cmd_servers_list(api: ChefBenchClient, args) -> Any:
+    data = api.list_servers()
+    if args.json:
+        return data
+    rows = [{**s, "tables": ", ".join(map(str, s["tables"])) or "all"} for s in data["servers"]]
+    _print_table(rows, ["name", "tables", "relayed", "delays_reported"])
+
+
+def cmd_servers_add(api: ChefBenchClient, args) -> Any:
+    data = api.add_server(args.name, args.tables)
+    if args.json:
+        return data
+    print(f"{data['name']} covers tables {', '.join(map(str, data['tables'])) or 'all'}")
+
+
+def cmd_servers_remove(api: ChefBenchClient, args) -> Any:
+    data = api.remove_server(args.name)
+    if args.json:
+        return data
+    print(f"{data['removed']} is off the floor")
+
+
+def _print_stations(data: Dict[str, Any], theme):
+    run = data["run_id"][:8] if data["run_id"] else "no run executing"
+    print(f"Kitchen stations ({run})")
+    rows = []
+    for station in data["stations"]:
+        items = station["equipment"]
+        rows.append({
+            "station": station["name"],
+            "role": station["role"],
+            "staff": station["staff_count"],
+            "orders": f"{station['in_progress']} in progress, {station['queued']} queued"
+                      + (f", {station['held']} held" if station.get("held") else ""),
+            "slots": f"{station['active_orders']}/{station['capacity']}"
+                     + (f" +{station['deferred']} deferred" if station["deferred"] else ""),
+            "load": f"{station['load']:.2f}",
+            "equipment": f"{len(items) - len(station['equipment_down'])}/{len(items)} up" if items else "-",
+            "down": theme.status("broken", ", ".join(station["equipment_down"])) if station["equipment_down"] else "-"
+        })
+    _print_table(rows, ["station", "role", "staff", "orders", "slots", "load", "equipment", "down"])
+    if not data["equipment_simulated"]:
+        print("(equipment simulation is off for this run)")
+
+
+def _print_station(data: Dict[str, Any], theme):
+    print(f"{data['name']} ({data['role']})  load {data['load']:.2f}  staff: {', '.join(data['staff']) or '-'}")
+    if data["equipment"]:
+        print("\nEquipment")
+        _print_table([
+            {
+                **item,
+                "status": theme.status(item["status"]),
+                "in_use_by": item["in_use_by"] or "-",
+                "temperature": "-" if item["temperature_status"] is None else theme.status(
+                    item["temperature_status"], f"{item['temperature']}C" if item["temperature"] is not None else None
+                )
+            }
+            for item in data["equipment"]
+        ], ["name", "status", "wear", "in_use_by", "temperature"])
+    print("\nOrders")
+    _print_table([
+        {
+            **order,
+            "state": theme.status(order["state"]),
+            "table": order["table"] if order["table"] is not None else "-",
+            "waited": f"{order['waited_seconds']:.0f}s" if order["waited_seconds"] is not None else "-"
+        }
+        for order in data["orders"]
+    ], ["task_id", "task_type", "agent_name", "state", "table", "waited"])
+
+
+def cmd_kitchen_stations(api: ChefBenchClient, args) -> Any:
+    """Show every station, or one with its orders; --watch refreshes until Ctrl-C"""
+    fetch = (lambda: api.get_station(args.name)) if args.name else api.list_stations
+    if args.json:
+        return fetch()
+    redraw = args.watch and sys.stdout.isatty()
+    try:
+        while True:
+            data = fetch()
+            if redraw:
+                print("\x1b[H\x1b[J", end="")
+            (_print_station if args.name else _print_stations)(data, args.theme)
+            if not args.watch:
+                return None
+            time.sleep(args.poll_interval)
+            if not redraw:
+                print()
+    except KeyboardInterrupt:
+        print()
+        return None
+
+
+def cmd_kitchen_snapshot(api: ChefBenchClient, args) -> Any:
+    data = api.get_kitchen_snapshot()
+    if args.json:
+        return data
+    if data["run_id"] is None:
+        print("No run has started since the server came up")
+        return None
+    print(f"Kitchen snapshot of run {data['run_id'][:8]} ({args.theme.status(data['status'])}) "
+          f"as of event {data['last_event_id']}")
+
+    print("\nOrders")
+    _print_table([
+        {
+            "table": order["table"] if order["table"] is not None else "kitchen",
+            "status": order["status"],
+            "items": len(order["items"]),
+            "progress": ", ".join(f"{n} {status}" for status, n in sorted(order["item_counts"].items()))
+        }
+        for order in data["orders"]
+    ], ["table", "status", "items", "progress"])
+
+    print("\nStations")
+    _print_table([
+        {
+            "station": station["name"],
+            "staff": ", ".join(station["staff"]) or "-",
+            "orders": station["active_orders"],
+            "load": f"{station['load']:.2f}",
+            "down": args.theme.status("broken", ", ".join(station["equipment_down"])) if station["equipment_down"] else "-"
+        }
+        for station in data["stations"]
+    ], ["station", "staff", "orders", "load", "down"])
+
+    print("\nStaff")
+    _print_table([
+        {**member, "status": args.theme.status(member["status"])}
+        for member in data["staff"]
+    ], ["name", "role", "status", "open_orders", "tasks_completed", "tasks_failed"])
+
+    inventory = data["inventory"]
+    print(f"\nInventory: {inventory['stocked']} ingredients in stock, "
+          f"{len(inventory['held_by_open_orders'])} held by open orders, "
+          f"spoiled: {', '.join(inventory['spoiled']) or 'none'}")
+
+    alerts = data["alerts"]
+    print(f"\nAlerts: {sum(alerts['counts'].values())}")
+    for alert in alerts["recent"][:args.alerts]:
+        print(f"  {args.theme.status(alert['severity'])}  {alert['title']}")
+    return None
+
+
+def cmd_events_list(api: ChefBenchClient, args) -> Any:
+    data = api.list_events(
+        run_id=args.run_id,
+        agent_name=args.agent,
+        event_type=args.type,
+        limit=args.limit,
+        include_archived=args.archived
+    )
+    if args.json:
+        return data
+    rows = [
+        {
+            "id": e["event_id"],
+            "type": e["event_type"],
+            "agent": e["agent_name"] or "",
+            "task": e["task_id"] or "",
+            "caused_by": e["caused_by"] or ""
+        }
+        for e in data["events"]
+    ]
+    _print_table(rows, ["id", "type", "agent", "task", "caused_by"])
+
+
+def _print_delivery(delivery: Dict[str, Any], theme):
+    status = "delivered" if delivery["delivered"] else "failed"
+    detail = f"HTTP {delivery['status_code']}" if delivery["delivered"] else delivery["error"]
+    print(f"{theme.status(status)} {delivery['event']} after {delivery['attempts']} attempt(s): {detail}")
+
+
+def cmd_webhooks_list(api: ChefBenchClient, args) -> Any:
+    data = api.list_webhooks()
+    if args.json:
+        return data
+    rows = [
+        {
+            **w,
+            "events": ", ".join(w["events"]) or "all",
+            "enabled": "yes" if w["enabled"] else "no"
+        }
+        for w in data["webhooks"]
+    ]
+    _print_table(rows, ["webhook_id", "url", "events", "min_quality", "enabled"])
+
+
+def cmd_webhooks_add(api: ChefBenchClient, args) -> Any:
+    data = api.add_webhook(args.url, secret=args.secret, events=args.events, min_quality=args.min_quality)
+    if args.json:
+        return data
+    print(f"Added webhook {data['webhook_id']} for {', '.join(data['events']) or 'all events'}")
+    if not args.secret:
+        print(f"  signing secret (shown once): {data['secret']}")
+
+
+def cmd_webhooks_remove(api: ChefBenchClient, args) -> Any:
+    data = api.remove_webhook(args.webhook_id)
+    if args.json:
+        return data
+    print(f"Removed webhook {args.webhook_id}")
+
+
+def cmd_webhooks_test(api: ChefBenchClient, args) -> Any:
+    data = api.test_webhook(args.webhook_id, timeout=60)
+    if args.json:
+        return data
+    _print_delivery(data, args.theme)
+    if not data["delivered"]:
+        raise SystemExit(1)
+
+
+def cmd_webhooks_deliveries(api: ChefBenchClient, args) -> Any:
+    data = api.get_webhook_deliveries(args.webhook_id)
+    if args.json:
+        return data
+    rows = [
+        {
+            **d,
+            "status": args.theme.status("delivered" if d["delivered"] else "failed"),
+            "sent": datetime.fromtimestamp(d["sent_at"]).strftime("%H:%M:%S"),
+            "response": d["status_code"] or d["error"] or ""
+        }
+        for d in data["deliveries"]
+    ]
+    _print_table(rows, ["sent", "event", "run_id", "status", "attempts", "response"])
+
+
+def cmd_alerts_list(api: ChefBenchClient, args) -> Any:
+    data = api.get_notifications()
+    if args.json:
+        return data
+    if not data["sinks"]:
+        print("No alert sinks configured; add them under notifications.sinks in configs/config.yaml")
+        return None
+    _print_table(
+        [{**s, "kinds": ", ".join(s["kinds"]) or "all", "signed": "yes" if s["signed"] else "no"} for s in data["sinks"]],
+        ["name", "type", "url", "min_severity", "kinds", "signed"]
+    )
+    if data["recent"]:
+        print("\nRecent alerts")
+        _print_table([
+            {
+                **r,
+                "status": args.theme.status("delivered" if r["delivered"] else "failed"),
+                "sent": datetime.fromtimestamp(r["sent_at"]).strftime("%H:%M:%S")
+            }
+            for r in data["recent"]
+        ], ["sent", "sink", "kind", "severity", "run_id", "status", "attempts"])
+
+
+def cmd_alerts_test(api: ChefBenchClient, args) -> Any:
+    data = api.test_notifications(args.severity, timeout=60)
+    if args.json:
+        return data
+    for result in data["results"]:
+        print(f"{result['sink']}: ", end="")
+        _print_delivery({**result, "event": "test alert"}, args.theme)
+    if not all(r["delivered"] for r in data["results"]):
+        raise SystemExit(1)
+
+
+def cmd_simulator(api: ChefBenchClient, args) -> Any:
+    rates = {
+        "error_rate": args.error_rate,
+        "malformed_rate": args.malformed_rate,
+        "hallucination_rate": args.hallucination_rate,
+        "violation_rate": args.violation_rate,
+    }
+    rates = {k: v for k, v in rates.items() if v is not None}
+    if args.role:
+        roles = api.get_simulator()["script"]["roles"]
+        data = api.configure_simulator(roles={**roles, args.role: {**roles.get(args.role, {}), **rates}})
+    elif rates or args.latency is not None:
+        data = api.configure_simulator(latency_seconds=args.latency, **rates)
+    else:
+        data = api.get_simulator()
+    if args.json:
+        return data
+    script, stats = data["script"], data["stats"]
+    print(f"Mock model: errors {script['error_rate']:g}, malformed {script['malformed_rate']:g}, "
+          f"hallucinations {script['hallucination_rate']:g}, violations {script['violation_rate']:g}, "
+          f"latency {script['latency_seconds']:g}s")
+    for role, overrides in script["roles"].items():
+        print(f"  {role}: " + ", ".join(f"{k} {v:g}" for k, v in overrides.items()))
+    print(f"  {stats['calls']} calls: {stats['errors']} failed, {stats['malformed']} malformed, "
+          f"{stats['hallucinations']} hallucinated, {stats['violations']} violated restrictions")
+
+
+def _transcript_rows(transcripts: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    return [
+        {
+            "id": t["transcript_id"],
+            "agent": t["agent_name"],
+            "task": t["task_id"] or "",
+            "response": t["response"].replace("\n", " ")[:60]
+        }
+        for t in transcripts
+    ]
+
+
+def _print_transcript(transcript: Dict[str, Any]):
+    print(f"#{transcript['transcript_id']} {transcript['agent_name']} ({transcript['model_name']})"
+          f" run={transcript['run_id']} task={transcript['task_id']}")
+    if transcript["details"].get("fallback"):
+        print("  (no model loaded, canned fallback response)")
+    print("\n--- prompt ---")
+    print(transcript["prompt"])
+    print("\n--- response ---")
+    print(transcript["response"])
+
+
+def _transcript_filters(args) -> Dict[str, Any]:
+    return {"run_id": args.run_id, "agent_name": args.agent, "task_id": args.task, "contains": args.search}
+
+
+def cmd_transcripts_list(api: ChefBenchClient, args) -> Any:
+    data = api.list_transcripts(**_transcript_filters(args), limit=args.limit)
+    if args.json:
+        return data
+    _print_table(_transcript_rows(data["transcripts"]), ["id", "agent", "task", "response"])
+
+
+def cmd_transcripts_show(api: ChefBenchClient, args) -> Any:
+    data = api.get_transcript(args.transcript_id)
+    if args.json:
+        return data
+    _print_transcript(data)
+
+
+def cmd_transcripts_browse(api: ChefBenchClient, args) -> Any:
+    """Page through transcripts, opening any by id"""
+    filters = _transcript_filters(args)
+    pages = [0]  # after_id of each page seen so far
+    while True:
+        # One extra row tells us whether another page exists
+        data = api.list_transcripts(**filters, after_id=pages[-1], limit=args.page_size + 1)
+        transcripts = data["transcripts"][:args.page_size]
+        has_next = len(data["transcripts"]) > args.page_size
+        _print_table(_transcript_rows(transcripts), ["id", "agent", "task", "response"])
+
+        action, choice = TRANSCRIPT_BROWSER_KEYS.read("\n")
+        if action == "quit":
+            return None
+        if action == "next":
+            if has_next:
+                pages.append(transcripts[-1]["transcript_id"])
+            else:
+                print("(last page)")
+        elif action == "previous":
+            if len(pages) > 1:
+                pages.pop()
+        elif action == "open":
+            try:
+                _print_transcript(api.get_transcript(int(choice)))
+            except ChefBenchClientError as e:
+                print(f"Error: {e}")
+                continue
+            if TRANSCRIPT_KEYS.read("\n")[0] == "quit":
+                return None
+
+
+def cmd_recipes_import(api: ChefBenchClient, args) -> Any:
+    path = Path(args.file)
+    format = args.format or ("paprika" if path.suffix in (".paprikarecipes", ".paprikarecipe") else "schema_org")
+    if format == "paprika":
+        content = base64.b64encode(path.read_bytes()).decode("ascii")
+    else:
+        content = path.read_text(encoding="utf-8")
+
+    data = api.import_recipes(format, content, dry_run=args.dry_run)
+    if args.json:
+        return data
+    rows = [
+        {
+            "id": "-" if args.dry_run else r["id"],
+            "name": r["name"],
+            "cuisine": r["cuisine"],
+            "ingredients": len(r["ingredients"]),
+            "minutes": round(r["duration_seconds"] / 60),
+            "equipment": ",".join(r["equipment"]) or "-",
+            "techniques": ",".join(r.get("techniques", [])) or "-"
+        }
+        for r in data["recipes"]
+    ]
+    _print_table(rows, ["id", "name", "cuisine", "ingredients", "minutes", "equipment", "techniques"])
+    for r in data["recipes"]:
+        for error in r["errors"]:
+            print(f"{r['name']}: {error}")
+    verb = "Parsed" if args.dry_run else "Imported"
+    print(f"{verb} {data['count']} recipes ({data['total_recipes']} in dataset)")
+
+
+def cmd_metrics_report(api: ChefBenchClient, args) -> Any:
+    report = api.generate_report()
+    if args.output:
+        Path(args.output).write_bytes(report)
+        print(f"Wrote report to {args.output}")
+    else:
+        sys.stdout.write(report.decode("utf-8"))
+
+
+def cmd_metrics_export(api: ChefBenchClient, args) -> Any:
+    data = api.export_metrics()
+    if args.json:
+        return data
+    for path in data["files"]:
+        print(path)
+
+
+def cmd_metrics_leaderboard(api: ChefBenchClient, args) -> Any:
+    data = api.get_leaderboard(args.profile, args.limit)
+    if args.json:
+        return data
+    print(f"{data['profile']['name']}: {data['profile']['description']}")
+    rows = [
+        {
+            "rank": run["rank"],
+            "score": _format_float(run["score"]),
+            "scenario": run["scenario_name"],
+            "policy": run["assignment_policy"],
+            "seed": run["seed"],
+            **{d: f"{v:.2f}" for d, v in run["scores"]["dimensions"].items()}
+        }
+        for run in data["runs"]
+    ]
+    _print_table(rows, ["rank", "score", "scenario", "policy", "seed",
+                        "business", "quality", "speed", "safety", "cost"])
+
+
+def cmd_metrics_scoring(api: ChefBenchClient, args) -> Any:
+    if args.file:
+        config = _load_scenario_file(args.file)
+        data = api.set_scoring(config.get("profiles"), config.get("scenarios"))
+    else:
+        data = api.get_scoring()
+    if args.json:
+        return data
+    if args.file:
+        print(f"Loaded {len(data['profiles'])} custom profiles and {len(data['scenarios'])} scenario defaults")
+        data = api.get_scoring()
+    rows = [
+        {
+            "profile": profile["name"],
+            "source": profile["source"],
+            "weights": ", ".join(f"{d} {w:g}" for d, w in profile["weights"].items()) or "-",
+            "thresholds": ", ".join(f"{m} >= {t:g}" for m, t in profile["thresholds"].items()) or "-",
+            "formula": profile["formula"] or "weighted mean"
+        }
+        for profile in data["profiles"]
+    ]
+    _print_table(rows, ["profile", "source", "weights", "thresholds", "formula"])
+    if data["scenarios"]:
+        print("\nScenario defaults: " + ", ".join(f"{s} -> {p}" for s, p in data["scenarios"].items()))
+    return None
+
+
+def cmd_metrics_eod(api: ChefBenchClient, args) -> Any:
+    if args.list:
+        data = api.list_daily_reports()
+        if args.json:
+            return data
+        _print_table(data, ["date", "runs", "orders", "generated_at"])
+        return None
+
+    if args.saved:
+        data = api.get_daily_report(args.date or datetime.now().strftime("%Y-%m-%d"))
+    else:
+        data = api.generate_daily_report(args.date)
+    if args.json:
+        return data
+
+    orders = data["orders"]
+    ticket = data["average_ticket_seconds"]
+    rule = "=" * 44
+    print(rule)
+    print(f" End of day {data['date']}".ljust(30) + f"{data['runs']} runs".rjust(14))
+    print(rule)
+    print(f" Orders        {orders['total']:>6}   served {orders['served']}, failed {orders['failed']}, "
+          f"cancelled {orders['cancelled']}")
+    print(f" Avg ticket    {'-' if ticket is None else f'{ticket:.1f}s':>6}")
+    print(f" Quality fails {data['quality_failures']['total']:>6}")
+    for task_type, count in data["quality_failures"]["by_task_type"].items():
+        print(f"   {task_type:<24}{count:>6}")
+    print("-" * 44)
+    print(f" Labor cost    {data['labor_cost']:>10.2f}")
+    print(f" Food cost     {data['food_cost']:>10.2f}")
+    print(f" Waste         {data['waste']['cost']:>10.2f}   ({data['waste']['orders']} orders)")
+    print("-" * 44)
+    print(" Top sellers")
+    for seller in data["top_sellers"] or [{"item": "(none)", "orders": ""}]:
+        print(f"   {seller['item']:<24}{seller['orders']:>6}")
+    if data.get("service_periods"):
+        print("-" * 44)
+        print(" Service periods  covers    revenue  avg ticket")
+        for period in data["service_periods"]:
+            average = period["average_ticket"]
+            print(f"   {period['period']:<14}{period['covers']:>6}{period['revenue']:>11.2f}"
+                  f"{'-' if average is None else f'{average:.2f}':>12}")
+    print(rule)
+
+
+def cmd_metrics_menu(api: ChefBenchClient, args) -> Any:
+    data = api.get_menu_engineering(args.days)
+    if args.json:
+        return data
+    _print_table([
+        {**item, "class": item["class"] or "-", "mix": f"{item['mix_share']:.1%}",
+         "price": f"{item['price']:.2f}", "margin": f"{item['margin']:.2f}"}
+        for item in data["items"]
+    ], ["recipe_id", "dish", "class", "sold", "mix", "price", "margin"])
+    if data["total_sold"]:
+        print(f"{data['total_sold']} portions sold; popular from {data['popularity_threshold']:.1%} of the mix, "
+              f"profitable from a ${data['average_margin']:.2f} margin")
+    else:
+        print("Nothing sold yet, so no item is classified")
+
+
+def cmd_serve(api: Optional[ChefBenchClient], args) -> Any:
+    import uvicorn
+    from kitchen.api import create_app, trusted_proxies, tls_options
+
+    scheme = "https" if args.tls_cert else "http"
+    host = "localhost" if args.host in ("0.0.0.0", "::") else args.host
+    print(f"Playground at {scheme}://{host}:{args.port}/playground")
+    uvicorn.run(
+        create_app(seed=args.seed, seed_profile=args.seed_profile),
+        host=args.host,
+        port=args.port,
+        log_level="info",
+        proxy_headers=True,
+        forwarded_allow_ips=trusted_proxies(),
+        **tls_options(args.tls_cert, args.tls_key, args.tls_client_ca, args.require_client_cert)
+    )
+
+
+def cmd_macro_record(api: Optional[ChefBenchClient], args) -> Any:
+    step = args.step[1:] if args.step[:1] == ["--"] else args.step
+    MacroStore().record(args.name, step, args.repeat)
+    print(f"Recorded {args.repeat} step(s) in macro '{args.name}'")
+
+
+def cmd_macro_list(api: Optional[ChefBenchClient], args) -> Any:
+    macros = MacroStore().macros
+    if args.json:
+        return macros
+    _print_table(
+        [{"name": name, "steps": len(steps)} for name, steps in sorted(macros.items())],
+        ["name", "steps"]
+    )
+
+
+def cmd_macro_show(api: Optional[ChefBenchClient], args) -> Any:
+    steps = MacroStore().get(args.name)
+    if args.json:
+        return steps
+    for index, step in enumerate(steps, 1):
+        print(f"{index:3}. escoffier {' '.join(step)}")
+
+
+def cmd_macro_delete(api: Optional[ChefBenchClient], args) -> Any:
+    if not MacroStore().delete(args.name):
+        raise SystemExit(f"No macro named '{args.name}'")
+    print(f"Deleted macro '{args.name}'")
+
+
+def cmd_macro_run(api: Optional[ChefBenchClient], args) -> Any:
+    """Replay each recorded step through main(), stopping at the first failure"""
+    steps = MacroStore().get(args.name)
+    global_args = ["--timeout", str(args.timeout), "--theme", args.theme.name]
+    for option in ("api_url", "token", "session", "ca_cert", "client_cert", "client_key"):
+        if getattr(args, option):
+            global_args += [f"--{option.replace('_', '-')}", getattr(args, option)]
+    if args.json:
+        global_args.append("--json")
+
+    for index, step in enumerate(steps, 1):
+        if not args.json:
+            print(f"[{index}/{len(steps)}] escoffier {' '.join(step)}")
+        if main(global_args + step) != 0 and not args.keep_going:
+            raise SystemExit(f"Macro '{args.name}' stopped at step {index}")
+
+
+def cmd_keys(api: Optional[ChefBenchClient], args) -> Any:
+    if args.json:
+        return [keymap.to_dict() for keymap in KEYMAPS]
+    print("\n\n".join(keymap.help_text() for keymap in KEYMAPS))
+
+
+def cmd_settings_show(api: Optional[ChefBenchClient], args) -> Any:
+    data = args.settings.to_dict(reveal=args.reveal)
+    if args.json:
+        return data
+    print(f"Settings file: {data['path']}")
+    rows = [
+        {"setting": name, "value": "-" if row["value"] is None else row["value"], "source": row["source"], "env": row["env"]}
+        for name, row in data["settings"].items()
+    ]
+    _print_table(rows, ["setting", "value", "source", "env"])
+
+
+def cmd_settings_set(api: Optional[ChefBenchClient], args) -> Any:
+    value = args.settings.set(args.name, args.value)
+    if args.json:
+        return {args.name: value}
+    print(f"{args.name} = {value}")
+    _warn_env_override(args.settings, args.name)
+
+
+def cmd_settings_unset(api: Optional[ChefBenchClient], args) -> Any:
+    if not args.settings.unset(args.name):
+        print(f"{args.name} was not set")
+        return None
+    print(f"{args.name} reset to {SETTINGS[args.name].default}")
+    _warn_env_override(args.settings, args.name)
+
+
+def cmd_settings_edit(api: Optional[ChefBenchClient], args) -> Any:
+    """Step through every setting, Enter keeping the current value, then save them all"""
+    store = args.settings
+    print(f"Editing {store.path} (Enter keeps the current value)")
+    values = dict(store.values)
+    for name, setting in SETTINGS.items():
+        current = values.get(name, setting.default)
+        if setting.secret:
+            answer = _ask(f"{name} - {setting.description} ({'set' if current else 'not set'}; '-' clears)")
+            if answer == "-":
+                values.pop(name, None)
+            elif answer:
+                values[name] = setting.parse(answer)
+            continue
+        value = _ask(f"{name} - {setting.description}", default=current, parse=setting.parse,
+                     choices=list(THEMES) if name == "theme" else None)
+        if value is None or (value == setting.default and name not in values):
+            values.pop(name, None)
+        else:
+            values[name] = value
+    store.values = values
+    store.save()
+    print(f"Saved {store.path}")
+    for name in SETTINGS:
+        _warn_env_override(store, name)
+
+
+def cmd_settings_path(api: Optional[ChefBenchClient], args) -> Any:
+    print(args.settings.path)
+
+
+def _warn_env_override(store: SettingsStore, name: str):
+    if store.resolve(name)[1] == "env":
+        print(f"  note: ${SETTINGS[name].env} is set and takes precedence over the file", file=sys.stderr)
+
+
+def build_parser() -> argparse.ArgumentParser:
+    parser = argparse.ArgumentParser(prog="escoffier", description="ChefBench command line interface")
+    parser.add_argument("--api-url", default=None,
+                        help="API base URL (env: ESCOFFIER_API_URL, setting: api_url)")
+    parser.add_argument("--token", default=None,
+                        help="Admin token for protected endpoints (env: ESCOFFIER_AUTH_TOKEN, setting: auth_token)")
+    parser.add_argument("--timeout", type=float, default=30.0, help="Per-request timeout in seconds")
+    parser.add_argument("--session", default=os.environ.get("ESCOFFIER_SESSION"),
+                        help="Work in an isolated server-side sandbox (env: ESCOFFIER_SESSION)")
+    parser.add_argument("--ca-cert", default=os.environ.get("ESCOFFIER_CA_CERT"),
+                        help="CA bundle for verifying an HTTPS server (env: ESCOFFIER_CA_CERT)")
+    parser.add_argument("--client-cert", default=os.environ.get("ESCOFFIER_CLIENT_CERT"),
+                        help="Client certificate for mutual TLS (env: ESCOFFIER_CLIENT_CERT)")
+    parser.add_argument("--client-key", default=os.environ.get("ESCOFFIER_CLIENT_KEY"),
+                        help="Key for --client-cert (env: ESCOFFIER_CLIENT_KEY)")
+    parser.add_argument("--theme", default=None, choices=THEMES,
+                        help="Output colors; no-color keeps only status symbols (env: ESCOFFIER_THEME, setting: theme)")
+    parser.add_argument("--json", action="store_true", help="Print machine-readable JSON")
+    commands = parser.add_subparsers(dest="command", required=True)
+
+    status = commands.add_parser("status", help="Check the API server")
+    status.set_defaults(handler=cmd_status)
+
+    health = commands.add_parser("health", help="Check server dependencies (exit 1 if not ready)")
+    health.add_argument("--check", action="append", help="Only run this dependency check (repeatable)")
+    health.set_defaults(handler=cmd_health)
+
+    seed = commands.add_parser("seed", help="Add a seed profile's data to the session, or list profiles")
+    seed.add_argument("profile", nargs="?", default=None, choices=["empty", "demo", "stress"])
+    seed.set_defaults(handler=cmd_seed)
+
+    # agents
+    agents = commands.add_parser("agents", help="Manage agents").add_subparsers(dest="action", required=True)
+    for name in ("list", "status"):
+        agents_list = agents.add_parser(name, help="List agents with metrics")
+        agents_list.add_argument("--role", default=None)
+        agents_list.add_argument("--model", default=None)
+        agents_list.add_argument("--sort", default="name",
+                                 help="name, role, model, tasks_completed, success_rate or avg_quality; "
+                                      "prefix '-' for descending")
+        agents_list.add_argument("--limit", type=int, default=20, help="Rows per page")
+        agents_list.add_argument("--offset", type=int, default=0)
+        agents_list.set_defaults(handler=cmd_agents_list)
+    create = agents.add_parser("create", help="Create a single agent")
+    create.add_argument("name")
+    create.add_argument("role", choices=["HEAD_CHEF", "SOUS_CHEF", "CHEF_DE_PARTIE",
+                                         "LINE_COOK", "PREP_COOK", "KITCHEN_PORTER", "COMMIS"])
+    create.add_argument("--model", default=None, help="Default: the default_model setting, or mock in mock mode")
+    create.add_argument("--device", default="cpu", choices=["cpu", "gpu"])
+    create.set_defaults(handler=cmd_agents_create)
+    skills = agents.add_parser("skills", help="Show or edit an agent's skills and certifications")
+    skills.add_argument("name")
+    skills.add_argument("--set", nargs="+", metavar="TASK=VALUE", default=None,
+                        help="e.g. sauce_preparation=expert or plating_design=0.6")
+    skills.add_argument("--certifications", nargs="*", default=None,
+                        help="Replace certifications: food_safety, allergen_awareness, equipment_safety")
+    skills.set_defaults(handler=cmd_agents_skills)
+    memory = agents.add_parser("memory", help="Show what an agent recalls, summaries first")
+    memory.add_argument("name")
+    memory.set_defaults(handler=cmd_agents_memory)
+
+    # teams
+    teams = commands.add_parser("teams", help="Manage teams").add_subparsers(dest="action", required=True)
+    team_create = teams.add_parser("create", help="Create a team sharing one model")
+    team_create.add_argument("--model", default=None, help="Default: the default_model setting, or mock in mock mode")
+    team_create.add_argument("--size", type=int, default=4)
+    team_create.add_argument("--roles", default=None, help="Comma-separated roles")
+    team_create.set_defaults(handler=cmd_teams_create)
+    team_routed = teams.add_parser("routed", help="Create a team with each role on the model the kitchen config routes it to")
+    team_routed.add_argument("--size", type=int, default=4)
+    team_routed.add_argument("--roles", default=None, help="Comma-separated roles")
+    team_routed.add_argument("--default-model", default=None, help="Model for roles the routing doesn't list")
+    team_routed.set_defaults(handler=cmd_teams_routed)
+    team_routing = teams.add_parser("routing", help="Show or change models by role")
+    team_routing.add_argument("--set", nargs="+", metavar="ROLE=MODEL", default=None,
+                              help="e.g. HEAD_CHEF=gpt-4 LINE_COOK=llama3.2:1b; ROLE= removes a role's model")
+    team_routing.add_argument("--default", default=None, help="Model for roles not listed")
+    team_routing.set_defaults(handler=cmd_teams_routing)
+
+    for sub in (create, team_create, team_routed):
+        sub.add_argument("--fallback", default="retry", choices=["retry", "heuristic", "pause"],
+                         help="What agents do when their model fails mid-run")
+
+    # bench
+    bench = commands.add_parser("bench", help="Run and inspect benchmark scenarios").add_subparsers(
+        dest="action", required=True)
+    run = bench.add_parser("run", help="Start a scenario, optionally from a JSON/YAML file")
+    run.add_argument("scenario_file", nargs="?", default=None)
+    run.add_argument("--type", dest="scenario_type", default=None,
+                     help="standard, crisis, collaboration, complex, tutorial or a scenario bundle name")
+    run.add_argument("--duration", dest="duration_seconds", type=int, default=None)
+    run.add_argument("--tasks", dest="num_tasks", type=int, default=None)
+    run.add_argument("--policy", dest="assignment_policy", default=None)
+    run.add_argument("--seed", type=int, default=None)
+    run.add_argument("--equipment", dest="simulate_equipment", action="store_true", default=None,
+                     help="Simulate equipment breakdowns and maintenance")
+    run.add_argument("--guests", dest="simulate_guests", action="store_true", default=None,
+                     help="Have seated guests change and cancel orders through their servers")
+    run.add_argument("--customers", dest="simulate_customers", action="store_true", default=None,
+                     help="Have customers order from the menu through the run, on a service day's demand curves; "
+                          "tune them with customer_demand in the scenario file")
+    run.add_argument("--pacing", dest="plan_pacing", action="store_true", default=None,
+                     help="Plan the firing order of all orders across stations, for the head chef to review")
+    run.add_argument("--expo", dest="expeditor", action="store_true", default=None,
+                     help="Put an expeditor on the pass, sending table orders out a course at a time")
+    run.add_argument("--profile", dest="scoring_profile", default=None,
+                     help="Scoring profile for the headline score (balanced, fine_dining, ...)")
+    run.add_argument("--restriction", dest="dietary_restrictions", action="append", default=None,
+                     help="Guest allergen or dietary tag, e.g. peanuts or vegan (repeatable)")
+    run.add_argument("--rubric", dest="quality_rubric", default=None,
+                     help="Built-in quality rubric (default, line_check, tasting_panel); "
+                          "define custom ones in the scenario file")
+    run.add_argument("--judge", dest="judge_transcripts", action="store_true", default=None,
+                     help="Have a judge model score each agent's transcript after the run")
+    run.add_argument("--judge-model", dest="judge_model", default=None)
+    run.add_argument("--seed-profile", dest="seed_profile", default=None, choices=["empty", "demo", "stress"],
+                     help="Seed the session with this profile's data before starting")
+    run.add_argument("--prompt", dest="prompts", action="append", default=None, metavar="NAME=FILE",
+                     help="Use this file as a prompt template for the run, e.g. task.line_cook=cook.tmpl (repeatable)")
+    run.add_argument("--max-tokens", dest="max_tokens", type=int, default=None,
+                     help="Pause the run once its LLM calls use this many tokens")
+    run.add_argument("--max-cost", dest="max_cost", type=float, default=None,
+                     help="Pause the run once its LLM calls cost this much, in USD")
+    run.set_defaults(handler=cmd_bench_run)
+
+    prompts = bench.add_parser("prompts", help="List prompt templates, or print one")
+    prompts.add_argument("name", nargs="?", default=None)
+    prompts.set_defaults(handler=cmd_bench_prompts)
+
+    ab = bench.add_parser("ab", help="Run one scenario under two prompt variants and test which does better")
+    ab.add_argument("scenario_file", nargs="?", default=None)
+    ab.add_argument("--a", dest="variant_a", action="append", default=None, metavar="NAME=FILE",
+                    help="Prompt template for variant A (repeatable); without any, A uses the registry's own")
+    ab.add_argument("--b", dest="variant_b", action="append", required=True, metavar="NAME=FILE",
+                    help="Prompt template for variant B (repeatable)")
+    ab.add_argument("--name-a", default="A")
+    ab.add_argument("--name-b", default="B")
+    ab.add_argument("--runs", type=int, default=10, help="Seeds each variant runs on")
+    ab.add_argument("--metric", default="score",
+                    choices=["score", "overall_success_rate", "average_quality", "labor_cost", "invalid_action_rate"],
+                    help="Metric that decides the winner")
+    ab.add_argument("--alpha", type=float, default=0.05)
+    ab.add_argument("--wait", action="store_true", help="Block until both variants have run on every seed")
+    ab.add_argument("--poll-interval", type=float, default=None, help="Default: the refresh_interval setting")
+    ab.set_defaults(handler=cmd_bench_ab)
+
+    compare = bench.add_parser("compare", help="Run one scenario once per model and rank the models")
+    compare.add_argument("scenario_file", nargs="?", default=None)
+    compare.add_argument("--model", dest="models", action="append", required=True,
+                         help="Model to evaluate; repeat for each model (at least two)")
+    compare.add_argument("--from-run", dest="evaluation_id", default=None,
+                         help="Reuse the config and seed of an earlier run")
+    compare.add_argument("--size", type=int, default=4)
+    compare.add_argument("--roles", default=None, help="Comma-separated roles")
+    compare.add_argument("--parallel", type=int, default=4, help="Models to run at once (1 runs them in turn)")
+    compare.add_argument("--wait", action="store_true", help="Block until every model has run")
+    compare.add_argument("--poll-interval", type=float, default=None, help="Default: the refresh_interval setting")
+    compare.set_defaults(handler=cmd_bench_compare)
+
+    run.add_argument("--wait", action="store_true", help="Block until the run finishes")
+    run.add_argument("--live", action="store_true", help="With --wait, show a live progress panel instead of polling")
+    run.add_argument("--poll-interval", type=float, default=None, help="Default: the refresh_interval setting")
+    run.add_argument("--notify", nargs="?", const=os.environ.get("ESCOFFIER_NOTIFY", "auto"),
+                     default=None, choices=NOTIFY_METHODS,
+                     help="With --wait, send a desktop notification on completion and critical events")
+
+    wizard = bench.add_parser("wizard", help="Put an order together from the menu step by step, review it, then submit it")
+    wizard.set_defaults(handler=cmd_bench_wizard)
+
+    bench_list = bench.add_parser("list", help="List runs, newest first")
+    bench_list.add_argument("--status", default=None, help="running, paused, completed or failed")
+    bench_list.add_argument("--type", dest="scenario_type", default=None)
+    bench_list.add_argument("--since", default=None, help="Only runs started after this ISO timestamp")
+    bench_list.add_argument("--sort", default="-started_at")
+    bench_list.add_argument("--limit", type=int, default=20, help="Rows per page")
+    bench_list.add_argument("--offset", type=int, default=0)
+    bench_list.set_defaults(handler=cmd_bench_list)
+
+    golden = bench.add_parser("golden", help="Record a completed run as a golden run for 'bench verify'")
+    golden.add_argument("evaluation_id")
+    golden.add_argument("--name", default=None, help="Default: the first 8 characters of the evaluation id")
+    golden.add_argument("-o", "--output", default=None, help="Default: data/golden/NAME.json")
+    golden.set_defaults(handler=cmd_bench_golden)
+
+    verify = bench.add_parser("verify", help="Replay golden runs from cached responses and check their metrics")
+    verify.add_argument("golden", nargs="+", help="Golden run files")
+    verify.add_argument("--tolerance", type=float, default=None,
+                        help="Allowed absolute difference per metric, overriding each file's default")
+    verify.add_argument("-v", "--verbose", action="store_true", help="Show every metric, not only the failures")
+    verify.set_defaults(handler=cmd_bench_verify, local=True)
+
+    scenarios = bench.add_parser("scenarios", help="List scenario bundles, or show one")
+    scenarios.add_argument("name", nargs="?", default=None)
+    scenarios.add_argument("--reload", action="store_true", help="Rescan the server's scenarios directory first")
+    scenarios.set_defaults(handler=cmd_bench_scenarios)
+
+    for name, handler, help_text in (
+        ("status", cmd_bench_status, "Show run status"),
+        ("results", cmd_bench_results, "Show run results"),
+        ("usage", cmd_bench_usage, "Show LLM token usage and estimated cost"),
+        ("pause", cmd_bench_pause, "Pause a running scenario and checkpoint it"),
+        ("resume", cmd_bench_resume, "Resume a paused scenario"),
+    ):
+        sub = bench.add_parser(name, help=help_text)
+        sub.add_argument("evaluation_id")
+        sub.set_defaults(handler=handler)
+
+    budget = bench.add_parser("budget", help="Show a run's token and cost budget, or change its limits")
+    budget.add_argument("evaluation_id")
+    budget.add_argument("--max-tokens", type=int, default=None)
+    budget.add_argument("--max-cost", type=float, default=None, help="USD")
+    budget.add_argument("--warn-at", type=float, default=None, help="Share of a limit used before warning, e.g. 0.8")
+    budget.set_defaults(handler=cmd_bench_budget)
+    export = bench.add_parser("export", help="Download a run's events, tasks and order timings as tables")
+    export.add_argument("evaluation_id")
+    export.add_argument("--format", choices=["csv", "parquet"], default="csv",
+                        help="Parquet needs pyarrow on the server")
+    export.add_argument("--output-dir", "-o", default="exports", help="Tables go under <dir>/<evaluation_id>/")
+    export.add_argument("--zip", action="store_true", help="Keep the downloaded zip instead of unpacking it")
+    export.set_defaults(handler=cmd_bench_export)
+
+    trends = bench.add_parser("trends", help="Recorded runs' metrics over weeks, per model, scenario or prompt")
+    trends.add_argument("--metric", action="append", default=None,
+                        help="Repeatable; score, success_rate and average_quality by default")
+    trends.add_argument("--group-by", action="append", default=None,
+                        help="Repeatable: model (default), scenario, assignment_policy, scoring_profile, "
+                             "quality_rubric or prompt")
+    trends.add_argument("--interval", choices=["run", "day", "week"], default="day")
+    trends.add_argument("--window", type=int, default=7, help="Periods in each rolling average")
+    trends.add_argument("--since", default=None, help="YYYY-MM-DD")
+    trends.add_argument("--until", default=None, help="YYYY-MM-DD")
+    trends.add_argument("--model", default=None, help="Only runs on this model")
+    trends.add_argument("--scenario", default=None, help="Only runs of this scenario")
+    trends.set_defaults(handler=cmd_bench_trends)
+
+    report = bench.add_parser("report", help="Summarize a finished run: role metrics, ticket times, failures and cost")
+    report.add_argument("evaluation_id")
+    report.add_argument("--output", "-o", choices=REPORT_FORMATS, default="text",
+                        help="markdown and json are for papers and READMEs")
+    report.add_argument("--file", "-f", default=None, help="Write the report here instead of printing it")
+    report.set_defaults(handler=cmd_bench_report)
+
+    watch = bench.add_parser("watch", help="Follow a run with a live progress panel")
+    watch.add_argument("evaluation_id")
+    watch.set_defaults(handler=cmd_bench_watch)
+
+    chaos = bench.add_parser("chaos", help="Disrupt the executing run (admin), or list chaos actions")
+    chaos.add_argument("action", nargs="?", default=None,
+                       choices=["kill_agent", "break_equipment", "spike_orders", "spoil_inventory", "delay_llm"])
+    chaos.add_argument("--agent", default=None, help="kill_agent: agent to take off the team")
+    chaos.add_argument("--equipment", default=None, help="break_equipment: item to break")
+    chaos.add_argument("--count", type=int, default=None, help="spike_orders: extra tasks")
+    chaos.add_argument("--ingredients", nargs="+", default=None, help="spoil_inventory: ingredients to spoil")
+    chaos.add_argument("--delay", type=float, default=None, help="delay_llm: seconds added per response")
+    chaos.add_argument("--tasks", type=int, default=None, help="delay_llm: tasks to slow down")
+    chaos.set_defaults(handler=cmd_bench_chaos)
+
+    submit = bench.add_parser("submit", help="Check an order and queue it in the executing run")
+    submit.add_argument("--recipe", type=int, default=None, help="Recipe id on the menu")
+    submit.add_argument("--dish", default=None)
+    submit.add_argument("--ingredient", dest="ingredients", action="append", default=None,
+                        help="Ingredient of a dish that isn't on the menu; repeat for each")
+    submit.add_argument("--type", default=None, help="Order task type (cooking_execution, basic_cooking, ...)")
+    submit.add_argument("--quantity", type=int, default=None)
+    submit.add_argument("--course", choices=["starter", "main", "dessert"], default=None,
+                        help="Course the line is held and fired with (default main)")
+    submit.add_argument("--note", dest="notes", action="append", default=None,
+                        help="A change for the cook, e.g. 'no onions'; repeat for each")
+    submit.add_argument("--priority", choices=ORDER_PRIORITIES, default=None,
+                        help="expedite to the front of the queue, or also reassign to the least busy cook")
+    submit.add_argument("--table", type=int, default=None)
+    submit.add_argument("--restriction", dest="dietary_restrictions", action="append", default=None)
+    submit.add_argument("--time-limit", type=float, default=None)
+    submit.add_argument("--dry-run", action="store_true", help="Only show the plan; queue nothing")
+    submit.set_defaults(handler=cmd_bench_submit)
+
+    bulk = bench.add_parser("bulk", help="Schedule orders from a file to arrive in the executing run")
+    bulk.add_argument("file", help="JSON array of orders, or one order per line; - for stdin")
+    bulk.add_argument("--dry-run", action="store_true", help="Only plan each order now; schedule nothing")
+    bulk.set_defaults(handler=cmd_bench_bulk)
+
+    batch = bench.add_parser("batch", help="Show where each order of a bulk submission stands")
+    batch.add_argument("batch_id")
+    batch.set_defaults(handler=cmd_bench_batch)
+
+    modify = bench.add_parser("modify", help="Change the ticket a task of the executing run belongs to")
+    modify.add_argument("task_id")
+    modify.add_argument("--recipe", type=int, default=None, help="Add a line: recipe id on the menu")
+    modify.add_argument("--dish", default=None, help="Add a line: a dish that isn't on the menu")
+    modify.add_argument("--ingredient", dest="ingredients", action="append", default=None,
+                        help="Ingredient of the added dish; repeat for each")
+    modify.add_argument("--type", default=None, help="Order task type of the added line")
+    modify.add_argument("--quantity", type=int, default=None, help="Portions of the added line")
+    modify.add_argument("--set", dest="quantities", action="append", default=None, metavar="LINE=QUANTITY",
+                        help="Portions a line should have (repeatable)")
+    modify.add_argument("--remove", type=int, action="append", default=None, metavar="LINE",
+                        help="Take a line off the ticket (repeatable)")
+    modify.add_argument("--void", action="append", default=None, metavar="TASK_ID", help="Void a portion (repeatable)")
+    modify.add_argument("--note", default=None, help="A change for every queued portion, e.g. 'no salt'")
+    modify.add_argument("--priority", choices=["normal", "expedite", "reassign"], default=None)
+    modify.add_argument("--reason", default=None, help="Why the portions were voided")
+    modify.set_defaults(handler=cmd_bench_modify)
+
+    menu = bench.add_parser("menu", help="Show, plan and flag the menu and its 86-list, or 86 and restore items")
+    menu.add_argument("--cuisine", default=None)
+    menu.add_argument("--limit", type=int, default=50)
+    menu.add_argument("--86", dest="eighty_six", type=int, default=None, metavar="RECIPE_ID",
+                      help="Take an item off until it's restored")
+    menu.add_argument("--reason", default="", help="Why the item is 86'd")
+    menu.add_argument("--restore", type=int, default=None, metavar="RECIPE_ID",
+                      help="Put an item 86'd by hand back on")
+    menu.add_argument("--86-list", dest="eighty_sixed", action="store_true", help="Only list what's 86'd")
+    menu.add_argument("--plan", type=int, nargs="+", default=None, metavar="RECIPE_ID",
+                      help="Plan the menu as these recipes; nothing else can be ordered")
+    menu.add_argument("--specialty", type=int, action="append", default=[], metavar="RECIPE_ID",
+                      help="Mark an item a house specialty (with --plan, one of the planned items); repeat for each")
+    menu.add_argument("--seasonal", type=int, action="append", default=[], metavar="RECIPE_ID",
+                      help="Mark an item seasonal (with --plan, one of the planned items); repeat for each")
+    menu.add_argument("--plain", type=int, action="append", default=[], metavar="RECIPE_ID",
+                      help="Clear an item's specialty and seasonal marks; repeat for each")
+    menu.add_argument("--price", nargs=2, type=float, action="append", default=[], metavar=("RECIPE_ID", "PRICE"),
+                      help="Set what an item sells for, in place of its list price; repeat for each")
+    menu.add_argument("--clear-plan", action="store_true", help="Drop the planned menu, putting every loaded recipe back on")
+    menu.add_argument("--specialties", action="store_true", help="Only list the house specialties")
+    menu.set_defaults(handler=cmd_bench_menu)
+
+    for call, help_text in (("hold", "Hold a course in the executing run, at one table or all of them"),
+                            ("fire", "Fire a held course in the executing run, at one table or all of them")):
+        course = bench.add_parser(call, help=help_text)
+        course.add_argument("course", choices=["starter", "main", "dessert"])
+        course.add_argument("--table", type=int, default=None, help="Only this table's; every table's if left out")
+        course.add_argument("--author", default=None, help="Agent name, or who is running the pass")
+        course.set_defaults(handler=cmd_bench_course, call=call)
+
+    holds = bench.add_parser("holds", help="Show the courses held in the current run and any fired anyway")
+    holds.set_defaults(handler=cmd_bench_holds)
+
+    cancel = bench.add_parser("cancel", help="Cancel a task in the executing run")
+    cancel.add_argument("task_id")
+    cancel.add_argument("--reason", default="cancelled")
+    cancel.set_defaults(handler=cmd_bench_cancel)
+
+    escalation = bench.add_parser("escalation", help="Show or adjust escalation of tasks left waiting")
+    escalation.add_argument("--on", dest="enabled", action="store_const", const=True, default=None)
+    escalation.add_argument("--off", dest="enabled", action="store_const", const=False)
+    escalation.add_argument("--interval", type=float, default=None, help="Simulated seconds between scans")
+    escalation.add_argument("--expedite-after", type=float, default=None,
+                            help="Multiple of a task's time limit before it jumps the queue")
+    escalation.add_argument("--reassign-after", type=float, default=None,
+                            help="Multiple of a task's time limit before it goes to a less loaded agent")
+    escalation.set_defaults(handler=cmd_bench_escalation)
+
+    admission = bench.add_parser("admission", help="Show or adjust when new orders are refused as the kitchen fills up")
+    admission.add_argument("--on", dest="enabled", action="store_const", const=True, default=None)
+    admission.add_argument("--off", dest="enabled", action="store_const", const=False)
+    admission.add_argument("--max-active-orders", type=int, default=None,
+                           help="Order portions the kitchen holds before refusing more")
+    admission.add_argument("--max-station-load", type=float, default=None,
+                           help="Tasks on a station per slot before orders for it are refused")
+    admission.add_argument("--max-wait", type=float, default=None,
+                           help="Expected wait in seconds past which new orders are refused")
+    admission.set_defaults(handler=cmd_bench_admission)
+
+    handoffs = bench.add_parser("handoffs", help="Show or adjust how stations accept delegated tasks")
+    handoffs.add_argument("--on", dest="enabled", action="store_const", const=True, default=None)
+    handoffs.add_argument("--off", dest="enabled", action="store_const", const=False)
+    handoffs.add_argument("--capacity", type=int, default=None,
+                          help="Tasks an agent accepts per run before rejecting as over capacity")
+    handoffs.add_argument("--min-skill", type=float, default=None, help="Skill below which an agent rejects a task")
+    handoffs.add_argument("--ack-seconds", type=float, default=None,
+                          help="Simulated seconds a station takes to answer an offer")
+    handoffs.set_defaults(handler=cmd_bench_handoffs)
+
+    judge = bench.add_parser("judge", help="Score a finished run's agent transcripts with a judge model")
+    judge.add_argument("evaluation_id")
+    judge.add_argument("--model", default=None, help="Judge model")
+    judge.add_argument("--timeout", type=float, default=600, help="Seconds to wait for the judge")
+    judge.set_defaults(handler=cmd_bench_judge)
+
+    orders = bench.add_parser("orders", help="Search and filter a run's orders")
+    orders.add_argument("evaluation_id")
+    orders.add_argument("-q", "--search", default=None,
+                        help="Text in the task id, type, agent, table, guest notes or annotations")
+    orders.add_argument("--status", action="append", default=None,
+                        choices=["queued", "held", "in_progress", "completed", "failed", "cancelled"], help="Repeatable")
+    orders.add_argument("--priority", action="append", default=None,
+                        choices=["normal", "expedite", "reassign"], help="Repeatable")
+    orders.add_argument("--type", dest="task_type", action="append", default=None, help="Task type (repeatable)")
+    orders.add_argument("--sort", default="placed_at",
+                        help="placed_at, finished_at, task_id, status, priority or quality_score; prefix '-' for descending")
+    orders.add_argument("--limit", type=int, default=50, help="Rows per page")
+    orders.add_argument("--offset", type=int, default=0)
+    orders.add_argument("--browse", action="store_true", help="Toggle search and filters interactively (? for keys)")
+    orders.set_defaults(handler=cmd_bench_orders)
+
+    order = bench.add_parser("order", help="Show one of a run's orders with its notes")
+    order.add_argument("evaluation_id")
+    order.add_argument("task_id")
+    order.set_defaults(handler=cmd_bench_order)
+
+    timeline = bench.add_parser("timeline", help="Show an order's way from received to served, with stage times")
+    timeline.add_argument("evaluation_id")
+    timeline.add_argument("task_id")
+    timeline.add_argument("--follow", action="store_true", help="Redraw as the order moves, until it is served")
+    timeline.set_defaults(handler=cmd_bench_timeline)
+
+    note = bench.add_parser("note", help="Note a problem with an order in the executing run")
+    note.add_argument("task_id")
+    note.add_argument("reason")
+    note.add_argument("--severity", default="warning", choices=["info", "warning", "critical"])
+    note.add_argument("--author", default="operator", help="Agent name, or who is writing the note")
+    note.set_defaults(handler=cmd_bench_note)
+
+    replay = bench.add_parser("replay", help="Reconstruct run state from the event log")
+    replay.add_argument("evaluation_id")
+    replay.add_argument("--at", type=float, default=None, help="Unix timestamp to replay up to")
+    replay.set_defaults(handler=cmd_bench_replay)
+
+    # tables
+    tables = commands.add_parser("tables", help="Manage dining tables and reservations").add_subparsers(
+        dest="action", required=True)
+    tables.add_parser("list", help="Show tables, reservations and course pacing").set_defaults(
+        handler=cmd_tables_list)
+    tables_add = tables.add_parser("add", help="Add a table")
+    tables_add.add_argument("number", type=int)
+    tables_add.add_argument("capacity", type=int)
+    tables_add.set_defaults(handler=cmd_tables_add)
+    tables_reserve = tables.add_parser("reserve", help="Book a party")
+    tables_reserve.add_argument("name")
+    tables_reserve.add_argument("party_size", type=int)
+    tables_reserve.add_argument("time", help="ISO timestamp, e.g. 2024-06-01T19:30")
+    tables_reserve.set_defaults(handler=cmd_tables_reserve)
+    tables_cancel = tables.add_parser("cancel", help="Cancel a reservation")
+    tables_cancel.add_argument("reservation_id")
+    tables_cancel.set_defaults(handler=cmd_tables_cancel)
+    tables_seat = tables.add_parser("seat", help="Seat a reservation or a walk-in party")
+    tables_seat.add_argument("--reservation", default=None)
+    tables_seat.add_argument("--party-size", type=int, default=None)
+    tables_seat.set_defaults(handler=cmd_tables_seat)
+    tables_status = tables.add_parser("status", help="Move a table on to fired, dessert or paid")
+    tables_status.add_argument("number", type=int)
+    tables_status.add_argument("status", choices=["fired", "dessert", "paid"])
+    tables_status.set_defaults(handler=cmd_tables_status)
+    tables.add_parser("servers", help="List front-of-house servers").set_defaults(handler=cmd_servers_list)
+    server_add = tables.add_parser("add-server", help="Put a server on the floor")
+    server_add.add_argument("name")
+    server_add.add_argument("--tables", type=int, nargs="+", default=None, help="Section; omit to cover every table")
+    server_add.set_defaults(handler=cmd_servers_add)
+    server_remove = tables.add_parser("remove-server", help="Take a server off the floor")
+    server_remove.add_argument("name")
+    server_remove.set_defaults(handler=cmd_servers_remove)
+
+    # kitchen
+    kitchen = commands.add_parser("kitchen", help="Watch the kitchen floor").add_subparsers(
+        dest="action", required=True)
+    stations = kitchen.add_parser("stations", help="Show station load, staff, orders and equipment")
+    stations.add_argument("name", nargs="?", default=None, help="Drill into one station's current orders")
+    stations.add_argument("--watch", action="store_true", help="Refresh until Ctrl-C")
+    stations.add_argument("--interval", dest="poll_interval", type=float, default=None,
+                          help="Seconds between refreshes (default: the refresh_interval setting)")
+    stations.set_defaults(handler=cmd_kitchen_stations)
+    snapshot = kitchen.add_parser("snapshot", help="Show orders, stations, staff, inventory and alerts at once")
+    snapshot.add_argument("--alerts", type=int, default=5, help="Latest alerts to list (default: 5)")
+    snapshot.set_defaults(handler=cmd_kitchen_snapshot)
+
+    # events
+    events = commands.add_parser("events", help="Query the event log").add_subparsers(
+        dest="action", required=True)
+    events_list = events.add_parser("list", help="List events")
+    events_list.add_argument("--run-id", default=None)
+    events_list.add_argument("--agent", default=None)
+    events_list.add_argument("--type", default=None)
+    events_list.add_argument("--limit", type=int, default=100)
+    events_list.add_argument("--archived", action="store_true", help="Include runs moved to the archive")
+    events_list.set_defaults(handler=cmd_events_list)
+
+    # webhooks
+    webhooks = commands.add_parser("webhooks", help="Notify URLs of run and order events (admin)").add_subparsers(
+        dest="action", required=True)
+    webhooks.add_parser("list", help="List registered webhooks").set_defaults(handler=cmd_webhooks_list)
+    webhooks_add = webhooks.add_parser("add", help="Register a URL for signed notifications")
+    webhooks_add.add_argument("url")
+    webhooks_add.add_argument("--secret", default=None, help="HMAC signing key (default: generated and shown once)")
+    webhooks_add.add_argument("--event", dest="events", action="append", default=None,
+                              choices=["order_completed", "run_completed", "quality_failed", "chaos_injected"],
+                              help="Repeat to subscribe to several (default: all)")
+    webhooks_add.add_argument("--min-quality", type=float, default=None,
+                              help="Quality below which an order is a quality failure (default 0.5)")
+    webhooks_add.set_defaults(handler=cmd_webhooks_add)
+    webhooks_remove = webhooks.add_parser("remove", help="Stop notifying a webhook")
+    webhooks_remove.add_argument("webhook_id")
+    webhooks_remove.set_defaults(handler=cmd_webhooks_remove)
+    webhooks_test = webhooks.add_parser("test", help="Send a ping and show how it went (exit 1 if undelivered)")
+    webhooks_test.add_argument("webhook_id")
+    webhooks_test.set_defaults(handler=cmd_webhooks_test)
+    webhooks_deliveries = webhooks.add_parser("deliveries", help="Show a webhook's recent deliveries")
+    webhooks_deliveries.add_argument("webhook_id")
+    webhooks_deliveries.set_defaults(handler=cmd_webhooks_deliveries)
+
+    # alerts
+    alerts = commands.add_parser("alerts", help="Kitchen alerts posted to Slack, Discord or webhooks (admin)").add_subparsers(
+        dest="action", required=True)
+    alerts.add_parser("list", help="Show alert sinks and recent alerts").set_defaults(handler=cmd_alerts_list)
+    alerts_test = alerts.add_parser("test", help="Post a test alert to every sink (exit 1 if any fails)")
+    alerts_test.add_argument("--severity", choices=["info", "warning", "critical"], default="critical")
+    alerts_test.set_defaults(handler=cmd_alerts_test)
+
+    simulator = commands.add_parser("simulator", help="Show or script the mock model's failure rates (admin)")
+    simulator.add_argument("--error-rate", type=float, default=None, help="Share of calls that fail outright")
+    simulator.add_argument("--malformed-rate", type=float, default=None, help="Share of responses that aren't JSON")
+    simulator.add_argument("--hallucination-rate", type=float, default=None,
+                           help="Share asking for an ingredient not on hand")
+    simulator.add_argument("--violation-rate", type=float, default=None,
+                           help="Share using a guest's restricted ingredient")
+    simulator.add_argument("--latency", type=float, default=None, help="Seconds added to every call")
+    simulator.add_argument("--role", default=None, choices=["HEAD_CHEF", "SOUS_CHEF", "CHEF_DE_PARTIE",
+                                                           "LINE_COOK", "PREP_COOK", "KITCHEN_PORTER", "COMMIS"],
+                           help="Set the rates for this role only")
+    simulator.set_defaults(handler=cmd_simulator)
+
+    # transcripts
+    transcripts = commands.add_parser("transcripts", help="Inspect agent prompts and responses").add_subparsers(
+        dest="action", required=True)
+    transcripts_list = transcripts.add_parser("list", help="List recorded transcripts")
+    transcripts_browse = transcripts.add_parser("browse", help="Page through transcripts interactively")
+    for sub in (transcripts_list, transcripts_browse):
+        sub.add_argument("--run-id", default=None)
+        sub.add_argument("--agent", default=None)
+        sub.add_argument("--task", default=None)
+        sub.add_argument("--search", default=None, help="Only transcripts whose prompt or response contains this")
+    transcripts_list.add_argument("--limit", type=int, default=100)
+    transcripts_list.set_defaults(handler=cmd_transcripts_list)
+    transcripts_browse.add_argument("--page-size", type=int, default=20)
+    transcripts_browse.set_defaults(handler=cmd_transcripts_browse)
+    transcripts_show = transcripts.add_parser("show", help="Print one prompt and response in full")
+    transcripts_show.add_argument("transcript_id", type=int)
+    transcripts_show.set_defaults(handler=cmd_transcripts_show)
+
+    # recipes
+    recipes = commands.add_parser("recipes", help="Manage the recipe dataset").add_subparsers(
+        dest="action", required=True)
+    recipes_import = recipes.add_parser("import", help="Import Schema.org JSON-LD/HTML or Paprika recipes")
+    recipes_import.add_argument("file")
+    recipes_import.add_argument("--format", choices=["schema_org", "paprika"], default=None,
+                                help="Defaults to paprika for .paprikarecipe(s) files, else schema_org")
+    recipes_import.add_argument("--dry-run", action="store_true", help="Show what would be imported")
+    recipes_import.set_defaults(handler=cmd_recipes_import)
+
+    # metrics
+    metrics = commands.add_parser("metrics", help="Reports and exports").add_subparsers(
+        dest="action", required=True)
+    report = metrics.add_parser("report", help="Download the markdown report")
+    report.add_argument("--output", "-o", default=None)
+    report.set_defaults(handler=cmd_metrics_report)
+    metrics.add_parser("export", help="Export metrics to CSV on the server").set_defaults(
+        handler=cmd_metrics_export)
+    leaderboard = metrics.add_parser("leaderboard", help="Rank recorded runs under a scoring profile")
+    leaderboard.add_argument("--profile", default="balanced")
+    leaderboard.add_argument("--limit", type=int, default=None)
+    leaderboard.set_defaults(handler=cmd_metrics_leaderboard)
+    scoring = metrics.add_parser("scoring", help="Show scoring profiles, or load custom ones from a file")
+    scoring.add_argument("--file", default=None,
+                         help="YAML or JSON with profiles and scenarios to replace the server's custom ones (admin)")
+    scoring.set_defaults(handler=cmd_metrics_scoring)
+    eod = metrics.add_parser("eod", help="Generate and show the end-of-day report")
+    eod.add_argument("--date", default=None, help="YYYY-MM-DD, defaults to today")
+    eod.add_argument("--saved", action="store_true", help="Show the saved report instead of regenerating it")
+    eod.add_argument("--list", action="store_true", help="List saved reports")
+    eod.set_defaults(handler=cmd_metrics_eod)
+    menu_engineering = metrics.add_parser("menu", help="Classify menu items as stars, plowhorses, puzzles and dogs")
+    menu_engineering.add_argument("--days", type=int, default=None, help="Only sales from the last this many days")
+    menu_engineering.set_defaults(handler=cmd_metrics_menu)
+
+    # macros
+    macro = commands.add_parser("macro", help="Record and replay command sequences").add_subparsers(
+        dest="action", required=True)
+    record = macro.add_parser("record", help="Append a command to a macro")
+    record.add_argument("name")
+    record.add_argument("--repeat", type=int, default=1, help="Record the step this many times")
+    record.add_argument("step", nargs=argparse.REMAINDER, help="-- followed by the command to record")
+    record.set_defaults(handler=cmd_macro_record, local=True)
+    macro.add_parser("list", help="List macros").set_defaults(handler=cmd_macro_list, local=True)
+    for name, handler, help_text in (
+        ("show", cmd_macro_show, "Show a macro's steps"),
+        ("delete", cmd_macro_delete, "Delete a macro"),
+    ):
+        sub = macro.add_parser(name, help=help_text)
+        sub.add_argument("name")
+        sub.set_defaults(handler=handler, local=True)
+    macro_run = macro.add_parser("run", help="Replay a macro")
+    macro_run.add_argument("name")
+    macro_run.add_argument("--keep-going", action="store_true", help="Continue after a failed step")
+    macro_run.set_defaults(handler=cmd_macro_run, local=True)
+
+    # keys
+    commands.add_parser("keys", help="List the shortcuts of every interactive view").set_defaults(
+        handler=cmd_keys, local=True)
+
+    # settings
+    settings = commands.add_parser("settings", help="Show or change saved client settings")
+    settings.add_argument("--reveal", action="store_true", help="Show the auth token in full")
+    settings.set_defaults(handler=cmd_settings_show, local=True)
+    settings_actions = settings.add_subparsers(dest="action")
+    settings_set = settings_actions.add_parser("set", help="Save a setting")
+    settings_set.add_argument("name", choices=list(SETTINGS))
+    settings_set.add_argument("value")
+    settings_set.set_defaults(handler=cmd_settings_set, local=True)
+    settings_unset = settings_actions.add_parser("unset", help="Remove a setting from the file, restoring its default")
+    settings_unset.add_argument("name", choices=list(SETTINGS))
+    settings_unset.set_defaults(handler=cmd_settings_unset, local=True)
+    settings_actions.add_parser("edit", help="Edit every setting interactively").set_defaults(
+        handler=cmd_settings_edit, local=True)
+    settings_actions.add_parser("path", help="Print the settings file's path").set_defaults(
+        handler=cmd_settings_path, local=True)
+
+    # serve
+    serve = commands.add_parser("serve", help="Run the API server")
+    serve.add_argument("--host", default="localhost")
+    serve.add_argument("--port", type=int, default=8000)
+    serve.add_argument("--seed", type=int, default=None)
+    serve.add_argument("--seed-profile", default=None, choices=["empty", "demo", "stress"],
+                       help="Data to start with (default: $CHEFBENCH_SEED_PROFILE or empty)")
+    serve.add_argument("--tls-cert", default=None, help="Server certificate (PEM) to serve HTTPS")
+    serve.add_argument("--tls-key", default=None, help="Private key for --tls-cert")
+    serve.add_argument("--tls-client-ca", default=None, help="CA bundle used to verify client certificates")
+    serve.add_argument("--require-client-cert", action="store_true", default=None,
+                       help="Reject clients without a certificate signed by --tls-client-ca")
+    serve.set_defaults(handler=cmd_serve, local=True)
+
+    return parser
+
+
+def main(argv: Optional[List[str]] = None) -> int:
+    args = build_parser().parse_args(argv)
+
+    try:
+        args.settings = SettingsStore()
+        args.theme = load_theme(args.theme or args.settings.get("theme"), sys.stdout)
+        if getattr(args, "poll_interval", False) is None:
+            args.poll_interval = args.settings.get("refresh_interval")
+    except ValueError as e:
+        print(f"error: settings: {e}", file=sys.stderr)
+        return 1
+
+    if getattr(args, "local", False):
+        try:
+            output = args.handler(None, args)
+        except (KeyError, ValueError) as e:
+            print(f"error: {e}", file=sys.stderr)
+            return 1
+        if output is not None:
+            _print_json(output)
+        return 0
+
+    try:
+        overrides = {"base_url": args.api_url, "admin_token": args.token}
+        with ChefBenchClient.from_settings(
+            args.settings,
+            **{key: value for key, value in overrides.items() if value},
+            timeout=args.timeout,
+            verify=args.ca_cert or True,
+            cert=(args.client_cert, args.client_key) if args.client_key else args.client_cert,
+            session_id=args.session
+        ) as api:
+            output = args.handler(api, args)
+    except ChefBenchClientError as e:
+        if args.json:
+            _print_json({"error": str(e)})
+        else:
+            print(f"error: {e}", file=sys.stderr)
+        return 1
+
+    if output is not None:
+        _print_json(output)
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -0,0 +1,61 @@
+"""
+CLI Desktop Notifications
+Alert the user in another window when runs finish or critical kitchen events fire
+"""
+
+import os
+import platform
+import shutil
+import subprocess
+import sys
+from typing import Optional
+
+# Event types worth interrupting the user for
+CRITICAL_EVENTS = {"scenario_failed", "equipment_failed", "agent_paused"}
+
+NOTIFY_METHODS = ("auto", "osc", "system", "bell")
+
+
+def _osc(title: str, body: str) -> bool:
+    """Terminal notification escape codes (OSC 777 for VTE/kitty/WezTerm, OSC 9 for iTerm2/Windows Terminal)"""
+    if not sys.stderr.isatty():
+        return False
+    clean = lambda text: text.replace("\x1b", "").replace("\x07", "").replace(";", ",")
+    if os.environ.get("TERM_PROGRAM") in ("iTerm.app", "WezTerm") or "WT_SESSION" in os.environ:
+        sys.stderr.write(f"\x1b]9;{clean(title)}: {clean(body)}\x07")
+    else:
+        sys.stderr.write(f"\x1b]777;notify;{clean(title)};{clean(body)}\x07")
+    sys.stderr.flush()
+    return True
+
+
+def _system(title: str, body: str) -> bool:
+    """Native notifier: notify-send on Linux, osascript on macOS"""
+    system = platform.system()
+    if system == "Linux" and shutil.which("notify-send"):
+        command = ["notify-send", "--app-name=escoffier", title, body]
+    elif system == "Darwin" and shutil.which("osascript"):
+        script = f'display notification {body!r} with title {title!r}'.replace("'", '"')
+        command = ["osascript", "-e", script]
+    else:
+        return False
+    try:
+        return subprocess.run(command, timeout=5, capture_output=True).returncode == 0
+    except (OSError, subprocess.SubprocessError):
+        return False
+
+
+def _bell(title: str, body: str) -> bool:
+    sys.stderr.write("\a")
+    sys.stderr.flush()
+    return True
+
+
+def notify(title: str, body: str, method: str = "auto") -> Optional[str]:
+    """Send a desktop notification, returning the method that worked"""
+    methods = {"osc": _osc, "system": _system, "bell": _bell}
+    order = ["system", "osc", "bell"] if method == "auto" else [method]
+    for name in order:
+        if methods[name](title, body):
+            return name
+    return None
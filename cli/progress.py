@@ -0,0 +1,120 @@
+"""
+CLI Live Progress Panel
+Redraws a run's progress in place from the server's progress stream
+"""
+
+import sys
+from collections import deque
+from typing import Any, Dict, Iterable, List, Optional, TextIO
+
+from .layout import clip, terminal_size
+from .theme import Theme, load_theme
+
+BAR_WIDTH = 30
+MIN_BAR_WIDTH = 10
+RECENT_DECISIONS = 5
+
+# Below this the panel can't show a run's status legibly, so it says so instead
+MIN_COLUMNS = 50
+HEADER_WIDTH = 36  # the header line apart from its bar
+
+
+def _bar(percent: float, width: int = BAR_WIDTH) -> str:
+    filled = int(width * percent / 100)
+    return "[" + "#" * filled + "-" * (width - filled) + "]"
+
+
+class ProgressPanel:
+    """A few lines of run status, redrawn in place on a terminal
+
+    Without a terminal (pipes, CI logs) each snapshot is printed as one line
+    instead, so the output stays readable. Outcomes carry a symbol as well as
+    the theme's color, so they can be told apart without color.
+
+    The terminal is measured on every redraw: the bar narrows and lines are
+    cut to the window's width, since a wrapped line would throw off the
+    redraw, and a window too small for the panel gets a notice instead.
+    """
+
+    def __init__(self, evaluation_id: str, out: TextIO = sys.stdout, theme: Optional[Theme] = None):
+        self.evaluation_id = evaluation_id
+        self.out = out
+        self.live = out.isatty()
+        self.theme = theme or load_theme("auto", out)
+        self.snapshot: Dict[str, Any] = {}
+        self.recent = deque(maxlen=RECENT_DECISIONS)
+        self._drawn = 0
+
+    def update(self, frame: Dict[str, Any]):
+        """Apply one progress frame and redraw"""
+        event, data = frame.get("event"), frame.get("data", {})
+        if event == "decision":
+            outcome = self.theme.mark("ok  ", "success") if data.get("success") else self.theme.mark("FAIL", "error")
+            quality = data.get("quality")
+            quality = f"q={quality:.2f}" if isinstance(quality, (int, float)) else ""
+            self.recent.append(f"  {outcome} {data.get('agent') or '?':<18} {data.get('task_type') or '':<24} {quality}")
+            return
+        if event == "chaos":
+            params = ", ".join(f"{k}={v}" for k, v in data.items() if k != "action")
+            self.recent.append(f"  {self.theme.mark('CHAOS', 'warning')} {data.get('action')} {params}")
+            return
+        if event == "status" and self.snapshot:
+            self.snapshot["status"] = data.get("status")
+        elif event == "snapshot":
+            self.snapshot = data
+        else:
+            return
+        self._draw()
+
+    def _lines(self, columns: Optional[int] = None) -> List[str]:
+        s = self.snapshot
+        bar_width = BAR_WIDTH if columns is None else max(MIN_BAR_WIDTH, min(BAR_WIDTH, columns - HEADER_WIDTH))
+        executed = s.get("tasks_completed", 0) + s.get("tasks_failed", 0)
+        status = self.theme.status(f"{s.get('status', '?'):<9}")
+        lines = [
+            f"Run {self.evaluation_id[:8]}  {status} "
+            f"{_bar(s.get('percent_complete', 0.0), bar_width)} {s.get('percent_complete', 0.0):5.1f}%",
+            f"  tasks {executed}/{s.get('total_tasks', 0)} ({s.get('tasks_failed', 0)} failed, "
+            f"{s.get('tasks_pending', 0)} pending)  success {s.get('success_rate', 0.0):.3f}  "
+            f"quality {s.get('average_quality', 0.0):.3f}  messages {s.get('messages', 0)}",
+        ]
+        return lines + list(self.recent)
+
+    def _draw(self):
+        if not self.live:
+            self.out.write(self._lines()[0] + "\n")
+            self.out.flush()
+            return
+        columns, rows = terminal_size(self.out) or (None, None)
+        lines = self._lines(columns)
+        if columns is not None:
+            if columns < MIN_COLUMNS or rows < len(lines) + 1:
+                lines = [clip(
+                    f"Terminal too small for the progress panel ({columns}x{rows}, "
+                    f"need {MIN_COLUMNS}x{len(lines) + 1})", columns - 1
+                )]
+            else:
+                # The last column is left free so no line wraps
+                lines = [clip(line, columns - 1) for line in lines]
+        if self._drawn:
+            # Move back over the previous panel and clear it
+            self.out.write(f"\x1b[{self._drawn}F\x1b[J")
+        self.out.write("\n".join(lines) + "\n")
+        self.out.flush()
+        self._drawn = len(lines)
+
+
+def follow(
+    evaluation_id: str,
+    frames: Iterable[Dict[str, Any]],
+    out: TextIO = sys.stdout,
+    theme: Optional[Theme] = None
+) -> Optional[str]:
+    """Render frames until the stream is done, returning the run's final status"""
+    panel = ProgressPanel(evaluation_id, out, theme)
+    status = None
+    for frame in frames:
+        panel.update(frame)
+        if frame.get("event") == "done":
+            status = frame["data"].get("status")
+    return status
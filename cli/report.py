@@ -0,0 +1,302 @@
+"""
+CLI Run Reports
+A finished run's results as a styled terminal report, markdown or JSON: role metrics, ticket times, service periods,
+failures and cost
+"""
+
+import json
+from collections import defaultdict
+from typing import Any, Dict, List, Optional
+
+from .layout import clip, fit_columns, visible_len
+from .theme import Theme, SYMBOLS
+
+REPORT_FORMATS = ("text", "json", "markdown")
+
+SPARK_CHARS = "▁▂▃▄▅▆▇█"
+ASCII_SPARK_CHARS = "_.-=+*#@"
+
+HISTOGRAM_BINS = 8
+HISTOGRAM_WIDTH = 30
+TOP_FAILURES = 5
+
+ROLE_COLUMNS = ["role", "agents", "tasks", "success_rate", "avg_quality", "labor_cost", "task_errors"]
+PERIOD_COLUMNS = ["period", "tickets_served", "covers", "revenue", "average_ticket", "table_turn_seconds", "seat_turns"]
+MONEY_COLUMNS = {"labor_cost", "revenue", "average_ticket"}
+
+
+def sparkline(values: List[float], chars: str = SPARK_CHARS) -> str:
+    """One character per value, its height scaled between the smallest and largest"""
+    if not values:
+        return ""
+    low, high = min(values), max(values)
+    span = high - low
+    return "".join(
+        chars[int((v - low) / span * (len(chars) - 1)) if span else len(chars) // 2]
+        for v in values
+    )
+
+
+def histogram(values: List[float], bins: int = HISTOGRAM_BINS) -> List[Dict[str, Any]]:
+    """Counts of values in equal-width bins from the smallest to the largest"""
+    if not values:
+        return []
+    low, high = min(values), max(values)
+    if high == low:
+        return [{"low": low, "high": high, "count": len(values)}]
+    width = (high - low) / bins
+    counts = [0] * bins
+    for v in values:
+        counts[min(int((v - low) / width), bins - 1)] += 1
+    return [
+        {"low": round(low + i * width, 2), "high": round(low + (i + 1) * width, 2), "count": count}
+        for i, count in enumerate(counts)
+    ]
+
+
+def percentile(values: List[float], p: float) -> Optional[float]:
+    """Nearest-rank percentile"""
+    if not values:
+        return None
+    ordered = sorted(values)
+    return ordered[min(len(ordered) - 1, max(0, int(round(p / 100 * len(ordered) + 0.5)) - 1))]
+
+
+def build_report(evaluation_id: str, results: Dict[str, Any]) -> Dict[str, Any]:
+    """Boil a run's results down to what the report shows"""
+    metrics = results.get("agent_metrics", {})
+    team = metrics.get("team", {})
+    history = results.get("execution_history", [])
+
+    # Agents grouped by role, their task counts weighting the rates
+    roles: Dict[str, Dict[str, Any]] = {}
+    for agent in metrics.get("agents", {}).values():
+        role = roles.setdefault(agent["role"], {
+            "role": agent["role"], "agents": 0, "tasks": 0, "successes": 0.0, "quality": 0.0,
+            "labor_cost": 0.0, "task_errors": 0
+        })
+        tasks = agent.get("tasks_completed", 0)
+        role["agents"] += 1
+        role["tasks"] += tasks
+        role["successes"] += agent.get("success_rate", 0) * tasks
+        role["quality"] += agent.get("avg_quality", 0) * agent.get("success_rate", 0) * tasks
+        role["labor_cost"] += agent.get("labor_cost", 0.0)
+        role["task_errors"] += agent.get("task_errors", 0)
+    by_role = []
+    for role in sorted(roles.values(), key=lambda r: -r["tasks"]):
+        successes = role.pop("successes")
+        quality = role.pop("quality")
+        by_role.append({
+            **role,
+            "success_rate": round(successes / role["tasks"], 4) if role["tasks"] else None,
+            "avg_quality": round(quality / successes, 4) if successes else None,
+            "labor_cost": round(role["labor_cost"], 2)
+        })
+
+    # Order ticket times, or each task's own time when the run had no orders
+    tickets = list((metrics.get("tickets") or {}).values())
+    source = "tickets"
+    if not tickets:
+        tickets = [e.get("execution_time", 0.0) for e in history]
+        source = "task_seconds"
+
+    # Failed tasks by type, and the errors agents ran into, most frequent first
+    failed: Dict[str, List[str]] = defaultdict(list)
+    for e in history:
+        if not e.get("success"):
+            failed[e["task_type"]].append(e["agent_name"])
+    failures = [
+        {"kind": "failed_task", "what": task_type, "count": len(agents), "detail": ", ".join(sorted(set(agents)))}
+        for task_type, agents in failed.items()
+    ]
+    errors: Dict[tuple, List[str]] = defaultdict(list)
+    for error in (metrics.get("reliability") or {}).get("task_errors", []):
+        errors[(error["error_class"], error["error"])].append(error["agent_name"])
+    failures += [
+        {"kind": "task_error", "what": f"{error_class}: {message}", "count": len(agents),
+         "detail": ", ".join(sorted(set(agents)))}
+        for (error_class, message), agents in errors.items()
+    ]
+    failures.sort(key=lambda f: -f["count"])
+
+    # Service periods, left out when the run took no orders
+    periods = results.get("service_periods") or {}
+    service_periods = []
+    if (periods.get("total") or {}).get("tickets"):
+        service_periods = [p for p in periods["periods"] if p["tickets"]] + [periods["total"]]
+
+    labor = metrics.get("labor") or {}
+    order_costs = results.get("order_costs") or {}
+    total_usage = (results.get("usage") or {}).get("total") or {}
+    scores = results.get("scores") or {}
+    return {
+        "evaluation_id": evaluation_id,
+        "tasks_completed": results.get("tasks_completed"),
+        "total_tasks": results.get("total_tasks"),
+        "duration": results.get("duration"),
+        "score": scores.get("score"),
+        "profile": scores.get("profile"),
+        "dimensions": scores.get("dimensions") or {},
+        "team": {
+            key: team.get(key)
+            for key in ("overall_success_rate", "average_quality", "hierarchy_compliance", "coordination_score",
+                        "reliability", "foh_coordination")
+            if team.get(key) is not None
+        },
+        "by_role": by_role,
+        "ticket_times": {
+            "source": source,
+            "count": len(tickets),
+            "values": [round(t, 2) for t in tickets],
+            "min": round(min(tickets), 2) if tickets else None,
+            "p50": percentile(tickets, 50),
+            "p90": percentile(tickets, 90),
+            "max": round(max(tickets), 2) if tickets else None,
+            "histogram": histogram(tickets)
+        },
+        "service_periods": service_periods,
+        "failures": failures[:TOP_FAILURES],
+        "cost": {
+            "labor_cost": labor.get("total_cost", team.get("labor_cost")),
+            "cost_per_successful_task": team.get("cost_per_successful_task"),
+            "food_cost": order_costs.get("food_cost") if order_costs.get("tickets") else None,
+            "revenue": order_costs.get("revenue") if order_costs.get("tickets") else None,
+            "llm_tokens": total_usage.get("total_tokens"),
+            "llm_calls": total_usage.get("calls"),
+            "llm_cost": total_usage.get("cost")
+        }
+    }
+
+
+def _fmt(value: Any, places: int = 3) -> str:
+    """Floats to a fixed number of places, missing values as a dash"""
+    if value is None:
+        return "-"
+    return f"{value:.{places}f}" if isinstance(value, float) else str(value)
+
+
+def _table(rows: List[Dict[str, Any]], columns: List[str], width: Optional[int]) -> List[str]:
+    cells = [{col: _fmt(row.get(col), 2 if col in MONEY_COLUMNS else 3) for col in columns} for row in rows]
+    widths = {col: max(len(col), *(visible_len(c[col]) for c in cells)) for col in columns}
+    widths = fit_columns(widths, width - 2 if width else None)
+
+    def line(values: Dict[str, str]) -> str:
+        return "  " + "  ".join(clip(values[col], widths[col]).ljust(widths[col]) for col in columns)
+
+    return [line({col: col.upper() for col in columns})] + [line(c) for c in cells]
+
+
+def render_text(report: Dict[str, Any], theme: Theme, width: Optional[int] = None) -> str:
+    """The report for a terminal, in the theme's colors and symbols"""
+    unicode = theme.symbols.get("success") == SYMBOLS["success"]
+    bar = "█" if unicode else "#"
+    lines = [theme.paint(f"Run report {report['evaluation_id']}", "info")]
+    summary = f"  tasks {report['tasks_completed']}/{report['total_tasks']}"
+    if report["duration"] is not None:
+        summary += f" in {report['duration']:.1f}s"
+    if report["score"] is not None:
+        summary += f", score {report['score']:.3f} ({report['profile']})"
+    lines.append(summary)
+    if report["dimensions"]:
+        lines.append("  " + "  ".join(f"{name} {value:.2f}" for name, value in report["dimensions"].items()))
+    if report["team"]:
+        lines.append("  " + "  ".join(f"{name} {_fmt(value)}" for name, value in report["team"].items()))
+
+    lines += ["", theme.paint("By role", "info")]
+    lines += _table(report["by_role"], ROLE_COLUMNS, width) if report["by_role"] else ["  (none)"]
+
+    tickets = report["ticket_times"]
+    label = "Ticket times" if tickets["source"] == "tickets" else "Task times (no orders)"
+    lines += ["", theme.paint(f"{label}, {tickets['count']} samples", "info")]
+    if tickets["count"]:
+        lines.append(f"  min {tickets['min']:.1f}s  p50 {tickets['p50']:.1f}s  p90 {tickets['p90']:.1f}s  "
+                     f"max {tickets['max']:.1f}s")
+        lines.append("  " + sparkline(tickets["values"], SPARK_CHARS if unicode else ASCII_SPARK_CHARS))
+        peak = max(b["count"] for b in tickets["histogram"])
+        for b in tickets["histogram"]:
+            filled = round(HISTOGRAM_WIDTH * b["count"] / peak) if peak else 0
+            span = f"{b['low']:.1f}-{b['high']:.1f}s"
+            lines.append(f"  {span:>15} {theme.paint(bar * filled, 'muted')} {b['count']}")
+
+    if report["service_periods"]:
+        lines += ["", theme.paint("Service periods", "info")]
+        lines += _table(report["service_periods"], PERIOD_COLUMNS, width)
+
+    lines += ["", theme.paint("Top failures", "info")]
+    if report["failures"]:
+        for failure in report["failures"]:
+            lines.append("  " + theme.mark(f"{failure['count']}x {failure['what']}", "error")
+                         + theme.paint(f"  {failure['detail']}", "muted"))
+    else:
+        lines.append("  " + theme.mark("none", "success"))
+
+    cost = report["cost"]
+    lines += ["", theme.paint("Cost", "info")]
+    lines.append(f"  labor ${_fmt(cost['labor_cost'], 2)}, ${_fmt(cost['cost_per_successful_task'], 2)} "
+                 f"per successful task")
+    if cost["food_cost"] is not None:
+        lines.append(f"  orders: food ${cost['food_cost']:.2f}, revenue ${cost['revenue']:.2f}")
+    if cost["llm_tokens"] is not None:
+        lines.append(f"  LLM {cost['llm_tokens']} tokens over {cost['llm_calls']} calls, est. ${cost['llm_cost']:.4f}")
+    return "\n".join(clip(line, width) if width else line for line in lines)
+
+
+def render_markdown(report: Dict[str, Any]) -> str:
+    """The report as GitHub-flavored markdown, for papers and READMEs"""
+
+    def table(rows: List[Dict[str, Any]], columns: List[str]) -> List[str]:
+        return [
+            "| " + " | ".join(columns) + " |",
+            "|" + "|".join(" --- " for _ in columns) + "|",
+        ] + [
+            "| " + " | ".join(_fmt(row.get(col), 2 if col in MONEY_COLUMNS else 3) for col in columns) + " |"
+            for row in rows
+        ]
+
+    lines = [f"## Run {report['evaluation_id']}", ""]
+    lines.append(f"- Tasks: {report['tasks_completed']}/{report['total_tasks']}")
+    if report["score"] is not None:
+        lines.append(f"- Score: {report['score']:.3f} ({report['profile']})")
+    for name, value in {**report["dimensions"], **report["team"]}.items():
+        lines.append(f"- {name}: {_fmt(value)}")
+
+    lines += ["", "### By role", ""]
+    lines += table(report["by_role"], ROLE_COLUMNS) if report["by_role"] else ["(none)"]
+
+    tickets = report["ticket_times"]
+    lines += ["", "### Ticket times" if tickets["source"] == "tickets" else "### Task times", ""]
+    if tickets["count"]:
+        lines.append(f"`{sparkline(tickets['values'])}` min {tickets['min']:.1f}s, p50 {tickets['p50']:.1f}s, "
+                     f"p90 {tickets['p90']:.1f}s, max {tickets['max']:.1f}s over {tickets['count']} samples")
+        lines.append("")
+        lines += table(
+            [{"seconds": f"{b['low']:.1f}-{b['high']:.1f}", "count": b["count"]} for b in tickets["histogram"]],
+            ["seconds", "count"]
+        )
+    else:
+        lines.append("(none)")
+
+    if report["service_periods"]:
+        lines += ["", "### Service periods", ""]
+        lines += table(report["service_periods"], PERIOD_COLUMNS)
+
+    lines += ["", "### Top failures", ""]
+    lines += table(report["failures"], ["what", "count", "detail"]) if report["failures"] else ["None"]
+
+    cost = report["cost"]
+    lines += ["", "### Cost", ""]
+    lines.append(f"- Labor: ${_fmt(cost['labor_cost'], 2)} (${_fmt(cost['cost_per_successful_task'], 2)} per successful task)")
+    if cost["food_cost"] is not None:
+        lines.append(f"- Orders: food ${cost['food_cost']:.2f}, revenue ${cost['revenue']:.2f}")
+    if cost["llm_tokens"] is not None:
+        lines.append(f"- LLM: {cost['llm_tokens']} tokens over {cost['llm_calls']} calls, est. ${cost['llm_cost']:.4f}")
+    return "\n".join(lines) + "\n"
+
+
+def render_report(report: Dict[str, Any], output: str, theme: Theme, width: Optional[int] = None) -> str:
+    """The report in one of REPORT_FORMATS"""
+    if output == "json":
+        return json.dumps(report, indent=2, default=str)
+    if output == "markdown":
+        return render_markdown(report)
+    return render_text(report, theme, width)
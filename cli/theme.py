@@ -0,0 +1,92 @@
+"""
+CLI Themes
+Color palettes and status symbols, so output reads the same with or without color
+"""
+
+import os
+from dataclasses import dataclass, field
+from typing import Dict, Optional, TextIO
+
+from client import THEMES
+
+# SGR codes per style; no-color has none, so nothing but symbols marks a status
+PALETTES = {
+    "dark": {"success": "32", "error": "31", "warning": "33", "info": "36", "muted": "90"},
+    "light": {"success": "32", "error": "31", "warning": "35", "info": "34", "muted": "90"},
+    # Bold bright colors, with blue rather than green for success so it stays apart from red for red-green colorblindness
+    "high-contrast": {"success": "1;94", "error": "1;91", "warning": "1;93", "info": "1;97"},
+    "no-color": {},
+}
+
+SYMBOLS = {"success": "✓", "error": "✗", "warning": "!", "info": "•", "muted": "·"}
+ASCII_SYMBOLS = {"success": "+", "error": "x", "warning": "!", "info": "*", "muted": "-"}
+
+STATUS_STYLES = {
+    "ok": "success", "ready": "success", "completed": "success", "delivered": "success", "operational": "success", "healthy": "success",
+    "info": "info", "running": "info", "started": "info", "queued": "info", "pending": "info", "in_progress": "info",
+    "warning": "warning", "paused": "warning", "degraded": "warning", "maintenance": "warning", "high": "warning", "low": "warning", "chaos": "warning", "half_open": "warning",
+    "critical": "error", "failed": "error", "fail": "error", "down": "error", "broken": "error", "sensor_failed": "error", "cancelled": "error", "open": "error", "exceeded": "error",
+}
+
+
+def supports_color(out: TextIO) -> bool:
+    """Whether out is a terminal that shows color, honoring NO_COLOR and FORCE_COLOR"""
+    if os.environ.get("NO_COLOR"):
+        return False
+    if os.environ.get("FORCE_COLOR"):
+        return True
+    isatty = getattr(out, "isatty", None)
+    return bool(isatty and isatty()) and os.environ.get("TERM") != "dumb"
+
+
+def _light_background() -> bool:
+    # COLORFGBG is "fg;bg" in rxvt, Konsole and others; 7 and 15 are white backgrounds
+    background = os.environ.get("COLORFGBG", "").split(";")[-1]
+    return background in ("7", "15")
+
+
+def _supports_unicode(out: TextIO) -> bool:
+    try:
+        "".join(SYMBOLS.values()).encode(getattr(out, "encoding", None) or "ascii")
+    except (UnicodeEncodeError, LookupError):
+        return False
+    return True
+
+
+@dataclass(frozen=True)
+class Theme:
+    """A palette plus the symbols printed alongside each style"""
+    name: str
+    palette: Dict[str, str] = field(default_factory=dict)
+    symbols: Dict[str, str] = field(default_factory=lambda: dict(SYMBOLS))
+
+    def paint(self, text: str, style: str) -> str:
+        code = self.palette.get(style)
+        return f"\x1b[{code}m{text}\x1b[0m" if code else text
+
+    def mark(self, text: str, style: str) -> str:
+        """Text behind its style's symbol, colored if the palette has one"""
+        return self.paint(f"{self.symbols.get(style, ' ')} {text}", style)
+
+    def status(self, status: str, text: Optional[str] = None) -> str:
+        """A status word, or text standing for it, with a symbol and color for what it means"""
+        return self.mark(status if text is None else text, STATUS_STYLES.get(str(status).strip().lower(), "muted"))
+
+
+def load_theme(name: Optional[str] = "auto", out: Optional[TextIO] = None) -> Theme:
+    """The named theme, or for "auto" the one suiting out's terminal
+
+    "auto" picks no-color when out isn't a color terminal (pipes, CI logs) and
+    light or dark from COLORFGBG otherwise. Symbols fall back to ASCII when
+    out's encoding can't print them.
+    """
+    name = name or "auto"
+    if name not in THEMES:
+        raise ValueError(f"Unknown theme '{name}', expected one of {', '.join(THEMES)}")
+    if name == "auto":
+        if out is not None and supports_color(out):
+            name = "light" if _light_background() else "dark"
+        else:
+            name = "no-color"
+    symbols = SYMBOLS if out is None or _supports_unicode(out) else ASCII_SYMBOLS
+    return Theme(name, PALETTES[name], dict(symbols))
@@ -0,0 +1,41 @@
+"""
+Python client for the ChefBench REST API
+"""
+
+from .api_client import ChefBenchClient, DEFAULT_BASE_URL
+from .resilience import RetryPolicy, CircuitBreaker
+from .settings import SETTINGS, THEMES, MOCK_MODEL, DEFAULT_CONFIG_DIR, SettingsStore
+from .errors import (
+    ChefBenchClientError,
+    ClientConnectionError,
+    CircuitOpenError,
+    APIError,
+    BadRequestError,
+    NotFoundError,
+    ValidationError,
+    ConflictError,
+    ThrottledError,
+    ServerError
+)
+
+__all__ = [
+    "ChefBenchClient",
+    "DEFAULT_BASE_URL",
+    "SETTINGS",
+    "THEMES",
+    "MOCK_MODEL",
+    "DEFAULT_CONFIG_DIR",
+    "SettingsStore",
+    "RetryPolicy",
+    "CircuitBreaker",
+    "ChefBenchClientError",
+    "ClientConnectionError",
+    "CircuitOpenError",
+    "APIError",
+    "BadRequestError",
+    "NotFoundError",
+    "ValidationError",
+    "ConflictError",
+    "ThrottledError",
+    "ServerError"
+]
@@ -0,0 +1,1541 @@
+"""
+ChefBench API Client
+Typed Python client for the ChefBench REST API, shared by the CLI and external users
+"""
+
+import time
+import uuid
+import logging
+import json
+from typing import Callable, Dict, Iterator, List, Optional, Tuple, Union, Any
+
+import httpx
+
+from .errors import CircuitOpenError, ClientConnectionError, ServerError, error_for_status
+from .resilience import RetryPolicy, CircuitBreaker
+from .settings import DEFAULT_BASE_URL, SettingsStore
+
+logger = logging.getLogger(__name__)
+
+
+def _without_none(body: Dict[str, Any]) -> Dict[str, Any]:
+    """Drop unset fields so the server (or a scenario bundle) supplies their defaults"""
+    return {k: v for k, v in body.items() if v is not None}
+
+
+class ChefBenchClient:
+    """Client for the ChefBench API server"""
+
+    ONLINE = "online"
+    OFFLINE = "offline"
+    RECONNECTING = "reconnecting"
+
+    def __init__(
+        self,
+        base_url: str = DEFAULT_BASE_URL,
+        timeout: float = 30.0,
+        retry_policy: Optional[RetryPolicy] = None,
+        circuit_breaker: Optional[CircuitBreaker] = None,
+        probe_timeout: float = 2.0,
+        on_status_change: Optional[Callable[[str], None]] = None,
+        transport: Optional[httpx.BaseTransport] = None,
+        admin_token: Optional[str] = None,
+        verify: Union[bool, str] = True,
+        cert: Optional[Union[str, Tuple[str, str]]] = None,
+        session_id: Optional[str] = None
+    ):
+        self.base_url = base_url.rstrip("/")
+        self.timeout = timeout
+        self.retry_policy = retry_policy or RetryPolicy()
+        self.circuit_breaker = circuit_breaker or CircuitBreaker()
+        self.probe_timeout = probe_timeout
+        self.on_status_change = on_status_change
+        self.circuit_breaker.on_state_change = self._breaker_changed
+        self._http = httpx.Client(
+            base_url=self.base_url,
+            timeout=timeout,
+            transport=transport,
+            headers=self._default_headers(admin_token, session_id),
+            verify=verify,
+            cert=cert
+        )
+
+    @classmethod
+    def from_settings(cls, settings: Optional[SettingsStore] = None, **kwargs) -> "ChefBenchClient":
+        """A client configured from the settings file and environment; keyword arguments win"""
+        settings = settings or SettingsStore()
+        kwargs.setdefault("base_url", settings.get("api_url"))
+        kwargs.setdefault("admin_token", settings.get("auth_token"))
+        return cls(**kwargs)
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *exc_info):
+        self.close()
+
+    def close(self):
+        """Close the underlying HTTP connection pool"""
+        self._http.close()
+
+    @staticmethod
+    def _default_headers(admin_token: Optional[str], session_id: Optional[str]) -> Dict[str, str]:
+        headers = {}
+        if admin_token:
+            headers["X-Admin-Token"] = admin_token
+        if session_id:
+            # Isolates agents and evaluations in a server-side sandbox
+            headers["X-Session-ID"] = session_id
+        return headers
+
+    @property
+    def status(self) -> str:
+        """Connection status for display: online, offline or reconnecting"""
+        return {
+            CircuitBreaker.CLOSED: self.ONLINE,
+            CircuitBreaker.OPEN: self.OFFLINE,
+            CircuitBreaker.HALF_OPEN: self.RECONNECTING
+        }[self.circuit_breaker.state]
+
+    @property
+    def is_online(self) -> bool:
+        return self.status == self.ONLINE
+
+    def _breaker_changed(self, previous: str, state: str):
+        if self.on_status_change:
+            self.on_status_change(self.status)
+
+    def probe(self) -> bool:
+        """Ping the server outside the retry loop and update the breaker"""
+        try:
+            response = self._http.request("GET", "/", timeout=self.probe_timeout)
+        except httpx.TransportError:
+            self.circuit_breaker.record_failure()
+            return False
+
+        if response.status_code >= 500:
+            self.circuit_breaker.record_failure()
+            return False
+
+        self.circuit_breaker.record_success()
+        return True
+
+    def _request(
+        self,
+        method: str,
+        path: str,
+        json: Optional[Any] = None,
+        params: Optional[Dict[str, Any]] = None,
+        timeout: Optional[float] = None,
+        raw: bool = False,
+        idempotency_key: Optional[str] = None
+    ) -> Any:
+        """Send a request with backoff retries behind the circuit breaker
+
+        Connection failures and 5xx responses are retried; other error
+        statuses raise immediately since retrying cannot change them. Every
+        attempt of a POST or PATCH carries the same Idempotency-Key, so a retry of a
+        request the server already handled replays its response instead of
+        repeating it.
+        """
+        if not self.circuit_breaker.allow_request():
+            raise CircuitOpenError(self.circuit_breaker.retry_after())
+
+        if self.circuit_breaker.should_probe() and not self.probe():
+            raise CircuitOpenError(self.circuit_breaker.retry_after())
+
+        attempts = self.retry_policy.max_retries + 1
+        last_error: Optional[Exception] = None
+        headers = None
+        if method in ("POST", "PATCH"):
+            headers = {"Idempotency-Key": idempotency_key or str(uuid.uuid4())}
+
+        for attempt in range(attempts):
+            try:
+                response = self._http.request(
+                    method,
+                    path,
+                    json=json,
+                    params=params,
+                    headers=headers,
+                    timeout=timeout if timeout is not None else self.timeout
+                )
+            except httpx.TransportError as e:
+                last_error = ClientConnectionError(f"{method} {path}: {e}")
+            else:
+                error = error_for_status(response.status_code, method=method, path=path, **self._error_body(response))
+                if not isinstance(error, ServerError):
+                    self.circuit_breaker.record_success()
+                    if error is not None:
+                        raise error
+                    return response.content if raw else response.json()
+                last_error = error
+
+            self.circuit_breaker.record_failure()
+            if not self.circuit_breaker.allow_request():
+                break
+
+            if attempt < attempts - 1:
+                delay = self.retry_policy.delay(attempt)
+                logger.debug(f"Retrying {method} {path} in {delay:.2f}s after error: {last_error}")
+                time.sleep(delay)
+
+        raise last_error
+
+    @staticmethod
+    def _error_body(response: httpx.Response) -> Dict[str, Any]:
+        """Unpack the server's {code, message, details} error envelope"""
+        if response.status_code < 400:
+            return {"detail": None}
+        try:
+            body = response.json()
+        except ValueError:
+            return {"detail": response.text}
+        if not isinstance(body, dict):
+            return {"detail": body}
+        return {
+            "detail": body.get("message", body.get("detail", response.text)),
+            "code": body.get("code"),
+            "details": body.get("details")
+        }
+
+    # System
+
+    def ping(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get server name, version and component status"""
+        return self._request("GET", "/", timeout=timeout)
+
+    def healthz(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get liveness status"""
+        return self._request("GET", "/healthz", timeout=timeout)
+
+    def readyz(self, checks: Optional[List[str]] = None, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get per-dependency readiness
+
+        Sent once outside the retry loop: a 503 here is an answer, not a failure.
+        """
+        try:
+            response = self._http.request(
+                "GET",
+                "/readyz",
+                params={"check": checks} if checks else None,
+                timeout=timeout if timeout is not None else self.probe_timeout * 2
+            )
+        except httpx.TransportError as e:
+            raise ClientConnectionError(f"GET /readyz: {e}")
+
+        if response.status_code not in (200, 503):
+            raise error_for_status(response.status_code, method="GET", path="/readyz", **self._error_body(response))
+        return response.json()
+
+    def reset(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Reset the entire system"""
+        return self._request("DELETE", "/reset", timeout=timeout)
+
+    def get_seed_profiles(self) -> Dict[str, Any]:
+        """List seed profiles and the seeders they are built from"""
+        return self._request("GET", "/seed/profiles")
+
+    def apply_seed_profile(self, profile: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Add a seed profile's agents, recipes and staffing data to the session"""
+        return self._request("POST", f"/seed/{profile}", timeout=timeout)
+
+    # Admin
+
+    def get_faults(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get active fault injection settings and counters"""
+        return self._request("GET", "/admin/faults", timeout=timeout)
+
+    def set_faults(self, timeout: Optional[float] = None, **settings) -> Dict[str, Any]:
+        """Enable or adjust server-side fault injection"""
+        return self._request("PUT", "/admin/faults", json=settings, timeout=timeout)
+
+    def clear_faults(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Disable all server-side fault injection"""
+        return self._request("DELETE", "/admin/faults", timeout=timeout)
+
+    def list_webhooks(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Registered webhooks and the events they can subscribe to"""
+        return self._request("GET", "/admin/webhooks", timeout=timeout)
+
+    def add_webhook(
+        self,
+        url: str,
+        secret: Optional[str] = None,
+        events: Optional[List[str]] = None,
+        min_quality: Optional[float] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Register a webhook; the response is the only place its secret is returned"""
+        body = _without_none({"url": url, "secret": secret, "events": events, "min_quality": min_quality})
+        return self._request("POST", "/admin/webhooks", json=body, timeout=timeout)
+
+    def update_webhook(self, webhook_id: str, timeout: Optional[float] = None, **settings) -> Dict[str, Any]:
+        """Change a webhook's url, secret, events, min_quality or enabled"""
+        return self._request("PUT", f"/admin/webhooks/{webhook_id}", json=settings, timeout=timeout)
+
+    def remove_webhook(self, webhook_id: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        return self._request("DELETE", f"/admin/webhooks/{webhook_id}", timeout=timeout)
+
+    def get_webhook_deliveries(self, webhook_id: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """A webhook's recent deliveries, newest first"""
+        return self._request("GET", f"/admin/webhooks/{webhook_id}/deliveries", timeout=timeout)
+
+    def test_webhook(self, webhook_id: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Send a signed ping to a webhook and wait for the outcome"""
+        return self._request("POST", f"/admin/webhooks/{webhook_id}/test", timeout=timeout)
+
+    def get_notifications(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Kitchen alert sinks configured on the server, and recent alerts posted"""
+        return self._request("GET", "/admin/notifications", timeout=timeout)
+
+    def test_notifications(self, severity: str = "critical", timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Post a test alert to every sink and wait for the outcomes"""
+        return self._request("POST", "/admin/notifications/test", params={"severity": severity}, timeout=timeout)
+
+    def get_simulator(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """The mock model's failure script and how many calls it has broken"""
+        return self._request("GET", "/admin/simulator", timeout=timeout)
+
+    def configure_simulator(self, timeout: Optional[float] = None, **rates: Any) -> Dict[str, Any]:
+        """Change the mock model's failure rates (error_rate, malformed_rate, hallucination_rate,
+        violation_rate, latency_seconds, roles), keeping any not given"""
+        return self._request("PUT", "/admin/simulator", json=_without_none(rates), timeout=timeout)
+
+    def get_sandbox(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get this client's session sandbox"""
+        return self._request("GET", "/sandbox", timeout=timeout)
+
+    # Dataset
+
+    def get_dataset_stats(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get loaded dataset statistics"""
+        return self._request("GET", "/dataset/stats", timeout=timeout)
+
+    # Recipes
+
+    def import_recipes(
+        self,
+        format: str,
+        content: str,
+        dry_run: bool = False,
+        idempotency_key: Optional[str] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Import Schema.org JSON-LD or base64-encoded Paprika recipes
+
+        Pass the same idempotency_key when re-running an import that may
+        already have gone through; one is generated per call otherwise.
+        """
+        return self._request("POST", "/recipes/import", json={
+            "format": format,
+            "content": content,
+            "dry_run": dry_run
+        }, timeout=timeout, idempotency_key=idempotency_key)
+
+    # Menu
+
+    def get_menu(
+        self,
+        cuisine: Optional[str] = None,
+        available: Optional[bool] = None,
+        limit: Optional[int] = None,
+        offset: int = 0,
+        specialty: Optional[bool] = None,
+        seasonal: Optional[bool] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """List menu items, each with whether it can be ordered now or why it's 86'd"""
+        params = _without_none({"cuisine": cuisine, "limit": limit, "offset": offset})
+        for name, flag in (("available", available), ("specialty", specialty), ("seasonal", seasonal)):
+            if flag is not None:
+                params[name] = str(flag).lower()
+        return self._request("GET", "/menu", params=params, timeout=timeout)
+
+    def plan_menu(self, items: List[Dict[str, Any]], timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Replace the menu with items ({"recipe_id", "specialty", "seasonal", "price"}); nothing else can be ordered"""
+        return self._request("PUT", "/menu", json={"items": items}, timeout=timeout)
+
+    def clear_menu(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Drop the planned menu, putting every loaded recipe back on"""
+        return self._request("DELETE", "/menu", timeout=timeout)
+
+    def get_specialties(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get the house specialties on the menu"""
+        return self._request("GET", "/menu/specialties", timeout=timeout)
+
+    def update_menu_item(
+        self,
+        recipe_id: int,
+        specialty: Optional[bool] = None,
+        seasonal: Optional[bool] = None,
+        price: Optional[float] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Mark a menu item as a specialty or seasonal, or not, or reprice it; whatever isn't given is kept"""
+        return self._request("PATCH", f"/menu/items/{recipe_id}", json=_without_none({
+            "specialty": specialty,
+            "seasonal": seasonal,
+            "price": price
+        }), timeout=timeout)
+
+    def get_eighty_six_list(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get the menu items that can't be ordered now, and why"""
+        return self._request("GET", "/menu/86", timeout=timeout)
+
+    def eighty_six(self, recipe_id: int, reason: str = "", timeout: Optional[float] = None) -> Dict[str, Any]:
+        """86 a menu item by hand until it's restored"""
+        return self._request("PUT", f"/menu/items/{recipe_id}/86", json={"reason": reason}, timeout=timeout)
+
+    def restore_menu_item(self, recipe_id: int, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Put a menu item 86'd by hand back on"""
+        return self._request("DELETE", f"/menu/items/{recipe_id}/86", timeout=timeout)
+
+    # Ingredients
+
+    def list_ingredients(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get the ingredient catalog"""
+        return self._request("GET", "/ingredients", timeout=timeout)
+
+    def get_ingredient(self, ingredient: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get the catalog entry an ingredient name resolves to"""
+        return self._request("GET", f"/ingredients/{ingredient}", timeout=timeout)
+
+    def add_ingredient(
+        self,
+        name: str,
+        unit: str = "units",
+        cost_per_unit: float = 0.0,
+        shelf_life_days: Optional[int] = None,
+        allergens: Optional[List[str]] = None,
+        dietary: Optional[List[str]] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Add or replace a catalog entry"""
+        return self._request("POST", "/ingredients", json={
+            "name": name,
+            "unit": unit,
+            "cost_per_unit": cost_per_unit,
+            "shelf_life_days": shelf_life_days,
+            "allergens": allergens or [],
+            "dietary": dietary or []
+        }, timeout=timeout)
+
+    def remove_ingredient(self, ingredient: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Remove a catalog entry"""
+        return self._request("DELETE", f"/ingredients/{ingredient}", timeout=timeout)
+
+    def check_ingredients(
+        self,
+        ingredients: List[str],
+        restrictions: Optional[List[str]] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Check ingredients against allergen and dietary restrictions"""
+        return self._request("POST", "/ingredients/check", json={
+            "ingredients": ingredients,
+            "restrictions": restrictions or []
+        }, timeout=timeout)
+
+    def resolve_ingredients(
+        self,
+        ingredients: List[Union[str, Dict[str, Any]]],
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Canonical ids of ingredient names; dicts with quantity and unit also get converted to catalog units"""
+        return self._request("POST", "/ingredients/resolve", json={
+            "ingredients": [{"name": i} if isinstance(i, str) else i for i in ingredients]
+        }, timeout=timeout)
+
+    def list_ingredient_aliases(self, timeout: Optional[float] = None) -> Dict[str, str]:
+        """Get the alias table, alias -> catalog ingredient"""
+        return self._request("GET", "/ingredients/aliases", timeout=timeout)
+
+    def add_ingredient_alias(self, alias: str, ingredient: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Map another name to a catalog ingredient"""
+        return self._request("POST", "/ingredients/aliases", json={"alias": alias, "ingredient": ingredient}, timeout=timeout)
+
+    def remove_ingredient_alias(self, alias: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Remove an alias"""
+        return self._request("DELETE", f"/ingredients/aliases/{alias}", timeout=timeout)
+
+    # Substitutions
+
+    def list_substitutions(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get the full ingredient substitution table"""
+        return self._request("GET", "/substitutions", timeout=timeout)
+
+    def get_substitutions(
+        self,
+        ingredient: str,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Get substitutes for an ingredient"""
+        return self._request("GET", f"/substitutions/{ingredient}", timeout=timeout)
+
+    def add_substitution(
+        self,
+        ingredient: str,
+        substitute: str,
+        ratio: float = 1.0,
+        quality_penalty: float = 0.0,
+        notes: str = "",
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Add or replace a substitution"""
+        return self._request("POST", "/substitutions", json={
+            "ingredient": ingredient,
+            "substitute": substitute,
+            "ratio": ratio,
+            "quality_penalty": quality_penalty,
+            "notes": notes
+        }, timeout=timeout)
+
+    def remove_substitution(
+        self,
+        ingredient: str,
+        substitute: str,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Remove a substitution"""
+        return self._request(
+            "DELETE", f"/substitutions/{ingredient}/{substitute}", timeout=timeout
+        )
+
+    # Agents and teams
+
+    def create_agent(
+        self,
+        name: str,
+        role: str,
+        device: str,
+        model_name: str = "cohere/command-r",
+        fallback_policy: str = "retry",
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Create a single agent"""
+        return self._request("POST", "/agents/create", json={
+            "name": name,
+            "role": role,
+            "model_name": model_name,
+            "device": device,
+            "fallback_policy": fallback_policy
+        }, timeout=timeout)
+
+    def create_uniform_team(
+        self,
+        model_name: str,
+        team_size: int = 4,
+        roles: Optional[List[str]] = None,
+        fallback_policy: str = "retry",
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Create a team of agents sharing one model"""
+        return self._request("POST", "/teams/create_uniform", json={
+            "model_name": model_name,
+            "team_size": team_size,
+            "roles": roles,
+            "fallback_policy": fallback_policy
+        }, timeout=timeout)
+
+    def create_routed_team(
+        self,
+        team_size: int = 4,
+        roles: Optional[List[str]] = None,
+        fallback_policy: str = "retry",
+        default_model: Optional[str] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Create a team with each role on the model the server's kitchen config routes it to"""
+        return self._request("POST", "/teams/create_routed", json=_without_none({
+            "team_size": team_size,
+            "roles": roles,
+            "fallback_policy": fallback_policy,
+            "default_model": default_model
+        }), timeout=timeout)
+
+    def get_model_routing(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Models by role from the kitchen config, and those the current team runs"""
+        return self._request("GET", "/teams/routing", timeout=timeout)
+
+    def set_model_routing(
+        self,
+        roles: Optional[Dict[str, Optional[str]]] = None,
+        default: Optional[str] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Change models by role until the server restarts; a role set to None loses its own model"""
+        return self._request("PUT", "/teams/routing", json=_without_none({
+            "roles": roles,
+            "default": default
+        }), timeout=timeout)
+
+    def create_mixed_team(
+        self,
+        agents: List[Dict[str, str]],
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Create a team with a model per agent, e.g. [{"model": ..., "role": ...}]"""
+        return self._request(
+            "POST", "/teams/create_mixed", json={"agents": agents}, timeout=timeout
+        )
+
+    def list_agents(
+        self,
+        role: Optional[str] = None,
+        model: Optional[str] = None,
+        min_level: int = 0,
+        max_level: int = 6,
+        sort: str = "name",
+        limit: int = 100,
+        offset: int = 0,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """List one page of registered agents with metrics; next_offset is None on the last page"""
+        params: Dict[str, Any] = {
+            "min_level": min_level, "max_level": max_level, "sort": sort, "limit": limit, "offset": offset
+        }
+        if role:
+            params["role"] = role
+        if model:
+            params["model"] = model
+        return self._request("GET", "/agents/list", params=params, timeout=timeout)
+
+    def get_agent_skills(self, name: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get an agent's per-task skills, proficiency and certifications"""
+        return self._request("GET", f"/agents/{name}/skills", timeout=timeout)
+
+    def get_agent_memory(self, name: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """What an agent recalls: summaries of older events, and the recent ones verbatim"""
+        return self._request("GET", f"/agents/{name}/memory", timeout=timeout)
+
+    def update_agent_skills(
+        self,
+        name: str,
+        skills: Optional[Dict[str, Union[float, str]]] = None,
+        certifications: Optional[List[str]] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Set skills ({"sauce_preparation": "expert"} or 0-1) and optionally replace certifications"""
+        payload: Dict[str, Any] = {"skills": skills or {}}
+        if certifications is not None:
+            payload["certifications"] = certifications
+        return self._request("PUT", f"/agents/{name}/skills", json=payload, timeout=timeout)
+
+    # Staffing
+
+    def get_staffing(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get the idle agent pool and open staffing requests"""
+        return self._request("GET", "/staff", timeout=timeout)
+
+    def hire_agent(
+        self,
+        name: str,
+        role: str,
+        model_name: str = "cohere/command-r",
+        device: str = "cpu",
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Add an idle agent to the staffing pool"""
+        return self._request("POST", "/staff/pool", json={
+            "name": name,
+            "role": role,
+            "model_name": model_name,
+            "device": device
+        }, timeout=timeout)
+
+    def release_agent(self, name: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Move a team member back to the idle pool"""
+        return self._request("POST", f"/staff/release/{name}", timeout=timeout)
+
+    def request_staff(
+        self,
+        role: str,
+        count: int = 1,
+        urgency: str = "normal",
+        reason: str = "",
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Request more agents of a role"""
+        return self._request("POST", "/staff/requests", json={
+            "role": role,
+            "count": count,
+            "urgency": urgency,
+            "reason": reason
+        }, timeout=timeout)
+
+    def list_staff_requests(
+        self,
+        status: Optional[str] = None,
+        role: Optional[str] = None,
+        urgency: Optional[str] = None,
+        created_after: Optional[float] = None,
+        sort: str = "created_at",
+        limit: int = 100,
+        offset: int = 0,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """List one page of staffing requests"""
+        params: Dict[str, Any] = {"sort": sort, "limit": limit, "offset": offset}
+        for key, value in (("status", status), ("role", role), ("urgency", urgency), ("created_after", created_after)):
+            if value is not None:
+                params[key] = value
+        return self._request("GET", "/staff/requests", params=params, timeout=timeout)
+
+    # Schedule
+
+    def get_schedule(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get shifts and hourly wages"""
+        return self._request("GET", "/schedule", timeout=timeout)
+
+    def set_schedule(self, shifts: List[Dict[str, Any]], timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Replace the shift schedule ({agent_name, start, end, hourly_wage?} per shift)"""
+        return self._request("PUT", "/schedule", json={"shifts": shifts}, timeout=timeout)
+
+    def auto_schedule(
+        self,
+        duration_seconds: float,
+        shift_seconds: float,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Rotate same-role agents through back-to-back shifts"""
+        return self._request("POST", "/schedule/auto", json={
+            "duration_seconds": duration_seconds,
+            "shift_seconds": shift_seconds
+        }, timeout=timeout)
+
+    def set_wages(self, wages: Dict[str, float], timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Set hourly wages by role name"""
+        return self._request("PUT", "/schedule/wages", json=wages, timeout=timeout)
+
+    def clear_schedule(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Drop custom shifts"""
+        return self._request("DELETE", "/schedule", timeout=timeout)
+
+    # Scenarios
+
+    def execute_scenario(
+        self,
+        scenario_type: str = "standard",
+        duration_seconds: Optional[int] = None,
+        num_tasks: Optional[int] = None,
+        use_dataset: bool = True,
+        assignment_policy: Optional[str] = None,
+        seed: Optional[int] = None,
+        simulate_equipment: Optional[bool] = None,
+        scoring_profile: Optional[str] = None,
+        dietary_restrictions: Optional[List[str]] = None,
+        quality_rubric: Optional[Union[str, Dict[str, Any]]] = None,
+        judge_transcripts: bool = False,
+        judge_model: Optional[str] = None,
+        seed_profile: Optional[str] = None,
+        simulate_guests: Optional[bool] = None,
+        simulate_customers: Optional[bool] = None,
+        customer_demand: Optional[Dict[str, Any]] = None,
+        plan_pacing: Optional[bool] = None,
+        expeditor: Optional[bool] = None,
+        prompt_overrides: Optional[Dict[str, str]] = None,
+        max_tokens: Optional[int] = None,
+        max_cost: Optional[float] = None,
+        idempotency_key: Optional[str] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Start a benchmark scenario in the background, seeding the session first if asked
+
+        Parameters left as None take the server's defaults, or a scenario bundle's.
+        Reusing an idempotency_key returns the run it already started.
+        """
+        return self._request("POST", "/scenarios/execute", json=_without_none({
+            "scenario_type": scenario_type,
+            "duration_seconds": duration_seconds,
+            "num_tasks": num_tasks,
+            "use_dataset": use_dataset,
+            "assignment_policy": assignment_policy,
+            "seed": seed,
+            "simulate_equipment": simulate_equipment,
+            "scoring_profile": scoring_profile,
+            "dietary_restrictions": dietary_restrictions,
+            "quality_rubric": quality_rubric,
+            "judge_transcripts": judge_transcripts,
+            "judge_model": judge_model,
+            "seed_profile": seed_profile,
+            "simulate_guests": simulate_guests,
+            "simulate_customers": simulate_customers,
+            "customer_demand": customer_demand,
+            "plan_pacing": plan_pacing,
+            "expeditor": expeditor,
+            "prompt_overrides": prompt_overrides or {},
+            "max_tokens": max_tokens,
+            "max_cost": max_cost
+        }), timeout=timeout, idempotency_key=idempotency_key)
+
+    def estimate_scenario(
+        self,
+        scenario_type: str = "standard",
+        duration_seconds: Optional[int] = None,
+        num_tasks: Optional[int] = None,
+        use_dataset: bool = True,
+        assignment_policy: Optional[str] = None,
+        seed: Optional[int] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Predict how long a scenario would take with the current team"""
+        return self._request("POST", "/scenarios/estimate", json=_without_none({
+            "scenario_type": scenario_type,
+            "duration_seconds": duration_seconds,
+            "num_tasks": num_tasks,
+            "use_dataset": use_dataset,
+            "assignment_policy": assignment_policy,
+            "seed": seed
+        }), timeout=timeout)
+
+    def list_scenarios(
+        self,
+        status: Optional[str] = None,
+        scenario_type: Optional[str] = None,
+        started_after: Optional[str] = None,
+        sort: str = "-started_at",
+        limit: int = 100,
+        offset: int = 0,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """List one page of this session's evaluations, newest first by default"""
+        params: Dict[str, Any] = {"sort": sort, "limit": limit, "offset": offset}
+        for key, value in (("status", status), ("scenario_type", scenario_type), ("started_after", started_after)):
+            if value is not None:
+                params[key] = value
+        return self._request("GET", "/scenarios", params=params, timeout=timeout)
+
+    def list_orders(
+        self,
+        evaluation_id: str,
+        q: Optional[str] = None,
+        status: Optional[List[str]] = None,
+        priority: Optional[List[str]] = None,
+        task_type: Optional[List[str]] = None,
+        sort: str = "placed_at",
+        limit: int = 100,
+        offset: int = 0,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Search one page of a run's orders; each filter matches any of its values"""
+        params: Dict[str, Any] = {"sort": sort, "limit": limit, "offset": offset}
+        for key, value in (("q", q), ("status", status), ("priority", priority), ("task_type", task_type)):
+            if value:
+                params[key] = value
+        return self._request("GET", f"/scenarios/{evaluation_id}/orders", params=params, timeout=timeout)
+
+    def get_order(self, evaluation_id: str, task_id: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get one order of a run with its notes, oldest first"""
+        return self._request("GET", f"/scenarios/{evaluation_id}/orders/{task_id}", timeout=timeout)
+
+    def get_scenario_options(self) -> Dict[str, Any]:
+        """Scenario types, assignment policies and limits accepted by execute_scenario"""
+        return self._request("GET", "/scenarios/options")
+
+    def get_golden_run(self, evaluation_id: str, name: Optional[str] = None) -> Dict[str, Any]:
+        """A completed run packaged for golden-run regression checks"""
+        return self._request("GET", f"/scenarios/{evaluation_id}/golden", params={"name": name} if name else None)
+
+    def list_scenario_bundles(self) -> Dict[str, Any]:
+        """Scenario bundles on the server, and any that failed to load"""
+        return self._request("GET", "/scenarios/bundles")
+
+    def get_scenario_bundle(self, name: str) -> Dict[str, Any]:
+        return self._request("GET", f"/scenarios/bundles/{name}")
+
+    def reload_scenario_bundles(self) -> Dict[str, Any]:
+        return self._request("POST", "/scenarios/bundles/reload")
+
+    def get_quality_rubrics(self) -> Dict[str, Any]:
+        """Built-in quality rubrics and the graders custom rubrics can use"""
+        return self._request("GET", "/scenarios/rubrics")
+
+    def get_prompt_templates(self) -> Dict[str, Any]:
+        """Prompt templates agents render, their versions and the variables each kind can use"""
+        return self._request("GET", "/scenarios/prompts")
+
+    def get_scenario_status(
+        self,
+        evaluation_id: str,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Get scenario execution status"""
+        return self._request(
+            "GET", f"/scenarios/{evaluation_id}/status", timeout=timeout
+        )
+
+    def get_scenario_results(
+        self,
+        evaluation_id: str,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Get results of a completed scenario"""
+        return self._request(
+            "GET", f"/scenarios/{evaluation_id}/results", timeout=timeout
+        )
+
+    def judge_scenario(
+        self,
+        evaluation_id: str,
+        model: Optional[str] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Have a judge model score a completed run's agent transcripts"""
+        return self._request(
+            "POST", f"/scenarios/{evaluation_id}/judge", json={"model": model}, timeout=timeout
+        )
+
+    def get_scenario_usage(
+        self,
+        evaluation_id: str,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """LLM token usage and estimated cost of a run, by agent and model"""
+        return self._request("GET", f"/scenarios/{evaluation_id}/usage", timeout=timeout)
+
+    def pause_run(self, evaluation_id: str) -> Dict[str, Any]:
+        """Pause a running evaluation before its next task; it is checkpointed while paused"""
+        return self._request("POST", f"/evaluations/runs/{evaluation_id}/pause")
+
+    def resume_run(self, evaluation_id: str) -> Dict[str, Any]:
+        """Resume a paused evaluation"""
+        return self._request("POST", f"/evaluations/runs/{evaluation_id}/resume")
+
+    def export_run(self, evaluation_id: str, format: str = "csv", timeout: Optional[float] = None) -> bytes:
+        """Download a zip of the run's events, tasks and orders tables, in csv or parquet"""
+        return self._request(
+            "GET", f"/evaluations/runs/{evaluation_id}/export", params={"format": format}, timeout=timeout, raw=True
+        )
+
+    def get_trends(
+        self,
+        metrics: Optional[List[str]] = None,
+        group_by: Optional[List[str]] = None,
+        interval: str = "day",
+        window: int = 7,
+        since: Optional[str] = None,
+        until: Optional[str] = None,
+        model: Optional[str] = None,
+        scenario: Optional[str] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Recorded runs' metrics over time, a series per group with rolling averages over window periods"""
+        params: Dict[str, Any] = {"interval": interval, "window": window}
+        for key, value in (
+            ("metric", metrics), ("group_by", group_by), ("since", since), ("until", until),
+            ("model", model), ("scenario", scenario)
+        ):
+            if value:
+                params[key] = value
+        return self._request("GET", "/evaluations/trends", params=params, timeout=timeout)
+
+    def get_budget(self, evaluation_id: str) -> Dict[str, Any]:
+        """A run's token and cost limits and how much of them it has used; budget is None without limits"""
+        return self._request("GET", f"/evaluations/runs/{evaluation_id}/budget")
+
+    def set_budget(
+        self,
+        evaluation_id: str,
+        max_tokens: Optional[int] = None,
+        max_cost: Optional[float] = None,
+        warn_at: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Change a run's limits, keeping any left as None; a run paused over budget resumes if it fits again"""
+        return self._request("PUT", f"/evaluations/runs/{evaluation_id}/budget", json=_without_none({
+            "max_tokens": max_tokens,
+            "max_cost": max_cost,
+            "warn_at": warn_at
+        }))
+
+    def stream_run_progress(self, evaluation_id: str, after_id: int = 0) -> Iterator[Dict[str, Any]]:
+        """Follow a run's progress stream, yielding {"event", "id", "data"} frames until it is done
+
+        Dropped connections are retried with backoff and resume after the
+        last frame received.
+        """
+        return self._stream_frames(
+            f"/evaluations/runs/{evaluation_id}/events",
+            lambda last_id: {"headers": {"Last-Event-ID": str(last_id)} if last_id is not None else None},
+            after_id or None,
+            done="done"
+        )
+
+    def stream_events(self, run_id: Optional[str] = None, after_id: int = 0) -> Iterator[Dict[str, Any]]:
+        """Follow the event log as it is written, yielding {"event", "id", "data"} frames until the run finishes
+
+        data is the event itself. Dropped connections are retried with backoff
+        and resume after the last event received.
+        """
+        return self._stream_frames(
+            "/events/stream",
+            lambda last_id: {"params": _without_none({"run_id": run_id, "after_id": last_id or 0})},
+            after_id or None
+        )
+
+    def _stream_frames(
+        self,
+        path: str,
+        resume: Callable[[Optional[int]], Dict[str, Any]],
+        last_id: Optional[int],
+        done: Optional[str] = None
+    ) -> Iterator[Dict[str, Any]]:
+        """Server-Sent Event frames from path, reconnecting after the last id received with the request resume gives"""
+        failures = 0
+        while True:
+            try:
+                with self._http.stream(
+                    "GET",
+                    path,
+                    timeout=httpx.Timeout(self.timeout, read=None),
+                    **resume(last_id)
+                ) as response:
+                    if response.status_code >= 400:
+                        response.read()
+                        raise error_for_status(
+                            response.status_code, method="GET", path=path, **self._error_body(response)
+                        )
+                    frame: Dict[str, Any] = {}
+                    for line in response.iter_lines():
+                        if line.startswith("id: "):
+                            frame["id"] = int(line[4:])
+                        elif line.startswith("event: "):
+                            frame["event"] = line[7:]
+                        elif line.startswith("data: "):
+                            frame["data"] = json.loads(line[6:])
+                        elif not line and frame:
+                            failures = 0
+                            last_id = frame.get("id", last_id)
+                            yield frame
+                            if done is not None and frame.get("event") == done:
+                                return
+                            frame = {}
+                    return
+            except httpx.TransportError as e:
+                failures += 1
+                if failures > self.retry_policy.max_retries:
+                    raise ClientConnectionError(f"GET {path}: {e}")
+                time.sleep(self.retry_policy.delay(failures - 1))
+
+    def wait_for_scenario(
+        self,
+        evaluation_id: str,
+        poll_interval: float = 2.0,
+        max_wait: Optional[float] = None,
+        on_poll: Optional[Callable[[Dict[str, Any]], None]] = None
+    ) -> Dict[str, Any]:
+        """Poll until a scenario finishes (paused runs count as unfinished) and return its status"""
+        deadline = time.time() + max_wait if max_wait is not None else None
+
+        while True:
+            status = self.get_scenario_status(evaluation_id)
+            if on_poll:
+                on_poll(status)
+            if status["status"] not in ("running", "paused"):
+                return status
+            if deadline is not None and time.time() >= deadline:
+                raise ClientConnectionError(
+                    f"Scenario {evaluation_id} still running after {max_wait}s"
+                )
+            time.sleep(poll_interval)
+
+    def start_model_comparison(
+        self,
+        models: List[str],
+        scenario: Optional[Dict[str, Any]] = None,
+        evaluation_id: Optional[str] = None,
+        team_size: int = 4,
+        roles: Optional[List[str]] = None,
+        max_parallel: int = 4,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Run one scenario once per model in separate kitchens"""
+        return self._request("POST", "/evaluations/compare", json={
+            "models": models,
+            "scenario": scenario,
+            "evaluation_id": evaluation_id,
+            "team_size": team_size,
+            "roles": roles,
+            "max_parallel": max_parallel
+        }, timeout=timeout)
+
+    def get_model_comparison(
+        self,
+        comparison_id: str,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Get per-model results and the ranking of a comparison"""
+        return self._request("GET", f"/evaluations/compare/{comparison_id}", timeout=timeout)
+
+    def wait_for_model_comparison(
+        self,
+        comparison_id: str,
+        poll_interval: float = 5.0,
+        max_wait: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Poll until every model in a comparison has run"""
+        deadline = time.time() + max_wait if max_wait is not None else None
+
+        while True:
+            comparison = self.get_model_comparison(comparison_id)
+            if comparison["status"] != "running":
+                return comparison
+            if deadline is not None and time.time() >= deadline:
+                raise ClientConnectionError(
+                    f"Comparison {comparison_id} still running after {max_wait}s"
+                )
+            time.sleep(poll_interval)
+
+    def start_prompt_experiment(
+        self,
+        variant_b: Dict[str, str],
+        variant_a: Optional[Dict[str, str]] = None,
+        scenario: Optional[Dict[str, Any]] = None,
+        name_a: str = "A",
+        name_b: str = "B",
+        runs: int = 10,
+        primary_metric: str = "score",
+        alpha: float = 0.05,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Run one scenario under two prompt variants on the same seeds and test which does better"""
+        return self._request("POST", "/experiments/prompts", json={
+            "scenario": scenario,
+            "variant_a": variant_a or {},
+            "variant_b": variant_b,
+            "name_a": name_a,
+            "name_b": name_b,
+            "runs": runs,
+            "primary_metric": primary_metric,
+            "alpha": alpha
+        }, timeout=timeout)
+
+    def get_prompt_experiment(self, experiment_id: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Progress of a prompt experiment, and its report once finished"""
+        return self._request("GET", f"/experiments/prompts/{experiment_id}", timeout=timeout)
+
+    def wait_for_prompt_experiment(
+        self,
+        experiment_id: str,
+        poll_interval: float = 5.0,
+        max_wait: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Poll until both variants have run on every seed"""
+        deadline = time.time() + max_wait if max_wait is not None else None
+
+        while True:
+            experiment = self.get_prompt_experiment(experiment_id)
+            if experiment["status"] != "running":
+                return experiment
+            if deadline is not None and time.time() >= deadline:
+                raise ClientConnectionError(
+                    f"Experiment {experiment_id} still running after {max_wait}s"
+                )
+            time.sleep(poll_interval)
+
+    # Equipment
+
+    def get_equipment(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get simulated equipment status for the current run"""
+        return self._request("GET", "/equipment", timeout=timeout)
+
+    def get_equipment_temperature(self, name: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get an item's current temperature reading and recent history"""
+        return self._request("GET", f"/equipment/{name}/temperature", timeout=timeout)
+
+    def break_equipment(self, name: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Force an equipment breakdown (admin)"""
+        return self._request("POST", f"/equipment/{name}/break", timeout=timeout)
+
+    # Kitchen
+
+    def list_stations(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get every station's load, staff, active orders and equipment state"""
+        return self._request("GET", "/kitchen/stations", timeout=timeout)
+
+    def get_station(self, name: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get one station with the orders queued for or being worked on it"""
+        return self._request("GET", f"/kitchen/stations/{name}", timeout=timeout)
+
+    def get_kitchen_snapshot(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get orders, stations, staff, inventory and alerts of the latest run in one consistent read"""
+        return self._request("GET", "/kitchen/snapshot", timeout=timeout)
+
+    # Chaos
+
+    def get_chaos(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """List chaos actions and the disruptions injected into the current run"""
+        return self._request("GET", "/chaos", timeout=timeout)
+
+    def inject_chaos(self, action: str, timeout: Optional[float] = None, **params: Any) -> Dict[str, Any]:
+        """Disrupt the executing run, e.g. inject_chaos("kill_agent", agent="LINE_COOK_3") (admin)"""
+        return self._request("POST", "/chaos", json={"action": action, **params}, timeout=timeout)
+
+    def submit_order(
+        self,
+        items: List[Dict[str, Any]],
+        table: Optional[int] = None,
+        dietary_restrictions: Optional[List[str]] = None,
+        time_limit: Optional[float] = None,
+        priority: Optional[str] = None,
+        party_size: Optional[int] = None,
+        dry_run: bool = False,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Queue an order in the executing run, or with dry_run only check it and get the kitchen's plan
+
+        Each item is e.g. {"recipe_id": 12, "quantity": 2} or {"dish": "omelette", "ingredients": ["eggs", "butter"]},
+        with an optional course (starter, main or dessert) for holding and firing it and notes for the cook.
+        Priority is normal, expedite or reassign. party_size is the guests it feeds, counted as covers in the
+        service period report; by default the party seated at the table.
+        Raises ThrottledError while the kitchen is too busy to admit it; its wait_estimate says when to try again.
+        """
+        return self._request("POST", "/orders", params={"dry_run": str(dry_run).lower()}, json=_without_none({
+            "items": items,
+            "table": table,
+            "dietary_restrictions": dietary_restrictions,
+            "time_limit": time_limit,
+            "priority": priority,
+            "party_size": party_size
+        }), timeout=timeout)
+
+    def cancel_order(self, task_id: str, reason: str = "cancelled", timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Cancel a task in the executing run, stopping it at its next safe point if it has started"""
+        return self._request("DELETE", f"/orders/{task_id}", params={"reason": reason}, timeout=timeout)
+
+    def submit_orders_bulk(
+        self,
+        orders: List[Dict[str, Any]],
+        dry_run: bool = False,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Schedule orders to arrive in the executing run, or with dry_run only plan each one now
+
+        Each order is submit_order's fields plus arrive_at, seconds into the run, e.g.
+        {"items": [{"recipe_id": 12}], "table": 3, "arrive_at": 90}.
+        """
+        return self._request(
+            "POST", "/orders/bulk", params={"dry_run": str(dry_run).lower()}, json=orders, timeout=timeout
+        )
+
+    def get_order_batch(self, batch_id: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Where each order of a bulk submission to the current run stands"""
+        return self._request("GET", f"/orders/bulk/{batch_id}", timeout=timeout)
+
+    def modify_order(
+        self,
+        task_id: str,
+        add_items: Optional[List[Dict[str, Any]]] = None,
+        remove_items: Optional[List[int]] = None,
+        quantities: Optional[Dict[int, int]] = None,
+        void: Optional[List[str]] = None,
+        note: Optional[str] = None,
+        priority: Optional[str] = None,
+        reason: Optional[str] = None,
+        author: Optional[str] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Change the ticket a task of the executing run belongs to
+
+        Lines are numbered from 0 in the order they were added; quantities maps a line to the
+        portions it should have. void takes task ids, and priority is normal, expedite or reassign.
+        """
+        return self._request("PATCH", f"/orders/{task_id}", json=_without_none({
+            "add_items": add_items,
+            "remove_items": remove_items,
+            "quantities": quantities,
+            "void": void,
+            "note": note,
+            "priority": priority,
+            "reason": reason,
+            "author": author
+        }), timeout=timeout)
+
+    def add_order_note(
+        self,
+        task_id: str,
+        author: str,
+        reason: str,
+        severity: str = "warning",
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Note a problem with an order in the executing run (severity info, warning or critical)"""
+        return self._request(
+            "POST", f"/orders/{task_id}/notes",
+            json={"author": author, "reason": reason, "severity": severity}, timeout=timeout
+        )
+
+    def get_holds(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get the courses held in the current run, the calls made and held portions fired anyway"""
+        return self._request("GET", "/orders/holds", timeout=timeout)
+
+    def hold_course(
+        self,
+        course: str,
+        table: Optional[int] = None,
+        author: Optional[str] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Hold a course (starter, main or dessert) in the executing run, at one table or every table"""
+        return self._request("POST", "/orders/hold", json=_without_none({
+            "course": course, "table": table, "author": author
+        }), timeout=timeout)
+
+    def fire_course(
+        self,
+        course: str,
+        table: Optional[int] = None,
+        author: Optional[str] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Fire a held course in the executing run, e.g. fire_course("main", table=7)"""
+        return self._request("POST", "/orders/fire", json=_without_none({
+            "course": course, "table": table, "author": author
+        }), timeout=timeout)
+
+    # Escalation
+
+    def get_escalation(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get escalation thresholds and the tasks escalated in the current run"""
+        return self._request("GET", "/escalation", timeout=timeout)
+
+    def configure_escalation(self, timeout: Optional[float] = None, **thresholds: Any) -> Dict[str, Any]:
+        """Adjust escalation thresholds, e.g. configure_escalation(expedite_after=0.8)"""
+        return self._request("PUT", "/escalation", json=thresholds, timeout=timeout)
+
+    # Admission
+
+    def get_admission(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get the admission policy, whether the kitchen would take an order now, and the run's throttled orders"""
+        return self._request("GET", "/admission", timeout=timeout)
+
+    def configure_admission(self, timeout: Optional[float] = None, **limits: Any) -> Dict[str, Any]:
+        """Adjust the admission policy, e.g. configure_admission(max_active_orders=20)"""
+        return self._request("PUT", "/admission", json=limits, timeout=timeout)
+
+    # Hand-offs
+
+    def get_handoffs(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get hand-off settings and how the current run's delegated tasks were accepted or rejected"""
+        return self._request("GET", "/handoffs", timeout=timeout)
+
+    def configure_handoffs(self, timeout: Optional[float] = None, **settings: Any) -> Dict[str, Any]:
+        """Adjust when stations reject delegated work, e.g. configure_handoffs(capacity=4)"""
+        return self._request("PUT", "/handoffs", json=settings, timeout=timeout)
+
+    # Dining
+
+    def list_tables(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get tables, upcoming reservations and course pacing"""
+        return self._request("GET", "/tables", timeout=timeout)
+
+    def add_table(self, number: int, capacity: int, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Add a table to the dining room"""
+        return self._request("POST", "/tables", json={"number": number, "capacity": capacity}, timeout=timeout)
+
+    def remove_table(self, number: int, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Remove a table nobody is dining at"""
+        return self._request("DELETE", f"/tables/{number}", timeout=timeout)
+
+    def reserve_table(
+        self,
+        name: str,
+        party_size: int,
+        time: str,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Book a party for an ISO 8601 time"""
+        return self._request("POST", "/reservations", json={
+            "name": name,
+            "party_size": party_size,
+            "time": time
+        }, timeout=timeout)
+
+    def list_reservations(self, timeout: Optional[float] = None) -> List[Dict[str, Any]]:
+        """List booked reservations, soonest first"""
+        return self._request("GET", "/reservations", timeout=timeout)
+
+    def cancel_reservation(self, reservation_id: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Cancel a booking and free its held table"""
+        return self._request("DELETE", f"/reservations/{reservation_id}", timeout=timeout)
+
+    def seat_party(
+        self,
+        reservation_id: Optional[str] = None,
+        party_size: Optional[int] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Seat a reservation, or a walk-in party of party_size"""
+        return self._request("POST", "/tables/seat", json={
+            "reservation_id": reservation_id,
+            "party_size": party_size
+        }, timeout=timeout)
+
+    def set_table_status(self, number: int, status: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Move a table on to fired, dessert or paid"""
+        return self._request("POST", f"/tables/{number}/status", json={"status": status}, timeout=timeout)
+
+    def get_pacing(self, timeout: Optional[float] = None) -> List[Dict[str, Any]]:
+        """Get how each dining table is doing against its course pacing, most behind first"""
+        return self._request("GET", "/tables/pacing", timeout=timeout)
+
+    def list_servers(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get the front-of-house servers and their sections"""
+        return self._request("GET", "/servers", timeout=timeout)
+
+    def add_server(self, name: str, tables: Optional[List[int]] = None, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Put a server on the floor covering these tables, or every table"""
+        return self._request("POST", "/servers", json={"name": name, "tables": tables or []}, timeout=timeout)
+
+    def remove_server(self, name: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Take a server off the floor"""
+        return self._request("DELETE", f"/servers/{name}", timeout=timeout)
+
+    # Events
+
+    def list_events(
+        self,
+        run_id: Optional[str] = None,
+        after_id: int = 0,
+        agent_name: Optional[str] = None,
+        event_type: Optional[str] = None,
+        limit: int = 500,
+        include_archived: bool = False,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Query the event log"""
+        params = {"after_id": after_id, "limit": limit}
+        if include_archived:
+            params["include_archived"] = "true"
+        if run_id:
+            params["run_id"] = run_id
+        if agent_name:
+            params["agent_name"] = agent_name
+        if event_type:
+            params["event_type"] = event_type
+        return self._request("GET", "/events", params=params, timeout=timeout)
+
+    def replay_scenario(
+        self,
+        evaluation_id: str,
+        at: Optional[float] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Reconstruct a run's state as of a timestamp"""
+        params = {"at": at} if at is not None else None
+        return self._request(
+            "GET", f"/scenarios/{evaluation_id}/replay", params=params, timeout=timeout
+        )
+
+    # Transcripts
+
+    def list_transcripts(
+        self,
+        run_id: Optional[str] = None,
+        agent_name: Optional[str] = None,
+        task_id: Optional[str] = None,
+        contains: Optional[str] = None,
+        after_id: int = 0,
+        limit: int = 100,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Query recorded agent prompts and responses"""
+        params: Dict[str, Any] = {"after_id": after_id, "limit": limit}
+        if run_id:
+            params["run_id"] = run_id
+        if agent_name:
+            params["agent_name"] = agent_name
+        if task_id:
+            params["task_id"] = task_id
+        if contains:
+            params["contains"] = contains
+        return self._request("GET", "/transcripts", params=params, timeout=timeout)
+
+    def get_transcript(self, transcript_id: int, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get one recorded prompt/response pair"""
+        return self._request("GET", f"/transcripts/{transcript_id}", timeout=timeout)
+
+    # Tutorial
+
+    def get_tutorial(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get tutorial steps and which are complete"""
+        return self._request("GET", "/tutorial", timeout=timeout)
+
+    def get_scenario_hints(
+        self,
+        evaluation_id: str,
+        after_id: int = 0,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Get event-driven commentary for a run"""
+        return self._request(
+            "GET", f"/scenarios/{evaluation_id}/hints",
+            params={"after_id": after_id}, timeout=timeout
+        )
+
+    # Metrics
+
+    def generate_charts(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Generate visualization charts on the server"""
+        return self._request("GET", "/metrics/charts", timeout=timeout)
+
+    def generate_report(self, timeout: Optional[float] = None) -> bytes:
+        """Generate and download the markdown benchmark report"""
+        return self._request("GET", "/metrics/report", timeout=timeout, raw=True)
+
+    def export_metrics(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Export metrics to CSV on the server"""
+        return self._request("GET", "/metrics/export", timeout=timeout)
+
+    def get_leaderboard(
+        self,
+        profile: str = "balanced",
+        limit: Optional[int] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Recorded runs ranked best-first under a scoring profile"""
+        params: Dict[str, Any] = {"profile": profile}
+        if limit is not None:
+            params["limit"] = limit
+        return self._request("GET", "/metrics/leaderboard", params=params, timeout=timeout)
+
+    def get_scoring(self, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get every scoring profile's weights, thresholds and formula, and the profile each scenario uses"""
+        return self._request("GET", "/metrics/scoring", timeout=timeout)
+
+    def set_scoring(
+        self,
+        profiles: Optional[Dict[str, Dict[str, Any]]] = None,
+        scenarios: Optional[Dict[str, str]] = None,
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Replace the server's custom scoring profiles and scenario defaults (admin)"""
+        return self._request(
+            "PUT", "/admin/scoring", json={"profiles": profiles or {}, "scenarios": scenarios or {}}, timeout=timeout
+        )
+
+    def generate_daily_report(self, day: Optional[str] = None, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Build and save the end-of-day report for a YYYY-MM-DD day, defaulting to today"""
+        return self._request("POST", "/reports/eod", json={"day": day}, timeout=timeout)
+
+    def list_daily_reports(self, timeout: Optional[float] = None) -> List[Dict[str, Any]]:
+        """List saved end-of-day reports, newest day first"""
+        return self._request("GET", "/reports/eod", timeout=timeout)
+
+    def get_daily_report(self, day: str, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Get a saved end-of-day report"""
+        return self._request("GET", f"/reports/eod/{day}", timeout=timeout)
+
+    def get_menu_engineering(self, days: Optional[int] = None, timeout: Optional[float] = None) -> Dict[str, Any]:
+        """Menu items classified as stars, plowhorses, puzzles and dogs, from sales of the last days (all by default)"""
+        return self._request("GET", "/reports/menu-engineering", params=_without_none({"days": days}), timeout=timeout)
+
+    def compare_models(
+        self,
+        model_groups: Dict[str, List[str]],
+        timeout: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Compare performance across models, keyed by model name to agent names"""
+        return self._request(
+            "POST", "/metrics/compare_models", json=model_groups, timeout=timeout
+        )
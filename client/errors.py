@@ -0,0 +1,98 @@
+"""
+Typed errors raised by the ChefBench API client
+"""
+
+from typing import Any, Optional
+
+
+class ChefBenchClientError(Exception):
+    """Base class for all client errors"""
+
+
+class ClientConnectionError(ChefBenchClientError):
+    """Server could not be reached or the request timed out"""
+
+
+class CircuitOpenError(ClientConnectionError):
+    """Server is marked offline and the request was not attempted"""
+
+    def __init__(self, retry_after: float):
+        self.retry_after = retry_after
+        super().__init__(f"Server offline, next probe in {retry_after:.1f}s")
+
+
+class APIError(ChefBenchClientError):
+    """Server returned a non-success status code"""
+
+    def __init__(
+        self,
+        status_code: int,
+        detail: Any,
+        method: str = "",
+        path: str = "",
+        code: Optional[str] = None,
+        details: Any = None
+    ):
+        self.status_code = status_code
+        self.detail = detail  # the envelope's message
+        self.method = method
+        self.path = path
+        self.code = code
+        self.details = details
+        super().__init__(f"{method} {path} failed with {status_code}: {detail}")
+
+
+class BadRequestError(APIError):
+    """Request was rejected by the server (400)"""
+
+
+class NotFoundError(APIError):
+    """Requested resource does not exist (404)"""
+
+
+class ValidationError(APIError):
+    """Request body failed schema validation (422)"""
+
+
+class ConflictError(APIError):
+    """Request conflicts with the resource's current state (409)"""
+
+
+class ThrottledError(APIError):
+    """The kitchen is too busy to take the order now; try again after wait_estimate seconds (429)"""
+
+    @property
+    def wait_estimate(self) -> Optional[float]:
+        return self.details.get("wait_estimate") if isinstance(self.details, dict) else None
+
+
+class ServerError(APIError):
+    """Server failed to handle the request (5xx)"""
+
+
+def error_for_status(
+    status_code: int,
+    detail: Any,
+    method: str = "",
+    path: str = "",
+    code: Optional[str] = None,
+    details: Any = None
+) -> Optional[APIError]:
+    """Map an HTTP status code to the matching typed error"""
+    if status_code < 400:
+        return None
+    if status_code == 400:
+        error_class = BadRequestError
+    elif status_code == 404:
+        error_class = NotFoundError
+    elif status_code == 409:
+        error_class = ConflictError
+    elif status_code == 422:
+        error_class = ValidationError
+    elif status_code == 429:
+        error_class = ThrottledError
+    elif status_code >= 500:
+        error_class = ServerError
+    else:
+        error_class = APIError
+    return error_class(status_code, detail, method, path, code, details)
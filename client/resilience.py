@@ -0,0 +1,91 @@
+"""
+Retry and circuit-breaker policies for the ChefBench API client
+"""
+
+import random
+import time
+import logging
+from dataclasses import dataclass
+from typing import Callable, Optional
+
+logger = logging.getLogger(__name__)
+
+
+@dataclass
+class RetryPolicy:
+    """Exponential backoff settings for retryable failures"""
+    max_retries: int = 3
+    base_delay: float = 0.25   # seconds before the first retry
+    max_delay: float = 8.0
+    multiplier: float = 2.0
+    jitter: float = 0.1        # fraction of the delay randomised either way
+
+    def delay(self, attempt: int) -> float:
+        """Delay before retry number `attempt` (0-based)"""
+        delay = min(self.max_delay, self.base_delay * (self.multiplier ** attempt))
+        if self.jitter:
+            delay *= 1 + random.uniform(-self.jitter, self.jitter)
+        return max(0.0, delay)
+
+
+class CircuitBreaker:
+    """Stops calling an unreachable server and periodically re-probes it
+
+    closed    -> requests flow normally
+    open      -> requests fail fast until `reset_timeout` has elapsed
+    half_open -> a single probe decides whether to close or re-open
+    """
+
+    CLOSED = "closed"
+    OPEN = "open"
+    HALF_OPEN = "half_open"
+
+    def __init__(
+        self,
+        failure_threshold: int = 3,
+        reset_timeout: float = 15.0,
+        on_state_change: Optional[Callable[[str, str], None]] = None
+    ):
+        self.failure_threshold = failure_threshold
+        self.reset_timeout = reset_timeout
+        self.on_state_change = on_state_change
+
+        self.state = self.CLOSED
+        self.consecutive_failures = 0
+        self.opened_at: Optional[float] = None
+
+    def _transition(self, state: str):
+        if state == self.state:
+            return
+
+        previous, self.state = self.state, state
+        logger.info(f"Circuit breaker {previous} -> {state}")
+        if self.on_state_change:
+            self.on_state_change(previous, state)
+
+    def allow_request(self) -> bool:
+        """Whether a request may be attempted right now"""
+        if self.state == self.OPEN and time.time() - self.opened_at >= self.reset_timeout:
+            self._transition(self.HALF_OPEN)
+        return self.state != self.OPEN
+
+    def should_probe(self) -> bool:
+        """Whether the next request should be preceded by a health probe"""
+        return self.allow_request() and self.state == self.HALF_OPEN
+
+    def record_success(self):
+        self.consecutive_failures = 0
+        self.opened_at = None
+        self._transition(self.CLOSED)
+
+    def record_failure(self):
+        self.consecutive_failures += 1
+        if self.state == self.HALF_OPEN or self.consecutive_failures >= self.failure_threshold:
+            self.opened_at = time.time()
+            self._transition(self.OPEN)
+
+    def retry_after(self) -> float:
+        """Seconds until the breaker will allow a probe"""
+        if self.state != self.OPEN:
+            return 0.0
+        return max(0.0, self.reset_timeout - (time.time() - self.opened_at))
@@ -0,0 +1,176 @@
+"""
+Client Settings
+Persisted CLI and client configuration in ~/.config/escoffier/config.yaml, overridable by environment
+"""
+
+import json
+import os
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Callable, Dict, Optional, Tuple
+
+DEFAULT_BASE_URL = "http://localhost:8000"
+
+DEFAULT_CONFIG_DIR = Path(os.environ.get("ESCOFFIER_CONFIG_DIR", Path.home() / ".config" / "escoffier"))
+DEFAULT_SETTINGS_PATH = DEFAULT_CONFIG_DIR / "config.yaml"
+
+# auto picks dark or light on a color terminal and no-color otherwise
+THEMES = ("auto", "dark", "light", "high-contrast", "no-color")
+
+# Agents created with this model skip loading one and answer with canned responses
+MOCK_MODEL = "mock"
+
+
+def _parse_bool(value: Any) -> bool:
+    if isinstance(value, bool):
+        return value
+    text = str(value).strip().lower()
+    if text in ("1", "true", "yes", "on"):
+        return True
+    if text in ("0", "false", "no", "off"):
+        return False
+    raise ValueError(f"expected true or false, got '{value}'")
+
+
+def _parse_interval(value: Any) -> float:
+    interval = float(value)
+    if interval <= 0:
+        raise ValueError("must be positive")
+    return interval
+
+
+def _parse_theme(value: Any) -> str:
+    if value not in THEMES:
+        raise ValueError(f"expected one of {', '.join(THEMES)}")
+    return value
+
+
+def _parse_text(value: Any) -> Optional[str]:
+    text = str(value).strip()
+    return text or None
+
+
+@dataclass(frozen=True)
+class Setting:
+    name: str
+    default: Any
+    env: str
+    parse: Callable[[Any], Any]
+    description: str
+    secret: bool = False
+
+
+SETTINGS = {
+    s.name: s for s in (
+        Setting("api_url", DEFAULT_BASE_URL, "ESCOFFIER_API_URL", _parse_text, "API base URL"),
+        Setting("auth_token", None, "ESCOFFIER_AUTH_TOKEN", _parse_text,
+                "Token sent as X-Admin-Token for admin endpoints", secret=True),
+        Setting("refresh_interval", 2.0, "ESCOFFIER_REFRESH_INTERVAL", _parse_interval,
+                "Seconds between polls while waiting on runs, comparisons and experiments"),
+        Setting("theme", "auto", "ESCOFFIER_THEME", _parse_theme, f"Output colors: {', '.join(THEMES)}"),
+        Setting("mock_mode", False, "ESCOFFIER_MOCK_MODE", _parse_bool,
+                f"Create agents with the '{MOCK_MODEL}' model (scripted simulator, nothing loaded)"),
+        Setting("default_model", "cohere/command-r", "ESCOFFIER_DEFAULT_MODEL", _parse_text,
+                "Model for new agents and teams when --model isn't given"),
+    )
+}
+
+
+def _read_flat_yaml(text: str) -> Dict[str, Any]:
+    """key: value lines, the subset of YAML the settings file uses; values may be JSON scalars"""
+    values = {}
+    for number, line in enumerate(text.splitlines(), 1):
+        line = line.strip()
+        if not line or line.startswith("#"):
+            continue
+        key, sep, raw = line.partition(":")
+        if not sep:
+            raise ValueError(f"line {number}: expected 'key: value'")
+        raw = raw.strip()
+        try:
+            values[key.strip()] = json.loads(raw)
+        except ValueError:
+            values[key.strip()] = raw
+    return values
+
+
+class SettingsStore:
+    """The settings file, layered under environment variables and over built-in defaults
+
+    Each setting resolves to its environment variable if set, else the file,
+    else the default.
+    """
+
+    def __init__(self, path: Optional[Path] = None):
+        self.path = Path(path) if path else DEFAULT_SETTINGS_PATH
+        self.values: Dict[str, Any] = {}
+        if self.path.exists():
+            with open(self.path, 'r', encoding='utf-8') as f:
+                raw = _read_flat_yaml(f.read())
+            for name, value in raw.items():
+                if name in SETTINGS and value is not None:
+                    try:
+                        self.values[name] = SETTINGS[name].parse(value)
+                    except ValueError as e:
+                        raise ValueError(f"{self.path}: {name} {e}") from e
+
+    def save(self):
+        """Write the file's settings; it can hold a token, so only the owner may read it"""
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        lines = ["# Escoffier client settings, edit with `escoffier settings`"]
+        lines += [f"{name}: {json.dumps(self.values[name])}" for name in SETTINGS if name in self.values]
+        tmp = self.path.with_suffix(".yaml.tmp")
+        with open(os.open(tmp, os.O_WRONLY | os.O_CREAT | os.O_TRUNC, 0o600), 'w', encoding='utf-8') as f:
+            f.write("\n".join(lines) + "\n")
+        os.replace(tmp, self.path)
+
+    def set(self, name: str, value: Any) -> Any:
+        if name not in SETTINGS:
+            raise KeyError(f"Unknown setting '{name}', expected one of {', '.join(SETTINGS)}")
+        try:
+            parsed = SETTINGS[name].parse(value)
+        except ValueError as e:
+            raise ValueError(f"{name} {e}") from e
+        if parsed is None:
+            self.values.pop(name, None)
+        else:
+            self.values[name] = parsed
+        self.save()
+        return parsed
+
+    def unset(self, name: str) -> bool:
+        if name not in SETTINGS:
+            raise KeyError(f"Unknown setting '{name}', expected one of {', '.join(SETTINGS)}")
+        if self.values.pop(name, None) is None:
+            return False
+        self.save()
+        return True
+
+    def resolve(self, name: str) -> Tuple[Any, str]:
+        """A setting's value and where it came from: env, file or default"""
+        setting = SETTINGS[name]
+        raw = os.environ.get(setting.env)
+        if raw:
+            try:
+                return setting.parse(raw), "env"
+            except ValueError as e:
+                raise ValueError(f"${setting.env}: {e}") from e
+        if name in self.values:
+            return self.values[name], "file"
+        return setting.default, "default"
+
+    def get(self, name: str) -> Any:
+        return self.resolve(name)[0]
+
+    def effective(self) -> Dict[str, Any]:
+        return {name: self.get(name) for name in SETTINGS}
+
+    def to_dict(self, reveal: bool = False) -> Dict[str, Any]:
+        """Every setting with its value and source, secrets masked unless revealed"""
+        rows = {}
+        for name, setting in SETTINGS.items():
+            value, source = self.resolve(name)
+            if setting.secret and value and not reveal:
+                value = "****" + str(value)[-4:] if len(str(value)) > 8 else "****"
+            rows[name] = {"value": value, "source": source, "env": setting.env, "description": setting.description}
+        return {"path": str(self.path), "settings": rows}
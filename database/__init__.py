@@ -4,5 +4,9 @@ raeecip
 """
 
 from .database import ChefBenchDatabase
+from .event_store import EventStore
+from .transcripts import TranscriptStore
+from .checkpoints import CheckpointStore
+from .menu_store import MenuStore
 
-__all__ = ['ChefBenchDatabase']
\ No newline at end of file
+__all__ = ['ChefBenchDatabase', 'EventStore', 'TranscriptStore', 'CheckpointStore', 'MenuStore']
\ No newline at end of file
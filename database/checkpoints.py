@@ -0,0 +1,60 @@
+"""
+Checkpoint Store for ChefBench
+Latest snapshot of each long-running evaluation so it can be resumed after a restart
+"""
+
+import json
+import os
+import threading
+from typing import Dict, List, Optional, Any
+from pathlib import Path
+import logging
+
+logger = logging.getLogger(__name__)
+
+
+class CheckpointStore:
+    """One JSON file per run, replaced atomically on every save"""
+
+    def __init__(self, directory: str = "data/checkpoints"):
+        self.directory = Path(directory)
+        self.directory.mkdir(parents=True, exist_ok=True)
+        self._lock = threading.Lock()
+
+    def _path(self, run_id: str) -> Path:
+        return self.directory / f"{run_id}.json"
+
+    def save(self, run_id: str, state: Dict[str, Any]):
+        """Write a checkpoint, never leaving a half-written file behind"""
+        path = self._path(run_id)
+        tmp = path.with_suffix(".json.tmp")
+        with self._lock:
+            with open(tmp, 'w') as f:
+                json.dump(state, f, default=str)
+            os.replace(tmp, path)
+
+    def load(self, run_id: str) -> Optional[Dict[str, Any]]:
+        path = self._path(run_id)
+        if not path.exists():
+            return None
+        with open(path, 'r', encoding='utf-8') as f:
+            return json.load(f)
+
+    def list(self) -> List[Dict[str, Any]]:
+        """All readable checkpoints, oldest first"""
+        checkpoints = []
+        for path in sorted(self.directory.glob("*.json"), key=lambda p: p.stat().st_mtime):
+            try:
+                with open(path, 'r', encoding='utf-8') as f:
+                    checkpoints.append(json.load(f))
+            except (OSError, ValueError) as e:
+                logger.error(f"Skipping unreadable checkpoint {path}: {e}")
+        return checkpoints
+
+    def delete(self, run_id: str) -> bool:
+        with self._lock:
+            try:
+                self._path(run_id).unlink()
+                return True
+            except FileNotFoundError:
+                return False
@@ -13,6 +13,8 @@ from datetime import datetime
 from pathlib import Path
 import logging
 
+from observability import TracedConnection
+
 logger = logging.getLogger(__name__)
 
 
@@ -26,7 +28,7 @@ class ChefBenchDatabase:
     
     def initialize_database(self):
         """Create database tables if they don't exist"""
-        self.connection = sqlite3.connect(str(self.db_path))
+        self.connection = sqlite3.connect(str(self.db_path), factory=TracedConnection)
         self.connection.row_factory = sqlite3.Row
 
         cursor = self.connection.cursor()
@@ -152,12 +154,12 @@ class ChefBenchDatabase:
 
     def connect(self):
         try:
-            self.connection = sqlite3.connect(self.db_path)
+            self.connection = sqlite3.connect(self.db_path, factory=TracedConnection)
             self.connection.row_factory = sqlite3.Row
             logger.info(f"Connected to database at {self.db_path}")
 
         except sqlite3.Error as e:
-            print(f"Database connection error: {e}")
+            logger.error(f"Database connection error: {e}")
             self.connection =  None
  
         finally :
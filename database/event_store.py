@@ -0,0 +1,426 @@
+"""
+Event Store for ChefBench
+Append-only log of every kitchen event with replay for debugging benchmark runs
+"""
+
+import sqlite3
+import json
+import threading
+import time
+from typing import Dict, List, Optional, Any, Iterator
+from pathlib import Path
+import logging
+
+from models.models import KitchenEvent
+from observability import TracedConnection
+
+logger = logging.getLogger(__name__)
+
+# A run is finished, and its events may be archived, once one of these is logged
+TERMINAL_EVENTS = ("scenario_completed", "scenario_failed")
+
+ORDER_STATUSES = ("queued", "held", "in_progress", "completed", "failed", "cancelled")
+# normal, or the last escalation level an order reached
+ORDER_PRIORITIES = ("normal", "expedite", "reassign")
+ORDER_EVENTS = (
+    "order_placed", "task_assigned", "order_modified", "order_escalated", "task_started",
+    "task_executed", "order_served", "order_cancelled", "task_revoked", "order_noted", "order_held", "order_fired"
+)
+# Where an order is on its way to the guests, and the event that moves it into each stage
+ORDER_STAGES = ("received", "prep", "cook", "plate", "served")
+STAGE_EVENTS = {
+    "order_placed": "received",
+    "task_assigned": "prep",  # the station has the ticket
+    "task_started": "cook",  # fired: its cook holds the ingredients and equipment
+    "task_executed": "plate",  # off the line, if it came out
+    "order_served": "served"
+}
+# How much an annotation says is wrong with its order, least first
+NOTE_SEVERITIES = ("info", "warning", "critical")
+
+
+class EventStore:
+    """SQLite-backed append-only event log"""
+
+    def __init__(self, db_path: str = "data/events.db"):
+        self.db_path = Path(db_path)
+        self.db_path.parent.mkdir(parents=True, exist_ok=True)
+        self._lock = threading.Lock()
+        self.connection = sqlite3.connect(str(self.db_path), check_same_thread=False, factory=TracedConnection)
+        self.connection.row_factory = sqlite3.Row
+        self.initialize()
+
+    def initialize(self):
+        """Create the events and events_archive tables if they don't exist"""
+        with self._lock:
+            self.connection.execute("""
+                CREATE TABLE IF NOT EXISTS events (
+                    event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    run_id TEXT,
+                    event_type TEXT NOT NULL,
+                    agent_name TEXT,
+                    task_id TEXT,
+                    caused_by INTEGER,
+                    payload TEXT NOT NULL,
+                    timestamp REAL NOT NULL,
+                    FOREIGN KEY (caused_by) REFERENCES events (event_id)
+                )
+            """)
+            self.connection.execute(
+                "CREATE INDEX IF NOT EXISTS idx_events_run ON events (run_id, event_id)"
+            )
+            # Finished runs past retention, moved out of the hot table; event_ids are kept
+            self.connection.execute("""
+                CREATE TABLE IF NOT EXISTS events_archive (
+                    event_id INTEGER PRIMARY KEY,
+                    run_id TEXT,
+                    event_type TEXT NOT NULL,
+                    agent_name TEXT,
+                    task_id TEXT,
+                    caused_by INTEGER,
+                    payload TEXT NOT NULL,
+                    timestamp REAL NOT NULL,
+                    archived_at REAL NOT NULL
+                )
+            """)
+            self.connection.execute(
+                "CREATE INDEX IF NOT EXISTS idx_events_archive_run ON events_archive (run_id, event_id)"
+            )
+            self.connection.commit()
+
+    def append(self, event: KitchenEvent) -> KitchenEvent:
+        """Append an event, assigning its event_id"""
+        with self._lock:
+            cursor = self.connection.execute("""
+                INSERT INTO events (
+                    run_id, event_type, agent_name, task_id,
+                    caused_by, payload, timestamp
+                ) VALUES (?, ?, ?, ?, ?, ?, ?)
+            """, (
+                event.run_id,
+                event.event_type,
+                event.agent_name,
+                event.task_id,
+                event.caused_by,
+                json.dumps(event.payload, default=str),
+                event.timestamp
+            ))
+            self.connection.commit()
+            event.event_id = cursor.lastrowid
+        return event
+
+    def query(
+        self,
+        run_id: Optional[str] = None,
+        after_id: int = 0,
+        until: Optional[float] = None,
+        agent_name: Optional[str] = None,
+        event_type: Optional[str] = None,
+        limit: Optional[int] = None,
+        include_archived: bool = False
+    ) -> List[KitchenEvent]:
+        """Get events in append order, optionally filtered and including archived runs"""
+        clauses = ["event_id > ?"]
+        params: List[Any] = [after_id]
+
+        if run_id is not None:
+            clauses.append("run_id = ?")
+            params.append(run_id)
+        if until is not None:
+            clauses.append("timestamp <= ?")
+            params.append(until)
+        if agent_name is not None:
+            clauses.append("agent_name = ?")
+            params.append(agent_name)
+        if event_type is not None:
+            clauses.append("event_type = ?")
+            params.append(event_type)
+
+        where = " AND ".join(clauses)
+        columns = "event_id, run_id, event_type, agent_name, task_id, caused_by, payload, timestamp"
+        sql = f"SELECT {columns} FROM events WHERE {where}"
+        if include_archived:
+            sql += f" UNION ALL SELECT {columns} FROM events_archive WHERE {where}"
+            params = params + params
+        sql += " ORDER BY event_id"
+        if limit is not None:
+            sql += " LIMIT ?"
+            params.append(limit)
+
+        with self._lock:
+            rows = self.connection.execute(sql, params).fetchall()
+        return [self._row_to_event(row) for row in rows]
+
+    def iter_events(
+        self,
+        run_id: Optional[str] = None,
+        batch_size: int = 500,
+        include_archived: bool = False
+    ) -> Iterator[KitchenEvent]:
+        """Iterate over all events without loading them at once"""
+        after_id = 0
+        while True:
+            batch = self.query(
+                run_id=run_id, after_id=after_id, limit=batch_size, include_archived=include_archived
+            )
+            if not batch:
+                return
+            yield from batch
+            after_id = batch[-1].event_id
+
+    def replay(self, run_id: str, until: Optional[float] = None) -> Dict[str, Any]:
+        """Reconstruct kitchen state for a run as of a timestamp"""
+        state = new_replay_state(run_id, until)
+        for event in self.query(run_id=run_id, until=until, include_archived=True):
+            apply_event(state, event)
+        return state
+
+    def orders(self, run_id: str) -> List[Dict[str, Any]]:
+        """Every order of a run and where it stands, folded from its events in order"""
+        orders: Dict[str, Dict[str, Any]] = {}
+        for event in self.query(run_id=run_id, include_archived=True):
+            apply_order_event(orders, event)
+        return list(orders.values())
+
+    def archive(self, older_than: float) -> Dict[str, int]:
+        """Move every event of runs that finished before a timestamp into events_archive
+
+        Runs still in progress, and events without a run, stay in the hot table.
+        """
+        with self._lock:
+            placeholders = ", ".join("?" for _ in TERMINAL_EVENTS)
+            runs = [row[0] for row in self.connection.execute(f"""
+                SELECT run_id FROM events
+                WHERE run_id IS NOT NULL AND event_type IN ({placeholders})
+                GROUP BY run_id HAVING MAX(timestamp) < ?
+            """, (*TERMINAL_EVENTS, older_than)).fetchall()]
+
+            moved = 0
+            try:
+                for run_id in runs:
+                    self.connection.execute("""
+                        INSERT OR IGNORE INTO events_archive (
+                            event_id, run_id, event_type, agent_name, task_id,
+                            caused_by, payload, timestamp, archived_at
+                        )
+                        SELECT event_id, run_id, event_type, agent_name, task_id,
+                               caused_by, payload, timestamp, ?
+                        FROM events WHERE run_id = ?
+                    """, (time.time(), run_id))
+                    moved += self.connection.execute(
+                        "DELETE FROM events WHERE run_id = ?", (run_id,)
+                    ).rowcount
+                self.connection.commit()
+            except sqlite3.Error:
+                self.connection.rollback()
+                raise
+
+        if runs:
+            logger.info(f"Archived {moved} events from {len(runs)} finished runs")
+        return {"runs": len(runs), "events": moved}
+
+    def latest_event_id(self) -> int:
+        """Id of the newest event, archived or not; 0 when the log is empty"""
+        with self._lock:
+            row = self.connection.execute(
+                "SELECT MAX(id) FROM (SELECT MAX(event_id) AS id FROM events "
+                "UNION ALL SELECT MAX(event_id) FROM events_archive)"
+            ).fetchone()
+        return row[0] or 0
+
+    def counts(self) -> Dict[str, int]:
+        """Number of events in the hot table and in the archive"""
+        with self._lock:
+            live = self.connection.execute("SELECT COUNT(*) FROM events").fetchone()[0]
+            archived = self.connection.execute("SELECT COUNT(*) FROM events_archive").fetchone()[0]
+        return {"events": live, "archived": archived}
+
+    @staticmethod
+    def _row_to_event(row: sqlite3.Row) -> KitchenEvent:
+        return KitchenEvent(
+            event_id=row['event_id'],
+            run_id=row['run_id'],
+            event_type=row['event_type'],
+            agent_name=row['agent_name'],
+            task_id=row['task_id'],
+            caused_by=row['caused_by'],
+            payload=json.loads(row['payload']),
+            timestamp=row['timestamp']
+        )
+
+    def close(self):
+        """Close database connection"""
+        if self.connection:
+            self.connection.close()
+            logger.info("Event store connection closed")
+
+
+def new_replay_state(run_id: str, until: Optional[float] = None) -> Dict[str, Any]:
+    return {
+        "run_id": run_id,
+        "as_of": until,
+        "status": "unknown",
+        "events_applied": 0,
+        "last_event_id": None,
+        "agents": {},
+        "pending_tasks": {},
+        "messages": 0
+    }
+
+
+def _agent_state(state: Dict[str, Any], name: str) -> Dict[str, Any]:
+    return state["agents"].setdefault(name, {
+        "tasks_completed": 0,
+        "tasks_failed": 0,
+        "quality_total": 0.0,
+        "last_task": None,
+        "messages_sent": 0,
+        "messages_received": 0
+    })
+
+
+def apply_event(state: Dict[str, Any], event: KitchenEvent):
+    """Fold one event into a replay state"""
+    payload = event.payload
+
+    if event.event_type == "scenario_started":
+        state["status"] = "running"
+        for name in payload.get("agents", []):
+            _agent_state(state, name)
+    elif event.event_type == "task_assigned":
+        state["pending_tasks"][event.task_id] = {
+            "agent": event.agent_name,
+            "task_type": payload.get("task_type")
+        }
+    elif event.event_type == "order_served":
+        pass  # a stage, not a status: the order stays completed
+    elif event.event_type == "task_executed":
+        state["pending_tasks"].pop(event.task_id, None)
+        agent = _agent_state(state, event.agent_name)
+        if payload.get("success"):
+            agent["tasks_completed"] += 1
+        else:
+            agent["tasks_failed"] += 1
+        agent["quality_total"] += payload.get("quality_score", 0)
+        agent["last_task"] = payload.get("task_type")
+    elif event.event_type == "message_sent":
+        state["messages"] += 1
+        _agent_state(state, event.agent_name)["messages_sent"] += 1
+        recipient = payload.get("recipient")
+        if recipient:
+            _agent_state(state, recipient)["messages_received"] += 1
+    elif event.event_type in ("scenario_completed", "scenario_failed"):
+        state["status"] = event.event_type.split("_")[1]
+
+    state["events_applied"] += 1
+    state["last_event_id"] = event.event_id
+
+
+def apply_order_event(orders: Dict[str, Dict[str, Any]], event: KitchenEvent):
+    """Fold one event into a run's orders, keyed by task id; other events are ignored
+
+    Orders read as queued until started, then in progress until executed or
+    cancelled, and as held while the pass holds their course. Each keeps when
+    it reached each of ORDER_STAGES.
+    """
+    if event.event_type not in ORDER_EVENTS or event.task_id is None:
+        return
+    payload = event.payload
+    order = orders.setdefault(event.task_id, {
+        "task_id": event.task_id,
+        "task_type": None,
+        "agent_name": None,
+        "status": "queued",
+        "priority": "normal",
+        "table": None,
+        "complexity": None,
+        "complexity_score": None,
+        "notes": [],
+        "annotations": [],
+        "success": None,
+        "quality_score": None,
+        "cancel_reason": None,
+        "placed_at": event.timestamp,
+        "finished_at": None,
+        "stages": {"received": event.timestamp}  # stage -> when the order reached it
+    })
+
+    stage = STAGE_EVENTS.get(event.event_type)
+    if stage and (stage != "plate" or payload.get("success")):
+        order["stages"].setdefault(stage, event.timestamp)
+
+    if event.event_type == "order_placed":
+        order["table"] = payload.get("table")
+    elif event.event_type == "task_assigned":
+        order["task_type"] = payload.get("task_type")
+        order["agent_name"] = event.agent_name
+        if payload.get("complexity"):
+            order["complexity"] = payload["complexity"]["level"]
+            order["complexity_score"] = payload["complexity"]["score"]
+    elif event.event_type == "order_modified":
+        # A guest's or the API's change; voids and cuts are folded from the cancellation itself
+        if payload.get("modification"):
+            order["notes"].append(payload["modification"])
+        if payload.get("priority"):
+            order["priority"] = payload["priority"]
+            order["agent_name"] = event.agent_name or order["agent_name"]
+    elif event.event_type == "task_started":
+        order["status"] = "in_progress"
+        order["agent_name"] = event.agent_name
+    elif event.event_type == "order_escalated":
+        order["priority"] = payload.get("level", order["priority"])
+        order["agent_name"] = event.agent_name
+    elif event.event_type == "order_noted":
+        order["annotations"].append({
+            "author": event.agent_name,
+            "source": payload.get("source"),
+            "severity": payload.get("severity"),
+            "reason": payload.get("reason"),
+            "timestamp": event.timestamp
+        })
+    elif event.event_type == "order_held":
+        if order["status"] == "queued":
+            order["status"] = "held"
+    elif event.event_type == "order_fired":
+        if order["status"] == "held":
+            order["status"] = "queued"
+    elif event.event_type == "order_served":
+        pass  # a stage, not a status: the order stays completed
+    elif event.event_type == "task_executed":
+        order["task_type"] = order["task_type"] or payload.get("task_type")
+        order["status"] = "completed" if payload.get("success") else "failed"
+        order["success"] = payload.get("success")
+        order["quality_score"] = payload.get("quality_score")
+        order["finished_at"] = event.timestamp
+    else:  # order_cancelled, task_revoked
+        order["status"] = "cancelled"
+        order["cancel_reason"] = payload.get("reason")
+        order["finished_at"] = event.timestamp
+
+
+def order_timeline(order: Dict[str, Any], now: Optional[float] = None) -> List[Dict[str, Any]]:
+    """The stages an order has reached, each with when it began, seconds since it was received and how long it lasted
+
+    A stage lasts until the next one. The last stage of a failed or cancelled
+    order ends when it finished, and served has no duration. The stage an open
+    order is in runs until now, or has no duration without it.
+    """
+    reached = [(stage, order["stages"][stage]) for stage in ORDER_STAGES if stage in order["stages"]]
+    received = order["stages"]["received"]
+    timeline = []
+    for i, (stage, at) in enumerate(reached):
+        if i + 1 < len(reached):
+            until = reached[i + 1][1]
+        elif stage == "served":
+            until = None
+        elif order["status"] in ("failed", "cancelled"):
+            until = order["finished_at"]
+        else:
+            until = now
+        timeline.append({
+            "stage": stage,
+            "at": at,
+            "elapsed_seconds": round(at - received, 3),
+            "duration_seconds": round(until - at, 3) if until is not None else None
+        })
+    return timeline
@@ -0,0 +1,203 @@
+"""
+Run Export for ChefBench
+Flattens a run's event log, task records and order timings into tables for offline analysis
+"""
+
+import csv
+import io
+import json
+import zipfile
+from typing import Any, BinaryIO, Dict, List, Tuple
+import logging
+
+from models.models import KitchenEvent
+from database.event_store import EventStore, apply_order_event
+
+logger = logging.getLogger(__name__)
+
+EXPORT_FORMATS = ("csv", "parquet")
+
+BATCH_SIZE = 500  # events read, and written to Parquet, at a time
+
+# Columns of each exported table and their types, so every export of every run has the same schema
+EXPORT_COLUMNS: Dict[str, List[Tuple[str, type]]] = {
+    "events": [
+        ("event_id", int), ("run_id", str), ("event_type", str), ("agent_name", str), ("task_id", str),
+        ("caused_by", int), ("timestamp", float), ("payload", str),
+    ],
+    "tasks": [
+        ("task_id", str), ("task_type", str), ("agent_name", str), ("policy", str), ("assigned_at", float),
+        ("executed_at", float), ("success", bool), ("quality_score", float), ("reasoning_time", float),
+        ("execution_time", float), ("chosen_approach", str), ("degraded", bool), ("errors", int),
+        ("handoffs", int),
+    ],
+    "orders": [
+        ("task_id", str), ("table", int), ("task_type", str), ("agent_name", str), ("status", str),
+        ("priority", str), ("complexity", str), ("complexity_score", float), ("placed_at", float), ("assigned_at", float), ("finished_at", float),
+        ("wait_seconds", float), ("turnaround_seconds", float), ("modifications", int), ("annotations", int),
+        ("success", bool), ("quality_score", float), ("cancel_reason", str),
+    ],
+}
+
+
+def _task_row(task_id: str) -> Dict[str, Any]:
+    return {**{name: None for name, _ in EXPORT_COLUMNS["tasks"]}, "task_id": task_id, "errors": 0, "handoffs": 0}
+
+
+class RunExport:
+    """One pass over a run's events, yielding event rows while collecting its tasks and orders
+
+    Tasks are one row per task id: its (last) assignment, its execution if it
+    ran, and how many errors and hand-offs it went through. Orders add
+    timings to the event store's order view: wait is placement to
+    assignment, turnaround placement to being finished.
+    """
+
+    def __init__(self, event_store: EventStore, run_id: str):
+        self.event_store = event_store
+        self.run_id = run_id
+        self.tasks: Dict[str, Dict[str, Any]] = {}
+        self.orders: Dict[str, Dict[str, Any]] = {}
+        self.assigned_at: Dict[str, float] = {}
+        self.events = 0
+
+    def event_rows(self):
+        """Every event of the run, archived or not, in batches of flat rows"""
+        after_id = 0
+        while True:
+            batch = self.event_store.query(
+                run_id=self.run_id, after_id=after_id, limit=BATCH_SIZE, include_archived=True
+            )
+            if not batch:
+                return
+            after_id = batch[-1].event_id
+            for event in batch:
+                self._collect(event)
+            self.events += len(batch)
+            yield [
+                {
+                    "event_id": e.event_id,
+                    "run_id": e.run_id,
+                    "event_type": e.event_type,
+                    "agent_name": e.agent_name,
+                    "task_id": e.task_id,
+                    "caused_by": e.caused_by,
+                    "timestamp": e.timestamp,
+                    "payload": json.dumps(e.payload, default=str)
+                }
+                for e in batch
+            ]
+
+    def _collect(self, event: KitchenEvent):
+        apply_order_event(self.orders, event)
+        if event.task_id is None:
+            return
+        payload = event.payload
+        if event.event_type == "task_assigned":
+            task = self.tasks.setdefault(event.task_id, _task_row(event.task_id))
+            task.update(
+                task_type=payload.get("task_type"),
+                agent_name=event.agent_name,
+                policy=payload.get("policy"),
+                assigned_at=event.timestamp
+            )
+            self.assigned_at.setdefault(event.task_id, event.timestamp)
+        elif event.event_type == "task_executed":
+            task = self.tasks.setdefault(event.task_id, _task_row(event.task_id))
+            task.update(
+                task_type=task["task_type"] or payload.get("task_type"),
+                agent_name=event.agent_name,
+                executed_at=event.timestamp,
+                **{key: payload.get(key) for key in (
+                    "success", "quality_score", "reasoning_time", "execution_time", "chosen_approach", "degraded"
+                )}
+            )
+        elif event.event_type == "task_error":
+            self.tasks.setdefault(event.task_id, _task_row(event.task_id))["errors"] += 1
+        elif event.event_type == "task_handed_off":
+            self.tasks.setdefault(event.task_id, _task_row(event.task_id))["handoffs"] += 1
+
+    def task_rows(self) -> List[Dict[str, Any]]:
+        return list(self.tasks.values())
+
+    def order_rows(self) -> List[Dict[str, Any]]:
+        rows = []
+        for order in self.orders.values():
+            assigned_at = self.assigned_at.get(order["task_id"])
+            rows.append({
+                "task_id": order["task_id"],
+                "table": order["table"],
+                "task_type": order["task_type"],
+                "agent_name": order["agent_name"],
+                "status": order["status"],
+                "priority": order["priority"],
+                "complexity": order["complexity"],
+                "complexity_score": order["complexity_score"],
+                "placed_at": order["placed_at"],
+                "assigned_at": assigned_at,
+                "finished_at": order["finished_at"],
+                "wait_seconds": round(assigned_at - order["placed_at"], 3) if assigned_at is not None else None,
+                "turnaround_seconds": (
+                    round(order["finished_at"] - order["placed_at"], 3) if order["finished_at"] is not None else None
+                ),
+                "modifications": len(order["notes"]),
+                "annotations": len(order["annotations"]),
+                "success": order["success"],
+                "quality_score": order["quality_score"],
+                "cancel_reason": order["cancel_reason"]
+            })
+        return rows
+
+
+def _write_csv(archive: zipfile.ZipFile, table: str, batches):
+    """Stream rows into the archive as they come"""
+    columns = [name for name, _ in EXPORT_COLUMNS[table]]
+    with archive.open(f"{table}.csv", "w") as entry, io.TextIOWrapper(entry, encoding="utf-8", newline="") as out:
+        writer = csv.DictWriter(out, fieldnames=columns)
+        writer.writeheader()
+        for rows in batches:
+            writer.writerows(rows)
+
+
+def _write_parquet(archive: zipfile.ZipFile, table: str, batches):
+    """Write rows a row group per batch; Parquet needs a seekable file, so it's built in memory"""
+    import pyarrow as pa
+    import pyarrow.parquet as pq
+
+    types = {int: pa.int64(), float: pa.float64(), str: pa.string(), bool: pa.bool_()}
+    schema = pa.schema([(name, types[kind]) for name, kind in EXPORT_COLUMNS[table]])
+    out = io.BytesIO()
+    with pq.ParquetWriter(out, schema) as writer:
+        for rows in batches:
+            writer.write_table(pa.Table.from_pylist(rows, schema=schema))
+    archive.writestr(f"{table}.parquet", out.getvalue())
+
+
+def check_format(fmt: str):
+    """Raise ValueError for an unknown format, or Parquet without pyarrow installed"""
+    if fmt not in EXPORT_FORMATS:
+        raise ValueError(f"Unknown export format '{fmt}', expected one of {list(EXPORT_FORMATS)}")
+    if fmt == "parquet":
+        try:
+            import pyarrow.parquet  # noqa: F401
+        except ImportError:
+            raise ValueError("Parquet export needs pyarrow (pip install 'escoffier[export]')")
+
+
+def write_run_export(event_store: EventStore, run_id: str, fmt: str, out: BinaryIO) -> Dict[str, int]:
+    """Write a zip of events, tasks and orders tables in the format to out, returning each table's row count
+
+    The event log is read and written in batches; tasks and orders, one row
+    per task, are collected along the way and written after it.
+    """
+    check_format(fmt)
+    write = _write_parquet if fmt == "parquet" else _write_csv
+    export = RunExport(event_store, run_id)
+    with zipfile.ZipFile(out, "w", compression=zipfile.ZIP_DEFLATED) as archive:
+        write(archive, "events", export.event_rows())
+        tasks, orders = export.task_rows(), export.order_rows()
+        write(archive, "tasks", [tasks])
+        write(archive, "orders", [orders])
+    counts = {"events": export.events, "tasks": len(tasks), "orders": len(orders)}
+    logger.info(f"Exported run {run_id} as {fmt}: {counts}")
+    return counts
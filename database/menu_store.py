@@ -0,0 +1,161 @@
+"""
+Menu Store for ChefBench
+The planned menu, with its specialties, seasonal items and prices, what has been 86'd by hand, and what each item sold
+"""
+
+import sqlite3
+import threading
+import time
+from typing import Dict, List, Optional, Any
+from pathlib import Path
+import logging
+
+from observability import TracedConnection
+
+logger = logging.getLogger(__name__)
+
+
+class MenuStore:
+    """SQLite-backed menu items, kept across restarts"""
+
+    def __init__(self, db_path: str = "data/menu.db"):
+        self.db_path = Path(db_path)
+        self.db_path.parent.mkdir(parents=True, exist_ok=True)
+        self._lock = threading.Lock()
+        self.connection = sqlite3.connect(str(self.db_path), check_same_thread=False, factory=TracedConnection)
+        self.connection.row_factory = sqlite3.Row
+        self.initialize()
+
+    def initialize(self):
+        """Create the menu tables if they don't exist"""
+        with self._lock:
+            self.connection.execute("""
+                CREATE TABLE IF NOT EXISTS menu_items (
+                    recipe_id INTEGER PRIMARY KEY,
+                    specialty INTEGER NOT NULL DEFAULT 0,
+                    seasonal INTEGER NOT NULL DEFAULT 0,
+                    planned_by TEXT,
+                    price REAL,
+                    updated_at REAL NOT NULL
+                )
+            """)
+            # Menus planned before items had prices
+            columns = {row["name"] for row in self.connection.execute("PRAGMA table_info(menu_items)")}
+            if "price" not in columns:
+                self.connection.execute("ALTER TABLE menu_items ADD COLUMN price REAL")
+            self.connection.execute("""
+                CREATE TABLE IF NOT EXISTS menu_sales (
+                    evaluation_id TEXT NOT NULL,
+                    recipe_id INTEGER NOT NULL,
+                    sold INTEGER NOT NULL,
+                    food_cost REAL NOT NULL,
+                    revenue REAL NOT NULL,
+                    recorded_at REAL NOT NULL,
+                    PRIMARY KEY (evaluation_id, recipe_id)
+                )
+            """)
+            self.connection.execute("""
+                CREATE TABLE IF NOT EXISTS eighty_sixed (
+                    recipe_id INTEGER PRIMARY KEY,
+                    reason TEXT NOT NULL,
+                    updated_at REAL NOT NULL
+                )
+            """)
+            self.connection.commit()
+
+    def items(self) -> List[Dict[str, Any]]:
+        """The planned menu in recipe order; empty if none has been planned"""
+        with self._lock:
+            rows = self.connection.execute("SELECT * FROM menu_items ORDER BY recipe_id").fetchall()
+        return [
+            {**dict(row), "specialty": bool(row["specialty"]), "seasonal": bool(row["seasonal"])}
+            for row in rows
+        ]
+
+    def replace(self, items: List[Dict[str, Any]], planned_by: Optional[str] = None):
+        """Make items the whole menu, dropping whatever was planned before"""
+        now = time.time()
+        with self._lock:
+            self.connection.execute("DELETE FROM menu_items")
+            self.connection.executemany(
+                "INSERT INTO menu_items (recipe_id, specialty, seasonal, planned_by, price, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+                [
+                    (
+                        item["recipe_id"], int(item.get("specialty", False)), int(item.get("seasonal", False)),
+                        planned_by, item.get("price"), now
+                    )
+                    for item in items
+                ]
+            )
+            self.connection.commit()
+
+    def update(
+        self,
+        recipe_id: int,
+        specialty: bool,
+        seasonal: bool,
+        planned_by: Optional[str] = None,
+        price: Optional[float] = None
+    ):
+        with self._lock:
+            self.connection.execute("""
+                INSERT INTO menu_items (recipe_id, specialty, seasonal, planned_by, price, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+                ON CONFLICT (recipe_id) DO UPDATE SET
+                    specialty = excluded.specialty, seasonal = excluded.seasonal, planned_by = excluded.planned_by,
+                    price = excluded.price, updated_at = excluded.updated_at
+            """, (recipe_id, int(specialty), int(seasonal), planned_by, price, time.time()))
+            self.connection.commit()
+
+    def clear(self):
+        """Forget the planned menu"""
+        with self._lock:
+            self.connection.execute("DELETE FROM menu_items")
+            self.connection.commit()
+
+    def record_sales(self, evaluation_id: str, sales: Dict[int, Dict[str, Any]]):
+        """Keep what each item sold in a run ({"sold", "food_cost", "revenue"}), replacing any earlier record of it"""
+        now = time.time()
+        with self._lock:
+            self.connection.execute("DELETE FROM menu_sales WHERE evaluation_id = ?", (evaluation_id,))
+            self.connection.executemany(
+                "INSERT INTO menu_sales (evaluation_id, recipe_id, sold, food_cost, revenue, recorded_at) VALUES (?, ?, ?, ?, ?, ?)",
+                [
+                    (evaluation_id, recipe_id, item["sold"], item["food_cost"], item["revenue"], now)
+                    for recipe_id, item in sales.items()
+                ]
+            )
+            self.connection.commit()
+
+    def sales(self, since: Optional[float] = None) -> Dict[int, Dict[str, Any]]:
+        """Recipe id -> portions sold, their food cost and revenue, over every run recorded (since a time, if given)"""
+        with self._lock:
+            rows = self.connection.execute("""
+                SELECT recipe_id, SUM(sold) AS sold, SUM(food_cost) AS food_cost, SUM(revenue) AS revenue,
+                       COUNT(*) AS runs
+                FROM menu_sales WHERE recorded_at >= ? GROUP BY recipe_id
+            """, (since or 0.0,)).fetchall()
+        return {row["recipe_id"]: {k: row[k] for k in ("sold", "food_cost", "revenue", "runs")} for row in rows}
+
+    def eighty_sixed(self) -> Dict[int, str]:
+        """Recipe id -> reason, for items 86'd by hand"""
+        with self._lock:
+            rows = self.connection.execute("SELECT recipe_id, reason FROM eighty_sixed").fetchall()
+        return {row["recipe_id"]: row["reason"] for row in rows}
+
+    def eighty_six(self, recipe_id: int, reason: str):
+        with self._lock:
+            self.connection.execute(
+                "INSERT OR REPLACE INTO eighty_sixed (recipe_id, reason, updated_at) VALUES (?, ?, ?)",
+                (recipe_id, reason, time.time())
+            )
+            self.connection.commit()
+
+    def restore(self, recipe_id: int):
+        with self._lock:
+            self.connection.execute("DELETE FROM eighty_sixed WHERE recipe_id = ?", (recipe_id,))
+            self.connection.commit()
+
+    def close(self):
+        """Close database connection"""
+        if self.connection:
+            self.connection.close()
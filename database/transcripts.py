@@ -0,0 +1,122 @@
+"""
+Transcript Store for ChefBench
+Persisted agent prompt/response pairs for inspecting why an agent decided what it did
+"""
+
+import sqlite3
+import json
+import threading
+from typing import Dict, List, Optional, Any
+from pathlib import Path
+import logging
+
+from observability import TracedConnection
+
+logger = logging.getLogger(__name__)
+
+
+class TranscriptStore:
+    """SQLite-backed log of LLM prompts and responses"""
+
+    def __init__(self, db_path: str = "data/transcripts.db"):
+        self.db_path = Path(db_path)
+        self.db_path.parent.mkdir(parents=True, exist_ok=True)
+        self._lock = threading.Lock()
+        self.connection = sqlite3.connect(str(self.db_path), check_same_thread=False, factory=TracedConnection)
+        self.connection.row_factory = sqlite3.Row
+        self.initialize()
+
+    def initialize(self):
+        """Create the transcripts table if it doesn't exist"""
+        with self._lock:
+            self.connection.execute("""
+                CREATE TABLE IF NOT EXISTS transcripts (
+                    transcript_id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    run_id TEXT,
+                    agent_name TEXT NOT NULL,
+                    model_name TEXT,
+                    task_id TEXT,
+                    prompt TEXT NOT NULL,
+                    response TEXT NOT NULL,
+                    details TEXT NOT NULL,
+                    timestamp REAL NOT NULL
+                )
+            """)
+            self.connection.execute(
+                "CREATE INDEX IF NOT EXISTS idx_transcripts_run ON transcripts (run_id, transcript_id)"
+            )
+            self.connection.commit()
+
+    def append(self, entry: Dict[str, Any]) -> int:
+        """Store one transcript entry and return its id"""
+        with self._lock:
+            cursor = self.connection.execute("""
+                INSERT INTO transcripts (
+                    run_id, agent_name, model_name, task_id,
+                    prompt, response, details, timestamp
+                ) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+            """, (
+                entry.get("run_id"),
+                entry["agent_name"],
+                entry.get("model_name"),
+                entry.get("task_id"),
+                entry["prompt"],
+                entry["response"],
+                json.dumps(entry.get("details", {}), default=str),
+                entry["timestamp"]
+            ))
+            self.connection.commit()
+            return cursor.lastrowid
+
+    def query(
+        self,
+        run_id: Optional[str] = None,
+        agent_name: Optional[str] = None,
+        task_id: Optional[str] = None,
+        contains: Optional[str] = None,
+        after_id: int = 0,
+        limit: Optional[int] = None
+    ) -> List[Dict[str, Any]]:
+        """Get transcripts in recording order, optionally filtered"""
+        clauses = ["transcript_id > ?"]
+        params: List[Any] = [after_id]
+
+        if run_id is not None:
+            clauses.append("run_id = ?")
+            params.append(run_id)
+        if agent_name is not None:
+            clauses.append("agent_name = ?")
+            params.append(agent_name)
+        if task_id is not None:
+            clauses.append("task_id = ?")
+            params.append(task_id)
+        if contains:
+            clauses.append("(prompt LIKE ? OR response LIKE ?)")
+            params.extend([f"%{contains}%"] * 2)
+
+        sql = f"SELECT * FROM transcripts WHERE {' AND '.join(clauses)} ORDER BY transcript_id"
+        if limit is not None:
+            sql += " LIMIT ?"
+            params.append(limit)
+
+        with self._lock:
+            rows = self.connection.execute(sql, params).fetchall()
+        return [self._row_to_dict(row) for row in rows]
+
+    def get(self, transcript_id: int) -> Optional[Dict[str, Any]]:
+        with self._lock:
+            row = self.connection.execute(
+                "SELECT * FROM transcripts WHERE transcript_id = ?", (transcript_id,)
+            ).fetchone()
+        return self._row_to_dict(row) if row else None
+
+    @staticmethod
+    def _row_to_dict(row: sqlite3.Row) -> Dict[str, Any]:
+        data = dict(row)
+        data["details"] = json.loads(data["details"])
+        return data
+
+    def close(self):
+        """Close database connection"""
+        if self.connection:
+            self.connection.close()
@@ -0,0 +1,71 @@
+"""
+Dining room tables, reservations, course pacing, front-of-house service, customers and service period reports
+"""
+
+from .floor import (
+    TABLE_STATUSES,
+    COURSE_PACING,
+    ORDER_TASKS,
+    PASS_TASKS,
+    Table,
+    Reservation,
+    FloorPlan
+)
+from .service import (
+    REQUEST_KINDS,
+    GuestRequest,
+    Server,
+    GuestSimulator,
+    FrontOfHouse,
+    service_summary
+)
+from .customers import (
+    DAY_OF_WEEK,
+    SERVICE_PERIODS,
+    DemandConfig,
+    CustomerOrder,
+    CustomerSimulator,
+    demand_summary
+)
+from .periods import (
+    OTHER_PERIOD,
+    service_report,
+    combine_periods
+)
+from .complexity import (
+    COMPLEXITY_LEVELS,
+    OrderComplexity,
+    score_order,
+    complexity_level,
+    time_factor
+)
+
+__all__ = [
+    "TABLE_STATUSES",
+    "COURSE_PACING",
+    "ORDER_TASKS",
+    "PASS_TASKS",
+    "Table",
+    "Reservation",
+    "FloorPlan",
+    "REQUEST_KINDS",
+    "GuestRequest",
+    "Server",
+    "GuestSimulator",
+    "FrontOfHouse",
+    "service_summary",
+    "DAY_OF_WEEK",
+    "SERVICE_PERIODS",
+    "DemandConfig",
+    "CustomerOrder",
+    "CustomerSimulator",
+    "demand_summary",
+    "OTHER_PERIOD",
+    "service_report",
+    "combine_periods",
+    "COMPLEXITY_LEVELS",
+    "OrderComplexity",
+    "score_order",
+    "complexity_level",
+    "time_factor"
+]
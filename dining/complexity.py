@@ -0,0 +1,94 @@
+"""
+Order Complexity for ChefBench
+Scores how hard an order is to cook from its item count, techniques, coursing and modifications
+"""
+
+from dataclasses import dataclass, asdict
+from typing import Dict, List, Optional, Tuple, Any
+
+from models.models import TaskType
+from equipment import station_for
+from recipes.techniques import TECHNIQUES
+from .floor import ORDER_TASKS
+
+COMPLEXITY_LEVELS = ("simple", "moderate", "complex")
+
+# Share of the score each part of an order carries
+COMPLEXITY_WEIGHTS = {"items": 0.25, "technique": 0.35, "coursing": 0.2, "modifications": 0.2}
+
+# Where each part tops out: portions, ingredients in a dish, stations the order passes and changes asked for
+FULL_ITEMS = 8
+FULL_INGREDIENTS = 12
+FULL_STATIONS = 3
+FULL_MODIFICATIONS = 4
+
+# Lowest score of each level above simple
+COMPLEXITY_THRESHOLDS = {"moderate": 0.3, "complex": 0.55}
+
+# How much longer than usual an order of each level is expected to take
+COMPLEXITY_TIME_FACTORS = {"simple": 0.85, "moderate": 1.0, "complex": 1.3}
+
+
+@dataclass
+class OrderComplexity:
+    """How hard an order is, in [0, 1], and the level the score falls in"""
+    score: float
+    level: str
+    items: int
+    technique: float
+    stations: int
+    modifications: int
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def _technique(task_type: TaskType, context: Dict[str, Any]) -> float:
+    """Senior tasks, demanding cooking techniques and long ingredient lists take more skill"""
+    role_level = max([task_type.min_role_level] + [TECHNIQUES[t].min_role_level for t in context.get('techniques', [])])
+    level = (role_level - 1) / 5
+    ingredients = min(len(context.get('ingredients', [])) / FULL_INGREDIENTS, 1.0)
+    return 0.6 * level + 0.4 * ingredients
+
+
+def score_order(lines: List[Tuple[TaskType, Dict[str, Any]]]) -> OrderComplexity:
+    """Score an order from its tasks, one per portion
+
+    Modifications are the substitutions the order needed, the guests'
+    dietary restrictions and changes already asked of it, each counted once.
+    """
+    if not lines:
+        return OrderComplexity(0.0, COMPLEXITY_LEVELS[0], 0, 0.0, 0, 0)
+    technique = sum(_technique(task_type, context) for task_type, context in lines) / len(lines)
+    stations = len({station_for(task_type) for task_type, _ in lines})
+    modifications = len(
+        {f"substitution:{i}" for _, context in lines for i in context.get('substitutions', {})}
+        | {f"restriction:{r}" for _, context in lines for r in context.get('dietary_restrictions', [])}
+        | {f"modification:{m}" for _, context in lines for m in context.get('modifications', [])}
+    )
+    parts = {
+        "items": min((len(lines) - 1) / (FULL_ITEMS - 1), 1.0),
+        "technique": technique,
+        "coursing": min((stations - 1) / (FULL_STATIONS - 1), 1.0),
+        "modifications": min(modifications / FULL_MODIFICATIONS, 1.0),
+    }
+    score = round(sum(COMPLEXITY_WEIGHTS[name] * value for name, value in parts.items()), 4)
+    level = next(
+        (name for name, threshold in sorted(COMPLEXITY_THRESHOLDS.items(), key=lambda t: -t[1]) if score >= threshold),
+        COMPLEXITY_LEVELS[0]
+    )
+    return OrderComplexity(score, level, len(lines), round(technique, 4), stations, modifications)
+
+
+def complexity_level(task_type: TaskType, context: Dict[str, Any]) -> Optional[str]:
+    """An order task's level as scored when the order was accepted, or scored alone if it wasn't; None for other tasks"""
+    if task_type.function_name not in ORDER_TASKS:
+        return None
+    if context.get('complexity'):
+        return context['complexity']['level']
+    return score_order([(task_type, context)]).level
+
+
+def time_factor(level: Optional[str]) -> float:
+    """Multiplier on a task's usual duration for its order's complexity; 1 for tasks that aren't orders"""
+    return COMPLEXITY_TIME_FACTORS.get(level, 1.0) if level else 1.0
@@ -0,0 +1,290 @@
+"""
+Customers for ChefBench
+Seeded parties who order from the menu through a run, following demand curves over a service day
+"""
+
+import json
+import math
+import os
+import random
+from dataclasses import dataclass, field, fields, asdict
+from typing import Dict, List, Optional, Tuple, Any
+import logging
+
+from recipes.ingredients import ALLERGENS, DIETARY_TAGS
+
+logger = logging.getLogger(__name__)
+
+DAYS = ("monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday")
+
+# How busy each day is against the base rate
+DAY_OF_WEEK = {
+    "monday": 0.6,
+    "tuesday": 0.7,
+    "wednesday": 0.8,
+    "thursday": 0.9,
+    "friday": 1.3,
+    "saturday": 1.5,
+    "sunday": 1.1,
+}
+
+# What a guest with a special request can't have, or must be served
+SPECIAL_REQUESTS = ALLERGENS + DIETARY_TAGS
+
+# The parts of the service day reported on apart, as hours [start, end)
+SERVICE_PERIODS = {"lunch": (11.0, 16.0), "dinner": (16.0, 23.0)}
+
+
+@dataclass
+class DemandConfig:
+    """How many customers come in over a service day, and what they order
+
+    The arrival rate is orders_per_hour, times the day's multiplier, plus a
+    bell-shaped peak around lunch and another around dinner, each adding
+    its boost times the base rate at its height. Dishes are picked by a
+    Zipf-like popularity over the menu, in a seeded order, with
+    item_weights (recipe id -> weight) standing in for it where given.
+    """
+    orders_per_hour: float = 1.0  # base rate on an average day, outside the peaks
+    day: str = "friday"
+    day_multipliers: Dict[str, float] = field(default_factory=lambda: dict(DAY_OF_WEEK))
+    service_start: float = 11.0  # hour the doors open
+    service_end: float = 23.0  # hour they close
+    lunch_peak: float = 12.5
+    lunch_boost: float = 2.0
+    dinner_peak: float = 19.5
+    dinner_boost: float = 3.0
+    peak_width: float = 1.0  # hours either side of a peak to where it has fallen to ~60%
+    popularity_skew: float = 1.0  # 0 orders every dish alike; higher piles orders onto the favourites
+    item_weights: Dict[int, float] = field(default_factory=dict)
+    party_size: Tuple[int, int] = (1, 4)
+    starter_rate: float = 0.3  # share of guests who also order a starter
+    dessert_rate: float = 0.25
+    special_request_rate: float = 0.1  # share of parties ordering around an allergen or dietary need
+    modification_rate: float = 0.2  # share of table orders guests change or cancel once placed
+    cancel_share: float = 0.25  # of those, the share that are cancellations
+    max_orders: int = 60  # the day stops taking customers past this many
+    service_periods: Dict[str, Tuple[float, float]] = field(default_factory=lambda: dict(SERVICE_PERIODS))
+
+    def validate(self):
+        if self.day not in DAYS:
+            raise ValueError(f"Unknown day '{self.day}', expected one of {list(DAYS)}")
+        if self.orders_per_hour <= 0:
+            raise ValueError("orders_per_hour must be positive")
+        if set(self.day_multipliers) - set(DAYS) or any(m < 0 for m in self.day_multipliers.values()):
+            raise ValueError("day_multipliers takes days of the week, and can't be negative")
+        if not 0 <= self.service_start < self.service_end <= 24:
+            raise ValueError("Service hours must satisfy 0 <= service_start < service_end <= 24")
+        if self.lunch_boost < 0 or self.dinner_boost < 0 or self.peak_width <= 0:
+            raise ValueError("Peak boosts can't be negative and peak_width must be positive")
+        if self.popularity_skew < 0 or any(w < 0 for w in self.item_weights.values()):
+            raise ValueError("popularity_skew and item_weights can't be negative")
+        if not 1 <= self.party_size[0] <= self.party_size[1] <= 20:
+            raise ValueError("party_size must be a range within 1-20")
+        for name in ("starter_rate", "dessert_rate", "special_request_rate", "modification_rate", "cancel_share"):
+            if not 0 <= getattr(self, name) <= 1:
+                raise ValueError(f"{name} must be between 0 and 1")
+        if self.max_orders < 1:
+            raise ValueError("max_orders must be at least 1")
+        periods = sorted(self.service_periods.values())
+        if any(not 0 <= start < end <= 24 for start, end in periods):
+            raise ValueError("Service periods must satisfy 0 <= start < end <= 24")
+        if any(earlier[1] > later[0] for earlier, later in zip(periods, periods[1:])):
+            raise ValueError("Service periods can't overlap")
+
+    def with_overrides(self, overrides: Optional[Dict[str, Any]]) -> "DemandConfig":
+        """A copy with the fields given replaced; raises ValueError for unknown fields or the result is invalid"""
+        names = {f.name for f in fields(self)}
+        unknown = set(overrides or {}) - names
+        if unknown:
+            raise ValueError(f"Unknown demand fields: {', '.join(sorted(unknown))}")
+        data = {**asdict(self), **(overrides or {})}
+        try:
+            config = DemandConfig(**{
+                **data,
+                "day_multipliers": {**DAY_OF_WEEK, **{str(d).lower(): float(m) for d, m in data["day_multipliers"].items()}},
+                "item_weights": {int(k): float(w) for k, w in data["item_weights"].items()},
+                "party_size": tuple(int(n) for n in data["party_size"]),
+                "service_periods": {
+                    str(name): tuple(float(h) for h in hours) for name, hours in data["service_periods"].items()
+                },
+                "day": str(data["day"]).lower()
+            })
+        except (TypeError, AttributeError) as e:
+            raise ValueError(str(e))
+        if len(config.party_size) != 2:
+            raise ValueError("party_size must be [smallest, largest]")
+        if any(len(hours) != 2 for hours in config.service_periods.values()):
+            raise ValueError("Each service period must be [start, end]")
+        config.validate()
+        return config
+
+    @classmethod
+    def from_env(cls) -> "DemandConfig":
+        """Defaults, overridden by a JSON object in CHEFBENCH_DEMAND"""
+        raw = os.environ.get("CHEFBENCH_DEMAND")
+        if not raw:
+            return cls()
+        try:
+            names = {f.name for f in fields(cls)}
+            return cls().with_overrides({k: v for k, v in json.loads(raw).items() if k in names})
+        except (ValueError, TypeError, AttributeError) as e:
+            logger.error(f"Ignoring invalid CHEFBENCH_DEMAND: {e}")
+            return cls()
+
+    def rate(self, hour: float) -> float:
+        """Expected orders an hour at this time of day"""
+        if not self.service_start <= hour <= self.service_end:
+            return 0.0
+        base = self.orders_per_hour * self.day_multipliers.get(self.day, 1.0)
+        peaks = sum(
+            boost * math.exp(-0.5 * ((hour - peak) / self.peak_width) ** 2)
+            for peak, boost in ((self.lunch_peak, self.lunch_boost), (self.dinner_peak, self.dinner_boost))
+        )
+        return base * (1 + peaks)
+
+    def hour_at(self, seconds: float, duration_seconds: float) -> float:
+        """The time of day a moment of the run stands for, the run spanning the whole service day"""
+        if not duration_seconds:
+            return self.service_start
+        share = min(max(seconds / duration_seconds, 0.0), 1.0)
+        return self.service_start + share * (self.service_end - self.service_start)
+
+    def period_at(self, hour: float) -> Optional[str]:
+        """The service period an hour falls in, or None between periods; the closing hour belongs to the last"""
+        return next((
+            name for name, (start, end) in self.service_periods.items()
+            if start <= hour < end or hour == end == self.service_end
+        ), None)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            **asdict(self),
+            "party_size": list(self.party_size),
+            "service_periods": {name: list(hours) for name, hours in self.service_periods.items()}
+        }
+
+
+@dataclass
+class CustomerOrder:
+    """One party's order, coming in partway through the run"""
+    arrive_at: float  # seconds into the run
+    hour: float  # time of day it stands for
+    party_size: int
+    items: List[Dict[str, Any]]  # recipe_id, course and quantity, as POST /orders takes them
+    table: Optional[int] = None
+    dietary_restrictions: List[str] = field(default_factory=list)
+
+    def to_order(self) -> Dict[str, Any]:
+        """The order as a POST /orders/bulk entry"""
+        return {
+            "arrive_at": self.arrive_at,
+            "table": self.table,
+            "party_size": self.party_size,
+            "items": self.items,
+            "dietary_restrictions": self.dietary_restrictions
+        }
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class CustomerSimulator:
+    """Seeded customers who order from the menu through a run, on the demand curves of a service day
+
+    The service day is squeezed into the run: its first second is the doors
+    opening, its last the close. Parties arrive as a Poisson process whose
+    rate follows the demand curve, and each guest has a main, sometimes with
+    a starter or dessert. Once an order is placed, a GuestSimulator run at
+    the demand's modification_rate and cancel_share has its guests change or
+    cancel it.
+    """
+
+    def __init__(self, seed: Optional[int] = None, config: Optional[DemandConfig] = None):
+        self.seed = seed
+        self.config = config or DemandConfig.from_env()
+        self.rng = random.Random(f"customers-{seed}") if seed is not None else random.Random()
+
+    def arrivals(self) -> List[float]:
+        """Hours of the day parties come in, by thinning a Poisson process at the peak rate"""
+        config = self.config
+        step = 1 / 60
+        peak = max(config.rate(config.service_start + i * step)
+                   for i in range(int((config.service_end - config.service_start) / step) + 1))
+        hours, hour = [], config.service_start
+        while peak > 0 and len(hours) < config.max_orders:
+            hour += self.rng.expovariate(peak)
+            if hour > config.service_end:
+                break
+            if self.rng.random() < config.rate(hour) / peak:
+                hours.append(hour)
+        return hours
+
+    def popularity(self, recipes: List[Dict[str, Any]]) -> List[float]:
+        """Each dish's weight: its item_weights entry, else Zipf-like by its rank in a seeded order"""
+        ranks = list(range(len(recipes)))
+        (random.Random(f"menu-{self.seed}") if self.seed is not None else self.rng).shuffle(ranks)
+        return [
+            self.config.item_weights.get(recipe['id'], 1 / (rank + 1) ** self.config.popularity_skew)
+            for recipe, rank in zip(recipes, ranks)
+        ]
+
+    def orders(
+        self,
+        recipes: List[Dict[str, Any]],
+        duration_seconds: float,
+        tables: Optional[List[int]] = None
+    ) -> List[CustomerOrder]:
+        """The parties who come in over a run of this length, ordering from these menu items
+
+        Orders go to the tables given in turn, or to no table without any. A
+        special request is left to the kitchen to honour, or refuse the order
+        over.
+        """
+        weights = self.popularity(recipes)
+        if not sum(weights):
+            return []
+        config = self.config
+        span = config.service_end - config.service_start
+        orders = []
+        for index, hour in enumerate(self.arrivals()):
+            party = self.rng.randint(*config.party_size)
+            lines: Dict[Tuple[int, str], int] = {}
+            for _ in range(party):
+                # Courses as providers.holds names them
+                courses = ["main"]
+                if self.rng.random() < config.starter_rate:
+                    courses.insert(0, "starter")
+                if self.rng.random() < config.dessert_rate:
+                    courses.append("dessert")
+                for course in courses:
+                    recipe = self.rng.choices(recipes, weights)[0]
+                    lines[(recipe['id'], course)] = lines.get((recipe['id'], course), 0) + 1
+            special = self.rng.random() < config.special_request_rate
+            orders.append(CustomerOrder(
+                arrive_at=round((hour - config.service_start) / span * duration_seconds, 2),
+                hour=round(hour, 2),
+                party_size=party,
+                items=[
+                    {"recipe_id": recipe_id, "course": course, "quantity": quantity}
+                    for (recipe_id, course), quantity in lines.items()
+                ],
+                table=tables[index % len(tables)] if tables else None,
+                dietary_restrictions=[self.rng.choice(SPECIAL_REQUESTS)] if special else []
+            ))
+        return orders
+
+
+def demand_summary(orders: List[CustomerOrder]) -> Dict[str, Any]:
+    """How the orders fell over the day: counts by hour, the busiest hour, and special requests"""
+    by_hour: Dict[int, int] = {}
+    for order in orders:
+        by_hour[int(order.hour)] = by_hour.get(int(order.hour), 0) + 1
+    return {
+        "orders": len(orders),
+        "covers": sum(order.party_size for order in orders),
+        "portions": sum(item["quantity"] for order in orders for item in order.items),
+        "special_requests": sum(1 for order in orders if order.dietary_restrictions),
+        "by_hour": dict(sorted(by_hour.items())),
+        "busiest_hour": max(by_hour, key=by_hour.get) if by_hour else None
+    }
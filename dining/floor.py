@@ -0,0 +1,267 @@
+"""
+Floor Plan for ChefBench
+Tables, reservations and seating, with course pacing hints for the kitchen
+"""
+
+import json
+import time
+import uuid
+from dataclasses import dataclass, field, asdict
+from pathlib import Path
+from typing import Dict, List, Optional, Any
+import logging
+
+logger = logging.getLogger(__name__)
+
+# A table moves forward through these as its meal goes on; paid tables are cleared back to available
+TABLE_STATUSES = ["available", "reserved", "seated", "fired", "dessert", "paid"]
+DINING_STATUSES = ("seated", "fired", "dessert")
+
+# Minutes a table should spend at each stage before moving on
+COURSE_PACING = {"seated": 10, "fired": 25, "dessert": 15}
+
+# Minutes either side of the target that still count as on pace
+PACING_TOLERANCE = 5
+
+# Scenario tasks that cook a guest's order, and so belong to a table
+ORDER_TASKS = {"basic_cooking", "cooking_execution", "sauce_preparation", "plating_design"}
+
+# Tasks run from the pass, which see the pacing of every table
+PASS_TASKS = {"timing_coordination", "quality_control", "staff_coordination"}
+
+
+@dataclass
+class Table:
+    """A table and who is sitting at it"""
+    number: int
+    capacity: int
+    status: str = "available"
+    party_size: int = 0
+    reservation_id: Optional[str] = None
+    seated_at: Optional[float] = None
+    status_changed_at: float = field(default_factory=time.time)
+    task_ids: List[str] = field(default_factory=list)  # scenario tasks cooked for this table
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class Reservation:
+    """A booking for a party at a time"""
+    name: str
+    party_size: int
+    time: float  # unix timestamp
+    status: str = "booked"  # booked | seated | cancelled
+    table: Optional[int] = None
+    reservation_id: str = field(default_factory=lambda: uuid.uuid4().hex[:12])
+    created_at: float = field(default_factory=time.time)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class FloorPlan:
+    """Tables and reservations for the dining room
+
+    Parties are seated at the smallest free table that fits them. A
+    reservation holds its table from when it is assigned until the party is
+    seated or the booking is cancelled.
+    """
+
+    def __init__(self, path: Optional[str] = None):
+        self.path = Path(path) if path else None
+        self.tables: Dict[int, Table] = {}
+        self.reservations: Dict[str, Reservation] = {}
+        if self.path and self.path.exists():
+            self.load()
+
+    # Tables
+
+    def add_table(self, number: int, capacity: int) -> Table:
+        if number in self.tables:
+            raise ValueError(f"Table {number} already exists")
+        if capacity < 1:
+            raise ValueError("Capacity must be at least 1")
+        table = Table(number, capacity)
+        self.tables[number] = table
+        self.save()
+        return table
+
+    def remove_table(self, number: int) -> Table:
+        table = self._table(number)
+        if table.status in DINING_STATUSES:
+            raise ValueError(f"Table {number} is {table.status}")
+        del self.tables[number]
+        self.save()
+        return table
+
+    def _table(self, number: int) -> Table:
+        if number not in self.tables:
+            raise KeyError(f"Unknown table {number}")
+        return self.tables[number]
+
+    def _best_fit(self, party_size: int) -> Optional[Table]:
+        fits = [t for t in self.tables.values() if t.status == "available" and t.capacity >= party_size]
+        return min(fits, key=lambda t: (t.capacity, t.number)) if fits else None
+
+    # Reservations
+
+    def reserve(self, name: str, party_size: int, at: float) -> Reservation:
+        """Book a party, holding the best-fitting free table when there is one"""
+        if not any(t.capacity >= party_size for t in self.tables.values()):
+            raise ValueError(f"No table seats a party of {party_size}")
+        reservation = Reservation(name, party_size, at)
+        table = self._best_fit(party_size)
+        if table:
+            table.status = "reserved"
+            table.reservation_id = reservation.reservation_id
+            table.status_changed_at = time.time()
+            reservation.table = table.number
+        self.reservations[reservation.reservation_id] = reservation
+        self.save()
+        return reservation
+
+    def cancel(self, reservation_id: str) -> Reservation:
+        reservation = self._reservation(reservation_id)
+        if reservation.status != "booked":
+            raise ValueError(f"Reservation is {reservation.status}")
+        reservation.status = "cancelled"
+        if reservation.table is not None and reservation.table in self.tables:
+            table = self.tables[reservation.table]
+            if table.reservation_id == reservation_id and table.status == "reserved":
+                self._clear(table)
+        self.save()
+        return reservation
+
+    def _reservation(self, reservation_id: str) -> Reservation:
+        if reservation_id not in self.reservations:
+            raise KeyError(f"Unknown reservation {reservation_id}")
+        return self.reservations[reservation_id]
+
+    def upcoming(self) -> List[Reservation]:
+        return sorted((r for r in self.reservations.values() if r.status == "booked"), key=lambda r: r.time)
+
+    # Seating
+
+    def seat(self, reservation_id: Optional[str] = None, party_size: Optional[int] = None) -> Table:
+        """Seat a reservation at its held table, or a walk-in party at the best free fit"""
+        reservation = self._reservation(reservation_id) if reservation_id else None
+        if reservation:
+            if reservation.status != "booked":
+                raise ValueError(f"Reservation is {reservation.status}")
+            party_size = reservation.party_size
+            held = self.tables.get(reservation.table)
+            table = held if held and held.reservation_id == reservation_id else self._best_fit(party_size)
+        elif party_size:
+            table = self._best_fit(party_size)
+        else:
+            raise ValueError("Seating needs a reservation or a party size")
+        if table is None:
+            raise ValueError(f"No free table for a party of {party_size}")
+
+        now = time.time()
+        table.status = "seated"
+        table.party_size = party_size
+        table.seated_at = now
+        table.status_changed_at = now
+        table.task_ids = []
+        if reservation:
+            reservation.status = "seated"
+            reservation.table = table.number
+            table.reservation_id = reservation.reservation_id
+        self.save()
+        return table
+
+    def advance(self, number: int, status: str) -> Table:
+        """Move a table on to a later status; paid tables are cleared for the next party"""
+        table = self._table(number)
+        if status not in TABLE_STATUSES:
+            raise ValueError(f"Unknown table status '{status}', expected one of {TABLE_STATUSES}")
+        if table.status not in DINING_STATUSES:
+            raise ValueError(f"Table {number} is {table.status}, not seated")
+        if TABLE_STATUSES.index(status) <= TABLE_STATUSES.index(table.status):
+            raise ValueError(f"Table {number} is already {table.status}")
+
+        if status == "paid":
+            self._clear(table)
+        else:
+            table.status = status
+            table.status_changed_at = time.time()
+        self.save()
+        return table
+
+    def _clear(self, table: Table):
+        table.status = "available"
+        table.party_size = 0
+        table.reservation_id = None
+        table.seated_at = None
+        table.status_changed_at = time.time()
+
+    # Orders
+
+    def dining_tables(self) -> List[Table]:
+        return sorted((t for t in self.tables.values() if t.status in DINING_STATUSES), key=lambda t: t.seated_at)
+
+    def attach_tasks(self, task_ids: List[str]) -> Dict[str, int]:
+        """Spread tasks over the dining tables, longest seated first, returning task id -> table"""
+        tables = self.dining_tables()
+        if not tables:
+            return {}
+        placed = {}
+        for i, task_id in enumerate(task_ids):
+            table = tables[i % len(tables)]
+            table.task_ids.append(task_id)
+            placed[task_id] = table.number
+        self.save()
+        return placed
+
+    def pacing(self, now: Optional[float] = None) -> List[Dict[str, Any]]:
+        """How each dining table is doing against COURSE_PACING, most behind first"""
+        now = now or time.time()
+        hints = []
+        for table in self.dining_tables():
+            in_stage = (now - table.status_changed_at) / 60
+            target = COURSE_PACING[table.status]
+            if in_stage > target + PACING_TOLERANCE:
+                pace = "behind"
+            elif in_stage < target - PACING_TOLERANCE:
+                pace = "ahead"
+            else:
+                pace = "on_pace"
+            hints.append({
+                "table": table.number,
+                "status": table.status,
+                "party_size": table.party_size,
+                "seated_minutes": round((now - table.seated_at) / 60, 1),
+                "stage_minutes": round(in_stage, 1),
+                "target_minutes": target,
+                "pace": pace
+            })
+        return sorted(hints, key=lambda h: h["stage_minutes"] - h["target_minutes"], reverse=True)
+
+    # Persistence
+
+    def save(self):
+        if not self.path:
+            return
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        with open(self.path, 'w') as f:
+            json.dump({
+                "tables": [t.to_dict() for t in self.tables.values()],
+                "reservations": [r.to_dict() for r in self.reservations.values()]
+            }, f, indent=2)
+
+    def load(self):
+        with open(self.path, 'r', encoding='utf-8') as f:
+            data = json.load(f)
+        self.tables = {t["number"]: Table(**t) for t in data.get("tables", [])}
+        self.reservations = {r["reservation_id"]: Reservation(**r) for r in data.get("reservations", [])}
+        logger.info(f"Loaded {len(self.tables)} tables and {len(self.reservations)} reservations")
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "tables": [t.to_dict() for t in sorted(self.tables.values(), key=lambda t: t.number)],
+            "upcoming_reservations": [r.to_dict() for r in self.upcoming()],
+            "pacing": self.pacing()
+        }
@@ -0,0 +1,84 @@
+"""
+Service period reporting for ChefBench
+What each part of the service day did for the business: covers served, average ticket, table turns and revenue
+"""
+
+from typing import Callable, Dict, List, Any
+
+from .customers import DemandConfig
+
+# Tickets placed outside every configured period
+OTHER_PERIOD = "other"
+
+
+def _period_row(
+    period: str,
+    tickets: List[Dict[str, Any]],
+    price: Callable[[Dict[str, Any]], float],
+    seats: int
+) -> Dict[str, Any]:
+    served = [t for t in tickets if any(p["served"] for p in t["portions"])]
+    revenue = sum((price(p) for t in served for p in t["portions"] if p["served"]), 0.0)
+    turns = [t["turn_seconds"] for t in served if t["table"] is not None and t["turn_seconds"] is not None]
+    covers = sum(t["covers"] for t in served)
+    return {
+        "period": period,
+        "tickets": len(tickets),
+        "tickets_served": len(served),
+        "covers": covers,
+        "portions_served": sum(1 for t in served for p in t["portions"] if p["served"]),
+        "revenue": round(revenue, 2),
+        "average_ticket": round(revenue / len(served), 2) if served else None,
+        "tables_turned": len(turns),
+        "table_turn_seconds": round(sum(turns) / len(turns), 1) if turns else None,
+        "seat_turns": round(covers / seats, 2) if seats else None
+    }
+
+
+def service_report(
+    tickets: List[Dict[str, Any]],
+    demand: DemandConfig,
+    duration_seconds: float,
+    price: Callable[[Dict[str, Any]], float],
+    seats: int = 0
+) -> Dict[str, Any]:
+    """Covers, average ticket, table turn time and revenue for each service period of a run
+
+    tickets are the coordinator's ticket_ledger; each goes to the period its
+    time of day falls in, the run spanning the demand's service day. Only
+    served portions earn revenue, at price(portion), and a ticket is served
+    once any of its portions is. Seat turns are covers over the floor's seats.
+    """
+    by_period: Dict[str, List[Dict[str, Any]]] = {name: [] for name in demand.service_periods}
+    for ticket in tickets:
+        period = demand.period_at(demand.hour_at(ticket["placed_at"], duration_seconds)) or OTHER_PERIOD
+        by_period.setdefault(period, []).append(ticket)
+    return {
+        "service_periods": {name: list(hours) for name, hours in demand.service_periods.items()},
+        "seats": seats,
+        "periods": [_period_row(name, period_tickets, price, seats) for name, period_tickets in by_period.items()],
+        "total": _period_row("total", tickets, price, seats)
+    }
+
+
+def combine_periods(reports: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """Period rows summed across runs by period name, in the order first seen"""
+    combined: Dict[str, Dict[str, Any]] = {}
+    turn_seconds: Dict[str, float] = {}
+    for report in reports:
+        for row in report["periods"]:
+            total = combined.setdefault(row["period"], {
+                "period": row["period"], "tickets": 0, "tickets_served": 0, "covers": 0,
+                "portions_served": 0, "revenue": 0.0, "tables_turned": 0
+            })
+            for key in ("tickets", "tickets_served", "covers", "portions_served", "revenue", "tables_turned"):
+                total[key] += row[key]
+            if row["table_turn_seconds"] is not None:
+                turn_seconds[row["period"]] = turn_seconds.get(row["period"], 0.0) + row["table_turn_seconds"] * row["tables_turned"]
+    for name, total in combined.items():
+        total["revenue"] = round(total["revenue"], 2)
+        total["average_ticket"] = round(total["revenue"] / total["tickets_served"], 2) if total["tickets_served"] else None
+        total["table_turn_seconds"] = (
+            round(turn_seconds[name] / total["tables_turned"], 1) if total["tables_turned"] else None
+        )
+    return list(combined.values())
@@ -0,0 +1,145 @@
+"""
+Front of House for ChefBench
+Server agents who relay guests' changes and cancellations to the kitchen and carry delays back
+"""
+
+import random
+from dataclasses import dataclass, field, asdict
+from typing import Dict, List, Optional, Any
+
+REQUEST_KINDS = ("modification", "cancellation")
+
+# What guests ask to change about an order
+MODIFICATIONS = [
+    "no onions",
+    "sauce on the side",
+    "extra crispy",
+    "well done",
+    "no garlic",
+    "dressing on the side",
+    "swap fries for salad",
+    "hold the cheese",
+    "gluten-free bread",
+    "half portion",
+]
+
+
+@dataclass
+class GuestRequest:
+    """A guest asking, through their server, to change or cancel an order"""
+    request_id: str
+    kind: str  # modification | cancellation
+    table: int
+    task_id: str
+    arrives_after: int  # tasks the kitchen has finished when the guest asks
+    detail: str = ""
+    status: str = "pending"  # pending | relayed | too_late | missed
+    server: Optional[str] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class Server:
+    """A front-of-house server working a section of tables"""
+    name: str
+    tables: List[int] = field(default_factory=list)  # empty covers any table
+    relayed: int = 0
+    delays_reported: int = 0
+
+    def covers(self, table: int) -> bool:
+        return not self.tables or table in self.tables
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class GuestSimulator:
+    """Seeded guests who change or cancel their orders while the kitchen works"""
+
+    def __init__(self, seed: Optional[int] = None, request_rate: float = 0.3, cancel_share: float = 0.25):
+        self.rng = random.Random(f"guests-{seed}") if seed is not None else random.Random()
+        self.request_rate = request_rate
+        self.cancel_share = cancel_share
+        self.issued = 0  # requests decided on so far, numbering them across calls
+
+    def requests_for(self, orders: List[Dict[str, Any]], total_tasks: int, finished: int = 0) -> List[GuestRequest]:
+        """Decide which table orders guests will change or cancel, and when they'll ask
+
+        Orders placed mid-run, with tasks already finished, are asked about
+        only after those.
+        """
+        requests = []
+        for context in orders:
+            if self.rng.random() >= self.request_rate:
+                continue
+            kind = "cancellation" if self.rng.random() < self.cancel_share else "modification"
+            self.issued += 1
+            requests.append(GuestRequest(
+                request_id=f"guest-{self.issued}",
+                kind=kind,
+                table=context['table'],
+                task_id=context['task_id'],
+                arrives_after=self.rng.randrange(finished, max(total_tasks, finished + 1)),
+                detail=self.rng.choice(MODIFICATIONS) if kind == "modification" else ""
+            ))
+        return requests
+
+
+class FrontOfHouse:
+    """The servers on the floor, each covering a section of tables"""
+
+    def __init__(self):
+        self.servers: Dict[str, Server] = {}
+
+    def add_server(self, name: str, tables: Optional[List[int]] = None) -> Server:
+        if name in self.servers:
+            raise ValueError(f"Server {name} already exists")
+        server = Server(name, sorted(set(tables or [])))
+        self.servers[name] = server
+        return server
+
+    def remove_server(self, name: str) -> Server:
+        if name not in self.servers:
+            raise KeyError(f"Unknown server {name}")
+        return self.servers.pop(name)
+
+    def server_for(self, table: int) -> Optional[Server]:
+        """The server whose section has the table, preferring one that owns it over a floater"""
+        covering = [s for s in self.servers.values() if s.covers(table)]
+        if not covering:
+            return None
+        return min(covering, key=lambda s: (not s.tables, s.relayed + s.delays_reported, s.name))
+
+    def reset_counts(self):
+        for server in self.servers.values():
+            server.relayed = 0
+            server.delays_reported = 0
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"servers": [s.to_dict() for s in self.servers.values()]}
+
+
+def service_summary(requests: List[GuestRequest], delays: List[Dict[str, Any]]) -> Dict[str, Any]:
+    """How well the front and back of house kept each other informed
+
+    The score is the share of guest requests that reached the cook in time
+    plus late orders whose table was told, over both; None when neither came up.
+    """
+    asked = [r for r in requests if r.status != "pending"]  # the rest would have come after the run
+    by_status = {status: 0 for status in ("relayed", "too_late", "missed")}
+    for request in asked:
+        by_status[request.status] += 1
+    communicated = sum(1 for d in delays if d.get("server"))
+    total = len(asked) + len(delays)
+    return {
+        "guest_requests": len(asked),
+        **by_status,
+        "cancelled": sum(1 for r in requests if r.kind == "cancellation" and r.status == "relayed"),
+        "modified": sum(1 for r in requests if r.kind == "modification" and r.status == "relayed"),
+        "delays": len(delays),
+        "delays_communicated": communicated,
+        "score": (by_status["relayed"] + communicated) / total if total else None,
+        "requests": [r.to_dict() for r in asked],
+    }
@@ -0,0 +1,43 @@
+"""
+Equipment wear, maintenance and failure simulation, with temperature sensors and kitchen stations
+"""
+
+from .simulator import (
+    EquipmentItem,
+    EquipmentEvent,
+    EquipmentSimulator,
+    TASK_EQUIPMENT,
+    default_equipment
+)
+from .temperature import (
+    TemperatureSensor,
+    TemperatureService,
+    THERMAL_PROFILES,
+    STATION_ROLES
+)
+from .stations import (
+    STATION_EQUIPMENT,
+    STATION_CAPACITY,
+    station_for,
+    station_capacity_from_env,
+    station_report,
+    utilization_summary
+)
+
+__all__ = [
+    "EquipmentItem",
+    "EquipmentEvent",
+    "EquipmentSimulator",
+    "TASK_EQUIPMENT",
+    "default_equipment",
+    "TemperatureSensor",
+    "TemperatureService",
+    "THERMAL_PROFILES",
+    "STATION_ROLES",
+    "STATION_EQUIPMENT",
+    "STATION_CAPACITY",
+    "station_for",
+    "station_capacity_from_env",
+    "station_report",
+    "utilization_summary"
+]
@@ -0,0 +1,208 @@
+"""
+Equipment Simulator for ChefBench
+Per-item wear, scheduled maintenance and seedable MTBF-driven breakdowns during scenarios
+"""
+
+import math
+import random
+from dataclasses import dataclass, field
+from typing import Dict, Iterable, List, Optional, Any
+
+from models.models import TaskType
+from recipes.techniques import technique_equipment
+from .temperature import TemperatureService
+
+OPERATIONAL = "operational"
+BROKEN = "broken"
+MAINTENANCE = "maintenance"
+
+# Equipment kinds each task depends on; tasks not listed need no equipment
+TASK_EQUIPMENT: Dict[TaskType, List[str]] = {
+    TaskType.COOKING_EXECUTION: ["stove", "oven"],
+    TaskType.BASIC_COOKING: ["stove"],
+    TaskType.SAUCE_PREPARATION: ["stove"],
+    TaskType.TEMPERATURE_MONITORING: ["refrigerator"],
+    TaskType.INGREDIENT_PREPARATION: ["mixer"],
+    TaskType.MISE_EN_PLACE: ["refrigerator"],
+    TaskType.CLEANING: ["dishwasher"],
+}
+
+
+@dataclass
+class EquipmentItem:
+    """One piece of kitchen equipment"""
+    name: str
+    kind: str
+    mtbf_seconds: float       # mean simulated seconds between failures when new
+    wear_rate: float = 0.001  # wear gained per simulated second of service
+    wear: float = 0.0         # 0 (new) - 1 (worn out); doubles the failure rate at 1
+    status: str = OPERATIONAL
+    available_at: float = 0.0  # simulated time the current outage ends, if known
+    breakdowns: int = 0
+    maintenance_count: int = 0
+    in_use_by: Optional[str] = None  # task holding the item while it runs
+
+    @property
+    def operational(self) -> bool:
+        return self.status == OPERATIONAL
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "name": self.name,
+            "kind": self.kind,
+            "status": self.status,
+            "wear": round(self.wear, 3),
+            "mtbf_seconds": self.mtbf_seconds,
+            "available_at": self.available_at if not self.operational else None,
+            "breakdowns": self.breakdowns,
+            "maintenance_count": self.maintenance_count,
+            "in_use_by": self.in_use_by
+        }
+
+
+def default_equipment() -> List[EquipmentItem]:
+    return [
+        EquipmentItem("stove_1", "stove", mtbf_seconds=3600),
+        EquipmentItem("stove_2", "stove", mtbf_seconds=3600),
+        EquipmentItem("oven_1", "oven", mtbf_seconds=5400),
+        EquipmentItem("refrigerator_1", "refrigerator", mtbf_seconds=14400, wear_rate=0.0002),
+        EquipmentItem("mixer_1", "mixer", mtbf_seconds=2400, wear_rate=0.002),
+        EquipmentItem("dishwasher_1", "dishwasher", mtbf_seconds=3000, wear_rate=0.0015),
+    ]
+
+
+@dataclass
+class EquipmentEvent:
+    """A status change produced while advancing simulated time"""
+    # equipment_failed | maintenance_started | equipment_restored, or from the
+    # temperature sensors: temperature_alert | temperature_normal | sensor_failed | sensor_restored
+    event_type: str
+    equipment: str
+    time: float
+    details: Dict[str, Any] = field(default_factory=dict)
+
+
+class EquipmentSimulator:
+    """Advances equipment state through simulated scenario time"""
+
+    def __init__(
+        self,
+        items: Optional[List[EquipmentItem]] = None,
+        seed: Optional[int] = None,
+        maintenance_interval: float = 1800,
+        maintenance_threshold: float = 0.5,
+        maintenance_duration: float = 300
+    ):
+        self.items: Dict[str, EquipmentItem] = {i.name: i for i in (items or default_equipment())}
+        self.rng = random.Random(seed)
+        self.maintenance_interval = maintenance_interval
+        self.maintenance_threshold = maintenance_threshold
+        self.maintenance_duration = maintenance_duration
+        self.clock = 0.0
+        self._next_maintenance = maintenance_interval
+        self.blocked_tasks = 0
+        self.temperature = TemperatureService(self.items, seed)
+
+    def required_kinds(self, task_type: TaskType, techniques: Iterable[str] = ()) -> List[str]:
+        """Equipment kinds a task needs, along with any its dish's cooking techniques do"""
+        kinds = list(TASK_EQUIPMENT.get(task_type, []))
+        return kinds + [kind for kind in technique_equipment(techniques) if kind not in kinds]
+
+    def outages_for(self, task_type: TaskType, techniques: Iterable[str] = ()) -> List[str]:
+        """Equipment kinds a task needs that have no operational unit right now"""
+        return [
+            kind for kind in self.required_kinds(task_type, techniques)
+            if not any(i.operational for i in self.items.values() if i.kind == kind)
+        ]
+
+    def claim(self, task_id: str, task_type: TaskType, techniques: Iterable[str] = ()) -> List[str]:
+        """Hold a free operational unit of each kind a task needs, returning their names"""
+        claimed = []
+        for kind in self.required_kinds(task_type, techniques):
+            free = [i for i in self.items.values() if i.kind == kind and i.operational and i.in_use_by is None]
+            if free:
+                free[0].in_use_by = task_id
+                claimed.append(free[0].name)
+        return claimed
+
+    def release(self, task_id: str) -> List[str]:
+        """Free everything a task was holding, returning the item names"""
+        released = []
+        for item in self.items.values():
+            if item.in_use_by == task_id:
+                item.in_use_by = None
+                released.append(item.name)
+        return released
+
+    def unavailable(self) -> List[Dict[str, Any]]:
+        return [i.to_dict() for i in self.items.values() if not i.operational]
+
+    def advance(self, seconds: float) -> List[EquipmentEvent]:
+        """Move simulated time forward, applying wear, maintenance and breakdowns"""
+        events = []
+        seconds = max(0.0, seconds)
+        self.clock += seconds
+
+        for item in self.items.values():
+            if item.status == MAINTENANCE and self.clock >= item.available_at:
+                item.status = OPERATIONAL
+                item.wear = 0.0
+                events.append(EquipmentEvent("equipment_restored", item.name, self.clock, {"after": "maintenance"}))
+
+            if not item.operational:
+                continue
+
+            item.wear = min(1.0, item.wear + item.wear_rate * seconds)
+            failure_rate = (1 + item.wear) / item.mtbf_seconds
+            if self.rng.random() < 1 - math.exp(-failure_rate * seconds):
+                item.status = BROKEN
+                item.available_at = 0.0
+                item.breakdowns += 1
+                events.append(EquipmentEvent("equipment_failed", item.name, self.clock, {"wear": round(item.wear, 3)}))
+
+        if self.clock >= self._next_maintenance:
+            self._next_maintenance += self.maintenance_interval
+            for item in self.items.values():
+                if item.operational and item.wear >= self.maintenance_threshold:
+                    item.status = MAINTENANCE
+                    item.available_at = self.clock + self.maintenance_duration
+                    item.maintenance_count += 1
+                    events.append(EquipmentEvent(
+                        "maintenance_started", item.name, self.clock,
+                        {"until": item.available_at, "wear": round(item.wear, 3)}
+                    ))
+
+        for event_type, name, details in self.temperature.advance(seconds, self.clock):
+            events.append(EquipmentEvent(event_type, name, self.clock, details))
+        return events
+
+    def repair(self, name: str) -> EquipmentEvent:
+        """Return a broken item to service after a repair task"""
+        item = self.items[name]
+        item.status = OPERATIONAL
+        item.wear = max(0.0, item.wear - 0.5)
+        return EquipmentEvent("equipment_restored", name, self.clock, {"after": "repair"})
+
+    def break_item(self, name: str) -> EquipmentEvent:
+        """Force a breakdown, e.g. to test how agents adapt"""
+        item = self.items[name]
+        item.status = BROKEN
+        item.breakdowns += 1
+        return EquipmentEvent("equipment_failed", name, self.clock, {"forced": True})
+
+    def summary(self) -> Dict[str, Any]:
+        return {
+            "simulated_seconds": round(self.clock, 1),
+            "breakdowns": sum(i.breakdowns for i in self.items.values()),
+            "maintenance": sum(i.maintenance_count for i in self.items.values()),
+            "blocked_tasks": self.blocked_tasks,
+            "unavailable": [i.name for i in self.items.values() if not i.operational],
+            "temperature": self.temperature.summary()
+        }
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            **self.summary(),
+            "items": [i.to_dict() for i in self.items.values()],
+            "temperatures": {name: self.temperature.reading(name) for name in self.temperature.sensors}
+        }
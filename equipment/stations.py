@@ -0,0 +1,146 @@
+"""
+Kitchen Stations for ChefBench
+Which equipment stands at each station and which tasks are worked there
+"""
+
+import json
+import os
+from typing import Dict, List, Optional, Tuple, Any
+import logging
+
+from models.models import TaskType
+from .simulator import EquipmentSimulator, OPERATIONAL, TASK_EQUIPMENT
+from .temperature import STATION_ROLES
+
+# Equipment kinds at each station; the pass has none and takes every task that needs none
+STATION_EQUIPMENT: Dict[str, Tuple[str, ...]] = {
+    "hot_line": ("stove",),
+    "roast": ("oven",),
+    "prep": ("mixer",),
+    "cold_storage": ("refrigerator",),
+    "dish_pit": ("dishwasher",),
+    "pass": (),
+}
+
+PASS = "pass"
+
+# Tasks a station can have on it at once, queued or cooking: burners on the hot
+# line, oven slots at the roast, bench space at prep, shelves in cold storage,
+# racks at the dish pit and plates the pass can hold. Past that, the sous chef
+# defers new tasks until a slot frees up
+STATION_CAPACITY: Dict[str, int] = {
+    "hot_line": 6,
+    "roast": 4,
+    "prep": 4,
+    "cold_storage": 6,
+    "dish_pit": 4,
+    "pass": 10,
+}
+
+logger = logging.getLogger(__name__)
+
+
+def station_capacity_from_env() -> Dict[str, int]:
+    """STATION_CAPACITY, with stations overridden by a JSON object in CHEFBENCH_STATION_CAPACITY"""
+    capacity = dict(STATION_CAPACITY)
+    raw = os.environ.get("CHEFBENCH_STATION_CAPACITY")
+    if not raw:
+        return capacity
+    try:
+        overrides = json.loads(raw)
+        for station, slots in overrides.items():
+            if station not in capacity:
+                raise ValueError(f"Unknown station '{station}'")
+            if not isinstance(slots, int) or slots < 1:
+                raise ValueError(f"{station} needs at least one slot")
+        capacity.update(overrides)
+    except (ValueError, TypeError, AttributeError) as e:
+        logger.error(f"Ignoring invalid CHEFBENCH_STATION_CAPACITY: {e}")
+        return dict(STATION_CAPACITY)
+    return capacity
+
+
+def station_for(task_type: TaskType) -> str:
+    """The station of the first equipment kind a task needs, or the pass"""
+    for kind in TASK_EQUIPMENT.get(task_type, []):
+        for station, kinds in STATION_EQUIPMENT.items():
+            if kind in kinds:
+                return station
+    return PASS
+
+
+def station_equipment(station: str, equipment: Optional[EquipmentSimulator]) -> List[Dict[str, Any]]:
+    """State of the station's items, with their temperature where they have a sensor"""
+    if equipment is None:
+        return []
+    items = []
+    for item in equipment.items.values():
+        if item.kind not in STATION_EQUIPMENT[station]:
+            continue
+        reading = equipment.temperature.reading(item.name)
+        items.append({
+            "name": item.name,
+            "kind": item.kind,
+            "status": item.status,
+            "wear": round(item.wear, 3),
+            "in_use_by": item.in_use_by,
+            "temperature": reading["temperature"] if reading else None,
+            "temperature_status": reading["status"] if reading else None
+        })
+    return items
+
+
+def station_report(
+    station: str,
+    staff: List[str],
+    orders: List[Dict[str, Any]],
+    equipment: Optional[EquipmentSimulator],
+    capacity: Optional[int] = None,
+    deferred: int = 0
+) -> Dict[str, Any]:
+    """One station's snapshot; load is active orders per staff member on it, utilization per slot"""
+    items = station_equipment(station, equipment)
+    capacity = capacity or STATION_CAPACITY[station]
+    return {
+        "name": station,
+        "role": STATION_ROLES[station].name,
+        "staff": staff,
+        "staff_count": len(staff),
+        "active_orders": len(orders),
+        "in_progress": sum(1 for o in orders if o["state"] == "in_progress"),
+        "queued": sum(1 for o in orders if o["state"] == "queued"),
+        "held": sum(1 for o in orders if o["state"] == "held"),
+        "load": round(len(orders) / max(len(staff), 1), 2),
+        "capacity": capacity,
+        "utilization": round(len(orders) / capacity, 2),
+        "deferred": deferred,
+        "equipment": items,
+        "equipment_down": [i["name"] for i in items if i["status"] != OPERATIONAL],
+        "orders": orders
+    }
+
+
+def utilization_summary(
+    samples: List[Dict[str, Any]],
+    capacity: Dict[str, int],
+    deferrals: Dict[str, int]
+) -> Dict[str, Dict[str, Any]]:
+    """Per station, its slots, the share of them in use over the run, on average and at peak, and tasks deferred
+
+    samples are {"simulated_time", "load": {station: tasks on it}}, one per task started.
+    """
+    summary = {}
+    for station, slots in capacity.items():
+        loads = [sample["load"].get(station, 0) for sample in samples]
+        summary[station] = {
+            "capacity": slots,
+            "average_utilization": round(sum(loads) / len(loads) / slots, 3) if loads else None,
+            "peak_utilization": round(max(loads) / slots, 3) if loads else None,
+            "full_samples": sum(1 for load in loads if load >= slots),
+            "timeline": [
+                {"simulated_time": sample["simulated_time"], "utilization": round(load / slots, 3)}
+                for sample, load in zip(samples, loads)
+            ],
+            "deferred": deferrals.get(station, 0)
+        }
+    return summary
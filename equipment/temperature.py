@@ -0,0 +1,160 @@
+"""
+Temperature Monitoring for ChefBench
+Simulated sensors on hot and cold equipment with drifting, noisy readings and threshold alerts
+"""
+
+import math
+import random
+from collections import deque
+from dataclasses import dataclass, field
+from typing import Deque, Dict, List, Optional, Tuple, Any
+
+from models.models import AgentRole
+
+AMBIENT = 22.0  # what equipment settles to when it's off, broken or in maintenance
+
+HISTORY_LENGTH = 20
+
+
+@dataclass
+class ThermalProfile:
+    """How one kind of equipment holds temperature, in degrees C"""
+    setpoint: float
+    low: float
+    high: float
+    station: str
+    drift: float = 0.0            # setpoint offset at full wear
+    time_constant: float = 300.0  # simulated seconds to close most of the gap to its target
+    noise: float = 1.0
+
+
+THERMAL_PROFILES: Dict[str, ThermalProfile] = {
+    "stove": ThermalProfile(220.0, 180.0, 260.0, "hot_line", drift=-35.0, time_constant=120.0, noise=4.0),
+    "oven": ThermalProfile(180.0, 160.0, 200.0, "roast", drift=-25.0, time_constant=600.0, noise=2.0),
+    "refrigerator": ThermalProfile(3.0, 0.0, 5.0, "cold_storage", drift=4.0, time_constant=1800.0, noise=0.3),
+    "dishwasher": ThermalProfile(82.0, 77.0, 90.0, "dish_pit", drift=-8.0, time_constant=240.0, noise=1.0),
+}
+
+# The role that runs each station and gets its alerts
+STATION_ROLES: Dict[str, AgentRole] = {
+    "hot_line": AgentRole.LINE_COOK,
+    "roast": AgentRole.CHEF_DE_PARTIE,
+    "cold_storage": AgentRole.PREP_COOK,
+    "dish_pit": AgentRole.KITCHEN_PORTER,
+    "prep": AgentRole.PREP_COOK,
+    "pass": AgentRole.SOUS_CHEF,
+}
+
+OK = "ok"
+HIGH = "high"
+LOW = "low"
+SENSOR_FAILED = "sensor_failed"
+
+
+@dataclass
+class TemperatureSensor:
+    """The probe on one piece of equipment"""
+    equipment: str
+    kind: str
+    profile: ThermalProfile
+    temperature: float
+    mtbf_seconds: float = 43200  # mean simulated seconds between sensor failures
+    status: str = OK
+    failed_at_service: int = 0   # item breakdowns + maintenance when the sensor failed
+    history: Deque[Tuple[float, float]] = field(default_factory=lambda: deque(maxlen=HISTORY_LENGTH))
+
+    @property
+    def failed(self) -> bool:
+        return self.status == SENSOR_FAILED
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "equipment": self.equipment,
+            "kind": self.kind,
+            "station": self.profile.station,
+            "temperature": None if self.failed else round(self.temperature, 1),
+            "status": self.status,
+            "setpoint": self.profile.setpoint,
+            "low": self.profile.low,
+            "high": self.profile.high,
+            "history": [{"time": round(t, 1), "temperature": round(v, 1)} for t, v in self.history]
+        }
+
+
+class TemperatureService:
+    """Advances every sensor with its equipment and reports threshold crossings
+
+    Readings move towards the equipment's target (its setpoint, offset by wear,
+    or ambient when it isn't operational) with seedable noise. A failed sensor
+    reads nothing until its equipment is next repaired or maintained.
+    """
+
+    def __init__(self, items, seed: Optional[int] = None):
+        self.rng = random.Random(f"temperature-{seed}") if seed is not None else random.Random()
+        self.items = items
+        self.sensors: Dict[str, TemperatureSensor] = {}
+        for item in items.values():
+            profile = THERMAL_PROFILES.get(item.kind)
+            if profile:
+                self.sensors[item.name] = TemperatureSensor(
+                    item.name, item.kind, profile, profile.setpoint + self.rng.gauss(0, profile.noise)
+                )
+        self.alerts = 0
+
+    def target(self, name: str) -> float:
+        item, profile = self.items[name], self.sensors[name].profile
+        if not item.operational:
+            return AMBIENT
+        return profile.setpoint + profile.drift * item.wear
+
+    def advance(self, seconds: float, clock: float) -> List[Tuple[str, str, Dict[str, Any]]]:
+        """Move readings forward, returning (event type, equipment, details) for each change"""
+        changes = []
+        for name, sensor in self.sensors.items():
+            item, profile = self.items[name], sensor.profile
+            serviced = item.breakdowns + item.maintenance_count
+
+            if sensor.failed:
+                if serviced == sensor.failed_at_service or not item.operational:
+                    continue
+                sensor.status = OK  # replaced during the repair or maintenance
+                changes.append(("sensor_restored", name, {"station": profile.station}))
+
+            pull = 1 - math.exp(-max(0.0, seconds) / profile.time_constant)
+            sensor.temperature += (self.target(name) - sensor.temperature) * pull
+            sensor.temperature += self.rng.gauss(0, profile.noise) * math.sqrt(pull)
+
+            if self.rng.random() < 1 - math.exp(-seconds / sensor.mtbf_seconds):
+                sensor.status = SENSOR_FAILED
+                sensor.failed_at_service = serviced
+                changes.append(("sensor_failed", name, {"station": profile.station}))
+                continue
+            sensor.history.append((clock, sensor.temperature))
+
+            status = HIGH if sensor.temperature > profile.high else LOW if sensor.temperature < profile.low else OK
+            if status == sensor.status:
+                continue
+            sensor.status = status
+            details = {
+                "station": profile.station,
+                "temperature": round(sensor.temperature, 1),
+                "low": profile.low,
+                "high": profile.high
+            }
+            if status == OK:
+                changes.append(("temperature_normal", name, details))
+            else:
+                self.alerts += 1
+                changes.append(("temperature_alert", name, {**details, "threshold": status}))
+        return changes
+
+    def reading(self, name: str) -> Optional[Dict[str, Any]]:
+        sensor = self.sensors.get(name)
+        return sensor.to_dict() if sensor else None
+
+    def summary(self) -> Dict[str, Any]:
+        return {
+            "alerts": self.alerts,
+            "out_of_range": [n for n, s in self.sensors.items() if s.status in (HIGH, LOW)],
+            "failed_sensors": [n for n, s in self.sensors.items() if s.failed]
+        }
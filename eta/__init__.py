@@ -0,0 +1,11 @@
+"""
+ETA estimation for scenario runs
+"""
+
+from .estimator import ETAEstimate, ETAEstimator, score_eta
+
+__all__ = [
+    "ETAEstimate",
+    "ETAEstimator",
+    "score_eta"
+]
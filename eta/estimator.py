@@ -0,0 +1,192 @@
+"""
+Scenario ETA Estimation for ChefBench
+Predicts run completion time from agent load, task mix and historical task durations
+"""
+
+import time
+from collections import defaultdict
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Tuple, Any
+import logging
+
+from models.models import LLMAgent, TaskType
+from providers.policies import get_assignment_policy
+from dining.complexity import complexity_level, time_factor
+
+logger = logging.getLogger(__name__)
+
+# Prior seconds per task (reasoning + execution) before any history exists,
+# scaled by the task's minimum role level since senior tasks prompt for more
+PRIOR_BASE_SECONDS = 2.0
+PRIOR_SECONDS_PER_LEVEL = 0.5
+
+# Weight of the prior, in observations, when blending with history
+PRIOR_WEIGHT = 3
+
+
+def prior_seconds(task_type: TaskType) -> float:
+    return PRIOR_BASE_SECONDS + PRIOR_SECONDS_PER_LEVEL * task_type.min_role_level
+
+
+@dataclass
+class DurationStats:
+    """Running mean of observed task durations"""
+    count: int = 0
+    total: float = 0.0
+
+    def observe(self, seconds: float):
+        self.count += 1
+        self.total += seconds
+
+    def blended(self, prior: float) -> float:
+        return (self.total + prior * PRIOR_WEIGHT) / (self.count + PRIOR_WEIGHT)
+
+
+@dataclass
+class ETAEstimate:
+    """Predicted completion time for a scenario"""
+    predicted_seconds: float
+    capped_by_duration: bool
+    task_count: int
+    unassigned_tasks: int
+    agent_load: Dict[str, float]
+    observations: int
+    created_at: float = field(default_factory=time.time)
+
+    @property
+    def predicted_completion(self) -> float:
+        return self.created_at + self.predicted_seconds
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "predicted_seconds": round(self.predicted_seconds, 2),
+            "predicted_completion": self.predicted_completion,
+            "capped_by_duration": self.capped_by_duration,
+            "task_count": self.task_count,
+            "unassigned_tasks": self.unassigned_tasks,
+            "agent_load": {k: round(v, 2) for k, v in self.agent_load.items()},
+            "observations": self.observations
+        }
+
+
+class ETAEstimator:
+    """Learns per-model task durations and estimates scenario completion
+
+    Order tasks take longer the more complex their order, so durations are
+    learned as for an order of moderate complexity and scaled back up or
+    down by each order's complexity level when estimating.
+    """
+
+    def __init__(self):
+        # (model_name, task function name) -> stats
+        self.history: Dict[Tuple[str, str], DurationStats] = defaultdict(DurationStats)
+
+    def expected_seconds(self, model_name: str, task_type: TaskType, complexity: Optional[str] = None) -> float:
+        """Expected duration of one task for a model, blending history with the prior"""
+        key = (model_name, task_type.function_name)
+        prior = prior_seconds(task_type)
+        if key not in self.history:
+            return prior * time_factor(complexity)
+        return self.history[key].blended(prior) * time_factor(complexity)
+
+    def observe(self, model_name: str, task_type: str, seconds: float, complexity: Optional[str] = None):
+        self.history[(model_name, task_type)].observe(seconds / time_factor(complexity))
+
+    def observe_result(self, result: Dict[str, Any], agents: Dict[str, LLMAgent]):
+        """Learn task durations from a completed scenario result"""
+        for execution in result.get("execution_history", []):
+            agent = agents.get(execution["agent_name"])
+            if agent is None or execution.get("chosen_approach") == "UNAUTHORIZED":
+                continue
+            self.observe(
+                agent.model_name,
+                execution["task_type"],
+                execution["reasoning_time"] + execution["execution_time"],
+                execution.get("complexity")
+            )
+
+    def estimate(
+        self,
+        tasks: List[Tuple[TaskType, Dict[str, Any]]],
+        agents: Dict[str, LLMAgent],
+        assignment_policy: str = "highest_rank",
+        duration_seconds: Optional[float] = None
+    ) -> ETAEstimate:
+        """Simulate task assignment and sum expected durations
+
+        The coordinator executes tasks one at a time, so the scenario ETA is
+        the total expected work across all agents, capped by the time limit.
+        """
+        policy = get_assignment_policy(assignment_policy)
+        sorted_agents = sorted(agents.items(), key=lambda x: x[1].role.value, reverse=True)
+        assignments: Dict[str, List] = defaultdict(list)
+        agent_load: Dict[str, float] = defaultdict(float)
+        unassigned = 0
+
+        for task_type, context in tasks:
+            candidates = [(n, a) for n, a in sorted_agents if task_type in a.available_tasks]
+            if not candidates:
+                unassigned += 1
+                continue
+            name = policy(task_type, candidates, assignments)
+            assignments[name].append((task_type, context))
+            agent_load[name] += self.expected_seconds(
+                agents[name].model_name, task_type, complexity_level(task_type, context)
+            )
+
+        total = sum(agent_load.values())
+        capped = duration_seconds is not None and total > duration_seconds
+        observations = sum(
+            self.history[(agents[name].model_name, t.function_name)].count
+            for name, assigned in assignments.items()
+            for t, _ in assigned
+            if (agents[name].model_name, t.function_name) in self.history
+        )
+
+        return ETAEstimate(
+            predicted_seconds=min(total, duration_seconds) if capped else total,
+            capped_by_duration=capped,
+            task_count=len(tasks),
+            unassigned_tasks=unassigned,
+            agent_load=dict(agent_load),
+            observations=observations
+        )
+
+
+def score_eta(
+    estimate: Dict[str, Any],
+    actual_seconds: float,
+    execution_history: Optional[List[Dict[str, Any]]] = None,
+    agents: Optional[Dict[str, LLMAgent]] = None
+) -> Dict[str, Any]:
+    """Compare a stored prediction with the actual run, overall and per model"""
+    predicted = estimate["predicted_seconds"]
+    error = predicted - actual_seconds
+    score = {
+        "predicted_seconds": predicted,
+        "actual_seconds": round(actual_seconds, 2),
+        "error_seconds": round(error, 2),
+        "abs_pct_error": round(abs(error) / actual_seconds, 4) if actual_seconds > 0 else None,
+        "by_model": {}
+    }
+    if not execution_history or not agents:
+        return score
+
+    by_model: Dict[str, Dict[str, float]] = defaultdict(lambda: {"predicted": 0.0, "actual": 0.0})
+    for name, load in estimate.get("agent_load", {}).items():
+        if name in agents:
+            by_model[agents[name].model_name]["predicted"] += load
+    for execution in execution_history:
+        agent = agents.get(execution["agent_name"])
+        if agent is not None:
+            by_model[agent.model_name]["actual"] += execution["reasoning_time"] + execution["execution_time"]
+
+    score["by_model"] = {
+        model: {
+            "predicted_seconds": round(times["predicted"], 2),
+            "actual_seconds": round(times["actual"], 2),
+            "error_seconds": round(times["predicted"] - times["actual"], 2)
+        }
+        for model, times in by_model.items()
+    }
+    return score
@@ -0,0 +1,16 @@
+"""
+Experiment helpers for studying policies on top of the kitchen simulator
+"""
+
+from .bandit import BanditAllocator, PolicyExperiment
+from .prompt_ab import OUTCOME_METRICS, PromptVariant, PromptExperiment, outcome_metrics, paired_permutation_test
+
+__all__ = [
+    "BanditAllocator",
+    "PolicyExperiment",
+    "OUTCOME_METRICS",
+    "PromptVariant",
+    "PromptExperiment",
+    "outcome_metrics",
+    "paired_permutation_test"
+]
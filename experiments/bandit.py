@@ -0,0 +1,154 @@
+"""
+Multi-Armed Bandit Policy Experiments for ChefBench
+Compares task assignment policies online across repeated scenario runs
+"""
+
+import math
+import random
+from typing import Callable, Dict, List, Optional, Tuple, Any
+import logging
+
+from models.models import TaskType
+from providers.llm import MultiAgentCoordinator
+from providers.policies import ASSIGNMENT_POLICIES
+
+logger = logging.getLogger(__name__)
+
+
+def default_reward(result: Dict[str, Any]) -> float:
+    """Reward a run by success rate weighted by average quality (0-1)"""
+    team = result.get("agent_metrics", {}).get("team", {})
+    return team.get("overall_success_rate", 0) * team.get("average_quality", 0)
+
+
+class BanditAllocator:
+    """Chooses between arms using UCB1 or epsilon-greedy exploration"""
+
+    def __init__(
+        self,
+        arms: List[str],
+        strategy: str = "ucb1",
+        epsilon: float = 0.1,
+        rng: Optional[random.Random] = None
+    ):
+        if strategy not in ("ucb1", "epsilon_greedy"):
+            raise ValueError(f"Unknown bandit strategy '{strategy}'")
+
+        self.arms = list(arms)
+        self.strategy = strategy
+        self.epsilon = epsilon
+        self.rng = rng or random.Random()
+
+        self.pulls: Dict[str, int] = {arm: 0 for arm in self.arms}
+        self.total_reward: Dict[str, float] = {arm: 0.0 for arm in self.arms}
+
+    def mean_reward(self, arm: str) -> float:
+        return self.total_reward[arm] / self.pulls[arm] if self.pulls[arm] else 0.0
+
+    def select(self) -> str:
+        """Pick the next arm to play"""
+        # Play every arm once before trusting the estimates
+        for arm in self.arms:
+            if self.pulls[arm] == 0:
+                return arm
+
+        if self.strategy == "epsilon_greedy":
+            if self.rng.random() < self.epsilon:
+                return self.rng.choice(self.arms)
+            return max(self.arms, key=self.mean_reward)
+
+        total_pulls = sum(self.pulls.values())
+        return max(
+            self.arms,
+            key=lambda arm: self.mean_reward(arm) + math.sqrt(2 * math.log(total_pulls) / self.pulls[arm])
+        )
+
+    def update(self, arm: str, reward: float):
+        """Record the reward observed for an arm"""
+        self.pulls[arm] += 1
+        self.total_reward[arm] += reward
+
+    def best_arm(self) -> str:
+        return max(self.arms, key=self.mean_reward)
+
+
+class PolicyExperiment:
+    """Runs repeated scenarios, letting a bandit pick the assignment policy each round"""
+
+    def __init__(
+        self,
+        coordinator: MultiAgentCoordinator,
+        task_factory: Callable[[], List[Tuple[TaskType, Dict[str, Any]]]],
+        policies: Optional[List[str]] = None,
+        strategy: str = "ucb1",
+        reward_fn: Callable[[Dict[str, Any]], float] = default_reward,
+        duration_seconds: int = 300,
+        rng: Optional[random.Random] = None
+    ):
+        self.coordinator = coordinator
+        self.task_factory = task_factory
+        self.policies = policies or list(ASSIGNMENT_POLICIES)
+        self.reward_fn = reward_fn
+        self.duration_seconds = duration_seconds
+        self.allocator = BanditAllocator(self.policies, strategy, rng=rng)
+        self.rounds: List[Dict[str, Any]] = []
+
+    async def run(self, num_rounds: int) -> Dict[str, Any]:
+        """Play `num_rounds` scenarios and return the experiment report"""
+        original_policy = self.coordinator.assignment_policy_name
+
+        try:
+            for round_number in range(1, num_rounds + 1):
+                policy = self.allocator.select()
+                async with self.coordinator.run_lock:
+                    self.coordinator.set_assignment_policy(policy)
+                    self.coordinator.reset()
+
+                    result = await self.coordinator.execute_scenario(
+                        self.task_factory(),
+                        self.duration_seconds
+                    )
+                reward = self.reward_fn(result)
+                self.allocator.update(policy, reward)
+
+                self.rounds.append({
+                    "round": round_number,
+                    "policy": policy,
+                    "reward": reward
+                })
+                logger.info(f"Bandit round {round_number}: {policy} reward={reward:.3f}")
+        finally:
+            self.coordinator.set_assignment_policy(original_policy)
+
+        return self.report()
+
+    def report(self) -> Dict[str, Any]:
+        """Summarise pulls, mean rewards and regret against the best policy"""
+        if not self.rounds:
+            return {"rounds": [], "policies": {}, "best_policy": None, "total_regret": 0.0}
+
+        best_policy = self.allocator.best_arm()
+        best_mean = self.allocator.mean_reward(best_policy)
+
+        # Pseudo-regret: gap between the best policy's mean and the chosen policy's mean
+        cumulative_regret = 0.0
+        rounds = []
+        for entry in self.rounds:
+            cumulative_regret += best_mean - self.allocator.mean_reward(entry["policy"])
+            rounds.append({**entry, "cumulative_regret": cumulative_regret})
+
+        return {
+            "strategy": self.allocator.strategy,
+            "rounds": rounds,
+            "policies": {
+                policy: {
+                    "pulls": self.allocator.pulls[policy],
+                    "mean_reward": self.allocator.mean_reward(policy),
+                    "gap_to_best": best_mean - self.allocator.mean_reward(policy)
+                }
+                for policy in self.policies
+            },
+            "best_policy": best_policy,
+            "best_mean_reward": best_mean,
+            "total_regret": cumulative_regret
+        }
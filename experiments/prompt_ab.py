@@ -0,0 +1,192 @@
+"""
+Prompt A/B Experiments for ChefBench
+Runs one scenario under two prompt template sets on matched seeds and tests which does better
+"""
+
+import itertools
+import random
+import statistics
+from dataclasses import dataclass, field
+from typing import Callable, Dict, List, Optional, Tuple, Any
+import logging
+
+from models.models import TaskType
+from providers.llm import MultiAgentCoordinator
+
+logger = logging.getLogger(__name__)
+
+# Team outcomes compared between variants, and whether higher is better
+OUTCOME_METRICS = {
+    "overall_success_rate": True,
+    "average_quality": True,
+    "labor_cost": False,
+    "invalid_action_rate": False,
+}
+
+# Paired runs up to this many are tested exactly; beyond it by random sign flips
+EXACT_TEST_LIMIT = 16
+PERMUTATION_SAMPLES = 10000
+
+
+def outcome_metrics(result: Dict[str, Any]) -> Dict[str, float]:
+    """The team metrics a variant is judged on"""
+    team = result.get("agent_metrics", {}).get("team", {})
+    return {name: float(team.get(name, 0.0)) for name in OUTCOME_METRICS}
+
+
+def paired_permutation_test(
+    a: List[float],
+    b: List[float],
+    rng: Optional[random.Random] = None
+) -> float:
+    """Two-sided p-value that paired samples a and b have the same mean
+
+    Under the null hypothesis each pair's difference is as likely to have
+    either sign, so the observed mean difference is compared against every
+    (or, for many pairs, a random sample of) sign assignment.
+    """
+    diffs = [x - y for x, y in zip(a, b)]
+    if not diffs:
+        return 1.0
+    observed = abs(sum(diffs))
+    tolerance = 1e-12 * max(1.0, observed)
+
+    def extreme(signs) -> int:
+        return sum(1 for s in signs if abs(sum(d * x for d, x in zip(diffs, s))) >= observed - tolerance)
+
+    if len(diffs) <= EXACT_TEST_LIMIT:
+        return extreme(itertools.product((1, -1), repeat=len(diffs))) / 2 ** len(diffs)
+    # Counting the observed signs as one of the samples keeps the estimate above zero
+    rng = rng or random.Random(0)
+    sampled = ([rng.choice((1, -1)) for _ in diffs] for _ in range(PERMUTATION_SAMPLES))
+    return (extreme(sampled) + 1) / (PERMUTATION_SAMPLES + 1)
+
+
+@dataclass
+class PromptVariant:
+    """One arm of the experiment: a name and the templates it swaps in"""
+    name: str
+    overrides: Dict[str, str] = field(default_factory=dict)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"name": self.name, "overrides": dict(self.overrides)}
+
+
+class PromptExperiment:
+    """Runs the same scenario under two prompt variants on each of a list of seeds
+
+    Both variants see the same seed, so their outcomes are compared pair by
+    pair. Which variant goes first alternates, since agents keep their
+    memories between runs on the same team.
+    """
+
+    def __init__(
+        self,
+        coordinator: MultiAgentCoordinator,
+        task_factory: Callable[[int], List[Tuple[TaskType, Dict[str, Any]]]],
+        variant_a: PromptVariant,
+        variant_b: PromptVariant,
+        seeds: List[int],
+        duration_seconds: int = 300,
+        metric_fn: Callable[[Dict[str, Any]], Dict[str, float]] = outcome_metrics,
+        primary_metric: str = "overall_success_rate",
+        higher_is_better: Optional[Dict[str, bool]] = None,
+        alpha: float = 0.05,
+        prepare: Optional[Callable[[MultiAgentCoordinator], None]] = None
+    ):
+        if variant_a.name == variant_b.name:
+            raise ValueError("Variants need different names")
+        self.coordinator = coordinator
+        self.task_factory = task_factory
+        self.variants = [variant_a, variant_b]
+        self.seeds = list(seeds)
+        self.duration_seconds = duration_seconds
+        self.metric_fn = metric_fn
+        self.primary_metric = primary_metric
+        self.higher_is_better = {**OUTCOME_METRICS, **(higher_is_better or {})}
+        self.alpha = alpha
+        self.prepare = prepare
+        self.runs: List[Dict[str, Any]] = []
+
+    async def run(self) -> Dict[str, Any]:
+        """Play every seed under both variants and return the experiment report"""
+        original_overrides = self.coordinator.prompts.manifest()["overrides"]
+        try:
+            for pair, seed in enumerate(self.seeds):
+                order = self.variants if pair % 2 == 0 else self.variants[::-1]
+                for variant in order:
+                    self.runs.append(await self._play(pair, seed, variant))
+        finally:
+            self.coordinator.set_prompts(original_overrides)
+        return self.report()
+
+    async def _play(self, pair: int, seed: int, variant: PromptVariant) -> Dict[str, Any]:
+        async with self.coordinator.run_lock:
+            self.coordinator.reset()
+            if self.prepare:
+                self.prepare(self.coordinator)
+            self.coordinator.set_seed(seed)
+            self.coordinator.set_prompts(variant.overrides)
+            result = await self.coordinator.execute_scenario(self.task_factory(seed), self.duration_seconds)
+        metrics = self.metric_fn(result)
+        logger.info(f"Prompt experiment seed {seed}: {variant.name} {self.primary_metric}={metrics.get(self.primary_metric, 0):.3f}")
+        return {
+            "pair": pair + 1,
+            "seed": seed,
+            "variant": variant.name,
+            "metrics": metrics,
+            "prompts": result.get("prompts")
+        }
+
+    def _values(self, variant: str, metric: str) -> List[float]:
+        by_seed = {r["pair"]: r["metrics"].get(metric, 0.0) for r in self.runs if r["variant"] == variant}
+        return [by_seed[pair] for pair in sorted(by_seed)]
+
+    def compare(self, metric: str) -> Dict[str, Any]:
+        """Means, paired difference and significance for one metric, and the variant it favours"""
+        a_name, b_name = (v.name for v in self.variants)
+        a, b = self._values(a_name, metric), self._values(b_name, metric)
+        pairs = min(len(a), len(b))
+        a, b = a[:pairs], b[:pairs]
+        diffs = [x - y for x, y in zip(a, b)]
+        p_value = paired_permutation_test(a, b, random.Random(f"{metric}-{self.seeds}"))
+        mean_diff = statistics.fmean(diffs) if diffs else 0.0
+        if mean_diff == 0:
+            better = None
+        elif (mean_diff > 0) == self.higher_is_better.get(metric, True):
+            better = a_name
+        else:
+            better = b_name
+        return {
+            "pairs": pairs,
+            "mean": {a_name: statistics.fmean(a) if a else None, b_name: statistics.fmean(b) if b else None},
+            "stdev": {
+                a_name: statistics.stdev(a) if len(a) > 1 else None,
+                b_name: statistics.stdev(b) if len(b) > 1 else None
+            },
+            "mean_difference": mean_diff,  # a - b
+            "p_value": p_value,
+            "significant": p_value < self.alpha,
+            "better": better
+        }
+
+    def report(self) -> Dict[str, Any]:
+        """Every metric compared, with the winner decided by the primary metric when its difference is significant"""
+        metrics = sorted({m for r in self.runs for m in r["metrics"]})
+        comparisons = {metric: self.compare(metric) for metric in metrics}
+        primary = comparisons.get(self.primary_metric)
+        winner = primary["better"] if primary and primary["significant"] else None
+        return {
+            "variants": [v.to_dict() for v in self.variants],
+            "seeds": self.seeds,
+            "primary_metric": self.primary_metric,
+            "alpha": self.alpha,
+            "comparisons": comparisons,
+            "winner": winner,
+            "conclusion": (
+                f"{winner} produced better {self.primary_metric} (p={primary['p_value']:.3g})" if winner
+                else f"No significant difference in {self.primary_metric}"
+                     + (f" (p={primary['p_value']:.3g})" if primary else "")
+            ),
+            "runs": self.runs
+        }
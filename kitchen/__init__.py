@@ -1,13 +1,3 @@
 """
-Kitchen simulation package with advanced state management.
+Kitchen API server: routes, order planning, run recording and the services behind them
 """
-
-from .engine import KitchenEngine, KitchenState, Equipment, KitchenStation, EnvironmentalConditions
-
-__all__ = [
-    "KitchenEngine",
-    "KitchenState", 
-    "Equipment",
-    "KitchenStation",
-    "EnvironmentalConditions"
-]
\ No newline at end of file
@@ -0,0 +1,168 @@
+"""
+Order Admission for ChefBench
+Turns new orders away at the door while the kitchen is overloaded, with an estimate of when it could take them
+"""
+
+import json
+import os
+from dataclasses import dataclass, asdict, field, fields
+from typing import Dict, List, Optional, Any
+import logging
+
+from equipment import station_for
+from dining import ORDER_TASKS
+from providers import MultiAgentCoordinator
+from kitchen.orders import OrderPlan
+
+logger = logging.getLogger(__name__)
+
+
+@dataclass
+class AdmissionPolicy:
+    """When the kitchen refuses a new order; a limit left at None isn't checked"""
+    enabled: bool = True
+    max_active_orders: Optional[int] = 40  # order portions queued, deferred or cooking, counting the new one's
+    max_station_load: Optional[float] = 2.0  # tasks on a station, counting the new order's, per slot it has
+    max_wait_seconds: Optional[float] = 900.0  # expected work ahead of the new order
+
+    def validate(self):
+        if self.max_active_orders is not None and self.max_active_orders < 1:
+            raise ValueError("max_active_orders must be at least 1")
+        if self.max_station_load is not None and self.max_station_load <= 0:
+            raise ValueError("max_station_load must be positive")
+        if self.max_wait_seconds is not None and self.max_wait_seconds < 0:
+            raise ValueError("max_wait_seconds can't be negative")
+
+    @classmethod
+    def from_env(cls) -> "AdmissionPolicy":
+        """Defaults, overridden by a JSON object in CHEFBENCH_ADMISSION"""
+        raw = os.environ.get("CHEFBENCH_ADMISSION")
+        if not raw:
+            return cls()
+        try:
+            names = {f.name for f in fields(cls)}
+            policy = cls(**{k: v for k, v in json.loads(raw).items() if k in names})
+            policy.validate()
+            return policy
+        except (ValueError, TypeError, AttributeError) as e:
+            logger.error(f"Ignoring invalid CHEFBENCH_ADMISSION: {e}")
+            return cls()
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class AdmissionDecision:
+    """Whether an order gets in, and if not why and how long until it could
+
+    wait_estimate is None when the order is refused however quiet the kitchen
+    gets, because it's bigger than the limits on its own.
+    """
+    admitted: bool
+    reasons: List[str] = field(default_factory=list)
+    wait_estimate: Optional[float] = None  # expected seconds of work to clear before the order fits
+    active_orders: int = 0
+    queued_seconds: float = 0.0
+
+    @property
+    def retryable(self) -> bool:
+        return not self.admitted and self.wait_estimate is not None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "admitted": self.admitted,
+            "reasons": self.reasons,
+            "wait_estimate": round(self.wait_estimate, 2) if self.wait_estimate is not None else None,
+            "active_orders": self.active_orders,
+            "queued_seconds": round(self.queued_seconds, 2)
+        }
+
+
+def _is_order(task_type) -> bool:
+    return task_type.function_name in ORDER_TASKS
+
+
+def admit(policy: AdmissionPolicy, coordinator: MultiAgentCoordinator, plan: OrderPlan) -> AdmissionDecision:
+    """Check a planned order against the policy and the kitchen's work in hand, changing nothing
+
+    A plan of no items checks the kitchen itself, every station included.
+    Work is taken to clear in queue order at the average expected time of the
+    queued tasks, so the wait estimate is how long until enough of it has
+    cleared for the order to fit.
+    """
+    active = coordinator.active_tasks()
+    tasks = plan.tasks()
+    queued_seconds = plan.queued_seconds
+    decision = AdmissionDecision(
+        admitted=True,
+        active_orders=sum(1 for task_type, _ in active if _is_order(task_type)),
+        queued_seconds=queued_seconds
+    )
+    if not policy.enabled:
+        return decision
+
+    depth = coordinator.queue_depth()
+    per_task = queued_seconds / depth if depth else None
+    if per_task is None:
+        expected = [item.expected_seconds for item in plan.items if item.expected_seconds]
+        per_task = max(expected) if expected else None
+    waits: List[float] = []
+    oversized = False
+
+    def clear(count: int, matches) -> Optional[float]:
+        """Expected seconds until count of the active tasks that match have finished"""
+        seen = 0
+        for position, (task_type, _) in enumerate(active):
+            if matches(task_type):
+                seen += 1
+                if seen == count:
+                    return (position + 1) * (per_task or 0.0)
+        return None
+
+    if policy.max_active_orders is not None:
+        # Checking the kitchen itself asks whether it has room for anything at all
+        excess = decision.active_orders + (len(tasks) or 1) - policy.max_active_orders
+        if excess > 0:
+            decision.reasons.append(
+                f"{decision.active_orders} orders in the kitchen, past the limit of {policy.max_active_orders}"
+                if decision.active_orders >= policy.max_active_orders
+                else f"{len(tasks)} more portions would take the kitchen past its limit of {policy.max_active_orders} orders"
+            )
+            wait = clear(excess, _is_order)
+            if wait is None:
+                oversized = True
+            else:
+                waits.append(wait)
+
+    if policy.max_station_load is not None:
+        incoming: Dict[str, int] = {}
+        for task_type, _ in tasks:
+            incoming[station_for(task_type)] = incoming.get(station_for(task_type), 0) + 1
+        on_station: Dict[str, int] = {}
+        for task_type, _ in active:
+            on_station[station_for(task_type)] = on_station.get(station_for(task_type), 0) + 1
+        for station in sorted(incoming or on_station):
+            slots = coordinator.station_capacity[station]
+            limit = int(policy.max_station_load * slots)
+            load = on_station.get(station, 0) + incoming.get(station, 0)
+            excess = load - limit
+            if excess <= 0:
+                continue
+            decision.reasons.append(f"{station} would have {load} tasks on it, past {limit} for its {slots} slots")
+            wait = clear(excess, lambda task_type, station=station: station_for(task_type) == station)
+            if wait is None:
+                oversized = True
+            else:
+                waits.append(wait)
+
+    if policy.max_wait_seconds is not None and queued_seconds > policy.max_wait_seconds:
+        decision.reasons.append(
+            f"Expected wait of {queued_seconds:.0f}s is past the limit of {policy.max_wait_seconds:.0f}s"
+        )
+        waits.append(queued_seconds - policy.max_wait_seconds)
+
+    if decision.reasons:
+        decision.admitted = False
+        decision.wait_estimate = None if oversized else max(waits)
+    return decision
@@ -3,76 +3,618 @@ ChefBench API Server
 Production-ready REST API for benchmark evaluation
 """
 
-from fastapi import FastAPI, HTTPException, BackgroundTasks
-from fastapi.responses import FileResponse, JSONResponse
-from pydantic import BaseModel, Field
-from typing import Dict, List, Optional, Any, Tuple
+from fastapi import FastAPI, HTTPException, BackgroundTasks, Header, Query, Request
+from fastapi.middleware.cors import CORSMiddleware
+from fastapi.responses import FileResponse, JSONResponse, StreamingResponse
+from pydantic import BaseModel, Field, ValidationError
+from typing import Dict, List, Optional, Any, Tuple, Union
 from pathlib import Path
 import asyncio
+import copy
+import hmac
+import json
+import math
+import random
+import tempfile
 import uuid
 import logging
-from datetime import datetime
+import os
+import time
+from datetime import date, datetime
 
 # Import ChefBench modules
-from models.models import AgentRole, TaskType, LLMAgent
-from providers import MultiAgentCoordinator
+from models.models import AgentRole, TaskType, LLMAgent, FALLBACK_POLICIES, CERTIFICATIONS, SkillProfile, MOCK_MODEL
+from models.simulator import get_simulator
+from providers import MultiAgentCoordinator, ASSIGNMENT_POLICIES, QUALITY_RUBRICS, GRADERS, get_quality_rubric
+from providers import LLMJudge, DEFAULT_JUDGE_MODEL, judge_transcripts, CHAOS_ACTIONS, EscalationThresholds, HandoffSettings
+from providers import ModelRouting, COURSES, DEFAULT_COURSE
 from recipes.dataset_parser import RecipeDatasetParser
-from metrics import MetricsCollector
+from recipes.substitutions import SubstitutionKnowledgeBase, Substitution
+from recipes.importer import IMPORT_FORMATS, import_recipes
+from recipes.ingredients import IngredientCatalog, IngredientInfo, ALLERGENS, DIETARY_TAGS
+from recipes.normalization import IngredientNormalizer, set_normalizer
+from metrics import MetricsCollector, SCORING_PROFILES, score_run, plate_cost, build_daily_report, DailyReportStore
+from metrics import ScoringConfig, get_scoring_config, get_scoring_profile, set_scoring_config
+from metrics import TREND_GROUPS, TREND_INTERVALS, TREND_METRICS, build_trends
+from database.event_store import EventStore, NOTE_SEVERITIES, ORDER_PRIORITIES, ORDER_STATUSES, order_timeline
+from database.transcripts import TranscriptStore
+from database.menu_store import MenuStore
+from database.checkpoints import CheckpointStore
+from database.export import EXPORT_FORMATS, check_format, write_run_export
+from eta import ETAEstimator, score_eta
+from experiments import OUTCOME_METRICS, PromptVariant, PromptExperiment, outcome_metrics
+from staffing import Shift, HRSystem, SkillStore, URGENCY_LEVELS
+from dining import FloorPlan, TABLE_STATUSES, ORDER_TASKS, CustomerSimulator, DemandConfig, demand_summary
+from dining import service_report
+from kitchen.admission import AdmissionPolicy, AdmissionDecision, admit
+from kitchen.bundles import ScenarioLibrary
+from kitchen.costing import cost_ticket, item_sales, menu_engineering
+from kitchen.tutorial import TUTORIAL_TASK_DISTRIBUTION, tutorial_progress, hints_for_events
+from kitchen.errors import install_error_handlers
+from kitchen.faults import FaultInjector
+from kitchen.golden import GoldenRun
+from kitchen.idempotency import IdempotencyStore
+from kitchen.menu import Menu, MenuItem, EightySixed, dish_name, list_price
+from kitchen.orders import OrderItem, OrderPlan, OrderPlanner, DEFAULT_ORDER_TASK, DEFAULT_TIME_LIMIT
+from kitchen.pagination import DEFAULT_LIMIT, MAX_LIMIT, paginate, sort_items
+from kitchen.progress import RunProgress
+from kitchen.notifier import KitchenNotifier, SEVERITIES
+from kitchen.sandbox import SandboxManager, DEFAULT_SESSION
+from kitchen.snapshot import KitchenReadModel
+from kitchen.webhooks import WebhookDispatcher, WEBHOOK_EVENTS, PING, ALLOWED_HOSTS_ENV
+from kitchen.schema import SCHEMA_MODELS, all_schemas, get_schema
+from kitchen.seeds import SEED_PROFILES, SEEDERS, SeedTarget, get_seed_profile
+from kitchen.health import (
+    HealthChecker, check_event_store, check_llm_agents, check_dataset, check_writable
+)
+from observability import (
+    configure_logging, get_log_buffer, log_context, get_usage_tracker, set_transcript_sink,
+    configure_tracing, tracing_middleware, RunBudget, BUDGET_EXCEEDED
+)
+from prompts import PROMPT_VARIABLES, get_prompt_registry
 
-logging.basicConfig(level=logging.INFO)
 logger = logging.getLogger(__name__)
 
+# Stop reverse proxies (nginx, Traefik) from buffering Server-Sent Events
+SSE_HEADERS = {"Cache-Control": "no-cache", "X-Accel-Buffering": "no"}
+
+SCENARIO_TYPES = {
+    "standard": "Menu planning, prep, cooking, plating and a quality check",
+    "crisis": "Equipment failures and stock shortages under time pressure",
+    "collaboration": "Coordination and communication-heavy service",
+    "complex": "Recipe changes, station management and temperature control",
+    "tutorial": "A gentle walkthrough with hints for new users",
+}
+DURATION_RANGE = (60, 3600)
+NUM_TASKS_RANGE = (1, 50)
+
+# On hand when neither a bundle nor the dataset says otherwise
+DEFAULT_INGREDIENTS = ["salt", "pepper", "oil", "flour", "eggs", "milk", "butter"]
+
+# Most orders one POST /orders/bulk can schedule
+MAX_BULK_ORDERS = 500
+
+# Most recipes a menu planning task is offered to choose from
+MENU_CANDIDATES = 12
+
+# Suggested in the playground's model picker, alongside the current team's models
+PLAYGROUND_MODELS = [
+    MOCK_MODEL,
+    "cohere/command-r",
+    "microsoft/DialoGPT-medium",
+    "meta-llama/Llama-3.1-8B-Instruct",
+]
+PLAYGROUND_PAGE = Path(__file__).parent / "static" / "playground.html"
+
 
 # Request/Response Models
 class AgentCreationRequest( BaseModel):
     name: str
-    role: str = Field(..., pattern="^(HEAD_CHEF|SOUS_CHEF|CHEF_DE_PARTIE|LINE_COOK|PREP_COOK|KITCHEN_PORTER)$")
+    role: str = Field(..., pattern="^(HEAD_CHEF|SOUS_CHEF|CHEF_DE_PARTIE|LINE_COOK|PREP_COOK|KITCHEN_PORTER|COMMIS)$")
     model_name: str = Field(default="cohere/command-r")
     device: str = Field(..., pattern="^(cpu|gpu)$")
+    fallback_policy: str = Field("retry", pattern=f"^({'|'.join(FALLBACK_POLICIES)})$")
 
 
 class TeamCreationRequest(BaseModel):
     model_name: str
     team_size: int = Field(4, ge=2, le=6)
     roles: Optional[List[str]] = None
+    fallback_policy: str = Field("retry", pattern=f"^({'|'.join(FALLBACK_POLICIES)})$")
+
+
+class RoutedTeamRequest(BaseModel):
+    team_size: int = Field(4, ge=2, le=6)
+    roles: Optional[List[str]] = None
+    fallback_policy: str = Field("retry", pattern=f"^({'|'.join(FALLBACK_POLICIES)})$")
+    default_model: Optional[str] = Field(None, description="Model for roles the routing doesn't list, over its default")
+
+
+class ModelRoutingRequest(BaseModel):
+    roles: Optional[Dict[str, Optional[str]]] = Field(
+        None, description="Models by role name to set, e.g. {'HEAD_CHEF': 'gpt-4'}; null removes a role's"
+    )
+    default: Optional[str] = Field(None, description="Model for roles not listed")
 
 
 class MixedTeamRequest(BaseModel):
-    agents: List[Dict[str, str]]  # [{"model": "model_name", "role": "ROLE_NAME"}]
+    # [{"model": "model_name", "role": "ROLE_NAME", "fallback_policy": "retry"}]
+    agents: List[Dict[str, str]]
 
 
 class ScenarioExecutionRequest(BaseModel):
-    scenario_type: str = Field("standard", pattern="^(standard|crisis|collaboration|complex)$")
-    duration_seconds: int = Field(300, ge=60, le=3600)
-    num_tasks: int = Field(10, ge=1, le=50)
+    scenario_type: str = Field(
+        "standard",
+        description=f"Built-in type ({', '.join(SCENARIO_TYPES)}) or the name of a scenario bundle"
+    )
+    duration_seconds: int = Field(300, ge=DURATION_RANGE[0], le=DURATION_RANGE[1])
+    num_tasks: int = Field(10, ge=NUM_TASKS_RANGE[0], le=NUM_TASKS_RANGE[1])
     use_dataset: bool = True
+    assignment_policy: str = Field("highest_rank", pattern=f"^({'|'.join(ASSIGNMENT_POLICIES)})$")
+    seed: Optional[int] = Field(None, ge=0, description="RNG seed; defaults to the server seed or a random one")
+    simulate_equipment: bool = Field(False, description="Simulate equipment wear, maintenance and breakdowns")
+    simulate_guests: bool = Field(False, description="Have seated guests change and cancel orders through their servers")
+    simulate_customers: bool = Field(
+        False,
+        description="Have customers order from the menu through the run, following the demand curves of a service day"
+    )
+    customer_demand: Dict[str, Any] = Field(
+        default_factory=dict,
+        description="Demand settings to use in place of $CHEFBENCH_DEMAND's, e.g. {'day': 'saturday', 'dinner_boost': 4}"
+    )
+    plan_pacing: bool = Field(False, description="Plan the firing order of all orders across stations, for the head chef to review")
+    expeditor: bool = Field(
+        False,
+        description="Put an expeditor on the pass: table orders go out a whole course at a time, later courses are "
+                    "held until it fires them, and portions a waiting course is short of are called back"
+    )
+    scoring_profile: Optional[str] = Field(
+        None,
+        description="Profile the run's headline score and pass/fail use; defaults to the scenario's in the "
+                    "scoring config, else balanced. Every profile is still computed"
+    )
+    dietary_restrictions: List[str] = Field(
+        default_factory=list,
+        description="Guest allergens to avoid and dietary tags to satisfy, e.g. ['peanuts', 'vegan']"
+    )
+    quality_rubric: Union[str, Dict[str, Any]] = Field(
+        "default",
+        description="Built-in rubric name, or a rubric definition with weighted, graded categories"
+    )
+    judge_transcripts: bool = Field(False, description="Have a judge model score each agent's transcript after the run")
+    judge_model: Optional[str] = Field(None, description=f"Judge model, defaults to {DEFAULT_JUDGE_MODEL}")
+    prompt_overrides: Dict[str, str] = Field(
+        default_factory=dict,
+        description="Prompt templates to use in place of the registry's for this run, by name, e.g. {'task.line_cook': '...'}"
+    )
+    seed_profile: Optional[str] = Field(
+        None,
+        pattern=f"^({'|'.join(SEED_PROFILES)})$",
+        description="Seed data the scenario needs, applied to the sandbox before tasks are generated"
+    )
+    max_tokens: Optional[int] = Field(None, ge=1, description="Pause the run once its LLM calls use this many tokens")
+    max_cost: Optional[float] = Field(None, gt=0, description="Pause the run once its LLM calls cost this much, in USD")
+
+
+class BudgetRequest(BaseModel):
+    max_tokens: Optional[int] = Field(None, ge=1, description="New token limit; omitted keeps the current one")
+    max_cost: Optional[float] = Field(None, gt=0, description="New cost limit in USD; omitted keeps the current one")
+    warn_at: Optional[float] = Field(None, gt=0, lt=1, description="Share of a limit used before the run is warned")
+
+
+class JudgeRequest(BaseModel):
+    model: Optional[str] = Field(None, description=f"Judge model, defaults to {DEFAULT_JUDGE_MODEL}")
+
+
+class SubstitutionRequest(BaseModel):
+    ingredient: str
+    substitute: str
+    ratio: float = Field(1.0, gt=0)
+    quality_penalty: float = Field(0.0, ge=0, le=1)
+    notes: str = ""
+
+
+class IngredientRequest(BaseModel):
+    name: str
+    unit: str = "units"
+    cost_per_unit: float = Field(0.0, ge=0)
+    shelf_life_days: Optional[int] = Field(None, ge=0)
+    allergens: List[str] = Field(default_factory=list, description=f"Any of {list(ALLERGENS)}")
+    dietary: List[str] = Field(default_factory=list, description=f"Any of {list(DIETARY_TAGS)}")
+
+
+class IngredientQuantity(BaseModel):
+    name: str = Field(..., min_length=1)
+    quantity: Optional[float] = Field(None, ge=0, description="Converted to the catalog's unit when given")
+    unit: Optional[str] = Field(None, description="Unit of quantity, e.g. 'cups', 'oz'; defaults to pieces")
+
+
+class IngredientResolveRequest(BaseModel):
+    ingredients: List[IngredientQuantity] = Field(..., min_length=1)
+
+
+class IngredientAliasRequest(BaseModel):
+    alias: str = Field(..., min_length=1)
+    ingredient: str = Field(..., min_length=1, description="Catalog ingredient the alias stands for")
+
+
+class RestrictionCheckRequest(BaseModel):
+    ingredients: List[str] = Field(..., min_length=1)
+    restrictions: List[str] = Field(
+        default_factory=list,
+        description="Allergens to avoid and dietary tags to satisfy, e.g. ['peanuts', 'vegan']"
+    )
+
+
+class RecipeImportRequest(BaseModel):
+    format: str = Field(..., pattern=f"^({'|'.join(IMPORT_FORMATS)})$")
+    content: str = Field(
+        ...,
+        min_length=1,
+        description="JSON-LD (or an HTML page embedding it) for schema_org; base64 export file for paprika"
+    )
+    dry_run: bool = Field(False, description="Parse and return the recipes without adding them")
+
+
+class SkillUpdateRequest(BaseModel):
+    skills: Dict[str, Union[float, str]] = Field(
+        default_factory=dict,
+        description="Task function name -> skill from 0 to 1 or a proficiency name; merged into the profile"
+    )
+    certifications: Optional[List[str]] = Field(None, description=f"Replaces the agent's certifications: {list(CERTIFICATIONS)}")
+
+
+class ShiftRequest(BaseModel):
+    agent_name: str
+    start: float = Field(..., ge=0, description="Simulated seconds from scenario start")
+    end: float = Field(..., gt=0)
+    hourly_wage: Optional[float] = Field(None, ge=0)
+
+
+class ScheduleRequest(BaseModel):
+    shifts: List[ShiftRequest]
+
+
+class StaffRequestModel(BaseModel):
+    role: str = Field(..., pattern="^(HEAD_CHEF|SOUS_CHEF|CHEF_DE_PARTIE|LINE_COOK|PREP_COOK|KITCHEN_PORTER|COMMIS)$")
+    count: int = Field(1, ge=1, le=10)
+    urgency: str = Field("normal", pattern="^(low|normal|high|critical)$")
+    reason: str = ""
+    requested_by: Optional[str] = None
+
+
+class OrderNoteRequest(BaseModel):
+    author: str = Field(..., min_length=1, description="Agent on the team, or whoever else is writing the note")
+    reason: str = Field(..., min_length=1, max_length=1000)
+    severity: str = Field("warning", pattern=f"^({'|'.join(NOTE_SEVERITIES)})$")
+
+
+class CourseCallRequest(BaseModel):
+    course: str = Field(..., pattern=f"^({'|'.join(COURSES)})$")
+    table: Optional[int] = Field(None, description="The table to call it for; every table if left out")
+    author: str = Field("expo", min_length=1, description="Agent on the team, or whoever is running the pass")
+
+
+class OrderItemRequest(BaseModel):
+    task_type: str = Field(DEFAULT_ORDER_TASK, pattern=f"^({'|'.join(sorted(ORDER_TASKS))})$")
+    recipe_id: Optional[int] = Field(None, description="A recipe on the menu (the loaded dataset)")
+    dish: Optional[str] = Field(None, max_length=200)
+    ingredients: List[str] = Field(default_factory=list, description="For a dish that isn't on the menu")
+    quantity: int = Field(1, ge=1, le=20)
+    course: str = Field(DEFAULT_COURSE, pattern=f"^({'|'.join(COURSES)})$", description="Held and fired with its course")
+    notes: List[str] = Field(default_factory=list, max_length=10, description="Changes for the cook, e.g. 'no onions'")
+
+
+class OrderSubmissionRequest(BaseModel):
+    items: List[OrderItemRequest] = Field(..., min_length=1, max_length=50)
+    table: Optional[int] = Field(None, description="A seated table the order is for")
+    party_size: Optional[int] = Field(None, ge=1, le=20, description="Guests the order feeds; defaults to the party seated at its table")
+    dietary_restrictions: List[str] = Field(default_factory=list)
+    time_limit: float = Field(300, gt=0, description="Seconds the order should be ready within")
+    priority: str = Field(
+        "normal",
+        pattern=f"^({'|'.join(ORDER_PRIORITIES)})$",
+        description="expedite puts the order at the front of the queue; reassign also moves it to the least busy cook"
+    )
+
+
+class ScheduledOrderRequest(OrderSubmissionRequest):
+    arrive_at: float = Field(0, ge=0, description="Seconds into the run the order comes in; past times arrive at once")
+
+
+class OrderModificationRequest(BaseModel):
+    add_items: List[OrderItemRequest] = Field(default_factory=list, max_length=50)
+    remove_items: List[int] = Field(default_factory=list, description="Lines to take off the ticket")
+    quantities: Dict[int, int] = Field(default_factory=dict, description="Line -> portions it should have")
+    void: List[str] = Field(default_factory=list, description="Portions (task ids) to void")
+    note: Optional[str] = Field(None, min_length=1, max_length=200, description="A change for every queued portion")
+    priority: Optional[str] = Field(None, pattern=f"^({'|'.join(ORDER_PRIORITIES)})$")
+    reason: str = Field("voided", min_length=1, max_length=200, description="Why the voided portions were voided")
+    author: Optional[str] = Field(None, max_length=100)
+
+
+class ModelComparisonRequest(BaseModel):
+    models: List[str] = Field(..., min_length=2, max_length=8)
+    scenario: Optional[ScenarioExecutionRequest] = None
+    evaluation_id: Optional[str] = Field(None, description="Reuse the config and seed of an earlier run")
+    team_size: int = Field(4, ge=2, le=6)
+    roles: Optional[List[str]] = None
+    max_parallel: int = Field(4, ge=1, le=8, description="Models run at once; 1 runs them in turn")
+
+
+class PromptExperimentRequest(BaseModel):
+    scenario: Optional[ScenarioExecutionRequest] = None
+    variant_a: Dict[str, str] = Field(
+        default_factory=dict,
+        description="Prompt templates variant A swaps in, by name; empty uses the registry's own"
+    )
+    variant_b: Dict[str, str] = Field(..., description="Prompt templates variant B swaps in, by name")
+    name_a: str = Field("A", min_length=1)
+    name_b: str = Field("B", min_length=1)
+    runs: int = Field(
+        10, ge=2, le=50,
+        description="Seeds each variant runs on; pairs are tested exactly, so p < 0.05 needs at least 6"
+    )
+    primary_metric: str = Field(
+        "score",
+        pattern=f"^({'|'.join(['score', *OUTCOME_METRICS])})$",
+        description="Metric that decides the winner; score is the scenario's scoring profile"
+    )
+    alpha: float = Field(0.05, gt=0, lt=1, description="Significance level")
+
+
+class AutoScheduleRequest(BaseModel):
+    duration_seconds: float = Field(..., gt=0)
+    shift_seconds: float = Field(..., gt=0)
+
+
+class TableRequest(BaseModel):
+    number: int = Field(..., ge=1)
+    capacity: int = Field(..., ge=1, le=20)
+
+
+class ReservationRequest(BaseModel):
+    name: str = Field(..., min_length=1)
+    party_size: int = Field(..., ge=1, le=20)
+    time: datetime
+
+
+class SeatRequest(BaseModel):
+    reservation_id: Optional[str] = None
+    party_size: Optional[int] = Field(None, ge=1, le=20, description="Walk-in party, when there's no reservation")
+
+
+class TableStatusRequest(BaseModel):
+    status: str = Field(..., pattern=f"^({'|'.join(TABLE_STATUSES[3:])})$")
+
+
+class ServerRequest(BaseModel):
+    name: str = Field(..., min_length=1)
+    tables: List[int] = Field(default_factory=list, description="Section of table numbers; empty floats across all")
+
+
+class DailyReportRequest(BaseModel):
+    day: Optional[date] = Field(None, description="Day to report on, defaults to today")
+
+
+class ChaosRequest(BaseModel):
+    action: str = Field(..., pattern=f"^({'|'.join(CHAOS_ACTIONS)})$")
+    agent: Optional[str] = Field(None, description="kill_agent: agent to take off the team")
+    equipment: Optional[str] = Field(None, description="break_equipment: equipment item to break")
+    count: int = Field(5, ge=1, le=NUM_TASKS_RANGE[1], description="spike_orders: extra tasks to add")
+    ingredients: List[str] = Field(default_factory=list, description="spoil_inventory: ingredients to spoil")
+    delay_seconds: float = Field(2.0, gt=0, le=60, description="delay_llm: added to each model response")
+    tasks: int = Field(5, ge=1, le=NUM_TASKS_RANGE[1], description="delay_llm: how many tasks are slowed")
+
+
+class EscalationConfigRequest(BaseModel):
+    enabled: Optional[bool] = None
+    scan_interval: Optional[float] = Field(None, gt=0, description="Simulated seconds between scans")
+    expedite_after: Optional[float] = Field(None, gt=0, description="Multiple of a task's time limit before it jumps the queue")
+    reassign_after: Optional[float] = Field(None, gt=0, description="Multiple of a task's time limit before it is reassigned")
+
+
+class EightySixRequest(BaseModel):
+    reason: str = Field("", max_length=200, description="Why the item is off, shown to the team and on orders refused for it")
+
+
+class MenuItemRequest(BaseModel):
+    recipe_id: int = Field(..., description="A loaded recipe")
+    specialty: bool = Field(False, description="A house specialty, listed by GET /menu/specialties")
+    seasonal: bool = Field(False, description="On for the season only")
+    price: Optional[float] = Field(None, gt=0, description="What it sells for; defaults to its list price for what it costs to plate")
+
+
+class MenuPlanRequest(BaseModel):
+    items: List[MenuItemRequest] = Field(..., min_length=1, description="The whole menu; anything not listed comes off")
+
+
+class MenuItemUpdateRequest(BaseModel):
+    specialty: Optional[bool] = None
+    seasonal: Optional[bool] = None
+    price: Optional[float] = Field(None, gt=0)
+
+
+class AdmissionConfigRequest(BaseModel):
+    enabled: Optional[bool] = None
+    max_active_orders: Optional[int] = Field(None, ge=1, description="Order portions the kitchen holds before refusing more")
+    max_station_load: Optional[float] = Field(None, gt=0, description="Tasks on a station per slot before orders for it are refused")
+    max_wait_seconds: Optional[float] = Field(None, ge=0, description="Expected wait past which new orders are refused")
+
+
+class HandoffConfigRequest(BaseModel):
+    enabled: Optional[bool] = None
+    capacity: Optional[int] = Field(None, ge=1, description="Tasks an agent accepts per run before rejecting as over capacity")
+    min_skill: Optional[float] = Field(None, ge=0, le=1, description="Skill below which an agent rejects a task")
+    ack_seconds: Optional[float] = Field(None, ge=0, description="Simulated seconds a station takes to answer an offer")
+
+
+class SimulatorRequest(BaseModel):
+    error_rate: Optional[float] = Field(None, ge=0, le=1, description="Share of mock-model calls that fail outright")
+    malformed_rate: Optional[float] = Field(None, ge=0, le=1, description="Share of responses that aren't valid JSON")
+    hallucination_rate: Optional[float] = Field(None, ge=0, le=1, description="Share asking for an ingredient not on hand")
+    violation_rate: Optional[float] = Field(None, ge=0, le=1, description="Share using a guest's restricted ingredient")
+    latency_seconds: Optional[float] = Field(None, ge=0, description="Delay added to every call")
+    roles: Optional[Dict[str, Dict[str, float]]] = Field(
+        None, description="Rates overriding the above for particular roles, e.g. {'COMMIS': {'error_rate': 0.3}}"
+    )
+
+
+class ScoringConfigRequest(BaseModel):
+    profiles: Dict[str, Dict[str, Any]] = Field(
+        default_factory=dict,
+        description="Custom profiles by name: description, weights, thresholds and an optional formula, e.g. "
+                    "{'pastry': {'weights': {'quality': 2, 'safety': 1}, 'thresholds': {'safety': 0.9}}}"
+    )
+    scenarios: Dict[str, str] = Field(default_factory=dict, description="Profile each scenario type or bundle scores under")
+
+
+class WebhookRequest(BaseModel):
+    url: str = Field(..., pattern=r"^https?://")
+    secret: Optional[str] = Field(None, min_length=8, description="HMAC signing key; generated when omitted")
+    events: List[str] = Field(default_factory=list, description=f"Some of {list(WEBHOOK_EVENTS)}; empty for all")
+    min_quality: float = Field(0.5, ge=0, le=1, description="Quality below which an order counts as a quality failure")
+
+
+class WebhookUpdateRequest(BaseModel):
+    url: Optional[str] = Field(None, pattern=r"^https?://")
+    secret: Optional[str] = Field(None, min_length=8)
+    events: Optional[List[str]] = None
+    min_quality: Optional[float] = Field(None, ge=0, le=1)
+    enabled: Optional[bool] = None
+
+
+class FaultConfigRequest(BaseModel):
+    enabled: Optional[bool] = None
+    latency_ms: Optional[int] = Field(None, ge=0, le=60000)
+    latency_jitter_ms: Optional[int] = Field(None, ge=0, le=60000)
+    error_rate: Optional[float] = Field(None, ge=0, le=1)
+    error_status: Optional[int] = Field(None, ge=500, le=599)
+    stream_drop_rate: Optional[float] = Field(None, ge=0, le=1)
+    paths: Optional[List[str]] = None
 
 
 class ChefBenchAPI:
     """Main API server for ChefBench evaluation"""
     
-    def __init__(self):
+    def __init__(
+        self,
+        default_seed: Optional[int] = None,
+        admin_token: Optional[str] = None,
+        root_path: str = ""
+    ):
+        self.default_seed = default_seed
+        self.admin_token = admin_token or os.environ.get("CHEFBENCH_ADMIN_TOKEN")
         self.app = FastAPI(
             title="ChefBench API",
             description="Multi-agent LLM kitchen coordination benchmark",
-            version="1.0.0"
+            version="1.0.0",
+            root_path=root_path
         )
         
         # Initialize components
-        self.coordinator = MultiAgentCoordinator()
-        self.dataset_parser = RecipeDatasetParser()
+        self.event_store = EventStore("data/events.db")
+        self.transcripts = TranscriptStore("data/transcripts.db")
+        self.checkpoints = CheckpointStore("data/checkpoints")
+        self.event_retention_days = float(os.environ.get("CHEFBENCH_EVENT_RETENTION_DAYS", 30))
+        set_transcript_sink(self.transcripts.append)
+        self.sandboxes = SandboxManager(
+            self._sandbox_coordinator,
+            ttl_seconds=float(os.environ.get("CHEFBENCH_SANDBOX_TTL", 1800))
+        )
+        self.substitutions = SubstitutionKnowledgeBase("data/substitutions.json")
+        self.ingredient_catalog = IngredientCatalog("data/ingredients.json")
+        self.dataset_parser = RecipeDatasetParser(substitutions=self.substitutions, catalog=self.ingredient_catalog)
+        self.menu = Menu(self.dataset_parser, MenuStore("data/menu.db"))
+        self.normalizer = IngredientNormalizer(self.ingredient_catalog, "data/ingredient_aliases.json")
+        set_normalizer(self.normalizer)
+        self.scenario_library = ScenarioLibrary(reserved=tuple(SCENARIO_TYPES))
         self.metrics_collector = MetricsCollector()
+        self.daily_reports = DailyReportStore("data/reports")
+        self.eta_estimator = ETAEstimator()
+        # Turns orders away with 429 while the kitchen is overloaded
+        self.admission = AdmissionPolicy.from_env()
+        self.comparisons: Dict[str, Dict[str, Any]] = {}
+        self.experiments: Dict[str, Dict[str, Any]] = {}
+        
+        # Every error, raised or unexpected, answers as {code, message, details}
+        install_error_handlers(self.app)
         
-        # Active evaluations
-        self.active_evaluations: Dict[str, Dict] = {}
+        # Retried POSTs with the same Idempotency-Key get the first response back
+        self.idempotency = IdempotencyStore(ttl_seconds=float(os.environ.get("CHEFBENCH_IDEMPOTENCY_TTL", 86400)))
+        self.app.middleware("http")(self.idempotency.middleware)
+        
+        # Fault injection for client resilience testing
+        self.faults = FaultInjector(seed=default_seed)
+        self.webhooks = WebhookDispatcher("data/webhooks.json", allowed_hosts=_env_list(ALLOWED_HOSTS_ENV))
+        self.notifier = KitchenNotifier.from_config()
+        self.kitchen_view = KitchenReadModel()
+        # Models by role from the kitchen config, for routed teams
+        self.model_routing = ModelRouting.from_config()
+        get_scoring_config()
+        self.app.middleware("http")(self.faults.middleware)
+        self.app.middleware("http")(self.sandboxes.middleware)
+        
+        # Dependency checks for /readyz
+        self.health = HealthChecker()
+        self.health.register("event_store", lambda: check_event_store(self.event_store))
+        self.health.register("llm", lambda: check_llm_agents(self.coordinator.agents))
+        self.health.register("metrics", lambda: check_writable(self.metrics_collector.output_dir))
+        self.health.register("dataset", lambda: check_dataset(self.dataset_parser), required=False)
         
         # Setup routes
         self.setup_routes()
 
+    def _sandbox_coordinator(self, session_id: str) -> MultiAgentCoordinator:
+        """A session's kitchen, with staffing, skills and floor plan saved under its own data directory"""
+        root = Path("data") if session_id == DEFAULT_SESSION else Path("data/sessions") / session_id
+        coordinator = MultiAgentCoordinator(event_store=self.event_store)
+        coordinator.hr = HRSystem(str(root / "staffing.json"))
+        coordinator.skill_store = SkillStore(str(root / "skills.json"))
+        coordinator.floor = FloorPlan(str(root / "floor.json"))
+        return coordinator
+
+    @property
+    def coordinator(self) -> MultiAgentCoordinator:
+        """Coordinator of the calling session's sandbox"""
+        return self.sandboxes.current().coordinator
+    
+    @property
+    def active_evaluations(self) -> Dict[str, Dict]:
+        """Evaluations of the calling session's sandbox"""
+        return self.sandboxes.current().active_evaluations
+
     def setup_routes(self):
         """Configure all API routes"""
 
-        @self.app.get("/")
+        @self.app.on_event("startup")
+        async def resume_checkpointed_runs():
+            """Pick evaluations interrupted by a restart back up from their last checkpoint"""
+            for checkpoint in self.checkpoints.list():
+                asyncio.create_task(self._resume_evaluation(checkpoint))
+        
+        @self.app.on_event("startup")
+        async def start_event_archival():
+            """Move finished runs past retention out of the hot event table, hourly"""
+            asyncio.create_task(self._archive_events_periodically())
+        
+        @self.app.on_event("startup")
+        async def start_webhook_delivery():
+            """Notify registered webhooks of lifecycle events as they are logged"""
+            asyncio.create_task(self.webhooks.follow(self.event_store))
+        
+        @self.app.on_event("startup")
+        async def start_kitchen_alerts():
+            """Post high-severity events to the chat sinks in the config file, if it lists any"""
+            if self.notifier.sinks:
+                asyncio.create_task(self.notifier.follow(self.event_store))
+        
+        @self.app.on_event("startup")
+        async def start_kitchen_snapshot():
+            """Keep the kitchen read model current with the events logged"""
+            asyncio.create_task(self.kitchen_view.follow(self.event_store))
+        
+        @self.app.get("/", tags=["system"])
         async def root():
             return {
                 "name": "ChefBench API",
@@ -81,8 +623,39 @@ class ChefBenchAPI:
                 "components": {
                     "coordinator": "ready",
                     "dataset": "ready" if self.dataset_parser.loaded else "not_loaded"
-                }
+                },
+                "playground": "/playground"
             }
+        
+        @self.app.get("/playground", tags=["system"], include_in_schema=False)
+        async def playground():
+            """Single-page UI: pick a model and scenario, run it, follow the transcript and charts"""
+            return FileResponse(PLAYGROUND_PAGE, media_type="text/html")
+
+
+        @self.app.get("/healthz", tags=["system"])
+        async def healthz():
+            """Liveness: the process is up and serving requests"""
+            return self.health.liveness()
+        
+        @self.app.get("/readyz", tags=["system"])
+        async def readyz(check: Optional[List[str]] = Query(None)):
+            """Readiness: probe each dependency, 503 if a required one is down"""
+            report = await self.health.readiness(check)
+            return JSONResponse(report, status_code=200 if report["ready"] else 503)
+
+
+        @self.app.get("/schema", tags=["system"])
+        async def list_schemas():
+            """JSON Schema for every persisted model"""
+            return all_schemas()
+        
+        @self.app.get("/schema/{model_name}", tags=["system"])
+        async def get_model_schema(model_name: str):
+            """JSON Schema for one persisted model"""
+            if model_name not in SCHEMA_MODELS:
+                raise HTTPException(404, f"Unknown model '{model_name}', expected one of {sorted(SCHEMA_MODELS)}")
+            return get_schema(model_name)
 
 
         async def _load_dataset(file_path: str):
@@ -98,7 +671,7 @@ class ChefBenchAPI:
             }
 
 
-        @self.app.get("/dataset/stats")
+        @self.app.get("/dataset/stats", tags=["dataset"])
         async def get_dataset_stats():
             """Get dataset statistics"""
             if not self.dataset_parser.loaded:
@@ -106,7 +679,141 @@ class ChefBenchAPI:
             
             return self.dataset_parser.get_statistics()
         
-        @self.app.post("/agents/create")
+        @self.app.post("/recipes/import", tags=["recipes"])
+        async def import_recipe_file(request: RecipeImportRequest):
+            """Import Schema.org JSON-LD or Paprika recipes into the recipe dataset"""
+            try:
+                imported = import_recipes(request.format, request.content, self.dataset_parser.cuisines)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            if not imported:
+                raise HTTPException(400, f"No recipes found in {request.format} content")
+            
+            # A recipe calling for a technique the kitchen doesn't know can't be
+            # routed to anyone; a dry run shows why instead of refusing it
+            errors = [f"{r.name}: {error}" for r in imported for error in r.errors]
+            if errors and not request.dry_run:
+                raise HTTPException(422, {"errors": errors})
+            
+            if not request.dry_run:
+                added = self.dataset_parser.add_recipes([r.recipe for r in imported])
+                for entry, recipe in zip(imported, added):
+                    entry.recipe = recipe
+            
+            return {
+                "status": "parsed" if request.dry_run else "imported",
+                "count": len(imported),
+                "recipes": [
+                    {**r.to_dict(), "dietary_profile": self.ingredient_catalog.profile(r.recipe["ingredients"])}
+                    for r in imported
+                ],
+                "total_recipes": len(self.dataset_parser.recipes)
+            }
+        
+        @self.app.get("/ingredients", tags=["recipes"])
+        async def list_ingredients():
+            """List the ingredient catalog"""
+            return self.ingredient_catalog.to_dict()
+        
+        @self.app.get("/ingredients/aliases", tags=["recipes"])
+        async def list_ingredient_aliases():
+            """Alias -> catalog ingredient"""
+            return dict(sorted(self.normalizer.aliases.items()))
+        
+        @self.app.post("/ingredients/aliases", tags=["recipes"])
+        async def add_ingredient_alias(request: IngredientAliasRequest):
+            """Map another name to a catalog ingredient"""
+            try:
+                alias, ingredient = self.normalizer.add_alias(request.alias, request.ingredient)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            return {"status": "saved", "alias": alias, "ingredient": ingredient}
+        
+        @self.app.delete("/ingredients/aliases/{alias}", tags=["recipes"])
+        async def remove_ingredient_alias(alias: str):
+            """Remove an alias; the catalog ingredient stays"""
+            if not self.normalizer.remove_alias(alias):
+                raise HTTPException(404, f"No alias '{alias}'")
+            return {"status": "removed", "alias": alias}
+        
+        @self.app.post("/ingredients/resolve", tags=["recipes"])
+        async def resolve_ingredients(request: IngredientResolveRequest):
+            """Canonical ids of free-text ingredient names, with quantities in the catalog's units"""
+            resolved = []
+            for item in request.ingredients:
+                entry = self.normalizer.resolve(item.name)
+                if item.quantity is not None:
+                    try:
+                        entry = self.normalizer.to_catalog_unit(item.name, item.quantity, item.unit or "pieces")
+                    except ValueError as e:
+                        entry["conversion_error"] = str(e)
+                resolved.append(entry)
+            return {"resolved": resolved, "unknown": [r["input"] for r in resolved if not r["known"]]}
+        
+        @self.app.get("/ingredients/{ingredient}", tags=["recipes"])
+        async def get_ingredient(ingredient: str):
+            """Get the catalog entry an ingredient name resolves to, through aliases too"""
+            info = self.ingredient_catalog.get(self.normalizer.canonical_id(ingredient))
+            if info is None:
+                raise HTTPException(404, f"Ingredient '{ingredient}' is not in the catalog")
+            return info.to_dict()
+        
+        @self.app.post("/ingredients", tags=["recipes"])
+        async def add_ingredient(request: IngredientRequest):
+            """Add or replace a catalog entry"""
+            try:
+                info = self.ingredient_catalog.add(IngredientInfo(**request.dict()))
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            return {"status": "saved", "ingredient": info.to_dict()}
+        
+        @self.app.delete("/ingredients/{ingredient}", tags=["recipes"])
+        async def remove_ingredient(ingredient: str):
+            """Remove a catalog entry"""
+            if not self.ingredient_catalog.remove(ingredient):
+                raise HTTPException(404, f"Ingredient '{ingredient}' is not in the catalog")
+            return {"status": "removed", "ingredient": ingredient}
+        
+        @self.app.post("/ingredients/check", tags=["recipes"])
+        async def check_ingredients(request: RestrictionCheckRequest):
+            """Dietary profile of a set of ingredients and any conflicts with restrictions"""
+            # Aliases resolved to their catalog names; unknown names are reported as given
+            ingredients = [
+                r["id"] if r["known"] else r["input"] for r in map(self.normalizer.resolve, request.ingredients)
+            ]
+            try:
+                check = self.ingredient_catalog.check_restrictions(ingredients, request.restrictions)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            return {**check, "profile": self.ingredient_catalog.profile(ingredients)}
+        
+        @self.app.get("/substitutions", tags=["recipes"])
+        async def list_substitutions():
+            """List the full substitution table"""
+            return self.substitutions.to_dict()
+        
+        @self.app.get("/substitutions/{ingredient}", tags=["recipes"])
+        async def get_substitutions(ingredient: str):
+            """Get substitutes for an ingredient, best quality first"""
+            return {
+                "ingredient": ingredient.lower(),
+                "substitutes": [s.to_dict() for s in self.substitutions.get_substitutes(ingredient)]
+            }
+        
+        @self.app.post("/substitutions", tags=["recipes"])
+        async def add_substitution(request: SubstitutionRequest):
+            """Add or replace a substitution"""
+            substitution = self.substitutions.add(Substitution(**request.dict()))
+            return {"status": "saved", "substitution": substitution.to_dict()}
+        
+        @self.app.delete("/substitutions/{ingredient}/{substitute}", tags=["recipes"])
+        async def remove_substitution(ingredient: str, substitute: str):
+            """Remove a substitution"""
+            if not self.substitutions.remove(ingredient, substitute):
+                raise HTTPException(404, f"No substitution {ingredient} -> {substitute}")
+            return {"status": "removed"}
+        
+        @self.app.post("/agents/create", tags=["agents"])
         async def create_agent(request: AgentCreationRequest):
             """Create a single agent"""
             try:
@@ -114,8 +821,8 @@ class ChefBenchAPI:
                 agent = self.coordinator.create_agent(
                     request.name,
                     role,
-                    request.model_name
-                    
+                    request.model_name,
+                    request.fallback_policy
                 )
                 
                 return {
@@ -130,7 +837,7 @@ class ChefBenchAPI:
             except Exception as e:
                 raise HTTPException(400, f"Failed to create agent: {str(e)}")
         
-        @self.app.post("/teams/create_uniform")
+        @self.app.post("/teams/create_uniform", tags=["agents"])
         async def create_uniform_team(request: TeamCreationRequest):
             """Create team with same model"""
             try:
@@ -141,7 +848,8 @@ class ChefBenchAPI:
                 team = self.coordinator.create_agent_team(
                     request.model_name,
                     request.team_size,
-                    roles
+                    roles,
+                    request.fallback_policy
                 )
                 
                 return {
@@ -159,7 +867,64 @@ class ChefBenchAPI:
             except Exception as e:
                 raise HTTPException(400, f"Failed to create team: {str(e)}")
         
-        @self.app.post("/teams/create_mixed")
+        @self.app.post("/teams/create_routed", tags=["agents"])
+        async def create_routed_team(request: RoutedTeamRequest):
+            """Create team with each role on the model the kitchen config routes it to"""
+            try:
+                roles = None
+                if request.roles:
+                    roles = [AgentRole[r] for r in request.roles]
+                
+                team = self.coordinator.create_routed_team(
+                    self.model_routing,
+                    request.team_size,
+                    roles,
+                    request.fallback_policy,
+                    request.default_model
+                )
+                
+                return {
+                    "status": "created",
+                    "team_size": len(team),
+                    "routing": self.coordinator.routing.to_dict(),
+                    "agents": [
+                        {
+                            "name": agent.name,
+                            "role": agent.role.name,
+                            "model": agent.model_name
+                        }
+                        for agent in team
+                    ]
+                }
+            except Exception as e:
+                raise HTTPException(400, f"Failed to create routed team: {str(e)}")
+        
+        @self.app.get("/teams/routing", tags=["agents"])
+        async def get_model_routing():
+            """Models by role from the kitchen config, and the models the current team's roles run"""
+            return {
+                "routing": self.model_routing.to_dict(),
+                "team": self.coordinator.model_routing()
+            }
+        
+        @self.app.put("/teams/routing", tags=["agents"])
+        async def configure_model_routing(request: ModelRoutingRequest):
+            """Change the routing until the server restarts, keeping roles not mentioned; applies to the next routed team"""
+            roles = dict(self.model_routing.roles)
+            for role, model in (request.roles or {}).items():
+                if model is None:
+                    roles.pop(role, None)
+                else:
+                    roles[role] = model
+            routing = ModelRouting(roles, request.default or self.model_routing.default)
+            try:
+                routing.validate()
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            self.model_routing = routing
+            return routing.to_dict()
+        
+        @self.app.post("/teams/create_mixed", tags=["agents"])
         async def create_mixed_team(request: MixedTeamRequest):
             """Create team with different models"""
             try:
@@ -168,7 +933,10 @@ class ChefBenchAPI:
                     for agent in request.agents
                 ]
                 
-                team = self.coordinator.create_mixed_provider_team(provider_models)
+                team = self.coordinator.create_mixed_provider_team(
+                    provider_models,
+                    [agent.get("fallback_policy", "retry") for agent in request.agents]
+                )
                 
                 return {
                     "status": "created",
@@ -185,11 +953,25 @@ class ChefBenchAPI:
             except Exception as e:
                 raise HTTPException(400, f"Failed to create mixed team: {str(e)}")
         
-        @self.app.get("/agents/list")
-        async def list_agents():
-            """List all registered agents"""
+        @self.app.get("/agents/list", tags=["agents"])
+        async def list_agents(
+            role: Optional[str] = None,
+            model: Optional[str] = None,
+            min_level: int = Query(0, ge=0, le=6, description="Lowest role level, 0 (commis) to 6 (head chef)"),
+            max_level: int = Query(6, ge=0, le=6),
+            sort: str = Query("name", description="name, role, model, tasks_completed, success_rate or avg_quality; '-' for descending"),
+            limit: int = Query(DEFAULT_LIMIT, ge=1, le=MAX_LIMIT),
+            offset: int = Query(0, ge=0)
+        ):
+            """List registered agents, filtered, sorted and paginated"""
             agents = []
             for name, agent in self.coordinator.agents.items():
+                if role is not None and agent.role.name != role.upper():
+                    continue
+                if model is not None and agent.model_name != model:
+                    continue
+                if not min_level <= agent.role.value <= max_level:
+                    continue
                 metrics = agent.get_metrics()
                 agents.append({
                     "name": name,
@@ -198,82 +980,1645 @@ class ChefBenchAPI:
                     "metrics": metrics
                 })
             
+            try:
+                agents = sort_items(agents, sort, {
+                    "name": lambda a: a["name"],
+                    "role": lambda a: AgentRole[a["role"]].value,
+                    "model": lambda a: a["model"],
+                    "tasks_completed": lambda a: a["metrics"]["tasks_completed"],
+                    "success_rate": lambda a: a["metrics"]["success_rate"],
+                    "avg_quality": lambda a: a["metrics"]["avg_quality"]
+                })
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            page, pagination = paginate(agents, limit, offset)
+            
             return {
-                "total_agents": len(agents),
-                "agents": agents
+                "total_agents": pagination["total"],
+                "agents": page,
+                **pagination
             }
         
-        @self.app.post("/scenarios/execute")
-        async def execute_scenario(
-            request: ScenarioExecutionRequest,
-            background_tasks: BackgroundTasks
-        ):
-            """Execute a benchmark scenario"""
-            if len(self.coordinator.agents) < 2:
-                raise HTTPException(400, "Need at least 2 agents to run scenario")
-            
-            evaluation_id = str(uuid.uuid4())
-            
-            # Generate tasks based on scenario type
-            tasks = self._generate_scenario_tasks(
-                request.scenario_type,
-                request.num_tasks,
-                request.use_dataset
-            )
+        @self.app.get("/agents/{name}/skills", tags=["agents"])
+        async def get_agent_skills(name: str):
+            """Get an agent's per-task skills, proficiency and certifications"""
+            agent = self.coordinator.agents.get(name)
+            if agent is None:
+                raise HTTPException(404, f"Unknown agent '{name}'")
+            return {"name": name, "role": agent.role.name, **agent.skills.to_dict()}
+        
+        @self.app.get("/agents/{name}/memory", tags=["agents"])
+        async def get_agent_memory(name: str):
+            """What an agent recalls: summaries of older events, and the recent ones verbatim"""
+            agent = self.coordinator.agents.get(name)
+            if agent is None:
+                raise HTTPException(404, f"Unknown agent '{name}'")
+            return {"name": name, **await asyncio.to_thread(agent.memory_snapshot)}
+        
+        @self.app.put("/agents/{name}/skills", tags=["agents"])
+        async def update_agent_skills(name: str, request: SkillUpdateRequest):
+            """Set skills by number or proficiency name, and optionally replace certifications"""
+            agent = self.coordinator.agents.get(name)
+            if agent is None:
+                raise HTTPException(404, f"Unknown agent '{name}'")
+            unknown = [c for c in request.certifications or [] if c not in CERTIFICATIONS]
+            if unknown:
+                raise HTTPException(400, f"Unknown certifications {unknown}, expected some of {list(CERTIFICATIONS)}")
             
-            # Initialize evaluation
-            self.active_evaluations[evaluation_id] = {
-                "id": evaluation_id,
-                "status": "running",
-                "started_at": datetime.now().isoformat(),
-                "config": request.dict(),
-                "result": None
-            }
+            # Validate every skill before changing any
+            checked = SkillProfile()
+            try:
+                for task, value in request.skills.items():
+                    checked.set_skill(TaskType.from_function_name(task), value)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
             
-            # Start execution in background
-            background_tasks.add_task(
-                self._run_scenario,
-                evaluation_id,
-                tasks,
-                request.duration_seconds,
-                request.scenario_type
+            agent.skills.skills.update(checked.skills)
+            if request.certifications is not None:
+                agent.skills.certifications = list(dict.fromkeys(request.certifications))
+            self.coordinator.skill_store.update(agent)
+            self.coordinator.record_event(
+                "skills_updated",
+                agent_name=name,
+                skills=dict(request.skills),
+                certifications=agent.skills.certifications
             )
-            
-            return {
-                "evaluation_id": evaluation_id,
-                "status": "started",
-                "message": f"Scenario started with {len(tasks)} tasks"
-            }
+            return {"name": name, "role": agent.role.name, **agent.skills.to_dict()}
         
-        @self.app.get("/scenarios/{evaluation_id}/status")
-        async def get_scenario_status(evaluation_id: str):
-            """Get scenario execution status"""
-            if evaluation_id not in self.active_evaluations:
-                raise HTTPException(404, "Evaluation not found")
-            
-            eval_data = self.active_evaluations[evaluation_id]
-            return {
-                "evaluation_id": evaluation_id,
-                "status": eval_data["status"],
-                "started_at": eval_data["started_at"],
-                "config": eval_data["config"]
-            }
+        @self.app.get("/staff", tags=["staff"])
+        async def get_staffing():
+            """Get the idle agent pool and open staffing requests"""
+            return self.coordinator.hr.to_dict()
         
-        @self.app.get("/scenarios/{evaluation_id}/results")
-        async def get_scenario_results(evaluation_id: str):
-            """Get scenario results"""
-            if evaluation_id not in self.active_evaluations:
-                raise HTTPException(404, "Evaluation not found")
-            
-            eval_data = self.active_evaluations[evaluation_id]
-            if eval_data["status"] != "completed":
-                raise HTTPException(400, f"Evaluation is {eval_data['status']}")
-            
-            return eval_data["result"]
+        @self.app.post("/staff/pool", tags=["staff"])
+        async def hire_agent(request: AgentCreationRequest):
+            """Create an idle agent that staffing requests can draw on"""
+            if request.name in self.coordinator.agents or request.name in self.coordinator.hr.pool:
+                raise HTTPException(400, f"Agent {request.name} already exists")
+            agent = self.coordinator.hr.hire(request.name, AgentRole[request.role], request.model_name)
+            # A new hire may be able to fill a waiting request
+            joined = self.coordinator.fulfill_staff_requests()
+            return {"status": "assigned" if agent.name in joined else "pooled", "agent": agent.name}
         
-        @self.app.get("/metrics/charts")
-        async def generate_charts():
-            """Generate visualization charts"""
+        @self.app.delete("/staff/pool/{agent_name}", tags=["staff"])
+        async def dismiss_agent(agent_name: str):
+            """Remove an idle agent from the pool"""
+            if not self.coordinator.hr.dismiss(agent_name):
+                raise HTTPException(404, "Agent not in pool")
+            return {"status": "dismissed", "agent": agent_name}
+        
+        @self.app.post("/staff/release/{agent_name}", tags=["staff"])
+        async def release_agent(agent_name: str):
+            """Move a team member back to the idle pool"""
+            if agent_name not in self.coordinator.agents:
+                raise HTTPException(404, "Agent not found")
+            self.coordinator.release_staff(agent_name)
+            return {"status": "released", "agent": agent_name}
+        
+        @self.app.get("/staff/requests", tags=["staff"])
+        async def list_staff_requests(
+            status: Optional[str] = None,
+            role: Optional[str] = None,
+            urgency: Optional[str] = None,
+            created_after: Optional[float] = Query(None, description="Unix timestamp"),
+            sort: str = Query("created_at", description="created_at, updated_at, urgency or role; '-' for descending"),
+            limit: int = Query(DEFAULT_LIMIT, ge=1, le=MAX_LIMIT),
+            offset: int = Query(0, ge=0)
+        ):
+            """List staffing requests, filtered, sorted and paginated"""
+            requests = [
+                r.to_dict() for r in self.coordinator.hr.requests.values()
+                if (status is None or r.status == status)
+                and (role is None or r.role == role.upper())
+                and (urgency is None or r.urgency == urgency)
+                and (created_after is None or r.created_at > created_after)
+            ]
+            try:
+                requests = sort_items(requests, sort, {
+                    "created_at": lambda r: r["created_at"],
+                    "updated_at": lambda r: r["updated_at"],
+                    "urgency": lambda r: URGENCY_LEVELS.index(r["urgency"]),
+                    "role": lambda r: AgentRole[r["role"]].value
+                })
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            page, pagination = paginate(requests, limit, offset)
+            return {"count": len(page), "requests": page, **pagination}
+        
+        @self.app.post("/staff/requests", tags=["staff"])
+        async def create_staff_request(request: StaffRequestModel):
+            """Request more agents of a role; filled from the pool by urgency"""
+            staff_request = self.coordinator.request_staff(
+                AgentRole[request.role],
+                request.count,
+                request.urgency,
+                request.reason,
+                request.requested_by
+            )
+            return staff_request.to_dict()
+        
+        @self.app.post("/staff/requests/{request_id}/cancel", tags=["staff"])
+        async def cancel_staff_request(request_id: str):
+            """Cancel an open staffing request"""
+            if request_id not in self.coordinator.hr.requests:
+                raise HTTPException(404, "Staff request not found")
+            return self.coordinator.hr.cancel(request_id).to_dict()
+        
+        @self.app.get("/schedule", tags=["schedule"])
+        async def get_schedule():
+            """Get shifts and hourly wages; without custom shifts everyone works the whole run"""
+            return self.coordinator.schedule.to_dict(self.coordinator.agents)
+        
+        @self.app.put("/schedule", tags=["schedule"])
+        async def set_schedule(request: ScheduleRequest):
+            """Replace the shift schedule; agents without a shift sit out scenarios"""
+            try:
+                self.coordinator.schedule.set_shifts(
+                    [Shift(**shift.dict()) for shift in request.shifts],
+                    self.coordinator.agents
+                )
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            return self.coordinator.schedule.to_dict(self.coordinator.agents)
+        
+        @self.app.post("/schedule/auto", tags=["schedule"])
+        async def auto_schedule(request: AutoScheduleRequest):
+            """Rotate same-role agents through back-to-back shifts covering a run"""
+            if not self.coordinator.agents:
+                raise HTTPException(400, "No agents created")
+            self.coordinator.schedule.auto_schedule(
+                self.coordinator.agents,
+                request.duration_seconds,
+                request.shift_seconds
+            )
+            return self.coordinator.schedule.to_dict(self.coordinator.agents)
+        
+        @self.app.put("/schedule/wages", tags=["schedule"])
+        async def set_wages(wages: Dict[str, float]):
+            """Set hourly wages by role name"""
+            try:
+                updates = {AgentRole[role]: float(wage) for role, wage in wages.items()}
+            except KeyError as e:
+                raise HTTPException(400, f"Unknown role {e}")
+            self.coordinator.schedule.wages.update(updates)
+            return self.coordinator.schedule.to_dict(self.coordinator.agents)
+        
+        @self.app.delete("/schedule", tags=["schedule"])
+        async def clear_schedule():
+            """Drop custom shifts so every agent works the whole run"""
+            self.coordinator.schedule.clear()
+            return self.coordinator.schedule.to_dict(self.coordinator.agents)
+        
+        @self.app.get("/tables", tags=["dining"])
+        async def list_tables():
+            """Tables, upcoming reservations and course pacing"""
+            return self.coordinator.floor.to_dict()
+        
+        @self.app.post("/tables", tags=["dining"])
+        async def add_table(request: TableRequest):
+            """Add a table to the dining room"""
+            try:
+                table = self.coordinator.floor.add_table(request.number, request.capacity)
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            return table.to_dict()
+        
+        @self.app.delete("/tables/{number}", tags=["dining"])
+        async def remove_table(number: int):
+            """Remove a table nobody is dining at"""
+            try:
+                table = self.coordinator.floor.remove_table(number)
+            except KeyError:
+                raise HTTPException(404, f"Table {number} not found")
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            return {"removed": table.number}
+        
+        @self.app.get("/tables/pacing", tags=["dining"])
+        async def get_pacing():
+            """How long each dining table has been at its course, most behind first"""
+            return self.coordinator.floor.pacing()
+        
+        @self.app.post("/tables/seat", tags=["dining"])
+        async def seat_party(request: SeatRequest):
+            """Seat a reservation at its held table, or a walk-in at the best free fit"""
+            try:
+                table = self.coordinator.floor.seat(request.reservation_id, request.party_size)
+            except KeyError:
+                raise HTTPException(404, f"Reservation {request.reservation_id} not found")
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            self.coordinator.record_event(
+                "table_seated",
+                table=table.number,
+                party_size=table.party_size,
+                reservation_id=table.reservation_id
+            )
+            return table.to_dict()
+        
+        @self.app.post("/tables/{number}/status", tags=["dining"])
+        async def set_table_status(number: int, request: TableStatusRequest):
+            """Move a seated table on to fired, dessert or paid"""
+            try:
+                table = self.coordinator.floor.advance(number, request.status)
+            except KeyError:
+                raise HTTPException(404, f"Table {number} not found")
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            self.coordinator.record_event("table_status_changed", table=number, status=request.status)
+            return table.to_dict()
+        
+        @self.app.get("/reservations", tags=["dining"])
+        async def list_reservations():
+            """Booked reservations, soonest first"""
+            return [r.to_dict() for r in self.coordinator.floor.upcoming()]
+        
+        @self.app.post("/reservations", tags=["dining"])
+        async def make_reservation(request: ReservationRequest):
+            """Book a party, holding a table for it when one is free"""
+            try:
+                reservation = self.coordinator.floor.reserve(
+                    request.name, request.party_size, request.time.timestamp()
+                )
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            self.coordinator.record_event(
+                "reservation_made",
+                reservation_id=reservation.reservation_id,
+                party_size=reservation.party_size,
+                table=reservation.table
+            )
+            return reservation.to_dict()
+        
+        @self.app.delete("/reservations/{reservation_id}", tags=["dining"])
+        async def cancel_reservation(reservation_id: str):
+            """Cancel a booking and free its held table"""
+            try:
+                reservation = self.coordinator.floor.cancel(reservation_id)
+            except KeyError:
+                raise HTTPException(404, f"Reservation {reservation_id} not found")
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            self.coordinator.record_event("reservation_cancelled", reservation_id=reservation_id)
+            return reservation.to_dict()
+        
+        @self.app.get("/servers", tags=["dining"])
+        async def list_servers():
+            """Front-of-house servers and the tables each covers"""
+            return self.coordinator.front_of_house.to_dict()
+        
+        @self.app.post("/servers", tags=["dining"])
+        async def add_server(request: ServerRequest):
+            """Put a server on the floor to relay guests' requests and the kitchen's delays"""
+            try:
+                server = self.coordinator.front_of_house.add_server(request.name, request.tables)
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            return server.to_dict()
+        
+        @self.app.delete("/servers/{name}", tags=["dining"])
+        async def remove_server(name: str):
+            """Take a server off the floor"""
+            try:
+                server = self.coordinator.front_of_house.remove_server(name)
+            except KeyError:
+                raise HTTPException(404, f"Server {name} not found")
+            return {"removed": server.name}
+        
+        @self.app.get("/scenarios", tags=["scenarios"])
+        async def list_scenarios(
+            status: Optional[str] = None,
+            scenario_type: Optional[str] = None,
+            started_after: Optional[datetime] = None,
+            sort: str = Query("-started_at", description="started_at, status or scenario_type; '-' for descending"),
+            limit: int = Query(DEFAULT_LIMIT, ge=1, le=MAX_LIMIT),
+            offset: int = Query(0, ge=0)
+        ):
+            """List this session's evaluations, filtered, sorted and paginated"""
+            if started_after is not None and started_after.tzinfo is not None:
+                # started_at is recorded in server local time
+                started_after = started_after.astimezone().replace(tzinfo=None)
+            
+            evaluations = [
+                {
+                    "evaluation_id": e["id"],
+                    "status": e["status"],
+                    "started_at": e["started_at"],
+                    "scenario_type": e["config"].get("scenario_type"),
+                    "num_tasks": e["config"].get("num_tasks"),
+                    "seed": e["seed"]
+                }
+                for e in self.active_evaluations.values()
+                if (status is None or e["status"] == status)
+                and (scenario_type is None or e["config"].get("scenario_type") == scenario_type)
+                and (started_after is None or datetime.fromisoformat(e["started_at"]) > started_after)
+            ]
+            try:
+                evaluations = sort_items(evaluations, sort, {
+                    "started_at": lambda e: e["started_at"],
+                    "status": lambda e: e["status"],
+                    "scenario_type": lambda e: e["scenario_type"] or ""
+                })
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            page, pagination = paginate(evaluations, limit, offset)
+            return {"count": len(page), "evaluations": page, **pagination}
+        
+        @self.app.get("/scenarios/bundles", tags=["scenarios"])
+        async def list_scenario_bundles():
+            """Scenario bundles found under the scenarios directory, and any that failed to load"""
+            bundles = self.scenario_library.list()
+            return {
+                "root": str(self.scenario_library.root),
+                "count": len(bundles),
+                "bundles": [b.to_dict() for b in bundles],
+                "errors": self.scenario_library.errors
+            }
+        
+        @self.app.post("/scenarios/bundles/reload", tags=["scenarios"])
+        async def reload_scenario_bundles():
+            """Rescan the scenarios directory even if no scenario.yaml looks changed"""
+            self.scenario_library.refresh(force=True)
+            return {
+                "count": len(self.scenario_library.bundles),
+                "bundles": sorted(self.scenario_library.bundles),
+                "errors": self.scenario_library.errors
+            }
+        
+        @self.app.get("/scenarios/bundles/{name}", tags=["scenarios"])
+        async def get_scenario_bundle(name: str):
+            """One scenario bundle: its task mix, defaults, seed steps and assertions"""
+            bundle = self.scenario_library.get(name)
+            if bundle is None:
+                raise HTTPException(404, f"Scenario bundle '{name}' not found")
+            return bundle.to_dict()
+        
+        @self.app.get("/scenarios/{evaluation_id}/orders", tags=["scenarios"])
+        async def list_orders(
+            evaluation_id: str,
+            q: Optional[str] = Query(None, description="Case-insensitive text in the task id, type, agent, table, guest notes or annotations"),
+            status: Optional[List[str]] = Query(None, description="Any of these statuses"),
+            priority: Optional[List[str]] = Query(None, description="Any of these priorities"),
+            task_type: Optional[List[str]] = Query(None, description="Any of these task types"),
+            sort: str = Query("placed_at", description="placed_at, finished_at, task_id, status, priority or quality_score; '-' for descending"),
+            limit: int = Query(DEFAULT_LIMIT, ge=1, le=MAX_LIMIT),
+            offset: int = Query(0, ge=0)
+        ):
+            """Search and filter a run's orders, with counts per status, priority and type for the matches"""
+            for name, values, allowed in (("status", status, ORDER_STATUSES), ("priority", priority, ORDER_PRIORITIES)):
+                unknown = set(values or []) - set(allowed)
+                if unknown:
+                    raise HTTPException(400, f"Unknown {name} {sorted(unknown)}, expected some of {list(allowed)}")
+            
+            orders = self.event_store.orders(evaluation_id)
+            if not orders and evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            coordinator = self.coordinator
+            if coordinator.running and coordinator.run_id == evaluation_id:
+                for order in orders:
+                    if order["task_id"] == coordinator.in_flight_task_id:
+                        order["status"] = "in_progress"
+            
+            if q:
+                needle = q.lower()
+                orders = [
+                    o for o in orders
+                    if needle in " ".join(
+                        str(v) for v in (
+                            o["task_id"], o["task_type"], o["agent_name"], o["table"],
+                            *o["notes"], *(a["reason"] for a in o["annotations"])
+                        ) if v is not None
+                    ).lower()
+                ]
+            # Counts before the chip filters, so each chip shows what toggling it would match
+            facets = {}
+            for field in ("status", "priority", "task_type", "complexity"):
+                values = [o[field] for o in orders if o[field] is not None]
+                facets[field] = {value: values.count(value) for value in sorted(set(values))}
+            orders = [
+                o for o in orders
+                if (not status or o["status"] in status)
+                and (not priority or o["priority"] in priority)
+                and (not task_type or o["task_type"] in task_type)
+            ]
+            try:
+                orders = sort_items(orders, sort, {
+                    "placed_at": lambda o: o["placed_at"],
+                    "finished_at": lambda o: o["finished_at"] or float("inf"),
+                    "task_id": lambda o: o["task_id"],
+                    "status": lambda o: ORDER_STATUSES.index(o["status"]),
+                    "priority": lambda o: ORDER_PRIORITIES.index(o["priority"]),
+                    "quality_score": lambda o: o["quality_score"] if o["quality_score"] is not None else -1.0
+                })
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            page, pagination = paginate(orders, limit, offset)
+            return {"evaluation_id": evaluation_id, "count": len(page), "orders": page, "facets": facets, **pagination}
+        
+        @self.app.get("/scenarios/{evaluation_id}/orders/{task_id}", tags=["scenarios"])
+        async def get_order(evaluation_id: str, task_id: str):
+            """One order of a run, with its notes oldest first and its timeline from received to served"""
+            order = next((o for o in self.event_store.orders(evaluation_id) if o["task_id"] == task_id), None)
+            if order is None:
+                raise HTTPException(404, f"Order {task_id} not found in evaluation {evaluation_id}")
+            coordinator = self.coordinator
+            live = coordinator.running and coordinator.run_id == evaluation_id
+            if live and coordinator.in_flight_task_id == task_id:
+                order["status"] = "in_progress"
+            return {
+                "evaluation_id": evaluation_id,
+                **order,
+                "timeline": order_timeline(order, time.time() if live else None)
+            }
+        
+        @self.app.get("/scenarios/rubrics", tags=["scenarios"])
+        async def get_quality_rubrics():
+            """Built-in quality rubrics in full, and the graders a custom rubric can use"""
+            return {
+                "rubrics": [r.to_dict() for r in QUALITY_RUBRICS.values()],
+                "graders": {name: (grader.__doc__ or "").strip() for name, grader in GRADERS.items()}
+            }
+        
+        @self.app.get("/scenarios/prompts", tags=["scenarios"])
+        async def get_prompt_templates():
+            """Prompt templates agents render, with their versions and the variables each kind can use"""
+            registry = get_prompt_registry()
+            return {
+                "directory": str(registry.directory),
+                "hot_reload": registry.hot_reload,
+                "roles": registry.roles,
+                "variables": {kind: list(names) for kind, names in PROMPT_VARIABLES.items()},
+                "templates": [t.to_dict() for _, t in sorted(registry.templates().items())]
+            }
+        
+        @self.app.get("/seed/profiles", tags=["system"])
+        async def get_seed_profiles():
+            """Seed profiles and the seeders they are composed of"""
+            return {
+                "profiles": [p.to_dict() for p in SEED_PROFILES.values()],
+                "seeders": {name: (seeder.__doc__ or "").strip().splitlines()[0] for name, seeder in SEEDERS.items()}
+            }
+        
+        @self.app.post("/seed/{profile}", tags=["system"])
+        async def apply_seed_profile(profile: str):
+            """Add a seed profile's data to the calling session's sandbox"""
+            if profile not in SEED_PROFILES:
+                raise HTTPException(404, f"Unknown seed profile '{profile}', expected one of {sorted(SEED_PROFILES)}")
+            if self.coordinator.running:
+                raise HTTPException(409, "A scenario is running; wait for it to finish before seeding")
+            return {"profile": profile, "created": self.apply_seed_profile(profile)}
+        
+        @self.app.get("/scenarios/options", tags=["scenarios"])
+        async def get_scenario_options():
+            """List the scenario types, assignment policies and limits a run accepts"""
+            return {
+                "scenario_types": [
+                    {"name": name, "description": description, "bundle": False}
+                    for name, description in SCENARIO_TYPES.items()
+                ] + [
+                    {"name": b.name, "description": b.description, "bundle": True, "defaults": b.defaults}
+                    for b in self.scenario_library.list()
+                ],
+                "assignment_policies": [
+                    {"name": name, "description": (policy.__doc__ or "").strip()}
+                    for name, policy in ASSIGNMENT_POLICIES.items()
+                ],
+                "quality_rubrics": [
+                    {"name": r.name, "description": r.description}
+                    for r in QUALITY_RUBRICS.values()
+                ],
+                "scoring_profiles": [
+                    {"name": p.name, "description": p.description}
+                    for p in SCORING_PROFILES.values()
+                ],
+                "seed_profiles": [
+                    {"name": p.name, "description": p.description}
+                    for p in SEED_PROFILES.values()
+                ],
+                "duration_seconds": {"min": DURATION_RANGE[0], "max": DURATION_RANGE[1], "default": 300},
+                "num_tasks": {"min": NUM_TASKS_RANGE[0], "max": NUM_TASKS_RANGE[1], "default": 10},
+                "dietary_restrictions": {"allergens": list(ALLERGENS), "dietary": list(DIETARY_TAGS)},
+                "agents": len(self.coordinator.agents),
+                "min_agents": 2,
+                "models": list(dict.fromkeys(
+                    [a.model_name for a in self.coordinator.agents.values()] + PLAYGROUND_MODELS
+                ))
+            }
+        
+        @self.app.post("/scenarios/execute", tags=["scenarios"])
+        async def execute_scenario(
+            request: ScenarioExecutionRequest,
+            background_tasks: BackgroundTasks
+        ):
+            """Execute a benchmark scenario"""
+            try:
+                request = self._with_bundle_defaults(request)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            bundle = self.scenario_library.get(request.scenario_type)
+            if request.seed_profile:
+                self.apply_seed_profile(request.seed_profile)
+            if bundle:
+                bundle.apply_seed(SeedTarget(self.coordinator, self.dataset_parser))
+            if len(self.coordinator.agents) < 2:
+                raise HTTPException(400, "Need at least 2 agents to run scenario")
+            
+            evaluation_id = str(uuid.uuid4())
+            
+            # Every run gets a recorded seed so it can be reproduced exactly
+            seed = request.seed
+            if seed is None:
+                seed = self.default_seed if self.default_seed is not None else random.randrange(2**31)
+            self.dataset_parser.reseed(seed)
+            
+            # Generate tasks based on scenario type
+            try:
+                get_quality_rubric(request.quality_rubric)
+                get_prompt_registry().with_overrides(request.prompt_overrides)
+                DemandConfig.from_env().with_overrides(request.customer_demand)
+                tasks = self._generate_scenario_tasks(
+                    request.scenario_type,
+                    request.num_tasks,
+                    request.use_dataset,
+                    request.dietary_restrictions
+                )
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            
+            eta = self.eta_estimator.estimate(
+                tasks,
+                self.coordinator.agents,
+                request.assignment_policy,
+                request.duration_seconds
+            ).to_dict()
+            
+            # Initialize evaluation
+            self.active_evaluations[evaluation_id] = {
+                "id": evaluation_id,
+                "status": "running",
+                "started_at": datetime.now().isoformat(),
+                "config": {**request.dict(), "seed": seed},
+                "seed": seed,
+                "eta": eta,
+                "result": None,
+                # As generated, before the coordinator annotates them, for recording golden runs
+                "tasks": [{"task_type": t.function_name, "context": copy.deepcopy(c)} for t, c in tasks]
+            }
+            
+            # Start execution in background
+            background_tasks.add_task(
+                self._run_scenario,
+                evaluation_id,
+                tasks,
+                request.duration_seconds,
+                request.scenario_type
+            )
+            
+            return {
+                "evaluation_id": evaluation_id,
+                "status": "started",
+                "seed": seed,
+                "eta": eta,
+                "message": f"Scenario started with {len(tasks)} tasks"
+            }
+        
+        @self.app.post("/scenarios/estimate", tags=["scenarios"])
+        async def estimate_scenario(request: ScenarioExecutionRequest):
+            """Estimate how long a scenario would take with the current team"""
+            if not self.coordinator.agents:
+                raise HTTPException(400, "No agents created")
+            
+            try:
+                request = self._with_bundle_defaults(request)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            if request.seed is not None:
+                self.dataset_parser.reseed(request.seed)
+            try:
+                tasks = self._generate_scenario_tasks(
+                    request.scenario_type,
+                    request.num_tasks,
+                    request.use_dataset,
+                    request.dietary_restrictions
+                )
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            return self.eta_estimator.estimate(
+                tasks,
+                self.coordinator.agents,
+                request.assignment_policy,
+                request.duration_seconds
+            ).to_dict()
+        
+        @self.app.get("/scenarios/{evaluation_id}/status", tags=["scenarios"])
+        async def get_scenario_status(evaluation_id: str):
+            """Get scenario execution status"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            eval_data = self.active_evaluations[evaluation_id]
+            eta = eval_data.get("eta")
+            return {
+                "evaluation_id": evaluation_id,
+                "status": eval_data["status"],
+                "started_at": eval_data["started_at"],
+                "config": eval_data["config"],
+                "eta": eta,
+                "eta_remaining_seconds": (
+                    max(0.0, round(eta["predicted_completion"] - time.time(), 2))
+                    if eta and eval_data["status"] == "running" else None
+                ),
+                "customers": eval_data.get("customers")
+            }
+        
+        @self.app.get("/scenarios/{evaluation_id}/results", tags=["scenarios"])
+        async def get_scenario_results(evaluation_id: str):
+            """Get scenario results"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            eval_data = self.active_evaluations[evaluation_id]
+            if eval_data["status"] != "completed":
+                raise HTTPException(400, f"Evaluation is {eval_data['status']}")
+            
+            eval_data["results_viewed"] = True
+            return eval_data["result"]
+        
+        @self.app.get("/scenarios/{evaluation_id}/golden", tags=["scenarios"])
+        async def get_golden_run(evaluation_id: str, name: Optional[str] = None):
+            """A completed run as a golden run: its team, tasks, settings, model responses and metrics"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            try:
+                golden = GoldenRun.record(
+                    name or evaluation_id[:8],
+                    self.active_evaluations[evaluation_id],
+                    self.transcripts.query(run_id=evaluation_id)
+                )
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            return golden.to_dict()
+        
+        @self.app.post("/scenarios/{evaluation_id}/judge", tags=["scenarios"])
+        async def judge_scenario(evaluation_id: str, request: JudgeRequest):
+            """Score a completed run's agent transcripts with a judge model, replacing any earlier judgement"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            eval_data = self.active_evaluations[evaluation_id]
+            if eval_data["status"] != "completed":
+                raise HTTPException(400, f"Evaluation is {eval_data['status']}")
+            
+            eval_data["result"]["judgement"] = await self._judge_run(evaluation_id, eval_data["result"], request.model)
+            return eval_data["result"]["judgement"]
+        
+        @self.app.post("/evaluations/runs/{evaluation_id}/pause", tags=["evaluations"])
+        async def pause_run(evaluation_id: str):
+            """Pause a running evaluation before its next task and checkpoint it"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            eval_data = self.active_evaluations[evaluation_id]
+            if eval_data["status"] != "running" or self.coordinator.run_id != evaluation_id:
+                raise HTTPException(409, f"Evaluation is {eval_data['status']} and not executing")
+            
+            self.coordinator.pause()
+            eval_data["status"] = "paused"
+            return {"evaluation_id": evaluation_id, "status": "paused"}
+        
+        @self.app.post("/evaluations/runs/{evaluation_id}/resume", tags=["evaluations"])
+        async def resume_run(evaluation_id: str):
+            """Resume a paused evaluation"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            eval_data = self.active_evaluations[evaluation_id]
+            if eval_data["status"] != "paused":
+                raise HTTPException(409, f"Evaluation is {eval_data['status']}, not paused")
+            
+            self.coordinator.resume()
+            eval_data["status"] = "running"
+            return {"evaluation_id": evaluation_id, "status": "running"}
+        
+        @self.app.get("/evaluations/runs/{evaluation_id}/budget", tags=["evaluations"])
+        async def get_run_budget(evaluation_id: str):
+            """A run's token and cost limits and how much of them it has used"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            status = get_usage_tracker().budget_status(evaluation_id)
+            return {"evaluation_id": evaluation_id, "budget": status}
+        
+        @self.app.put("/evaluations/runs/{evaluation_id}/budget", tags=["evaluations"])
+        async def set_run_budget(evaluation_id: str, request: BudgetRequest):
+            """Raise or lower a run's limits, resuming it if it was paused for going over budget"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            tracker = get_usage_tracker()
+            current = tracker.budget_status(evaluation_id) or {}
+            budget = RunBudget(
+                max_tokens=request.max_tokens if request.max_tokens is not None else current.get("max_tokens"),
+                max_cost=request.max_cost if request.max_cost is not None else current.get("max_cost"),
+                warn_at=request.warn_at if request.warn_at is not None else current.get("warn_at", 0.8)
+            )
+            try:
+                tracker.set_budget(evaluation_id, budget)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            
+            eval_data = self.active_evaluations[evaluation_id]
+            status = tracker.budget_status(evaluation_id)
+            resumed = (
+                eval_data["status"] == "paused"
+                and self.coordinator.run_id == evaluation_id
+                and self.coordinator.pause_reason == "budget_exceeded"
+                and status["state"] != BUDGET_EXCEEDED
+                and self.coordinator.resume()
+            )
+            if resumed:
+                eval_data["status"] = "running"
+            return {"evaluation_id": evaluation_id, "budget": status, "resumed": resumed}
+        
+        @self.app.get("/evaluations/runs/{evaluation_id}/events", tags=["evaluations"])
+        async def stream_run_progress(
+            evaluation_id: str,
+            after_id: int = 0,
+            last_event_id: Optional[int] = Header(None)
+        ):
+            """Stream a run's progress as Server-Sent Events: queued tasks, agent decisions,
+            status changes and metric snapshots with percentage complete
+            """
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            eval_data = self.active_evaluations[evaluation_id]
+            progress = RunProgress(evaluation_id, eval_data["config"].get("num_tasks", 0))
+            
+            def frame(event_type: str, data: Dict[str, Any], event_id: Optional[int]) -> str:
+                id_line = f"id: {event_id}\n" if event_id is not None else ""
+                return f"{id_line}event: {event_type}\ndata: {json.dumps(data, default=str)}\n\n"
+            
+            def snapshot() -> Dict[str, Any]:
+                return {**progress.snapshot(eval_data["status"]), "budget": get_usage_tracker().budget_status(evaluation_id)}
+            
+            async def progress_source():
+                # A reconnecting client has seen everything up to Last-Event-ID; fold it in silently
+                last_id = last_event_id if last_event_id is not None else after_id
+                for event in self.event_store.query(run_id=evaluation_id, include_archived=True):
+                    if event.event_id > last_id:
+                        break
+                    progress.apply(event)
+                yield frame("snapshot", snapshot(), progress.state["last_event_id"])
+                
+                while True:
+                    # Read the status first so every event of a finished run is sent before "done"
+                    finished = eval_data["status"] not in ("running", "paused")
+                    events = self.event_store.query(run_id=evaluation_id, after_id=last_id, include_archived=True)
+                    for event in events:
+                        last_id = event.event_id
+                        for event_type, data in progress.apply(event):
+                            if self.faults.should_drop_frame(f"/evaluations/runs/{evaluation_id}/events"):
+                                continue
+                            yield frame(event_type, data, event.event_id)
+                    
+                    if events or finished:
+                        yield frame("snapshot", snapshot(), last_id or None)
+                    if finished:
+                        yield frame("done", {"status": eval_data["status"]}, last_id or None)
+                        break
+                    await asyncio.sleep(0.5)
+            
+            return StreamingResponse(progress_source(), media_type="text/event-stream", headers=SSE_HEADERS)
+        
+        @self.app.get("/evaluations/runs/{evaluation_id}/export", tags=["evaluations"])
+        async def export_run(evaluation_id: str, format: str = Query("csv", description=f"One of {list(EXPORT_FORMATS)}")):
+            """Download a run's event log, task records and order timings as a zip of flat tables
+
+            The zip holds events, tasks and orders in CSV or Parquet (which needs
+            pyarrow), each with a fixed set of columns so exports load the same
+            way in pandas whatever the run did.
+            """
+            try:
+                check_format(format)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            known = evaluation_id in self.active_evaluations or await asyncio.to_thread(
+                self.event_store.query, run_id=evaluation_id, limit=1, include_archived=True
+            )
+            if not known:
+                raise HTTPException(404, "Evaluation not found")
+            
+            archive = tempfile.SpooledTemporaryFile(max_size=8 * 1024 * 1024)
+            counts = await asyncio.to_thread(write_run_export, self.event_store, evaluation_id, format, archive)
+            archive.seek(0)
+            
+            def chunks():
+                with archive:
+                    while chunk := archive.read(64 * 1024):
+                        yield chunk
+            
+            return StreamingResponse(chunks(), media_type="application/zip", headers={
+                "Content-Disposition": f'attachment; filename="run-{evaluation_id}-{format}.zip"',
+                "X-Export-Rows": ",".join(f"{table}={n}" for table, n in counts.items())
+            })
+        
+        @self.app.post("/evaluations/compare", tags=["evaluations"])
+        async def compare_models_on_scenario(
+            request: ModelComparisonRequest,
+            background_tasks: BackgroundTasks
+        ):
+            """Run one scenario once per model, each in its own kitchen, and rank the results"""
+            if request.evaluation_id:
+                if request.evaluation_id not in self.active_evaluations:
+                    raise HTTPException(404, "Evaluation not found")
+                config = dict(self.active_evaluations[request.evaluation_id]["config"])
+            else:
+                try:
+                    config = self._with_bundle_defaults(request.scenario or ScenarioExecutionRequest()).dict()
+                except ValueError as e:
+                    raise HTTPException(400, str(e))
+            
+            if config.get("seed") is None:
+                config["seed"] = self.default_seed if self.default_seed is not None else random.randrange(2**31)
+            
+            try:
+                roles = [AgentRole[r] for r in request.roles] if request.roles else None
+            except KeyError as e:
+                raise HTTPException(400, f"Unknown role {e}")
+            try:
+                get_quality_rubric(config.get("quality_rubric", "default"))
+                get_prompt_registry().with_overrides(config.get("prompt_overrides"))
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            
+            comparison_id = str(uuid.uuid4())
+            self.comparisons[comparison_id] = {
+                "comparison_id": comparison_id,
+                "status": "running",
+                "started_at": datetime.now().isoformat(),
+                "config": config,
+                "models": request.models,
+                "runs": [],
+                "ranking": []
+            }
+            background_tasks.add_task(
+                self._run_model_comparison,
+                comparison_id,
+                request.team_size,
+                roles,
+                request.max_parallel
+            )
+            
+            return {
+                "comparison_id": comparison_id,
+                "status": "started",
+                "seed": config["seed"],
+                "message": f"Comparing {len(request.models)} models on one {config['scenario_type']} scenario"
+            }
+        
+        @self.app.get("/evaluations/compare/{comparison_id}", tags=["evaluations"])
+        async def get_model_comparison(comparison_id: str):
+            """Get per-model results and the ranking of a comparison"""
+            if comparison_id not in self.comparisons:
+                raise HTTPException(404, "Comparison not found")
+            return self.comparisons[comparison_id]
+        
+        @self.app.get("/evaluations/trends", tags=["evaluations"])
+        async def get_trends(
+            metric: Optional[List[str]] = Query(None, description=f"Any of {list(TREND_METRICS)}"),
+            group_by: Optional[List[str]] = Query(None, description=f"Any of {list(TREND_GROUPS)}; model by default"),
+            interval: str = Query("day", description=f"One of {list(TREND_INTERVALS)}"),
+            window: int = Query(7, ge=1, le=90, description="Periods in each rolling average"),
+            since: Optional[date] = None,
+            until: Optional[date] = None,
+            model: Optional[str] = None,
+            scenario: Optional[str] = None
+        ):
+            """Recorded runs' metrics over time, a series per group with rolling averages"""
+            runs = await asyncio.to_thread(
+                self.metrics_collector.saved_runs,
+                since.isoformat() if since else None,
+                until.isoformat() if until else None
+            )
+            filters = {name: value for name, value in (("model", model), ("scenario", scenario)) if value}
+            try:
+                return build_trends(runs, metric, group_by, interval, window, filters)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+        
+        @self.app.post("/experiments/prompts", tags=["experiments"])
+        async def start_prompt_experiment(
+            request: PromptExperimentRequest,
+            background_tasks: BackgroundTasks
+        ):
+            """Run one scenario under two prompt variants on the same seeds and test which does better"""
+            coordinator = self.coordinator
+            if len(coordinator.agents) < 2:
+                raise HTTPException(400, "Need at least 2 agents to run scenario")
+            try:
+                config = self._with_bundle_defaults(request.scenario or ScenarioExecutionRequest()).dict()
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            try:
+                get_quality_rubric(config["quality_rubric"])
+                variants = [
+                    PromptVariant(request.name_a, request.variant_a),
+                    PromptVariant(request.name_b, request.variant_b)
+                ]
+                for variant in variants:
+                    get_prompt_registry().with_overrides(variant.overrides)
+                if request.name_a == request.name_b:
+                    raise ValueError("Variants need different names")
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            
+            base_seed = config["seed"]
+            if base_seed is None:
+                base_seed = self.default_seed if self.default_seed is not None else random.randrange(2**31)
+            rng = random.Random(base_seed)
+            seeds = [rng.randrange(2**31) for _ in range(request.runs)]
+            
+            experiment_id = str(uuid.uuid4())
+            self.experiments[experiment_id] = {
+                "experiment_id": experiment_id,
+                "status": "running",
+                "started_at": datetime.now().isoformat(),
+                "config": {**config, "seed": base_seed},
+                "variants": [v.to_dict() for v in variants],
+                "seeds": seeds,
+                "completed_runs": 0,
+                "total_runs": 2 * len(seeds),
+                "report": None
+            }
+            background_tasks.add_task(
+                self._run_prompt_experiment,
+                experiment_id,
+                coordinator,
+                variants,
+                request.primary_metric,
+                request.alpha
+            )
+            
+            return {
+                "experiment_id": experiment_id,
+                "status": "started",
+                "seed": base_seed,
+                "message": f"Running {request.name_a} and {request.name_b} on {len(seeds)} seeds each"
+            }
+        
+        @self.app.get("/experiments/prompts/{experiment_id}", tags=["experiments"])
+        async def get_prompt_experiment(experiment_id: str):
+            """Progress of a prompt experiment, and its report once finished"""
+            if experiment_id not in self.experiments:
+                raise HTTPException(404, "Experiment not found")
+            return self.experiments[experiment_id]
+        
+        @self.app.get("/scenarios/{evaluation_id}/usage", tags=["scenarios"])
+        async def get_scenario_usage(evaluation_id: str):
+            """LLM token usage, latency and estimated cost of a run, by agent and model"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            usage = get_usage_tracker().for_run(evaluation_id)
+            return usage or {"run_id": evaluation_id, "total": None, "by_agent": {}, "by_model": {}}
+        
+        @self.app.get("/tutorial", tags=["tutorial"])
+        async def get_tutorial():
+            """Tutorial steps with completion state for the current server"""
+            return tutorial_progress(len(self.coordinator.agents), self.active_evaluations)
+        
+        @self.app.get("/scenarios/{evaluation_id}/hints", tags=["tutorial"])
+        async def get_scenario_hints(evaluation_id: str, after_id: int = 0):
+            """Plain-language commentary on a run, driven by its events"""
+            if evaluation_id not in self.active_evaluations:
+                raise HTTPException(404, "Evaluation not found")
+            
+            events = self.event_store.query(run_id=evaluation_id, after_id=after_id, include_archived=True)
+            return {
+                "evaluation_id": evaluation_id,
+                "status": self.active_evaluations[evaluation_id]["status"],
+                "hints": hints_for_events(events)
+            }
+        
+        @self.app.get("/scenarios/{evaluation_id}/replay", tags=["events"])
+        async def replay_scenario(evaluation_id: str, at: Optional[float] = None):
+            """Reconstruct run state as of a timestamp (defaults to the end of the run)"""
+            state = self.event_store.replay(evaluation_id, until=at)
+            if not state["events_applied"]:
+                raise HTTPException(404, "No events recorded for this evaluation")
+            return state
+        
+        @self.app.get("/events", tags=["events"])
+        async def list_events(
+            run_id: Optional[str] = None,
+            after_id: int = 0,
+            agent_name: Optional[str] = None,
+            event_type: Optional[str] = None,
+            limit: int = 500,
+            include_archived: bool = False
+        ):
+            """Query the event log in append order; archived runs only when asked for"""
+            events = self.event_store.query(
+                run_id=run_id,
+                after_id=after_id,
+                agent_name=agent_name,
+                event_type=event_type,
+                limit=limit,
+                include_archived=include_archived
+            )
+            return {
+                "count": len(events),
+                "events": [e.to_dict() for e in events]
+            }
+        
+        @self.app.get("/transcripts", tags=["transcripts"])
+        async def list_transcripts(
+            run_id: Optional[str] = None,
+            agent_name: Optional[str] = None,
+            task_id: Optional[str] = None,
+            contains: Optional[str] = None,
+            after_id: int = 0,
+            limit: int = Query(100, ge=1, le=1000)
+        ):
+            """Query recorded agent prompts and responses"""
+            transcripts = self.transcripts.query(
+                run_id=run_id,
+                agent_name=agent_name,
+                task_id=task_id,
+                contains=contains,
+                after_id=after_id,
+                limit=limit
+            )
+            return {"count": len(transcripts), "transcripts": transcripts}
+        
+        @self.app.get("/transcripts/{transcript_id}", tags=["transcripts"])
+        async def get_transcript(transcript_id: int):
+            """Get one recorded prompt/response pair"""
+            transcript = self.transcripts.get(transcript_id)
+            if transcript is None:
+                raise HTTPException(404, "Transcript not found")
+            return transcript
+        
+        @self.app.get("/events/stream", tags=["events"])
+        async def stream_events(run_id: Optional[str] = None, after_id: int = 0):
+            """Stream events as Server-Sent Events until the run finishes"""
+            async def event_source():
+                last_id = after_id
+                while True:
+                    for event in self.event_store.query(run_id=run_id, after_id=last_id):
+                        last_id = event.event_id
+                        if self.faults.should_drop_frame("/events/stream"):
+                            continue
+                        yield f"id: {event.event_id}\nevent: {event.event_type}\ndata: {json.dumps(event.to_dict())}\n\n"
+                    
+                    evaluation = self.active_evaluations.get(run_id) if run_id else None
+                    if evaluation and evaluation["status"] != "running":
+                        break
+                    await asyncio.sleep(0.5)
+            
+            return StreamingResponse(event_source(), media_type="text/event-stream", headers=SSE_HEADERS)
+        
+        @self.app.get("/equipment", tags=["equipment"])
+        async def get_equipment():
+            """Get simulated equipment status for the current run"""
+            if self.coordinator.equipment is None:
+                raise HTTPException(404, "Equipment simulation is not active")
+            return self.coordinator.equipment.to_dict()
+        
+        @self.app.get("/equipment/{name}/temperature", tags=["equipment"])
+        async def get_equipment_temperature(name: str):
+            """Get the current reading and recent history of an item's temperature sensor"""
+            equipment = self.coordinator.equipment
+            if equipment is None:
+                raise HTTPException(404, "Equipment simulation is not active")
+            if name not in equipment.items:
+                raise HTTPException(404, f"Unknown equipment '{name}'")
+            reading = equipment.temperature.reading(name)
+            if reading is None:
+                raise HTTPException(404, f"{name} has no temperature sensor")
+            return reading
+        
+        @self.app.post("/equipment/{name}/break", tags=["equipment"])
+        async def break_equipment(name: str, x_admin_token: Optional[str] = Header(None)):
+            """Force a breakdown so agents have to adapt; a repair is dispatched after the next task"""
+            _check_admin(x_admin_token)
+            equipment = self.coordinator.equipment
+            if equipment is None:
+                raise HTTPException(404, "Equipment simulation is not active")
+            if name not in equipment.items:
+                raise HTTPException(404, f"Unknown equipment '{name}'")
+            
+            change = equipment.break_item(name)
+            self.coordinator.record_event(change.event_type, equipment=name, simulated_time=change.time, **change.details)
+            return equipment.items[name].to_dict()
+        
+        @self.app.get("/kitchen/snapshot", tags=["kitchen"])
+        async def get_kitchen_snapshot():
+            """Orders with their items, stations with their load, staff, inventory and alerts of the latest run
+
+            Read from a model the event log keeps current, so every part is as of the same last_event_id.
+            """
+            return self.kitchen_view.snapshot()
+        
+        @self.app.get("/kitchen/stations", tags=["kitchen"])
+        async def get_stations():
+            """Load, staff, active orders and equipment state of every station"""
+            coordinator = self.coordinator
+            stations = coordinator.station_status()
+            for station in stations:
+                del station["orders"]
+            return {
+                "run_id": coordinator.run_id if coordinator.running else None,
+                "equipment_simulated": coordinator.equipment is not None,
+                "stations": stations
+            }
+        
+        @self.app.get("/kitchen/stations/{name}", tags=["kitchen"])
+        async def get_station(name: str):
+            """One station with the orders currently queued for or being worked on it"""
+            coordinator = self.coordinator
+            station = next((s for s in coordinator.station_status() if s["name"] == name), None)
+            if station is None:
+                raise HTTPException(404, f"Unknown station '{name}'")
+            return {"run_id": coordinator.run_id if coordinator.running else None, **station}
+        
+        @self.app.get("/menu", tags=["recipes"])
+        async def get_menu(
+            cuisine: Optional[str] = None,
+            available: Optional[bool] = Query(None, description="Only items that can be ordered (true) or are 86'd (false)"),
+            specialty: Optional[bool] = Query(None, description="Only house specialties (true) or the rest (false)"),
+            seasonal: Optional[bool] = Query(None, description="Only seasonal items (true) or the rest (false)"),
+            limit: int = Query(DEFAULT_LIMIT, ge=1, le=MAX_LIMIT),
+            offset: int = Query(0, ge=0)
+        ):
+            """The menu: the planned items, or every loaded recipe until one is planned, with whether each can be made now"""
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id) if coordinator.running else None
+            on_hand = self._ingredients_on_hand(eval_data)
+            items = []
+            for recipe in self.menu.recipes():
+                if cuisine is not None and recipe['cuisine'].lower() != cuisine.lower():
+                    continue
+                entry = self._menu_entry(recipe, on_hand, coordinator.spoiled)
+                if available is not None and available != entry["available"]:
+                    continue
+                if specialty is not None and specialty != entry["specialty"]:
+                    continue
+                if seasonal is not None and seasonal != entry["seasonal"]:
+                    continue
+                items.append(entry)
+            page, pagination = paginate(items, limit, offset)
+            return {"planned": bool(self.menu.planned), "items": page, **pagination}
+        
+        @self.app.put("/menu", tags=["recipes"])
+        async def plan_menu(request: MenuPlanRequest):
+            """Plan the menu: only the items given can be ordered, until it's planned again or cleared
+
+            Menu planning tasks in a run write the menu they choose the same way.
+            """
+            try:
+                planned = self.menu.plan([MenuItem(**item.dict()) for item in request.items])
+            except KeyError as e:
+                raise HTTPException(404, e.args[0])
+            return {"planned": True, "count": len(planned), "items": [item.to_dict() for item in planned]}
+        
+        @self.app.delete("/menu", tags=["recipes"])
+        async def clear_menu():
+            """Drop the planned menu, putting every loaded recipe back on"""
+            if not self.menu.clear():
+                raise HTTPException(409, "No menu has been planned")
+            return {"planned": False, "count": len(self.menu.recipes())}
+        
+        @self.app.get("/menu/specialties", tags=["recipes"])
+        async def get_specialties():
+            """The house specialties on the menu, with whether each can be made now"""
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id) if coordinator.running else None
+            on_hand = self._ingredients_on_hand(eval_data)
+            items = [self._menu_entry(recipe, on_hand, coordinator.spoiled) for recipe in self.menu.specialties()]
+            return {"count": len(items), "items": items}
+        
+        @self.app.patch("/menu/items/{recipe_id}", tags=["recipes"])
+        async def update_menu_item(recipe_id: int, request: MenuItemUpdateRequest):
+            """Mark an item on the menu as a specialty or seasonal, or not, or change its price"""
+            try:
+                item = self.menu.update(recipe_id, request.specialty, request.seasonal, price=request.price)
+            except KeyError as e:
+                raise HTTPException(404, e.args[0])
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id) if coordinator.running else None
+            return self._menu_entry(self.menu.recipe(item.recipe_id), self._ingredients_on_hand(eval_data), coordinator.spoiled)
+        
+        @self.app.get("/menu/86", tags=["recipes"])
+        async def get_eighty_six_list():
+            """Menu items that can't be ordered now: out of stock with no substitute, spoiled, or 86'd by hand"""
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id) if coordinator.running else None
+            items = self.menu.eighty_six_list(self._ingredients_on_hand(eval_data), coordinator.spoiled)
+            return {"count": len(items), "items": [item.to_dict() for item in items]}
+        
+        @self.app.put("/menu/items/{recipe_id}/86", tags=["recipes"])
+        async def eighty_six_item(recipe_id: int, request: EightySixRequest):
+            """86 a menu item by hand; orders for it are refused, and the executing run's team is told"""
+            try:
+                recipe = self.menu.eighty_six(recipe_id, request.reason)
+            except KeyError as e:
+                raise HTTPException(404, e.args[0])
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id) if coordinator.running else None
+            item = self.menu.check(recipe, self._ingredients_on_hand(eval_data), coordinator.spoiled)
+            return {**item.to_dict(), "announced": bool(self._announce_86(coordinator, [item]))}
+        
+        @self.app.delete("/menu/items/{recipe_id}/86", tags=["recipes"])
+        async def restore_item(recipe_id: int):
+            """Put a menu item 86'd by hand back on; it can still be 86'd for stock"""
+            recipe = self.menu.recipe(recipe_id)
+            if recipe is None:
+                raise HTTPException(404, f"Recipe {recipe_id} is not on the menu")
+            if not self.menu.restore(recipe_id):
+                raise HTTPException(409, f"{dish_name(recipe)} was not 86'd by hand")
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id) if coordinator.running else None
+            item = self.menu.check(recipe, self._ingredients_on_hand(eval_data), coordinator.spoiled)
+            if item is None and coordinator.running:
+                coordinator.announce_restored(recipe_id, dish_name(recipe))
+            return {
+                "recipe_id": recipe_id,
+                "dish": dish_name(recipe),
+                "available": item is None,
+                "eighty_sixed": item.reasons if item else []
+            }
+        
+        @self.app.post("/orders", tags=["scenarios"])
+        async def submit_order(request: OrderSubmissionRequest, dry_run: bool = False):
+            """Check an order against the menu, inventory, equipment and staff, and queue it in the executing run
+
+            An order the kitchen can cook is still refused with 429 while the
+            admission policy says the kitchen is overloaded; the details say
+            why, with a wait_estimate also sent as Retry-After. An order given
+            a priority is moved up once queued, as PATCH /orders/{task_id}
+            would. With dry_run the plan and the admission decision are
+            returned and nothing is changed, whether or not a run is executing.
+            """
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id)
+            running = coordinator.running and eval_data is not None and eval_data["status"] in ("running", "paused")
+            
+            plan = self._plan_order(coordinator, request, eval_data if running else None)
+            decision = admit(self.admission, coordinator, plan)
+            if running:
+                for item in plan.items:
+                    if item.eighty_sixed:
+                        coordinator.announce_86(item.recipe_id, item.dish, item.eighty_sixed)
+            if dry_run:
+                return {"dry_run": True, "committed": False, "evaluation_id": coordinator.run_id if running else None,
+                        **plan.to_dict(), "priority": request.priority, "admission": decision.to_dict()}
+            
+            if not running:
+                raise HTTPException(409, "No run is executing")
+            if not plan.feasible:
+                raise HTTPException(422, plan.to_dict())
+            if not decision.admitted:
+                coordinator.throttle_order(
+                    len(plan.tasks()), decision.reasons, decision.wait_estimate, table=request.table
+                )
+                self._refuse(decision)
+            try:
+                task_ids = self._place_order(coordinator, plan, request)
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            return {"dry_run": False, "committed": True, "evaluation_id": coordinator.run_id, "task_ids": task_ids,
+                    **plan.to_dict(), "priority": request.priority}
+        
+        @self.app.post("/orders/bulk", tags=["scenarios"])
+        async def submit_orders_bulk(request: Request, dry_run: bool = False):
+            """Schedule many orders to arrive in the executing run, each at its arrive_at
+
+            The body is a JSON array of orders, or one order per line with
+            Content-Type application/x-ndjson. Each order is POST /orders's body
+            plus arrive_at, in seconds into the run (not counting time paused).
+            On arrival an order is planned against the kitchen as it is then and
+            queued if it can be cooked, as POST /orders would; otherwise it is
+            rejected, or throttled if admission refuses it. The submission
+            itself is refused with 429 while the kitchen is already full. With
+            dry_run every order is planned now and nothing is scheduled.
+            """
+            body = await request.body()
+            try:
+                if "ndjson" in request.headers.get("content-type", ""):
+                    raw = [json.loads(line) for line in body.decode().splitlines() if line.strip()]
+                else:
+                    raw = json.loads(body or b"null")
+            except ValueError as e:
+                raise HTTPException(400, f"Invalid JSON: {e}")
+            if not isinstance(raw, list) or not raw:
+                raise HTTPException(422, "Expected a non-empty array of orders, or one order per line")
+            if len(raw) > MAX_BULK_ORDERS:
+                raise HTTPException(422, f"At most {MAX_BULK_ORDERS} orders at a time, got {len(raw)}")
+            orders, errors = [], []
+            for index, order in enumerate(raw):
+                if not isinstance(order, dict):
+                    errors.append({"index": index, "field": None, "error": "Expected an object"})
+                    continue
+                try:
+                    orders.append(ScheduledOrderRequest(**order))
+                except ValidationError as e:
+                    errors.extend(
+                        {"index": index, "field": ".".join(map(str, err["loc"])), "error": err["msg"]}
+                        for err in e.errors()
+                    )
+            if errors:
+                raise HTTPException(422, {"errors": errors})
+            
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id)
+            running = coordinator.running and eval_data is not None and eval_data["status"] in ("running", "paused")
+            if dry_run:
+                plans = [self._plan_order(coordinator, order, eval_data if running else None) for order in orders]
+                return {
+                    "dry_run": True,
+                    "evaluation_id": coordinator.run_id if running else None,
+                    "orders": [
+                        {"index": i, "arrive_at": order.arrive_at, **plan.to_dict(),
+                         "admission": admit(self.admission, coordinator, plan).to_dict()}
+                        for i, (order, plan) in enumerate(zip(orders, plans))
+                    ]
+                }
+            if not running:
+                raise HTTPException(409, "No run is executing")
+            # Each order is checked again as it arrives; a kitchen already full takes no schedule at all
+            decision = admit(self.admission, coordinator, self._kitchen_plan(coordinator, eval_data))
+            if not decision.admitted:
+                coordinator.throttle_order(
+                    sum(item.quantity for order in orders for item in order.items), decision.reasons,
+                    decision.wait_estimate, orders=len(orders)
+                )
+                self._refuse(decision)
+            
+            return self._batch_summary(self._schedule_orders(coordinator, eval_data, orders))
+        
+        @self.app.get("/orders/bulk/{batch_id}", tags=["scenarios"])
+        async def get_order_batch(batch_id: str):
+            """Where each order of a bulk submission to the current run stands"""
+            eval_data = self.active_evaluations.get(self.coordinator.run_id) or {}
+            batch = eval_data.get("order_batches", {}).get(batch_id)
+            if batch is None:
+                raise HTTPException(404, f"Batch {batch_id} not found in the current run")
+            return self._batch_summary(batch)
+        
+        @self.app.patch("/orders/{task_id}", tags=["scenarios"])
+        async def modify_order(task_id: str, request: OrderModificationRequest):
+            """Change the ticket a task of the executing run belongs to
+
+            Added items and extra portions are planned against the kitchen as it is
+            now, like a new order, and refused with 422 if they can't be cooked.
+            Removed lines, fewer portions and voids cancel portions as DELETE does.
+            """
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id)
+            if not coordinator.running or eval_data is None or eval_data["status"] not in ("running", "paused"):
+                raise HTTPException(409, "No run is executing")
+            if not (request.add_items or request.remove_items or request.quantities or request.void
+                    or request.note or request.priority):
+                raise HTTPException(422, "Nothing to change")
+            try:
+                ticket = coordinator.order_ticket(task_id)
+            except KeyError:
+                raise HTTPException(404, f"Order {task_id} not found in the run")
+            
+            lines = {line["line"]: line for line in ticket["lines"]}
+            unknown = sorted((set(request.quantities) | set(request.remove_items)) - set(lines))
+            if unknown:
+                raise HTTPException(422, f"Ticket {ticket['ticket']} has no line {', '.join(map(str, unknown))}")
+            if any(quantity < 0 for quantity in request.quantities.values()):
+                raise HTTPException(422, "Quantities can't be negative")
+            if set(request.quantities) & set(request.remove_items):
+                raise HTTPException(422, "A line can't be both removed and given a quantity")
+            
+            # Removing a line takes off whatever of it is still to cook
+            reduce = {}
+            for line in request.remove_items:
+                still_open = sum(1 for p in lines[line]["portions"] if p["state"] in ("queued", "held", "in_progress"))
+                if not still_open:
+                    raise HTTPException(409, f"Line {line} of ticket {ticket['ticket']} has nothing left to cook")
+                reduce[line] = still_open
+            
+            # New lines, then extra portions of existing ones, planned together
+            next_line = max(lines) + 1
+            items = [OrderItem(**item.dict()) for item in request.add_items]
+            targets = [next_line + i for i in range(len(items))]
+            for line, quantity in sorted(request.quantities.items()):
+                entry = lines[line]
+                if quantity > entry["quantity"]:
+                    items.append(OrderItem(
+                        task_type=entry["task_type"],
+                        recipe_id=entry["recipe_id"],
+                        dish=entry["dish"],
+                        ingredients=entry["ingredients"],
+                        quantity=quantity - entry["quantity"],
+                        course=entry["course"]
+                    ))
+                    targets.append(line)
+                elif quantity < entry["quantity"]:
+                    reduce[line] = entry["quantity"] - quantity
+            
+            plan = None
+            add = []
+            if items:
+                plan = self._order_planner(coordinator).plan(
+                    items,
+                    self._ingredients_on_hand(eval_data),
+                    ticket["table"],
+                    ticket["dietary_restrictions"],
+                    ticket["time_limit"] or DEFAULT_TIME_LIMIT
+                )
+                if not plan.feasible:
+                    raise HTTPException(422, plan.to_dict())
+                add = plan.tasks()
+                for _, context in add:
+                    context['line'] = targets[context['line']]
+            
+            try:
+                result = coordinator.modify_order(
+                    task_id,
+                    add=add,
+                    reduce=reduce,
+                    void=request.void,
+                    note=request.note,
+                    priority=request.priority,
+                    reason=request.reason,
+                    author=request.author
+                )
+            except KeyError:
+                raise HTTPException(404, f"Order {task_id} not found in the run")
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+            return {"evaluation_id": coordinator.run_id, **result, "plan": plan.to_dict() if plan else None}
+        
+        @self.app.delete("/orders/{task_id}", tags=["scenarios"])
+        async def cancel_order(task_id: str, reason: str = "cancelled"):
+            """Cancel a task in the executing run; one already being worked on stops at its next safe point"""
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id)
+            if not coordinator.running or eval_data is None or eval_data["status"] not in ("running", "paused"):
+                raise HTTPException(409, "No run is executing")
+            try:
+                return {"evaluation_id": coordinator.run_id, **coordinator.cancel_order(task_id, reason)}
+            except KeyError:
+                raise HTTPException(404, f"Task {task_id} not found in the run")
+            except ValueError as e:
+                raise HTTPException(409, str(e))
+        
+        @self.app.post("/orders/{task_id}/notes", tags=["scenarios"])
+        async def annotate_order(task_id: str, request: OrderNoteRequest):
+            """Note a problem with an order in the executing run; notes weigh on the run's quality by severity"""
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id)
+            if not coordinator.running or eval_data is None or eval_data["status"] not in ("running", "paused"):
+                raise HTTPException(409, "No run is executing")
+            try:
+                note = coordinator.annotate_order(task_id, request.author, request.reason, request.severity)
+            except KeyError:
+                raise HTTPException(404, f"Task {task_id} not found in the run")
+            return {"evaluation_id": coordinator.run_id, **note}
+        
+        @self.app.get("/orders/holds", tags=["scenarios"])
+        async def get_holds():
+            """Courses the pass is holding in the current run, the calls made and held portions fired anyway"""
+            return {"evaluation_id": self.coordinator.run_id, **self.coordinator.holds.summary()}
+        
+        @self.app.post("/orders/hold", tags=["scenarios"])
+        async def hold_course(request: CourseCallRequest):
+            """Hold a course in the executing run, at one table or all of them ("hold desserts")
+
+            Its portions still to cook wait until it's fired. Cooks are told, and
+            one who fires a held portion anyway counts against coordination.
+            """
+            return self._call_course("hold", request)
+        
+        @self.app.post("/orders/fire", tags=["scenarios"])
+        async def fire_course(request: CourseCallRequest):
+            """Fire a held course in the executing run, at one table ("fire mains for table 7") or all of them"""
+            return self._call_course("fire", request)
+        
+        @self.app.get("/escalation", tags=["escalation"])
+        async def get_escalation():
+            """Escalation thresholds and the tasks escalated in the current run"""
+            return {"run_id": self.coordinator.run_id, **self.coordinator.escalation.summary()}
+        
+        @self.app.put("/escalation", tags=["escalation"])
+        async def configure_escalation(request: EscalationConfigRequest):
+            """Adjust escalation thresholds, keeping unspecified ones; takes effect at the next scan"""
+            current = self.coordinator.escalation.thresholds.to_dict()
+            current.update({k: v for k, v in request.dict().items() if v is not None})
+            thresholds = EscalationThresholds(**current)
+            try:
+                thresholds.validate()
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            self.coordinator.escalation.thresholds = thresholds
+            return thresholds.to_dict()
+        
+        @self.app.get("/admission", tags=["scenarios"])
+        async def get_admission():
+            """The admission policy, the kitchen's current decision for a new order, and the run's throttled orders"""
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id) if coordinator.running else None
+            return {
+                "run_id": coordinator.run_id,
+                "policy": self.admission.to_dict(),
+                "decision": admit(self.admission, coordinator, self._kitchen_plan(coordinator, eval_data)).to_dict(),
+                "throttled": list(coordinator.throttled_orders)
+            }
+        
+        @self.app.put("/admission", tags=["scenarios"])
+        async def configure_admission(request: AdmissionConfigRequest):
+            """Adjust the admission policy, keeping unspecified limits; applies to the next order"""
+            current = self.admission.to_dict()
+            current.update({k: v for k, v in request.dict().items() if v is not None})
+            policy = AdmissionPolicy(**current)
+            try:
+                policy.validate()
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            self.admission = policy
+            return policy.to_dict()
+        
+        @self.app.get("/handoffs", tags=["handoffs"])
+        async def get_handoffs():
+            """Hand-off settings, and how the current run's delegated tasks were accepted or rejected"""
+            return {"run_id": self.coordinator.run_id, **self.coordinator.handoff.summary()}
+        
+        @self.app.put("/handoffs", tags=["handoffs"])
+        async def configure_handoffs(request: HandoffConfigRequest):
+            """Adjust when stations reject delegated work, keeping unspecified settings; applies to the next hand-off"""
+            current = self.coordinator.handoff.settings.to_dict()
+            current.update({k: v for k, v in request.dict().items() if v is not None})
+            settings = HandoffSettings(**current)
+            try:
+                settings.validate()
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            self.coordinator.handoff.settings = settings
+            return settings.to_dict()
+        
+        @self.app.get("/chaos", tags=["chaos"])
+        async def get_chaos():
+            """List chaos actions and the disruptions injected into the current run"""
+            return {
+                "actions": CHAOS_ACTIONS,
+                "run_id": self.coordinator.run_id,
+                "injections": [c.to_dict() for c in self.coordinator.chaos]
+            }
+        
+        @self.app.post("/chaos", tags=["chaos"])
+        async def inject_chaos(request: ChaosRequest, x_admin_token: Optional[str] = Header(None)):
+            """Disrupt the executing run before its next task; recorded as a chaos_injected event (admin)"""
+            _check_admin(x_admin_token)
+            coordinator = self.coordinator
+            eval_data = self.active_evaluations.get(coordinator.run_id)
+            if not coordinator.running or eval_data is None or eval_data["status"] not in ("running", "paused"):
+                raise HTTPException(409, "No run is executing")
+            
+            if request.action == "kill_agent":
+                if not request.agent:
+                    raise HTTPException(400, "kill_agent needs an agent")
+                params = {"agent": request.agent}
+            elif request.action == "break_equipment":
+                if not request.equipment:
+                    raise HTTPException(400, "break_equipment needs an equipment item")
+                params = {"equipment": request.equipment}
+            elif request.action == "spike_orders":
+                config = eval_data["config"]
+                params = {"tasks": self._generate_scenario_tasks(
+                    config.get("scenario_type", "standard"),
+                    request.count,
+                    config.get("use_dataset", True),
+                    config.get("dietary_restrictions")
+                )}
+            elif request.action == "spoil_inventory":
+                if not request.ingredients:
+                    raise HTTPException(400, "spoil_inventory needs ingredients")
+                params = {"ingredients": request.ingredients}
+            else:
+                params = {"seconds": request.delay_seconds, "tasks": request.tasks}
+            
+            try:
+                injection = coordinator.inject_chaos(request.action, **params)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            if request.action == "spoil_inventory":
+                # Whatever the spoiled stock took off the menu goes on the 86-list
+                self._announce_86(coordinator, [
+                    item for item in self.menu.eighty_six_list(self._ingredients_on_hand(eval_data), coordinator.spoiled)
+                    if item.spoiled
+                ])
+            return {"evaluation_id": coordinator.run_id, **injection.to_dict()}
+        
+        @self.app.get("/metrics/charts", tags=["metrics"])
+        async def generate_charts():
+            """Generate visualization charts"""
             chart_files = self.metrics_collector.generate_charts()
             
             if not chart_files:
@@ -284,7 +2629,7 @@ class ChefBenchAPI:
                 "files": [str(f) for f in chart_files]
             }
         
-        @self.app.get("/metrics/report")
+        @self.app.get("/metrics/report", tags=["metrics"])
         async def generate_report():
             """Generate comprehensive report"""
             report_file = self.metrics_collector.generate_report()
@@ -295,7 +2640,7 @@ class ChefBenchAPI:
                 filename=report_file.name
             )
         
-        @self.app.get("/metrics/export")
+        @self.app.get("/metrics/export", tags=["metrics"])
         async def export_metrics():
             """Export metrics to CSV"""
             csv_files = self.metrics_collector.export_to_csv()
@@ -305,7 +2650,74 @@ class ChefBenchAPI:
                 "files": [str(f) for f in csv_files]
             }
         
-        @self.app.post("/metrics/compare_models")
+        @self.app.get("/metrics/scoring", tags=["metrics"])
+        async def get_scoring():
+            """Every scoring profile with its weights, thresholds and formula, and the profile each scenario uses"""
+            config = get_scoring_config()
+            return {
+                "profiles": [p.to_dict() for p in SCORING_PROFILES.values()],
+                "scenarios": config.scenarios,
+                "source": config.source
+            }
+        
+        @self.app.put("/admin/scoring", tags=["admin"])
+        async def set_scoring(request: ScoringConfigRequest, x_admin_token: Optional[str] = Header(None)):
+            """Replace the custom profiles and scenario defaults until restart; runs already scored keep theirs"""
+            _check_admin(x_admin_token)
+            try:
+                config = ScoringConfig.from_dict(request.dict(), "api")
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            set_scoring_config(config)
+            return config.to_dict()
+        
+        @self.app.get("/metrics/leaderboard", tags=["metrics"])
+        async def get_leaderboard(profile: str = "balanced", limit: Optional[int] = Query(None, ge=1)):
+            """Rank recorded runs by their score under one scoring profile"""
+            if profile not in SCORING_PROFILES:
+                raise HTTPException(400, f"Unknown scoring profile '{profile}'")
+            
+            return {
+                "profile": SCORING_PROFILES[profile].to_dict(),
+                "runs": self.metrics_collector.leaderboard(profile, limit)
+            }
+        
+        @self.app.post("/reports/eod", tags=["metrics"])
+        async def generate_daily_report(request: DailyReportRequest):
+            """Roll a day's recorded runs up into an end-of-day report and save it"""
+            day = request.day or date.today()
+            runs = await asyncio.to_thread(self.metrics_collector.runs_on, day.isoformat())
+            report = build_daily_report(runs, day, self.ingredient_catalog)
+            await asyncio.to_thread(self.daily_reports.save, report)
+            return report
+        
+        @self.app.get("/reports/eod", tags=["metrics"])
+        async def list_daily_reports():
+            """Saved end-of-day reports, newest day first"""
+            return self.daily_reports.list()
+        
+        @self.app.get("/reports/eod/{day}", tags=["metrics"])
+        async def get_daily_report(day: date):
+            """A saved end-of-day report"""
+            report = self.daily_reports.load(day.isoformat())
+            if report is None:
+                raise HTTPException(404, f"No end-of-day report for {day}")
+            return report
+        
+        @self.app.get("/reports/menu-engineering", tags=["metrics"])
+        async def get_menu_engineering(
+            days: Optional[int] = Query(None, ge=1, description="Only sales from runs of the last this many days")
+        ):
+            """Menu items classified as stars, plowhorses, puzzles and dogs by what they sold and their margin
+
+            Sales are the portions served in every recorded run, each costing its
+            ingredients at the catalog's cost per unit. Menu planning tasks are
+            shown the class of each recipe they can choose.
+            """
+            since = time.time() - days * 86400 if days else None
+            return await asyncio.to_thread(self._menu_engineering, since)
+        
+        @self.app.post("/metrics/compare_models", tags=["metrics"])
         async def compare_models(model_groups: Dict[str, List[str]]):
             """Compare performance across models"""
             comparison = self.metrics_collector.analyze_model_comparison(model_groups)
@@ -315,33 +2727,280 @@ class ChefBenchAPI:
                 "comparison": comparison
             }
         
-        @self.app.delete("/reset")
+        def _log_buffer(level: Optional[str]):
+            buffer = get_log_buffer()
+            if buffer is None:
+                raise HTTPException(503, "Structured logging is not configured")
+            if level and level.upper() not in ("DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL"):
+                raise HTTPException(400, f"Unknown log level '{level}'")
+            return buffer
+        
+        @self.app.get("/logs", tags=["logs"])
+        async def list_logs(
+            run_id: Optional[str] = None,
+            agent_name: Optional[str] = None,
+            task_id: Optional[str] = None,
+            level: Optional[str] = None,
+            after_id: int = 0,
+            limit: int = 500
+        ):
+            """Get recent structured log entries"""
+            buffer = _log_buffer(level)
+            
+            entries = buffer.query(after_id, run_id, agent_name, task_id, level, limit)
+            return {"count": len(entries), "logs": entries}
+        
+        @self.app.get("/logs/stream", tags=["logs"])
+        async def stream_logs(
+            run_id: Optional[str] = None,
+            agent_name: Optional[str] = None,
+            task_id: Optional[str] = None,
+            level: Optional[str] = None,
+            after_id: int = 0
+        ):
+            """Stream log entries as Server-Sent Events, filterable by run or agent"""
+            buffer = _log_buffer(level)
+            
+            async def log_source():
+                last_id = after_id
+                while True:
+                    for entry in buffer.query(last_id, run_id, agent_name, task_id, level):
+                        last_id = entry["log_id"]
+                        yield f"id: {last_id}\nevent: log\ndata: {json.dumps(entry, default=str)}\n\n"
+                    await asyncio.sleep(0.5)
+            
+            return StreamingResponse(log_source(), media_type="text/event-stream", headers=SSE_HEADERS)
+        
+        def _check_admin(token: Optional[str]):
+            # Admin routes can fail requests on purpose and make the server call out, so without a token they're off
+            if not self.admin_token:
+                raise HTTPException(403, "Admin routes are disabled; set CHEFBENCH_ADMIN_TOKEN to enable them")
+            if token is None or not hmac.compare_digest(token.encode(), self.admin_token.encode()):
+                raise HTTPException(403, "Invalid admin token")
+        
+        @self.app.get("/admin/faults", tags=["admin"])
+        async def get_faults(x_admin_token: Optional[str] = Header(None)):
+            """Get active fault injection settings and counters"""
+            _check_admin(x_admin_token)
+            return self.faults.to_dict()
+        
+        @self.app.put("/admin/faults", tags=["admin"])
+        async def configure_faults(request: FaultConfigRequest, x_admin_token: Optional[str] = Header(None)):
+            """Enable or adjust injected latency, errors and dropped stream events"""
+            _check_admin(x_admin_token)
+            self.faults.configure(**request.dict())
+            return self.faults.to_dict()
+        
+        @self.app.delete("/admin/faults", tags=["admin"])
+        async def clear_faults(x_admin_token: Optional[str] = Header(None)):
+            """Disable all injected faults"""
+            _check_admin(x_admin_token)
+            self.faults.clear()
+            return self.faults.to_dict()
+        
+        @self.app.get("/admin/events/archive", tags=["admin"])
+        async def get_event_archive(x_admin_token: Optional[str] = Header(None)):
+            """Event counts in the hot table and the archive, and the retention window"""
+            _check_admin(x_admin_token)
+            return {"retention_days": self.event_retention_days, **self.event_store.counts()}
+        
+        @self.app.post("/admin/events/archive", tags=["admin"])
+        async def archive_events(
+            older_than_days: Optional[float] = Query(None, ge=0),
+            x_admin_token: Optional[str] = Header(None)
+        ):
+            """Archive finished runs now, optionally with a shorter window than the configured retention"""
+            _check_admin(x_admin_token)
+            days = self.event_retention_days if older_than_days is None else older_than_days
+            archived = await asyncio.to_thread(self.event_store.archive, time.time() - days * 86400)
+            return {"older_than_days": days, "archived": archived, **self.event_store.counts()}
+        
+        @self.app.get("/admin/webhooks", tags=["admin"])
+        async def list_webhooks(x_admin_token: Optional[str] = Header(None)):
+            """Registered webhooks, without their secrets"""
+            _check_admin(x_admin_token)
+            return {
+                "events": list(WEBHOOK_EVENTS),
+                "webhooks": [w.to_dict() for w in self.webhooks.webhooks.values()]
+            }
+        
+        @self.app.post("/admin/webhooks", tags=["admin"])
+        async def add_webhook(request: WebhookRequest, x_admin_token: Optional[str] = Header(None)):
+            """Register a URL for signed notifications of every session's runs; the secret is only returned here"""
+            _check_admin(x_admin_token)
+            try:
+                webhook = self.webhooks.add(request.url, request.secret, request.events, request.min_quality)
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            return webhook.to_dict(include_secret=True)
+        
+        @self.app.put("/admin/webhooks/{webhook_id}", tags=["admin"])
+        async def update_webhook(
+            webhook_id: str,
+            request: WebhookUpdateRequest,
+            x_admin_token: Optional[str] = Header(None)
+        ):
+            """Change a webhook, keeping unspecified settings"""
+            _check_admin(x_admin_token)
+            try:
+                webhook = self.webhooks.update(webhook_id, **{k: v for k, v in request.dict().items() if v is not None})
+            except KeyError:
+                raise HTTPException(404, f"Webhook {webhook_id} not found")
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            return webhook.to_dict()
+        
+        @self.app.delete("/admin/webhooks/{webhook_id}", tags=["admin"])
+        async def remove_webhook(webhook_id: str, x_admin_token: Optional[str] = Header(None)):
+            """Stop notifying a webhook"""
+            _check_admin(x_admin_token)
+            try:
+                self.webhooks.remove(webhook_id)
+            except KeyError:
+                raise HTTPException(404, f"Webhook {webhook_id} not found")
+            return {"status": "removed", "webhook_id": webhook_id}
+        
+        @self.app.get("/admin/webhooks/{webhook_id}/deliveries", tags=["admin"])
+        async def list_webhook_deliveries(webhook_id: str, x_admin_token: Optional[str] = Header(None)):
+            """A webhook's recent deliveries, newest first, with attempts and the last response"""
+            _check_admin(x_admin_token)
+            if webhook_id not in self.webhooks.webhooks:
+                raise HTTPException(404, f"Webhook {webhook_id} not found")
+            return {"webhook_id": webhook_id, "deliveries": self.webhooks.history(webhook_id)}
+        
+        @self.app.post("/admin/webhooks/{webhook_id}/test", tags=["admin"])
+        async def test_webhook(webhook_id: str, x_admin_token: Optional[str] = Header(None)):
+            """Send a signed ping now, retries included, and return how it went"""
+            _check_admin(x_admin_token)
+            webhook = self.webhooks.webhooks.get(webhook_id)
+            if webhook is None:
+                raise HTTPException(404, f"Webhook {webhook_id} not found")
+            delivery = await self.webhooks.deliver(webhook, PING, {"webhook_id": webhook_id})
+            return delivery.to_dict()
+        
+        @self.app.get("/admin/notifications", tags=["admin"])
+        async def get_notifications(x_admin_token: Optional[str] = Header(None)):
+            """Kitchen alert sinks from the config file, without their URLs' paths, and recent alerts posted"""
+            _check_admin(x_admin_token)
+            return self.notifier.to_dict()
+        
+        @self.app.post("/admin/notifications/test", tags=["admin"])
+        async def test_notifications(
+            severity: str = Query("critical", pattern=f"^({'|'.join(SEVERITIES)})$"),
+            x_admin_token: Optional[str] = Header(None)
+        ):
+            """Post a test alert to every sink, whatever its threshold, and return how each went"""
+            _check_admin(x_admin_token)
+            if not self.notifier.sinks:
+                raise HTTPException(409, "No notification sinks are configured")
+            records = await self.notifier.test(severity)
+            return {"severity": severity, "results": [r.to_dict() for r in records]}
+        
+        @self.app.get("/admin/simulator", tags=["admin"])
+        async def get_simulator_script(x_admin_token: Optional[str] = Header(None)):
+            """The script mock-model agents answer from, and how many calls it has broken"""
+            _check_admin(x_admin_token)
+            return get_simulator().to_dict()
+        
+        @self.app.put("/admin/simulator", tags=["admin"])
+        async def configure_simulator(request: SimulatorRequest, x_admin_token: Optional[str] = Header(None)):
+            """Change the mock model's failure rates, keeping unspecified ones; applies from the next call"""
+            _check_admin(x_admin_token)
+            try:
+                get_simulator().configure(**{k: v for k, v in request.dict().items() if v is not None})
+            except ValueError as e:
+                raise HTTPException(400, str(e))
+            return get_simulator().to_dict()
+        
+        @self.app.get("/sandbox", tags=["sandboxes"])
+        async def get_sandbox():
+            """Get the calling session's sandbox"""
+            return self.sandboxes.current().to_dict()
+        
+        @self.app.get("/admin/sandboxes", tags=["admin"])
+        async def list_sandboxes(x_admin_token: Optional[str] = Header(None)):
+            """List all session sandboxes"""
+            _check_admin(x_admin_token)
+            return self.sandboxes.to_dict()
+        
+        @self.app.delete("/admin/sandboxes/{session_id}", tags=["admin"])
+        async def delete_sandbox(session_id: str, x_admin_token: Optional[str] = Header(None)):
+            """Discard a session sandbox immediately"""
+            _check_admin(x_admin_token)
+            if not self.sandboxes.remove(session_id):
+                raise HTTPException(404, "Sandbox not found")
+            return {"status": "removed", "session_id": session_id}
+        
+        @self.app.delete("/reset", tags=["system"])
         async def reset_system():
-            """Reset the entire system"""
+            """Reset the calling session's sandbox"""
+            if self.coordinator.running:
+                raise HTTPException(409, "A scenario is running; wait for it to finish before resetting")
             self.coordinator.reset()
             self.coordinator.agents.clear()
+            self.coordinator.schedule.clear()
             self.active_evaluations.clear()
             
             return {"status": "reset", "message": "System reset successfully"}
     
+    def apply_seed_profile(self, name: str) -> Dict[str, int]:
+        """Seed the current sandbox's kitchen and the shared recipe dataset"""
+        return get_seed_profile(name).apply(SeedTarget(self.coordinator, self.dataset_parser))
+    
     def _generate_scenario_tasks(
         self,
         scenario_type: str,
         num_tasks: int,
-        use_dataset: bool
+        use_dataset: bool,
+        dietary_restrictions: Optional[List[str]] = None
     ) -> List[Tuple[TaskType, Dict]]:
         """Generate tasks for a scenario"""
         tasks = []
+        bundle = None
+        if scenario_type not in SCENARIO_TYPES:
+            bundle = self.scenario_library.get(scenario_type)
+            if bundle is None:
+                raise ValueError(f"Unknown scenario type '{scenario_type}', expected one of {self._scenario_names()}")
         
-        # Get inventory from dataset if available
-        if use_dataset and self.dataset_parser.loaded:
+        # Get inventory from the bundle, or the dataset if available
+        if bundle and bundle.ingredients:
+            ingredients = list(bundle.ingredients)
+        elif use_dataset and self.dataset_parser.loaded:
             inventory = self.dataset_parser.generate_kitchen_inventory("medium")
             ingredients = list(inventory.keys())
         else:
-            ingredients = ["salt", "pepper", "oil", "flour", "eggs", "milk", "butter"]
+            ingredients = list(DEFAULT_INGREDIENTS)
+        
+        # Make agents aware of what each ingredient on hand contains
+        allergens = {}
+        for ingredient in ingredients[:10]:
+            info = self.ingredient_catalog.get(ingredient)
+            if info and info.allergens:
+                allergens[ingredient] = info.allergens
+        
+        # Ingredients on hand that the guests' restrictions rule out
+        restricted = []
+        if dietary_restrictions:
+            check = self.ingredient_catalog.check_restrictions(ingredients[:10], dietary_restrictions)
+            restricted = sorted({c["ingredient"] for c in check["conflicts"]})
+        
+        # Recipes menu planners can choose from: those the stock can make, with how each has sold
+        engineering = {item["recipe_id"]: item for item in self._menu_engineering()["items"] if item["class"]}
+        menu_candidates = []
+        for recipe in self.dataset_parser.recipes:
+            if len(menu_candidates) >= MENU_CANDIDATES:
+                break
+            if not self.dataset_parser.validate_recipe(recipe, ingredients[:10])['missing']:
+                candidate = {"recipe_id": recipe['id'], "dish": dish_name(recipe), "ingredients": list(recipe['ingredients'])}
+                if recipe['id'] in engineering:
+                    item = engineering[recipe['id']]
+                    candidate.update({"class": item["class"], "sold": item["sold"], "margin": item["margin"]})
+                menu_candidates.append(candidate)
         
         # Define task distributions by scenario type
-        if scenario_type == "standard":
+        if bundle:
+            task_distribution = bundle.tasks
+        elif scenario_type == "standard":
             task_distribution = [
                 (TaskType.MENU_PLANNING, 1),
                 (TaskType.INGREDIENT_PREPARATION, 3),
@@ -363,6 +3022,8 @@ class ChefBenchAPI:
                 (TaskType.SAUCE_PREPARATION, 2),
                 (TaskType.PLATING_DESIGN, 3)
             ]
+        elif scenario_type == "tutorial":
+            task_distribution = TUTORIAL_TASK_DISTRIBUTION
         else:  # complex
             task_distribution = [
                 (TaskType.MENU_PLANNING, 1),
@@ -385,14 +3046,357 @@ class ChefBenchAPI:
                         "ingredients": ingredients[:10],
                         "time_limit": 300,
                         "difficulty": scenario_type,
-                        "task_number": task_count + 1
+                        "task_number": task_count + 1,
+                        **(bundle.context if bundle else {})
                     }
+                    if allergens:
+                        context["allergens"] = allergens
+                    if dietary_restrictions:
+                        context["dietary_restrictions"] = list(dietary_restrictions)
+                        context["restricted_ingredients"] = restricted
+                    
+                    if task_type == TaskType.MENU_PLANNING and menu_candidates:
+                        context["menu_candidates"] = menu_candidates
+                    
+                    # Ground re-planning tasks in the substitution table
+                    if task_type in (TaskType.RECIPE_MODIFICATION, TaskType.INVENTORY_MANAGEMENT):
+                        context["substitutions"] = {
+                            ingredient: [
+                                f"{s.substitute} (x{s.ratio:g}, -{s.quality_penalty:.2f} quality)"
+                                for s in self.substitutions.get_substitutes(ingredient)
+                            ]
+                            for ingredient in ingredients[:10]
+                            if self.substitutions.get_substitutes(ingredient)
+                        }
                     
                     tasks.append((task_type, context))
                     task_count += 1
         
         return tasks[:num_tasks]
     
+    def _plan_order(
+        self,
+        coordinator: MultiAgentCoordinator,
+        request: OrderSubmissionRequest,
+        evaluation: Optional[Dict[str, Any]]
+    ) -> OrderPlan:
+        """Check an order against the kitchen as it is now, and plan how it would be cooked"""
+        return self._order_planner(coordinator).plan(
+            [OrderItem(**item.dict()) for item in request.items],
+            self._ingredients_on_hand(evaluation),
+            request.table,
+            request.dietary_restrictions,
+            request.time_limit
+        )
+    
+    @staticmethod
+    def _place_order(
+        coordinator: MultiAgentCoordinator,
+        plan: OrderPlan,
+        request: OrderSubmissionRequest
+    ) -> List[str]:
+        """Queue a planned order at its priority, returning its task ids; raises ValueError if its table has gone"""
+        task_ids = coordinator.submit_orders(plan.tasks(), request.table, covers=request.party_size)
+        if task_ids and request.priority != "normal":
+            coordinator.modify_order(task_ids[0], priority=request.priority, author="order")
+        return task_ids
+    
+    def _call_course(self, call: str, request: CourseCallRequest) -> Dict[str, Any]:
+        """Hold or fire a course in the executing run"""
+        coordinator = self.coordinator
+        eval_data = self.active_evaluations.get(coordinator.run_id)
+        if not coordinator.running or eval_data is None or eval_data["status"] not in ("running", "paused"):
+            raise HTTPException(409, "No run is executing")
+        if request.table is not None and request.table not in coordinator.floor.tables:
+            raise HTTPException(404, f"Unknown table {request.table}")
+        call_course = coordinator.hold_course if call == "hold" else coordinator.fire_course
+        return {"evaluation_id": coordinator.run_id, **call_course(request.course, request.table, request.author)}
+    
+    def _order_planner(self, coordinator: MultiAgentCoordinator) -> OrderPlanner:
+        return OrderPlanner(coordinator, self.dataset_parser, self.ingredient_catalog, self.eta_estimator, self.menu)
+    
+    def _write_menu(self, planned_by: str, items: List[Dict[str, Any]]) -> List[Any]:
+        """Plan the menu from a planning agent's items, returning the recipe ids that aren't loaded
+
+        Unknown items are left out; a plan with none known changes nothing.
+        """
+        loaded = {r['id'] for r in self.dataset_parser.recipes}
+        known, unknown = [], []
+        for item in items:
+            try:
+                recipe_id = int(item.get("recipe_id"))
+            except (TypeError, ValueError):
+                recipe_id = None
+            if recipe_id in loaded:
+                price = item.get("price")
+                price = float(price) if isinstance(price, (int, float)) and price > 0 else None
+                known.append(MenuItem(recipe_id, bool(item.get("specialty")), bool(item.get("seasonal")), price=price))
+            else:
+                unknown.append(item.get("recipe_id"))
+        if known:
+            self.menu.plan(known, planned_by)
+        return unknown
+    
+    def _menu_entry(self, recipe: Dict[str, Any], on_hand: List[str], spoiled) -> Dict[str, Any]:
+        """A recipe as GET /menu lists it"""
+        eighty_sixed = self.menu.check(recipe, on_hand, spoiled)
+        item = self.menu.item(recipe['id'])
+        food_cost = plate_cost(recipe['ingredients'], self.ingredient_catalog)
+        return {
+            "recipe_id": recipe['id'],
+            "dish": dish_name(recipe),
+            "cuisine": recipe['cuisine'],
+            "ingredients": list(recipe['ingredients']),
+            "specialty": item.specialty,
+            "seasonal": item.seasonal,
+            "price": self.menu.price(recipe['id'], food_cost),
+            "food_cost": round(food_cost, 2),
+            "available": eighty_sixed is None,
+            "eighty_sixed": eighty_sixed.reasons if eighty_sixed else []
+        }
+    
+    def _service_report(
+        self,
+        coordinator: MultiAgentCoordinator,
+        config: Dict[str, Any],
+        duration_seconds: float
+    ) -> Dict[str, Any]:
+        """A finished run's tickets by service period, each served portion earning its menu price"""
+        demand = DemandConfig.from_env().with_overrides(config.get("customer_demand"))
+
+        def price(portion: Dict[str, Any]) -> float:
+            food_cost = plate_cost(portion["ingredients"], self.ingredient_catalog)
+            if portion["recipe_id"] is None:
+                return list_price(food_cost)
+            return self.menu.price(portion["recipe_id"], food_cost)
+
+        return service_report(
+            coordinator.ticket_ledger(),
+            demand,
+            duration_seconds,
+            price,
+            seats=sum(table.capacity for table in coordinator.floor.tables.values())
+        )
+    
+    def _order_costs(self, coordinator: MultiAgentCoordinator) -> Dict[str, Any]:
+        """Every ticket of a finished run costed line by line, with the run's totals"""
+        ledger = coordinator.ticket_ledger()
+        served = {p["task_id"] for ticket in ledger for p in ticket["portions"] if p["served"]}
+        tickets = [
+            cost_ticket(coordinator.order_ticket(ticket["portions"][0]["task_id"]), served, self.ingredient_catalog, self.menu)
+            for ticket in ledger
+        ]
+        food_cost = sum(t["food_cost"] for t in tickets)
+        revenue = sum(t["revenue"] for t in tickets)
+        return {
+            "tickets": tickets,
+            "food_cost": round(food_cost, 2),
+            "revenue": round(revenue, 2),
+            "margin": round(revenue - food_cost, 2),
+            "food_cost_pct": round(food_cost / revenue, 4) if revenue else None
+        }
+    
+    def _menu_engineering(self, since: Optional[float] = None) -> Dict[str, Any]:
+        """The menu engineering report over the sales recorded (since a time, if given)
+
+        It ranks the planned menu, or with none planned every recipe that has sold.
+        """
+        sales = self.menu.store.sales(since) if self.menu.store is not None else {}
+        recipes = self.menu.recipes() if self.menu.planned else [
+            r for r in self.dataset_parser.recipes if r['id'] in sales
+        ]
+        costs = {r['id']: plate_cost(r['ingredients'], self.ingredient_catalog) for r in recipes}
+        return menu_engineering(
+            [{"recipe_id": r['id'], "dish": dish_name(r)} for r in recipes],
+            sales,
+            {recipe_id: self.menu.price(recipe_id, cost) for recipe_id, cost in costs.items()},
+            costs
+        )
+    
+    def _announce_86(self, coordinator: MultiAgentCoordinator, items: List[EightySixed]) -> List[int]:
+        """Tell the executing run's team about newly 86'd items, returning the ones announced"""
+        if not coordinator.running:
+            return []
+        return [
+            item.recipe_id for item in items
+            if coordinator.announce_86(item.recipe_id, item.dish, item.reasons)
+        ]
+    
+    def _kitchen_plan(self, coordinator: MultiAgentCoordinator, evaluation: Optional[Dict[str, Any]]) -> OrderPlan:
+        """An order of nothing, planned only for the work already ahead of it"""
+        return self._order_planner(coordinator).plan(
+            [], self._ingredients_on_hand(evaluation)
+        )
+    
+    @staticmethod
+    def _refuse(decision: AdmissionDecision):
+        """Answer an order admission turned away: 429 to try again later, 422 if it never fits"""
+        if not decision.retryable:
+            raise HTTPException(422, {**decision.to_dict(), "reasons": decision.reasons + ["The order is too big to admit"]})
+        raise HTTPException(
+            429,
+            decision.to_dict(),
+            headers={"Retry-After": str(max(1, math.ceil(decision.wait_estimate)))}
+        )
+    
+    def _schedule_orders(
+        self,
+        coordinator: MultiAgentCoordinator,
+        evaluation: Dict[str, Any],
+        orders: List[ScheduledOrderRequest],
+        source: str = "bulk"
+    ) -> Dict[str, Any]:
+        """Start delivering orders to the executing run at their arrival times, as a batch it can be followed by"""
+        batch_id = f"{source}-{uuid.uuid4().hex[:8]}"
+        batch = {
+            "batch_id": batch_id,
+            "evaluation_id": coordinator.run_id,
+            "created_at": time.time(),
+            "orders": [
+                {"index": i, "arrive_at": order.arrive_at, "table": order.table, "status": "scheduled",
+                 "task_ids": [], "errors": []}
+                for i, order in enumerate(orders)
+            ]
+        }
+        evaluation.setdefault("order_batches", {})[batch_id] = batch
+        coordinator.record_event(
+            "orders_scheduled",
+            batch_id=batch_id,
+            orders=len(orders),
+            last_arrival=max(order.arrive_at for order in orders),
+            source=source
+        )
+        asyncio.create_task(self._deliver_orders(coordinator, evaluation, batch, orders))
+        return batch
+    
+    def _seat_customers(
+        self,
+        coordinator: MultiAgentCoordinator,
+        evaluation_id: str,
+        customers: CustomerSimulator,
+        duration_seconds: float
+    ):
+        """Decide the customers an evaluation's run will have, for them to order once it starts
+
+        They order only what's not 86'd as the run starts, at the seated
+        tables in turn, and the run stays open until the last of them is due.
+        """
+        evaluation = self.active_evaluations[evaluation_id]
+        on_hand = self._ingredients_on_hand(evaluation)
+        menu = [r for r in self.menu.recipes() if self.menu.check(r, on_hand, coordinator.spoiled) is None]
+        tables = [table.number for table in coordinator.floor.dining_tables()]
+        orders = customers.orders(menu, duration_seconds, tables)
+        evaluation["customers"] = {"demand": customers.config.to_dict(), **demand_summary(orders), "batch_id": None}
+        if not orders:
+            logger.warning("No customers to simulate: nothing on the menu can be made, or no one is due")
+            return
+        coordinator.expect_orders(max(order.arrive_at for order in orders))
+        asyncio.create_task(self._open_doors(
+            coordinator, evaluation_id, [ScheduledOrderRequest(**order.to_order()) for order in orders]
+        ))
+        logger.info(f"{len(orders)} customers due over the run, busiest at {evaluation['customers']['busiest_hour']}:00")
+    
+    async def _open_doors(self, coordinator: MultiAgentCoordinator, evaluation_id: str, orders: List[ScheduledOrderRequest]):
+        """Schedule the customers' orders as a batch, once their run has started"""
+        while coordinator.run_id != evaluation_id:
+            if not coordinator.running:
+                return
+            await asyncio.sleep(0.05)
+        evaluation = self.active_evaluations[evaluation_id]
+        batch = self._schedule_orders(coordinator, evaluation, orders, "customers")
+        evaluation["customers"]["batch_id"] = batch["batch_id"]
+    
+    async def _deliver_orders(
+        self,
+        coordinator: MultiAgentCoordinator,
+        evaluation: Dict[str, Any],
+        batch: Dict[str, Any],
+        orders: List[ScheduledOrderRequest]
+    ):
+        """Place a bulk submission's orders as their arrival times come, while their run executes
+
+        Orders still to arrive when the run finishes are dropped.
+        """
+        run_id = batch["evaluation_id"]
+        pending = sorted(zip(batch["orders"], orders), key=lambda pair: pair[1].arrive_at)
+        while pending:
+            if coordinator.run_id != run_id or not coordinator.running or evaluation["status"] not in ("running", "paused"):
+                for entry, _ in pending:
+                    entry["status"] = "dropped"
+                break
+            wait = pending[0][1].arrive_at - coordinator.elapsed_seconds
+            if coordinator.paused or wait > 0:
+                await asyncio.sleep(0.5 if coordinator.paused else min(wait, 0.5))
+                continue
+            
+            entry, order = pending.pop(0)
+            plan = self._plan_order(coordinator, order, evaluation)
+            errors = plan.errors + [
+                f"item {item.index}: {error}" for item in plan.items for error in item.errors
+            ]
+            decision = admit(self.admission, coordinator, plan)
+            if plan.feasible and not decision.admitted:
+                entry["status"] = "throttled"
+                entry["errors"] = decision.reasons
+                entry["wait_estimate"] = decision.to_dict()["wait_estimate"]
+                coordinator.throttle_order(
+                    len(plan.tasks()), decision.reasons, decision.wait_estimate,
+                    table=order.table, batch_id=batch["batch_id"], index=entry["index"]
+                )
+                continue
+            if plan.feasible:
+                try:
+                    entry["task_ids"] = self._place_order(coordinator, plan, order)
+                    entry["status"] = "queued"
+                except ValueError as e:
+                    errors.append(str(e))
+            if errors:
+                entry["errors"] = errors
+                entry["status"] = "rejected"
+                coordinator.record_event(
+                    "order_rejected",
+                    batch_id=batch["batch_id"],
+                    index=entry["index"],
+                    table=order.table,
+                    errors=entry["errors"]
+                )
+    
+    @staticmethod
+    def _batch_summary(batch: Dict[str, Any]) -> Dict[str, Any]:
+        counts: Dict[str, int] = {}
+        for entry in batch["orders"]:
+            counts[entry["status"]] = counts.get(entry["status"], 0) + 1
+        return {**batch, "counts": counts}
+    
+    def _ingredients_on_hand(self, evaluation: Optional[Dict[str, Any]]) -> List[str]:
+        """What the kitchen has to cook with: the executing run's stock, or the default pantry"""
+        if evaluation:
+            stock = {i for task in evaluation.get("tasks") or [] for i in task["context"].get("ingredients", [])}
+            if stock:
+                return sorted(stock)
+        return list(DEFAULT_INGREDIENTS)
+    
+    def _scenario_names(self) -> List[str]:
+        return list(SCENARIO_TYPES) + [b.name for b in self.scenario_library.list()]
+    
+    def _with_bundle_defaults(self, request: ScenarioExecutionRequest) -> ScenarioExecutionRequest:
+        """The request with its bundle's defaults for the fields it leaves unset, and its scoring profile
+
+        Without one from the request or bundle, the profile is the one the
+        scoring config sets for the scenario. Raises ValueError for an unknown
+        scenario type or profile, or a bundle default out of range.
+        """
+        if request.scenario_type not in SCENARIO_TYPES:
+            bundle = self.scenario_library.get(request.scenario_type)
+            if bundle is None:
+                raise ValueError(f"Unknown scenario type '{request.scenario_type}', expected one of {self._scenario_names()}")
+            defaults = {k: v for k, v in bundle.defaults.items() if k not in request.model_fields_set}
+            request = ScenarioExecutionRequest(**{**request.dict(), **defaults})
+        if request.scoring_profile is None:
+            request.scoring_profile = get_scoring_config().profile_for(request.scenario_type)
+        get_scoring_profile(request.scoring_profile)
+        return request
+    
     async def _run_scenario(
         self,
         evaluation_id: str,
@@ -401,48 +3405,471 @@ class ChefBenchAPI:
         scenario_type: str
     ):
         """Run scenario execution"""
+        with log_context(run_id=evaluation_id):
+            await self._execute_evaluation(evaluation_id, tasks, duration_seconds, scenario_type)
+    
+    async def _archive_events_periodically(self, interval_seconds: float = 3600):
+        """Archive finished runs older than the retention window, for as long as the server is up"""
+        while True:
+            try:
+                cutoff = time.time() - self.event_retention_days * 86400
+                await asyncio.to_thread(self.event_store.archive, cutoff)
+            except Exception as e:
+                logger.error(f"Event archival failed: {e}")
+            await asyncio.sleep(interval_seconds)
+    
+    async def _resume_evaluation(self, checkpoint: Dict[str, Any]):
+        """Restore a checkpointed evaluation into its session's sandbox and run it to completion"""
+        evaluation = checkpoint["evaluation"]
+        evaluation_id = checkpoint["run_id"]
+        logger.info(f"Resuming evaluation {evaluation_id} from checkpoint")
+        
+        with self.sandboxes.bind(evaluation["session_id"]):
+            self.active_evaluations[evaluation_id] = {
+                "id": evaluation_id,
+                "status": "paused" if checkpoint.get("paused") else "running",
+                "started_at": evaluation["started_at"],
+                "config": evaluation["config"],
+                "seed": checkpoint["seed"],
+                "eta": evaluation["eta"],
+                "result": None,
+                "resumed_from": checkpoint.get("checkpointed_at")
+            }
+            with log_context(run_id=evaluation_id):
+                await self._execute_evaluation(
+                    evaluation_id,
+                    [],
+                    checkpoint["duration_seconds"],
+                    evaluation["scenario_type"],
+                    checkpoint=checkpoint
+                )
+    
+    async def _execute_evaluation(
+        self,
+        evaluation_id: str,
+        tasks: List[Tuple[TaskType, Dict]],
+        duration_seconds: int,
+        scenario_type: str,
+        checkpoint: Optional[Dict[str, Any]] = None
+    ):
+        evaluation = self.active_evaluations[evaluation_id]
+        session_id = self.sandboxes.current().session_id
         try:
-            # Reset coordinator for fresh execution
-            self.coordinator.reset()
+            # Runs queue here behind any evaluation already using this coordinator
+            async with self.coordinator.run_lock:
+                # Snapshot after every task so a restart can pick the run back up
+                self.coordinator.checkpoint_sink = lambda state: self.checkpoints.save(evaluation_id, {
+                    **state,
+                    "evaluation": {
+                        "session_id": session_id,
+                        "scenario_type": scenario_type,
+                        "started_at": evaluation["started_at"],
+                        "config": evaluation["config"],
+                        "eta": evaluation["eta"]
+                    }
+                })
+                self.coordinator.pause_sink = lambda reason: evaluation.update(status="paused")
+                # Menu planning tasks put the menu they choose on as PUT /menu would
+                self.coordinator.menu_sink = self._write_menu
+                
+                # Limits raised through the API while the run was queued are kept
+                config = evaluation["config"]
+                if (config.get("max_tokens") or config.get("max_cost")) and not get_usage_tracker().budget_status(evaluation_id):
+                    get_usage_tracker().set_budget(evaluation_id, RunBudget(config.get("max_tokens"), config.get("max_cost")))
+                
+                if checkpoint:
+                    result = await self.coordinator.resume_scenario(checkpoint, run_id=evaluation_id)
+                else:
+                    # Reset coordinator for fresh execution
+                    self.coordinator.reset()
+                    self.coordinator.set_assignment_policy(evaluation["config"]["assignment_policy"])
+                    self.coordinator.set_seed(evaluation["seed"])
+                    self.coordinator.set_rubric(get_quality_rubric(evaluation["config"].get("quality_rubric", "default")))
+                    self.coordinator.set_prompts(evaluation["config"].get("prompt_overrides"))
+                    if evaluation["config"].get("simulate_equipment"):
+                        self.coordinator.enable_equipment()
+                    if evaluation["config"].get("simulate_guests"):
+                        self.coordinator.enable_guests()
+                    if evaluation["config"].get("simulate_customers"):
+                        # Guests change and cancel orders at the demand's rates, the customers' included
+                        demand = DemandConfig.from_env().with_overrides(evaluation["config"].get("customer_demand"))
+                        self.coordinator.enable_guests(request_rate=demand.modification_rate, cancel_share=demand.cancel_share)
+                        customers = CustomerSimulator(evaluation["seed"], demand)
+                        self._seat_customers(self.coordinator, evaluation_id, customers, duration_seconds)
+                    if evaluation["config"].get("plan_pacing"):
+                        self.coordinator.enable_pacing()
+                    if evaluation["config"].get("expeditor"):
+                        self.coordinator.enable_expeditor()
+                
+                    # Execute scenario
+                    result = await self.coordinator.execute_scenario(
+                        tasks,
+                        duration_seconds,
+                        run_id=evaluation_id
+                    )
             
-            # Execute scenario
-            result = await self.coordinator.execute_scenario(tasks, duration_seconds)
+                if evaluation.get("customers"):
+                    result["customers"] = evaluation["customers"]
+                result["service_periods"] = self._service_report(self.coordinator, evaluation["config"], duration_seconds)
+                result["order_costs"] = self._order_costs(self.coordinator)
+                if self.menu.store is not None:
+                    self.menu.store.record_sales(evaluation_id, item_sales(result["order_costs"]["tickets"]))
+                # Score the prediction made at submission, then learn from the run
+                result["eta"] = score_eta(
+                    self.active_evaluations[evaluation_id]["eta"],
+                    result["duration"],
+                    result["execution_history"],
+                    self.coordinator.agents
+                )
+                self.eta_estimator.observe_result(result, self.coordinator.agents)
+                result["scores"] = score_run(
+                    result,
+                    duration_seconds,
+                    self.active_evaluations[evaluation_id]["config"]["scoring_profile"]
+                )
+                bundle = self.scenario_library.get(scenario_type)
+                if bundle and bundle.assertions:
+                    result["assertions"] = bundle.check(result)
             
-            # Record metrics
-            self.metrics_collector.record_scenario(
-                scenario_type,
-                result,
-                self.active_evaluations[evaluation_id]["config"]
-            )
+                if evaluation["config"].get("judge_transcripts"):
+                    result["judgement"] = await self._judge_run(
+                        evaluation_id, result, evaluation["config"].get("judge_model")
+                    )
+                
+                # Record metrics
+                self.metrics_collector.record_scenario(
+                    scenario_type,
+                    result,
+                    self.active_evaluations[evaluation_id]["config"]
+                )
             
-            # Update evaluation
-            self.active_evaluations[evaluation_id]["status"] = "completed"
-            self.active_evaluations[evaluation_id]["result"] = result
+                # Update evaluation
+                self.active_evaluations[evaluation_id]["status"] = "completed"
+                self.active_evaluations[evaluation_id]["result"] = result
             
-            logger.info(f"Scenario {evaluation_id} completed successfully")
+                logger.info(f"Scenario {evaluation_id} completed successfully")
             
         except Exception as e:
             logger.error(f"Scenario {evaluation_id} failed: {str(e)}")
             self.active_evaluations[evaluation_id]["status"] = "failed"
             self.active_evaluations[evaluation_id]["error"] = str(e)
+        finally:
+            self.coordinator.checkpoint_sink = None
+            self.coordinator.menu_sink = None
+        
+        # A cancelled run (server shutdown) keeps its checkpoint for the next startup
+        self.checkpoints.delete(evaluation_id)
+
+
+    async def _judge_run(
+        self,
+        evaluation_id: str,
+        result: Dict[str, Any],
+        model: Optional[str] = None
+    ) -> Dict[str, Any]:
+        """Feed a run's recorded transcripts and outcomes to a separate judge model"""
+        judge = LLMJudge(model or DEFAULT_JUDGE_MODEL, seed=result.get("seed"))
+        transcripts = self.transcripts.query(run_id=evaluation_id)
+        logger.info(f"Judging {len(transcripts)} transcripts of {evaluation_id} with {judge.model_name}")
+        try:
+            return await asyncio.to_thread(judge_transcripts, judge, transcripts, result)
+        except Exception as e:
+            # A failed judgement shouldn't cost the run its automatic metrics
+            logger.error(f"Judging {evaluation_id} failed: {str(e)}")
+            return {"judge": judge.to_dict(), "error": str(e)}
+    
+    async def _run_model_comparison(
+        self,
+        comparison_id: str,
+        team_size: int,
+        roles: Optional[List[AgentRole]],
+        max_parallel: int = 1
+    ):
+        """Run the comparison's scenario for each model, up to max_parallel at once, then rank them"""
+        comparison = self.comparisons[comparison_id]
+        config = comparison["config"]
+        
+        # Same seed, same tasks for every model; each run gets its own copy
+        # because coordinators annotate task contexts as they go
+        self.dataset_parser.reseed(config["seed"])
+        try:
+            tasks = self._generate_scenario_tasks(
+                config["scenario_type"],
+                config["num_tasks"],
+                config["use_dataset"],
+                config.get("dietary_restrictions")
+            )
+        except Exception as e:
+            logger.error(f"Comparison {comparison_id} failed to generate tasks: {str(e)}")
+            comparison.update(status="failed", error=str(e))
+            return
+        
+        entries = [
+            {"model": model, "evaluation_id": str(uuid.uuid4()), "status": "queued"}
+            for model in comparison["models"]
+        ]
+        comparison["runs"].extend(entries)
+        
+        slots = asyncio.Semaphore(max_parallel)
+        
+        async def run(entry: Dict[str, Any]):
+            async with slots:
+                await self._run_comparison_entry(comparison_id, entry, copy.deepcopy(tasks), team_size, roles)
+        
+        await asyncio.gather(*(run(entry) for entry in entries))
+        
+        completed = [r for r in comparison["runs"] if r["status"] == "completed"]
+        completed.sort(key=lambda r: r["scores"]["score"], reverse=True)
+        comparison["ranking"] = [
+            {"rank": i, "model": r["model"], "score": r["scores"]["score"], "evaluation_id": r["evaluation_id"]}
+            for i, r in enumerate(completed, 1)
+        ]
+        comparison["status"] = "completed" if completed else "failed"
+        logger.info(f"Comparison {comparison_id} finished, {len(completed)}/{len(comparison['runs'])} models completed")
+    
+    async def _run_prompt_experiment(
+        self,
+        experiment_id: str,
+        coordinator: MultiAgentCoordinator,
+        variants: List[PromptVariant],
+        primary_metric: str,
+        alpha: float
+    ):
+        """Run a prompt experiment on the session's team, one run at a time"""
+        experiment = self.experiments[experiment_id]
+        config = experiment["config"]
+        
+        def tasks_for(seed: int) -> List[Tuple[TaskType, Dict]]:
+            self.dataset_parser.reseed(seed)
+            return self._generate_scenario_tasks(
+                config["scenario_type"],
+                config["num_tasks"],
+                config["use_dataset"],
+                config.get("dietary_restrictions")
+            )
+        
+        def prepare(kitchen: MultiAgentCoordinator):
+            kitchen.set_assignment_policy(config["assignment_policy"])
+            kitchen.set_rubric(get_quality_rubric(config.get("quality_rubric", "default")))
+            if config.get("simulate_equipment"):
+                kitchen.enable_equipment()
+            if config.get("simulate_guests"):
+                kitchen.enable_guests()
+            if config.get("plan_pacing"):
+                kitchen.enable_pacing()
+            if config.get("expeditor"):
+                kitchen.enable_expeditor()
+        
+        def metrics_for(result: Dict[str, Any]) -> Dict[str, float]:
+            experiment["completed_runs"] += 1
+            score = score_run(result, config["duration_seconds"], config["scoring_profile"])["score"]
+            return {**outcome_metrics(result), "score": score or 0.0}
+        
+        runner = PromptExperiment(
+            coordinator,
+            tasks_for,
+            variants[0],
+            variants[1],
+            experiment["seeds"],
+            duration_seconds=config["duration_seconds"],
+            metric_fn=metrics_for,
+            primary_metric=primary_metric,
+            alpha=alpha,
+            prepare=prepare
+        )
+        try:
+            with log_context(run_id=experiment_id):
+                experiment["report"] = await runner.run()
+            experiment["status"] = "completed"
+            logger.info(f"Prompt experiment {experiment_id} finished: {experiment['report']['conclusion']}")
+        except Exception as e:
+            logger.error(f"Prompt experiment {experiment_id} failed: {str(e)}")
+            experiment.update(status="failed", error=str(e), report=runner.report() if runner.runs else None)
+    
+    async def _run_comparison_entry(
+        self,
+        comparison_id: str,
+        entry: Dict[str, Any],
+        tasks: List[Tuple[TaskType, Dict]],
+        team_size: int,
+        roles: Optional[List[AgentRole]]
+    ):
+        """Run one model of a comparison in its own kitchen"""
+        config = self.comparisons[comparison_id]["config"]
+        model, run_id = entry["model"], entry["evaluation_id"]
+        entry["status"] = "running"
+        
+        try:
+            # A fresh kitchen per model: its own agents, clock, equipment and
+            # event stream (keyed by run id), so parallel runs never mix
+            coordinator = MultiAgentCoordinator(config["assignment_policy"], event_store=self.event_store)
+            await asyncio.to_thread(coordinator.create_agent_team, model, team_size, roles)
+            coordinator.set_seed(config["seed"])
+            coordinator.set_rubric(get_quality_rubric(config.get("quality_rubric", "default")))
+            coordinator.set_prompts(config.get("prompt_overrides"))
+            if config.get("simulate_equipment"):
+                coordinator.enable_equipment()
+            
+            with log_context(run_id=run_id):
+                result = await coordinator.execute_scenario(tasks, config["duration_seconds"], run_id=run_id)
+            result["scores"] = score_run(result, config["duration_seconds"], config["scoring_profile"])
+            self.metrics_collector.record_scenario(config["scenario_type"], result, {**config, "model": model})
+            
+            team = result["agent_metrics"]["team"]
+            entry.update(
+                status="completed",
+                tasks_completed=result["tasks_completed"],
+                total_tasks=result["total_tasks"],
+                success_rate=team["overall_success_rate"],
+                average_quality=team["average_quality"],
+                labor_cost=team["labor_cost"],
+                invalid_action_rate=team["invalid_action_rate"],
+                duration=result["duration"],
+                usage=(result.get("usage") or {}).get("total"),
+                scores=result["scores"]
+            )
+        except Exception as e:
+            logger.error(f"Comparison {comparison_id} failed for {model}: {str(e)}")
+            entry.update(status="failed", error=str(e))
+
+
+def _env_list(name: str) -> List[str]:
+    return [item.strip() for item in os.environ.get(name, "").split(",") if item.strip()]
 
 
-def create_app() -> FastAPI:
-    """Create and configure the FastAPI application"""
-    api = ChefBenchAPI()
+def create_app(
+    seed: Optional[int] = None,
+    log_level: Optional[str] = None,
+    log_format: str = "json",
+    cors_origins: Optional[List[str]] = None,
+    root_path: Optional[str] = None,
+    seed_profile: Optional[str] = None,
+    otlp_endpoint: Optional[str] = None
+) -> FastAPI:
+    """Create and configure the FastAPI application
+
+    CORS origins and the base path default to $CHEFBENCH_CORS_ORIGINS
+    (comma separated) and $CHEFBENCH_ROOT_PATH so the server can run behind
+    a reverse proxy at a non-root path with browser frontends elsewhere.
+    The default sandbox starts with $CHEFBENCH_SEED_PROFILE's data, empty by default.
+    Traces go to otlp_endpoint, or $OTEL_EXPORTER_OTLP_ENDPOINT, when either is set.
+    """
+    configure_logging(
+        log_level or os.environ.get("CHEFBENCH_LOG_LEVEL", "info"),
+        json_output=log_format == "json"
+    )
+    root_path = (root_path if root_path is not None else os.environ.get("CHEFBENCH_ROOT_PATH", "")).rstrip("/")
+    api = ChefBenchAPI(default_seed=seed, root_path=root_path)
+    api.apply_seed_profile(seed_profile or os.environ.get("CHEFBENCH_SEED_PROFILE", "empty"))
+    
+    cors_origins = cors_origins if cors_origins is not None else _env_list("CHEFBENCH_CORS_ORIGINS")
+    if cors_origins:
+        api.app.add_middleware(
+            CORSMiddleware,
+            allow_origins=cors_origins,
+            allow_credentials="*" not in cors_origins,
+            allow_methods=["*"],
+            allow_headers=["*"],
+            expose_headers=["X-Fault-Injected"]
+        )
+        logger.info(f"CORS enabled for {cors_origins}")
+    
+    # Outermost, so each request's span covers every other middleware
+    if configure_tracing(otlp_endpoint):
+        api.app.middleware("http")(tracing_middleware)
+    
     return api.app
 
 
+def trusted_proxies(value: Optional[str] = None) -> str:
+    """Addresses whose X-Forwarded-* headers uvicorn should trust"""
+    return value or os.environ.get("CHEFBENCH_TRUSTED_PROXIES", "127.0.0.1")
+
+
+def tls_options(
+    certfile: Optional[str] = None,
+    keyfile: Optional[str] = None,
+    client_ca: Optional[str] = None,
+    require_client_cert: Optional[bool] = None
+) -> Dict[str, Any]:
+    """uvicorn keyword arguments for native TLS and optional mutual TLS
+
+    Values default to $CHEFBENCH_TLS_CERT, $CHEFBENCH_TLS_KEY,
+    $CHEFBENCH_TLS_CLIENT_CA and $CHEFBENCH_TLS_REQUIRE_CLIENT_CERT. Without a
+    certificate the server runs plaintext.
+    """
+    import ssl
+    
+    certfile = certfile or os.environ.get("CHEFBENCH_TLS_CERT")
+    keyfile = keyfile or os.environ.get("CHEFBENCH_TLS_KEY")
+    client_ca = client_ca or os.environ.get("CHEFBENCH_TLS_CLIENT_CA")
+    if require_client_cert is None:
+        require_client_cert = os.environ.get("CHEFBENCH_TLS_REQUIRE_CLIENT_CERT", "").lower() in ("1", "true", "yes")
+    
+    if not certfile:
+        if keyfile or client_ca or require_client_cert:
+            raise ValueError("TLS key, client CA and client certificates require a server certificate")
+        return {}
+    if require_client_cert and not client_ca:
+        raise ValueError("Requiring client certificates needs a client CA bundle")
+    
+    options = {"ssl_certfile": certfile, "ssl_keyfile": keyfile}
+    if client_ca:
+        options["ssl_ca_certs"] = client_ca
+        options["ssl_cert_reqs"] = ssl.CERT_REQUIRED if require_client_cert else ssl.CERT_OPTIONAL
+    logger.info(f"TLS enabled with {certfile}" + (" (mutual TLS)" if require_client_cert else ""))
+    return options
+
+
 if __name__ == "__main__":
+    import argparse
     import uvicorn
     
+    parser = argparse.ArgumentParser(description="ChefBench API server")
+    parser.add_argument("--host", default="localhost")
+    parser.add_argument("--port", type=int, default=8000)
+    parser.add_argument("--seed", type=int, default=None,
+                        help="Default RNG seed for scenario runs that don't specify one")
+    parser.add_argument("--log-level", default=None,
+                        choices=["debug", "info", "warning", "error", "critical"],
+                        help="Log level (default: $CHEFBENCH_LOG_LEVEL or info)")
+    parser.add_argument("--log-format", default="json", choices=["json", "text"])
+    parser.add_argument("--seed-profile", default=None, choices=list(SEED_PROFILES),
+                        help="Data to start with (default: $CHEFBENCH_SEED_PROFILE or empty)")
+    parser.add_argument("--cors-origin", action="append", dest="cors_origins", default=None,
+                        help="Allowed browser origin, repeatable (default: $CHEFBENCH_CORS_ORIGINS)")
+    parser.add_argument("--root-path", default=None,
+                        help="Base path when served behind a proxy, e.g. /chefbench (default: $CHEFBENCH_ROOT_PATH)")
+    parser.add_argument("--trusted-proxies", default=None,
+                        help="Comma-separated proxy IPs trusted for X-Forwarded-* (default: $CHEFBENCH_TRUSTED_PROXIES or 127.0.0.1)")
+    parser.add_argument("--otlp-endpoint", default=None,
+                        help="OTLP/HTTP collector to send traces to, e.g. http://localhost:4318 (default: $OTEL_EXPORTER_OTLP_ENDPOINT)")
+    parser.add_argument("--tls-cert", default=None, help="Server certificate (PEM) to serve HTTPS")
+    parser.add_argument("--tls-key", default=None, help="Private key for --tls-cert")
+    parser.add_argument("--tls-client-ca", default=None, help="CA bundle used to verify client certificates")
+    parser.add_argument("--require-client-cert", action="store_true", default=None,
+                        help="Reject clients without a certificate signed by --tls-client-ca")
+    args = parser.parse_args()
+    
     # Create app
-    app = create_app()
+    app = create_app(
+        seed=args.seed,
+        log_level=args.log_level,
+        log_format=args.log_format,
+        cors_origins=args.cors_origins,
+        root_path=args.root_path,
+        seed_profile=args.seed_profile,
+        otlp_endpoint=args.otlp_endpoint
+    )
     
     # Run server
     uvicorn.run(
         app,
-        host="localhost",
-        port=8000,
-        log_level="info"
+        host=args.host,
+        port=args.port,
+        log_level=(args.log_level or os.environ.get("CHEFBENCH_LOG_LEVEL", "info")).lower(),
+        log_config=None,
+        proxy_headers=True,
+        forwarded_allow_ips=trusted_proxies(args.trusted_proxies),
+        **tls_options(args.tls_cert, args.tls_key, args.tls_client_ca, args.require_client_cert)
     )
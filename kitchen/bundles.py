@@ -0,0 +1,251 @@
+"""
+Scenario Bundles for ChefBench
+Portable scenarios as directories: a scenario.yaml, optional seed data, and assertions on the outcome
+"""
+
+import operator
+import os
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Callable, Dict, List, Optional, Tuple, Any
+import logging
+
+from models.models import TaskType
+from kitchen.seeds import SeedProfile, SeedTarget
+
+logger = logging.getLogger(__name__)
+
+BUNDLE_FILE = "scenario.yaml"
+DEFAULT_SCENARIOS_DIR = "scenarios"
+
+BUNDLE_NAME = re.compile(r"^[a-z0-9][a-z0-9_-]*$")
+
+# Run parameters a bundle may default; a request still overrides any it sets itself
+BUNDLE_DEFAULTS = (
+    "num_tasks", "duration_seconds", "assignment_policy", "scoring_profile",
+    "dietary_restrictions", "quality_rubric", "simulate_equipment", "simulate_guests",
+    "plan_pacing", "expeditor"
+)
+
+ASSERTION_OPS: Dict[str, Callable[[Any, Any], bool]] = {
+    ">=": operator.ge,
+    "<=": operator.le,
+    ">": operator.gt,
+    "<": operator.lt,
+    "==": operator.eq,
+    "!=": operator.ne,
+}
+
+# Seeder options naming a file, resolved against the bundle directory
+PATH_OPTIONS = ("path",)
+
+
+@dataclass
+class Assertion:
+    """An expected outcome: a dotted path into the run's results compared against a value"""
+    metric: str
+    op: str
+    value: Any
+    description: str = ""
+
+    def __post_init__(self):
+        if self.op not in ASSERTION_OPS:
+            raise ValueError(f"Unknown operator '{self.op}', expected one of {list(ASSERTION_OPS)}")
+
+    def check(self, result: Dict[str, Any]) -> Dict[str, Any]:
+        actual: Any = result
+        for key in self.metric.split("."):
+            actual = actual.get(key) if isinstance(actual, dict) else None
+        try:
+            passed = actual is not None and ASSERTION_OPS[self.op](actual, self.value)
+        except TypeError:
+            passed = False
+        return {
+            "metric": self.metric,
+            "op": self.op,
+            "expected": self.value,
+            "actual": actual,
+            "passed": passed,
+            "description": self.description
+        }
+
+
+@dataclass
+class ScenarioBundle:
+    """One scenario directory: task mix, run defaults, seed data and expected outcomes"""
+    name: str
+    description: str
+    path: Path
+    tasks: List[Tuple[TaskType, int]]
+    defaults: Dict[str, Any] = field(default_factory=dict)
+    ingredients: Optional[List[str]] = None
+    context: Dict[str, Any] = field(default_factory=dict)
+    seed: Optional[SeedProfile] = None
+    assertions: List[Assertion] = field(default_factory=list)
+    version: str = "1"
+
+    def apply_seed(self, target: SeedTarget) -> Dict[str, int]:
+        return self.seed.apply(target) if self.seed else {}
+
+    def check(self, result: Dict[str, Any]) -> Dict[str, Any]:
+        """Evaluate every assertion against a completed run's results"""
+        checks = [a.check(result) for a in self.assertions]
+        return {
+            "bundle": self.name,
+            "passed": all(c["passed"] for c in checks),
+            "total": len(checks),
+            "failed": sum(1 for c in checks if not c["passed"]),
+            "checks": checks
+        }
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "name": self.name,
+            "description": self.description,
+            "version": self.version,
+            "path": str(self.path),
+            "tasks": [{"type": t.name, "weight": w} for t, w in self.tasks],
+            "defaults": self.defaults,
+            "ingredients": self.ingredients,
+            "seed": self.seed.to_dict()["steps"] if self.seed else [],
+            "expect": [
+                {"metric": a.metric, "op": a.op, "value": a.value, "description": a.description}
+                for a in self.assertions
+            ]
+        }
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any], path: Path) -> "ScenarioBundle":
+        """Validate a parsed scenario.yaml; raises ValueError naming the first problem"""
+        if not isinstance(data, dict):
+            raise ValueError(f"{BUNDLE_FILE} must be a mapping")
+        unknown = set(data) - {"name", "description", "version", "tasks", "defaults", "ingredients",
+                               "context", "seed", "expect"}
+        if unknown:
+            raise ValueError(f"Unknown fields: {', '.join(sorted(unknown))}")
+
+        name = str(data.get("name") or path.name)
+        if not BUNDLE_NAME.match(name):
+            raise ValueError(f"Name '{name}' must be lowercase letters, digits, '-' and '_'")
+
+        tasks = []
+        for entry in data.get("tasks") or []:
+            try:
+                task_type = TaskType[str(entry["type"]).upper()]
+            except KeyError:
+                raise ValueError(f"Unknown task type in {entry!r}, expected one of {[t.name for t in TaskType]}")
+            weight = int(entry.get("weight", 1))
+            if weight < 1:
+                raise ValueError(f"Weight of {task_type.name} must be at least 1")
+            tasks.append((task_type, weight))
+        if not tasks:
+            raise ValueError("A bundle needs at least one entry under 'tasks'")
+
+        defaults = data.get("defaults") or {}
+        unknown = set(defaults) - set(BUNDLE_DEFAULTS)
+        if unknown:
+            raise ValueError(f"Unknown defaults: {', '.join(sorted(unknown))}, expected some of {list(BUNDLE_DEFAULTS)}")
+
+        seed = None
+        if data.get("seed"):
+            steps = []
+            for step in data["seed"]:
+                options = {k: v for k, v in step.items() if k != "seeder"}
+                for key in PATH_OPTIONS:
+                    if key in options:
+                        options[key] = str(path / options[key])
+                steps.append((step.get("seeder"), options))
+            seed = SeedProfile(f"bundle:{name}", steps, f"Seed data for the {name} scenario")
+
+        assertions = [
+            Assertion(str(a["metric"]), str(a.get("op", ">=")), a["value"], a.get("description", ""))
+            for a in data.get("expect") or []
+        ]
+
+        return cls(
+            name=name,
+            description=str(data.get("description", "")),
+            path=path,
+            tasks=tasks,
+            defaults=dict(defaults),
+            ingredients=list(data["ingredients"]) if data.get("ingredients") else None,
+            context=dict(data.get("context") or {}),
+            seed=seed,
+            assertions=assertions,
+            version=str(data.get("version", "1"))
+        )
+
+
+def load_bundle(path: Path) -> ScenarioBundle:
+    """Load and validate the bundle in one directory"""
+    try:
+        import yaml
+    except ImportError:
+        raise ValueError("PyYAML is required for scenario bundles (pip install pyyaml)")
+    with open(path / BUNDLE_FILE, 'r', encoding='utf-8') as f:
+        try:
+            data = yaml.safe_load(f)
+        except yaml.YAMLError as e:
+            raise ValueError(f"Invalid YAML: {e}")
+    try:
+        return ScenarioBundle.from_dict(data, path)
+    except (KeyError, TypeError, AttributeError) as e:
+        raise ValueError(f"Malformed {BUNDLE_FILE}: {e!r}")
+
+
+class ScenarioLibrary:
+    """The bundles under a scenarios directory, rescanned whenever a scenario.yaml changes
+
+    Bundles that fail validation are skipped and reported in errors rather than
+    stopping the others loading. Names of built-in scenario types are reserved.
+    """
+
+    def __init__(self, root: Optional[str] = None, reserved: Tuple[str, ...] = ()):
+        self.root = Path(root or os.environ.get("CHEFBENCH_SCENARIOS_DIR", DEFAULT_SCENARIOS_DIR))
+        self.reserved = set(reserved)
+        self.bundles: Dict[str, ScenarioBundle] = {}
+        self.errors: Dict[str, str] = {}
+        self._signature: Optional[Tuple] = None
+        self.refresh()
+
+    def _scan(self) -> List[Path]:
+        if not self.root.is_dir():
+            return []
+        return sorted(p for p in self.root.iterdir() if (p / BUNDLE_FILE).is_file())
+
+    def refresh(self, force: bool = False) -> bool:
+        """Reload if any bundle was added, removed or edited; returns whether it reloaded"""
+        paths = self._scan()
+        signature = tuple(
+            (str(p), max(f.stat().st_mtime for f in p.iterdir() if f.is_file()))
+            for p in paths
+        )
+        if not force and signature == self._signature:
+            return False
+
+        self._signature = signature
+        self.bundles, self.errors = {}, {}
+        for path in paths:
+            try:
+                bundle = load_bundle(path)
+                if bundle.name in self.reserved:
+                    raise ValueError(f"'{bundle.name}' is a built-in scenario type")
+                if bundle.name in self.bundles:
+                    raise ValueError(f"'{bundle.name}' is already defined by {self.bundles[bundle.name].path}")
+            except (OSError, ValueError) as e:
+                self.errors[path.name] = str(e)
+                logger.warning(f"Skipping scenario bundle {path}: {e}")
+                continue
+            self.bundles[bundle.name] = bundle
+
+        logger.info(f"Loaded {len(self.bundles)} scenario bundles from {self.root}")
+        return True
+
+    def get(self, name: str) -> Optional[ScenarioBundle]:
+        self.refresh()
+        return self.bundles.get(name)
+
+    def list(self) -> List[ScenarioBundle]:
+        self.refresh()
+        return list(self.bundles.values())
@@ -0,0 +1,125 @@
+"""
+Order Costing and Menu Engineering for ChefBench
+What each order cost to plate and brought in, and the menu sorted by popularity and margin into stars, plowhorses,
+puzzles and dogs
+"""
+
+from typing import Dict, Iterable, List, Optional, Any
+
+from kitchen.menu import Menu, list_price
+from metrics.daily import plate_cost
+
+# High or low popularity, high or low margin
+MENU_CLASSES = {
+    (True, True): "star",
+    (True, False): "plowhorse",
+    (False, True): "puzzle",
+    (False, False): "dog"
+}
+
+# An item is popular once it sells this share of what an even split of the sales would give it
+POPULARITY_FACTOR = 0.7
+
+
+def cost_ticket(ticket: Dict[str, Any], served: Iterable[str], catalog, menu: Menu) -> Dict[str, Any]:
+    """An order_ticket's food cost and revenue, line by line
+
+    Each portion costs its line's ingredients at the catalog's cost per unit,
+    cooked or not, and only served portions are sold, at the menu price.
+    Dishes off the menu sell at their list price.
+    """
+    served = set(served)
+    lines = []
+    for line in ticket["lines"]:
+        portions = [p for p in line["portions"] if p["state"] != "cancelled"]
+        cost = plate_cost(line["ingredients"], catalog)
+        price = menu.price(line["recipe_id"], cost) if line["recipe_id"] is not None else list_price(cost)
+        sold = sum(1 for p in portions if p["task_id"] in served)
+        lines.append({
+            "line": line["line"],
+            "recipe_id": line["recipe_id"],
+            "dish": line["dish"],
+            "plate_cost": round(cost, 2),
+            "price": price,
+            "portions": len(portions),
+            "sold": sold,
+            "food_cost": round(cost * len(portions), 2),
+            "revenue": round(price * sold, 2)
+        })
+    food_cost = sum(line["food_cost"] for line in lines)
+    revenue = sum(line["revenue"] for line in lines)
+    return {
+        "ticket": ticket["ticket"],
+        "table": ticket["table"],
+        "lines": lines,
+        "food_cost": round(food_cost, 2),
+        "revenue": round(revenue, 2),
+        "margin": round(revenue - food_cost, 2),
+        "food_cost_pct": round(food_cost / revenue, 4) if revenue else None
+    }
+
+
+def item_sales(costed: List[Dict[str, Any]]) -> Dict[int, Dict[str, Any]]:
+    """Recipe id -> portions sold, their food cost and revenue, over costed tickets; dishes off the menu are left out"""
+    sales: Dict[int, Dict[str, Any]] = {}
+    for ticket in costed:
+        for line in ticket["lines"]:
+            if line["recipe_id"] is None or not line["sold"]:
+                continue
+            item = sales.setdefault(line["recipe_id"], {"sold": 0, "food_cost": 0.0, "revenue": 0.0})
+            item["sold"] += line["sold"]
+            item["food_cost"] += line["plate_cost"] * line["sold"]
+            item["revenue"] += line["revenue"]
+    return sales
+
+
+def menu_engineering(
+    recipes: List[Dict[str, Any]],
+    sales: Dict[int, Dict[str, Any]],
+    prices: Dict[int, float],
+    costs: Dict[int, float]
+) -> Dict[str, Any]:
+    """Classify menu items by popularity and contribution margin
+
+    recipes are the items to rank, each with its id and dish; sales are what
+    each has sold, as item_sales gives them; prices and costs are the current
+    price and plate cost of each. An item sold at least POPULARITY_FACTOR of
+    an even share of the sales is popular; one whose margin per portion is at
+    least the sales-weighted average is profitable. An item that hasn't sold
+    is margined at its current price and cost. Until anything sells, no item
+    is classified.
+    """
+    total_sold = sum(sales.get(r["recipe_id"], {}).get("sold", 0) for r in recipes)
+    items = []
+    for recipe in recipes:
+        recipe_id = recipe["recipe_id"]
+        sold = sales.get(recipe_id, {}).get("sold", 0)
+        if sold:
+            margin = (sales[recipe_id]["revenue"] - sales[recipe_id]["food_cost"]) / sold
+        else:
+            margin = prices[recipe_id] - costs[recipe_id]
+        items.append({
+            "recipe_id": recipe_id,
+            "dish": recipe["dish"],
+            "sold": sold,
+            "mix_share": round(sold / total_sold, 4) if total_sold else 0.0,
+            "price": prices[recipe_id],
+            "plate_cost": round(costs[recipe_id], 2),
+            "margin": round(margin, 2),
+            "class": None
+        })
+    popularity_threshold = POPULARITY_FACTOR / len(items) if items else None
+    average_margin: Optional[float] = None
+    if total_sold:
+        average_margin = sum(item["margin"] * item["sold"] for item in items) / total_sold
+        for item in items:
+            item["class"] = MENU_CLASSES[(item["mix_share"] >= popularity_threshold, item["margin"] >= average_margin)]
+    order = list(MENU_CLASSES.values())
+    items.sort(key=lambda i: (order.index(i["class"]) if i["class"] else len(order), -i["sold"], i["recipe_id"]))
+    return {
+        "total_sold": total_sold,
+        "popularity_threshold": round(popularity_threshold, 4) if popularity_threshold is not None else None,
+        "average_margin": round(average_margin, 2) if average_margin is not None else None,
+        "classes": {name: [i["recipe_id"] for i in items if i["class"] == name] for name in order},
+        "items": items
+    }
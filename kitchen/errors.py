@@ -0,0 +1,93 @@
+"""
+Error Envelope for ChefBench
+Every error response has the same shape: {"code", "message", "details"}
+"""
+
+import sqlite3
+from typing import Any, Optional
+import logging
+
+from fastapi import FastAPI, Request
+from fastapi.exceptions import RequestValidationError
+from fastapi.responses import JSONResponse
+from starlette.exceptions import HTTPException as StarletteHTTPException
+
+logger = logging.getLogger(__name__)
+
+ERROR_CODES = {
+    400: "bad_request",
+    401: "unauthorized",
+    403: "forbidden",
+    404: "not_found",
+    405: "method_not_allowed",
+    409: "conflict",
+    422: "validation_error",
+    429: "rate_limited",
+    500: "internal_error",
+    502: "bad_gateway",
+    503: "unavailable",
+    504: "timeout",
+}
+
+
+def error_code(status_code: int) -> str:
+    return ERROR_CODES.get(status_code, "client_error" if status_code < 500 else "server_error")
+
+
+def error_response(
+    status_code: int,
+    message: str,
+    details: Any = None,
+    headers: Optional[dict] = None
+) -> JSONResponse:
+    """Build an error response in the shared envelope"""
+    return JSONResponse(
+        status_code=status_code,
+        content={"code": error_code(status_code), "message": message, "details": details},
+        headers=headers
+    )
+
+
+async def http_error_handler(request: Request, exc: StarletteHTTPException) -> JSONResponse:
+    """HTTPException raised by a route; structured details pass through as details"""
+    if isinstance(exc.detail, str):
+        return error_response(exc.status_code, exc.detail, headers=exc.headers)
+    return error_response(exc.status_code, error_code(exc.status_code).replace("_", " "), exc.detail, exc.headers)
+
+
+async def validation_error_handler(request: Request, exc: RequestValidationError) -> JSONResponse:
+    """Request body, path or query failing schema validation: one entry per invalid field"""
+    details = [
+        {
+            "field": ".".join(str(part) for part in error["loc"] if part != "body"),
+            "message": error["msg"],
+            "type": error["type"]
+        }
+        for error in exc.errors()
+    ]
+    fields = ", ".join(d["field"] for d in details if d["field"])
+    return error_response(422, f"Invalid request{': ' + fields if fields else ''}", details)
+
+
+async def database_error_handler(request: Request, exc: sqlite3.Error) -> JSONResponse:
+    """Store errors: constraint violations are conflicts, a locked database is temporarily unavailable"""
+    logger.error(f"Database error on {request.method} {request.url.path}: {exc}")
+    if isinstance(exc, sqlite3.IntegrityError):
+        return error_response(409, "Conflicts with existing data")
+    if isinstance(exc, sqlite3.OperationalError) and "locked" in str(exc):
+        return error_response(503, "Database busy, retry shortly", headers={"Retry-After": "1"})
+    return error_response(500, "Database error")
+
+
+async def unhandled_error_handler(request: Request, exc: Exception) -> JSONResponse:
+    """Anything else: log it, never leak internals to the caller"""
+    logger.exception(f"Unhandled error on {request.method} {request.url.path}: {exc}")
+    return error_response(500, "Internal server error")
+
+
+def install_error_handlers(app: FastAPI):
+    """Route every error a request can raise through the envelope"""
+    app.add_exception_handler(StarletteHTTPException, http_error_handler)
+    app.add_exception_handler(RequestValidationError, validation_error_handler)
+    app.add_exception_handler(sqlite3.Error, database_error_handler)
+    app.add_exception_handler(Exception, unhandled_error_handler)
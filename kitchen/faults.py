@@ -0,0 +1,90 @@
+"""
+Fault Injection for ChefBench
+Admin-controlled latency, error and dropped-stream faults for client resilience testing
+"""
+
+import asyncio
+import random
+from dataclasses import dataclass, asdict, field
+from typing import Dict, List, Optional, Any
+import logging
+
+from fastapi import Request
+
+from kitchen.errors import error_response
+
+logger = logging.getLogger(__name__)
+
+# Never inject faults here, otherwise faults could not be switched off again
+EXEMPT_PREFIXES = ("/admin", "/docs", "/openapi.json", "/redoc")
+
+
+@dataclass
+class FaultConfig:
+    """Active fault settings"""
+    enabled: bool = False
+    latency_ms: int = 0
+    latency_jitter_ms: int = 0
+    error_rate: float = 0.0
+    error_status: int = 500
+    stream_drop_rate: float = 0.0
+    paths: List[str] = field(default_factory=list)  # Route prefixes to target; empty means all
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class FaultInjector:
+    """Applies the current FaultConfig to requests and streamed events"""
+
+    def __init__(self, seed: Optional[int] = None):
+        self.config = FaultConfig()
+        self.rng = random.Random(seed)
+        self.stats = {"delayed": 0, "errors": 0, "dropped_frames": 0}
+
+    def configure(self, **settings) -> FaultConfig:
+        """Update fault settings, keeping unspecified ones"""
+        current = self.config.to_dict()
+        current.update({k: v for k, v in settings.items() if v is not None})
+        self.config = FaultConfig(**current)
+        logger.warning(f"Fault injection updated: {self.config.to_dict()}")
+        return self.config
+
+    def clear(self):
+        """Disable all faults and reset counters"""
+        self.config = FaultConfig()
+        self.stats = {"delayed": 0, "errors": 0, "dropped_frames": 0}
+        logger.info("Fault injection cleared")
+
+    def applies_to(self, path: str) -> bool:
+        if not self.config.enabled or path.startswith(EXEMPT_PREFIXES):
+            return False
+        return not self.config.paths or any(path.startswith(p) for p in self.config.paths)
+
+    def should_drop_frame(self, path: str) -> bool:
+        """Decide whether to silently drop one streamed event"""
+        if self.applies_to(path) and self.rng.random() < self.config.stream_drop_rate:
+            self.stats["dropped_frames"] += 1
+            return True
+        return False
+
+    async def middleware(self, request: Request, call_next):
+        """HTTP middleware adding latency and synthetic errors"""
+        path = request.url.path
+        if not self.applies_to(path):
+            return await call_next(request)
+
+        config = self.config
+        delay_ms = config.latency_ms + self.rng.uniform(0, config.latency_jitter_ms)
+        if delay_ms > 0:
+            self.stats["delayed"] += 1
+            await asyncio.sleep(delay_ms / 1000)
+
+        if self.rng.random() < config.error_rate:
+            self.stats["errors"] += 1
+            return error_response(config.error_status, "Injected fault", headers={"X-Fault-Injected": "true"})
+
+        return await call_next(request)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"config": self.config.to_dict(), "stats": dict(self.stats)}
@@ -0,0 +1,247 @@
+"""
+Golden-Run Regression for ChefBench
+Replays a recorded run with its cached model responses and checks the metrics stay within tolerance of the recording
+"""
+
+import copy
+import hashlib
+import json
+import time
+from dataclasses import dataclass, field, asdict
+from pathlib import Path
+from typing import Dict, List, Optional, Any
+import logging
+
+from models.models import AgentRole, TaskType, GenerationError, MOCK_MODEL
+from providers import MultiAgentCoordinator, get_quality_rubric
+from metrics.scoring import score_run
+
+logger = logging.getLogger(__name__)
+
+GOLDEN_VERSION = 1
+GOLDEN_DIR = "data/golden"
+DEFAULT_TOLERANCE = 0.05
+
+# Wall-clock measurements that differ between any two runs, so they aren't compared
+VOLATILE_METRICS = ("average_reasoning_time",)
+
+
+def prompt_hash(prompt: str) -> str:
+    return hashlib.sha256(prompt.encode("utf-8")).hexdigest()[:16]
+
+
+class ResponseCache:
+    """Recorded model responses, handed back to the agents that asked for them
+
+    Responses match by agent and prompt. When an agent's prompt has changed
+    since the recording, it gets its next unused response in recorded order
+    instead, counted as drift. An agent with nothing left misses, which raises
+    GenerationError so its fallback policy decides. The model is never called.
+    """
+
+    def __init__(self, responses: List[Dict[str, Any]]):
+        self.by_agent: Dict[str, List[Dict[str, Any]]] = {}
+        for response in responses:
+            self.by_agent.setdefault(response["agent"], []).append({**response, "used": False})
+        self.hits = 0
+        self.drift = 0
+        self.misses = 0
+
+    def respond(self, agent_name: str, prompt: str) -> str:
+        unused = [r for r in self.by_agent.get(agent_name, []) if not r["used"]]
+        digest = prompt_hash(prompt)
+        match = next((r for r in unused if r["prompt_hash"] == digest), None)
+        if match:
+            self.hits += 1
+        elif unused:
+            match = unused[0]
+            self.drift += 1
+        else:
+            self.misses += 1
+            raise GenerationError(f"No recorded response left for {agent_name}")
+        match["used"] = True
+        return match["response"]
+
+    def stats(self) -> Dict[str, int]:
+        return {
+            "hits": self.hits,
+            "drift": self.drift,
+            "misses": self.misses,
+            "unused": sum(1 for rs in self.by_agent.values() for r in rs if not r["used"])
+        }
+
+
+def extract_metrics(result: Dict[str, Any]) -> Dict[str, float]:
+    """The comparable numbers of a run's results, flattened to dotted names"""
+    metrics = {"tasks_completed": float(result.get("tasks_completed", 0))}
+    for name, value in result.get("agent_metrics", {}).get("team", {}).items():
+        if isinstance(value, (int, float)) and not isinstance(value, bool) and name not in VOLATILE_METRICS:
+            metrics[f"team.{name}"] = float(value)
+    scores = result.get("scores") or {}
+    if scores.get("score") is not None:
+        metrics["scores.score"] = float(scores["score"])
+    for name, value in (scores.get("dimensions") or {}).items():
+        metrics[f"scores.{name}"] = float(value)
+    return metrics
+
+
+def compare_metrics(
+    expected: Dict[str, float],
+    actual: Dict[str, float],
+    tolerances: Optional[Dict[str, float]] = None,
+    default_tolerance: float = DEFAULT_TOLERANCE
+) -> List[Dict[str, Any]]:
+    """Check each golden metric; one the replay no longer produces fails"""
+    checks = []
+    for metric, value in sorted(expected.items()):
+        tolerance = (tolerances or {}).get(metric, default_tolerance)
+        got = actual.get(metric)
+        delta = None if got is None else round(got - value, 6)
+        checks.append({
+            "metric": metric,
+            "expected": value,
+            "actual": got,
+            "delta": delta,
+            "tolerance": tolerance,
+            "passed": delta is not None and abs(delta) <= tolerance
+        })
+    return checks
+
+
+@dataclass
+class GoldenRun:
+    """A recorded run: its team, tasks, settings, model responses and resulting metrics"""
+    name: str
+    team: List[Dict[str, Any]]
+    tasks: List[Dict[str, Any]]
+    config: Dict[str, Any]
+    responses: List[Dict[str, Any]]
+    metrics: Dict[str, float]
+    tolerances: Dict[str, float] = field(default_factory=dict)
+    default_tolerance: float = DEFAULT_TOLERANCE
+    recorded_from: Optional[str] = None
+    recorded_at: float = field(default_factory=time.time)
+    version: int = GOLDEN_VERSION
+
+    @classmethod
+    def record(
+        cls,
+        name: str,
+        evaluation: Dict[str, Any],
+        transcripts: List[Dict[str, Any]]
+    ) -> "GoldenRun":
+        """Build a golden run from a completed evaluation and its transcripts
+
+        Raises ValueError if the evaluation isn't completed or its tasks weren't kept.
+        """
+        if evaluation.get("status") != "completed":
+            raise ValueError(f"Evaluation is {evaluation.get('status')}, not completed")
+        if not evaluation.get("tasks"):
+            raise ValueError("The evaluation's tasks weren't kept (it was resumed from a checkpoint)")
+
+        result = evaluation["result"]
+        models = {t["agent_name"]: t["model_name"] for t in transcripts}
+        team = [
+            {"name": agent, "role": metrics["role"], "model_name": models.get(agent)}
+            for agent, metrics in result["agent_metrics"]["agents"].items()
+        ]
+        config = evaluation["config"]
+        return cls(
+            name=name,
+            team=team,
+            tasks=copy.deepcopy(evaluation["tasks"]),
+            config={
+                "seed": evaluation["seed"],
+                "duration_seconds": config["duration_seconds"],
+                "assignment_policy": config["assignment_policy"],
+                "scoring_profile": config.get("scoring_profile", "balanced"),
+                "quality_rubric": config.get("quality_rubric", "default"),
+                "prompt_overrides": config.get("prompt_overrides") or {},
+                "simulate_equipment": bool(config.get("simulate_equipment")),
+                "simulate_guests": bool(config.get("simulate_guests")),
+                "plan_pacing": bool(config.get("plan_pacing")),
+                "expeditor": bool(config.get("expeditor")),
+                "scenario_type": config.get("scenario_type")
+            },
+            responses=[
+                {"agent": t["agent_name"], "prompt_hash": prompt_hash(t["prompt"]), "response": t["response"]}
+                for t in transcripts
+            ],
+            metrics=extract_metrics(result),
+            recorded_from=evaluation.get("id")
+        )
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "GoldenRun":
+        if data.get("version", GOLDEN_VERSION) > GOLDEN_VERSION:
+            raise ValueError(f"Golden run version {data['version']} is newer than this build understands")
+        return cls(**data)
+
+    def save(self, path: str) -> Path:
+        target = Path(path)
+        target.parent.mkdir(parents=True, exist_ok=True)
+        with open(target, 'w') as f:
+            json.dump(self.to_dict(), f, indent=2, default=str)
+        return target
+
+    @classmethod
+    def load(cls, path: str) -> "GoldenRun":
+        with open(path, 'r', encoding='utf-8') as f:
+            return cls.from_dict(json.load(f))
+
+    async def verify(self, default_tolerance: Optional[float] = None) -> Dict[str, Any]:
+        """Replay the run on a fresh kitchen against the cached responses and compare metrics
+
+        Agents are built on the mock model and answer only from the cache, so no
+        model is loaded or called. A miss falls back to the heuristic answer.
+        """
+        cache = ResponseCache(self.responses)
+        coordinator = MultiAgentCoordinator()
+        for spec in self.team:
+            agent = coordinator.create_agent(spec["name"], AgentRole[spec["role"]], MOCK_MODEL, "heuristic")
+            agent.model_name = spec.get("model_name") or MOCK_MODEL
+            agent.response_cache = cache
+
+        config = self.config
+        coordinator.set_assignment_policy(config["assignment_policy"])
+        coordinator.set_seed(config["seed"])
+        coordinator.set_rubric(get_quality_rubric(config.get("quality_rubric", "default")))
+        coordinator.set_prompts(config.get("prompt_overrides"))
+        if config.get("simulate_equipment"):
+            coordinator.enable_equipment()
+        if config.get("simulate_guests"):
+            coordinator.enable_guests()
+        if config.get("plan_pacing"):
+            coordinator.enable_pacing()
+        if config.get("expeditor"):
+            coordinator.enable_expeditor()
+
+        tasks = [
+            (TaskType.from_function_name(t["task_type"]), copy.deepcopy(t["context"]))
+            for t in self.tasks
+        ]
+        started = time.time()
+        result = await coordinator.execute_scenario(tasks, config["duration_seconds"], run_id=f"golden-{self.name}")
+        result["scores"] = score_run(result, config["duration_seconds"], config.get("scoring_profile", "balanced"))
+
+        checks = compare_metrics(
+            self.metrics,
+            extract_metrics(result),
+            self.tolerances,
+            self.default_tolerance if default_tolerance is None else default_tolerance
+        )
+        failed = [c for c in checks if not c["passed"]]
+        report = {
+            "name": self.name,
+            "passed": not failed,
+            "total": len(checks),
+            "failed": len(failed),
+            "checks": checks,
+            "responses": cache.stats(),
+            "replay_seconds": round(time.time() - started, 3)
+        }
+        logger.info(f"Golden run {self.name}: {len(checks) - len(failed)}/{len(checks)} metrics within tolerance")
+        return report
@@ -0,0 +1,115 @@
+"""
+Health Checks for ChefBench
+Liveness and per-dependency readiness probes for orchestration and CLI fallback logic
+"""
+
+import asyncio
+import os
+import tempfile
+import time
+from typing import Callable, Dict, List, Optional, Any
+import logging
+
+from models.models import MOCK_MODEL
+
+logger = logging.getLogger(__name__)
+
+OK = "ok"
+DEGRADED = "degraded"
+DOWN = "down"
+
+
+def check_event_store(event_store) -> Dict[str, Any]:
+    """Run a trivial query against the event database"""
+    with event_store._lock:
+        event_store.connection.execute("SELECT 1").fetchone()
+    return {"status": OK, "path": str(event_store.db_path)}
+
+
+def check_llm_agents(agents: Dict[str, Any]) -> Dict[str, Any]:
+    """Tokenize a probe string with each agent's model; fallbacks count as degraded, mock-model agents don't"""
+    if not agents:
+        return {"status": OK, "agents": 0, "detail": "no agents created"}
+
+    loaded, mocked = [], []
+    for name, agent in agents.items():
+        if agent.model_name == MOCK_MODEL:
+            continue
+        if agent.model is None or agent.tokenizer is None:
+            mocked.append(name)
+            continue
+        agent.tokenizer("ok")
+        loaded.append(name)
+
+    return {
+        "status": OK if not mocked else DEGRADED,
+        "agents": len(agents),
+        "loaded": loaded,
+        "mock_fallback": mocked
+    }
+
+
+def check_dataset(dataset_parser) -> Dict[str, Any]:
+    """Report whether a recipe dataset is loaded; scenarios fall back to templates without one"""
+    if not dataset_parser.loaded:
+        return {"status": DEGRADED, "detail": "dataset not loaded, using template tasks"}
+    return {"status": OK, "recipes": len(dataset_parser.recipes)}
+
+
+def check_writable(directory) -> Dict[str, Any]:
+    """Verify a results directory accepts new files"""
+    with tempfile.NamedTemporaryFile(dir=directory, prefix=".healthz-"):
+        pass
+    return {"status": OK, "path": str(directory)}
+
+
+class HealthChecker:
+    """Runs named dependency checks with a per-check timeout"""
+
+    def __init__(self, timeout: float = 2.0):
+        self.timeout = timeout
+        self.started_at = time.time()
+        self.checks: Dict[str, Callable[[], Dict[str, Any]]] = {}
+        self.required: List[str] = []
+
+    def register(self, name: str, check: Callable[[], Dict[str, Any]], required: bool = True):
+        """Add a dependency check; failing required checks make the service unready"""
+        self.checks[name] = check
+        if required:
+            self.required.append(name)
+
+    def liveness(self) -> Dict[str, Any]:
+        return {
+            "status": OK,
+            "pid": os.getpid(),
+            "uptime_seconds": round(time.time() - self.started_at, 1)
+        }
+
+    async def _run(self, name: str, check: Callable[[], Dict[str, Any]]) -> Dict[str, Any]:
+        start = time.time()
+        try:
+            result = await asyncio.wait_for(asyncio.to_thread(check), self.timeout)
+        except asyncio.TimeoutError:
+            result = {"status": DOWN, "error": f"timed out after {self.timeout}s"}
+        except Exception as e:
+            logger.warning(f"Health check {name} failed: {e}")
+            result = {"status": DOWN, "error": str(e)}
+
+        result["latency_ms"] = round((time.time() - start) * 1000, 1)
+        result["required"] = name in self.required
+        return result
+
+    async def readiness(self, names: Optional[List[str]] = None) -> Dict[str, Any]:
+        """Run all (or the named) checks concurrently and summarize"""
+        selected = {n: c for n, c in self.checks.items() if names is None or n in names}
+        results = await asyncio.gather(*(self._run(n, c) for n, c in selected.items()))
+        dependencies = dict(zip(selected, results))
+
+        if any(d["status"] == DOWN and d["required"] for d in dependencies.values()):
+            status = DOWN
+        elif any(d["status"] != OK for d in dependencies.values()):
+            status = DEGRADED
+        else:
+            status = OK
+
+        return {"status": status, "ready": status != DOWN, "dependencies": dependencies}
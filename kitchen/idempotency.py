@@ -0,0 +1,124 @@
+"""
+Idempotency Keys for ChefBench
+Replays the stored response when a POST or PATCH is retried with the same Idempotency-Key
+"""
+
+import asyncio
+import hashlib
+import time
+from collections import OrderedDict
+from dataclasses import dataclass, field
+from typing import Dict, Optional, Tuple, Any
+import logging
+
+from fastapi import Request
+from fastapi.responses import Response
+
+from kitchen.errors import error_response
+from kitchen.sandbox import SESSION_HEADER, DEFAULT_SESSION
+
+logger = logging.getLogger(__name__)
+
+IDEMPOTENCY_HEADER = "Idempotency-Key"
+REPLAYED_HEADER = "Idempotency-Replayed"
+
+MAX_KEY_LENGTH = 255
+
+# Requests that change state and would repeat the change if run twice
+KEYED_METHODS = ("POST", "PATCH")
+
+# Refusals that depend on the kitchen at the time (no run executing, too busy):
+# nothing changed, and the same request retried later may be let in
+TRANSIENT_STATUSES = (409, 429)
+
+
+@dataclass
+class StoredResponse:
+    """The outcome of the first request made with a key"""
+    fingerprint: str
+    created_at: float = field(default_factory=time.time)
+    done: asyncio.Event = field(default_factory=asyncio.Event)
+    status_code: Optional[int] = None
+    body: bytes = b""
+    headers: Dict[str, str] = field(default_factory=dict)
+
+
+class IdempotencyStore:
+    """Remembers POST and PATCH responses by session and Idempotency-Key
+
+    A retry with the same key and request gets the stored response instead of
+    running again; one that arrives while the first is still in flight waits
+    for it. Reusing a key for a different request is rejected. 5xx responses
+    and the transient refusals (409, 429) aren't stored, so a retry after a
+    server error, or after waiting out Retry-After, runs the request again.
+    """
+
+    def __init__(self, ttl_seconds: float = 86400, max_entries: int = 10000):
+        self.ttl_seconds = ttl_seconds
+        self.max_entries = max_entries
+        self.entries: "OrderedDict[Tuple[str, str], StoredResponse]" = OrderedDict()
+        self.stats = {"stored": 0, "replayed": 0, "mismatched": 0}
+
+    @staticmethod
+    def fingerprint(request: Request, body: bytes) -> str:
+        digest = hashlib.sha256(f"{request.method} {request.url.path}?{request.url.query}\n".encode())
+        digest.update(body)
+        return digest.hexdigest()
+
+    def expire(self, now: Optional[float] = None):
+        """Drop entries past their TTL, and the oldest ones beyond max_entries"""
+        now = now or time.time()
+        while self.entries:
+            key, entry = next(iter(self.entries.items()))
+            if now - entry.created_at <= self.ttl_seconds and len(self.entries) <= self.max_entries:
+                break
+            if not entry.done.is_set():
+                break
+            del self.entries[key]
+
+    async def middleware(self, request: Request, call_next):
+        """Deduplicate POSTs and PATCHes that carry an Idempotency-Key"""
+        key = request.headers.get(IDEMPOTENCY_HEADER)
+        if request.method not in KEYED_METHODS or key is None:
+            return await call_next(request)
+        if not key or len(key) > MAX_KEY_LENGTH:
+            return error_response(400, f"{IDEMPOTENCY_HEADER} must be 1-{MAX_KEY_LENGTH} characters")
+
+        self.expire()
+        scope = (request.headers.get(SESSION_HEADER, DEFAULT_SESSION), key)
+        fingerprint = self.fingerprint(request, await request.body())
+
+        while (entry := self.entries.get(scope)) is not None:
+            if entry.fingerprint != fingerprint:
+                self.stats["mismatched"] += 1
+                return error_response(422, f"{IDEMPOTENCY_HEADER} was already used for a different request")
+            await entry.done.wait()
+            if entry.status_code is not None:
+                self.stats["replayed"] += 1
+                logger.info(f"Replaying response for {IDEMPOTENCY_HEADER} {key}")
+                return Response(
+                    entry.body, status_code=entry.status_code, headers={**entry.headers, REPLAYED_HEADER: "true"}
+                )
+            # That attempt failed without storing a response; check whether another retry took over
+
+        entry = StoredResponse(fingerprint)
+        self.entries[scope] = entry
+        try:
+            response = await call_next(request)
+            if response.status_code >= 500 or response.status_code in TRANSIENT_STATUSES:
+                self.entries.pop(scope, None)
+                return response
+
+            body = b"".join([chunk async for chunk in response.body_iterator])
+            headers = {k: v for k, v in response.headers.items() if k.lower() != "content-length"}
+            entry.status_code, entry.body, entry.headers = response.status_code, body, headers
+            self.stats["stored"] += 1
+            return Response(body, status_code=response.status_code, headers=headers)
+        except BaseException:
+            self.entries.pop(scope, None)
+            raise
+        finally:
+            entry.done.set()
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"ttl_seconds": self.ttl_seconds, "entries": len(self.entries), "stats": dict(self.stats)}
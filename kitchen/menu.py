@@ -0,0 +1,227 @@
+"""
+Menu and 86-list for ChefBench
+The menu is the planned items, or every loaded recipe until one is planned; an item is 86'd when the kitchen
+can't make it, or someone takes it off
+"""
+
+from dataclasses import dataclass, field, asdict
+from typing import Dict, Iterable, List, Optional, Any
+import logging
+import math
+
+from database.menu_store import MenuStore
+from recipes.dataset_parser import RecipeDatasetParser
+from recipes.normalization import get_normalizer
+
+logger = logging.getLogger(__name__)
+
+# Share of a dish's price its ingredients cost, for items with no price of their own
+TARGET_FOOD_COST = 0.3
+MINIMUM_PRICE = 5.0
+
+
+def dish_name(recipe: Dict[str, Any]) -> str:
+    """What a menu item is called on tickets, having no name of its own in the dataset"""
+    return f"{recipe['cuisine']} recipe {recipe['id']}"
+
+
+def list_price(food_cost: float) -> float:
+    """What a dish costing food_cost to plate sells for at the target food cost, to the half dollar"""
+    return max(MINIMUM_PRICE, math.ceil(round(food_cost / TARGET_FOOD_COST * 2, 6)) / 2)
+
+
+@dataclass
+class MenuItem:
+    """A recipe on the planned menu, and how it's billed"""
+    recipe_id: int
+    specialty: bool = False  # a house specialty, listed by GET /menu/specialties
+    seasonal: bool = False  # on for the season, to come off when it ends
+    planned_by: Optional[str] = None  # who put it on: the planning agent, or None through the API
+    price: Optional[float] = None  # None sells it at its list price
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class EightySixed:
+    """A menu item off for now, and why"""
+    recipe_id: int
+    dish: str
+    missing: List[str] = field(default_factory=list)  # out of stock, with no substitute on hand
+    spoiled: List[str] = field(default_factory=list)  # of the missing, those gone because they spoiled
+    manual: Optional[str] = None  # reason given when it was 86'd by hand
+
+    @property
+    def reasons(self) -> List[str]:
+        reasons = []
+        if self.manual is not None:
+            reasons.append(self.manual or "86'd by hand")
+        if self.spoiled:
+            reasons.append(f"spoiled: {', '.join(self.spoiled)}")
+        out = [i for i in self.missing if i not in self.spoiled]
+        if out:
+            reasons.append(f"out of {', '.join(out)}")
+        return reasons
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {**asdict(self), "reasons": self.reasons}
+
+
+class Menu:
+    """The recipes the kitchen serves, checked against what it has to cook with
+
+    Until a menu is planned every loaded recipe is on it; once one is, only
+    the planned items are, and orders for anything else are refused. The
+    planned items and the items 86'd by hand are kept in the store, when
+    there is one, so they survive a restart.
+
+    An item is 86'd while an ingredient it needs is neither on hand nor
+    substitutable, spoiled stock not counting as on hand, or while it has been
+    86'd by hand. Ingredients are matched by their catalog id, so spoiling
+    "tomato" takes out dishes that call for "Tomatoes".
+    """
+
+    def __init__(self, dataset_parser: RecipeDatasetParser, store: Optional[MenuStore] = None):
+        self.dataset_parser = dataset_parser
+        self.store = store
+        self.planned: Dict[int, MenuItem] = {}  # recipe id -> item, empty until a menu is planned
+        self.manual: Dict[int, str] = {}  # recipe id -> reason, for items 86'd by hand
+        if store is not None:
+            self.planned = {item["recipe_id"]: MenuItem(
+                item["recipe_id"], item["specialty"], item["seasonal"], item["planned_by"], item["price"]
+            ) for item in store.items()}
+            self.manual = store.eighty_sixed()
+
+    def recipe(self, recipe_id: int) -> Optional[Dict[str, Any]]:
+        """A recipe on the menu, or None if it isn't loaded or isn't on the planned menu"""
+        if self.planned and recipe_id not in self.planned:
+            return None
+        return next((r for r in self.dataset_parser.recipes if r['id'] == recipe_id), None)
+
+    def recipes(self) -> List[Dict[str, Any]]:
+        """Every recipe on the menu, in dataset order"""
+        return [r for r in self.dataset_parser.recipes if not self.planned or r['id'] in self.planned]
+
+    def item(self, recipe_id: int) -> MenuItem:
+        """How a recipe on the menu is billed; unplanned recipes are plain items"""
+        return self.planned.get(recipe_id) or MenuItem(recipe_id)
+
+    def price(self, recipe_id: int, food_cost: float) -> float:
+        """What an item sells for: its own price, or the list price for what it costs to plate"""
+        price = self.item(recipe_id).price
+        return price if price is not None else list_price(food_cost)
+
+    def specialties(self) -> List[Dict[str, Any]]:
+        return [r for r in self.recipes() if self.item(r['id']).specialty]
+
+    def plan(self, items: List[MenuItem], planned_by: Optional[str] = None) -> List[MenuItem]:
+        """Make items the whole menu; raises KeyError naming any recipe that isn't loaded, ValueError if there are none
+
+        Items 86'd by hand that come off the menu are restored, so they are
+        back on if the recipe is planned again.
+        """
+        if not items:
+            raise ValueError("A menu needs at least one item")
+        loaded = {r['id'] for r in self.dataset_parser.recipes}
+        unknown = sorted({item.recipe_id for item in items} - loaded)
+        if unknown:
+            raise KeyError(f"Recipes {unknown} are not loaded")
+        planned = {
+            item.recipe_id: MenuItem(item.recipe_id, item.specialty, item.seasonal, planned_by, item.price)
+            for item in items
+        }
+        for recipe_id in [r for r in self.manual if r not in planned]:
+            self.restore(recipe_id)
+        self.planned = planned
+        if self.store is not None:
+            self.store.replace([item.to_dict() for item in planned.values()], planned_by)
+        logger.info(f"Menu planned with {len(planned)} items" + (f" by {planned_by}" if planned_by else ""))
+        return list(planned.values())
+
+    def update(
+        self,
+        recipe_id: int,
+        specialty: Optional[bool] = None,
+        seasonal: Optional[bool] = None,
+        planned_by: Optional[str] = None,
+        price: Optional[float] = None
+    ) -> MenuItem:
+        """Change how an item on the menu is billed or priced; raises KeyError if it's not on the menu
+
+        Flagging an item before a menu is planned plans every loaded recipe,
+        so the flag has somewhere to be kept.
+        """
+        if self.recipe(recipe_id) is None:
+            raise KeyError(f"Recipe {recipe_id} is not on the menu")
+        current = self.item(recipe_id)
+        item = MenuItem(
+            recipe_id,
+            current.specialty if specialty is None else specialty,
+            current.seasonal if seasonal is None else seasonal,
+            planned_by,
+            current.price if price is None else price
+        )
+        if self.planned:
+            self.planned[recipe_id] = item
+            if self.store is not None:
+                self.store.update(recipe_id, item.specialty, item.seasonal, planned_by, item.price)
+        else:
+            self.plan([self.item(r['id']) if r['id'] != recipe_id else item for r in self.recipes()], planned_by)
+        return self.planned[recipe_id]
+
+    def clear(self) -> bool:
+        """Go back to every loaded recipe being on the menu; False if none was planned"""
+        if not self.planned:
+            return False
+        self.planned = {}
+        if self.store is not None:
+            self.store.clear()
+        return True
+
+    def check(
+        self,
+        recipe: Dict[str, Any],
+        on_hand: Iterable[str],
+        spoiled: Iterable[str] = ()
+    ) -> Optional[EightySixed]:
+        """Why a menu item is 86'd, or None if the kitchen can make it"""
+        normalizer = get_normalizer()
+        spoiled_ids = set(normalizer.dedupe(spoiled))
+        available = [i for i in on_hand if normalizer.canonical_id(i) not in spoiled_ids]
+        missing = self.dataset_parser.validate_recipe(recipe, available)['missing']
+        manual = self.manual.get(recipe['id'])
+        if not missing and manual is None:
+            return None
+        return EightySixed(
+            recipe_id=recipe['id'],
+            dish=dish_name(recipe),
+            missing=list(missing),
+            spoiled=[i for i in missing if normalizer.canonical_id(i) in spoiled_ids],
+            manual=manual
+        )
+
+    def eighty_six_list(self, on_hand: Iterable[str], spoiled: Iterable[str] = ()) -> List[EightySixed]:
+        """Every item on the menu that's 86'd right now"""
+        on_hand, spoiled = list(on_hand), list(spoiled)
+        checked = (self.check(recipe, on_hand, spoiled) for recipe in self.recipes())
+        return [item for item in checked if item is not None]
+
+    def eighty_six(self, recipe_id: int, reason: str = "") -> Dict[str, Any]:
+        """Take an item off by hand until it's restored; raises KeyError if it's not on the menu"""
+        recipe = self.recipe(recipe_id)
+        if recipe is None:
+            raise KeyError(f"Recipe {recipe_id} is not on the menu")
+        self.manual[recipe_id] = reason
+        if self.store is not None:
+            self.store.eighty_six(recipe_id, reason)
+        logger.info(f"86'd {dish_name(recipe)}" + (f": {reason}" if reason else ""))
+        return recipe
+
+    def restore(self, recipe_id: int) -> bool:
+        """Put an item 86'd by hand back on, returning False if it wasn't"""
+        if self.manual.pop(recipe_id, None) is None:
+            return False
+        if self.store is not None:
+            self.store.restore(recipe_id)
+        return True
@@ -0,0 +1,320 @@
+"""
+Kitchen Alert Notifier for ChefBench
+Posts high-severity kitchen events to Slack, Discord or generic webhook sinks configured in the config file
+"""
+
+import asyncio
+import json
+import os
+import re
+import time
+import uuid
+from collections import deque
+from dataclasses import dataclass, field, asdict
+from typing import Dict, List, Optional, Set, Tuple, Any
+import logging
+
+import httpx
+
+from models.models import KitchenEvent
+from database.event_store import EventStore, NOTE_SEVERITIES
+from kitchen.webhooks import (
+    post_with_retries, sign, DELIVERY_HEADER, EVENT_HEADER, SIGNATURE_HEADER, TIMESTAMP_HEADER,
+    DELIVERY_LOG_SIZE, RETRY_BACKOFF_SECONDS
+)
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_CONFIG_PATH = "configs/config.yaml"
+CONFIG_SECTION = "notifications"
+
+# Alert kinds
+ITEM_86D = "item_86d"  # ingredients gone for the rest of the run
+HACCP_VIOLATION = "haccp_violation"  # equipment outside its safe temperature range
+RUN_FAILED = "run_failed"
+BUDGET_OVERRUN = "budget_overrun"  # an agent spent its whole error budget
+TEST = "test"
+
+ALERT_KINDS = (ITEM_86D, HACCP_VIOLATION, RUN_FAILED, BUDGET_OVERRUN)
+
+SEVERITIES = NOTE_SEVERITIES  # least first
+DEFAULT_MIN_SEVERITY = "warning"
+
+SEVERITY_EMOJI = {"info": ":information_source:", "warning": ":warning:", "critical": ":rotating_light:"}
+DISCORD_COLORS = {"info": 0x3498DB, "warning": 0xF1C40F, "critical": 0xE74C3C}
+
+_ENV_REFERENCE = re.compile(r"\$\{(\w+)\}")
+
+
+@dataclass
+class Alert:
+    """Something going wrong in the kitchen that someone should hear about"""
+    kind: str
+    severity: str
+    title: str
+    text: str
+    run_id: Optional[str] = None
+    event_id: Optional[int] = None
+    data: Dict[str, Any] = field(default_factory=dict)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def alerts_for(event: KitchenEvent) -> List[Alert]:
+    """The alerts a kitchen event raises; most raise none"""
+    payload = event.payload
+    common = {"run_id": event.run_id, "event_id": event.event_id}
+    if event.event_type == "chaos_injected" and payload.get("action") == "spoil_inventory":
+        items = payload.get("ingredients") or []
+        return [Alert(
+            ITEM_86D, "warning", f"86'd: {', '.join(items) or 'inventory'}",
+            "Spoiled and off the menu for the rest of the run; orders that need them will substitute or fail",
+            data={"ingredients": items}, **common
+        )]
+    if event.event_type == "temperature_alert":
+        return [Alert(
+            HACCP_VIOLATION, "critical", f"HACCP: {payload.get('equipment')} {payload.get('threshold')}",
+            f"{payload.get('equipment')} is at {payload.get('temperature')}C, "
+            f"outside its safe range of {payload.get('low')}-{payload.get('high')}C",
+            data=dict(payload), **common
+        )]
+    if event.event_type == "scenario_failed":
+        return [Alert(
+            RUN_FAILED, "critical", f"Run {event.run_id} failed", str(payload.get("error", "unknown error")),
+            data=dict(payload), **common
+        )]
+    if event.event_type == "task_error" and payload.get("budget_remaining") == 0:
+        return [Alert(
+            BUDGET_OVERRUN, "warning", f"{event.agent_name} is over its error budget",
+            f"Last error on {event.task_id} ({payload.get('error_class')}): {payload.get('error')}. "
+            f"Its errors are no longer retried",
+            data={"agent": event.agent_name, "task_id": event.task_id}, **common
+        )]
+    return []
+
+
+@dataclass
+class NotificationSink:
+    """Somewhere alerts are posted, from min_severity up; the generic sink posts the alert as JSON"""
+    name: str
+    url: str
+    min_severity: str = DEFAULT_MIN_SEVERITY
+    kinds: List[str] = field(default_factory=list)  # empty for every kind
+    secret: Optional[str] = None  # generic sinks sign their posts when set
+    type: str = "webhook"
+
+    def validate(self):
+        if not self.url.startswith(("http://", "https://")):
+            raise ValueError(f"Sink {self.name}: url must be http:// or https://")
+        if self.min_severity not in SEVERITIES:
+            raise ValueError(f"Sink {self.name}: min_severity must be one of {list(SEVERITIES)}")
+        unknown = sorted(set(self.kinds) - set(ALERT_KINDS))
+        if unknown:
+            raise ValueError(f"Sink {self.name}: unknown alert kinds {unknown}, expected some of {list(ALERT_KINDS)}")
+
+    def accepts(self, alert: Alert) -> bool:
+        if alert.kind == TEST:
+            return True
+        return (
+            SEVERITIES.index(alert.severity) >= SEVERITIES.index(self.min_severity)
+            and (not self.kinds or alert.kind in self.kinds)
+        )
+
+    def body(self, alert: Alert) -> Dict[str, Any]:
+        return alert.to_dict()
+
+    def headers(self, alert: Alert, delivery_id: str, body: bytes) -> Dict[str, str]:
+        headers = {"Content-Type": "application/json", EVENT_HEADER: alert.kind, DELIVERY_HEADER: delivery_id}
+        if self.secret:
+            timestamp = str(int(time.time()))
+            headers[TIMESTAMP_HEADER] = timestamp
+            headers[SIGNATURE_HEADER] = sign(self.secret, timestamp, body)
+        return headers
+
+    def to_dict(self) -> Dict[str, Any]:
+        """The sink without its URL and secret, which carry the credentials"""
+        data = asdict(self)
+        data["url"] = re.sub(r"^(https?://[^/]+).*", r"\1/...", self.url)
+        data["signed"] = bool(data.pop("secret"))
+        return data
+
+
+@dataclass
+class SlackSink(NotificationSink):
+    """A Slack incoming webhook"""
+    type: str = "slack"
+
+    def body(self, alert: Alert) -> Dict[str, Any]:
+        return {"text": f"{SEVERITY_EMOJI.get(alert.severity, '')} *{alert.title}*\n{alert.text}"}
+
+
+@dataclass
+class DiscordSink(NotificationSink):
+    """A Discord channel webhook"""
+    type: str = "discord"
+
+    def body(self, alert: Alert) -> Dict[str, Any]:
+        return {
+            "username": "ChefBench",
+            "embeds": [{
+                "title": alert.title[:256],
+                "description": alert.text[:4096],
+                "color": DISCORD_COLORS.get(alert.severity, 0),
+                "footer": {"text": f"{alert.severity} · {alert.kind}" + (f" · run {alert.run_id}" if alert.run_id else "")}
+            }]
+        }
+
+
+SINK_TYPES = {"slack": SlackSink, "discord": DiscordSink, "webhook": NotificationSink}
+
+
+def _expand_env(value: Any) -> Any:
+    """Replace ${VAR} references in config strings with the environment's values"""
+    if isinstance(value, str):
+        return _ENV_REFERENCE.sub(lambda m: os.environ.get(m.group(1), ""), value)
+    return value
+
+
+def build_sink(entry: Dict[str, Any], index: int = 0) -> NotificationSink:
+    """A sink from one config entry; raises ValueError naming the problem"""
+    entry = {k: _expand_env(v) for k, v in entry.items()}
+    sink_type = entry.pop("type", "webhook")
+    if sink_type not in SINK_TYPES:
+        raise ValueError(f"Unknown sink type '{sink_type}', expected one of {list(SINK_TYPES)}")
+    entry.setdefault("name", f"{sink_type}-{index}")
+    try:
+        sink = SINK_TYPES[sink_type](**entry)
+    except TypeError as e:
+        raise ValueError(f"Sink {entry['name']}: {e}")
+    sink.validate()
+    return sink
+
+
+@dataclass
+class NotificationRecord:
+    """One alert posted to one sink"""
+    delivery_id: str
+    sink: str
+    kind: str
+    severity: str
+    run_id: Optional[str]
+    attempts: int = 0
+    status_code: Optional[int] = None
+    delivered: bool = False
+    error: Optional[str] = None
+    sent_at: float = field(default_factory=time.time)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class KitchenNotifier:
+    """Posts alerts raised by the event log to every sink whose threshold they meet
+
+    Sinks come from the `notifications` section of the config file, and a
+    sink that fails to load is logged and left out. Like webhooks, the
+    notifier follows the shared event store from server startup, and each
+    post is retried on connection errors, 429 and 5xx responses. An agent's
+    budget overrun is only reported once per run.
+    """
+
+    def __init__(
+        self,
+        sinks: Optional[List[NotificationSink]] = None,
+        transport: Optional[httpx.AsyncBaseTransport] = None,
+        backoff_seconds: float = RETRY_BACKOFF_SECONDS
+    ):
+        self.sinks = list(sinks or [])
+        self.transport = transport
+        self.backoff_seconds = backoff_seconds
+        self.history: deque = deque(maxlen=DELIVERY_LOG_SIZE)
+        self._overruns: Set[Tuple[Optional[str], Optional[str]]] = set()
+        self._pending: Set[asyncio.Task] = set()
+
+    @classmethod
+    def from_config(cls, path: Optional[str] = None, **kwargs: Any) -> "KitchenNotifier":
+        """Sinks from the config file, CHEFBENCH_CONFIG or configs/config.yaml; none if it has no section"""
+        path = path or os.environ.get("CHEFBENCH_CONFIG", DEFAULT_CONFIG_PATH)
+        if not os.path.exists(path):
+            return cls(**kwargs)
+        try:
+            import yaml
+        except ImportError:
+            logger.warning(f"PyYAML is not installed; no kitchen alerts will be posted from {path}")
+            return cls(**kwargs)
+        with open(path, 'r', encoding='utf-8') as f:
+            try:
+                config = yaml.safe_load(f) or {}
+            except yaml.YAMLError as e:
+                logger.error(f"Ignoring notifications in {path}: {e}")
+                return cls(**kwargs)
+
+        sinks = []
+        for index, entry in enumerate((config.get(CONFIG_SECTION) or {}).get("sinks") or []):
+            try:
+                sinks.append(build_sink(dict(entry), index))
+            except (ValueError, AttributeError) as e:
+                logger.error(f"Ignoring notification sink in {path}: {e}")
+        if sinks:
+            logger.info(f"Posting kitchen alerts to {len(sinks)} sinks from {path}")
+        return cls(sinks, **kwargs)
+
+    def alerts(self, event: KitchenEvent) -> List[Alert]:
+        alerts = []
+        for alert in alerts_for(event):
+            if alert.kind == BUDGET_OVERRUN:
+                key = (event.run_id, event.agent_name)
+                if key in self._overruns:
+                    continue
+                self._overruns.add(key)
+            alerts.append(alert)
+        return alerts
+
+    def dispatch(self, event: KitchenEvent):
+        """Start posting the event's alerts to the sinks that take them"""
+        for alert in self.alerts(event):
+            for sink in self.sinks:
+                if sink.accepts(alert):
+                    task = asyncio.create_task(self.post(sink, alert))
+                    self._pending.add(task)
+                    task.add_done_callback(self._pending.discard)
+
+    async def post(self, sink: NotificationSink, alert: Alert) -> NotificationRecord:
+        record = NotificationRecord(uuid.uuid4().hex, sink.name, alert.kind, alert.severity, alert.run_id)
+        self.history.append(record)
+        body = json.dumps(sink.body(alert), default=str).encode()
+        record.attempts, record.status_code, record.error = await post_with_retries(
+            sink.url, body, lambda: sink.headers(alert, record.delivery_id, body), self.transport, self.backoff_seconds
+        )
+        record.delivered = record.error is None
+        if not record.delivered:
+            logger.warning(f"Kitchen alert {alert.kind} to {sink.name} failed after {record.attempts} attempts: "
+                           f"{record.error}")
+        return record
+
+    async def test(self, severity: str = "critical") -> List[NotificationRecord]:
+        """Post a test alert to every sink, whatever its threshold, and wait for the outcomes"""
+        alert = Alert(TEST, severity, "ChefBench test alert", f"A {severity} test alert from the kitchen notifier")
+        return list(await asyncio.gather(*(self.post(sink, alert) for sink in self.sinks)))
+
+    async def follow(self, event_store: EventStore, interval_seconds: float = 1.0):
+        """Dispatch new events from the store for as long as the server is up"""
+        latest = await asyncio.to_thread(event_store.latest_event_id)
+        while True:
+            try:
+                events = await asyncio.to_thread(event_store.query, after_id=latest, limit=500)
+                for event in events:
+                    latest = event.event_id
+                    self.dispatch(event)
+            except Exception as e:
+                logger.error(f"Kitchen alert dispatch failed: {e}")
+            await asyncio.sleep(interval_seconds)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "kinds": list(ALERT_KINDS),
+            "severities": list(SEVERITIES),
+            "sinks": [s.to_dict() for s in self.sinks],
+            "recent": [r.to_dict() for r in reversed(self.history)]
+        }
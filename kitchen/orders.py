@@ -0,0 +1,278 @@
+"""
+Order Submission for ChefBench
+Checks orders against the menu, inventory, equipment and staff, and plans how the kitchen would cook them
+"""
+
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Tuple, Any
+
+from models.models import TaskType
+from providers import MultiAgentCoordinator, COURSES, DEFAULT_COURSE
+from recipes.dataset_parser import RecipeDatasetParser
+from recipes.ingredients import IngredientCatalog
+from equipment import station_for
+from dining import ORDER_TASKS, OrderComplexity, score_order, complexity_level
+from dining.floor import DINING_STATUSES
+from eta import ETAEstimator
+from kitchen.menu import Menu, dish_name
+
+DEFAULT_ORDER_TASK = "cooking_execution"
+DEFAULT_TIME_LIMIT = 300
+MAX_NOTE_LENGTH = 200
+
+
+@dataclass
+class OrderItem:
+    """One line of an order: a menu recipe, or a dish from the listed ingredients"""
+    task_type: str = DEFAULT_ORDER_TASK
+    recipe_id: Optional[int] = None
+    dish: Optional[str] = None
+    ingredients: List[str] = field(default_factory=list)
+    quantity: int = 1
+    course: str = DEFAULT_COURSE
+    notes: List[str] = field(default_factory=list)  # changes for the cook, e.g. "no onions"
+
+
+@dataclass
+class PlannedItem:
+    """How the kitchen would cook one order line, and what stops it"""
+    index: int
+    task_type: str
+    dish: Optional[str]
+    recipe_id: Optional[int]
+    quantity: int
+    ingredients: List[str]
+    course: str = DEFAULT_COURSE
+    notes: List[str] = field(default_factory=list)
+    station: Optional[str] = None
+    agent: Optional[str] = None
+    expected_seconds: Optional[float] = None  # per portion
+    ready_in_seconds: Optional[float] = None  # when the last portion would be done
+    substitutions: Dict[str, Any] = field(default_factory=dict)
+    eighty_sixed: List[str] = field(default_factory=list)  # why the menu item is off, if it is
+    techniques: List[str] = field(default_factory=list)  # cooking techniques the recipe takes
+    errors: List[str] = field(default_factory=list)
+    warnings: List[str] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return dict(self.__dict__)
+
+
+@dataclass
+class OrderPlan:
+    """An order as checked and planned; feasible when no line has an error"""
+    items: List[PlannedItem]
+    table: Optional[int]
+    errors: List[str] = field(default_factory=list)  # problems with the order as a whole
+    queued_seconds: float = 0.0  # expected work already ahead of the order
+    complexity: Optional[OrderComplexity] = None  # of all its portions, scored once every line is planned
+    _tasks: List[Tuple[TaskType, Dict[str, Any]]] = field(default_factory=list, repr=False)
+
+    @property
+    def feasible(self) -> bool:
+        return not self.errors and not any(item.errors for item in self.items)
+
+    @property
+    def ready_in_seconds(self) -> Optional[float]:
+        ready = [item.ready_in_seconds for item in self.items if item.ready_in_seconds is not None]
+        return max(ready) if ready else None
+
+    def tasks(self) -> List[Tuple[TaskType, Dict[str, Any]]]:
+        """The tasks to queue for the order, one per portion"""
+        return self._tasks
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "feasible": self.feasible,
+            "table": self.table,
+            "errors": self.errors,
+            "queued_seconds": round(self.queued_seconds, 2),
+            "ready_in_seconds": round(self.ready_in_seconds, 2) if self.ready_in_seconds is not None else None,
+            "complexity": self.complexity.to_dict() if self.complexity else None,
+            "tasks": len(self._tasks),
+            "items": [item.to_dict() for item in self.items]
+        }
+
+
+class OrderPlanner:
+    """Validates orders and plans them against the kitchen as it is now, changing nothing
+
+    Each line must be on the menu (the planned menu, or any loaded recipe
+    until one is planned) or list its ingredients, and be cookable from what
+    is on hand, counting substitutions and leaving out spoiled stock. A menu item on the 86-list is refused before anything
+    else is checked. It must not break the guests' dietary restrictions, its
+    equipment, and that of its recipe's cooking techniques, must be working,
+    and someone on shift must be able to cook it by those techniques.
+    Portions are planned one after another behind the work already queued,
+    since the kitchen works tasks one at a time, and take longer the more
+    complex the order as a whole is.
+    """
+
+    def __init__(
+        self,
+        coordinator: MultiAgentCoordinator,
+        dataset_parser: RecipeDatasetParser,
+        catalog: IngredientCatalog,
+        eta_estimator: ETAEstimator,
+        menu: Optional[Menu] = None
+    ):
+        self.coordinator = coordinator
+        self.dataset_parser = dataset_parser
+        self.catalog = catalog
+        self.eta_estimator = eta_estimator
+        self.menu = menu or Menu(dataset_parser)
+
+    def plan(
+        self,
+        items: List[OrderItem],
+        on_hand: List[str],
+        table: Optional[int] = None,
+        dietary_restrictions: Optional[List[str]] = None,
+        time_limit: float = DEFAULT_TIME_LIMIT
+    ) -> OrderPlan:
+        coordinator = self.coordinator
+        plan = OrderPlan(items=[], table=table)
+
+        if table is not None:
+            floor_table = coordinator.floor.tables.get(table)
+            if floor_table is None:
+                plan.errors.append(f"Unknown table {table}")
+            elif floor_table.status not in DINING_STATUSES:
+                plan.errors.append(f"Table {table} is {floor_table.status}, not dining")
+
+        if dietary_restrictions:
+            try:
+                self.catalog.check_restrictions([], dietary_restrictions)
+            except ValueError as e:
+                plan.errors.append(str(e))
+                dietary_restrictions = None
+
+        available = [i for i in on_hand if i not in coordinator.spoiled]
+        agents = coordinator.agents
+        plan.queued_seconds = sum(
+            self.eta_estimator.expected_seconds(agents[name].model_name, task_type, complexity_level(task_type, context))
+            for name, task_type, context in coordinator.queued_tasks()
+            if name in agents
+        )
+        cookable = []
+
+        for index, item in enumerate(items):
+            planned = PlannedItem(
+                index, item.task_type, item.dish, item.recipe_id, item.quantity, list(item.ingredients), item.course,
+                list(item.notes)
+            )
+            plan.items.append(planned)
+
+            if item.course not in COURSES:
+                planned.errors.append(f"Unknown course '{item.course}', expected one of {list(COURSES)}")
+                continue
+            if any(not note.strip() or len(note) > MAX_NOTE_LENGTH for note in item.notes):
+                planned.errors.append(f"Notes must be 1-{MAX_NOTE_LENGTH} characters")
+                continue
+            if item.task_type not in ORDER_TASKS:
+                planned.errors.append(f"{item.task_type} is not an order task, expected one of {sorted(ORDER_TASKS)}")
+                continue
+            task_type = TaskType.from_function_name(item.task_type)
+            planned.station = station_for(task_type)
+
+            # Menu
+            if item.recipe_id is not None:
+                recipe = self.menu.recipe(item.recipe_id)
+                if recipe is None:
+                    planned.errors.append(f"Recipe {item.recipe_id} is not on the menu")
+                    continue
+                planned.ingredients = list(recipe['ingredients'])
+                planned.techniques = list(recipe.get('techniques', []))
+                planned.dish = planned.dish or dish_name(recipe)
+                eighty_sixed = self.menu.check(recipe, on_hand, coordinator.spoiled)
+                if eighty_sixed:
+                    planned.eighty_sixed = eighty_sixed.reasons
+                    planned.errors.append(f"{dish_name(recipe)} is 86'd: {'; '.join(eighty_sixed.reasons)}")
+                    continue
+            elif not item.ingredients:
+                planned.errors.append("Give a recipe_id from the menu, or the dish's ingredients")
+                continue
+
+            # Inventory
+            validation = self.dataset_parser.validate_recipe({'ingredients': planned.ingredients}, available)
+            planned.substitutions = validation['substitutions']
+            spoiled = sorted(set(planned.ingredients) & coordinator.spoiled)
+            if validation['missing']:
+                planned.errors.append(
+                    f"Not enough on hand: missing {', '.join(validation['missing'])}"
+                    + (f" (spoiled: {', '.join(spoiled)})" if spoiled else "")
+                )
+            elif planned.substitutions:
+                planned.warnings.append(
+                    f"Substituting {', '.join(planned.substitutions)}, "
+                    f"-{validation['quality_penalty']:.2f} quality"
+                )
+
+            # Guests' restrictions
+            if dietary_restrictions:
+                check = self.catalog.check_restrictions(planned.ingredients, dietary_restrictions)
+                for conflict in check["conflicts"]:
+                    planned.errors.append(f"{conflict['ingredient']} breaks the {conflict['restriction']} restriction")
+                if check.get("unverified"):
+                    planned.warnings.append(f"Couldn't verify {', '.join(check['unverified'])}")
+
+            # Equipment
+            if coordinator.equipment:
+                outages = coordinator.equipment.outages_for(task_type, planned.techniques)
+                if outages:
+                    planned.errors.append(f"No working {', '.join(outages)}")
+
+            # Staff
+            planned.agent = coordinator.preview_assignment(task_type, tuple(planned.techniques))
+            if planned.agent is None:
+                if planned.techniques:
+                    planned.errors.append(
+                        f"No one on shift can do {item.task_type} by {', '.join(planned.techniques)}"
+                    )
+                else:
+                    planned.errors.append(f"No one on shift can do {item.task_type}")
+                continue
+            cookable.append((planned, task_type))
+
+            for _ in range(item.quantity):
+                context = {
+                    "ingredients": list(planned.ingredients),
+                    "time_limit": time_limit,
+                    "difficulty": "order",
+                    "dish": planned.dish or ", ".join(planned.ingredients),
+                    "line": index,
+                    "course": item.course,
+                }
+                if item.recipe_id is not None:
+                    context["recipe_id"] = item.recipe_id
+                if planned.techniques:
+                    context["techniques"] = list(planned.techniques)
+                if item.notes:
+                    # Listed in the cook's prompt, as guests' changes are
+                    context["modifications"] = list(item.notes)
+                if planned.substitutions:
+                    context["substitutions"] = {
+                        ingredient: [f"{s['substitute']} (x{s['ratio']:g}, -{s['quality_penalty']:.2f} quality)"]
+                        for ingredient, s in planned.substitutions.items()
+                    }
+                if dietary_restrictions:
+                    context["dietary_restrictions"] = list(dietary_restrictions)
+                plan._tasks.append((task_type, context))
+
+        # Complexity is the whole order's, so when each line would be up is worked out once it's known
+        plan.complexity = score_order(plan._tasks)
+        for _, context in plan._tasks:
+            context["complexity"] = plan.complexity.to_dict()
+        clock = plan.queued_seconds
+        for planned, task_type in cookable:
+            planned.expected_seconds = round(
+                self.eta_estimator.expected_seconds(
+                    agents[planned.agent].model_name, task_type, plan.complexity.level
+                ), 2
+            )
+            clock += planned.expected_seconds * planned.quantity
+            planned.ready_in_seconds = round(clock, 2)
+            if planned.ready_in_seconds > time_limit:
+                planned.warnings.append(f"Ready in {planned.ready_in_seconds:.0f}s, past the {time_limit:.0f}s limit")
+
+        return plan
@@ -0,0 +1,29 @@
+"""
+List Pagination for ChefBench
+Shared limit/offset paging and field sorting for list endpoints
+"""
+
+from typing import Any, Callable, Dict, List, Tuple
+
+DEFAULT_LIMIT = 100
+MAX_LIMIT = 1000
+
+
+def sort_items(items: List[Any], sort: str, fields: Dict[str, Callable[[Any], Any]]) -> List[Any]:
+    """Sort by one of the named fields; a leading '-' sorts descending"""
+    name = sort.lstrip("-")
+    if name not in fields:
+        raise ValueError(f"Cannot sort by '{name}', expected one of {sorted(fields)}")
+    return sorted(items, key=fields[name], reverse=sort.startswith("-"))
+
+
+def paginate(items: List[Any], limit: int, offset: int) -> Tuple[List[Any], Dict[str, Any]]:
+    """One page of items, plus the totals and offset of the next page (None on the last)"""
+    page = items[offset:offset + limit]
+    end = offset + len(page)
+    return page, {
+        "total": len(items),
+        "offset": offset,
+        "limit": limit,
+        "next_offset": end if end < len(items) else None
+    }
@@ -0,0 +1,94 @@
+"""
+Run Progress for ChefBench
+Turns a run's event log into the progress frames streamed to live views
+"""
+
+from typing import Dict, List, Optional, Tuple, Any
+
+from models.models import KitchenEvent
+from database.event_store import new_replay_state, apply_event
+
+# Events forwarded to progress streams as run status changes
+STATUS_EVENTS = {
+    "scenario_started": "running",
+    "scenario_resumed": "running",
+    "run_paused": "paused",
+    "run_resumed": "running",
+    "scenario_completed": "completed",
+    "scenario_failed": "failed",
+}
+
+
+class RunProgress:
+    """Folds a run's events into counters and a percentage complete"""
+
+    def __init__(self, run_id: str, total_tasks: int = 0):
+        self.state = new_replay_state(run_id)
+        self.total_tasks = total_tasks
+        self.queued = 0
+
+    def apply(self, event: KitchenEvent) -> List[Tuple[str, Dict[str, Any]]]:
+        """Update the counters and return the (frame type, data) pairs the event produces"""
+        apply_event(self.state, event)
+        payload = event.payload
+
+        if event.event_type == "scenario_started":
+            self.total_tasks = max(self.total_tasks, payload.get("total_tasks", 0))
+        if event.event_type == "task_assigned":
+            self.queued += 1
+            return [("task_queued", {
+                "task_id": event.task_id,
+                "task_type": payload.get("task_type"),
+                "agent": event.agent_name,
+                "policy": payload.get("policy")
+            })]
+        if event.event_type == "task_executed":
+            return [("decision", {
+                "task_id": event.task_id,
+                "task_type": payload.get("task_type"),
+                "agent": event.agent_name,
+                "approach": payload.get("chosen_approach"),
+                "success": payload.get("success"),
+                "quality": payload.get("quality_score"),
+                "degraded": payload.get("degraded", False)
+            })]
+        if event.event_type == "chaos_injected":
+            if payload.get("action") == "spike_orders":
+                self.total_tasks += payload.get("count", 0)
+            return [("chaos", dict(payload))]
+        if event.event_type in ("budget_warning", "budget_exceeded"):
+            return [("budget", {"event": event.event_type, **payload})]
+        if event.event_type == "order_cancelled":
+            self.total_tasks = max(0, self.total_tasks - 1)
+        if event.event_type in STATUS_EVENTS:
+            return [("status", {"status": STATUS_EVENTS[event.event_type], "event": event.event_type})]
+        return []
+
+    def snapshot(self, status: Optional[str] = None) -> Dict[str, Any]:
+        """Metrics so far, with percentage complete by executed tasks"""
+        agents = self.state["agents"].values()
+        completed = sum(a["tasks_completed"] for a in agents)
+        failed = sum(a["tasks_failed"] for a in agents)
+        executed = completed + failed
+        status = status or self.state["status"]
+
+        if status == "completed":
+            percent = 100.0
+        elif self.total_tasks:
+            percent = min(99.9, 100.0 * executed / self.total_tasks)
+        else:
+            percent = 0.0
+
+        return {
+            "status": status,
+            "percent_complete": round(percent, 1),
+            "total_tasks": self.total_tasks,
+            "tasks_queued": self.queued,
+            "tasks_pending": len(self.state["pending_tasks"]),
+            "tasks_completed": completed,
+            "tasks_failed": failed,
+            "success_rate": round(completed / executed, 4) if executed else 0.0,
+            "average_quality": round(sum(a["quality_total"] for a in agents) / executed, 4) if executed else 0.0,
+            "messages": self.state["messages"],
+            "last_event_id": self.state["last_event_id"]
+        }
@@ -0,0 +1,154 @@
+"""
+Session Sandboxes for ChefBench
+Isolated per-session kitchens (agents, coordinator, evaluations) collected after inactivity
+"""
+
+import contextlib
+import contextvars
+import re
+import time
+from dataclasses import dataclass, field
+from typing import Callable, Dict, Iterator, List, Optional, Any
+import logging
+
+from fastapi import Request
+
+from kitchen.errors import error_response
+
+from providers import MultiAgentCoordinator
+
+logger = logging.getLogger(__name__)
+
+SESSION_HEADER = "X-Session-ID"
+DEFAULT_SESSION = "default"
+
+_SESSION_ID = re.compile(r"^[A-Za-z0-9_-]{1,64}$")
+
+
+@dataclass
+class Sandbox:
+    """One session's kitchen state"""
+    session_id: str
+    coordinator: MultiAgentCoordinator
+    active_evaluations: Dict[str, Dict] = field(default_factory=dict)
+    created_at: float = field(default_factory=time.time)
+    last_seen: float = field(default_factory=time.time)
+
+    def touch(self):
+        self.last_seen = time.time()
+
+    @property
+    def busy(self) -> bool:
+        return any(e["status"] in ("running", "paused") for e in self.active_evaluations.values())
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "session_id": self.session_id,
+            "agents": len(self.coordinator.agents),
+            "evaluations": len(self.active_evaluations),
+            "busy": self.busy,
+            "created_at": self.created_at,
+            "last_seen": self.last_seen
+        }
+
+
+class SandboxManager:
+    """Creates sandboxes on first use and garbage-collects idle ones
+
+    Requests without a session header share the default sandbox, which is
+    never collected. coordinator_factory builds the kitchen for a session id.
+    """
+
+    def __init__(
+        self,
+        coordinator_factory: Callable[[str], MultiAgentCoordinator],
+        ttl_seconds: float = 1800,
+        max_sandboxes: int = 50,
+        gc_interval: float = 60
+    ):
+        self.coordinator_factory = coordinator_factory
+        self.ttl_seconds = ttl_seconds
+        self.max_sandboxes = max_sandboxes
+        self.gc_interval = gc_interval
+        self.sandboxes: Dict[str, Sandbox] = {
+            DEFAULT_SESSION: Sandbox(DEFAULT_SESSION, coordinator_factory(DEFAULT_SESSION))
+        }
+        self._current: contextvars.ContextVar[str] = contextvars.ContextVar(
+            "sandbox_session", default=DEFAULT_SESSION
+        )
+        self._last_gc = time.time()
+
+    def current(self) -> Sandbox:
+        """Sandbox for the session handling the current request"""
+        return self.sandboxes.get(self._current.get()) or self.sandboxes[DEFAULT_SESSION]
+
+    def get_or_create(self, session_id: str) -> Sandbox:
+        sandbox = self.sandboxes.get(session_id)
+        if sandbox is None:
+            if len(self.sandboxes) > self.max_sandboxes:
+                raise OverflowError(f"Sandbox limit of {self.max_sandboxes} reached")
+            sandbox = Sandbox(session_id, self.coordinator_factory(session_id))
+            self.sandboxes[session_id] = sandbox
+            logger.info(f"Created sandbox {session_id}")
+        sandbox.touch()
+        return sandbox
+
+    @contextlib.contextmanager
+    def bind(self, session_id: str) -> Iterator[Sandbox]:
+        """Bind work started outside a request, like resumed runs, to a session's sandbox"""
+        sandbox = self.get_or_create(session_id)
+        token = self._current.set(session_id)
+        try:
+            yield sandbox
+        finally:
+            self._current.reset(token)
+
+    def remove(self, session_id: str) -> bool:
+        if session_id == DEFAULT_SESSION or session_id not in self.sandboxes:
+            return False
+        del self.sandboxes[session_id]
+        logger.info(f"Removed sandbox {session_id}")
+        return True
+
+    def collect_garbage(self, now: Optional[float] = None) -> List[str]:
+        """Drop sandboxes idle longer than the TTL, sparing ones with running evaluations"""
+        now = now or time.time()
+        expired = [
+            sid for sid, sandbox in self.sandboxes.items()
+            if sid != DEFAULT_SESSION
+            and not sandbox.busy
+            and now - sandbox.last_seen > self.ttl_seconds
+        ]
+        for sid in expired:
+            self.remove(sid)
+        self._last_gc = now
+        return expired
+
+    async def middleware(self, request: Request, call_next):
+        """Bind the request (and its background tasks) to the caller's sandbox"""
+        if time.time() - self._last_gc > self.gc_interval:
+            self.collect_garbage()
+
+        session_id = request.headers.get(SESSION_HEADER)
+        if session_id is None:
+            return await call_next(request)
+
+        if not _SESSION_ID.match(session_id):
+            return error_response(400, f"Invalid {SESSION_HEADER}")
+        try:
+            self.get_or_create(session_id)
+        except OverflowError as e:
+            return error_response(503, str(e))
+
+        token = self._current.set(session_id)
+        try:
+            return await call_next(request)
+        finally:
+            self._current.reset(token)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "ttl_seconds": self.ttl_seconds,
+            "max_sandboxes": self.max_sandboxes,
+            "sandboxes": [s.to_dict() for s in self.sandboxes.values()]
+        }
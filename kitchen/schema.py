@@ -0,0 +1,121 @@
+"""
+Model Schemas for ChefBench
+JSON Schema generated from the persisted dataclasses so integrations track the Python source
+"""
+
+import dataclasses
+import typing
+from enum import Enum
+from typing import Any, Dict, Tuple, Union, get_type_hints
+
+from models.models import KitchenEvent, TaskExecution, Message, AgentRole, TaskType
+from recipes.dataset_parser import Recipe
+from recipes.substitutions import Substitution
+from recipes.ingredients import IngredientInfo
+
+JSON_SCHEMA_DIALECT = "https://json-schema.org/draft/2020-12/schema"
+
+# name -> (model class, fields left out of its to_dict() form)
+SCHEMA_MODELS: Dict[str, Tuple[type, Tuple[str, ...]]] = {
+    "Event": (KitchenEvent, ()),
+    "Task": (TaskExecution, ("device", "response", "degradation")),
+    "Message": (Message, ()),
+    "Recipe": (Recipe, ()),
+    "Substitution": (Substitution, ()),
+    "Ingredient": (IngredientInfo, ()),
+}
+
+_PRIMITIVES = {str: "string", int: "integer", float: "number", bool: "boolean"}
+
+
+def _enum_values(enum_type: type) -> list:
+    """Values as serialized by to_dict(): task types by function name, other enums by name"""
+    if enum_type is TaskType:
+        return [t.function_name for t in TaskType]
+    return [member.name for member in enum_type]
+
+
+def type_schema(annotation: Any) -> Dict[str, Any]:
+    """JSON Schema for a type hint"""
+    if annotation is Any:
+        return {}
+    if annotation in _PRIMITIVES:
+        return {"type": _PRIMITIVES[annotation]}
+    if isinstance(annotation, type) and issubclass(annotation, Enum):
+        return {"$ref": f"#/$defs/{annotation.__name__}"}
+
+    origin = typing.get_origin(annotation)
+    args = typing.get_args(annotation)
+
+    if origin is Union:
+        non_null = [a for a in args if a is not type(None)]
+        schema = type_schema(non_null[0]) if len(non_null) == 1 else {"anyOf": [type_schema(a) for a in non_null]}
+        if type(None) in args:
+            schema = {"anyOf": [schema, {"type": "null"}]}
+        return schema
+    if origin in (list, tuple, set):
+        return {"type": "array", "items": type_schema(args[0]) if args else {}}
+    if origin is dict or annotation is dict:
+        schema = {"type": "object"}
+        if len(args) == 2 and args[1] is not Any:
+            schema["additionalProperties"] = type_schema(args[1])
+        return schema
+    raise TypeError(f"No JSON Schema mapping for {annotation!r}")
+
+
+def model_schema(model: type, exclude: Tuple[str, ...] = ()) -> Dict[str, Any]:
+    """JSON Schema for a dataclass or TypedDict"""
+    hints = get_type_hints(model)
+    properties = {}
+    required = []
+
+    if dataclasses.is_dataclass(model):
+        for f in dataclasses.fields(model):
+            if f.name in exclude:
+                continue
+            properties[f.name] = type_schema(hints[f.name])
+            if f.default is dataclasses.MISSING and f.default_factory is dataclasses.MISSING:
+                required.append(f.name)
+    else:
+        for name, hint in hints.items():
+            if name in exclude:
+                continue
+            properties[name] = type_schema(hint)
+        required_keys = getattr(model, "__required_keys__", hints)
+        required = [name for name in hints if name in required_keys and name not in exclude]
+
+    schema = {
+        "title": model.__name__,
+        "type": "object",
+        "properties": properties,
+        "required": required
+    }
+    if model.__doc__:
+        schema["description"] = model.__doc__.strip().splitlines()[0]
+    return schema
+
+
+def _enum_defs() -> Dict[str, Any]:
+    return {
+        enum_type.__name__: {"type": "string", "enum": _enum_values(enum_type)}
+        for enum_type in (AgentRole, TaskType)
+    }
+
+
+def all_schemas() -> Dict[str, Any]:
+    """A single document defining every persisted model"""
+    return {
+        "$schema": JSON_SCHEMA_DIALECT,
+        "$defs": {
+            **{name: model_schema(model, exclude) for name, (model, exclude) in SCHEMA_MODELS.items()},
+            **_enum_defs()
+        }
+    }
+
+
+def get_schema(name: str) -> Dict[str, Any]:
+    """Standalone schema document for one model"""
+    if name not in SCHEMA_MODELS:
+        raise KeyError(name)
+    model, exclude = SCHEMA_MODELS[name]
+    return {"$schema": JSON_SCHEMA_DIALECT, **model_schema(model, exclude), "$defs": _enum_defs()}
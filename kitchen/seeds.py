@@ -0,0 +1,204 @@
+"""
+Seed Profiles for ChefBench
+Named starting data (empty, demo, stress) composed from small reusable seeders
+"""
+
+import ast
+import csv
+import random
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Callable, Dict, List, Optional, Tuple, Any
+import logging
+
+from models.models import AgentRole
+from providers import MultiAgentCoordinator
+from recipes.dataset_parser import RecipeDatasetParser
+from recipes.ingredients import DEFAULT_INGREDIENTS
+from staffing import URGENCY_LEVELS
+
+logger = logging.getLogger(__name__)
+
+SAMPLE_RECIPES = "data/sample_recipes.csv"
+
+BRIGADE = [AgentRole.HEAD_CHEF, AgentRole.SOUS_CHEF, AgentRole.LINE_COOK, AgentRole.PREP_COOK]
+
+STRESS_CUISINES = ["french", "italian", "japanese", "mexican", "indian", "thai", "greek", "moroccan"]
+
+# Crossed with the catalog's ingredients to make a pantry of a few hundred distinct items
+PANTRY_STYLES = ["fresh", "dried", "smoked", "roasted", "pickled", "frozen", "organic", "minced"]
+
+
+@dataclass
+class SeedTarget:
+    """What seeders write into: a sandbox's coordinator and the recipe dataset"""
+    coordinator: MultiAgentCoordinator
+    dataset: RecipeDatasetParser
+    model_name: str = "cohere/command-r"
+
+
+# A seeder adds its data to the target and returns how many records it created.
+# Seeders skip what is already there, so applying a profile twice is harmless.
+Seeder = Callable[..., int]
+
+
+def seed_brigade(target: SeedTarget, roles: Optional[List[str]] = None) -> int:
+    """Team members named ROLE_n by position, as create_agent_team names them"""
+    roles = [AgentRole[r] for r in roles] if roles else BRIGADE
+    created = 0
+    for i, role in enumerate(roles):
+        name = f"{role.name}_{i + 1}"
+        if name not in target.coordinator.agents:
+            target.coordinator.create_agent(name, role, target.model_name)
+            created += 1
+    return created
+
+
+def seed_staff_pool(target: SeedTarget, roles: List[str]) -> int:
+    """Idle agents that staffing requests can draw on"""
+    hr = target.coordinator.hr
+    created = 0
+    for i, role in enumerate(roles):
+        name = f"POOL_{role}_{i + 1}"
+        if name not in hr.pool and name not in target.coordinator.agents:
+            hr.hire(name, AgentRole[role], target.model_name)
+            created += 1
+    return created
+
+
+def seed_sample_recipes(target: SeedTarget, path: str = SAMPLE_RECIPES) -> int:
+    """The handful of recipes shipped in data/sample_recipes.csv"""
+    if not Path(path).exists():
+        logger.warning(f"Sample recipes not found at {path}")
+        return 0
+
+    recipes = []
+    with open(path, newline="", encoding="utf-8") as f:
+        for row in csv.DictReader(f):
+            ingredients = ast.literal_eval(row["ner"]) if row.get("ner") else []
+            if ingredients:
+                recipes.append({"id": 0, "cuisine": "unknown", "ingredients": ingredients})
+    return _add_new_recipes(target, recipes)
+
+
+def seed_synthetic_recipes(target: SeedTarget, count: int, pantry_size: int, seed: int = 0) -> int:
+    """Generated recipes drawn from a pantry of pantry_size ingredients
+
+    Draws are skewed towards the front of the pantry so ingredient frequencies
+    look like a real dataset's, with staples common and specialities rare.
+    """
+    pantry = [f"{style} {info.name}" for style in PANTRY_STYLES for info in DEFAULT_INGREDIENTS]
+    pantry = [info.name for info in DEFAULT_INGREDIENTS] + pantry
+    pantry = pantry[:pantry_size]
+    weights = [1 / (rank + 1) ** 0.5 for rank in range(len(pantry))]
+
+    rng = random.Random(seed)
+    recipes = []
+    for _ in range(count):
+        ingredients = set()
+        size = rng.randint(6, 14)
+        while len(ingredients) < min(size, len(pantry)):
+            ingredients.add(rng.choices(pantry, weights)[0])
+        recipes.append({"id": 0, "cuisine": rng.choice(STRESS_CUISINES), "ingredients": sorted(ingredients)})
+    return _add_new_recipes(target, recipes)
+
+
+def seed_staff_requests(target: SeedTarget, count: int, seed: int = 0) -> int:
+    """A backlog of open staffing requests, topped up to count"""
+    hr = target.coordinator.hr
+    rng = random.Random(seed)
+    created = 0
+    for _ in range(count - len(hr.open_requests())):
+        hr.submit(
+            rng.choice(list(AgentRole)),
+            count=rng.randint(1, 3),
+            urgency=rng.choice(URGENCY_LEVELS),
+            reason="Seeded backlog"
+        )
+        created += 1
+    return created
+
+
+def _add_new_recipes(target: SeedTarget, recipes: List[Dict[str, Any]]) -> int:
+    """Add recipes whose ingredient list isn't in the dataset yet"""
+    known = {tuple(r["ingredients"]) for r in target.dataset.recipes}
+    new = []
+    for recipe in recipes:
+        if tuple(recipe["ingredients"]) not in known:
+            known.add(tuple(recipe["ingredients"]))
+            new.append(recipe)
+    if new:
+        target.dataset.add_recipes(new)
+    return len(new)
+
+
+SEEDERS: Dict[str, Seeder] = {
+    "brigade": seed_brigade,
+    "staff_pool": seed_staff_pool,
+    "sample_recipes": seed_sample_recipes,
+    "synthetic_recipes": seed_synthetic_recipes,
+    "staff_requests": seed_staff_requests,
+}
+
+
+@dataclass
+class SeedProfile:
+    """A named, ordered list of seeders and their options"""
+    name: str
+    steps: List[Tuple[str, Dict[str, Any]]]
+    description: str = ""
+
+    def __post_init__(self):
+        for seeder, _ in self.steps:
+            if seeder not in SEEDERS:
+                raise ValueError(f"Unknown seeder '{seeder}', expected one of {sorted(SEEDERS)}")
+
+    def apply(self, target: SeedTarget) -> Dict[str, int]:
+        """Run each seeder in order, returning the number of records each created"""
+        created: Dict[str, int] = {}
+        for seeder, options in self.steps:
+            created[seeder] = created.get(seeder, 0) + SEEDERS[seeder](target, **options)
+        logger.info(f"Applied seed profile '{self.name}': {created}")
+        return created
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "name": self.name,
+            "description": self.description,
+            "steps": [{"seeder": seeder, **options} for seeder, options in self.steps]
+        }
+
+
+SEED_PROFILES: Dict[str, SeedProfile] = {
+    profile.name: profile for profile in (
+        SeedProfile("empty", [], "Nothing beyond the built-in ingredient catalog and substitutions"),
+        SeedProfile(
+            "demo",
+            [("brigade", {}), ("sample_recipes", {})],
+            "A four-person brigade and the sample recipes"
+        ),
+        SeedProfile(
+            "stress",
+            [
+                # Starts with the demo brigade, so the first four agents are the same ones
+                ("brigade", {"roles": [r.name for r in BRIGADE] + [
+                    "CHEF_DE_PARTIE", "CHEF_DE_PARTIE", "LINE_COOK", "LINE_COOK",
+                    "PREP_COOK", "PREP_COOK", "KITCHEN_PORTER", "KITCHEN_PORTER"
+                ]}),
+                ("sample_recipes", {}),
+                ("staff_pool", {"roles": ["LINE_COOK", "LINE_COOK", "PREP_COOK", "KITCHEN_PORTER"]}),
+                ("synthetic_recipes", {"count": 60, "pantry_size": 300}),
+                ("staff_requests", {"count": 40}),
+            ],
+            "A twelve-person brigade with four more in the staff pool, the sample recipes plus 60 "
+            "generated ones from a 300-ingredient pantry, and a backlog of 40 open staffing requests"
+        ),
+    )
+}
+
+
+def get_seed_profile(name: str) -> SeedProfile:
+    """Look up a seed profile by name"""
+    if name not in SEED_PROFILES:
+        raise ValueError(f"Unknown seed profile '{name}', expected one of {sorted(SEED_PROFILES)}")
+    return SEED_PROFILES[name]
@@ -0,0 +1,252 @@
+"""
+Kitchen Snapshot for ChefBench
+A read model of the whole kitchen, folded from events as they are logged, so one request sees it all at once
+"""
+
+import asyncio
+import copy
+import threading
+from collections import deque
+from typing import Dict, List, Optional, Any
+import logging
+
+from models.models import KitchenEvent, TaskType
+from database.event_store import EventStore, apply_order_event
+from equipment import STATION_EQUIPMENT, STATION_ROLES, station_for
+from equipment.simulator import OPERATIONAL, BROKEN, MAINTENANCE
+from kitchen.notifier import alerts_for
+from kitchen.progress import STATUS_EVENTS
+
+logger = logging.getLogger(__name__)
+
+ALERT_LOG_SIZE = 50  # latest alerts kept in the snapshot
+FINISHED_ITEMS = ("completed", "failed", "cancelled")
+# Equipment events and the status each leaves an item in
+EQUIPMENT_STATUS_EVENTS = {
+    "equipment_failed": BROKEN,
+    "maintenance_started": MAINTENANCE,
+    "equipment_restored": OPERATIONAL,
+}
+
+
+class KitchenReadModel:
+    """Orders, stations, staff, inventory and alerts of the latest run, kept current by its events
+
+    A scenario_started starts the model afresh for that run; events of
+    other runs are ignored. Orders are the items placed for each table,
+    with the kitchen's own tasks under no table; items read as queued until
+    executed or cancelled, or held while the pass holds their course, as in
+    the event store's order view. Every
+    snapshot is taken under one lock, so its parts agree as of
+    last_event_id.
+    """
+
+    def __init__(self):
+        self._lock = threading.Lock()
+        self._reset(None)
+
+    def _reset(self, run_id: Optional[str]):
+        self.run_id = run_id
+        self.status = "idle" if run_id is None else "running"
+        self.started_at: Optional[float] = None
+        self.items: Dict[str, Dict[str, Any]] = {}  # by task id, as folded by apply_order_event
+        self.assigned_ingredients: Dict[str, List[str]] = {}  # by task id
+        self.staff: Dict[str, Dict[str, Any]] = {}
+        self.equipment: Dict[str, Dict[str, Any]] = {}
+        self.spoiled: List[str] = []
+        self.holds: List[Dict[str, Any]] = []  # courses the pass is holding, as of its last call
+        self.alerts: deque = deque(maxlen=ALERT_LOG_SIZE)
+        self.alert_counts: Dict[str, int] = {}
+        self.events_applied = 0
+        self.last_event_id: Optional[int] = None
+        self.updated_at: Optional[float] = None
+
+    def apply(self, event: KitchenEvent):
+        """Fold one logged event into the model"""
+        with self._lock:
+            if event.event_type == "scenario_started" and event.run_id != self.run_id:
+                self._reset(event.run_id)
+            elif event.run_id != self.run_id:
+                return
+            self._apply(event)
+            self.events_applied += 1
+            self.last_event_id = event.event_id
+            self.updated_at = event.timestamp
+
+    def _apply(self, event: KitchenEvent):
+        payload = event.payload
+        kind = event.event_type
+
+        if kind in STATUS_EVENTS:
+            self.status = STATUS_EVENTS[kind]
+        if kind == "scenario_started":
+            self.started_at = event.timestamp
+            roles = payload.get("roles") or {}
+            for name in payload.get("agents", []):
+                self._staff(name, roles.get(name))
+            for name, equipment_kind in (payload.get("equipment") or {}).items():
+                self.equipment[name] = {"name": name, "kind": equipment_kind, "status": OPERATIONAL,
+                                        "temperature_status": None}
+        elif kind == "staff_assigned":
+            self._staff(event.agent_name, payload.get("role"))["status"] = "on_shift"
+        elif kind == "staff_released":
+            self.staff.pop(event.agent_name, None)
+        elif kind == "agent_paused":
+            self._staff(event.agent_name)["status"] = "paused"
+        elif kind in EQUIPMENT_STATUS_EVENTS:
+            item = self.equipment.get(payload.get("equipment"))
+            if item is not None:
+                item["status"] = EQUIPMENT_STATUS_EVENTS[kind]
+        elif kind in ("temperature_alert", "temperature_normal"):
+            item = self.equipment.get(payload.get("equipment"))
+            if item is not None:
+                item["temperature_status"] = payload.get("threshold", "ok")
+        elif kind == "chaos_injected" and payload.get("action") == "spoil_inventory":
+            self.spoiled = sorted(set(self.spoiled) | set(payload.get("ingredients") or []))
+        elif kind in ("course_held", "course_fired"):
+            self.holds = list(payload.get("holding") or [])
+
+        if kind == "task_assigned" and event.task_id is not None:
+            self.assigned_ingredients[event.task_id] = list(payload.get("ingredients") or [])
+        if kind == "task_executed" and event.agent_name in self.staff:
+            key = "tasks_completed" if payload.get("success") else "tasks_failed"
+            self.staff[event.agent_name][key] += 1
+        apply_order_event(self.items, event)
+
+        for alert in alerts_for(event):
+            alert_data = alert.to_dict()
+            alert_data["timestamp"] = event.timestamp
+            self.alerts.append(alert_data)
+            self.alert_counts[alert.severity] = self.alert_counts.get(alert.severity, 0) + 1
+
+    def _staff(self, name: str, role: Optional[str] = None) -> Dict[str, Any]:
+        member = self.staff.setdefault(name, {
+            "name": name,
+            "role": role,
+            "status": "on_shift",
+            "tasks_completed": 0,
+            "tasks_failed": 0
+        })
+        member["role"] = role or member["role"]
+        return member
+
+    def snapshot(self) -> Dict[str, Any]:
+        """The whole kitchen as of the last event folded in"""
+        with self._lock:
+            items = [self._item(item) for item in self.items.values()]
+            return copy.deepcopy({
+                "run_id": self.run_id,
+                "status": self.status,
+                "last_event_id": self.last_event_id,
+                "events_applied": self.events_applied,
+                "started_at": self.started_at,
+                "updated_at": self.updated_at,
+                "orders": self._orders(items),
+                "stations": self._stations(items),
+                "holds": list(self.holds),
+                "staff": self._staff_report(items),
+                "inventory": self._inventory(items),
+                "alerts": {"counts": dict(self.alert_counts), "recent": list(reversed(self.alerts))}
+            })
+
+    def _item(self, order: Dict[str, Any]) -> Dict[str, Any]:
+        try:
+            station = station_for(TaskType.from_function_name(order["task_type"])) if order["task_type"] else None
+        except ValueError:
+            station = None
+        return {**order, "station": station, "ingredients": self.assigned_ingredients.get(order["task_id"], [])}
+
+    def _orders(self, items: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+        tables: Dict[Any, List[Dict[str, Any]]] = {}
+        for item in items:
+            tables.setdefault(item["table"], []).append(item)
+        orders = []
+        for table, table_items in tables.items():
+            counts: Dict[str, int] = {}
+            for item in table_items:
+                counts[item["status"]] = counts.get(item["status"], 0) + 1
+            orders.append({
+                "table": table,
+                "status": "closed" if all(i["status"] in FINISHED_ITEMS for i in table_items) else "open",
+                "placed_at": min(i["placed_at"] for i in table_items),
+                "item_counts": counts,
+                "items": table_items
+            })
+        # Tables in the order they were seated, the kitchen's own tasks last
+        return sorted(orders, key=lambda o: (o["table"] is None, o["placed_at"]))
+
+    def _open_items(self, items: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+        return [i for i in items if i["status"] not in FINISHED_ITEMS]
+
+    def _stations(self, items: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+        stations = []
+        open_items = self._open_items(items)
+        for station, kinds in STATION_EQUIPMENT.items():
+            staff = sorted(
+                name for name, member in self.staff.items()
+                if member["role"] == STATION_ROLES[station].name and member["status"] == "on_shift"
+            )
+            orders = [i["task_id"] for i in open_items if i["station"] == station]
+            equipment = [e for e in self.equipment.values() if e["kind"] in kinds]
+            stations.append({
+                "name": station,
+                "role": STATION_ROLES[station].name,
+                "staff": staff,
+                "active_orders": len(orders),
+                "held": sum(1 for i in open_items if i["station"] == station and i["status"] == "held"),
+                "load": round(len(orders) / max(len(staff), 1), 2),
+                "orders": orders,
+                "equipment": equipment,
+                "equipment_down": [e["name"] for e in equipment if e["status"] != OPERATIONAL]
+            })
+        return stations
+
+    def _staff_report(self, items: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+        assigned: Dict[str, int] = {}
+        for item in self._open_items(items):
+            assigned[item["agent_name"]] = assigned.get(item["agent_name"], 0) + 1
+        return [
+            {**member, "open_orders": assigned.get(name, 0)}
+            for name, member in sorted(self.staff.items())
+        ]
+
+    def _inventory(self, items: List[Dict[str, Any]]) -> Dict[str, Any]:
+        held: Dict[str, int] = {}
+        used: Dict[str, int] = {}
+        for item in items:
+            counts = used if item["status"] == "completed" else held if item["status"] not in FINISHED_ITEMS else None
+            if counts is None:
+                continue
+            for ingredient in item["ingredients"]:
+                counts[ingredient] = counts.get(ingredient, 0) + 1
+        stocked = {i for ingredients in self.assigned_ingredients.values() for i in ingredients}
+        return {
+            "stocked": len(stocked - set(self.spoiled)),
+            "spoiled": list(self.spoiled),
+            "held_by_open_orders": dict(sorted(held.items())),
+            "used": dict(sorted(used.items()))
+        }
+
+    def catch_up(self, event_store: EventStore) -> int:
+        """Fold the latest run's events logged so far, returning the id to follow from"""
+        latest = event_store.latest_event_id()
+        started = event_store.query(event_type="scenario_started")
+        if started:
+            for event in event_store.query(run_id=started[-1].run_id, after_id=started[-1].event_id - 1):
+                if event.event_id > latest:
+                    break
+                self.apply(event)
+        return latest
+
+    async def follow(self, event_store: EventStore, interval_seconds: float = 0.5):
+        """Keep the model current with the store for as long as the server is up"""
+        latest = await asyncio.to_thread(self.catch_up, event_store)
+        while True:
+            try:
+                events = await asyncio.to_thread(event_store.query, after_id=latest, limit=500)
+                for event in events:
+                    latest = event.event_id
+                    self.apply(event)
+            except Exception as e:
+                logger.error(f"Kitchen snapshot update failed: {e}")
+            await asyncio.sleep(interval_seconds)
@@ -0,0 +1,106 @@
+"""
+Guided Tutorial Scenario for ChefBench
+Walks new users through creating a team, running a scenario and reading the results
+"""
+
+from typing import Dict, List, Any
+
+from models.models import KitchenEvent, TaskType
+
+# Small, fast scenario that exercises assignment, messaging and quality checks
+TUTORIAL_TASK_DISTRIBUTION = [
+    (TaskType.MENU_PLANNING, 1),
+    (TaskType.INGREDIENT_PREPARATION, 1),
+    (TaskType.COOKING_EXECUTION, 1),
+    (TaskType.QUALITY_CONTROL, 1)
+]
+
+TUTORIAL_STEPS = [
+    {
+        "id": "create_team",
+        "title": "Hire a kitchen brigade",
+        "hint": "POST /teams/create_uniform with a model_name to create a head chef, sous chef, line cook and prep cook."
+    },
+    {
+        "id": "start_run",
+        "title": "Start the tutorial service",
+        "hint": "POST /scenarios/execute with scenario_type \"tutorial\". Keep the returned evaluation_id."
+    },
+    {
+        "id": "watch_run",
+        "title": "Watch the brigade work",
+        "hint": "Follow GET /events/stream?run_id=<evaluation_id> or poll GET /scenarios/<evaluation_id>/hints for commentary."
+    },
+    {
+        "id": "view_results",
+        "title": "Review the evaluation",
+        "hint": "GET /scenarios/<evaluation_id>/results for metrics, then GET /metrics/report for the full markdown report."
+    }
+]
+
+
+def tutorial_progress(agent_count: int, evaluations: Dict[str, Dict[str, Any]]) -> Dict[str, Any]:
+    """Mark tutorial steps complete based on the current server state"""
+    tutorial_runs = [
+        e for e in evaluations.values()
+        if e["config"].get("scenario_type") == "tutorial"
+    ]
+    done = {
+        "create_team": agent_count >= 2,
+        "start_run": bool(tutorial_runs),
+        "watch_run": any(e["status"] != "running" for e in tutorial_runs),
+        "view_results": any(e.get("results_viewed") for e in tutorial_runs)
+    }
+
+    steps = [{**step, "completed": done[step["id"]]} for step in TUTORIAL_STEPS]
+    next_step = next((step for step in steps if not step["completed"]), None)
+
+    return {
+        "steps": steps,
+        "next_step": next_step,
+        "completed": next_step is None
+    }
+
+
+def hints_for_events(events: List[KitchenEvent]) -> List[Dict[str, Any]]:
+    """Turn raw run events into plain-language commentary for new users"""
+    hints = []
+    explained = set()
+
+    for event in events:
+        payload = event.payload
+        text = None
+
+        if event.event_type == "scenario_started":
+            text = (f"The service has started with {payload.get('total_tasks', 0)} tasks "
+                    f"and {len(payload.get('agents', []))} agents.")
+        elif event.event_type == "task_assigned" and "task_assigned" not in explained:
+            text = (f"{event.task_id} ({payload.get('task_type')}) went to {event.agent_name}. "
+                    f"Tasks go to capable agents chosen by the '{payload.get('policy')}' policy; "
+                    f"each TaskType has a minimum role level.")
+        elif event.event_type == "message_sent" and "message_sent" not in explained:
+            text = (f"{event.agent_name} messaged {payload.get('recipient')}: \"{payload.get('content')}\". "
+                    f"Messages from higher-ranked chefs affect authority compliance.")
+        elif event.event_type == "task_executed":
+            if not payload.get("success"):
+                text = (f"{event.agent_name} failed {payload.get('task_type')} "
+                        f"({payload.get('chosen_approach')}). Failures lower the success rate.")
+            elif payload.get("quality_score", 1) < 0.7:
+                text = (f"{event.agent_name} finished {payload.get('task_type')} with quality "
+                        f"{payload.get('quality_score', 0):.2f}; below 0.7 the head chef will flag it.")
+            elif "task_executed" not in explained:
+                text = (f"{event.agent_name} completed {payload.get('task_type')} with quality "
+                        f"{payload.get('quality_score', 0):.2f}.")
+        elif event.event_type == "scenario_completed":
+            text = (f"Service is over: success rate {payload.get('overall_success_rate', 0):.0%}. "
+                    f"Open the results to see per-agent metrics.")
+
+        if text:
+            explained.add(event.event_type)
+            hints.append({
+                "event_id": event.event_id,
+                "timestamp": event.timestamp,
+                "hint": text
+            })
+
+    return hints
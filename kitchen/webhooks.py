@@ -0,0 +1,377 @@
+"""
+Webhooks for ChefBench
+Posts signed notifications of run and order lifecycle events to registered URLs, retrying failed deliveries
+"""
+
+import asyncio
+import hashlib
+import hmac
+import ipaddress
+import json
+import secrets
+import socket
+import time
+import uuid
+from collections import deque
+from dataclasses import dataclass, field, asdict
+from pathlib import Path
+from typing import Callable, Collection, Dict, List, Optional, Set, Tuple, Any
+from urllib.parse import urlsplit, urlunsplit
+import logging
+
+import httpx
+
+from models.models import KitchenEvent
+from database.event_store import EventStore
+from dining.floor import ORDER_TASKS
+
+logger = logging.getLogger(__name__)
+
+ORDER_COMPLETED = "order_completed"
+RUN_COMPLETED = "run_completed"
+QUALITY_FAILED = "quality_failed"
+CHAOS_INJECTED = "chaos_injected"
+PING = "ping"  # sent only by a test delivery
+
+WEBHOOK_EVENTS = (ORDER_COMPLETED, RUN_COMPLETED, QUALITY_FAILED, CHAOS_INJECTED)
+
+EVENT_HEADER = "X-ChefBench-Event"
+DELIVERY_HEADER = "X-ChefBench-Delivery"
+TIMESTAMP_HEADER = "X-ChefBench-Timestamp"
+SIGNATURE_HEADER = "X-ChefBench-Signature"
+
+DEFAULT_MIN_QUALITY = 0.5
+MAX_ATTEMPTS = 4
+RETRY_BACKOFF_SECONDS = 1.0  # doubled after every failed attempt
+DELIVERY_TIMEOUT_SECONDS = 10.0
+DELIVERY_LOG_SIZE = 500
+ALLOWED_HOSTS_ENV = "CHEFBENCH_WEBHOOK_ALLOWED_HOSTS"
+
+
+def sign(secret: str, timestamp: str, body: bytes) -> str:
+    """HMAC-SHA256 of "<timestamp>.<body>", as receivers should recompute it to verify a delivery"""
+    digest = hmac.new(secret.encode(), timestamp.encode() + b"." + body, hashlib.sha256).hexdigest()
+    return f"sha256={digest}"
+
+
+async def post_with_retries(
+    url: str,
+    body: bytes,
+    headers: Callable[[], Dict[str, str]],
+    transport: Optional[httpx.AsyncBaseTransport] = None,
+    backoff_seconds: float = RETRY_BACKOFF_SECONDS,
+    address: Optional[str] = None
+) -> Tuple[int, Optional[int], Optional[str]]:
+    """POST a body until it lands or fails for good, as (attempts, last status code, error or None)
+
+    Connection errors, 429 and 5xx responses are retried with doubling
+    backoff, up to MAX_ATTEMPTS; any other response is final. headers is
+    called before every attempt. With an address, every attempt connects to
+    it rather than resolving the host again, still naming the host in the
+    Host header and to TLS.
+    """
+    attempts, status_code, error = 0, None, None
+    extensions: Dict[str, Any] = {}
+    fixed_headers: Dict[str, str] = {}
+    if address:
+        parts = urlsplit(url)
+        netloc = f"[{address}]" if ":" in address else address
+        url = urlunsplit(parts._replace(netloc=f"{netloc}:{parts.port}" if parts.port else netloc))
+        fixed_headers["Host"] = parts.netloc.rsplit("@", 1)[-1]
+        if parts.scheme == "https":
+            extensions["sni_hostname"] = parts.hostname
+    async with httpx.AsyncClient(timeout=DELIVERY_TIMEOUT_SECONDS, transport=transport) as client:
+        while attempts < MAX_ATTEMPTS:
+            if attempts:
+                await asyncio.sleep(backoff_seconds * 2 ** (attempts - 1))
+            attempts += 1
+            try:
+                response = await client.post(
+                    url, content=body, headers={**headers(), **fixed_headers}, extensions=extensions
+                )
+            except httpx.HTTPError as e:
+                error = f"{type(e).__name__}: {e}"
+                continue
+            status_code = response.status_code
+            if response.is_success:
+                return attempts, status_code, None
+            error = f"HTTP {status_code}"
+            if status_code != 429 and status_code < 500:
+                break
+    return attempts, status_code, error
+
+
+def check_destination(url: str, allowed_hosts: Collection[str] = ()) -> Optional[str]:
+    """The public address to post url to; raises ValueError unless its host is public, or allowed by name
+
+    Whoever registers a webhook decides where the server posts, so it must not
+    be the server itself or anything else on its private network. A host name
+    is resolved, and every address it resolves to has to be public. A host
+    allowed by name isn't resolved, and gives None.
+    """
+    host = urlsplit(url).hostname
+    if not host:
+        raise ValueError("url has no host")
+    if host.lower() in {h.lower() for h in allowed_hosts}:
+        return None
+    try:
+        addresses = [ipaddress.ip_address(host)]
+    except ValueError:
+        try:
+            addresses = [
+                ipaddress.ip_address(info[4][0].split("%")[0])
+                for info in socket.getaddrinfo(host, None, proto=socket.IPPROTO_TCP)
+            ]
+        except socket.gaierror as e:
+            raise ValueError(f"Can't resolve {host}: {e}")
+    if not addresses:
+        raise ValueError(f"Can't resolve {host}")
+    for address in addresses:
+        address = getattr(address, "ipv4_mapped", None) or address
+        if not address.is_global:
+            raise ValueError(
+                f"{host} is a loopback, private or reserved address ({address}); "
+                f"list it in ${ALLOWED_HOSTS_ENV} to allow it"
+            )
+    return str(addresses[0])
+
+
+def webhook_events(event: KitchenEvent, min_quality: float = DEFAULT_MIN_QUALITY) -> List[Tuple[str, Dict[str, Any]]]:
+    """The (webhook event, data) pairs a kitchen event produces; most produce none
+
+    Only order tasks report on their execution: a cooked order as completed,
+    and a failed or poorly scored one as a quality failure.
+    """
+    payload = event.payload
+    if event.event_type == "task_executed" and payload.get("task_type") in ORDER_TASKS:
+        data = {
+            "task_id": event.task_id,
+            "task_type": payload.get("task_type"),
+            "agent": event.agent_name,
+            "success": payload.get("success"),
+            "quality_score": payload.get("quality_score"),
+            "execution_time": payload.get("execution_time"),
+        }
+        if not payload.get("success"):
+            return [(QUALITY_FAILED, {**data, "reason": "failed"})]
+        produced = [(ORDER_COMPLETED, data)]
+        if (payload.get("quality_score") or 0.0) < min_quality:
+            produced.append((QUALITY_FAILED, {**data, "reason": "below_min_quality", "min_quality": min_quality}))
+        return produced
+    if event.event_type == "scenario_completed":
+        return [(RUN_COMPLETED, {"status": "completed", **payload})]
+    if event.event_type == "scenario_failed":
+        return [(RUN_COMPLETED, {"status": "failed", **payload})]
+    if event.event_type == "chaos_injected":
+        return [(CHAOS_INJECTED, dict(payload))]
+    return []
+
+
+@dataclass
+class Webhook:
+    """A URL notified of the events it subscribes to; no events means all of them"""
+    url: str
+    secret: str
+    events: List[str] = field(default_factory=list)
+    min_quality: float = DEFAULT_MIN_QUALITY  # quality below which an executed order counts as a quality failure
+    enabled: bool = True
+    webhook_id: str = field(default_factory=lambda: uuid.uuid4().hex[:12])
+    created_at: float = field(default_factory=time.time)
+
+    def validate(self):
+        if not self.url.startswith(("http://", "https://")):
+            raise ValueError("url must be http:// or https://")
+        unknown = sorted(set(self.events) - set(WEBHOOK_EVENTS))
+        if unknown:
+            raise ValueError(f"Unknown webhook events {unknown}, expected some of {list(WEBHOOK_EVENTS)}")
+        if not 0 <= self.min_quality <= 1:
+            raise ValueError("min_quality must be between 0 and 1")
+        if not self.secret:
+            raise ValueError("secret must not be empty")
+
+    def subscribes_to(self, name: str) -> bool:
+        return self.enabled and (not self.events or name in self.events)
+
+    def to_dict(self, include_secret: bool = False) -> Dict[str, Any]:
+        data = asdict(self)
+        if not include_secret:
+            del data["secret"]
+        return data
+
+
+@dataclass
+class WebhookDelivery:
+    """One notification sent to one webhook, with every attempt it took"""
+    delivery_id: str
+    webhook_id: str
+    event: str
+    run_id: Optional[str]
+    attempts: int = 0
+    status_code: Optional[int] = None
+    delivered: bool = False
+    error: Optional[str] = None
+    sent_at: float = field(default_factory=time.time)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class WebhookDispatcher:
+    """Registered webhooks, and delivery of the event log's lifecycle events to them
+
+    Follows the shared event store from where it was when the server started,
+    so every session's runs are reported, and events from before startup are
+    not. Each delivery is a JSON POST signed with the webhook's secret. It is
+    retried with backoff on connection errors, 429 and 5xx responses, up to
+    MAX_ATTEMPTS; other responses are final. URLs must point at public
+    addresses, except for the allowed_hosts named. That is checked when a
+    webhook is registered or loaded, and again for every delivery, which
+    then connects to the address it checked.
+    """
+
+    def __init__(
+        self,
+        path: Optional[str] = None,
+        transport: Optional[httpx.AsyncBaseTransport] = None,
+        backoff_seconds: float = RETRY_BACKOFF_SECONDS,
+        allowed_hosts: Collection[str] = ()
+    ):
+        self.path = Path(path) if path else None
+        self.transport = transport
+        self.backoff_seconds = backoff_seconds
+        self.allowed_hosts = list(allowed_hosts)
+        self.webhooks: Dict[str, Webhook] = {}
+        self.deliveries: deque = deque(maxlen=DELIVERY_LOG_SIZE)
+        self._pending: Set[asyncio.Task] = set()
+
+        if self.path and self.path.exists():
+            self.load()
+
+    def add(self, url: str, secret: Optional[str] = None, events: Optional[List[str]] = None,
+            min_quality: float = DEFAULT_MIN_QUALITY) -> Webhook:
+        """Register a webhook; without a secret one is generated"""
+        webhook = Webhook(url=url, secret=secret or secrets.token_hex(16), events=list(events or []),
+                          min_quality=min_quality)
+        webhook.validate()
+        check_destination(webhook.url, self.allowed_hosts)
+        self.webhooks[webhook.webhook_id] = webhook
+        self.save()
+        return webhook
+
+    def update(self, webhook_id: str, **changes: Any) -> Webhook:
+        """Change a webhook's settings; raises KeyError if it isn't registered"""
+        webhook = self.webhooks[webhook_id]
+        candidate = Webhook(**{**asdict(webhook), **changes})
+        candidate.validate()
+        if "url" in changes:
+            check_destination(candidate.url, self.allowed_hosts)
+        self.webhooks[webhook_id] = candidate
+        self.save()
+        return candidate
+
+    def remove(self, webhook_id: str) -> Webhook:
+        """Unregister a webhook; raises KeyError if it isn't registered"""
+        webhook = self.webhooks.pop(webhook_id)
+        self.save()
+        return webhook
+
+    def history(self, webhook_id: Optional[str] = None) -> List[Dict[str, Any]]:
+        """Recent deliveries, newest first"""
+        return [
+            d.to_dict() for d in reversed(self.deliveries)
+            if webhook_id is None or d.webhook_id == webhook_id
+        ]
+
+    def dispatch(self, event: KitchenEvent):
+        """Start delivering what an event produces to every webhook subscribed to it"""
+        for webhook in list(self.webhooks.values()):
+            for name, data in webhook_events(event, webhook.min_quality):
+                if webhook.subscribes_to(name):
+                    task = asyncio.create_task(self.deliver(webhook, name, data, event.run_id, event.event_id))
+                    self._pending.add(task)
+                    task.add_done_callback(self._pending.discard)
+
+    async def deliver(
+        self,
+        webhook: Webhook,
+        name: str,
+        data: Dict[str, Any],
+        run_id: Optional[str] = None,
+        event_id: Optional[int] = None
+    ) -> WebhookDelivery:
+        """POST one notification, retrying until it lands, fails for good, or runs out of attempts"""
+        delivery = WebhookDelivery(uuid.uuid4().hex, webhook.webhook_id, name, run_id)
+        self.deliveries.append(delivery)
+        body = json.dumps({
+            "delivery_id": delivery.delivery_id,
+            "event": name,
+            "run_id": run_id,
+            "event_id": event_id,
+            "data": data
+        }, default=str).encode()
+
+        def headers() -> Dict[str, str]:
+            # Signed per attempt, so receivers can reject stale timestamps
+            timestamp = str(int(time.time()))
+            return {
+                "Content-Type": "application/json",
+                EVENT_HEADER: name,
+                DELIVERY_HEADER: delivery.delivery_id,
+                TIMESTAMP_HEADER: timestamp,
+                SIGNATURE_HEADER: sign(webhook.secret, timestamp, body),
+            }
+
+        try:
+            # Resolved again, so a host that has since moved to a private address is refused
+            address = await asyncio.to_thread(check_destination, webhook.url, self.allowed_hosts)
+        except ValueError as e:
+            delivery.error = f"Refused: {e}"
+        else:
+            delivery.attempts, delivery.status_code, delivery.error = await post_with_retries(
+                webhook.url, body, headers, self.transport, self.backoff_seconds, address
+            )
+        delivery.delivered = delivery.error is None
+        if not delivery.delivered:
+            logger.warning(f"Webhook {webhook.webhook_id} {name} delivery failed after "
+                           f"{delivery.attempts} attempts: {delivery.error}")
+        return delivery
+
+    async def follow(self, event_store: EventStore, interval_seconds: float = 1.0):
+        """Dispatch new events from the store for as long as the server is up"""
+        latest = await asyncio.to_thread(event_store.latest_event_id)
+        while True:
+            try:
+                events = await asyncio.to_thread(event_store.query, after_id=latest, limit=500)
+                for event in events:
+                    latest = event.event_id
+                    if self.webhooks:
+                        self.dispatch(event)
+            except Exception as e:
+                logger.error(f"Webhook dispatch failed: {e}")
+            await asyncio.sleep(interval_seconds)
+
+    def save(self):
+        """Persist the webhooks, secrets included, if a path is configured"""
+        if not self.path:
+            return
+
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        with open(self.path, 'w') as f:
+            json.dump([w.to_dict(include_secret=True) for w in self.webhooks.values()], f, indent=2)
+
+    def load(self):
+        """Load the webhooks from disk, leaving out any whose URL is invalid or not allowed"""
+        with open(self.path, 'r', encoding='utf-8') as f:
+            data = json.load(f)
+
+        self.webhooks = {}
+        for entry in data:
+            try:
+                webhook = Webhook(**entry)
+                webhook.validate()
+                check_destination(webhook.url, self.allowed_hosts)
+            except (ValueError, TypeError) as e:
+                logger.error(f"Ignoring webhook {entry.get('webhook_id')} in {self.path}: {e}")
+                continue
+            self.webhooks[webhook.webhook_id] = webhook
+        logger.info(f"Loaded {len(self.webhooks)} webhooks from {self.path}")
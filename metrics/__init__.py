@@ -2,5 +2,15 @@
 Metrics and Analytics Module
 """
 from .collector import MetricsCollector
+from .scoring import (
+    SCORING_PROFILES, ScoringProfile, ScoringConfig, get_scoring_profile, get_scoring_config, set_scoring_config,
+    score_run
+)
+from .daily import plate_cost, build_daily_report, DailyReportStore
+from .trends import TREND_GROUPS, TREND_INTERVALS, TREND_METRICS, build_trends
 
-__all__ = ['MetricsCollector']
\ No newline at end of file
+__all__ = [
+    'MetricsCollector', 'SCORING_PROFILES', 'ScoringProfile', 'ScoringConfig', 'get_scoring_profile',
+    'get_scoring_config', 'set_scoring_config', 'score_run',
+    'plate_cost', 'build_daily_report', 'DailyReportStore', 'TREND_GROUPS', 'TREND_INTERVALS', 'TREND_METRICS', 'build_trends'
+]
\ No newline at end of file
@@ -14,6 +14,8 @@ import seaborn as sns
 from collections import defaultdict
 import logging
 
+from .scoring import rank_runs
+
 logger = logging.getLogger(__name__)
 
 # Set style for better-looking plots
@@ -54,7 +56,10 @@ class MetricsCollector:
             "scenario_name": scenario_name,
             "config": scenario_config,
             "metrics": coordinator_metrics,
-            "duration": coordinator_metrics.get("duration", 0)
+            "duration": coordinator_metrics.get("duration", 0),
+            "seed": scenario_config.get("seed"),
+            "eta": coordinator_metrics.get("eta"),
+            "scores": coordinator_metrics.get("scores")
         }
         
         self.scenario_results.append(result)
@@ -82,6 +87,26 @@ class MetricsCollector:
         with open(filepath, 'w') as f:
             json.dump(result, f, indent=2, default=str)
     
+    def runs_on(self, day: str) -> List[Dict]:
+        """Every saved scenario result recorded on a day (YYYY-MM-DD), including earlier sessions'"""
+        return [r for r in self.saved_runs() if str(r.get("timestamp", "")).startswith(day)]
+    
+    def saved_runs(self, since: Optional[str] = None, until: Optional[str] = None) -> List[Dict]:
+        """Every saved scenario result, oldest first, optionally from since to until (YYYY-MM-DD, inclusive)"""
+        runs = []
+        for path in self.data_dir.glob("*.json"):
+            try:
+                with open(path, 'r', encoding='utf-8') as f:
+                    result = json.load(f)
+            except (OSError, ValueError) as e:
+                logger.error(f"Skipping unreadable result {path}: {e}")
+                continue
+            day = str(result.get("timestamp", ""))[:10]
+            if (since and day < since) or (until and day > until):
+                continue
+            runs.append(result)
+        return sorted(runs, key=lambda r: str(r.get("timestamp", "")))
+    
     def analyze_model_comparison(
         self, 
         model_groups: Dict[str, List[str]]
@@ -113,6 +138,20 @@ class MetricsCollector:
         self.model_comparisons = comparison
         return comparison
     
+    def leaderboard(self, profile: str, limit: Optional[int] = None) -> List[Dict[str, Any]]:
+        """Recorded runs ranked by their score under one profile"""
+        runs = [
+            {
+                "timestamp": r["timestamp"],
+                "scenario_name": r["scenario_name"],
+                "assignment_policy": r["config"].get("assignment_policy"),
+                "seed": r.get("seed"),
+                "scores": r["scores"]
+            }
+            for r in self.scenario_results if r.get("scores")
+        ]
+        return rank_runs(runs, profile, limit)
+    
     def generate_charts(self) -> List[Path]:
         """Generate comprehensive visualization charts"""
         generated_files = []
@@ -346,12 +385,64 @@ class MetricsCollector:
                 f.write(f"### {result['scenario_name']}\n")
                 f.write(f"- Timestamp: {result['timestamp']}\n")
                 f.write(f"- Duration: {result['duration']:.2f}s\n")
+                f.write(f"- Seed: {result.get('seed')}\n")
+                if result.get("eta"):
+                    eta = result["eta"]
+                    f.write(f"- ETA: predicted {eta['predicted_seconds']:.2f}s, error {eta['error_seconds']:+.2f}s\n")
+                if result.get("scores"):
+                    scores = result["scores"]
+                    others = ", ".join(f"{name} {value:.3f}" for name, value in scores["profiles"].items())
+                    f.write(f"- Score ({scores['profile']}): {scores['score']:.3f} [{others}]\n")
                 
                 team_metrics = result["metrics"].get("agent_metrics", {}).get("team", {})
                 f.write(f"- Success Rate: {team_metrics.get('overall_success_rate', 0):.3f}\n")
                 f.write(f"- Average Quality: {team_metrics.get('average_quality', 0):.3f}\n")
+                if team_metrics.get("quality_by_category"):
+                    categories = ", ".join(
+                        f"{name} {score:.3f}" for name, score in team_metrics["quality_by_category"].items()
+                    )
+                    rubric = result["metrics"].get("quality_rubric", "default")
+                    f.write(f"- Quality by Category ({rubric} rubric): {categories}\n")
                 f.write(f"- Total Messages: {team_metrics.get('total_messages', 0)}\n")
-                f.write(f"- Unique Collaborations: {team_metrics.get('unique_collaborations', 0)}\n\n")
+                f.write(f"- Unique Collaborations: {team_metrics.get('unique_collaborations', 0)}\n")
+                f.write(f"- Memory Consistency: {team_metrics.get('memory_consistency', 0):.3f}\n")
+                f.write(f"- Role Coherence: {team_metrics.get('role_coherence', 1.0):.3f} "
+                        f"({team_metrics.get('permission_violations', 0)} permission violations)\n")
+                f.write(f"- Labor Cost: ${team_metrics.get('labor_cost', 0):.2f} "
+                        f"(${team_metrics.get('cost_per_successful_task', 0):.2f} per successful task)\n")
+                if "reliability" in team_metrics:
+                    budget = (result["metrics"].get("agent_metrics", {}).get("reliability") or {}).get("budget")
+                    f.write(f"- Reliability: {team_metrics['reliability']:.3f} "
+                            f"({team_metrics.get('task_errors', 0)} task errors against a budget of "
+                            f"{budget} per agent)\n")
+                if team_metrics.get("degraded"):
+                    paused = ", ".join(team_metrics.get("paused_agents", [])) or "none"
+                    f.write(f"- Degraded: {team_metrics.get('degraded_tasks', 0)} tasks decided by fallback "
+                            f"heuristics after model failures; paused agents: {paused}\n")
+                if "invalid_action_rate" in team_metrics:
+                    by_model = ", ".join(
+                        f"{model} {m['invalid_action_rate']:.3f}"
+                        for model, m in team_metrics.get("invalid_actions_by_model", {}).items()
+                    )
+                    f.write(f"- Invalid Actions: {team_metrics['invalid_actions']} "
+                            f"({team_metrics['invalid_action_rate']:.3f} per task; by model: {by_model or 'n/a'})\n")
+                judgement = result["metrics"].get("judgement") or {}
+                if judgement.get("team"):
+                    scores = ", ".join(
+                        f"{name.replace('_', ' ')} {score:.3f}" for name, score in judgement["team"].items()
+                    )
+                    f.write(f"- LLM Judge ({judgement['judge']['model']}): {scores}\n")
+                    for agent_name, verdict in judgement.get("agents", {}).items():
+                        if verdict.get("rationale"):
+                            f.write(f"  - {agent_name}: {verdict['rationale']}\n")
+                if team_metrics.get("restriction_violations"):
+                    f.write(f"- Dietary Restriction Violations: {team_metrics['restriction_violations']} tasks "
+                            f"used restricted ingredients\n")
+                usage = (result["metrics"].get("usage") or {}).get("total")
+                if usage:
+                    f.write(f"- LLM Usage: {usage['total_tokens']} tokens over {usage['calls']} calls, "
+                            f"{usage['latency_seconds']:.1f}s generating, est. ${usage['cost']:.4f}\n")
+                f.write("\n")
             
             # Key Findings
             f.write("## Key Findings\n\n")
@@ -0,0 +1,135 @@
+"""
+End-of-Day Reports for ChefBench
+Roll a day's runs up into orders, ticket times, quality, labor and food cost, waste, top sellers and service periods
+"""
+
+import json
+import os
+import threading
+from collections import Counter, defaultdict
+from datetime import date, datetime
+from pathlib import Path
+from typing import Dict, List, Optional, Any
+import logging
+
+from dining import ORDER_TASKS, combine_periods
+
+logger = logging.getLogger(__name__)
+
+# Below this a served task fails the head chef's quality check
+QUALITY_FAILURE_THRESHOLD = 0.7
+
+# Amount of an ingredient that goes on one plate, by catalog unit
+PORTION_SIZES = {"g": 100, "ml": 50, "pieces": 1, "cloves": 2}
+
+TOP_SELLERS = 5
+
+
+def plate_cost(ingredients: List[str], catalog) -> float:
+    """Cost of one portion of each catalog ingredient; unknown ingredients are free"""
+    cost = 0.0
+    for ingredient in ingredients:
+        info = catalog.get(ingredient)
+        if info:
+            cost += info.cost_per_unit * PORTION_SIZES.get(info.unit, 1)
+    return cost
+
+
+def build_daily_report(runs: List[Dict[str, Any]], day: date, catalog) -> Dict[str, Any]:
+    """Aggregate the runs recorded on one day
+
+    Orders are the runs' cooking and plating tasks. A failed order is thrown
+    away, so its food cost counts as waste as well as food cost. Service
+    periods sum the runs' covers and revenue by period name.
+    """
+    orders = []
+    failures: Dict[str, int] = defaultdict(int)
+    labor_cost = 0.0
+    cancelled = 0
+    for run in runs:
+        metrics = run.get("metrics", {})
+        team = metrics.get("agent_metrics", {}).get("team", {})
+        labor_cost += team.get("labor_cost", 0.0)
+        cancelled += (metrics.get("agent_metrics", {}).get("front_of_house") or {}).get("cancelled", 0)
+        for execution in metrics.get("execution_history", []):
+            if not execution.get("success") or execution.get("quality_score", 0.0) < QUALITY_FAILURE_THRESHOLD:
+                failures[execution["task_type"]] += 1
+            if execution["task_type"] in ORDER_TASKS:
+                orders.append(execution)
+
+    served = [o for o in orders if o.get("success")]
+    wasted = [o for o in orders if not o.get("success")]
+    food_cost = sum(plate_cost(o.get("ingredients", []), catalog) for o in orders)
+    waste_cost = sum(plate_cost(o.get("ingredients", []), catalog) for o in wasted)
+    sellers = Counter(o["task_type"] for o in served)
+
+    return {
+        "date": day.isoformat(),
+        "generated_at": datetime.now().isoformat(),
+        "runs": len(runs),
+        "orders": {
+            "total": len(orders),
+            "served": len(served),
+            "failed": len(wasted),
+            "cancelled": cancelled
+        },
+        "average_ticket_seconds": (
+            sum(o.get("execution_time", 0.0) for o in orders) / len(orders) if orders else None
+        ),
+        "quality_failures": {
+            "total": sum(failures.values()),
+            "by_task_type": dict(sorted(failures.items(), key=lambda kv: -kv[1]))
+        },
+        "labor_cost": round(labor_cost, 2),
+        "food_cost": round(food_cost, 2),
+        "waste": {"orders": len(wasted), "cost": round(waste_cost, 2)},
+        "top_sellers": [{"item": item, "orders": count} for item, count in sellers.most_common(TOP_SELLERS)],
+        "service_periods": combine_periods([
+            run["metrics"]["service_periods"] for run in runs if run.get("metrics", {}).get("service_periods")
+        ])
+    }
+
+
+class DailyReportStore:
+    """One JSON file per day, replaced when the day's report is regenerated"""
+
+    def __init__(self, directory: str = "data/reports"):
+        self.directory = Path(directory)
+        self.directory.mkdir(parents=True, exist_ok=True)
+        self._lock = threading.Lock()
+
+    def _path(self, day: str) -> Path:
+        return self.directory / f"eod-{day}.json"
+
+    def save(self, report: Dict[str, Any]):
+        path = self._path(report["date"])
+        tmp = path.with_suffix(".json.tmp")
+        with self._lock:
+            with open(tmp, 'w') as f:
+                json.dump(report, f, indent=2, default=str)
+            os.replace(tmp, path)
+
+    def load(self, day: str) -> Optional[Dict[str, Any]]:
+        path = self._path(day)
+        if not path.exists():
+            return None
+        with open(path, 'r', encoding='utf-8') as f:
+            return json.load(f)
+
+    def list(self) -> List[Dict[str, Any]]:
+        """A one-line summary of every saved report, newest day first"""
+        summaries = []
+        for path in sorted(self.directory.glob("eod-*.json"), reverse=True):
+            try:
+                with open(path, 'r', encoding='utf-8') as f:
+                    report = json.load(f)
+            except (OSError, ValueError) as e:
+                logger.error(f"Skipping unreadable report {path}: {e}")
+                continue
+            summaries.append({
+                "date": report["date"],
+                "generated_at": report["generated_at"],
+                "runs": report["runs"],
+                "orders": report["orders"]["total"]
+            })
+        return summaries
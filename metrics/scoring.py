@@ -0,0 +1,416 @@
+"""
+Scoring Profiles for ChefBench
+Weight business outcomes, quality, speed, safety and cost into one score per kitchen style
+"""
+
+import ast
+import json
+import os
+from dataclasses import dataclass, field
+from typing import Callable, Dict, List, Optional, Any
+import logging
+
+logger = logging.getLogger(__name__)
+
+SCORING_DIMENSIONS = ("business", "quality", "speed", "safety", "cost")
+
+DEFAULT_PROFILE = "balanced"
+DEFAULT_CONFIG_PATH = "configs/config.yaml"
+CONFIG_SECTION = "scoring"
+
+# What a composite formula can use: the dimensions, the profile's weighted mean, numbers,
+# + - * / and these functions
+FORMULA_NAMES = SCORING_DIMENSIONS + ("weighted",)
+FORMULA_FUNCTIONS: Dict[str, Callable[..., float]] = {
+    "min": min,
+    "max": max,
+    "mean": lambda *values: sum(values) / len(values),
+}
+FORMULA_OPERATORS: Dict[type, Callable[[float, float], float]] = {
+    ast.Add: lambda a, b: a + b,
+    ast.Sub: lambda a, b: a - b,
+    ast.Mult: lambda a, b: a * b,
+    ast.Div: lambda a, b: a / b if b else 0.0,
+}
+
+# A threshold may hold any dimension, or the profile's score, to a minimum
+THRESHOLD_METRICS = SCORING_DIMENSIONS + ("score",)
+
+# Task types whose failures put diners or staff at risk
+SAFETY_TASKS = {"quality_control", "temperature_monitoring", "equipment_maintenance", "cleaning"}
+
+# Labor cost per successful task that scores 0.5 on the cost dimension
+REFERENCE_COST_PER_TASK = 2.0
+
+# Share of a task's quality an order note of each severity takes off
+NOTE_WEIGHTS = {"info": 0.0, "warning": 0.1, "critical": 0.5}
+
+
+def compile_formula(text: str) -> ast.Expression:
+    """Parse a composite score formula, e.g. "0.6 * weighted + 0.4 * min(safety, quality)"
+
+    Raises ValueError for anything but numbers, dimension names, weighted,
+    + - * /, parentheses and min, max and mean.
+    """
+    try:
+        tree = ast.parse(text, mode="eval")
+    except SyntaxError as e:
+        raise ValueError(f"Formula '{text}' is not an expression: {e.msg}")
+    called = {id(node.func) for node in ast.walk(tree) if isinstance(node, ast.Call)}
+    for node in ast.walk(tree):
+        if isinstance(node, (ast.Expression, ast.Load, ast.USub, ast.UAdd)) or type(node) in FORMULA_OPERATORS:
+            continue
+        if isinstance(node, (ast.BinOp, ast.UnaryOp)):
+            continue  # their operators are checked as nodes of their own
+        if isinstance(node, ast.Constant) and type(node.value) in (int, float):
+            continue
+        if isinstance(node, ast.Call):
+            name = node.func.id if isinstance(node.func, ast.Name) else None
+            if name not in FORMULA_FUNCTIONS:
+                raise ValueError(f"Formula '{text}' calls unknown function '{name or ast.unparse(node.func)}', "
+                                 f"expected one of {list(FORMULA_FUNCTIONS)}")
+            if not node.args or node.keywords:
+                raise ValueError(f"Formula '{text}': {name} takes one or more positional arguments")
+            continue
+        if isinstance(node, ast.Name):
+            if node.id in FORMULA_NAMES or (id(node) in called and node.id in FORMULA_FUNCTIONS):
+                continue
+            raise ValueError(f"Formula '{text}' uses unknown name '{node.id}', expected some of {list(FORMULA_NAMES)}")
+        raise ValueError(f"Formula '{text}' uses unsupported syntax ({type(node).__name__})")
+    return tree
+
+
+def evaluate_formula(tree: ast.Expression, values: Dict[str, float]) -> float:
+    """A compiled formula's value, with division by zero counting as 0"""
+    def evaluate(node: ast.AST) -> float:
+        if isinstance(node, ast.Expression):
+            return evaluate(node.body)
+        if isinstance(node, ast.Constant):
+            return float(node.value)
+        if isinstance(node, ast.Name):
+            return values.get(node.id, 0.0)
+        if isinstance(node, ast.UnaryOp):
+            operand = evaluate(node.operand)
+            return -operand if isinstance(node.op, ast.USub) else operand
+        if isinstance(node, ast.BinOp):
+            return FORMULA_OPERATORS[type(node.op)](evaluate(node.left), evaluate(node.right))
+        return FORMULA_FUNCTIONS[node.func.id](*(evaluate(arg) for arg in node.args))
+    return evaluate(tree)
+
+
+@dataclass
+class ScoringProfile:
+    """Relative importance of each dimension for one kind of kitchen, and what a run must reach to pass"""
+    name: str
+    description: str
+    weights: Dict[str, float] = field(default_factory=dict)
+    thresholds: Dict[str, float] = field(default_factory=dict)  # dimension or "score" -> minimum to pass
+    formula: Optional[str] = None  # composite score in place of the weighted mean
+    source: str = "builtin"  # or the config file the profile was loaded from
+    _compiled: Optional[ast.Expression] = field(default=None, init=False, repr=False, compare=False)
+
+    def validate(self):
+        if not self.weights and not self.formula:
+            raise ValueError(f"Profile '{self.name}' needs weights or a formula")
+        unknown = sorted(set(self.weights) - set(SCORING_DIMENSIONS))
+        if unknown:
+            raise ValueError(f"Profile '{self.name}' weights unknown dimensions {unknown}, "
+                             f"expected some of {list(SCORING_DIMENSIONS)}")
+        for dimension, weight in self.weights.items():
+            if not isinstance(weight, (int, float)) or weight < 0:
+                raise ValueError(f"Profile '{self.name}' weight for {dimension} must be a non-negative number")
+        if self.weights and sum(self.weights.values()) <= 0:
+            raise ValueError(f"Profile '{self.name}' weights must not all be zero")
+        unknown = sorted(set(self.thresholds) - set(THRESHOLD_METRICS))
+        if unknown:
+            raise ValueError(f"Profile '{self.name}' has thresholds for unknown metrics {unknown}, "
+                             f"expected some of {list(THRESHOLD_METRICS)}")
+        for metric, minimum in self.thresholds.items():
+            if not isinstance(minimum, (int, float)) or not 0 <= minimum <= 1:
+                raise ValueError(f"Profile '{self.name}' threshold for {metric} must be between 0 and 1")
+        if self.formula is not None:
+            self._compiled = compile_formula(self.formula)
+
+    def weighted(self, dimensions: Dict[str, float]) -> float:
+        """Weighted mean of the dimension scores, each in [0, 1]"""
+        total = sum(self.weights.values())
+        if total <= 0:
+            return 0.0
+        return sum(dimensions.get(d, 0.0) * w for d, w in self.weights.items()) / total
+
+    def score(self, dimensions: Dict[str, float]) -> float:
+        """The profile's formula if it has one, clamped to [0, 1], otherwise the weighted mean"""
+        if self.formula is None:
+            return self.weighted(dimensions)
+        if self._compiled is None:
+            self._compiled = compile_formula(self.formula)
+        value = evaluate_formula(self._compiled, {**dimensions, "weighted": self.weighted(dimensions)})
+        return min(1.0, max(0.0, value))
+
+    def failed_thresholds(self, dimensions: Dict[str, float], score: float) -> List[Dict[str, Any]]:
+        """The thresholds a run fell short of; it passes when there are none"""
+        values = {**dimensions, "score": score}
+        return [
+            {"metric": metric, "minimum": minimum, "actual": round(values.get(metric, 0.0), 4)}
+            for metric, minimum in self.thresholds.items()
+            if values.get(metric, 0.0) < minimum
+        ]
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "name": self.name,
+            "description": self.description,
+            "weights": dict(self.weights),
+            "thresholds": dict(self.thresholds),
+            "formula": self.formula,
+            "source": self.source
+        }
+
+    @classmethod
+    def from_dict(cls, name: str, data: Dict[str, Any], source: str = "builtin") -> "ScoringProfile":
+        if not isinstance(data, dict):
+            raise ValueError(f"Profile '{name}' must be a mapping")
+        unknown = sorted(set(data) - {"description", "weights", "thresholds", "formula"})
+        if unknown:
+            raise ValueError(f"Profile '{name}' has unknown keys {unknown}")
+        profile = cls(
+            name=name,
+            description=data.get("description", ""),
+            weights=dict(data.get("weights") or {}),
+            thresholds=dict(data.get("thresholds") or {}),
+            formula=data.get("formula"),
+            source=source
+        )
+        profile.validate()
+        return profile
+
+
+SCORING_PROFILES: Dict[str, ScoringProfile] = {
+    profile.name: profile for profile in (
+        ScoringProfile(
+            "balanced",
+            "Every dimension counts equally",
+            {d: 1.0 for d in SCORING_DIMENSIONS}
+        ),
+        ScoringProfile(
+            "fine_dining",
+            "Quality and safety first; time and labor are worth spending",
+            {"business": 0.15, "quality": 0.45, "speed": 0.05, "safety": 0.25, "cost": 0.10}
+        ),
+        ScoringProfile(
+            "fast_casual",
+            "Throughput and speed at a low labor cost, with acceptable quality",
+            {"business": 0.30, "quality": 0.10, "speed": 0.30, "safety": 0.10, "cost": 0.20}
+        ),
+        ScoringProfile(
+            "cost_control",
+            "Keep the kitchen running on the smallest labor bill",
+            {"business": 0.25, "quality": 0.15, "speed": 0.10, "safety": 0.10, "cost": 0.40}
+        ),
+    )
+}
+BUILTIN_PROFILES = tuple(SCORING_PROFILES)
+
+
+@dataclass
+class ScoringConfig:
+    """Benchmark authors' own scoring profiles, and the profile each scenario scores under"""
+    profiles: Dict[str, ScoringProfile] = field(default_factory=dict)
+    scenarios: Dict[str, str] = field(default_factory=dict)  # scenario type or bundle -> profile name
+    source: Optional[str] = None
+
+    def validate(self):
+        clashes = sorted(set(self.profiles) & set(BUILTIN_PROFILES))
+        if clashes:
+            raise ValueError(f"Profiles {clashes} would replace built-in ones; give them other names")
+        for profile in self.profiles.values():
+            profile.validate()
+        known = set(BUILTIN_PROFILES) | set(self.profiles)
+        for scenario, name in self.scenarios.items():
+            if name not in known:
+                raise ValueError(f"Scenario '{scenario}' scores under unknown profile '{name}', "
+                                 f"expected one of {sorted(known)}")
+
+    def profile_for(self, scenario: str) -> str:
+        return self.scenarios.get(scenario, DEFAULT_PROFILE)
+
+    def apply(self):
+        """Make this config's profiles the custom ones alongside the built-ins"""
+        for name in [n for n in SCORING_PROFILES if n not in BUILTIN_PROFILES]:
+            del SCORING_PROFILES[name]
+        SCORING_PROFILES.update(self.profiles)
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any], source: Optional[str] = None) -> "ScoringConfig":
+        """Raises ValueError for anything that would fail at scoring time"""
+        if not isinstance(data, dict):
+            raise ValueError("The scoring config must be a mapping")
+        unknown = sorted(set(data) - {"profiles", "scenarios"})
+        if unknown:
+            raise ValueError(f"Unknown scoring config keys {unknown}, expected profiles and scenarios")
+        try:
+            config = cls(
+                profiles={
+                    name: ScoringProfile.from_dict(name, spec, source or "config")
+                    for name, spec in (data.get("profiles") or {}).items()
+                },
+                scenarios=dict(data.get("scenarios") or {}),
+                source=source
+            )
+        except (TypeError, AttributeError) as e:
+            raise ValueError(f"Invalid scoring config: {e}")
+        config.validate()
+        return config
+
+    @classmethod
+    def from_config(cls, path: Optional[str] = None) -> "ScoringConfig":
+        """The file in CHEFBENCH_SCORING (YAML or JSON), else the scoring section of the config file
+
+        An invalid config is logged and ignored, leaving the built-in profiles.
+        """
+        standalone = path or os.environ.get("CHEFBENCH_SCORING")
+        path = standalone or os.environ.get("CHEFBENCH_CONFIG", DEFAULT_CONFIG_PATH)
+        if not os.path.exists(path):
+            if standalone:
+                logger.error(f"Scoring config {path} does not exist")
+            return cls()
+        try:
+            with open(path, 'r', encoding='utf-8') as f:
+                if path.endswith(".json"):
+                    data = json.load(f)
+                else:
+                    import yaml
+                    data = yaml.safe_load(f) or {}
+            if not standalone:
+                data = data.get(CONFIG_SECTION) or {}
+            config = cls.from_dict(data, path)
+        except ImportError:
+            logger.warning(f"PyYAML is not installed; no scoring config is read from {path}")
+            return cls()
+        except Exception as e:
+            logger.error(f"Ignoring scoring config in {path}: {e}")
+            return cls()
+        if config.profiles or config.scenarios:
+            logger.info(f"Loaded {len(config.profiles)} scoring profiles and {len(config.scenarios)} "
+                        f"scenario defaults from {path}")
+        return config
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "source": self.source,
+            "profiles": [p.to_dict() for p in self.profiles.values()],
+            "scenarios": dict(self.scenarios)
+        }
+
+
+_scoring_config: Optional[ScoringConfig] = None
+
+
+def get_scoring_config() -> ScoringConfig:
+    """The process-wide scoring config, loaded and applied on first use"""
+    global _scoring_config
+    if _scoring_config is None:
+        set_scoring_config(ScoringConfig.from_config())
+    return _scoring_config
+
+
+def set_scoring_config(config: ScoringConfig):
+    global _scoring_config
+    config.apply()
+    _scoring_config = config
+
+
+def get_scoring_profile(name: str) -> ScoringProfile:
+    """Look up a scoring profile by name"""
+    if name not in SCORING_PROFILES:
+        raise ValueError(f"Unknown scoring profile '{name}', expected one of {sorted(SCORING_PROFILES)}")
+    return SCORING_PROFILES[name]
+
+
+def score_dimensions(result: Dict[str, Any], duration_seconds: float) -> Dict[str, float]:
+    """Reduce a scenario result to one [0, 1] score per dimension"""
+    team = result.get("agent_metrics", {}).get("team", {})
+    history = result.get("execution_history", [])
+    labor = result.get("agent_metrics", {}).get("labor") or {}
+
+    # Business: the share of the scenario's tasks actually delivered
+    business = result.get("tasks_completed", 0) / max(result.get("total_tasks", 0), 1)
+
+    # Speed: the share of the simulated time budget left when the work was done
+    elapsed = labor.get("simulated_seconds", sum(e.get("execution_time", 0) for e in history))
+    speed = max(0.0, 1.0 - elapsed / duration_seconds) if duration_seconds else 0.0
+
+    # Safety: success on safety-critical tasks, less tasks run without working equipment
+    # or using ingredients the guests' dietary restrictions rule out
+    safety_runs = [e for e in history if e.get("task_type") in SAFETY_TASKS]
+    safety = (
+        sum(1 for e in safety_runs if e.get("success")) / len(safety_runs)
+        if safety_runs else 1.0
+    )
+    equipment = result.get("agent_metrics", {}).get("equipment") or {}
+    if history and equipment.get("blocked_tasks"):
+        safety *= 1.0 - min(1.0, equipment["blocked_tasks"] / len(history))
+    if history and team.get("restriction_violations"):
+        safety *= 1.0 - min(1.0, team["restriction_violations"] / len(history))
+
+    # Quality: average graded quality, less the problems flagged on orders
+    quality = team.get("average_quality", 0.0)
+    if history and team.get("order_notes"):
+        flagged = sum(NOTE_WEIGHTS.get(severity, 0.0) * n for severity, n in team["order_notes"].items())
+        quality *= 1.0 - min(1.0, flagged / len(history))
+
+    # Cost: 1 for free labor, halving at the reference cost per successful task
+    cost_per_task = team.get("cost_per_successful_task", 0.0)
+    cost = REFERENCE_COST_PER_TASK / (REFERENCE_COST_PER_TASK + cost_per_task)
+
+    dimensions = {
+        "business": business,
+        "quality": quality,
+        "speed": speed,
+        "safety": safety,
+        "cost": cost,
+    }
+    return {d: round(min(1.0, max(0.0, v)), 4) for d, v in dimensions.items()}
+
+
+def score_run(
+    result: Dict[str, Any],
+    duration_seconds: float,
+    profile: str = DEFAULT_PROFILE
+) -> Dict[str, Any]:
+    """Score a run under every profile, highlighting the one it was configured for
+
+    The headline profile's definition is recorded with the scores, so a run
+    still says how it was judged after the scoring config changes.
+    """
+    dimensions = score_dimensions(result, duration_seconds)
+    scores = {name: round(p.score(dimensions), 4) for name, p in SCORING_PROFILES.items()}
+    headline = SCORING_PROFILES.get(profile)
+    failed = headline.failed_thresholds(dimensions, scores[profile]) if headline else []
+    return {
+        "profile": profile,
+        "score": scores.get(profile),
+        "passed": not failed if headline else None,
+        "failed_thresholds": failed,
+        "dimensions": dimensions,
+        "profiles": scores,
+        "scoring": headline.to_dict() if headline else None,
+    }
+
+
+def rank_runs(
+    runs: List[Dict[str, Any]],
+    profile: str,
+    limit: Optional[int] = None
+) -> List[Dict[str, Any]]:
+    """Order scored runs best-first under one profile"""
+    get_scoring_profile(profile)
+    ranked = sorted(
+        (r for r in runs if r.get("scores")),
+        key=lambda r: r["scores"]["profiles"].get(profile, 0.0),
+        reverse=True
+    )
+    return [
+        {"rank": i, **r, "score": r["scores"]["profiles"].get(profile, 0.0)}
+        for i, r in enumerate(ranked[:limit] if limit else ranked, 1)
+    ]
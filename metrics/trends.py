@@ -0,0 +1,175 @@
+"""
+Performance Trends for ChefBench
+Time series of run metrics across weeks of recorded runs, grouped by model, scenario or prompt, with rolling averages
+"""
+
+import hashlib
+import json
+from collections import defaultdict
+from datetime import datetime, timedelta
+from typing import Any, Callable, Dict, List, Optional, Tuple
+import logging
+
+logger = logging.getLogger(__name__)
+
+
+def _team(run: Dict[str, Any]) -> Dict[str, Any]:
+    return run.get("metrics", {}).get("agent_metrics", {}).get("team", {})
+
+
+def _scores(run: Dict[str, Any]) -> Dict[str, Any]:
+    return run.get("scores") or {}
+
+
+def _completion(run: Dict[str, Any]) -> Optional[float]:
+    metrics = run.get("metrics", {})
+    total = metrics.get("total_tasks")
+    return metrics.get("tasks_completed", 0) / total if total else None
+
+
+# Metric name -> how to read it off a recorded run; None where the run didn't measure it
+TREND_METRICS: Dict[str, Callable[[Dict[str, Any]], Optional[float]]] = {
+    "score": lambda run: _scores(run).get("score"),
+    "success_rate": lambda run: _team(run).get("overall_success_rate"),
+    "average_quality": lambda run: _team(run).get("average_quality"),
+    "completion": _completion,
+    "labor_cost": lambda run: _team(run).get("labor_cost"),
+    "cost_per_successful_task": lambda run: _team(run).get("cost_per_successful_task"),
+    "reliability": lambda run: _team(run).get("reliability"),
+    "duration": lambda run: run.get("duration"),
+    **{
+        dimension: (lambda run, d=dimension: (_scores(run).get("dimensions") or {}).get(d))
+        for dimension in ("business", "quality", "speed", "safety", "cost")
+    },
+}
+DEFAULT_TREND_METRICS = ("score", "success_rate", "average_quality")
+
+# Bucket runs by calendar day, by ISO week (starting Monday), or not at all
+TREND_INTERVALS = ("run", "day", "week")
+
+
+def run_model(run: Dict[str, Any]) -> str:
+    """The model a run was on: its configured one, else the team's models joined with '+'"""
+    if run.get("config", {}).get("model"):
+        return run["config"]["model"]
+    by_role = (run.get("metrics", {}).get("model_routing") or {}).get("by_role") or {}
+    models = {m for value in by_role.values() for m in ([value] if isinstance(value, str) else value)}
+    return "+".join(sorted(models)) or "unknown"
+
+
+def prompt_fingerprint(run: Dict[str, Any]) -> str:
+    """"default" for runs on the registry's prompts, else a short hash of the overrides they ran with"""
+    overrides = run.get("config", {}).get("prompt_overrides") or {}
+    if not overrides:
+        return "default"
+    return hashlib.sha256(json.dumps(overrides, sort_keys=True).encode()).hexdigest()[:8]
+
+
+# Dimension name -> how to read it off a recorded run
+TREND_GROUPS: Dict[str, Callable[[Dict[str, Any]], Any]] = {
+    "model": run_model,
+    "scenario": lambda run: run.get("scenario_name"),
+    "assignment_policy": lambda run: run.get("config", {}).get("assignment_policy"),
+    "scoring_profile": lambda run: _scores(run).get("profile") or run.get("config", {}).get("scoring_profile"),
+    "quality_rubric": lambda run: (
+        run.get("config", {}).get("quality_rubric") if isinstance(run.get("config", {}).get("quality_rubric"), str)
+        else "custom"
+    ),
+    "prompt": prompt_fingerprint,
+}
+
+
+def _period(timestamp: str, interval: str) -> str:
+    if interval == "run":
+        return timestamp
+    moment = datetime.fromisoformat(timestamp)
+    if interval == "week":
+        return (moment.date() - timedelta(days=moment.weekday())).isoformat()
+    return moment.date().isoformat()
+
+
+def _mean(values: List[float]) -> Optional[float]:
+    return round(sum(values) / len(values), 4) if values else None
+
+
+def build_trends(
+    runs: List[Dict[str, Any]],
+    metrics: Optional[List[str]] = None,
+    group_by: Optional[List[str]] = None,
+    interval: str = "day",
+    window: int = 1,
+    filters: Optional[Dict[str, str]] = None
+) -> Dict[str, Any]:
+    """Each group's metrics per period, oldest first, with a rolling mean over the last window periods
+
+    The rolling mean weighs every run in the window equally, so a busy day
+    counts for more than a quiet one. A group's change is its last rolling
+    value less its first. Raises ValueError for an unknown metric, group or
+    interval.
+    """
+    metrics = list(metrics or DEFAULT_TREND_METRICS)
+    group_by = list(group_by if group_by is not None else ["model"])
+    filters = filters or {}
+    unknown = sorted(set(metrics) - set(TREND_METRICS))
+    if unknown:
+        raise ValueError(f"Unknown metrics {unknown}, expected some of {list(TREND_METRICS)}")
+    unknown = sorted((set(group_by) | set(filters)) - set(TREND_GROUPS))
+    if unknown:
+        raise ValueError(f"Unknown groups {unknown}, expected some of {list(TREND_GROUPS)}")
+    if interval not in TREND_INTERVALS:
+        raise ValueError(f"Unknown interval '{interval}', expected one of {list(TREND_INTERVALS)}")
+    if window < 1:
+        raise ValueError("window must be at least 1")
+
+    # group key -> period -> metric -> values
+    buckets: Dict[Tuple, Dict[str, Dict[str, List[float]]]] = defaultdict(lambda: defaultdict(lambda: defaultdict(list)))
+    counts: Dict[Tuple, Dict[str, int]] = defaultdict(lambda: defaultdict(int))
+    used = 0
+    for run in runs:
+        if not run.get("timestamp"):
+            continue
+        if any(str(TREND_GROUPS[name](run)) != value for name, value in filters.items()):
+            continue
+        key = tuple(TREND_GROUPS[name](run) for name in group_by)
+        period = _period(run["timestamp"], interval)
+        counts[key][period] += 1
+        used += 1
+        for metric in metrics:
+            value = TREND_METRICS[metric](run)
+            if isinstance(value, (int, float)):
+                buckets[key][period][metric].append(float(value))
+
+    series = []
+    for key in sorted(counts, key=lambda k: [str(part) for part in k]):
+        periods = sorted(counts[key])
+        points = []
+        for i, period in enumerate(periods):
+            recent = periods[max(0, i - window + 1):i + 1]
+            point: Dict[str, Any] = {"period": period, "runs": counts[key][period]}
+            for metric in metrics:
+                point[metric] = _mean(buckets[key][period][metric])
+                point[f"{metric}_rolling"] = _mean([v for p in recent for v in buckets[key][p][metric]])
+            points.append(point)
+
+        change = {}
+        for metric in metrics:
+            rolling = [p[f"{metric}_rolling"] for p in points if p[f"{metric}_rolling"] is not None]
+            change[metric] = round(rolling[-1] - rolling[0], 4) if len(rolling) > 1 else None
+        series.append({
+            "group": dict(zip(group_by, key)),
+            "runs": sum(counts[key].values()),
+            "first": periods[0],
+            "last": periods[-1],
+            "change": change,
+            "points": points
+        })
+
+    return {
+        "metrics": metrics,
+        "group_by": group_by,
+        "interval": interval,
+        "window": window,
+        "filters": filters,
+        "runs": used,
+        "series": series
+    }
@@ -7,9 +7,19 @@ from .models import (
     AgentRole,
     TaskType,
     Message,
+    KitchenEvent,
     TaskExecution,
-    AgentResponse
+    AgentResponse,
+    SkillProfile,
+    PROFICIENCY_LEVELS,
+    CERTIFICATIONS,
+    ROLE_CERTIFICATIONS,
+    FALLBACK_POLICIES,
+    MOCK_MODEL,
+    GenerationError,
+    AgentPaused
 )   
+from .simulator import ScriptedSimulator, SimulatorScript, FAILURE_RATES, get_simulator
 
 
 __all__ = [
@@ -17,6 +27,19 @@ __all__ = [
     "AgentRole",
     "TaskType",
     "Message",
+    "KitchenEvent",
     "TaskExecution",
-    "AgentResponse"
+    "AgentResponse",
+    "SkillProfile",
+    "PROFICIENCY_LEVELS",
+    "CERTIFICATIONS",
+    "ROLE_CERTIFICATIONS",
+    "FALLBACK_POLICIES",
+    "MOCK_MODEL",
+    "GenerationError",
+    "AgentPaused",
+    "ScriptedSimulator",
+    "SimulatorScript",
+    "FAILURE_RATES",
+    "get_simulator"
 ]
\ No newline at end of file
@@ -0,0 +1,222 @@
+"""
+Agent Memory for ChefBench
+Keeps an agent's recall bounded in long runs by folding older events into summaries
+"""
+
+import json
+import os
+from dataclasses import dataclass, field, fields, asdict
+from typing import Any, Callable, Dict, List, Optional
+import logging
+
+logger = logging.getLogger(__name__)
+
+# How older events are summarized: counted by rule, or written by the agent's own model
+MEMORY_SUMMARIZERS = ("rule", "llm")
+
+MAX_MESSAGE_CHARS = 80  # of the latest message quoted in a summary
+
+
+@dataclass
+class MemoryEvent:
+    """One thing an agent can remember: a task it ran or a message it got"""
+    timestamp: float
+    kind: str  # "task" or "message"
+    text: str  # as shown in prompts
+    task: Optional[str] = None
+    success: Optional[bool] = None
+    sender: Optional[str] = None
+    content: Optional[str] = None
+
+
+@dataclass
+class MemorySettings:
+    """How much an agent recalls verbatim, and when older events are summarized"""
+    window: int = 20  # recent events always recalled verbatim
+    compact_after: int = 10  # events past the window that trigger a compaction
+    max_summaries: int = 4  # summaries kept; past this the oldest after the first are merged
+    summarizer: str = "rule"
+
+    def validate(self):
+        if self.window < 1:
+            raise ValueError("window must be at least 1")
+        if self.compact_after < 1:
+            raise ValueError("compact_after must be at least 1")
+        if self.max_summaries < 2:
+            raise ValueError("max_summaries must be at least 2")
+        if self.summarizer not in MEMORY_SUMMARIZERS:
+            raise ValueError(f"Unknown summarizer '{self.summarizer}', expected one of {list(MEMORY_SUMMARIZERS)}")
+
+    @classmethod
+    def from_env(cls) -> "MemorySettings":
+        """Defaults, overridden by a JSON object in CHEFBENCH_MEMORY"""
+        raw = os.environ.get("CHEFBENCH_MEMORY")
+        if not raw:
+            return cls()
+        try:
+            names = {f.name for f in fields(cls)}
+            settings = cls(**{k: v for k, v in json.loads(raw).items() if k in names})
+            settings.validate()
+            return settings
+        except (ValueError, TypeError, AttributeError) as e:
+            logger.error(f"Ignoring invalid CHEFBENCH_MEMORY: {e}")
+            return cls()
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class MemoryStats:
+    """Counts behind a summary, kept so summaries can be merged without rereading events"""
+    tasks: Dict[str, int] = field(default_factory=dict)
+    succeeded: int = 0
+    failed: int = 0
+    first_task: Optional[str] = None
+    last_task: Optional[str] = None
+    senders: Dict[str, int] = field(default_factory=dict)
+    last_message: Optional[str] = None  # "<sender>: <content>"
+    last_quality_flag: Optional[str] = None  # task the head chef last flagged
+
+    @classmethod
+    def of(cls, events: List[MemoryEvent]) -> "MemoryStats":
+        stats = cls()
+        for event in events:
+            if event.kind == "task":
+                stats.tasks[event.task] = stats.tasks.get(event.task, 0) + 1
+                if event.success:
+                    stats.succeeded += 1
+                else:
+                    stats.failed += 1
+                stats.first_task = stats.first_task or event.task
+                stats.last_task = event.task
+            elif event.kind == "message":
+                stats.senders[event.sender] = stats.senders.get(event.sender, 0) + 1
+                stats.last_message = f"{event.sender}: {event.content[:MAX_MESSAGE_CHARS]}"
+                if event.content.startswith("Quality issue with"):
+                    stats.last_quality_flag = event.content[len("Quality issue with "):].split(".")[0].strip()
+        return stats
+
+    def merge(self, later: "MemoryStats") -> "MemoryStats":
+        """These counts followed by a later summary's"""
+        return MemoryStats(
+            tasks={k: self.tasks.get(k, 0) + later.tasks.get(k, 0) for k in {**self.tasks, **later.tasks}},
+            succeeded=self.succeeded + later.succeeded,
+            failed=self.failed + later.failed,
+            first_task=self.first_task or later.first_task,
+            last_task=later.last_task or self.last_task,
+            senders={k: self.senders.get(k, 0) + later.senders.get(k, 0) for k in {**self.senders, **later.senders}},
+            last_message=later.last_message or self.last_message,
+            last_quality_flag=later.last_quality_flag or self.last_quality_flag
+        )
+
+    def describe(self) -> str:
+        parts = []
+        if self.tasks:
+            counts = ", ".join(f"{task} x{n}" for task, n in sorted(self.tasks.items(), key=lambda t: -t[1]))
+            parts.append(f"{self.succeeded + self.failed} tasks ({self.succeeded} succeeded, {self.failed} failed): "
+                         f"{counts}; first {self.first_task}, last {self.last_task}")
+        if self.senders:
+            senders = ", ".join(f"{sender} ({n})" for sender, n in sorted(self.senders.items(), key=lambda s: -s[1]))
+            parts.append(f"{sum(self.senders.values())} messages from {senders}; latest from {self.last_message}")
+        if self.last_quality_flag:
+            parts.append(f"head chef last flagged {self.last_quality_flag} for quality")
+        return ". ".join(parts) or "nothing of note"
+
+
+@dataclass
+class MemorySummary:
+    """Older events folded into one line of recall"""
+    text: str
+    events: int
+    start: float
+    end: float
+    summarizer: str
+    stats: MemoryStats
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class AgentMemory:
+    """An agent's recall: recent events verbatim, and summaries of everything before
+
+    Events come from the agent's task history and received messages, which
+    the run keeps whole for its metrics; memory only tracks how far they
+    have been summarized. Once more than window + compact_after events are
+    unsummarized, all but the latest window are folded into a summary,
+    written by the summarize callback if given and it succeeds, by rule
+    otherwise. Past max_summaries, the second-oldest summary is merged into
+    the next so the first, which covers the start of the run, survives.
+    """
+
+    def __init__(self, settings: Optional[MemorySettings] = None):
+        self.settings = settings or MemorySettings()
+        self.summaries: List[MemorySummary] = []
+        self.summarized_until: Optional[float] = None  # timestamp of the last event in a summary
+        self.compactions = 0
+
+    def recent(self, events: List[MemoryEvent]) -> List[MemoryEvent]:
+        """Events not yet summarized, oldest first"""
+        if self.summarized_until is None:
+            return list(events)
+        return [e for e in events if e.timestamp > self.summarized_until]
+
+    def needs_compaction(self, events: List[MemoryEvent]) -> bool:
+        return len(self.recent(events)) >= self.settings.window + self.settings.compact_after
+
+    def compact(
+        self,
+        events: List[MemoryEvent],
+        summarize: Optional[Callable[[List[str]], str]] = None
+    ) -> Optional[MemorySummary]:
+        """Fold all but the latest window of unsummarized events into a summary, if there are enough"""
+        if not self.needs_compaction(events):
+            return None
+        recent = self.recent(events)
+        older = recent[:len(recent) - self.settings.window]
+
+        stats = MemoryStats.of(older)
+        text, summarizer = None, "rule"
+        if summarize is not None:
+            try:
+                text = summarize([e.text for e in older]).strip() or None
+                summarizer = "llm" if text else "rule"
+            except Exception as e:
+                logger.warning(f"Memory summary failed, summarizing by rule: {e}")
+        summary = MemorySummary(
+            text or stats.describe(), len(older), older[0].timestamp, older[-1].timestamp, summarizer, stats
+        )
+        self.summaries.append(summary)
+        self.summarized_until = summary.end
+        self.compactions += 1
+
+        if len(self.summaries) > self.settings.max_summaries:
+            second, third = self.summaries[1], self.summaries[2]
+            stats = second.stats.merge(third.stats)
+            self.summaries[1:3] = [MemorySummary(
+                stats.describe(), second.events + third.events, second.start, third.end, "rule", stats
+            )]
+        return summary
+
+    def lines(self, events: List[MemoryEvent]) -> List[str]:
+        """What the agent recalls, oldest first: its summaries, then its unsummarized events"""
+        return [
+            f"[summary of {s.events} earlier events] {s.text}" for s in self.summaries
+        ] + [e.text for e in self.recent(events)]
+
+    def clear(self):
+        self.summaries = []
+        self.summarized_until = None
+        self.compactions = 0
+
+    def to_dict(self, events: Optional[List[MemoryEvent]] = None) -> Dict[str, Any]:
+        data = {
+            "settings": self.settings.to_dict(),
+            "compactions": self.compactions,
+            "summarized_events": sum(s.events for s in self.summaries),
+            "summaries": [s.to_dict() for s in self.summaries]
+        }
+        if events is not None:
+            data["recent"] = [e.text for e in self.recent(events)]
+        return data
@@ -4,18 +4,54 @@ Defines core data structures and Hugging Face model interfaces
 """
 
 from dataclasses import dataclass, field
-from typing import Dict, List, Optional, Any, Callable
+from typing import Dict, List, Optional, Any, Callable, Tuple
 from enum import Enum
+import contextlib
 import json
+import random
+import re
+import threading
 import time
 from datetime import datetime
 import torch
 from transformers import AutoModelForCausalLM, AutoTokenizer, pipeline
 import logging
 
-logging.basicConfig(level=logging.INFO)
+from observability import get_usage_tracker, record_transcript, start_span
+from prompts import PromptSet, get_prompt_registry
+from recipes.normalization import get_normalizer
+from .simulator import SimulatedFailure, get_simulator
+from .memory import AgentMemory, MemoryEvent
+
 logger = logging.getLogger(__name__)
 
+# What an agent does when its model fails: retry then fall back to heuristics,
+# go straight to heuristics, or pause and leave its remaining tasks
+FALLBACK_POLICIES = ("retry", "heuristic", "pause")
+RETRY_BACKOFF_SECONDS = 0.5
+
+# Agents on this model don't load one and answer from the scripted simulator (the client's mock mode)
+MOCK_MODEL = "mock"
+
+# torch.manual_seed sets process-wide state; seeded sampling holds this so
+# kitchens running side by side stay reproducible
+SEEDED_SAMPLING_LOCK = threading.Lock()
+
+# Commis skill progression: each task's skill closes part of its gap to 1 with
+# practice, and enough successful tasks open up the next level's work
+SKILL_START = 0.2
+SKILL_GAIN = 0.15
+SKILL_GAIN_ON_FAILURE = 0.05
+COMMIS_PROMOTIONS = {2: 5, 3: 15}  # role level -> successful tasks needed for its tasks
+
+
+class GenerationError(Exception):
+    """The model failed to produce a response"""
+
+
+class AgentPaused(Exception):
+    """Raised under the pause policy when an agent's model fails on a task"""
+
 
 class AgentRole(Enum):
     """Kitchen hierarchy - defines authority levels"""
@@ -25,6 +61,7 @@ class AgentRole(Enum):
     LINE_COOK = 3        # Specialized cook
     PREP_COOK = 2        # Basic preparation
     KITCHEN_PORTER = 1   # Support role
+    COMMIS = 0           # Trainee; takes on more senior tasks as skills grow
 
 
 class TaskType(Enum):
@@ -63,6 +100,13 @@ class TaskType(Enum):
         self.min_role_level = min_role_level
         self.function_name = function_name
 
+    @classmethod
+    def from_function_name(cls, function_name: str) -> "TaskType":
+        for task_type in cls:
+            if task_type.function_name == function_name:
+                return task_type
+        raise ValueError(f"Unknown task type: {function_name}")
+
 
 @dataclass
 class Message:
@@ -89,6 +133,44 @@ class Message:
 
         }
 
+    @classmethod
+    def from_dict(cls, data: Dict) -> "Message":
+        return cls(
+            sender=data["sender"],
+            recipient=data["recipient"],
+            role=AgentRole[data["role"]],
+            content=data["content"],
+            task_type=TaskType.from_function_name(data["task_type"]) if data.get("task_type") else None,
+            timestamp=data.get("timestamp", time.time()),
+            requires_response=data.get("requires_response", False),
+            priority=data.get("priority", 3)
+        )
+
+
+@dataclass
+class KitchenEvent:
+    """Append-only record of something that happened during a run"""
+    event_type: str
+    run_id: Optional[str] = None
+    agent_name: Optional[str] = None
+    task_id: Optional[str] = None  # scenario-local task reference
+    caused_by: Optional[int] = None  # event_id of the triggering event
+    payload: Dict[str, Any] = field(default_factory=dict)
+    timestamp: float = field(default_factory=time.time)
+    event_id: Optional[int] = None  # assigned by the event store
+    
+    def to_dict(self) -> Dict:
+        return {
+            "event_id": self.event_id,
+            "event_type": self.event_type,
+            "run_id": self.run_id,
+            "agent_name": self.agent_name,
+            "task_id": self.task_id,
+            "caused_by": self.caused_by,
+            "payload": self.payload,
+            "timestamp": self.timestamp
+        }
+
 
 @dataclass
 class TaskExecution:
@@ -104,6 +186,16 @@ class TaskExecution:
     success: bool
     quality_score: float  # 0-1
     device: str
+    degraded: bool = False  # decided by fallback heuristics after a model failure
+    restricted_ingredients_used: List[str] = field(default_factory=list)
+    invalid_references: List[str] = field(default_factory=list)  # "kind:name" the kitchen doesn't have
+    quality_breakdown: Dict[str, float] = field(default_factory=dict)  # rubric category -> score
+    ingredients: List[str] = field(default_factory=list)  # what the task had to cook with
+    notes: List[Dict[str, str]] = field(default_factory=list)  # problems the agent flagged: severity, reason
+    complexity: Optional[str] = None  # the order's complexity level, for order tasks
+    # Not persisted: what the run needs from this execution while it's being graded
+    response: Optional["AgentResponse"] = field(default=None, repr=False, compare=False)
+    degradation: Optional[str] = None  # model error behind a fallback answer
     
     def to_dict(self) -> Dict:
         return {
@@ -116,9 +208,39 @@ class TaskExecution:
             "resources_used": self.resources_used,
             "collaboration_agents": self.collaboration_agents,
             "success": self.success,
-            "quality_score": self.quality_score
+            "quality_score": self.quality_score,
+            "degraded": self.degraded,
+            "restricted_ingredients_used": self.restricted_ingredients_used,
+            "invalid_references": self.invalid_references,
+            "quality_breakdown": self.quality_breakdown,
+            "ingredients": self.ingredients,
+            "notes": self.notes,
+            "complexity": self.complexity
         }
 
+    @classmethod
+    def from_dict(cls, data: Dict) -> "TaskExecution":
+        return cls(
+            agent_name=data["agent_name"],
+            task_type=TaskType.from_function_name(data["task_type"]),
+            start_time=data["start_time"],
+            reasoning_time=data["reasoning_time"],
+            execution_time=data["execution_time"],
+            chosen_approach=data["chosen_approach"],
+            resources_used=data.get("resources_used", []),
+            collaboration_agents=data.get("collaboration_agents", []),
+            success=data["success"],
+            quality_score=data["quality_score"],
+            device=data.get("device", "unknown"),
+            degraded=data.get("degraded", False),
+            restricted_ingredients_used=data.get("restricted_ingredients_used", []),
+            invalid_references=data.get("invalid_references", []),
+            quality_breakdown=data.get("quality_breakdown", {}),
+            ingredients=data.get("ingredients", []),
+            notes=data.get("notes", []),
+            complexity=data.get("complexity")
+        )
+
 
 @dataclass
 class AgentResponse:
@@ -131,6 +253,7 @@ class AgentResponse:
     estimated_time: int  # seconds
     dependencies: List[str]
     confidence: float  # 0-1
+    notes: List[Dict[str, str]] = field(default_factory=list)  # [{"severity": ..., "reason": ...}]
     
     @classmethod
     def from_json(cls, agent_name: str, response_to: str, json_str: str):
@@ -145,13 +268,175 @@ class AgentResponse:
                 parameters=data.get("parameters", {}),
                 estimated_time=data.get("estimated_time", 60),
                 dependencies=data.get("dependencies", []),
-                confidence=data.get("confidence", 0.5)
+                confidence=data.get("confidence", 0.5),
+                notes=[
+                    {"severity": str(n.get("severity", "warning")).lower(), "reason": n["reason"].strip()}
+                    for n in (data.get("notes") if isinstance(data.get("notes"), list) else [])
+                    if isinstance(n, dict) and isinstance(n.get("reason"), str) and n["reason"].strip()
+                ]
             )
         except (json.JSONDecodeError, KeyError) as e:
             logger.error(f"Failed to parse response from {agent_name}: {e}")
             return None
 
 
+def restricted_ingredients_used(response: AgentResponse, restricted: List[str]) -> List[str]:
+    """Restricted ingredients the response's action or parameters call for
+
+    Mentions right after "without", "no" or "avoid" are taken as the agent
+    steering clear of the ingredient rather than using it.
+    """
+    text = f"{response.action} {json.dumps(response.parameters, default=str)}".lower()
+    used = []
+    for ingredient in restricted:
+        for match in re.finditer(rf"\b{re.escape(ingredient.lower())}\b", text):
+            if not re.search(r"\b(without|no|avoid|exclude|excluding)\s+$", text[:match.start()]):
+                used.append(ingredient)
+                break
+    return used
+
+
+def _requested(parameters: Dict[str, Any], key_part: str) -> List[str]:
+    """String values of parameters whose key mentions key_part (e.g. "ingredient")"""
+    values = []
+    for key, value in parameters.items():
+        if key_part in str(key).lower():
+            values.extend(v for v in (value if isinstance(value, list) else [value]) if isinstance(v, str))
+    return [v.strip().lower() for v in values if v.strip()]
+
+
+def _known(name: str, available: List[str]) -> bool:
+    return any(name in a or a in name for a in available)
+
+
+def invalid_references(response: AgentResponse, context: Dict[str, Any]) -> List[str]:
+    """Ingredients and equipment the response asks for that the kitchen doesn't have
+
+    Equipment is only checked when the context lists it (equipment simulation on).
+    """
+    ingredients = [i.lower() for i in context.get('ingredients', [])]
+    for ingredient, substitutes in context.get('substitutions', {}).items():
+        ingredients.append(ingredient.lower())
+        ingredients.extend(s.split(" (")[0].lower() for s in substitutes)
+
+    # Also matched by canonical id, so "tomato" counts for "Tomatoes" on hand
+    normalizer = get_normalizer()
+    ids = set(normalizer.dedupe(ingredients))
+    invalid = [
+        f"ingredient:{name}" for name in _requested(response.parameters, "ingredient")
+        if not _known(name, ingredients) and normalizer.canonical_id(name) not in ids
+    ]
+    if 'equipment' in context:
+        equipment = [e.lower() for e in context['equipment']]
+        invalid.extend(
+            f"equipment:{name}" for name in _requested(response.parameters, "equipment")
+            if not _known(name, equipment)
+        )
+    return invalid
+
+
+# Named proficiency levels, by the lowest skill each stands for
+PROFICIENCY_LEVELS = {"novice": 0.0, "competent": 0.4, "proficient": 0.7, "expert": 0.9}
+
+# Certifications a task calls for; uncertified agents can still do it but are a worse pick
+CERTIFICATIONS: Dict[str, List[TaskType]] = {
+    "food_safety": [TaskType.QUALITY_CONTROL, TaskType.TEMPERATURE_MONITORING, TaskType.INVENTORY_MANAGEMENT],
+    "allergen_awareness": [TaskType.MENU_PLANNING, TaskType.RECIPE_MODIFICATION],
+    "equipment_safety": [TaskType.EQUIPMENT_MAINTENANCE],
+}
+
+# What each role holds when it joins
+ROLE_CERTIFICATIONS: Dict[AgentRole, List[str]] = {
+    AgentRole.HEAD_CHEF: ["food_safety", "allergen_awareness"],
+    AgentRole.SOUS_CHEF: ["food_safety", "allergen_awareness"],
+    AgentRole.CHEF_DE_PARTIE: ["food_safety"],
+    AgentRole.LINE_COOK: ["food_safety"],
+    AgentRole.PREP_COOK: [],
+    AgentRole.KITCHEN_PORTER: ["equipment_safety"],
+    AgentRole.COMMIS: [],
+}
+
+
+def at_role_level(task_type: TaskType, role: AgentRole) -> bool:
+    """Whether the task is the role's own level of work; a commis's is the entry-level work anyone may do"""
+    return task_type.min_role_level == max(role.value, min(t.min_role_level for t in TaskType))
+
+
+def required_certifications(task_type: TaskType) -> List[str]:
+    return [name for name, tasks in CERTIFICATIONS.items() if task_type in tasks]
+
+
+def proficiency(skill: float) -> str:
+    """Name of the proficiency level a skill falls in"""
+    return max((floor, name) for name, floor in PROFICIENCY_LEVELS.items() if skill >= floor)[1]
+
+
+@dataclass
+class SkillProfile:
+    """An agent's per-task skill (0-1) and certifications, plus a trainee's progress
+
+    Tasks without an entry are at full skill, or at SKILL_START for a trainee.
+    Only trainees learn from practice; anyone's skills can be set directly.
+    """
+    skills: Dict[str, float] = field(default_factory=dict)  # task function name -> skill
+    certifications: List[str] = field(default_factory=list)
+    trainee: bool = False
+    successes: int = 0
+    tasks: int = 0
+
+    @property
+    def level(self) -> int:
+        """Highest role level whose tasks the trainee may take on"""
+        return max([1] + [level for level, needed in COMMIS_PROMOTIONS.items() if self.successes >= needed])
+
+    def skill(self, task_type: TaskType) -> float:
+        return self.skills.get(task_type.function_name, SKILL_START if self.trainee else 1.0)
+
+    def set_skill(self, task_type: TaskType, value: Any):
+        """Set a skill from a number or a proficiency name"""
+        if isinstance(value, str):
+            if value not in PROFICIENCY_LEVELS:
+                raise ValueError(f"Unknown proficiency '{value}', expected one of {list(PROFICIENCY_LEVELS)}")
+            value = PROFICIENCY_LEVELS[value]
+        if not 0 <= value <= 1:
+            raise ValueError(f"Skill must be between 0 and 1, got {value}")
+        self.skills[task_type.function_name] = float(value)
+
+    def certified_for(self, task_type: TaskType) -> bool:
+        return all(c in self.certifications for c in required_certifications(task_type))
+
+    def record(self, task_type: TaskType, success: bool) -> float:
+        """Learn from one attempt, returning the new skill"""
+        if self.trainee:
+            current = self.skill(task_type)
+            gain = SKILL_GAIN if success else SKILL_GAIN_ON_FAILURE
+            self.skills[task_type.function_name] = current + gain * (1 - current)
+        self.tasks += 1
+        self.successes += success
+        return self.skill(task_type)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "trainee": self.trainee,
+            "level": self.level if self.trainee else None,
+            "skills": {name: round(skill, 4) for name, skill in self.skills.items()},
+            "proficiency": {name: proficiency(skill) for name, skill in self.skills.items()},
+            "certifications": list(self.certifications),
+            "successes": self.successes,
+            "tasks": self.tasks
+        }
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "SkillProfile":
+        return cls(
+            dict(data.get("skills", {})),
+            list(data.get("certifications", [])),
+            data.get("trainee", True),  # profiles saved before certifications were all trainees'
+            data.get("successes", 0),
+            data.get("tasks", 0)
+        )
+
+
 class LLMAgent:
     """Hugging Face transformer-based agent"""
     
@@ -161,21 +446,32 @@ class LLMAgent:
         role: AgentRole,
         model_name: str = "cohere/command-r",
         device: Optional[str] = None,
+        seed: Optional[int] = None,
+        fallback_policy: str = "retry",
+        max_retries: int = 2,
     ):
+        if fallback_policy not in FALLBACK_POLICIES:
+            raise ValueError(f"Unknown fallback policy '{fallback_policy}', expected one of {FALLBACK_POLICIES}")
         self.name = name
         self.role = role
         self.model_name = model_name
+        self.seed = seed
+        self.fallback_policy = fallback_policy
+        self.max_retries = max_retries
         self.device = device if device != "auto" else ("cuda" if torch.cuda.is_available() else "cpu")
         
-        # Available functions based on role
-        self.available_tasks = [
-            task for task in TaskType 
-            if task.min_role_level <= role.value
-        ]
+        # Trainees learn per task; everyone else starts fully skilled at their role's tasks
+        self.skills = SkillProfile(
+            certifications=list(ROLE_CERTIFICATIONS[role]),
+            trainee=role == AgentRole.COMMIS
+        )
         
         # Message queue
         self.message_queue: List[Message] = []
         self.sent_messages: List[Message] = []
+        self.received_messages: List[Message] = []
+        self.memory = AgentMemory()  # recall for questions; the coordinator gives it the run's settings
+        self.prompts: PromptSet = get_prompt_registry().with_overrides()  # replaced by the coordinator per run
         
         # Performance tracking
         self.task_history: List[TaskExecution] = []
@@ -183,11 +479,25 @@ class LLMAgent:
         self.collaboration_score = 0.0
         self.authority_compliance = 1.0
         
+        # Tasks run on worker threads while the API reads the agent from the event loop.
+        # The state lock guards task history and messages, and is
+        # only held briefly; the memory lock is held through compaction, which may call
+        # the model, so the event loop should take it off a worker thread
+        self._state_lock = threading.RLock()
+        self._memory_lock = threading.RLock()
+        
+        # Recorded responses to answer from instead of the model, when replaying a golden run
+        self.response_cache = None
+        
         # Initialize model
         self._init_model()
     
     def _init_model(self):
         """Initialize Hugging Face model and tokenizer"""
+        if self.model_name == MOCK_MODEL:
+            self.model = None
+            self.tokenizer = None
+            return
         try:
             logger.info(f"Loading {self.model_name} for {self.name}")
             
@@ -238,9 +548,29 @@ class LLMAgent:
             self.model = None
             self.tokenizer = None
     
+    @property
+    def role_level(self) -> int:
+        """The role level the agent works at: their role's, or a trainee's progress"""
+        return self.skills.level if self.skills.trainee else self.role.value
+
+    @property
+    def available_tasks(self) -> List[TaskType]:
+        """Available functions based on role, or a trainee's progress"""
+        return [task for task in TaskType if task.min_role_level <= self.role_level]
+    
+    def skill(self, task_type: TaskType) -> float:
+        """How well the agent performs a task, 0-1"""
+        return self.skills.skill(task_type) if task_type in self.available_tasks else 0.0
+    
+    def quality_factor(self, task_type: TaskType) -> float:
+        """Multiplier on the quality of the agent's work, below 1 when it isn't fully skilled"""
+        return 0.5 + 0.5 * self.skills.skill(task_type)
+    
     def receive_message(self, message: Message):
         """Add message to agent's queue"""
-        self.message_queue.append(message)
+        with self._state_lock:
+            self.message_queue.append(message)
+            self.received_messages.append(message)
         
         # Track authority compliance
         if message.role.value > self.role.value:
@@ -269,18 +599,28 @@ class LLMAgent:
         # Generate reasoning
         reasoning_start = time.time()
         prompt = self._build_task_prompt(task_type, context)
-        response = self._generate_response(prompt)
+        response, degradation = self._generate_response(prompt, task_type, context)
         reasoning_time = time.time() - reasoning_start
         
         # Parse response
         agent_response = AgentResponse.from_json(self.name, task_type.function_name, response)
+        with self._state_lock:
+            tasks_done = len(self.task_history)
         
         if agent_response:
             # Simulate execution
             execution_time = agent_response.estimated_time
             
             # Calculate quality based on confidence and role match
-            quality = agent_response.confidence * (1.0 if task_type.min_role_level == self.role.value else 0.8)
+            quality = agent_response.confidence * (1.0 if at_role_level(task_type, self.role) else 0.8)
+            
+            # Less skilled agents make mistakes and turn out rougher work
+            success = True
+            skill = self.skills.skill(task_type)
+            if skill < 1:
+                quality *= self.quality_factor(task_type)
+                rng = random.Random(self.seed + tasks_done) if self.seed is not None else random
+                success = rng.random() < 0.5 + 0.5 * skill
             
             execution = TaskExecution(
                 agent_name=self.name,
@@ -291,9 +631,17 @@ class LLMAgent:
                 chosen_approach=agent_response.action,
                 resources_used=list(agent_response.parameters.keys()),
                 collaboration_agents=agent_response.dependencies,
-                success=True,
-                quality_score=quality,
-                device=device
+                success=success,
+                quality_score=quality if success else 0,
+                device=device,
+                degraded=degradation is not None,
+                restricted_ingredients_used=restricted_ingredients_used(
+                    agent_response, context.get('restricted_ingredients', [])
+                ),
+                invalid_references=invalid_references(agent_response, context),
+                notes=agent_response.notes,
+                response=agent_response,
+                degradation=degradation
             )
         else:
             # Failed to generate valid response
@@ -308,36 +656,154 @@ class LLMAgent:
                 collaboration_agents=[],
                 success=False,
                 quality_score=0,
-                device=device
+                device=device,
+                degradation=degradation
             )
         
-        self.task_history.append(execution)
+        with self._state_lock:
+            self.skills.record(task_type, execution.success)
+            self.task_history.append(execution)
+        self.compact_memory()
         return execution
     
+    def record_execution(self, execution: TaskExecution):
+        """Add an execution the agent didn't run itself, such as a failed task or a restored one"""
+        with self._state_lock:
+            self.task_history.append(execution)
+    
+    def discard_execution(self, execution: TaskExecution) -> bool:
+        """Take back the latest execution if it is this one, as when its task was revoked"""
+        with self._state_lock:
+            if self.task_history and self.task_history[-1] is execution:
+                self.task_history.pop()
+                return True
+            return False
+    
+    def history(self) -> List[TaskExecution]:
+        """A copy of the task history, safe to read while tasks run"""
+        with self._state_lock:
+            return list(self.task_history)
+    
+    def messages(self) -> List[Message]:
+        """A copy of the messages received, oldest first, safe to read while tasks run"""
+        with self._state_lock:
+            return list(self.received_messages)
+    
+    def clear_state(self):
+        """Forget tasks, messages and memories, as at the start of a run"""
+        with self._memory_lock, self._state_lock:
+            self.message_queue.clear()
+            self.sent_messages.clear()
+            self.received_messages.clear()
+            self.task_history.clear()
+            self.memory.clear()
+    
+    def take_messages(self) -> List[Message]:
+        """Empty the message queue, returning what was in it, oldest first"""
+        with self._state_lock:
+            messages, self.message_queue = self.message_queue, []
+            return messages
+    
     def _build_task_prompt(self, task_type: TaskType, context: Dict[str, Any]) -> str:
-        """Build prompt for task execution"""
-        system_prompt = f"""You are {self.name}, a {self.role.name} in a professional kitchen.
-Your role level is {self.role.value}/6 in the kitchen hierarchy.
-You must execute the task: {task_type.function_name}
-
-Available ingredients: {context.get('ingredients', [])}
-Time constraint: {context.get('time_limit', 'none')}
-Other agents: {context.get('other_agents', [])}
-
-Respond in JSON format:
-{{
-    "reasoning": "your thought process",
-    "action": "specific action to take",
-    "parameters": {{"key": "value"}},
-    "estimated_time": seconds_needed,
-    "dependencies": ["agent_names_if_help_needed"],
-    "confidence": 0.0-1.0
-}}"""
+        """Build prompt for task execution from the role's task template"""
+        role = self.role.name.lower()
+        return self.prompts.render(
+            "task", role,
+            name=self.name,
+            role=self.role.name,
+            role_level=self.role.value,
+            charter=self.prompts.charter(self.name, role, self.role.value),
+            task=task_type.function_name,
+            ingredients=context.get('ingredients', []),
+            time_limit=context.get('time_limit', 'none'),
+            other_agents=context.get('other_agents', []),
+            substitutions=context.get('substitutions', {}),
+            techniques=context.get('techniques', []),
+            equipment_unavailable=context.get('equipment_unavailable', []),
+            pacing=context.get('pacing', []),
+            modifications=context.get('modifications', []),
+            dietary_restrictions=context.get('dietary_restrictions', []),
+            restricted_ingredients=context.get('restricted_ingredients', []),
+            menu_candidates=context.get('menu_candidates', [])
+        )
+    
+    def _generate_response(
+        self,
+        prompt: str,
+        task_type: Optional[TaskType] = None,
+        context: Optional[Dict[str, Any]] = None
+    ) -> Tuple[str, Optional[str]]:
+        """Generate response using LLM, applying the fallback policy if the model fails
+
+        Returns the response and, when the fallback gave it after a model
+        failure, the error behind it. Both go back to the caller rather than
+        onto the agent, since tasks for one agent can run concurrently.
+        """
+        degradation = None
+        if not get_usage_tracker().within_budget():
+            # The run has spent its token or cost limit: no more model calls until it is raised
+            response = self._heuristic_response(task_type)
+            record_transcript(self.name, self.model_name, prompt, response, fallback=True, over_budget=True)
+            return response, None
+        attempts = self.max_retries + 1 if self.fallback_policy == "retry" else 1
         
-        return system_prompt
+        for attempt in range(attempts):
+            try:
+                response = self._call_model(prompt, task_type, context, attempt)
+                break
+            except GenerationError as e:
+                error = str(e)
+                if attempt + 1 < attempts:
+                    logger.warning(f"Generation failed for {self.name} (attempt {attempt + 1}/{attempts}): {e}")
+                    time.sleep(RETRY_BACKOFF_SECONDS * 2 ** attempt)
+        else:
+            logger.error(f"Generation failed for {self.name}, falling back ({self.fallback_policy}): {error}")
+            degradation = error
+            if self.fallback_policy == "pause" and task_type is not None:
+                record_transcript(self.name, self.model_name, prompt, "", degraded=error, paused=True)
+                raise AgentPaused(f"{self.name} paused after model failure: {error}")
+            response = self._heuristic_response(task_type)
+        
+        record_transcript(
+            self.name, self.model_name, prompt, response,
+            fallback=self.model is None,
+            degraded=degradation
+        )
+        return response, degradation
     
-    def _generate_response(self, prompt: str) -> str:
-        """Generate response using LLM"""
+    def _heuristic_response(self, task_type: Optional[TaskType]) -> str:
+        """Rule-based decision used when the model can't be reached"""
+        if task_type is None:
+            return json.dumps({"answer": "unknown"})
+        return json.dumps({
+            "reasoning": f"Model unavailable, following the standard procedure for {task_type.function_name}",
+            "action": "standard_procedure",
+            "parameters": {"method": "standard"},
+            "estimated_time": 30 * task_type.min_role_level,  # senior work takes longer
+            "dependencies": [],
+            "confidence": 0.4,
+            "notes": [{"severity": "info", "reason": "Decided without the model, by the standard procedure"}]
+        })
+    
+    def _sampling_guard(self):
+        """Hold the global torch RNG for seeded sampling so concurrent runs can't reseed it mid-call"""
+        return SEEDED_SAMPLING_LOCK if self.seed is not None else contextlib.nullcontext()
+    
+    def _call_model(
+        self,
+        prompt: str,
+        task_type: Optional[TaskType] = None,
+        context: Optional[Dict[str, Any]] = None,
+        attempt: int = 0
+    ) -> str:
+        """Run the model on a prompt, falling back to a canned response without one"""
+        if self.response_cache is not None:
+            return self.response_cache.respond(self.name, prompt)
+        if self.model_name == MOCK_MODEL and task_type is not None:
+            try:
+                return get_simulator().respond(self, task_type, context or {}, prompt, attempt)
+            except SimulatedFailure as e:
+                raise GenerationError(str(e)) from e
         if self.model is None or self.tokenizer is None:
             # Fallback mock response
             return json.dumps({
@@ -350,41 +816,133 @@ Respond in JSON format:
             })
         
         try:
-            inputs = self.tokenizer.encode(prompt, return_tensors="pt", max_length=512, truncation=True)
-            inputs = inputs.to(self.device)
+            with start_span(
+                "llm.generate",
+                kind="client",
+                **{
+                    "gen_ai.system": "huggingface",
+                    "gen_ai.request.model": self.model_name,
+                    "gen_ai.request.max_tokens": 256,
+                    "gen_ai.request.temperature": 0.7
+                }
+            ) as span:
+                inputs = self.tokenizer.encode(prompt, return_tensors="pt", max_length=512, truncation=True)
+                inputs = inputs.to(self.device)
             
-            with torch.no_grad():
-                outputs = self.model.generate(
-                    inputs,
-                    max_new_tokens=256,
-                    temperature=0.7,
-                    do_sample=True,
-                    pad_token_id=self.tokenizer.pad_token_id
+                generate_start = time.time()
+                with self._sampling_guard():
+                    # Derive a per-call seed so sampling is reproducible for seeded runs
+                    if self.seed is not None:
+                        with self._state_lock:
+                            tasks_done = len(self.task_history)
+                        torch.manual_seed(self.seed + tasks_done)
+                
+                    with torch.no_grad():
+                        outputs = self.model.generate(
+                            inputs,
+                            max_new_tokens=256,
+                            temperature=0.7,
+                            do_sample=True,
+                            pad_token_id=self.tokenizer.pad_token_id
+                        )
+            
+                # Attributed to the current run through the log context
+                prompt_tokens = inputs.shape[-1]
+                get_usage_tracker().record(
+                    self.name,
+                    self.model_name,
+                    prompt_tokens,
+                    outputs.shape[-1] - prompt_tokens,
+                    time.time() - generate_start
                 )
+                span.set_attributes({
+                    "gen_ai.usage.input_tokens": int(prompt_tokens),
+                    "gen_ai.usage.output_tokens": int(outputs.shape[-1] - prompt_tokens)
+                })
             
-            response = self.tokenizer.decode(outputs[0], skip_special_tokens=True)
+                response = self.tokenizer.decode(outputs[0], skip_special_tokens=True)
             
-            # Extract JSON from response
-            try:
-                json_start = response.find('{')
-                json_end = response.rfind('}') + 1
-                if json_start >= 0 and json_end > json_start:
-                    return response[json_start:json_end]
-            except:
-                pass
+                # Extract JSON from response
+                try:
+                    json_start = response.find('{')
+                    json_end = response.rfind('}') + 1
+                    if json_start >= 0 and json_end > json_start:
+                        return response[json_start:json_end]
+                except:
+                    pass
             
-            return response
+                return response
             
         except Exception as e:
-            logger.error(f"Generation failed for {self.name}: {e}")
-            return json.dumps({
-                "reasoning": "Error in generation",
-                "action": "fallback",
-                "parameters": {},
-                "estimated_time": 60,
-                "dependencies": [],
-                "confidence": 0.3
-            })
+            raise GenerationError(str(e)) from e
+    
+    def memory_events(self) -> List[MemoryEvent]:
+        """Tasks run and messages received, oldest first"""
+        with self._state_lock:
+            tasks, messages = list(self.task_history), list(self.received_messages)
+        events = [
+            MemoryEvent(
+                t.start_time, "task",
+                f"[task] {t.task_type.function_name} success={t.success} quality={t.quality_score:.2f}",
+                task=t.task_type.function_name, success=t.success
+            )
+            for t in tasks
+        ] + [
+            MemoryEvent(m.timestamp, "message", f"[message from {m.sender}] {m.content}", sender=m.sender, content=m.content)
+            for m in messages
+        ]
+        return sorted(events, key=lambda e: e.timestamp)
+    
+    def compact_memory(self):
+        """Summarize older memories once enough have piled up past the window"""
+        summarize = self._summarize_memory if self.memory.settings.summarizer == "llm" else None
+        with self._memory_lock:
+            summary = self.memory.compact(self.memory_events(), summarize)
+        if summary:
+            logger.debug(f"{self.name} summarized {summary.events} memories ({summary.summarizer})")
+    
+    def _summarize_memory(self, events: List[str]) -> str:
+        """The model's one-paragraph summary of events, raising ValueError if it gives none"""
+        role = self.role.name.lower()
+        prompt = self.prompts.render(
+            "summary", role,
+            name=self.name,
+            role=self.role.name,
+            role_level=self.role.value,
+            charter=self.prompts.charter(self.name, role, self.role.value),
+            events=chr(10).join(events)
+        )
+        response, degradation = self._generate_response(prompt)
+        if degradation is not None:
+            raise ValueError(degradation)
+        summary = json.loads(response).get("summary")
+        if not isinstance(summary, str) or not summary.strip():
+            raise ValueError("response has no summary")
+        return summary
+    
+    def answer_question(self, question: str) -> str:
+        """Answer a question from the agent's memory: summaries of older events, then recent ones"""
+        self.compact_memory()
+        with self._memory_lock:
+            memory = self.memory.lines(self.memory_events())
+        
+        role = self.role.name.lower()
+        prompt = self.prompts.render(
+            "question", role,
+            name=self.name,
+            role=self.role.name,
+            role_level=self.role.value,
+            charter=self.prompts.charter(self.name, role, self.role.value),
+            memory=chr(10).join(memory),
+            question=question
+        )
+        
+        if self.model is None or self.tokenizer is None:
+            # Fallback mock recall: only the latest memory
+            return json.dumps({"answer": memory[-1] if memory else "unknown"})
+        
+        response, _ = self._generate_response(prompt)
+        return response
     
     def send_message(self, recipient: str, content: str, task_type: Optional[TaskType] = None) -> Message:
         """Send message to another agent"""
@@ -396,12 +954,16 @@ Respond in JSON format:
             task_type=task_type,
             requires_response=task_type is not None
         )
-        self.sent_messages.append(message)
+        with self._state_lock:
+            self.sent_messages.append(message)
         return message
     
     def get_metrics(self) -> Dict[str, Any]:
-        """Get agent performance metrics"""
-        if not self.task_history:
+        """Get agent performance metrics, from a snapshot of its history taken under the state lock"""
+        with self._state_lock:
+            history = list(self.task_history)
+            messages_sent, messages_received = len(self.sent_messages), len(self.message_queue)
+        if not history:
             return {
                 "agent_name": self.name,
                 "role": self.role.name,
@@ -410,20 +972,29 @@ Respond in JSON format:
                 "avg_quality": 0,
                 "avg_reasoning_time": 0,
                 "collaboration_score": 0,
-                "authority_compliance": self.authority_compliance
+                "authority_compliance": self.authority_compliance,
+                "degraded_tasks": 0,
+                **({"skills": self.skills.to_dict()} if self.skills.trainee else {})
             }
         
-        successful_tasks = [t for t in self.task_history if t.success]
+        successful_tasks = [t for t in history if t.success]
         
         return {
             "agent_name": self.name,
             "role": self.role.name,
-            "tasks_completed": len(self.task_history),
-            "success_rate": len(successful_tasks) / len(self.task_history),
+            "tasks_completed": len(history),
+            "success_rate": len(successful_tasks) / len(history),
             "avg_quality": sum(t.quality_score for t in successful_tasks) / max(len(successful_tasks), 1),
-            "avg_reasoning_time": sum(t.reasoning_time for t in self.task_history) / len(self.task_history),
-            "collaboration_score": len(set(sum([t.collaboration_agents for t in self.task_history], []))) / max(len(self.task_history), 1),
+            "avg_reasoning_time": sum(t.reasoning_time for t in history) / len(history),
+            "collaboration_score": len(set(sum([t.collaboration_agents for t in history], []))) / max(len(history), 1),
             "authority_compliance": self.authority_compliance,
-            "messages_sent": len(self.sent_messages),
-            "messages_received": len(self.message_queue)
-        }
\ No newline at end of file
+            "messages_sent": messages_sent,
+            "messages_received": messages_received,
+            "degraded_tasks": sum(1 for t in history if t.degraded),
+            **({"skills": self.skills.to_dict()} if self.skills.trainee else {})
+        }
+    
+    def memory_snapshot(self) -> Dict[str, Any]:
+        """What the agent recalls; waits out a compaction in progress, so call it off the event loop"""
+        with self._memory_lock:
+            return self.memory.to_dict(self.memory_events())
\ No newline at end of file
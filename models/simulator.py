@@ -0,0 +1,261 @@
+"""
+Scripted LLM simulator for ChefBench
+Answers mock-model agents offline with plausible, role-specific decisions and failures at controllable rates
+"""
+
+import json
+import os
+import random
+import threading
+import time
+from dataclasses import dataclass, field, fields, asdict
+from typing import Any, Dict, List, Optional, Tuple
+import logging
+
+from observability import get_usage_tracker
+
+logger = logging.getLogger(__name__)
+
+# Rates a script sets, each the share of calls that go wrong that way
+FAILURE_RATES = ("error_rate", "malformed_rate", "hallucination_rate", "violation_rate")
+
+# An ingredient no kitchen stocks, asked for when the simulator hallucinates
+HALLUCINATED_INGREDIENT = "white truffle"
+
+# Recipes a simulated planner puts on the menu
+MENU_SIZE = 4
+
+# Tasks that bring other stations in, so their decisions name collaborators
+COORDINATING_TASKS = {
+    "menu_planning", "quality_control", "staff_coordination", "training_supervision",
+    "station_management", "timing_coordination", "communication"
+}
+
+# task -> (action, method) a competent cook at that station would choose
+TASK_SCRIPTS: Dict[str, Tuple[str, str]] = {
+    "menu_planning": ("plan_menu", "seasonal_courses"),
+    "quality_control": ("inspect_pass", "taste_and_temperature_check"),
+    "staff_coordination": ("assign_stations", "balance_by_skill"),
+    "recipe_modification": ("adapt_recipe", "swap_and_rebalance"),
+    "inventory_management": ("count_stock", "first_in_first_out"),
+    "training_supervision": ("supervise_trainee", "demonstrate_then_observe"),
+    "station_management": ("run_station", "clear_tickets_in_order"),
+    "sauce_preparation": ("make_sauce", "reduce_and_mount"),
+    "plating_design": ("plate_dish", "center_stack"),
+    "cooking_execution": ("cook_to_order", "sear_and_finish"),
+    "temperature_monitoring": ("log_temperatures", "probe_every_unit"),
+    "timing_coordination": ("call_fire_times", "backtime_from_pass"),
+    "ingredient_preparation": ("prep_ingredients", "wash_peel_cut"),
+    "basic_cooking": ("cook_components", "blanch_and_shock"),
+    "mise_en_place": ("set_mise_en_place", "portion_and_label"),
+    "cleaning": ("clean_station", "clean_as_you_go"),
+    "equipment_maintenance": ("service_equipment", "inspect_and_calibrate"),
+    "communication": ("relay_message", "call_and_confirm"),
+}
+
+
+class SimulatedFailure(Exception):
+    """A model call the script made fail"""
+
+
+@dataclass
+class SimulatorScript:
+    """How often the simulated model fails, overall and for particular roles"""
+    error_rate: float = 0.0  # calls that fail outright, so the agent's fallback policy decides
+    malformed_rate: float = 0.0  # responses that aren't valid JSON, failing the task
+    hallucination_rate: float = 0.0  # decisions asking for an ingredient the kitchen doesn't have
+    violation_rate: float = 0.0  # decisions using one of the guests' restricted ingredients
+    latency_seconds: float = 0.0  # added to every call
+    roles: Dict[str, Dict[str, float]] = field(default_factory=dict)  # role name -> rates overriding the above
+
+    def validate(self):
+        for name in FAILURE_RATES:
+            if not 0 <= getattr(self, name) <= 1:
+                raise ValueError(f"{name} must be between 0 and 1")
+        if self.latency_seconds < 0:
+            raise ValueError("latency_seconds must not be negative")
+        for role, rates in self.roles.items():
+            unknown = sorted(set(rates) - set(FAILURE_RATES))
+            if unknown:
+                raise ValueError(f"Unknown rates {unknown} for {role}, expected some of {list(FAILURE_RATES)}")
+            for name, rate in rates.items():
+                if not 0 <= rate <= 1:
+                    raise ValueError(f"{role} {name} must be between 0 and 1")
+
+    def rates_for(self, role_name: str) -> Dict[str, float]:
+        return {**{name: getattr(self, name) for name in FAILURE_RATES}, **self.roles.get(role_name, {})}
+
+    @classmethod
+    def from_env(cls) -> "SimulatorScript":
+        """No failures, overridden by a JSON object in CHEFBENCH_MOCK_SCRIPT"""
+        raw = os.environ.get("CHEFBENCH_MOCK_SCRIPT")
+        if not raw:
+            return cls()
+        try:
+            names = {f.name for f in fields(cls)}
+            script = cls(**{k: v for k, v in json.loads(raw).items() if k in names})
+            script.validate()
+            return script
+        except (ValueError, TypeError, AttributeError) as e:
+            logger.error(f"Ignoring invalid CHEFBENCH_MOCK_SCRIPT: {e}")
+            return cls()
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class ScriptedSimulator:
+    """Stands in for the model of every mock-model agent
+
+    Each task gets the decision its role would make from what the context
+    offers: ingredients on hand (minus the guests' restrictions), working
+    equipment, substitutions, and the other stations to bring in on
+    coordinating work. The script's rates then break a share of calls, so
+    fallbacks, failed tasks, invalid references and dietary violations can
+    all be exercised without a model. Seeded agents draw from their own
+    seed, so seeded runs repeat exactly. Calls are recorded as usage with
+    tokens estimated from the text.
+    """
+
+    def __init__(self, script: Optional[SimulatorScript] = None):
+        self.script = script or SimulatorScript()
+        self.stats = {"calls": 0, "errors": 0, "malformed": 0, "hallucinations": 0, "violations": 0}
+        self._lock = threading.Lock()  # agents generate in worker threads
+
+    def configure(self, **changes: Any) -> SimulatorScript:
+        """Change the script's rates; anything not given is kept"""
+        script = SimulatorScript(**{**asdict(self.script), **changes})
+        script.validate()
+        self.script = script
+        return script
+
+    def respond(self, agent: Any, task_type: Any, context: Dict[str, Any], prompt: str, attempt: int = 0) -> str:
+        """The simulated model's answer to one task prompt; raises SimulatedFailure for a failed call"""
+        started = time.time()
+        # Seeded agents draw the same sequence every run, retries of a task drawing afresh
+        rng = random.Random(
+            f"{agent.seed}:{agent.name}:{len(agent.history())}:{attempt}"
+        ) if agent.seed is not None else random.Random()
+        rates = self.script.rates_for(agent.role.name)
+        if self.script.latency_seconds:
+            time.sleep(self.script.latency_seconds)
+
+        with self._lock:
+            self.stats["calls"] += 1
+        if rng.random() < rates["error_rate"]:
+            self._count("errors")
+            raise SimulatedFailure(f"Simulated model timeout for {agent.name}")
+
+        decision = self._decide(agent, task_type, context, rng, rates)
+        response = json.dumps(decision)
+        if rng.random() < rates["malformed_rate"]:
+            self._count("malformed")
+            response = response[:len(response) // 2]
+
+        get_usage_tracker().record(
+            agent.name, agent.model_name, len(prompt) // 4, len(response) // 4, time.time() - started
+        )
+        return response
+
+    def _count(self, name: str):
+        with self._lock:
+            self.stats[name] += 1
+
+    def _decide(
+        self,
+        agent: Any,
+        task_type: Any,
+        context: Dict[str, Any],
+        rng: random.Random,
+        rates: Dict[str, float]
+    ) -> Dict[str, Any]:
+        action, method = TASK_SCRIPTS.get(task_type.function_name, ("standard_procedure", "standard"))
+        restricted = [r.lower() for r in context.get('restricted_ingredients', [])]
+        on_hand = [
+            i for i in context.get('ingredients', [])
+            if not any(r in i.lower() for r in restricted)
+        ]
+        ingredients = rng.sample(on_hand, min(len(on_hand), 3))
+        parameters: Dict[str, Any] = {"method": method}
+        notes: List[Dict[str, str]] = []
+
+        if rng.random() < rates["hallucination_rate"]:
+            self._count("hallucinations")
+            ingredients.append(HALLUCINATED_INGREDIENT)
+        if restricted and rng.random() < rates["violation_rate"]:
+            self._count("violations")
+            ingredients.append(restricted[0])
+        elif restricted:
+            # Phrased so the restriction reads as avoided, not used
+            parameters["dietary"] = ", ".join(f"without {r}" for r in restricted)
+            notes.append({"severity": "info", "reason": f"Kept clear of {', '.join(restricted)}"})
+        if ingredients:
+            parameters["ingredients"] = ingredients
+
+        substitutions = {
+            ingredient: substitutes[0].split(" (")[0]
+            for ingredient, substitutes in context.get('substitutions', {}).items() if substitutes
+        }
+        if substitutions:
+            parameters["substitutions"] = substitutions
+
+        if 'equipment' in context:
+            down = {e.lower() for e in context.get('equipment_unavailable', [])}
+            working = [e for e in context['equipment'] if e.lower() not in down]
+            if working:
+                parameters["equipment"] = rng.choice(working)
+            else:
+                notes.append({"severity": "warning", "reason": "No working equipment for this task"})
+        # A planner puts some of the recipes it's offered on, the first as the house specialty; dogs
+        # come off unless there's nothing else
+        candidates = context.get('menu_candidates', [])
+        if task_type.function_name == "menu_planning" and candidates:
+            keep = [c for c in candidates if c.get("class") != "dog"]
+            if len(keep) >= min(len(candidates), MENU_SIZE):
+                candidates = keep
+            chosen = rng.sample(candidates, min(len(candidates), MENU_SIZE))
+            parameters["menu"] = [
+                {"recipe_id": c["recipe_id"], "specialty": i == 0, "seasonal": rng.random() < 0.5}
+                for i, c in enumerate(chosen)
+            ]
+        if context.get('modifications'):
+            notes.append({"severity": "info", "reason": f"Applied {len(context['modifications'])} guest modifications"})
+
+        dependencies = []
+        others = context.get('other_agents', [])
+        if task_type.function_name in COORDINATING_TASKS and others:
+            dependencies = rng.sample(others, min(len(others), 2))
+
+        # Seniors take longer, and everyone aims to finish inside the time limit
+        estimated_time = int(30 * task_type.min_role_level * rng.uniform(0.8, 1.2))
+        if isinstance(context.get('time_limit'), (int, float)):
+            estimated_time = min(estimated_time, int(context['time_limit'] * 0.8))
+
+        from .models import at_role_level  # models imports this module
+        at_level = at_role_level(task_type, agent.role)
+        confidence = (0.85 if at_level else 0.8) + rng.uniform(-0.1, 0.1)
+
+        return {
+            "reasoning": f"As {agent.role.name.lower()}, {method.replace('_', ' ')} for {task_type.function_name}",
+            "action": action,
+            "parameters": parameters,
+            "estimated_time": max(1, estimated_time),
+            "dependencies": dependencies,
+            "confidence": round(min(1.0, max(0.0, confidence)), 3),
+            "notes": notes
+        }
+
+    def to_dict(self) -> Dict[str, Any]:
+        with self._lock:
+            return {"script": self.script.to_dict(), "stats": dict(self.stats)}
+
+
+_simulator: Optional[ScriptedSimulator] = None
+
+
+def get_simulator() -> ScriptedSimulator:
+    """Get the process-wide simulator, scripted from CHEFBENCH_MOCK_SCRIPT on first use"""
+    global _simulator
+    if _simulator is None:
+        _simulator = ScriptedSimulator(SimulatorScript.from_env())
+    return _simulator
@@ -0,0 +1,55 @@
+"""
+ChefBench Observability
+Structured logging, log streaming, LLM usage accounting, transcript capture and tracing
+"""
+
+from .logs import (
+    CONTEXT_FIELDS,
+    log_context,
+    current_context,
+    ContextFilter,
+    JsonFormatter,
+    LogBuffer,
+    configure_logging,
+    get_log_buffer
+)
+from .usage import (
+    DEFAULT_TOKEN_PRICES,
+    BUDGET_OK,
+    BUDGET_WARNING,
+    BUDGET_EXCEEDED,
+    BUDGET_STATES,
+    RunBudget,
+    UsageTotals,
+    UsageTracker,
+    get_usage_tracker
+)
+from .transcripts import set_transcript_sink, record_transcript
+from .tracing import configure_tracing, tracing_enabled, start_span, tracing_middleware, TracedConnection
+
+__all__ = [
+    'CONTEXT_FIELDS',
+    'log_context',
+    'current_context',
+    'ContextFilter',
+    'JsonFormatter',
+    'LogBuffer',
+    'configure_logging',
+    'get_log_buffer',
+    'DEFAULT_TOKEN_PRICES',
+    'UsageTotals',
+    'UsageTracker',
+    'get_usage_tracker',
+    'RunBudget',
+    'BUDGET_OK',
+    'BUDGET_WARNING',
+    'BUDGET_EXCEEDED',
+    'BUDGET_STATES',
+    'set_transcript_sink',
+    'record_transcript',
+    'configure_tracing',
+    'tracing_enabled',
+    'start_span',
+    'tracing_middleware',
+    'TracedConnection'
+]
@@ -0,0 +1,149 @@
+"""
+Structured Logging for ChefBench
+JSON log records tagged with run, agent and task context, plus an in-memory stream buffer
+"""
+
+import contextvars
+import json
+import logging
+import threading
+from collections import deque
+from contextlib import contextmanager
+from typing import Dict, List, Optional, Any, Iterator
+
+CONTEXT_FIELDS = ("run_id", "agent_name", "agent_role", "task_id")
+
+_context: Dict[str, contextvars.ContextVar] = {
+    name: contextvars.ContextVar(name, default=None) for name in CONTEXT_FIELDS
+}
+
+
+@contextmanager
+def log_context(**fields) -> Iterator[None]:
+    """Tag every log record emitted inside the block with the given fields"""
+    tokens = []
+    for name, value in fields.items():
+        if name not in _context:
+            raise ValueError(f"Unknown log context field '{name}'")
+        tokens.append((_context[name], _context[name].set(value)))
+    try:
+        yield
+    finally:
+        for var, token in reversed(tokens):
+            var.reset(token)
+
+
+def current_context() -> Dict[str, Any]:
+    """Get the log context fields that are currently set"""
+    return {name: var.get() for name, var in _context.items() if var.get() is not None}
+
+
+class ContextFilter(logging.Filter):
+    """Copy the active log context onto each record"""
+
+    def filter(self, record: logging.LogRecord) -> bool:
+        for name, var in _context.items():
+            if not hasattr(record, name):
+                setattr(record, name, var.get())
+        return True
+
+
+def record_to_dict(record: logging.LogRecord) -> Dict[str, Any]:
+    entry = {
+        "timestamp": record.created,
+        "level": record.levelname,
+        "logger": record.name,
+        "message": record.getMessage()
+    }
+    for name in CONTEXT_FIELDS:
+        value = getattr(record, name, None)
+        if value is not None:
+            entry[name] = value
+    if record.exc_info:
+        entry["exception"] = logging.Formatter().formatException(record.exc_info)
+    return entry
+
+
+class JsonFormatter(logging.Formatter):
+    """Format records as one JSON object per line"""
+
+    def format(self, record: logging.LogRecord) -> str:
+        return json.dumps(record_to_dict(record), default=str)
+
+
+class LogBuffer(logging.Handler):
+    """Keep recent records in memory with increasing ids for streaming"""
+
+    def __init__(self, capacity: int = 5000):
+        super().__init__()
+        self.entries: deque = deque(maxlen=capacity)
+        self._next_id = 1
+        self._lock = threading.Lock()
+
+    def emit(self, record: logging.LogRecord):
+        entry = record_to_dict(record)
+        with self._lock:
+            entry["log_id"] = self._next_id
+            self._next_id += 1
+            self.entries.append(entry)
+
+    def query(
+        self,
+        after_id: int = 0,
+        run_id: Optional[str] = None,
+        agent_name: Optional[str] = None,
+        task_id: Optional[str] = None,
+        level: Optional[str] = None,
+        limit: Optional[int] = None
+    ) -> List[Dict[str, Any]]:
+        """Get buffered entries in order, optionally filtered"""
+        min_level = logging.getLevelName(level.upper()) if level else 0
+        with self._lock:
+            entries = list(self.entries)
+
+        matched = []
+        for entry in entries:
+            if entry["log_id"] <= after_id:
+                continue
+            if run_id is not None and entry.get("run_id") != run_id:
+                continue
+            if agent_name is not None and entry.get("agent_name") != agent_name:
+                continue
+            if task_id is not None and entry.get("task_id") != task_id:
+                continue
+            if logging.getLevelName(entry["level"]) < min_level:
+                continue
+            matched.append(entry)
+            if limit is not None and len(matched) >= limit:
+                break
+        return matched
+
+
+_buffer: Optional[LogBuffer] = None
+
+
+def configure_logging(level: str = "info", json_output: bool = True, buffer_size: int = 5000) -> LogBuffer:
+    """Install structured logging on the root logger and return the stream buffer"""
+    global _buffer
+
+    root = logging.getLogger()
+    root.setLevel(level.upper())
+    for handler in list(root.handlers):
+        root.removeHandler(handler)
+
+    console = logging.StreamHandler()
+    console.setFormatter(
+        JsonFormatter() if json_output
+        else logging.Formatter("%(asctime)s %(levelname)s %(name)s [run=%(run_id)s agent=%(agent_name)s] %(message)s")
+    )
+    _buffer = LogBuffer(buffer_size)
+
+    for handler in (console, _buffer):
+        handler.addFilter(ContextFilter())
+        root.addHandler(handler)
+    return _buffer
+
+
+def get_log_buffer() -> Optional[LogBuffer]:
+    """Get the buffer installed by configure_logging, if any"""
+    return _buffer
@@ -0,0 +1,173 @@
+"""
+Request Tracing for ChefBench
+OpenTelemetry spans for HTTP requests, database queries, LLM calls and agent tasks, exported over OTLP
+"""
+
+import os
+import sqlite3
+from contextlib import contextmanager, nullcontext
+from pathlib import Path
+from typing import Dict, Optional, Any, Iterator
+import logging
+
+from .logs import current_context
+
+logger = logging.getLogger(__name__)
+
+# Log context fields copied onto every span, so a task's spans share its order ID
+SPAN_CONTEXT_ATTRIBUTES = {
+    "run_id": "chefbench.run_id",
+    "agent_name": "chefbench.agent.name",
+    "agent_role": "chefbench.agent.role",
+    "task_id": "chefbench.order_id",
+}
+
+_tracer = None
+
+
+class _NoopSpan:
+    """Stands in for a span while tracing is off"""
+
+    def set_attribute(self, key: str, value: Any):
+        pass
+
+    def set_attributes(self, attributes: Dict[str, Any]):
+        pass
+
+
+_NOOP_SPAN = _NoopSpan()
+
+
+def configure_tracing(endpoint: Optional[str] = None, service_name: Optional[str] = None) -> bool:
+    """Export spans over OTLP/HTTP if an endpoint is given or set in the standard OTEL_* variables
+
+    Returns whether tracing is on. Needs the opentelemetry-sdk and
+    opentelemetry-exporter-otlp-proto-http packages (the `tracing` extra).
+    """
+    global _tracer
+    endpoint = endpoint or os.environ.get("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") or os.environ.get("OTEL_EXPORTER_OTLP_ENDPOINT")
+    if not endpoint:
+        return False
+    try:
+        from opentelemetry import trace
+        from opentelemetry.sdk.resources import Resource
+        from opentelemetry.sdk.trace import TracerProvider
+        from opentelemetry.sdk.trace.export import BatchSpanProcessor
+        from opentelemetry.exporter.otlp.proto.http.trace_exporter import OTLPSpanExporter
+    except ImportError as e:
+        logger.error(f"Tracing disabled, OpenTelemetry is not installed (pip install 'escoffier[tracing]'): {e}")
+        return False
+
+    if not endpoint.rstrip("/").endswith("/v1/traces"):
+        endpoint = endpoint.rstrip("/") + "/v1/traces"
+    provider = TracerProvider(resource=Resource.create({
+        "service.name": service_name or os.environ.get("OTEL_SERVICE_NAME", "chefbench")
+    }))
+    provider.add_span_processor(BatchSpanProcessor(OTLPSpanExporter(endpoint=endpoint)))
+    trace.set_tracer_provider(provider)
+    _tracer = trace.get_tracer("chefbench")
+    logger.info(f"Exporting traces to {endpoint}")
+    return True
+
+
+def tracing_enabled() -> bool:
+    return _tracer is not None
+
+
+def _span_kind(kind: str):
+    from opentelemetry.trace import SpanKind
+    return {"server": SpanKind.SERVER, "client": SpanKind.CLIENT}.get(kind, SpanKind.INTERNAL)
+
+
+@contextmanager
+def start_span(name: str, kind: str = "internal", context=None, **attributes) -> Iterator[Any]:
+    """Run the block in a span, tagged with the log context and the given attributes
+
+    Attribute names may use dots through a dict, e.g. **{"gen_ai.request.model": m}.
+    None values are left out. A no-op when tracing is off.
+    """
+    if _tracer is None:
+        yield _NOOP_SPAN
+        return
+    tags = {
+        SPAN_CONTEXT_ATTRIBUTES[field]: value
+        for field, value in current_context().items()
+        if field in SPAN_CONTEXT_ATTRIBUTES
+    }
+    tags.update({key: value for key, value in attributes.items() if value is not None})
+    with _tracer.start_as_current_span(name, context=context, kind=_span_kind(kind), attributes=tags) as span:
+        yield span
+
+
+async def tracing_middleware(request, call_next):
+    """One server span per HTTP request, continuing the caller's trace from its traceparent header"""
+    if _tracer is None:
+        return await call_next(request)
+
+    from opentelemetry import propagate
+    from opentelemetry.trace import Status, StatusCode
+
+    method = request.method
+    with start_span(
+        f"{method} {request.url.path}",
+        kind="server",
+        context=propagate.extract(dict(request.headers)),
+        **{
+            "http.request.method": method,
+            "url.path": request.url.path,
+            "url.query": request.url.query or None,
+            "client.address": request.client.host if request.client else None
+        }
+    ) as span:
+        response = await call_next(request)
+        # Name the span after the route template once routing has matched one
+        route = request.scope.get("route")
+        if route is not None and hasattr(route, "path"):
+            span.update_name(f"{method} {route.path}")
+            span.set_attribute("http.route", route.path)
+        span.set_attribute("http.response.status_code", response.status_code)
+        if response.status_code >= 500:
+            span.set_status(Status(StatusCode.ERROR))
+        return response
+
+
+def _query_span(db_name: str, sql: str):
+    if _tracer is None:
+        return nullcontext()
+    statement = " ".join(sql.split())
+    return start_span(
+        f"db {statement.split(' ', 1)[0].upper()}",
+        kind="client",
+        **{"db.system": "sqlite", "db.name": db_name, "db.statement": statement[:1000]}
+    )
+
+
+class TracedCursor(sqlite3.Cursor):
+    """Cursor of a TracedConnection"""
+
+    def execute(self, sql, *args, **kwargs):
+        with _query_span(self.connection.db_name, sql):
+            return super().execute(sql, *args, **kwargs)
+
+    def executemany(self, sql, *args, **kwargs):
+        with _query_span(self.connection.db_name, sql):
+            return super().executemany(sql, *args, **kwargs)
+
+
+class TracedConnection(sqlite3.Connection):
+    """SQLite connection whose queries each get a client span; pass as sqlite3.connect(factory=...)"""
+
+    def __init__(self, database, *args, **kwargs):
+        super().__init__(database, *args, **kwargs)
+        self.db_name = Path(str(database)).name
+
+    def cursor(self, factory=None):
+        return super().cursor(factory or TracedCursor)
+
+    def execute(self, sql, *args, **kwargs):
+        with _query_span(self.db_name, sql):
+            return super().execute(sql, *args, **kwargs)
+
+    def executemany(self, sql, *args, **kwargs):
+        with _query_span(self.db_name, sql):
+            return super().executemany(sql, *args, **kwargs)
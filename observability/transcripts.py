@@ -0,0 +1,51 @@
+"""
+LLM Transcript Capture for ChefBench
+Hands every prompt/response pair, tagged with the active log context, to a pluggable sink
+"""
+
+import logging
+import time
+from typing import Callable, Dict, Optional, Any
+
+from .logs import current_context
+
+logger = logging.getLogger(__name__)
+
+TranscriptSink = Callable[[Dict[str, Any]], Any]
+
+_sink: Optional[TranscriptSink] = None
+
+
+def set_transcript_sink(sink: Optional[TranscriptSink]):
+    """Install where transcripts go (e.g. TranscriptStore.append); None disables capture"""
+    global _sink
+    _sink = sink
+
+
+def record_transcript(
+    agent_name: str,
+    model_name: str,
+    prompt: str,
+    response: str,
+    **details
+):
+    """Send one prompt/response pair to the sink, attributed to the current run and task"""
+    if _sink is None:
+        return
+
+    context = current_context()
+    entry = {
+        "run_id": context.get("run_id"),
+        "task_id": context.get("task_id"),
+        "agent_name": agent_name,
+        "model_name": model_name,
+        "prompt": prompt,
+        "response": response,
+        "timestamp": time.time(),
+        "details": details
+    }
+    try:
+        _sink(entry)
+    except Exception as e:
+        # Losing a transcript must never fail the task that produced it
+        logger.error(f"Failed to record transcript for {agent_name}: {e}")
@@ -0,0 +1,196 @@
+"""
+LLM Usage Accounting for ChefBench
+Token counts, latency and estimated cost per call, attributed to agents and runs
+"""
+
+import json
+import logging
+import os
+import threading
+from dataclasses import dataclass, asdict
+from typing import Dict, List, Optional, Any, Tuple
+
+from .logs import current_context
+
+logger = logging.getLogger(__name__)
+
+# USD per 1K (prompt, completion) tokens, matched by substring of the model name.
+# Local models default to free; override with CHEFBENCH_TOKEN_PRICES.
+DEFAULT_TOKEN_PRICES: Dict[str, Tuple[float, float]] = {
+    "gpt-4o-mini": (0.00015, 0.0006),
+    "gpt-4": (0.03, 0.06),
+    "claude-3-sonnet": (0.003, 0.015),
+    "command-r-plus": (0.0025, 0.01),
+    "command-r": (0.00015, 0.0006),
+}
+
+BUDGET_OK = "ok"
+BUDGET_WARNING = "warning"
+BUDGET_EXCEEDED = "exceeded"
+BUDGET_STATES = (BUDGET_OK, BUDGET_WARNING, BUDGET_EXCEEDED)
+
+
+@dataclass
+class RunBudget:
+    """Token and cost limits on one run; a limit left as None isn't enforced"""
+    max_tokens: Optional[int] = None
+    max_cost: Optional[float] = None  # USD, estimated from the token prices
+    warn_at: float = 0.8  # share of a limit used before the run is warned
+
+    def validate(self):
+        if self.max_tokens is not None and self.max_tokens < 1:
+            raise ValueError("max_tokens must be at least 1")
+        if self.max_cost is not None and self.max_cost <= 0:
+            raise ValueError("max_cost must be positive")
+        if not 0 < self.warn_at < 1:
+            raise ValueError("warn_at must be between 0 and 1")
+
+    def used(self, tokens: int, cost: float) -> float:
+        """Share of the tighter limit used so far"""
+        shares = []
+        if self.max_tokens is not None:
+            shares.append(tokens / self.max_tokens)
+        if self.max_cost is not None:
+            shares.append(cost / self.max_cost)
+        return max(shares, default=0.0)
+
+    def state(self, tokens: int, cost: float) -> str:
+        used = self.used(tokens, cost)
+        if used >= 1:
+            return BUDGET_EXCEEDED
+        return BUDGET_WARNING if used >= self.warn_at else BUDGET_OK
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class UsageTotals:
+    """Accumulated usage for one agent, model or run"""
+    calls: int = 0
+    prompt_tokens: int = 0
+    completion_tokens: int = 0
+    latency_seconds: float = 0.0
+    cost: float = 0.0
+
+    def add(self, prompt_tokens: int, completion_tokens: int, latency: float, cost: float):
+        self.calls += 1
+        self.prompt_tokens += prompt_tokens
+        self.completion_tokens += completion_tokens
+        self.latency_seconds += latency
+        self.cost += cost
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        data["total_tokens"] = self.prompt_tokens + self.completion_tokens
+        data["latency_seconds"] = round(self.latency_seconds, 3)
+        data["cost"] = round(self.cost, 6)
+        return data
+
+
+class UsageTracker:
+    """Aggregate LLM calls per run, broken down by agent and model
+
+    Calls outside a run (no run_id in the log context) are counted under
+    the "unattributed" key so nothing is dropped.
+    """
+
+    UNATTRIBUTED = "unattributed"
+
+    def __init__(self, prices: Optional[Dict[str, Tuple[float, float]]] = None):
+        self.prices = dict(DEFAULT_TOKEN_PRICES, **(prices or {}))
+        self._runs: Dict[str, Dict[str, Dict[str, UsageTotals]]] = {}
+        self._budgets: Dict[str, RunBudget] = {}
+        self._lock = threading.Lock()  # agents generate in worker threads
+
+    def price_for(self, model_name: str) -> Tuple[float, float]:
+        """Per-1K token prices, preferring the longest matching model key"""
+        matches = [key for key in self.prices if key.lower() in model_name.lower()]
+        return self.prices[max(matches, key=len)] if matches else (0.0, 0.0)
+
+    def record(
+        self,
+        agent_name: str,
+        model_name: str,
+        prompt_tokens: int,
+        completion_tokens: int,
+        latency: float,
+        run_id: Optional[str] = None
+    ) -> float:
+        """Record one LLM call and return its estimated cost"""
+        prompt_price, completion_price = self.price_for(model_name)
+        cost = prompt_tokens / 1000 * prompt_price + completion_tokens / 1000 * completion_price
+        run_id = run_id or current_context().get("run_id") or self.UNATTRIBUTED
+
+        with self._lock:
+            run = self._runs.setdefault(run_id, {"total": {}, "by_agent": {}, "by_model": {}})
+            for bucket, key in (("total", "total"), ("by_agent", agent_name), ("by_model", model_name)):
+                run[bucket].setdefault(key, UsageTotals()).add(prompt_tokens, completion_tokens, latency, cost)
+        return cost
+
+    def for_run(self, run_id: str) -> Optional[Dict[str, Any]]:
+        """Totals for one run, or None if it made no LLM calls"""
+        with self._lock:
+            run = self._runs.get(run_id)
+            if run is None:
+                return None
+            return {
+                "run_id": run_id,
+                "total": run["total"]["total"].to_dict(),
+                "by_agent": {name: t.to_dict() for name, t in run["by_agent"].items()},
+                "by_model": {name: t.to_dict() for name, t in run["by_model"].items()}
+            }
+
+    def set_budget(self, run_id: str, budget: Optional[RunBudget]):
+        """Limit a run's usage, or lift its limits with None"""
+        if budget is not None:
+            budget.validate()
+        with self._lock:
+            if budget is None:
+                self._budgets.pop(run_id, None)
+            else:
+                self._budgets[run_id] = budget
+
+    def budget_status(self, run_id: str) -> Optional[Dict[str, Any]]:
+        """A run's limits, what it has used against them and its state; None if it has no budget"""
+        with self._lock:
+            budget = self._budgets.get(run_id)
+            if budget is None:
+                return None
+            totals = self._runs.get(run_id, {}).get("total", {}).get("total") or UsageTotals()
+            tokens = totals.prompt_tokens + totals.completion_tokens
+            return {
+                **budget.to_dict(),
+                "tokens": tokens,
+                "cost": round(totals.cost, 6),
+                "used": round(budget.used(tokens, totals.cost), 4),
+                "state": budget.state(tokens, totals.cost)
+            }
+
+    def within_budget(self, run_id: Optional[str] = None) -> bool:
+        """Whether the run, by default the one in the log context, may make more LLM calls"""
+        run_id = run_id or current_context().get("run_id")
+        status = self.budget_status(run_id) if run_id else None
+        return status is None or status["state"] != BUDGET_EXCEEDED
+
+    def runs(self) -> List[str]:
+        with self._lock:
+            return list(self._runs)
+
+
+_tracker: Optional[UsageTracker] = None
+
+
+def get_usage_tracker() -> UsageTracker:
+    """Get the process-wide usage tracker, creating it on first use"""
+    global _tracker
+    if _tracker is None:
+        prices = {}
+        raw = os.environ.get("CHEFBENCH_TOKEN_PRICES")
+        if raw:
+            try:
+                prices = {model: tuple(p) for model, p in json.loads(raw).items()}
+            except (ValueError, TypeError, AttributeError) as e:
+                logger.error(f"Ignoring invalid CHEFBENCH_TOKEN_PRICES: {e}")
+        _tracker = UsageTracker(prices)
+    return _tracker
@@ -0,0 +1,24 @@
+"""
+ChefBench Prompts
+Versioned prompt templates for each agent role
+"""
+
+from .registry import (
+    DEFAULT_PROMPT_DIR,
+    PROMPT_VARIABLES,
+    PromptTemplate,
+    PromptRegistry,
+    PromptSet,
+    parse_template,
+    get_prompt_registry
+)
+
+__all__ = [
+    'DEFAULT_PROMPT_DIR',
+    'PROMPT_VARIABLES',
+    'PromptTemplate',
+    'PromptRegistry',
+    'PromptSet',
+    'parse_template',
+    'get_prompt_registry'
+]
@@ -0,0 +1,189 @@
+"""
+Prompt Templates for ChefBench
+Agent prompts loaded from a template directory, versioned by content and overridable per run
+"""
+
+import hashlib
+import os
+import re
+import threading
+from dataclasses import dataclass
+from pathlib import Path
+from string import Template
+from typing import Dict, List, Optional, Any
+import logging
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_PROMPT_DIR = Path(__file__).parent / "templates"
+
+# Variables each kind of template may use; a role's charter is rendered into the others
+PROMPT_VARIABLES = {
+    "task": (
+        "name", "role", "role_level", "charter", "task",
+        "ingredients", "time_limit", "other_agents", "substitutions", "techniques", "equipment_unavailable",
+        "pacing", "modifications", "dietary_restrictions", "restricted_ingredients", "menu_candidates"
+    ),
+    "question": ("name", "role", "role_level", "charter", "memory", "question"),
+    "summary": ("name", "role", "role_level", "charter", "events"),
+    "charter": ("name", "role", "role_level"),
+}
+
+# <kind>.tmpl applies to every role, <kind>.<role>.tmpl to one (e.g. task.head_chef.tmpl)
+TEMPLATE_NAME = re.compile(r"^([a-z]+)(?:\.([a-z_]+))?$")
+
+
+@dataclass
+class PromptTemplate:
+    """One template and the version its content hashes to"""
+    name: str
+    text: str
+    source: str  # file it was loaded from, or "override"
+
+    @property
+    def version(self) -> str:
+        return hashlib.sha256(self.text.encode("utf-8")).hexdigest()[:12]
+
+    def render(self, variables: Dict[str, Any]) -> str:
+        return Template(self.text).substitute({k: str(v) for k, v in variables.items()})
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"name": self.name, "version": self.version, "source": self.source, "text": self.text}
+
+
+def parse_template(name: str, text: str, source: str, roles: Optional[List[str]] = None) -> PromptTemplate:
+    """Check a template's name and the variables it uses, raising ValueError if either is unknown"""
+    match = TEMPLATE_NAME.match(name)
+    if not match or match.group(1) not in PROMPT_VARIABLES:
+        raise ValueError(f"Unknown prompt template '{name}', expected <kind> or <kind>.<role> with kind one of {sorted(PROMPT_VARIABLES)}")
+    kind, role = match.groups()
+    if role is not None and roles is not None and role not in roles:
+        raise ValueError(f"Prompt template '{name}' is for unknown role '{role}', expected one of {sorted(roles)}")
+    template = Template(text)
+    if not template.is_valid():
+        raise ValueError(f"Prompt template '{name}' has a malformed placeholder; write a literal $ as $$")
+    unknown = set(template.get_identifiers()) - set(PROMPT_VARIABLES[kind])
+    if unknown:
+        raise ValueError(f"Prompt template '{name}' uses unknown variables {sorted(unknown)}, expected some of {list(PROMPT_VARIABLES[kind])}")
+    return PromptTemplate(name, text.rstrip("\n"), source)
+
+
+class PromptRegistry:
+    """The templates in a directory, reloaded when the files change if hot_reload is on
+
+    Roles are the ones with a charter template.
+    """
+
+    def __init__(self, directory: Optional[str] = None, hot_reload: bool = False):
+        self.directory = Path(directory) if directory else DEFAULT_PROMPT_DIR
+        self.hot_reload = hot_reload
+        self._templates: Dict[str, PromptTemplate] = {}
+        self._signature = None
+        self._lock = threading.Lock()
+        self.load()
+
+    def _files(self) -> List[Path]:
+        return sorted(self.directory.glob("*.tmpl"))
+
+    def load(self):
+        """Read every template in the directory, skipping (and logging) the invalid ones"""
+        with self._lock:
+            files = self._files()
+            names = [path.name[:-len(".tmpl")] for path in files]
+            roles = [n.split(".", 1)[1] for n in names if n.startswith("charter.")]
+            templates = {}
+            for name, path in zip(names, files):
+                try:
+                    templates[name] = parse_template(name, path.read_text(encoding="utf-8"), str(path), roles)
+                except (OSError, ValueError) as e:
+                    logger.error(f"Skipping prompt template {path}: {e}")
+            self._templates = templates
+            self._signature = self._stat(files)
+            logger.info(f"Loaded {len(templates)} prompt templates from {self.directory}")
+
+    def _stat(self, files: List[Path]):
+        try:
+            return tuple((path.name, path.stat().st_mtime_ns, path.stat().st_size) for path in files)
+        except OSError:
+            return None
+
+    def templates(self) -> Dict[str, PromptTemplate]:
+        """The current templates, picking up edits first under hot reload"""
+        if self.hot_reload and self._stat(self._files()) != self._signature:
+            self.load()
+        return self._templates
+
+    @property
+    def roles(self) -> List[str]:
+        return sorted(n.split(".", 1)[1] for n in self.templates() if n.startswith("charter."))
+
+    def with_overrides(self, overrides: Optional[Dict[str, str]] = None) -> "PromptSet":
+        """A run's view of the registry, with its own templates in place of some of the files"""
+        return PromptSet(self, overrides)
+
+
+class PromptSet:
+    """The templates one run renders, recording every version it used
+
+    Overrides are fixed for the run; the registry's files may still change
+    under hot reload, so a run can use more than one version of a template.
+    """
+
+    def __init__(self, registry: PromptRegistry, overrides: Optional[Dict[str, str]] = None):
+        self.registry = registry
+        roles = registry.roles
+        self.overrides = {
+            name: parse_template(name, text, "override", roles)
+            for name, text in (overrides or {}).items()
+        }
+        self.used: Dict[str, List[str]] = {}
+        self._lock = threading.Lock()
+
+    def get(self, kind: str, role: str) -> PromptTemplate:
+        """The role's own template of this kind, else the shared one"""
+        templates = {**self.registry.templates(), **self.overrides}
+        for name in (f"{kind}.{role}", kind):
+            if name in templates:
+                return templates[name]
+        raise KeyError(f"No '{kind}' prompt template for {role}")
+
+    def render(self, kind: str, role: str, /, **variables) -> str:
+        template = self.get(kind, role)
+        with self._lock:
+            versions = self.used.setdefault(template.name, [])
+            if template.version not in versions:
+                versions.append(template.version)
+        return template.render(variables)
+
+    def charter(self, name: str, role: str, role_level: int) -> str:
+        try:
+            return self.render("charter", role, name=name, role=role.upper(), role_level=role_level)
+        except KeyError:
+            return ""
+
+    def manifest(self) -> Dict[str, Any]:
+        """What a rerun needs to use the same prompts: overrides in full, and the version of each template"""
+        templates = {**self.registry.templates(), **self.overrides}
+        return {
+            "directory": str(self.registry.directory),
+            "versions": {name: t.version for name, t in sorted(templates.items())},
+            "overrides": {name: t.text for name, t in self.overrides.items()},
+            "used": {name: list(versions) for name, versions in sorted(self.used.items())}
+        }
+
+
+_registry: Optional[PromptRegistry] = None
+
+
+def get_prompt_registry() -> PromptRegistry:
+    """Get the process-wide registry, loading it from CHEFBENCH_PROMPT_DIR on first use
+
+    Set CHEFBENCH_PROMPT_RELOAD=1 while developing prompts to pick up edits without a restart.
+    """
+    global _registry
+    if _registry is None:
+        _registry = PromptRegistry(
+            os.environ.get("CHEFBENCH_PROMPT_DIR"),
+            hot_reload=os.environ.get("CHEFBENCH_PROMPT_RELOAD", "").lower() in ("1", "true", "yes")
+        )
+    return _registry
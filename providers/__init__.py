@@ -5,7 +5,55 @@ LLM Provider package for multi-provider AI integration.
 from .llm import (
     MultiAgentCoordinator,
 )
+from .policies import ASSIGNMENT_POLICIES, get_assignment_policy
+from .permissions import PermissionGuard, PermissionViolation
+from .rubric import QualityRubric, RubricCategory, QUALITY_RUBRICS, GRADERS, get_quality_rubric
+from .judge import LLMJudge, DEFAULT_JUDGE_MODEL, TRANSCRIPT_CRITERIA, judge_transcripts
+from .chaos import CHAOS_ACTIONS, ChaosInjection, adaptation_capability
+from .escalation import EscalationThresholds, EscalationWorker, Escalation
+from .handoff import HandoffSettings, HandoffProtocol, Handoff, REJECT_REASONS
+from .holds import COURSES, DEFAULT_COURSE, CourseHolds, HoldViolation
+from .expo import CALLBACK_AFTER_SECONDS, CourseSent, Expeditor
+from .routing import ModelRouting, role_models
+from .reliability import ERROR_CLASSES, RETRY_POLICIES, ErrorBudget, RetryPolicy, classify_error
 
 __all__ = [
     "MultiAgentCoordinator",
+    "ASSIGNMENT_POLICIES",
+    "get_assignment_policy",
+    "PermissionGuard",
+    "PermissionViolation",
+    "QualityRubric",
+    "RubricCategory",
+    "QUALITY_RUBRICS",
+    "GRADERS",
+    "get_quality_rubric",
+    "LLMJudge",
+    "DEFAULT_JUDGE_MODEL",
+    "TRANSCRIPT_CRITERIA",
+    "judge_transcripts",
+    "CHAOS_ACTIONS",
+    "ChaosInjection",
+    "adaptation_capability",
+    "EscalationThresholds",
+    "EscalationWorker",
+    "Escalation",
+    "HandoffSettings",
+    "HandoffProtocol",
+    "Handoff",
+    "REJECT_REASONS",
+    "COURSES",
+    "DEFAULT_COURSE",
+    "CourseHolds",
+    "HoldViolation",
+    "CALLBACK_AFTER_SECONDS",
+    "CourseSent",
+    "Expeditor",
+    "ERROR_CLASSES",
+    "RETRY_POLICIES",
+    "ErrorBudget",
+    "RetryPolicy",
+    "classify_error",
+    "ModelRouting",
+    "role_models",
 ]
\ No newline at end of file
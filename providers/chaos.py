@@ -0,0 +1,75 @@
+"""
+Chaos Injection for ChefBench
+Mid-run disruptions and how well the kitchen's performance recovers from them
+"""
+
+import time
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Any
+
+from models.models import TaskExecution
+
+CHAOS_ACTIONS = {
+    "kill_agent": "Take an agent off the team; its unfinished tasks are reassigned",
+    "break_equipment": "Break a piece of equipment (needs equipment simulation)",
+    "spike_orders": "Add a burst of extra tasks to the running scenario",
+    "spoil_inventory": "Spoil ingredients so later tasks must work without them",
+    "delay_llm": "Slow down agents' model responses for the next tasks",
+}
+
+# Tasks either side of a disruption compared to score recovery
+RECOVERY_WINDOW = 5
+
+
+@dataclass
+class ChaosInjection:
+    """One disruption and where in the run it hit"""
+    action: str
+    params: Dict[str, Any]
+    at_task: int  # tasks executed before it
+    event_id: Optional[int] = None
+    timestamp: float = field(default_factory=time.time)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "action": self.action,
+            "params": self.params,
+            "at_task": self.at_task,
+            "event_id": self.event_id,
+            "timestamp": self.timestamp
+        }
+
+
+def performance(executions: List[TaskExecution]) -> float:
+    """Mean quality, counting failed tasks as zero"""
+    return sum(e.quality_score if e.success else 0.0 for e in executions) / len(executions)
+
+
+def adaptation_capability(
+    executions: List[TaskExecution],
+    injections: List[ChaosInjection],
+    window: int = RECOVERY_WINDOW
+) -> Dict[str, Any]:
+    """How well performance held up after each disruption
+
+    Each injection's recovery is performance over the next `window` tasks
+    relative to the `window` before it (the whole run when it hit first),
+    capped at 1. The score is the mean recovery, None without injections
+    that had tasks after them.
+    """
+    scored = []
+    for injection in injections:
+        before = executions[max(0, injection.at_task - window):injection.at_task] or executions
+        after = executions[injection.at_task:injection.at_task + window]
+        recovery = None
+        if after:
+            baseline = performance(before)
+            recovery = 1.0 if baseline == 0 else min(1.0, performance(after) / baseline)
+        scored.append({**injection.to_dict(), "tasks_after": len(after), "recovery": recovery})
+
+    recoveries = [s["recovery"] for s in scored if s["recovery"] is not None]
+    return {
+        "score": sum(recoveries) / len(recoveries) if recoveries else None,
+        "window": window,
+        "injections": scored
+    }
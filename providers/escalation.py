@@ -0,0 +1,120 @@
+"""
+Order Escalation for ChefBench
+Watches queued tasks on the simulation clock, expediting and reassigning the ones left waiting too long
+"""
+
+import json
+import os
+from dataclasses import dataclass, asdict, fields
+from typing import Dict, List, Optional, Tuple, Any
+import logging
+
+from models.models import TaskType
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_TIME_LIMIT = 300.0
+
+EXPEDITE = 1  # moved to the front of the queue
+REASSIGN = 2  # also handed to a less loaded agent who can do it
+
+LEVEL_NAMES = {EXPEDITE: "expedite", REASSIGN: "reassign"}
+
+
+@dataclass
+class EscalationThresholds:
+    """When a queued task is escalated, as multiples of its time limit"""
+    enabled: bool = True
+    scan_interval: float = 60.0  # simulated seconds between scans of the queue
+    expedite_after: float = 1.0
+    reassign_after: float = 1.5
+
+    def validate(self):
+        if self.scan_interval <= 0:
+            raise ValueError("scan_interval must be positive")
+        if not 0 < self.expedite_after <= self.reassign_after:
+            raise ValueError("Thresholds must satisfy 0 < expedite_after <= reassign_after")
+
+    @classmethod
+    def from_env(cls) -> "EscalationThresholds":
+        """Defaults, overridden by a JSON object in CHEFBENCH_ESCALATION"""
+        raw = os.environ.get("CHEFBENCH_ESCALATION")
+        if not raw:
+            return cls()
+        try:
+            names = {f.name for f in fields(cls)}
+            thresholds = cls(**{k: v for k, v in json.loads(raw).items() if k in names})
+            thresholds.validate()
+            return thresholds
+        except (ValueError, TypeError, AttributeError) as e:
+            logger.error(f"Ignoring invalid CHEFBENCH_ESCALATION: {e}")
+            return cls()
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class Escalation:
+    """One task escalated by the worker"""
+    task_id: str
+    task_type: str
+    level: int
+    agent_name: str
+    waited_seconds: float
+    time_limit: float
+    simulated_time: float
+    reassigned_to: Optional[str] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {**asdict(self), "level": LEVEL_NAMES[self.level]}
+
+
+class EscalationWorker:
+    """Scans the queue each time the simulation clock passes another interval
+
+    A task is escalated once per level: expedited when it has waited past
+    expedite_after times its time limit, reassigned past reassign_after.
+    """
+
+    def __init__(self, thresholds: Optional[EscalationThresholds] = None):
+        self.thresholds = thresholds or EscalationThresholds()
+        self.next_scan = self.thresholds.scan_interval
+        self.levels: Dict[str, int] = {}
+        self.escalations: List[Escalation] = []
+
+    def due(self, clock: float) -> bool:
+        return self.thresholds.enabled and clock >= self.next_scan
+
+    def scan(
+        self,
+        queue,
+        clock: float,
+        queued_at: Dict[str, float]
+    ) -> List[Tuple[Tuple[str, TaskType, Dict[str, Any]], int, float]]:
+        """Queued (agent, task type, context) items newly past a threshold, with level and wait, longest waiting first"""
+        interval = self.thresholds.scan_interval
+        self.next_scan = (clock // interval + 1) * interval
+        due = []
+        for item in queue:
+            task_id = item[2]['task_id']
+            limit = item[2].get('time_limit') or DEFAULT_TIME_LIMIT
+            waited = clock - queued_at.get(task_id, 0.0)
+            if waited >= self.thresholds.reassign_after * limit:
+                level = REASSIGN
+            elif waited >= self.thresholds.expedite_after * limit:
+                level = EXPEDITE
+            else:
+                continue
+            if level > self.levels.get(task_id, 0):
+                self.levels[task_id] = level
+                due.append((item, level, waited))
+        return sorted(due, key=lambda d: -d[2])
+
+    def summary(self) -> Dict[str, Any]:
+        return {
+            "thresholds": self.thresholds.to_dict(),
+            "expedited": sum(1 for e in self.escalations if e.level == EXPEDITE),
+            "reassigned": sum(1 for e in self.escalations if e.reassigned_to),
+            "escalations": [e.to_dict() for e in self.escalations]
+        }
@@ -0,0 +1,182 @@
+"""
+Expeditor for ChefBench
+The expo works the pass: cooked portions wait there for the rest of their course, and slow ones are called back
+"""
+
+from dataclasses import dataclass, field, asdict
+from typing import Dict, List, Optional, Set, Tuple, Any
+
+from .holds import COURSES
+
+# Simulated seconds a portion waits at the pass before the expo calls back the rest of its course
+CALLBACK_AFTER_SECONDS = 60.0
+
+
+@dataclass
+class CourseSent:
+    """A course the expo sent out to a table"""
+    ticket: str
+    table: Optional[int]
+    course: str
+    task_ids: List[str]  # portions that went out
+    short: List[str] = field(default_factory=list)  # portions that failed or never reached the pass
+    waited_seconds: float = 0.0  # longest a portion of it stood at the pass
+    called_back: bool = False
+    simulated_time: float = 0.0
+
+    @property
+    def clean(self) -> bool:
+        """Sent whole, without the expo having to chase any of it"""
+        return not self.short and not self.called_back
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {**asdict(self), "clean": self.clean}
+
+
+class Expeditor:
+    """Checks cooked portions against their ticket and sends each course out whole
+
+    A portion reaching the pass waits on the window until every portion of
+    its course on the ticket is done; cancelled ones aren't waited for, and
+    failed ones go out short. A course left waiting past callback_after is
+    called back once; tables behind their floor pacing are chased at half that.
+    """
+
+    def __init__(self, name: str = "expo", callback_after: float = CALLBACK_AFTER_SECONDS):
+        if callback_after <= 0:
+            raise ValueError("callback_after must be positive")
+        self.name = name
+        self.callback_after = callback_after
+        self.window: Dict[str, Dict[str, Any]] = {}  # task id -> ticket, course, table and when it arrived
+        self.failed: Set[str] = set()
+        self.called_back: Set[Tuple[str, str]] = set()  # (ticket, course)
+        self.callbacks: List[Dict[str, Any]] = []
+        self.sent: List[CourseSent] = []
+
+    def receive(self, task_id: str, ticket: str, course: str, table: Optional[int], success: bool, now: float):
+        """A cooked portion comes up to the pass"""
+        self.window[task_id] = {"ticket": ticket, "course": course, "table": table, "arrived": now}
+        if not success:
+            self.failed.add(task_id)
+
+    def waiting(self) -> List[Tuple[str, str]]:
+        """(ticket, course) of every course with portions on the window, first to arrive first"""
+        courses = []
+        for entry in sorted(self.window.values(), key=lambda e: e["arrived"]):
+            if (entry["ticket"], entry["course"]) not in courses:
+                courses.append((entry["ticket"], entry["course"]))
+        return courses
+
+    def is_sent(self, ticket: str, course: str) -> bool:
+        return any(s.ticket == ticket and s.course == course for s in self.sent)
+
+    def current_course(self, ticket: Dict[str, Any]) -> Optional[str]:
+        """The first course on an order_ticket still to go out, None once they all have"""
+        for course in COURSES:
+            ordered = any(
+                p["state"] != "cancelled"
+                for line in ticket["lines"] if line["course"] == course for p in line["portions"]
+            ) or any(e["ticket"] == ticket["ticket"] and e["course"] == course for e in self.window.values())
+            if ordered and not self.is_sent(ticket["ticket"], course):
+                return course
+        return None
+
+    def check(self, ticket: Dict[str, Any], course: str, now: float) -> Optional[CourseSent]:
+        """Send a course of an order_ticket if every portion of it is done, or None while some are still cooking"""
+        states = {
+            p["task_id"]: p["state"] for line in ticket["lines"] if line["course"] == course
+            for p in line["portions"] if p["state"] != "cancelled"
+        }
+        # A ticket restored from a checkpoint lists only unfinished portions, so the window's count too
+        states.update({
+            task_id: "finished" for task_id, entry in self.window.items()
+            if entry["ticket"] == ticket["ticket"] and entry["course"] == course
+        })
+        portions = sorted(states, key=lambda t: (len(t), t))
+        if not portions or any(s != "finished" for s in states.values()) or self.is_sent(ticket["ticket"], course):
+            return None
+        arrived = [self.window[t]["arrived"] for t in portions if t in self.window]
+        sent = CourseSent(
+            ticket=ticket["ticket"],
+            table=ticket["table"],
+            course=course,
+            task_ids=[t for t in portions if t in self.window and t not in self.failed],
+            short=[t for t in portions if t not in self.window or t in self.failed],
+            waited_seconds=round(now - min(arrived), 1) if arrived else 0.0,
+            called_back=(ticket["ticket"], course) in self.called_back,
+            simulated_time=round(now, 1)
+        )
+        for task_id in portions:
+            self.window.pop(task_id, None)
+        self.sent.append(sent)
+        return sent
+
+    def overdue(self, now: float, behind: Optional[Set[int]] = None) -> List[Tuple[str, str, float]]:
+        """(ticket, course, waited) for courses waiting past the callback limit and not yet called back"""
+        behind = behind or set()
+        due = []
+        for ticket, course in self.waiting():
+            if (ticket, course) in self.called_back:
+                continue
+            entries = [e for e in self.window.values() if e["ticket"] == ticket and e["course"] == course]
+            waited = now - min(e["arrived"] for e in entries)
+            limit = self.callback_after / 2 if entries[0]["table"] in behind else self.callback_after
+            if waited >= limit:
+                due.append((ticket, course, waited))
+        return due
+
+    def call_back(self, ticket: str, course: str, table: Optional[int], task_ids: List[str], waited: float, now: float):
+        """Record chasing the portions a waiting course is short of"""
+        self.called_back.add((ticket, course))
+        self.callbacks.append({
+            "ticket": ticket, "course": course, "table": table, "task_ids": list(task_ids),
+            "waited_seconds": round(waited, 1), "simulated_time": round(now, 1)
+        })
+
+    def score(self) -> Optional[float]:
+        """The share of courses sent clean, None if none went out"""
+        if not self.sent:
+            return None
+        return sum(1 for s in self.sent if s.clean) / len(self.sent)
+
+    def coordination(self, score: Optional[float]) -> Optional[float]:
+        """A coordination score averaged with the pass's, or left as it is if no course went out"""
+        expo = self.score()
+        if expo is None:
+            return score
+        return expo if score is None else (score + expo) / 2
+
+    def summary(self) -> Dict[str, Any]:
+        return {
+            "name": self.name,
+            "callback_after": self.callback_after,
+            "score": self.score(),
+            "courses_sent": len(self.sent),
+            "clean": sum(1 for s in self.sent if s.clean),
+            "short": sum(len(s.short) for s in self.sent),
+            "on_the_window": sorted(self.window),
+            "sent": [s.to_dict() for s in self.sent],
+            "callbacks": list(self.callbacks)
+        }
+
+    def to_dict(self) -> Dict[str, Any]:
+        """The window and what has gone out, as checkpointed"""
+        return {
+            "name": self.name,
+            "callback_after": self.callback_after,
+            "window": dict(self.window),
+            "failed": sorted(self.failed),
+            "called_back": sorted([ticket, course] for ticket, course in self.called_back),
+            "callbacks": list(self.callbacks),
+            "sent": [asdict(s) for s in self.sent]
+        }
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "Expeditor":
+        expo = cls(data.get("name", "expo"), data.get("callback_after", CALLBACK_AFTER_SECONDS))
+        expo.window = dict(data.get("window", {}))
+        expo.failed = set(data.get("failed", []))
+        expo.called_back = {(ticket, course) for ticket, course in data.get("called_back", [])}
+        expo.callbacks = list(data.get("callbacks", []))
+        expo.sent = [CourseSent(**s) for s in data.get("sent", [])]
+        return expo
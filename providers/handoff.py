@@ -0,0 +1,185 @@
+"""
+Station Hand-offs for ChefBench
+Delegated tasks are offered to a station, which accepts or rejects them with a reason; rejected work is re-routed
+"""
+
+import json
+import os
+from dataclasses import dataclass, asdict, fields
+from typing import Dict, Iterable, List, Optional, Any
+import logging
+
+from models.models import LLMAgent, TaskType
+from equipment import EquipmentSimulator
+
+logger = logging.getLogger(__name__)
+
+ACCEPTED = "accepted"
+REJECTED = "rejected"
+
+OVER_CAPACITY = "over_capacity"
+MISSING_SKILL = "missing_skill"
+MISSING_EQUIPMENT = "missing_equipment"
+
+REJECT_REASONS = (OVER_CAPACITY, MISSING_SKILL, MISSING_EQUIPMENT)
+
+
+@dataclass
+class HandoffSettings:
+    """When a station turns delegated work down, and how long each offer takes to answer"""
+    enabled: bool = True
+    capacity: Optional[int] = None  # tasks an agent accepts per run; None for no limit
+    min_skill: float = 0.2  # below this skill at the task, the agent rejects it
+    ack_seconds: float = 5.0  # simulated seconds for a station to answer one offer
+
+    def validate(self):
+        if self.capacity is not None and self.capacity < 1:
+            raise ValueError("capacity must be at least 1")
+        if not 0 <= self.min_skill <= 1:
+            raise ValueError("min_skill must be between 0 and 1")
+        if self.ack_seconds < 0:
+            raise ValueError("ack_seconds must not be negative")
+
+    @classmethod
+    def from_env(cls) -> "HandoffSettings":
+        """Defaults, overridden by a JSON object in CHEFBENCH_HANDOFF"""
+        raw = os.environ.get("CHEFBENCH_HANDOFF")
+        if not raw:
+            return cls()
+        try:
+            names = {f.name for f in fields(cls)}
+            settings = cls(**{k: v for k, v in json.loads(raw).items() if k in names})
+            settings.validate()
+            return settings
+        except (ValueError, TypeError, AttributeError) as e:
+            logger.error(f"Ignoring invalid CHEFBENCH_HANDOFF: {e}")
+            return cls()
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class HandoffOffer:
+    """One station's answer to a delegated task"""
+    recipient: str
+    status: str
+    reason: Optional[str] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class Handoff:
+    """A delegated task and every offer made until a station took it"""
+    task_id: str
+    task_type: str
+    delegator: str
+    offers: List[HandoffOffer]
+    assigned_to: str
+    routed: bool  # False when every station rejected it and it stayed with the first
+    latency_seconds: float
+
+    @property
+    def rerouted(self) -> bool:
+        return self.routed and self.assigned_to != self.offers[0].recipient
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            **asdict(self),
+            "offers": [o.to_dict() for o in self.offers],
+            "rerouted": self.rerouted
+        }
+
+
+class HandoffProtocol:
+    """Offers delegated tasks to stations and keeps the answers
+
+    A station rejects a task it has no capacity left for, isn't skilled enough
+    at, or has no working equipment for. The task is then offered to the other
+    agents who can do it, in order. If all of them reject it too, it stays with
+    the first station, which has to work it as best it can.
+    """
+
+    def __init__(self, settings: Optional[HandoffSettings] = None):
+        self.settings = settings or HandoffSettings()
+        self.accepted: Dict[str, int] = {}  # agent -> tasks accepted this run
+        self.handoffs: List[Handoff] = []
+
+    def answer(
+        self,
+        recipient: LLMAgent,
+        task_type: TaskType,
+        equipment: Optional[EquipmentSimulator] = None,
+        techniques: Iterable[str] = ()
+    ) -> HandoffOffer:
+        """The recipient's acknowledgement of one offer; techniques are the dish's, whose equipment it needs too"""
+        capacity = self.settings.capacity
+        if capacity is not None and self.accepted.get(recipient.name, 0) >= capacity:
+            reason = OVER_CAPACITY
+        elif recipient.skill(task_type) < self.settings.min_skill:
+            reason = MISSING_SKILL
+        elif equipment and equipment.outages_for(task_type, techniques):
+            reason = MISSING_EQUIPMENT
+        else:
+            return HandoffOffer(recipient.name, ACCEPTED)
+        return HandoffOffer(recipient.name, REJECTED, reason)
+
+    def offer(
+        self,
+        delegator: LLMAgent,
+        recipients: List[LLMAgent],
+        task_type: TaskType,
+        task_id: str,
+        equipment: Optional[EquipmentSimulator] = None,
+        techniques: Iterable[str] = ()
+    ) -> Handoff:
+        """Offer a task to each recipient in turn until one accepts"""
+        techniques = list(techniques)
+        offers = []
+        for recipient in recipients:
+            offers.append(self.answer(recipient, task_type, equipment, techniques))
+            if offers[-1].status == ACCEPTED:
+                break
+        routed = offers[-1].status == ACCEPTED
+        assigned_to = offers[-1].recipient if routed else offers[0].recipient
+        self.accepted[assigned_to] = self.accepted.get(assigned_to, 0) + 1
+
+        handoff = Handoff(
+            task_id=task_id,
+            task_type=task_type.function_name,
+            delegator=delegator.name,
+            offers=offers,
+            assigned_to=assigned_to,
+            routed=routed,
+            latency_seconds=len(offers) * self.settings.ack_seconds
+        )
+        self.handoffs.append(handoff)
+        return handoff
+
+    def coordination_score(self) -> Optional[float]:
+        """How smoothly work changed hands: each hand-off scores 1 when accepted on the first offer,
+        falling with every rejection it took to place, and 0 when no station would take it"""
+        if not self.handoffs:
+            return None
+        return sum(1 / len(h.offers) if h.routed else 0.0 for h in self.handoffs) / len(self.handoffs)
+
+    def summary(self) -> Dict[str, Any]:
+        rejections: Dict[str, int] = {reason: 0 for reason in REJECT_REASONS}
+        for handoff in self.handoffs:
+            for offer in handoff.offers:
+                if offer.reason:
+                    rejections[offer.reason] += 1
+        latencies = [h.latency_seconds for h in self.handoffs]
+        return {
+            "settings": self.settings.to_dict(),
+            "total": len(self.handoffs),
+            "accepted_first": sum(1 for h in self.handoffs if h.routed and len(h.offers) == 1),
+            "rerouted": sum(1 for h in self.handoffs if h.rerouted),
+            "unrouted": sum(1 for h in self.handoffs if not h.routed),
+            "rejections": rejections,
+            "average_latency_seconds": sum(latencies) / len(latencies) if latencies else None,
+            "coordination_score": self.coordination_score(),
+            "handoffs": [h.to_dict() for h in self.handoffs]
+        }
@@ -0,0 +1,152 @@
+"""
+Course Holds for ChefBench
+The pass holds and fires courses, for every table or just one, and counts the held portions cooks fire anyway
+"""
+
+import json
+import re
+from dataclasses import dataclass, asdict
+from typing import Dict, List, Optional, Set, Tuple, Any
+
+# An order line's course, in the order a meal goes out; lines given none are mains
+COURSES = ("starter", "main", "dessert")
+DEFAULT_COURSE = "main"
+
+# Coordination lost for each held portion a cook fires anyway
+HOLD_VIOLATION_PENALTY = 0.1
+
+PLURALS = {"starter": "starters", "main": "mains", "dessert": "desserts"}
+
+
+def hold_label(course: str, table: Optional[int] = None) -> str:
+    """How the pass calls it: "desserts", or "mains for table 7" """
+    return PLURALS[course] + (f" for table {table}" if table is not None else "")
+
+
+def course_of(context: Dict[str, Any]) -> str:
+    return context.get('course') or DEFAULT_COURSE
+
+
+def fires_anyway(answer: str) -> bool:
+    """Whether a cook's answer to a hold fires the portion: it says fire and never hold"""
+    try:
+        answer = str(json.loads(answer).get("answer", ""))
+    except (json.JSONDecodeError, AttributeError):
+        pass
+    return bool(re.search(r"\bfire\b", answer, re.IGNORECASE)) and not re.search(r"\bhold\b", answer, re.IGNORECASE)
+
+
+@dataclass
+class HoldViolation:
+    """A held portion its cook fired anyway"""
+    task_id: str
+    agent_name: str
+    course: str
+    table: Optional[int]
+    hold: str  # the call it went against, e.g. "desserts"
+    simulated_time: float
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class CourseHolds:
+    """Which courses the pass is holding, for every table or for one
+
+    A course held for every table stays held at a table until it's fired
+    there or everywhere; holding it everywhere again takes back the tables
+    it was fired at. Portions without a table are only held with every
+    table's.
+    """
+
+    def __init__(self):
+        self.everywhere: Set[str] = set()  # courses held for every table
+        self.tables: Set[Tuple[str, int]] = set()  # (course, table) held at one table
+        self.fired: Set[Tuple[str, int]] = set()  # (course, table) fired at one table while held everywhere
+        self.calls: List[Dict[str, Any]] = []  # every hold and fire called this run, oldest first
+        self.violations: List[HoldViolation] = []
+
+    @staticmethod
+    def validate(course: str):
+        if course not in COURSES:
+            raise ValueError(f"Unknown course '{course}', expected one of {list(COURSES)}")
+
+    def holding(self, context: Dict[str, Any]) -> Optional[str]:
+        """The call holding an order portion, e.g. "mains for table 7", or None if it can fire"""
+        course, table = course_of(context), context.get('table')
+        if table is not None and (course, table) in self.fired:
+            return None
+        if course in self.everywhere:
+            return hold_label(course)
+        if table is not None and (course, table) in self.tables:
+            return hold_label(course, table)
+        return None
+
+    def hold(self, course: str, table: Optional[int] = None) -> bool:
+        """Hold a course, returning False if it already was"""
+        self.validate(course)
+        if table is None:
+            changed = course not in self.everywhere or any(c == course for c, _ in self.fired)
+            self.everywhere.add(course)
+            self.fired = {(c, t) for c, t in self.fired if c != course}
+            self.tables = {(c, t) for c, t in self.tables if c != course}
+            return changed
+        if self.holding({'course': course, 'table': table}):
+            return False
+        self.fired.discard((course, table))
+        if course not in self.everywhere:
+            self.tables.add((course, table))
+        return True
+
+    def fire(self, course: str, table: Optional[int] = None) -> bool:
+        """Fire a held course, returning False if it wasn't held"""
+        self.validate(course)
+        if table is None:
+            changed = course in self.everywhere or any(c == course for c, _ in self.tables)
+            self.everywhere.discard(course)
+            self.tables = {(c, t) for c, t in self.tables if c != course}
+            self.fired = {(c, t) for c, t in self.fired if c != course}
+            return changed
+        if not self.holding({'course': course, 'table': table}):
+            return False
+        self.tables.discard((course, table))
+        if course in self.everywhere:
+            self.fired.add((course, table))
+        return True
+
+    def active(self) -> List[Dict[str, Any]]:
+        """The calls standing now, in course order"""
+        standing = [{"course": c, "table": None, "fired_at": sorted(t for fc, t in self.fired if fc == c)}
+                    for c in self.everywhere]
+        standing += [{"course": c, "table": t, "fired_at": []} for c, t in self.tables]
+        standing.sort(key=lambda h: (COURSES.index(h["course"]), h["table"] is not None, h["table"] or 0))
+        return [{**h, "hold": hold_label(h["course"], h["table"])} for h in standing]
+
+    def coordination(self, score: Optional[float]) -> Optional[float]:
+        """A coordination score less HOLD_VIOLATION_PENALTY per violation, from 1.0 if there was none"""
+        if not self.violations:
+            return score
+        return max(0.0, (1.0 if score is None else score) - HOLD_VIOLATION_PENALTY * len(self.violations))
+
+    def summary(self) -> Dict[str, Any]:
+        return {
+            "holding": self.active(),
+            "calls": list(self.calls),
+            "violations": [v.to_dict() for v in self.violations]
+        }
+
+    def to_dict(self) -> Dict[str, Any]:
+        """The standing calls, as checkpointed"""
+        return {
+            "everywhere": sorted(self.everywhere),
+            "tables": sorted([c, t] for c, t in self.tables),
+            "fired": sorted([c, t] for c, t in self.fired)
+        }
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "CourseHolds":
+        holds = cls()
+        holds.everywhere = set(data.get("everywhere", []))
+        holds.tables = {(c, t) for c, t in data.get("tables", [])}
+        holds.fired = {(c, t) for c, t in data.get("fired", [])}
+        return holds
@@ -0,0 +1,179 @@
+"""
+LLM Judge for ChefBench
+A separate model that scores agent output against written criteria
+"""
+
+import json
+import re
+import threading
+from typing import Dict, List, Optional, Any
+import logging
+
+from models.models import LLMAgent, AgentRole
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_JUDGE_MODEL = "cohere/command-r"
+
+# What the transcript judge scores each agent on
+TRANSCRIPT_CRITERIA = {
+    "reasoning_quality": "Is the reasoning sound, specific to the task and consistent with what happened?",
+    "role_adherence": "Does the agent act within its role, defer to seniors and only delegate work others may do?",
+    "safety": "Does the agent respect food safety: temperatures, hygiene, allergens and dietary restrictions?",
+}
+
+# Most recent exchanges shown to the judge per agent, and how much of each
+TRANSCRIPT_EXCERPTS = 8
+PROMPT_CHARS = 300
+RESPONSE_CHARS = 600
+
+_JSON_OBJECT = re.compile(r"\{.*\}", re.DOTALL)
+
+
+class LLMJudge:
+    """Judge model loaded on first use, shared by every grader in a run"""
+
+    def __init__(self, model_name: str = DEFAULT_JUDGE_MODEL, seed: Optional[int] = None):
+        self.model_name = model_name
+        self.seed = seed
+        self._agent: Optional[LLMAgent] = None
+        self._lock = threading.Lock()
+        self.calls = 0
+        self.failures = 0  # no usable verdict: model error or unparseable output
+
+    @property
+    def agent(self) -> LLMAgent:
+        with self._lock:
+            if self._agent is None:
+                self._agent = LLMAgent(
+                    "judge", AgentRole.HEAD_CHEF, self.model_name,
+                    seed=self.seed, fallback_policy="heuristic"
+                )
+            return self._agent
+
+    def ask(self, prompt: str) -> Optional[Dict[str, Any]]:
+        """Send a judging prompt, returning the JSON verdict or None if there isn't a usable one"""
+        self.calls += 1
+        agent = self.agent
+        response, degradation = agent._generate_response(prompt)
+        if degradation is not None:
+            self.failures += 1
+            logger.warning(f"Judge {self.model_name} failed: {degradation}")
+            return None
+
+        match = _JSON_OBJECT.search(response)
+        try:
+            verdict = json.loads(match.group()) if match else None
+        except json.JSONDecodeError:
+            verdict = None
+        if not isinstance(verdict, dict):
+            self.failures += 1
+            return None
+        return verdict
+
+    def score(self, criteria: str, submission: str) -> Optional[float]:
+        """Score a submission from 0 to 1 against the criteria"""
+        verdict = self.ask(f"""You are an impartial head chef judging a cook's work.
+Criteria: {criteria}
+
+Submission:
+{submission}
+
+Respond in JSON format:
+{{"score": 0.0-1.0, "rationale": "one sentence"}}""")
+        if verdict is None:
+            return None
+        try:
+            return min(1.0, max(0.0, float(verdict["score"])))
+        except (TypeError, KeyError, ValueError):
+            self.failures += 1
+            return None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"model": self.model_name, "calls": self.calls, "failures": self.failures}
+
+
+def _unit(value: Any) -> Optional[float]:
+    try:
+        return min(1.0, max(0.0, float(value)))
+    except (TypeError, ValueError):
+        return None
+
+
+def _transcript_prompt(
+    agent_name: str,
+    metrics: Dict[str, Any],
+    transcripts: List[Dict[str, Any]],
+    outcomes: List[Dict[str, Any]]
+) -> str:
+    criteria = "\n".join(f"- {name}: {question}" for name, question in TRANSCRIPT_CRITERIA.items())
+    facts = "\n".join(
+        f"- {o['task_type']}: {'succeeded' if o['success'] else 'failed'}, quality {o['quality_score']:.2f}"
+        + (f", used restricted ingredients {o['restricted_ingredients_used']}" if o.get("restricted_ingredients_used") else "")
+        + (f", referenced things the kitchen lacks {o['invalid_references']}" if o.get("invalid_references") else "")
+        for o in outcomes
+    ) or "- no tasks executed"
+    exchanges = "\n".join(
+        f"[{i + 1}] Prompt: {t['prompt'][:PROMPT_CHARS]}\n    Response: {t['response'][:RESPONSE_CHARS]}"
+        for i, t in enumerate(transcripts[-TRANSCRIPT_EXCERPTS:])
+    )
+    fields = ", ".join(f'"{name}": 0.0-1.0' for name in TRANSCRIPT_CRITERIA)
+    return f"""You are an impartial head chef reviewing {agent_name}, a {metrics.get('role', 'cook')}, after a kitchen service.
+Score each criterion from 0 to 1:
+{criteria}
+
+What actually happened (ground truth):
+{facts}
+- permission violations: {metrics.get('permission_violations', 0)}
+
+The agent's decisions, oldest first:
+{exchanges}
+
+Respond in JSON format:
+{{{fields}, "rationale": "two sentences"}}"""
+
+
+def judge_transcripts(
+    judge: LLMJudge,
+    transcripts: List[Dict[str, Any]],
+    result: Dict[str, Any]
+) -> Dict[str, Any]:
+    """Score each agent's decision transcript against the run's actual outcomes
+
+    Only agents on the team are judged, so the judge's own transcripts from
+    rubric grading are left out. Criteria the judge didn't score are omitted.
+    """
+    agents = result.get("agent_metrics", {}).get("agents", {})
+    history = result.get("execution_history", [])
+
+    judged: Dict[str, Dict[str, Any]] = {}
+    for agent_name, metrics in agents.items():
+        own = [t for t in transcripts if t["agent_name"] == agent_name]
+        if not own:
+            continue
+        outcomes = [e for e in history if e["agent_name"] == agent_name]
+        verdict = judge.ask(_transcript_prompt(agent_name, metrics, own, outcomes)) or {}
+
+        scores = {name: _unit(verdict.get(name)) for name in TRANSCRIPT_CRITERIA}
+        scores = {name: score for name, score in scores.items() if score is not None}
+        judged[agent_name] = {
+            **scores,
+            "overall": sum(scores.values()) / len(scores) if scores else None,
+            "rationale": str(verdict.get("rationale", "")),
+            "transcripts": len(own)
+        }
+        if not scores:
+            logger.warning(f"Judge gave no usable scores for {agent_name}")
+
+    team = {}
+    for name in (*TRANSCRIPT_CRITERIA, "overall"):
+        values = [a[name] for a in judged.values() if a.get(name) is not None]
+        if values:
+            team[name] = sum(values) / len(values)
+
+    return {
+        "judge": judge.to_dict(),
+        "criteria": dict(TRANSCRIPT_CRITERIA),
+        "agents": judged,
+        "team": team
+    }
@@ -5,46 +5,719 @@ Manages multi-agent message passing and task coordination
 
 import asyncio
 import json
+import random
 import time
-from typing import Dict, List, Optional, Tuple, Any
-from collections import defaultdict
+from typing import Dict, List, Optional, Tuple, Any, Callable
+from collections import defaultdict, deque
 import logging
-from models.models import LLMAgent, AgentRole, TaskType, Message, TaskExecution
+from models.memory import AgentMemory, MemorySettings
+from models.models import LLMAgent, AgentRole, TaskType, Message, TaskExecution, KitchenEvent, AgentPaused, SkillProfile
+from database.event_store import EventStore, NOTE_SEVERITIES, ORDER_PRIORITIES
+from .policies import AssignmentPolicy, get_assignment_policy
+from .permissions import PermissionGuard, PermissionViolation
+from .routing import ModelRouting, role_models
+from .probes import MemoryProbe, build_probes, ask_probe, summarize_probes
+from .rubric import QualityRubric, Submission, QUALITY_RUBRICS
+from .judge import LLMJudge, DEFAULT_JUDGE_MODEL
+from .chaos import CHAOS_ACTIONS, ChaosInjection, adaptation_capability, performance
+from .escalation import EscalationWorker, EscalationThresholds, Escalation, EXPEDITE, REASSIGN, LEVEL_NAMES, DEFAULT_TIME_LIMIT
+from .reliability import ErrorBudget, TaskError, RETRY_POLICIES, TRANSIENT, RETRIED, FALLBACK, FAILED, classify_error
+from .pacing import MealPlanner, PacingPlan
+from .handoff import HandoffProtocol, HandoffSettings, Handoff, ACCEPTED
+from .holds import COURSES, CourseHolds, HoldViolation, course_of, hold_label, fires_anyway
+from .expo import Expeditor, CourseSent
+from observability import log_context, get_usage_tracker, start_span, BUDGET_OK, BUDGET_WARNING, BUDGET_EXCEEDED, BUDGET_STATES
+from prompts import PromptSet, get_prompt_registry
+from recipes.normalization import get_normalizer
+from recipes.techniques import TECHNIQUES
+from equipment import (
+    EquipmentSimulator, STATION_EQUIPMENT, STATION_ROLES, station_for, station_report,
+    station_capacity_from_env, utilization_summary
+)
+from equipment.simulator import BROKEN
+from staffing import ShiftSchedule, HRSystem, StaffRequest, SkillStore
+from dining import FloorPlan, ORDER_TASKS, PASS_TASKS, FrontOfHouse, GuestSimulator, GuestRequest, service_summary
+from dining import COMPLEXITY_LEVELS, score_order, complexity_level
 
 logger = logging.getLogger(__name__)
 
+# Quality multiplier for tasks attempted while every unit of a required equipment kind is down
+EQUIPMENT_OUTAGE_PENALTY = 0.6
+
 
 class MultiAgentCoordinator:
     """Coordinates multiple LLM agents in kitchen simulation"""
     
-    def __init__(self):
+    def __init__(
+        self,
+        assignment_policy: str = "highest_rank",
+        probe_interval: int = 5,
+        event_store: Optional[EventStore] = None
+    ):
         self.agents: Dict[str, LLMAgent] = {}
+        self.event_store = event_store
+        self.event_log: List[KitchenEvent] = []
+        self.run_id: Optional[str] = None
+        self._task_events: Dict[str, Optional[int]] = {}
+        self._message_events: Dict[int, Optional[int]] = {}
+        self.probe_interval = probe_interval  # tasks between memory probes, 0 disables
+        self.probe_results: List[MemoryProbe] = []
+        # How much agents recall verbatim before older memories are summarized
+        self.memory_settings = MemorySettings.from_env()
+        self.assignment_policy_name = assignment_policy
+        self.assignment_policy: AssignmentPolicy = get_assignment_policy(assignment_policy)
         self.message_bus: List[Message] = []
         self.task_queue: List[Tuple[str, TaskType, Dict]] = []
         self.execution_history: List[TaskExecution] = []
         self.scenario_start_time: Optional[float] = None
         self.scenario_end_time: Optional[float] = None
+        self.seed: Optional[int] = None
+        self.equipment: Optional[EquipmentSimulator] = None
+        self.schedule = ShiftSchedule()
+        self.hr = HRSystem()
+        self.skill_store = SkillStore()
+        self.floor = FloorPlan()
+        self.front_of_house = FrontOfHouse()
+        self.guests: Optional[GuestSimulator] = None
+        self.guest_requests: List[GuestRequest] = []
+        # Plans the firing order of all orders at once, when enabled, and the plan it made
+        self.pacing: Optional[MealPlanner] = None
+        self.pacing_plan: Optional[PacingPlan] = None
+        self._ticket_seconds: Dict[str, float] = {}  # order task id -> simulated seconds from queued to done
+        self._order_completions: List[Dict[str, Any]] = []  # finished orders with their complexity, oldest first
+        self.delays: List[Dict[str, Any]] = []  # late table orders, and the server told (if any)
+        self.order_notes: List[Dict[str, Any]] = []  # annotations on the run's orders, oldest first
+        self.scenario_duration: float = 0.0
+        self.paused_agents: List[str] = []
+        self.permissions = PermissionGuard()
+        self.rubric: QualityRubric = QUALITY_RUBRICS["default"]
+        self.judge: Optional[LLMJudge] = None
+        self.prompts: PromptSet = get_prompt_registry().with_overrides()
+        # Role-to-model routing the current team was built from, if it was
+        self.routing: Optional[ModelRouting] = None
+        # Held for a whole evaluation (reset, seeding and execution) so runs on
+        # the same coordinator never interleave
+        self.run_lock = asyncio.Lock()
+        # Cleared while a run is paused; the task loop waits on it between tasks
+        self._unpaused = asyncio.Event()
+        self._unpaused.set()
+        self._paused_seconds = 0.0
+        self._assignments: Dict[str, List[Tuple[TaskType, Dict]]] = {}
+        self._settled: set = set()  # task ids executed or skipped this run
+        self._total_tasks = 0
+        self._orders_submitted = 0  # numbers order task ids, so they stay unique whatever becomes of the tasks
+        # Tasks still to run this scenario as (agent, task type, context), in order
+        self._queue: deque = deque()
+        # Disruptions injected into the current run
+        self.chaos: List[ChaosInjection] = []
+        # Expedites and reassigns tasks left waiting, on the simulation clock
+        self.escalation = EscalationWorker(EscalationThresholds.from_env())
+        # Stations acknowledge delegated work, and reject what they can't take
+        self.handoff = HandoffProtocol(HandoffSettings.from_env())
+        # Errors agents run into on their tasks, against a per-agent budget
+        self.error_budget = ErrorBudget.from_env()
+        self._queued_at: Dict[str, float] = {}  # task id -> simulated time it joined the queue
+        # Slots per station; tasks past them wait unassigned, in arrival order, until one frees up
+        self.station_capacity = station_capacity_from_env()
+        self._deferred: deque = deque()  # (None, task type, context), shaped like queue items
+        self._deferrals: Dict[str, int] = defaultdict(int)  # station -> tasks deferred this run
+        self._station_samples: List[Dict[str, Any]] = []  # station load as each task started
+        self.throttled_orders: List[Dict[str, Any]] = []  # orders refused by admission this run
+        self.eighty_sixed: Dict[int, List[str]] = {}  # menu items announced as 86'd this run, with why
+        # Courses the pass is holding, and queued portions their cooks have agreed to hold
+        self.holds = CourseHolds()
+        self._holding: set = set()
+        # Works the pass when enabled, sending table orders out a whole course at a time
+        self.expo: Optional[Expeditor] = None
+        # Set when a held run could move again: a course fired, or the queue changed
+        self._queue_changed = asyncio.Event()
+        # Seconds into the run orders are still due by, keeping it open with nothing queued until then
+        self._orders_due_until = 0.0
+        # The task being worked on, cancellations waiting for its next safe point, and what tasks hold
+        self._in_flight: Optional[Tuple[str, TaskType, Dict]] = None
+        self._revoked: Dict[str, str] = {}  # task id -> reason
+        self._cancelled: set = set()  # task ids dropped or revoked this run
+        # Submitted orders by ticket, each with its tasks in the order they were added
+        self.tickets: Dict[str, List[Tuple[TaskType, Dict]]] = {}
+        self.held_ingredients: Dict[str, List[str]] = {}
+        # Skill trainees gained this run, by agent
+        self._skill_gains: Dict[str, float] = defaultdict(float)
+        self.spoiled: set = set()
+        self.llm_delay = 0.0
+        self.llm_delay_tasks = 0
+        # Called with checkpoint_state() after every task and on pause
+        self.checkpoint_sink: Optional[Callable[[Dict[str, Any]], None]] = None
+        # Called with the reason whenever the run is paused, including when it pauses itself
+        self.pause_sink: Optional[Callable[[Optional[str]], None]] = None
+        self.pause_reason: Optional[str] = None
+        # Called with the planner's name and the items a menu planning task chose; returns recipe ids it didn't know
+        self.menu_sink: Optional[Callable[[str, List[Dict[str, Any]]], List[Any]]] = None
+        self._budget_state = BUDGET_OK  # of the run's token and cost budget, as last checked
         
+    @property
+    def running(self) -> bool:
+        return self.run_lock.locked()
+    
+    @property
+    def elapsed_seconds(self) -> float:
+        """Seconds the current run has been going, less the pauses it has come back from"""
+        if self.scenario_start_time is None:
+            return 0.0
+        return time.time() - self.scenario_start_time - self._paused_seconds
+    
+    @property
+    def paused(self) -> bool:
+        return not self._unpaused.is_set()
+    
+    @property
+    def in_flight_task_id(self) -> Optional[str]:
+        """The task being worked on in the executing run, if any"""
+        if self._in_flight and self._in_flight[2]['task_id'] not in self._settled:
+            return self._in_flight[2]['task_id']
+        return None
+    
+    def pause(self, reason: Optional[str] = None) -> bool:
+        """Hold the run before its next task, returning False if it was already paused"""
+        if self.paused:
+            return False
+        self._unpaused.clear()
+        self.pause_reason = reason
+        self.record_event("run_paused", **({"reason": reason} if reason else {}))
+        logger.info(f"Run {self.run_id} paused" + (f": {reason}" if reason else ""))
+        if self.pause_sink:
+            self.pause_sink(reason)
+        return True
+    
+    def resume(self) -> bool:
+        """Let a paused run continue, returning False if it wasn't paused"""
+        if not self.paused:
+            return False
+        self._unpaused.set()
+        self.pause_reason = None
+        self.record_event("run_resumed", paused_seconds=self._paused_seconds)
+        logger.info(f"Run {self.run_id} resumed")
+        return True
+    
+    def inject_chaos(self, action: str, **params: Any) -> ChaosInjection:
+        """Disrupt the running scenario before its next task, recording the disruption
+    
+        Raises ValueError for an unknown action or parameters that don't fit the run.
+        """
+        if action not in CHAOS_ACTIONS:
+            raise ValueError(f"Unknown chaos action '{action}', expected one of {sorted(CHAOS_ACTIONS)}")
+    
+        details: Dict[str, Any] = {}
+        if action == "kill_agent":
+            name = params["agent"]
+            if name not in self.agents:
+                raise ValueError(f"Unknown agent '{name}'")
+            self.agents.pop(name)
+            orphaned = [(task_type, context) for agent_name, task_type, context in self._queue if agent_name == name]
+            self._queue = deque(item for item in self._queue if item[0] != name)
+            self._assignments.pop(name, None)
+            details["reassigned"] = self._enqueue(orphaned)
+        elif action == "break_equipment":
+            name = params["equipment"]
+            if self.equipment is None:
+                raise ValueError("Equipment simulation is not active")
+            if name not in self.equipment.items:
+                raise ValueError(f"Unknown equipment '{name}'")
+            change = self.equipment.break_item(name)
+            self.record_event(change.event_type, equipment=name, simulated_time=change.time, **change.details)
+        elif action == "spike_orders":
+            tasks = params.pop("tasks")
+            for i, (_, context) in enumerate(tasks):
+                context['task_id'] = f"chaos-{len(self.chaos) + 1}-{i + 1}"
+            self._total_tasks += len(tasks)
+            params["count"] = len(tasks)
+            details["queued"] = self._enqueue(tasks)
+        elif action == "spoil_inventory":
+            self.spoiled.update(params["ingredients"])
+        else:  # delay_llm
+            self.llm_delay = params["seconds"]
+            self.llm_delay_tasks = params["tasks"]
+    
+        injection = ChaosInjection(action, params, len(self.execution_history))
+        injection.event_id = self.record_event("chaos_injected", action=action, **params, **details)
+        self.chaos.append(injection)
+        logger.info(f"Injected {action} into run {self.run_id}: {params}")
+        return injection
+    
+    def _enqueue(self, tasks: List[Tuple[TaskType, Dict]]) -> int:
+        """Assign tasks mid-run and put them at the back of the queue, returning how many were assigned"""
+        assigned = 0
+        clock = self._simulated_clock()
+        for agent_name, agent_tasks in self._assign_tasks(tasks).items():
+            self._assignments.setdefault(agent_name, []).extend(agent_tasks)
+            self._queue.extend((agent_name, task_type, context) for task_type, context in agent_tasks)
+            self._queued_at.update((context['task_id'], clock) for _, context in agent_tasks)
+            assigned += len(agent_tasks)
+        self._queue_changed.set()
+        return assigned
+    
+    def active_tasks(self) -> List[Tuple[TaskType, Dict]]:
+        """The run's unfinished tasks in the order they'll be worked: in progress, queued, then deferred"""
+        active = [(task_type, context) for _, task_type, context in (*self._queue, *self._deferred)]
+        if self.in_flight_task_id:
+            active.insert(0, self._in_flight[1:])
+        return active
+    
+    def queued_tasks(self) -> List[Tuple[str, TaskType, Dict]]:
+        """(agent name, task type, context) of the tasks queued to agents, in the order they'll be worked"""
+        return list(self._queue)
+    
+    def queue_depth(self) -> int:
+        """How many tasks are queued to agents, not counting the one in progress or deferred ones"""
+        return len(self._queue)
+    
+    def throttle_order(self, portions: int, reasons: List[str], wait_estimate: Optional[float], **details: Any):
+        """Record an order turned away at the door, which counts against how well the run managed intake"""
+        throttled = {
+            "portions": portions,
+            "reasons": list(reasons),
+            "wait_estimate": wait_estimate,
+            "simulated_time": round(self._simulated_clock(), 1),
+            **details
+        }
+        self.throttled_orders.append(throttled)
+        self.record_event("order_throttled", **throttled)
+    
+    def announce_86(self, recipe_id: int, dish: str, reasons: List[str]) -> bool:
+        """Tell the team a menu item is off, once per run; the head chef is asked for a special in its place
+
+        Returns False if the item was already announced.
+        """
+        if recipe_id in self.eighty_sixed:
+            return False
+        self.eighty_sixed[recipe_id] = list(reasons)
+        event_id = self.record_event("item_86d", recipe_id=recipe_id, dish=dish, reasons=list(reasons))
+        head_chef = self._get_head_chef()
+        for name, agent in self.agents.items():
+            content = f"86 {dish} (recipe {recipe_id}): {'; '.join(reasons)}"
+            if head_chef and name == head_chef.name:
+                content += ". Put a special on in its place from what we have"
+            message = Message(
+                sender="inventory",
+                recipient=name,
+                role=AgentRole.KITCHEN_PORTER,  # stock levels carry no authority in the kitchen
+                content=content,
+                requires_response=head_chef is not None and name == head_chef.name,
+                priority=1 if head_chef and name == head_chef.name else 2
+            )
+            self._deliver(message, event_id)
+        logger.info(f"86'd {dish} in run {self.run_id}: {'; '.join(reasons)}")
+        return True
+    
+    def announce_restored(self, recipe_id: int, dish: str) -> bool:
+        """Tell the team an 86'd item is back on; False if it wasn't announced as off"""
+        if self.eighty_sixed.pop(recipe_id, None) is None:
+            return False
+        event_id = self.record_event("item_restored", recipe_id=recipe_id, dish=dish)
+        for name in self.agents:
+            self._deliver(
+                Message("inventory", name, AgentRole.KITCHEN_PORTER, f"{dish} (recipe {recipe_id}) is back on"),
+                event_id
+            )
+        return True
+    
+    def hold_course(self, course: str, table: Optional[int] = None, author: str = "expo") -> Dict[str, Any]:
+        """Call a hold from the pass: portions of the course, at the table or every table, wait until it's fired
+
+        Cooks with portions held are told, and each is asked about a held
+        portion of theirs when it comes up. Raises ValueError for an unknown course.
+        """
+        return self._call_course("hold", course, table, author)
+    
+    def fire_course(self, course: str, table: Optional[int] = None, author: str = "expo") -> Dict[str, Any]:
+        """Fire a held course, at the table or every table, letting its portions cook
+
+        Raises ValueError for an unknown course.
+        """
+        return self._call_course("fire", course, table, author)
+    
+    def _call_course(self, call: str, course: str, table: Optional[int], author: str) -> Dict[str, Any]:
+        changed = self.holds.hold(course, table) if call == "hold" else self.holds.fire(course, table)
+        label = hold_label(course, table)
+        # Portions still to cook that the call took hold of, or let go
+        affected = [
+            item for item in (*self._queue, *self._deferred)
+            if item[1].function_name in ORDER_TASKS and course_of(item[2]) == course
+            and (table is None or item[2].get('table') == table)
+            and (self.holds.holding(item[2]) is not None) == (call == "hold")
+        ] if changed else []
+        task_ids = [context['task_id'] for _, _, context in affected]
+        result = {
+            "call": call,
+            "course": course,
+            "table": table,
+            "hold": label,
+            "changed": changed,
+            "task_ids": task_ids,
+            "holding": self.holds.active()
+        }
+        if not changed:
+            return result
+        
+        self.holds.calls.append({
+            "call": call, "course": course, "table": table, "author": author,
+            "simulated_time": round(self._simulated_clock(), 1), "task_ids": task_ids
+        })
+        event_id = self.record_event(
+            "course_held" if call == "hold" else "course_fired",
+            agent_name=author if author in self.agents else None,
+            course=course,
+            table=table,
+            hold=label,
+            author=author,
+            task_ids=task_ids,
+            holding=result["holding"]
+        )
+        for _, _, context in affected:
+            self.record_event(
+                "order_held" if call == "hold" else "order_fired",
+                task_id=context['task_id'],
+                caused_by=event_id,
+                hold=label
+            )
+            if call == "fire":
+                self._holding.discard(context['task_id'])
+        
+        # Calls from the pass carry the head chef's authority, whoever makes them
+        content = f"Hold {label}: don't fire them until the pass calls it" if call == "hold" else f"Fire {label}"
+        for name in sorted({agent_name for agent_name, _, _ in affected if agent_name in self.agents}):
+            role = self.agents[author].role if author in self.agents else AgentRole.HEAD_CHEF
+            self._deliver(Message(author, name, role, content, priority=1), event_id)
+        if call == "fire":
+            self._queue_changed.set()
+        logger.info(f"{call.capitalize()} {label} in run {self.run_id}: {len(task_ids)} portions")
+        return result
+    
+    def _held(self, task_type: TaskType, context: Dict[str, Any]) -> Optional[str]:
+        """The call holding an order portion, or None if it can fire"""
+        return self.holds.holding(context) if task_type.function_name in ORDER_TASKS else None
+    
+    async def _next_to_fire(self) -> Optional[Tuple[str, TaskType, Dict]]:
+        """Take the next queued task off the queue, passing over portions their cooks are holding
+
+        A cook is asked about a held portion of theirs once, when it comes up:
+        holding it keeps it off their station until its course is fired, and
+        firing it anyway is a hold violation. None if everything queued is held.
+        """
+        # Anything that frees a held run from here on wakes _wait_for_fire
+        self._queue_changed.clear()
+        for item in list(self._queue):
+            agent_name, task_type, context = item
+            hold = self._held(task_type, context)
+            if hold is not None and context['task_id'] in self._holding:
+                continue
+            agent = self.agents.get(agent_name)
+            if hold is not None and agent is not None:
+                question = (
+                    f"{context.get('dish') or task_type.function_name} ({context['task_id']}) is up next, "
+                    f"but the pass has called a hold on {hold}. "
+                    f"Answer HOLD to keep it back until it's fired, or FIRE to cook it now."
+                )
+                answer = await asyncio.to_thread(agent.answer_question, question)
+                if not fires_anyway(answer):
+                    self._holding.add(context['task_id'])
+                    self.record_event(
+                        "hold_respected",
+                        agent_name=agent_name,
+                        task_id=context['task_id'],
+                        caused_by=self._task_events.get(context['task_id']),
+                        hold=hold
+                    )
+                    continue
+                violation = HoldViolation(
+                    task_id=context['task_id'],
+                    agent_name=agent_name,
+                    course=course_of(context),
+                    table=context.get('table'),
+                    hold=hold,
+                    simulated_time=round(self._simulated_clock(), 1)
+                )
+                self.holds.violations.append(violation)
+                self.record_event(
+                    "hold_violated",
+                    agent_name=agent_name,
+                    task_id=context['task_id'],
+                    caused_by=self._task_events.get(context['task_id']),
+                    **{k: v for k, v in violation.to_dict().items() if k not in ("task_id", "agent_name")}
+                )
+                logger.warning(f"{agent_name} fired {context['task_id']} against the hold on {hold}")
+            self._queue.remove(item)
+            return item
+        return None
+    
+    def expect_orders(self, until: float):
+        """Keep the next or executing run open, with nothing queued, for orders due up to this many seconds in"""
+        self._orders_due_until = max(self._orders_due_until, until)
+    
+    def _expecting_orders(self) -> bool:
+        return self.elapsed_seconds < self._orders_due_until
+    
+    async def _wait_for_orders(self, end_time: float) -> bool:
+        """Wait with nothing queued until an order comes in or none are due any more; False at the time limit"""
+        self._queue_changed.clear()
+        due_in = self._orders_due_until - self.elapsed_seconds
+        try:
+            await asyncio.wait_for(self._queue_changed.wait(), max(0.0, min(end_time - time.time(), due_in)))
+        except asyncio.TimeoutError:
+            return time.time() < end_time
+        return True
+    
+    async def _wait_for_fire(self, end_time: float) -> bool:
+        """Wait while everything queued is held, until a course is fired or the queue changes; False at the time limit"""
+        try:
+            await asyncio.wait_for(self._queue_changed.wait(), max(0.0, end_time - time.time()))
+        except asyncio.TimeoutError:
+            return False
+        return True
+    
+    def _station_load(self) -> Dict[str, int]:
+        """Tasks on each station: queued for it or being worked there, less portions held off it"""
+        load = defaultdict(int)
+        for _, task_type, context in self._queue:
+            if context['task_id'] not in self._holding:
+                load[station_for(task_type)] += 1
+        if self.in_flight_task_id:
+            load[station_for(self._in_flight[1])] += 1
+        return load
+    
+    def _admit_deferred(self):
+        """Assign deferred tasks, oldest first, to the stations that have slots free again"""
+        if not self._deferred:
+            return
+        load = self._station_load()
+        admitted = []
+        for item in list(self._deferred):
+            _, task_type, context = item
+            station = station_for(task_type)
+            if load[station] >= self.station_capacity[station]:
+                continue
+            load[station] += 1
+            self._deferred.remove(item)
+            admitted.append((task_type, context))
+        if not admitted:
+            return
+        # They've been waiting since they were deferred, not since they were admitted
+        waiting_since = {c['task_id']: self._queued_at[c['task_id']] for _, c in admitted if c['task_id'] in self._queued_at}
+        self._enqueue(admitted)
+        self._queued_at.update(waiting_since)
+        for task_type, context in admitted:
+            self.record_event(
+                "task_admitted",
+                agent_name=self._station_keeper(),
+                task_id=context['task_id'],
+                caused_by=self._task_events.get(context['task_id']),
+                task_type=task_type.function_name,
+                station=station_for(task_type)
+            )
+    
+    def preview_assignment(self, task_type: TaskType, techniques: Tuple[str, ...] = ()) -> Optional[str]:
+        """Who the assignment policy would give a task to right now, without assigning it
+
+        techniques are the cooking techniques the dish takes; only agents whose
+        role supports all of them are considered.
+        """
+        scheduled = set(self.schedule.scheduled_agents(self.agents))
+        candidates = sorted(
+            [
+                (name, agent) for name, agent in self.agents.items()
+                if name in scheduled and name not in self.paused_agents
+                and self.permissions.can_perform(agent, task_type, techniques)
+            ],
+            key=lambda x: x[1].role.value,
+            reverse=True
+        )
+        if not candidates:
+            return None
+        assignments = defaultdict(list, {name: list(tasks) for name, tasks in self._assignments.items()})
+        return self.assignment_policy(task_type, candidates, assignments)
+    
+    def submit_orders(
+        self,
+        tasks: List[Tuple[TaskType, Dict]],
+        table: Optional[int] = None,
+        ticket: Optional[str] = None,
+        covers: Optional[int] = None
+    ) -> List[str]:
+        """Add orders to the executing run at the back of the queue, returning their task ids
+
+        The tasks make up one ticket, named after its first task, unless they're
+        added to an existing one. covers is the guests the ticket feeds: by
+        default those already on it, else the party seated at the table, else
+        one. With guests simulated, a table's guests may change or cancel them
+        later. Raises ValueError, changing nothing, if the table is no longer on
+        the floor.
+        """
+        floor_table = self.floor.tables.get(table) if table is not None else None
+        if table is not None and floor_table is None:
+            raise ValueError(f"Table {table} is no longer on the floor")
+        if covers is None:
+            on_ticket = self.tickets.get(ticket) if ticket else None
+            covers = (
+                on_ticket[0][1].get('covers') if on_ticket
+                else floor_table.party_size if floor_table is not None and floor_table.party_size
+                else 1
+            )
+        submitted = self._orders_submitted
+        self._orders_submitted += len(tasks)
+        placed_at = round(self.elapsed_seconds, 2)
+        for i, (_, context) in enumerate(tasks):
+            context['task_id'] = f"order-{submitted + i + 1}"
+            context['ticket'] = ticket or f"order-{submitted + 1}"
+            context.setdefault('line', i)
+            context['covers'] = covers
+            context['placed_at'] = placed_at
+            if table is not None:
+                context['table'] = table
+        task_ids = [context['task_id'] for _, context in tasks]
+        if task_ids:
+            self.tickets.setdefault(tasks[0][1]['ticket'], []).extend(tasks)
+        if floor_table is not None:
+            floor_table.task_ids.extend(task_ids)
+            self.floor.save()
+            for task_id in task_ids:
+                self.record_event("order_placed", task_id=task_id, table=table)
+        self._total_tasks += len(tasks)
+        if self.guests and floor_table is not None:
+            self.guest_requests.extend(self.guests.requests_for(
+                [context for _, context in tasks], self._total_tasks, len(self.execution_history)
+            ))
+        queued = self._enqueue(tasks)
+        self.record_event("orders_submitted", task_ids=task_ids, table=table, queued=queued)
+        # Portions of a course the pass is holding wait from the start
+        for task_type, context in tasks:
+            hold = self._held(task_type, context)
+            if hold:
+                self.record_event("order_held", task_id=context['task_id'], hold=hold)
+        # With an expeditor on the pass, the table's courses after the one going out wait for it to fire them
+        if self.expo and table is not None and task_ids:
+            current = self.expo.current_course(self.order_ticket(task_ids[0]))
+            later = COURSES[COURSES.index(current) + 1:] if current else ()
+            for course in sorted({course_of(context) for _, context in tasks} & set(later), key=COURSES.index):
+                self.hold_course(course, table, author=self.expo.name)
+        logger.info(f"Submitted {len(tasks)} orders to run {self.run_id}")
+        return task_ids
+    
+    def set_seed(self, seed: Optional[int]):
+        """Seed every agent deterministically from a single run seed"""
+        self.seed = seed
+        rng = random.Random(seed)
+        for name in sorted(self.agents):
+            self.agents[name].seed = rng.randrange(2**31) if seed is not None else None
+    
+    def set_rubric(self, rubric: QualityRubric):
+        """Grade task quality with this rubric, loading a judge model only if it needs one"""
+        self.rubric = rubric
+        judge_model = rubric.judge_model or DEFAULT_JUDGE_MODEL
+        if not rubric.uses_judge:
+            self.judge = None
+        elif self.judge is None or self.judge.model_name != judge_model:
+            self.judge = LLMJudge(judge_model, seed=self.seed)
+    
+    def set_prompts(self, overrides: Optional[Dict[str, str]] = None):
+        """Render every agent's prompts from the registry with these templates swapped in, recording the versions used"""
+        self.prompts = get_prompt_registry().with_overrides(overrides)
+        for agent in self.agents.values():
+            agent.prompts = self.prompts
+    
+    def enable_equipment(self, **options):
+        """Simulate equipment wear and breakdowns in the next scenario, seeded from the run seed"""
+        self.equipment = EquipmentSimulator(seed=self.seed, **options)
+    
+    def enable_guests(self, **options):
+        """Have guests change and cancel table orders in the next scenario, seeded from the run seed"""
+        self.guests = GuestSimulator(seed=self.seed, **options)
+    
+    def enable_pacing(self, **options):
+        """Plan the firing order of the next scenario's orders across stations, for the head chef to review"""
+        self.pacing = MealPlanner(**options)
+    
+    def enable_expeditor(self, **options):
+        """Put an expeditor on the pass in the next scenario, holding each table's later courses until it fires them"""
+        self.expo = Expeditor(**options)
+    
     def create_agent(
         self, 
         name: str, 
         role: AgentRole,
-        model_name: str = "cohere/command-r"
+        model_name: str = "cohere/command-r",
+        fallback_policy: str = "retry"
     ) -> LLMAgent:
         """Create and register an agent"""
         if name in self.agents:
             logger.warning(f"Agent {name} already exists, replacing")
         
-        agent = LLMAgent(name, role, model_name)
+        agent = LLMAgent(name, role, model_name, fallback_policy=fallback_policy)
+        agent.prompts = self.prompts
+        agent.memory = AgentMemory(self.memory_settings)
+        self.skill_store.attach(agent)
         self.agents[name] = agent
         logger.info(f"Created agent {name} with role {role.name} using {model_name}")
         return agent
     
+    def request_staff(
+        self,
+        role: AgentRole,
+        count: int = 1,
+        urgency: str = "normal",
+        reason: str = "",
+        requested_by: Optional[str] = None
+    ) -> StaffRequest:
+        """File a staffing request and try to fill it from the idle pool right away"""
+        request = self.hr.submit(role, count, urgency, reason, requested_by)
+        self.record_event(
+            "staff_requested",
+            agent_name=requested_by,
+            request_id=request.request_id,
+            role=role.name,
+            count=count,
+            urgency=urgency,
+            reason=reason
+        )
+        self.fulfill_staff_requests()
+        return request
+    
+    def fulfill_staff_requests(self) -> List[str]:
+        """Escalate stale requests, then move pooled agents onto the team"""
+        for request in self.hr.escalate():
+            self.record_event(
+                "staff_request_escalated",
+                request_id=request.request_id,
+                urgency=request.urgency,
+                escalations=request.escalations
+            )
+        
+        joined = []
+        for request, agent in self.hr.fulfill():
+            self.skill_store.attach(agent)
+            agent.prompts = self.prompts
+            self.agents[agent.name] = agent
+            joined.append(agent.name)
+            self.record_event(
+                "staff_assigned",
+                agent_name=agent.name,
+                request_id=request.request_id,
+                role=agent.role.name
+            )
+            logger.info(f"{agent.name} joined the team for request {request.request_id}")
+        return joined
+    
+    def release_staff(self, name: str) -> LLMAgent:
+        """Move an agent off the team into the idle pool"""
+        agent = self.agents.pop(name)
+        self.hr.add_to_pool(agent)
+        self.record_event("staff_released", agent_name=name, role=agent.role.name)
+        return agent
+    
     def create_agent_team(
         self,
         provider_model: str,
         team_size: int = 4,
-        roles: Optional[List[AgentRole]] = None
+        roles: Optional[List[AgentRole]] = None,
+        fallback_policy: str = "retry"
     ) -> List[LLMAgent]:
         """Create a team of agents using the same model"""
         if roles is None:
@@ -59,80 +732,906 @@ class MultiAgentCoordinator:
         team = []
         for i, role in enumerate(roles):
             name = f"{role.name}_{i+1}"
-            agent = self.create_agent(name, role, provider_model)
+            agent = self.create_agent(name, role, provider_model, fallback_policy)
             team.append(agent)
         
+        self.routing = None
+        return team
+    
+    def create_routed_team(
+        self,
+        routing: ModelRouting,
+        team_size: int = 4,
+        roles: Optional[List[AgentRole]] = None,
+        fallback_policy: str = "retry",
+        default_model: Optional[str] = None
+    ) -> List[LLMAgent]:
+        """Create a team with each role on the model routed to it, the rest on the default"""
+        if roles is None:
+            roles = [
+                AgentRole.HEAD_CHEF,
+                AgentRole.SOUS_CHEF,
+                AgentRole.LINE_COOK,
+                AgentRole.PREP_COOK
+            ][:team_size]
+        models = [routing.model_for(role, default_model) for role in roles]
+        
+        team = [
+            self.create_agent(f"{role.name}_{i+1}", role, model, fallback_policy)
+            for i, (role, model) in enumerate(zip(roles, models))
+        ]
+        self.routing = ModelRouting(dict(routing.roles), default_model or routing.default)
         return team
     
     def create_mixed_provider_team(
         self,
-        provider_models: List[Tuple[str, AgentRole]]
+        provider_models: List[Tuple[str, AgentRole]],
+        fallback_policies: Optional[List[str]] = None
     ) -> List[LLMAgent]:
         """Create team with different models for different roles"""
         team = []
         for i, (model, role) in enumerate(provider_models):
             name = f"{role.name}_{model.split('/')[-1][:8]}_{i+1}"
-            agent = self.create_agent(name, role, model)
+            policy = fallback_policies[i] if fallback_policies else "retry"
+            agent = self.create_agent(name, role, model, policy)
             team.append(agent)
+        self.routing = None
         return team
     
     async def execute_scenario(
         self,
         tasks: List[Tuple[TaskType, Dict[str, Any]]],
-        duration_seconds: int = 300
+        duration_seconds: int = 300,
+        run_id: Optional[str] = None
     ) -> Dict[str, Any]:
         """Execute a scenario with given tasks"""
         logger.info(f"Starting scenario with {len(tasks)} tasks, duration: {duration_seconds}s")
         
-        self.scenario_start_time = time.time()
-        self.scenario_end_time = self.scenario_start_time + duration_seconds
+        with (
+            log_context(run_id=run_id),
+            start_span("scenario.run", **{"chefbench.tasks": len(tasks), "chefbench.seed": self.seed})
+        ):
+            self._begin_scenario(run_id, duration_seconds, len(tasks))
+            self.record_event(
+                "scenario_started",
+                agents=sorted(self.agents),
+                total_tasks=len(tasks),
+                duration_seconds=duration_seconds,
+                seed=self.seed,
+                models=role_models(self.agents.values()),
+                roles={name: agent.role.name for name, agent in sorted(self.agents.items())},
+                equipment={i.name: i.kind for i in self.equipment.items.values()} if self.equipment else {}
+            )
+            self._attach_tables(tasks)
+            # Assign tasks to agents based on hierarchy
+            return await self._run_scenario(lambda: self._assign_tasks(tasks), duration_seconds, run_id)
+    
+    def model_routing(self) -> Dict[str, Any]:
+        """The model each role ran on, and the routing config the team was built from (None if it wasn't)"""
+        return {
+            "by_role": role_models(self.agents.values()),
+            "config": self.routing.to_dict() if self.routing else None
+        }
+    
+    def _attach_tables(self, tasks: List[Tuple[TaskType, Dict[str, Any]]]):
+        """Tie order tasks to the tables being served, longest seated first"""
+        orders = []
+        for index, (task_type, context) in enumerate(tasks):
+            if task_type.function_name in ORDER_TASKS:
+                context.setdefault('task_id', f"task-{context.get('task_number', index + 1)}")
+                orders.append(context)
+        placed = self.floor.attach_tasks([context['task_id'] for context in orders])
+        for context in orders:
+            if context['task_id'] in placed:
+                context['table'] = placed[context['task_id']]
+                self.record_event("order_placed", task_id=context['task_id'], table=context['table'])
+        if self.guests:
+            self.guest_requests = self.guests.requests_for([c for c in orders if 'table' in c], len(tasks))
+    
+    def _serve_guests(self):
+        """Have servers pass on guests' requests that have come in, to the cook holding the order"""
+        finished = len(self.execution_history)
+        for request in self.guest_requests:
+            if request.status != "pending" or request.arrives_after > finished:
+                continue
+            server = self.front_of_house.server_for(request.table)
+            if server is None:
+                request.status = "missed"
+                self.record_event(
+                    "guest_request_missed",
+                    task_id=request.task_id,
+                    table=request.table,
+                    kind=request.kind
+                )
+                continue
+            
+            request.server = server.name
+            queued = next(
+                (item for item in (*self._queue, *self._deferred) if item[2]['task_id'] == request.task_id), None
+            )
+            if queued is None:
+                # Already cooked, or its cook left the run
+                request.status = "too_late"
+                self.record_event(
+                    "guest_request_too_late",
+                    agent_name=server.name,
+                    task_id=request.task_id,
+                    table=request.table,
+                    kind=request.kind
+                )
+                continue
+            
+            agent_name, task_type, context = queued
+            request.status = "relayed"
+            server.relayed += 1
+            if agent_name is None:
+                # Deferred, so no cook has it yet; the change goes on the ticket itself
+                if request.kind == "cancellation":
+                    self._tombstone(queued, self._task_events.get(request.task_id), table=request.table)
+                else:
+                    context.setdefault('modifications', []).append(request.detail)
+                    self.record_event(
+                        "order_modified",
+                        agent_name=server.name,
+                        task_id=request.task_id,
+                        table=request.table,
+                        modification=request.detail
+                    )
+                continue
+            if request.kind == "cancellation":
+                content = f"Table {request.table} cancelled their {task_type.function_name} ({request.task_id})"
+            else:
+                content = f"Table {request.table} asks for {request.detail} on their {task_type.function_name} ({request.task_id})"
+            message = Message(
+                sender=server.name,
+                recipient=agent_name,
+                role=AgentRole.KITCHEN_PORTER,  # front of house has no authority in the kitchen
+                content=content,
+                task_type=task_type,
+                priority=1
+            )
+            relayed = self._deliver(message, self._task_events.get(request.task_id))
+            
+            if request.kind == "cancellation":
+                self._tombstone(queued, relayed, table=request.table)
+            else:
+                context.setdefault('modifications', []).append(request.detail)
+                self.record_event(
+                    "order_modified",
+                    agent_name=agent_name,
+                    task_id=request.task_id,
+                    caused_by=relayed,
+                    table=request.table,
+                    modification=request.detail
+                )
+    
+    def cancel_order(self, task_id: str, reason: str = "cancelled") -> Dict[str, Any]:
+        """Cancel a task in the running scenario
+
+        A queued task is dropped straight away. One being worked on is revoked at
+        its next safe point: before its model call, or as soon as the call returns,
+        discarding the result. Raises KeyError for a task the run doesn't have and
+        ValueError for one that has already finished.
+        """
+        if self._in_flight and self._in_flight[2]['task_id'] == task_id and task_id not in self._settled:
+            self._revoked[task_id] = reason
+            self._cancelled.add(task_id)
+            self.record_event(
+                "task_revocation_requested",
+                agent_name=self._in_flight[0],
+                task_id=task_id,
+                reason=reason
+            )
+            return {"task_id": task_id, "status": "revoking", "agent_name": self._in_flight[0]}
         
-        # Assign tasks to agents based on hierarchy
-        task_assignments = self._assign_tasks(tasks)
+        queued = next((item for item in (*self._queue, *self._deferred) if item[2]['task_id'] == task_id), None)
+        if queued:
+            self._tombstone(queued, None, reason=reason)
+            return {"task_id": task_id, "status": "cancelled", "agent_name": queued[0]}
+        if task_id in self._settled:
+            raise ValueError(f"Task {task_id} has already finished")
+        raise KeyError(f"Unknown task {task_id}")
+    
+    def annotate_order(
+        self,
+        task_id: str,
+        author: str,
+        reason: str,
+        severity: str = "warning",
+        source: Optional[str] = None
+    ) -> Dict[str, Any]:
+        """Attach a note to an order of the run, e.g. an agent flagging a problem with it
+
+        Notes count against the run's quality by severity. source defaults to
+        "agent" for a member of the team and "human" for anyone else. Raises
+        KeyError for a task the run doesn't have and ValueError for an unknown severity.
+        """
+        if severity not in NOTE_SEVERITIES:
+            raise ValueError(f"Unknown severity '{severity}', expected one of {list(NOTE_SEVERITIES)}")
+        if task_id not in self._task_events:
+            raise KeyError(f"Unknown task {task_id}")
+        note = {
+            "task_id": task_id,
+            "author": author,
+            "source": source or ("agent" if author in self.agents else "human"),
+            "severity": severity,
+            "reason": reason,
+            "simulated_time": round(self._simulated_clock(), 1)
+        }
+        self.order_notes.append(note)
+        self.record_event(
+            "order_noted",
+            agent_name=author,
+            task_id=task_id,
+            caused_by=self._task_events.get(task_id),
+            **{k: v for k, v in note.items() if k not in ("task_id", "author")}
+        )
+        return note
+    
+    def order_ticket(self, task_id: str) -> Dict[str, Any]:
+        """The ticket a submitted order task belongs to, line by line with each portion's state
+
+        Raises KeyError for a task that wasn't submitted as an order this run.
+        """
+        ticket = next(
+            (name for name, tasks in self.tickets.items() if any(c['task_id'] == task_id for _, c in tasks)),
+            None
+        )
+        if ticket is None:
+            raise KeyError(f"Unknown order {task_id}")
+        tasks = self.tickets[ticket]
+        lines: Dict[int, Dict[str, Any]] = {}
+        for task_type, context in tasks:
+            line = lines.setdefault(context['line'], {
+                "line": context['line'],
+                "task_type": task_type.function_name,
+                "recipe_id": context.get('recipe_id'),
+                "dish": context.get('dish'),
+                "course": course_of(context),
+                "ingredients": list(context.get('ingredients', [])),
+                "quantity": 0,
+                "portions": []
+            })
+            state = self._order_state(context['task_id'])
+            if state == "queued" and self._held(task_type, context):
+                state = "held"
+            line["quantity"] += state != "cancelled"
+            line["portions"].append({"task_id": context['task_id'], "state": state})
+        first = tasks[0][1]
+        return {
+            "ticket": ticket,
+            "table": first.get('table'),
+            "time_limit": first.get('time_limit'),
+            "dietary_restrictions": list(first.get('dietary_restrictions', [])),
+            "complexity": first.get('complexity'),
+            "lines": [lines[line] for line in sorted(lines)]
+        }
+    
+    def ticket_ledger(self) -> List[Dict[str, Any]]:
+        """Every ticket of the run: when it was placed, the guests it feeds and how each portion turned out
+
+        A portion is served once it has been cooked successfully. Turn time runs
+        from the ticket's first portion being queued to its last being done, in
+        simulated seconds, and is None while any is still to cook.
+        """
+        outcomes = {o["task_id"]: o["success"] for o in self._order_completions}
+        ledger = []
+        for name, tasks in self.tickets.items():
+            first = tasks[0][1]
+            live = [context for _, context in tasks if context['task_id'] not in self._cancelled]
+            done = [
+                self._queued_at[c['task_id']] + self._ticket_seconds[c['task_id']]
+                for c in live if c['task_id'] in self._ticket_seconds and c['task_id'] in self._queued_at
+            ]
+            queued = [self._queued_at[c['task_id']] for c in live if c['task_id'] in self._queued_at]
+            ledger.append({
+                "ticket": name,
+                "table": first.get('table'),
+                "covers": first.get('covers', 1),
+                "placed_at": min(c.get('placed_at', 0.0) for _, c in tasks),
+                "turn_seconds": round(max(done) - min(queued), 1) if live and len(done) == len(live) and queued else None,
+                "portions": [
+                    {
+                        "task_id": context['task_id'],
+                        "recipe_id": context.get('recipe_id'),
+                        "ingredients": list(context.get('ingredients', [])),
+                        "served": bool(outcomes.get(context['task_id'])) and context['task_id'] not in self._cancelled
+                    }
+                    for _, context in tasks
+                ]
+            })
+        return ledger
+    
+    def modify_order(
+        self,
+        task_id: str,
+        add: Optional[List[Tuple[TaskType, Dict]]] = None,
+        reduce: Optional[Dict[int, int]] = None,
+        void: Optional[List[str]] = None,
+        note: Optional[str] = None,
+        priority: Optional[str] = None,
+        reason: str = "voided",
+        author: Optional[str] = None
+    ) -> Dict[str, Any]:
+        """Change an order of the running scenario through the same paths that submit and cancel orders
+
+        add is tasks already planned for the ticket, each context naming the
+        line it joins; reduce takes that many portions off a line, the latest
+        still to cook first; void cancels portions by task id. A note goes to
+        every portion still queued, like a guest's change, and priority
+        expedites or reassigns the queued ones as escalation would. Everything
+        is checked before anything changes, and each change is recorded as an
+        order_modified event. The ticket's complexity is then scored again over
+        what it still holds. Raises KeyError for an unknown order and ValueError
+        for a change it can't take.
+        """
+        ticket = self.order_ticket(task_id)
+        name = ticket["ticket"]
+        on_ticket = {portion["task_id"]: portion["state"] for line in ticket["lines"] for portion in line["portions"]}
+        open_states = ("queued", "held", "in_progress")
         
-        # Process tasks with message passing
-        results = await self._process_with_messages(task_assignments, duration_seconds)
+        for voided in void or []:
+            if voided not in on_ticket:
+                raise ValueError(f"{voided} is not on ticket {name}")
+            if on_ticket[voided] not in open_states:
+                raise ValueError(f"{voided} is already {on_ticket[voided]}")
+        cuts: List[str] = []
+        for line, count in (reduce or {}).items():
+            entry = next((l for l in ticket["lines"] if l["line"] == line), None)
+            if entry is None:
+                raise ValueError(f"Ticket {name} has no line {line}")
+            cuttable = [
+                p["task_id"] for p in reversed(entry["portions"])
+                if p["state"] in open_states and p["task_id"] not in (void or [])
+            ]
+            # Portions not yet started go before one that's on the pass
+            cuttable.sort(key=lambda t: on_ticket[t] == "in_progress")
+            if count > len(cuttable):
+                raise ValueError(f"Only {len(cuttable)} portions of line {line} are still to cook")
+            cuts.extend(cuttable[:count])
+        if priority is not None and priority not in ORDER_PRIORITIES:
+            raise ValueError(f"Unknown priority '{priority}', expected one of {list(ORDER_PRIORITIES)}")
+        if add and ticket["table"] is not None and ticket["table"] not in self.floor.tables:
+            raise ValueError(f"Table {ticket['table']} is no longer on the floor")
+        
+        author = author or "api"
+        changes: List[Dict[str, Any]] = []
+        
+        def modified(changed_id: str, change: str, agent_name: Optional[str] = None, **details: Any):
+            changes.append({"task_id": changed_id, "change": change, **details})
+            self.record_event(
+                "order_modified",
+                agent_name=agent_name,
+                task_id=changed_id,
+                caused_by=self._task_events.get(changed_id),
+                ticket=name,
+                change=change,
+                author=author,
+                **details
+            )
+        
+        for voided in void or []:
+            result = self.cancel_order(voided, reason)
+            modified(voided, "item_voided", result["agent_name"], reason=reason, status=result["status"])
+        for cut in cuts:
+            result = self.cancel_order(cut, "quantity_reduced")
+            modified(cut, "quantity_reduced", result["agent_name"], status=result["status"])
+        
+        if add:
+            self.submit_orders(add, ticket["table"], ticket=name)
+            for _, context in add:
+                modified(context['task_id'], "item_added", line=context['line'])
+        
+        queued = [item for item in self._queue if item[2].get('ticket') == name]
+        if note:
+            deferred = [item for item in self._deferred if item[2].get('ticket') == name]
+            for agent_name, _, context in queued + deferred:
+                context.setdefault('modifications', []).append(note)
+                modified(context['task_id'], "note", agent_name, modification=note)
+        
+        if priority is not None:
+            level = next((lvl for lvl, label in LEVEL_NAMES.items() if label == priority), 0)
+            moved = []
+            for item in queued:
+                agent_name, task_type, context = item
+                reassigned_to = self._reassign(item) if level == REASSIGN else None
+                if level:
+                    self._queue.remove(item)
+                    moved.append((reassigned_to or agent_name, task_type, context))
+                    # Escalation won't take it back down to a lower level
+                    self.escalation.levels[context['task_id']] = max(
+                        level, self.escalation.levels.get(context['task_id'], 0)
+                    )
+                modified(
+                    context['task_id'], "priority", reassigned_to or agent_name,
+                    priority=priority, **({"previous_agent": agent_name} if reassigned_to else {})
+                )
+            self._queue.extendleft(reversed(moved))
+        
+        # Re-score what the ticket still holds, so estimates for its queued portions follow the change
+        remaining = [(t, c) for t, c in self.tickets[name] if c['task_id'] not in self._cancelled]
+        complexity = score_order(remaining).to_dict() if remaining else None
+        for _, context in remaining:
+            if context['task_id'] not in self._settled:
+                context['complexity'] = complexity
+        self.record_event("order_replanned", ticket=name, changes=len(changes), complexity=complexity)
+        logger.info(f"Modified ticket {name} in run {self.run_id}: {len(changes)} changes")
+        return {**self.order_ticket(name), "changes": changes}
+    
+    def _order_state(self, task_id: str) -> str:
+        if task_id in self._cancelled:
+            return "cancelled"
+        if task_id in self._settled:
+            return "finished"
+        if self.in_flight_task_id == task_id:
+            return "in_progress"
+        return "queued"
+    
+    def station_status(self) -> List[Dict[str, Any]]:
+        """Every station's staff, equipment and the orders of the executing run waiting on or being worked there
+
+        Staff are the scheduled, unpaused agents in the role that runs the station.
+        """
+        orders: Dict[str, List[Dict[str, Any]]] = {station: [] for station in STATION_EQUIPMENT}
+        if self.running:
+            clock = self._simulated_clock()
+            active = [(item, "held" if self._held(item[1], item[2]) else "queued") for item in self._queue]
+            if self.in_flight_task_id:
+                active.insert(0, (self._in_flight, "in_progress"))
+            active.extend((item, "deferred") for item in self._deferred)
+            for (agent_name, task_type, context), state in active:
+                queued_at = self._queued_at.get(context['task_id'])
+                orders[station_for(task_type)].append({
+                    "task_id": context['task_id'],
+                    "task_type": task_type.function_name,
+                    "agent_name": agent_name,
+                    "state": state,
+                    "table": context.get('table'),
+                    "waited_seconds": round(clock - queued_at, 1) if queued_at is not None else None
+                })
+        
+        scheduled = set(self.schedule.scheduled_agents(self.agents))
+        return [
+            station_report(
+                station,
+                sorted(
+                    name for name, agent in self.agents.items()
+                    if agent.role == STATION_ROLES[station] and name in scheduled and name not in self.paused_agents
+                ),
+                [o for o in orders[station] if o["state"] != "deferred"],
+                self.equipment,
+                capacity=self.station_capacity[station],
+                deferred=sum(1 for o in orders[station] if o["state"] == "deferred")
+            )
+            for station in STATION_EQUIPMENT
+        ]
+    
+    def _tombstone(self, item: Tuple[str, TaskType, Dict], caused_by: Optional[int], **details: Any):
+        """Drop a queued order: it's never cooked, and no longer counted against the kitchen"""
+        agent_name, _, context = item
+        (self._deferred if item in self._deferred else self._queue).remove(item)
+        self._settled.add(context['task_id'])
+        self._cancelled.add(context['task_id'])
+        self._holding.discard(context['task_id'])
+        self._total_tasks -= 1
+        self._queue_changed.set()
+        self.record_event(
+            "order_cancelled",
+            agent_name=agent_name,
+            task_id=context['task_id'],
+            caused_by=caused_by,
+            **details
+        )
+    
+    def _hold(self, task_type: TaskType, context: Dict[str, Any]):
+        """Reserve the ingredients and equipment a task is about to use"""
+        self.held_ingredients[context['task_id']] = list(context.get('ingredients', []))
+        if self.equipment:
+            self.equipment.claim(context['task_id'], task_type, context.get('techniques', ()))
+    
+    def _release(self, task_id: str) -> Dict[str, List[str]]:
+        return {
+            "ingredients": self.held_ingredients.pop(task_id, []),
+            "equipment": self.equipment.release(task_id) if self.equipment else []
+        }
+    
+    def _revoke_if_cancelled(
+        self,
+        agent: LLMAgent,
+        context: Dict[str, Any],
+        stage: str,
+        execution: Optional[TaskExecution] = None
+    ) -> bool:
+        """At a safe point, abandon the in-flight task if it was cancelled, freeing what it held"""
+        task_id = context['task_id']
+        reason = self._revoked.pop(task_id, None)
+        if reason is None:
+            return False
+        if execution is not None:
+            agent.discard_execution(execution)
+        self._settled.add(task_id)
+        self._total_tasks -= 1
+        revoked = self.record_event(
+            "task_revoked",
+            agent_name=agent.name,
+            task_id=task_id,
+            caused_by=self._task_events.get(task_id),
+            reason=reason,
+            stage=stage
+        )
+        self.record_event(
+            "resources_released",
+            agent_name=agent.name,
+            task_id=task_id,
+            caused_by=revoked,
+            **self._release(task_id)
+        )
+        self._checkpoint()
+        return True
+    
+    def _report_delay(
+        self,
+        agent: LLMAgent,
+        task_type: TaskType,
+        context: Dict[str, Any],
+        reason: str,
+        caused_by: Optional[int]
+    ):
+        """Have the cook tell the table's server an order is late, so the server can tell the guests"""
+        server = self.front_of_house.server_for(context['table'])
+        self.delays.append({
+            "task_id": context['task_id'],
+            "table": context['table'],
+            "reason": reason,
+            "server": server.name if server else None
+        })
+        if server is None:
+            self.record_event(
+                "delay_unreported",
+                agent_name=agent.name,
+                task_id=context['task_id'],
+                table=context['table'],
+                reason=reason
+            )
+            return
+        
+        message = agent.send_message(server.name, f"Table {context['table']}'s {task_type.function_name} is running late: {reason}")
+        reported = self._deliver(message, caused_by)
+        server.delays_reported += 1
+        self.record_event(
+            "delay_communicated",
+            agent_name=server.name,
+            task_id=context['task_id'],
+            caused_by=reported,
+            table=context['table'],
+            reason=reason
+        )
+    
+    async def resume_scenario(
+        self,
+        checkpoint: Dict[str, Any],
+        run_id: Optional[str] = None
+    ) -> Dict[str, Any]:
+        """Continue a checkpointed scenario with its unfinished tasks and the time it had left"""
+        run_id = run_id or checkpoint["run_id"]
+        # Rebuilding the team may load models, so keep it off the event loop
+        assignments = await asyncio.to_thread(self.restore_checkpoint, checkpoint)
+        elapsed = checkpoint["elapsed_seconds"]
+        remaining = max(0.0, checkpoint["duration_seconds"] - elapsed)
+        pending = sum(len(tasks) for tasks in assignments.values())
+        logger.info(f"Resuming scenario {run_id} with {pending} pending tasks, {remaining:.0f}s left")
+        
+        with (
+            log_context(run_id=run_id),
+            start_span("scenario.resume", **{"chefbench.tasks": pending, "chefbench.seed": self.seed})
+        ):
+            self._begin_scenario(run_id, checkpoint["duration_seconds"], checkpoint["total_tasks"], elapsed)
+            if checkpoint.get("paused"):
+                self._unpaused.clear()
+            self.record_event(
+                "scenario_resumed",
+                agents=sorted(self.agents),
+                pending_tasks=pending,
+                elapsed_seconds=elapsed,
+                checkpointed_at=checkpoint.get("checkpointed_at"),
+                paused=self.paused
+            )
+            return await self._run_scenario(lambda: assignments, remaining, run_id)
+    
+    def _begin_scenario(self, run_id: Optional[str], duration_seconds: float, total_tasks: int, elapsed: float = 0.0):
+        self.run_id = run_id
+        self.scenario_duration = duration_seconds
+        self.scenario_start_time = time.time() - elapsed
+        self.scenario_end_time = self.scenario_start_time + duration_seconds
+        self._total_tasks = total_tasks
+        self._settled = set()
+        self._paused_seconds = 0.0
+        self._unpaused.set()
+    
+    async def _run_scenario(
+        self,
+        assign: Callable[[], Dict[str, List[Tuple[TaskType, Dict]]]],
+        duration_seconds: float,
+        run_id: Optional[str]
+    ) -> Dict[str, Any]:
+        try:
+            task_assignments = assign()
+            self._assignments = task_assignments
+            
+            # Process tasks with message passing
+            results = await self._process_with_messages(task_assignments, duration_seconds)
+        except Exception as e:
+            self.record_event("scenario_failed", error=str(e))
+            raise
         
         # Collect metrics
         metrics = self._collect_scenario_metrics()
+        self.record_event(
+            "scenario_completed",
+            tasks_completed=len([e for e in self.execution_history if e.success]),
+            overall_success_rate=metrics["team"]["overall_success_rate"]
+        )
         
         return {
-            "duration": time.time() - self.scenario_start_time,
+            "duration": time.time() - self.scenario_start_time - self._paused_seconds,
             "tasks_completed": len([e for e in self.execution_history if e.success]),
-            "total_tasks": len(tasks),
+            "total_tasks": self._total_tasks,
             "agent_metrics": metrics,
             "execution_history": [e.to_dict() for e in self.execution_history],
-            "message_count": len(self.message_bus)
+            "message_count": len(self.message_bus),
+            "assignment_policy": self.assignment_policy_name,
+            "seed": self.seed,
+            "quality_rubric": self.rubric.name,
+            "judge": self.judge.to_dict() if self.judge else None,
+            "prompts": self.prompts.manifest(),
+            "usage": get_usage_tracker().for_run(run_id) if run_id else None,
+            "budget": get_usage_tracker().budget_status(run_id) if run_id else None,
+            "model_routing": self.model_routing()
+        }
+    
+    def checkpoint_state(self) -> Dict[str, Any]:
+        """Everything needed to pick the current run back up: team, memories and unfinished tasks"""
+        elapsed = self.elapsed_seconds
+        return {
+            "run_id": self.run_id,
+            "seed": self.seed,
+            "assignment_policy": self.assignment_policy_name,
+            "quality_rubric": self.rubric.to_dict(),
+            "prompt_overrides": self.prompts.manifest()["overrides"],
+            "duration_seconds": self.scenario_duration,
+            "elapsed_seconds": elapsed,
+            "total_tasks": self._total_tasks,
+            "orders_submitted": self._orders_submitted,
+            "paused": self.paused,
+            "agents": [
+                {
+                    "name": agent.name,
+                    "role": agent.role.name,
+                    "model_name": agent.model_name,
+                    "fallback_policy": agent.fallback_policy,
+                    "seed": agent.seed,
+                    "authority_compliance": agent.authority_compliance,
+                    "collaboration_score": agent.collaboration_score,
+                    "skills": agent.skills.to_dict()
+                }
+                for agent in self.agents.values()
+            ],
+            "pending": {
+                agent_name: [
+                    {"task_type": task_type.function_name, "context": context}
+                    for task_type, context in tasks
+                    if context['task_id'] not in self._settled
+                ]
+                for agent_name, tasks in self._assignments.items()
+            },
+            "deferred": [
+                {"task_type": task_type.function_name, "context": context}
+                for _, task_type, context in self._deferred
+            ],
+            "holds": self.holds.to_dict(),
+            "expo": self.expo.to_dict() if self.expo else None,
+            "execution_history": [e.to_dict() for e in self.execution_history],
+            "messages": [m.to_dict() for m in self.message_bus],
+            "paused_agents": list(self.paused_agents),
+            "order_notes": list(self.order_notes),
+            "task_errors": [e.to_dict() for e in self.error_budget.errors],
+            "checkpointed_at": time.time()
         }
     
+    def restore_checkpoint(self, checkpoint: Dict[str, Any]) -> Dict[str, List[Tuple[TaskType, Dict]]]:
+        """Rebuild the team and its memories from a checkpoint, returning the unfinished assignments
+        
+        Agents already on the team with the same model are reused rather than reloaded.
+        Equipment and guest simulation are not checkpointed and start fresh.
+        """
+        self.reset()
+        self.seed = checkpoint.get("seed")
+        self.set_assignment_policy(checkpoint.get("assignment_policy", self.assignment_policy_name))
+        if checkpoint.get("quality_rubric"):
+            self.set_rubric(QualityRubric.from_dict(checkpoint["quality_rubric"]))
+        self.set_prompts(checkpoint.get("prompt_overrides"))
+        
+        for spec in checkpoint["agents"]:
+            agent = self.agents.get(spec["name"])
+            if agent is None or agent.model_name != spec["model_name"] or agent.role.name != spec["role"]:
+                agent = self.create_agent(spec["name"], AgentRole[spec["role"]], spec["model_name"], spec["fallback_policy"])
+            agent.fallback_policy = spec["fallback_policy"]
+            agent.seed = spec.get("seed")
+            agent.authority_compliance = spec.get("authority_compliance", 1.0)
+            agent.collaboration_score = spec.get("collaboration_score", 0.0)
+            if spec.get("skills"):
+                agent.skills = SkillProfile.from_dict(spec["skills"])
+        
+        self.execution_history = [TaskExecution.from_dict(e) for e in checkpoint.get("execution_history", [])]
+        for execution in self.execution_history:
+            if execution.agent_name in self.agents:
+                self.agents[execution.agent_name].record_execution(execution)
+        
+        for message in (Message.from_dict(m) for m in checkpoint.get("messages", [])):
+            self.message_bus.append(message)
+            if message.sender in self.agents:
+                self.agents[message.sender].sent_messages.append(message)
+            if message.recipient in self.agents:
+                self.agents[message.recipient].received_messages.append(message)
+        
+        self.paused_agents = list(checkpoint.get("paused_agents", []))
+        self.order_notes = list(checkpoint.get("order_notes", []))
+        self.error_budget.errors = [TaskError(**e) for e in checkpoint.get("task_errors", [])]
+        pending = {
+            agent_name: [(TaskType.from_function_name(t["task_type"]), t["context"]) for t in tasks]
+            for agent_name, tasks in checkpoint.get("pending", {}).items()
+            if tasks and agent_name in self.agents
+        }
+        self._deferred = deque(
+            (None, TaskType.from_function_name(t["task_type"]), t["context"]) for t in checkpoint.get("deferred", [])
+        )
+        # Standing calls carry over; cooks are asked about their held portions again as they come up
+        self.holds = CourseHolds.from_dict(checkpoint.get("holds", {}))
+        # So does the expeditor, with the portions waiting on its window
+        if checkpoint.get("expo"):
+            self.expo = Expeditor.from_dict(checkpoint["expo"])
+        # Only a ticket's unfinished tasks are checkpointed, so only they can be modified after a resume
+        for tasks in [*pending.values(), [(t, c) for _, t, c in self._deferred]]:
+            for task_type, context in tasks:
+                if context.get('ticket'):
+                    self.tickets.setdefault(context['ticket'], []).append((task_type, context))
+        # Checkpoints from before the counter was kept number on from the highest pending order
+        self._orders_submitted = checkpoint.get("orders_submitted", max(
+            (
+                int(context['task_id'][len("order-"):])
+                for tasks in pending.values() for _, context in tasks
+                if context['task_id'].startswith("order-") and context['task_id'][len("order-"):].isdigit()
+            ),
+            default=0
+        ))
+        return pending
+    
+    def _check_budget(self, caused_by: Optional[int]):
+        """Warn as the run nears its token or cost budget, and pause it before its next task once spent"""
+        status = get_usage_tracker().budget_status(self.run_id) if self.run_id else None
+        state = status["state"] if status else BUDGET_OK
+        previous, self._budget_state = self._budget_state, state
+        # Only a worsening state is reported; a raised limit starts over
+        if BUDGET_STATES.index(state) <= BUDGET_STATES.index(previous):
+            return
+        if state == BUDGET_WARNING:
+            self.record_event("budget_warning", caused_by=caused_by, **status)
+            logger.warning(f"Run {self.run_id} has used {status['used']:.0%} of its budget")
+        elif state == BUDGET_EXCEEDED:
+            self.record_event("budget_exceeded", caused_by=caused_by, **status)
+            logger.warning(f"Run {self.run_id} is over its budget ({status['tokens']} tokens, ${status['cost']:.4f})")
+            if self._queue:
+                self.pause(reason="budget_exceeded")
+    
+    def _checkpoint(self):
+        """Hand the current state to the checkpoint sink; a failed checkpoint never stops the run"""
+        if self.checkpoint_sink is None:
+            return
+        try:
+            self.checkpoint_sink(self.checkpoint_state())
+        except Exception as e:
+            logger.error(f"Failed to checkpoint run {self.run_id}: {e}")
+    
+    async def _wait_while_paused(self) -> float:
+        """Block while the run is paused, returning how long it waited"""
+        if not self.paused:
+            return 0.0
+        self._checkpoint()
+        started = time.time()
+        await self._unpaused.wait()
+        waited = time.time() - started
+        self._paused_seconds += waited
+        self.scenario_end_time += waited
+        return waited
+    
     def _assign_tasks(
         self, 
         tasks: List[Tuple[TaskType, Dict[str, Any]]]
     ) -> Dict[str, List[Tuple[TaskType, Dict]]]:
-        """Assign tasks to agents based on role hierarchy"""
+        """Assign tasks to agents based on role hierarchy
+
+        A task whose station is at capacity is deferred instead, and assigned
+        once a slot frees up.
+        """
         assignments = defaultdict(list)
+        load = self._station_load()
         
-        # Sort agents by role level
+        # Sort agents by role level, leaving out anyone without a shift
+        on_shift = set(self.schedule.scheduled_agents(self.agents))
         sorted_agents = sorted(
-            self.agents.items(), 
+            [(name, agent) for name, agent in self.agents.items() if name in on_shift],
             key=lambda x: x[1].role.value, 
             reverse=True
         )
         
-        for task_type, context in tasks:
-            # Find suitable agents
-            suitable_agents = [
-                name for name, agent in sorted_agents
-                if task_type in agent.available_tasks
+        for index, (task_type, context) in enumerate(tasks):
+            context.setdefault('task_id', f"task-{context.get('task_number', index + 1)}")
+            
+            station = station_for(task_type)
+            if load[station] >= self.station_capacity[station]:
+                self._deferred.append((None, task_type, context))
+                self._deferrals[station] += 1
+                self._queued_at.setdefault(context['task_id'], self._simulated_clock())
+                self.record_event(
+                    "task_deferred",
+                    agent_name=self._station_keeper(),
+                    task_id=context['task_id'],
+                    task_type=task_type.function_name,
+                    station=station,
+                    load=load[station],
+                    capacity=self.station_capacity[station]
+                )
+                continue
+            
+            # Find suitable agents, whose role supports the dish's cooking techniques too
+            techniques = context.get('techniques', ())
+            candidates = [
+                (name, agent) for name, agent in sorted_agents
+                if self.permissions.can_perform(agent, task_type, techniques)
             ]
             
-            if suitable_agents:
-                # Assign to most appropriate agent (highest rank that can do it)
-                assigned_to = suitable_agents[0]
+            # Ask HR for someone qualified before giving up on the task
+            if not candidates and self.hr.pool:
+                role = AgentRole(max([task_type.min_role_level] + [TECHNIQUES[t].min_role_level for t in techniques]))
+                if any(r.role == role.name for r in self.hr.open_requests()):
+                    joined = self.fulfill_staff_requests()
+                else:
+                    joined = self.request_staff(
+                        role,
+                        urgency="high",
+                        reason=f"No agent can perform {task_type.function_name}",
+                        requested_by="coordinator"
+                    ).assigned
+                on_shift.update(joined)
+                sorted_agents = sorted(
+                    [(name, agent) for name, agent in self.agents.items() if name in on_shift],
+                    key=lambda x: x[1].role.value,
+                    reverse=True
+                )
+                candidates = [
+                    (name, agent) for name, agent in sorted_agents
+                    if self.permissions.can_perform(agent, task_type, techniques)
+                ]
+            
+            if candidates:
+                assigned_to = self.assignment_policy(task_type, candidates, assignments)
                 
                 # Add other suitable agents to context for collaboration
-                context['other_agents'] = suitable_agents[1:]
+                context['other_agents'] = [name for name, _ in candidates if name != assigned_to]
+                # Orders accepted without a score, as a scenario's own are, are scored on their own
+                if task_type.function_name in ORDER_TASKS and not context.get('complexity'):
+                    context['complexity'] = score_order([(task_type, context)]).to_dict()
                 assignments[assigned_to].append((task_type, context))
+                load[station] += 1
+                self._task_events[context['task_id']] = self.record_event(
+                    "task_assigned",
+                    agent_name=assigned_to,
+                    task_id=context['task_id'],
+                    task_type=task_type.function_name,
+                    policy=self.assignment_policy_name,
+                    ingredients=list(context.get('ingredients', [])),
+                    complexity=context.get('complexity')
+                )
             else:
                 logger.warning(f"No suitable agent for task {task_type.function_name}")
         
@@ -147,48 +1646,280 @@ class MultiAgentCoordinator:
         results = []
         end_time = time.time() + duration_seconds
         
-        # Head chef announces tasks
+        # Senior chefs hand tasks down to the stations, which accept or reject them
         head_chef = self._get_head_chef()
-        if head_chef:
-            for agent_name, tasks in task_assignments.items():
-                for task_type, _ in tasks:
-                    message = head_chef.send_message(
-                        agent_name,
-                        f"Please execute {task_type.function_name}",
-                        task_type
-                    )
-                    self.message_bus.append(message)
-                    self.agents[agent_name].receive_message(message)
+        delegated = [
+            (agent_name, task_type, context)
+            for agent_name, tasks in task_assignments.items()
+            for task_type, context in tasks
+        ]
+        for agent_name, task_type, context in delegated:
+            delegator = self._delegator_for(self.agents[agent_name])
+            if delegator is None:
+                continue
+            violation = self.permissions.check_delegation(
+                delegator, self.agents[agent_name], task_type, context.get('techniques', ())
+            )
+            if self._deny(violation, context['task_id']):
+                continue
+            if self.handoff.settings.enabled and delegator.name != agent_name:
+                self._hand_off(delegator, agent_name, task_type, context, task_assignments)
+                continue
+            message = delegator.send_message(
+                agent_name,
+                f"Please execute {task_type.function_name}",
+                task_type
+            )
+            self._deliver(message, self._task_events.get(context['task_id']))
         
-        # Process tasks and messages
-        for agent_name, tasks in task_assignments.items():
-            agent = self.agents[agent_name]
-            
-            for task_type, context in tasks:
-                if time.time() > end_time:
-                    logger.info("Time limit reached")
+        # Process tasks and messages; chaos can add to or reassign the queue mid-run
+        self._queue = deque(
+            (agent_name, task_type, context)
+            for agent_name, tasks in task_assignments.items()
+            for task_type, context in tasks
+        )
+        if self.pacing:
+            await self._plan_pacing(head_chef)
+        clock = self._simulated_clock()
+        for _, _, context in self._queue:
+            self._queued_at.setdefault(context['task_id'], clock)
+        while self._queue or self._deferred or self._expecting_orders():
+            self._admit_deferred()
+            # Guests' changes reach the kitchen between tasks, and may cancel what's queued
+            self._serve_guests()
+            if self.expo:
+                self._work_the_pass()
+            if not self._queue:
+                if self._deferred or not self._expecting_orders():
+                    # Everything queued was cancelled; deferred tasks, if any, take the slots next
+                    continue
+                # Customers are still due, so the line waits for the next order
+                if not await self._wait_for_orders(end_time):
+                    logger.info("Time limit reached waiting for orders")
                     break
-                
+                continue
+            self._escalate()
+            item = await self._next_to_fire()
+            if item is None:
+                # Every queued portion is held, so nothing cooks until the pass fires a course
+                if not await self._wait_for_fire(end_time):
+                    logger.info("Time limit reached with courses held")
+                    break
+                continue
+            agent_name, task_type, context = item
+            self._in_flight = (agent_name, task_type, context)
+            # Time spent paused doesn't count against the scenario
+            end_time += await self._wait_while_paused()
+            if time.time() > end_time:
+                logger.info("Time limit reached")
+                break
+            
+            # The roster can change through the API while inference runs
+            agent = self.agents.get(agent_name)
+            if agent is None:
+                logger.warning(f"{agent_name} left the team, skipping {context['task_id']}")
+                self._settled.add(context['task_id'])
+                continue
+            
+            with (
+                log_context(agent_name=agent_name, agent_role=agent.role.name, task_id=context['task_id']),
+                start_span(
+                    "agent.task",
+                    **{
+                        "chefbench.task.type": task_type.function_name,
+                        "chefbench.table": context.get('table'),
+                        "chefbench.order.complexity": complexity_level(task_type, context)
+                    }
+                ) as span
+            ):
                 # Process any pending messages first
                 self._process_agent_messages(agent)
+            
+                # Tell the agent which equipment is out of service
+                outages = []
+                if self.equipment:
+                    outages = self.equipment.outages_for(task_type, context.get('techniques', ()))
+                    context['equipment_unavailable'] = [i['name'] for i in self.equipment.unavailable()]
+                    context['equipment'] = sorted(
+                        {i.name for i in self.equipment.items.values()} | {i.kind for i in self.equipment.items.values()}
+                    )
+                    if task_type == TaskType.TEMPERATURE_MONITORING:
+                        context['temperatures'] = {
+                            name: sensor.to_dict()["temperature"]
+                            for name, sensor in self.equipment.temperature.sensors.items()
+                        }
+            
+                # Orders see how their table is pacing, and the pass sees every table
+                if self.floor.tables:
+                    pacing = self.floor.pacing()
+                    if task_type.function_name in PASS_TASKS:
+                        context['pacing'] = pacing
+                    elif 'table' in context:
+                        context['pacing'] = [h for h in pacing if h['table'] == context['table']]
+                
+                # Dispatches outside the agent's role still run, so the refusal
+                # shows up as a failed task rather than silently succeeding
+                self._deny(self.permissions.check_task(agent, task_type, context.get('techniques', ())), context['task_id'])
+            
+                # Spoiled stock is off the ingredient list, and the agent is told why
+                if self.spoiled:
+                    # Matched by canonical id, so spoiling "tomato" takes "Tomatoes" off the line too
+                    normalizer = get_normalizer()
+                    spoiled = set(normalizer.dedupe(self.spoiled))
+                    context['ingredients'] = [
+                        i for i in context.get('ingredients', []) if normalizer.canonical_id(i) not in spoiled
+                    ]
+                    context['spoiled_ingredients'] = sorted(self.spoiled)
+                
+                # Injected model latency
+                delay = 0.0
+                if self.llm_delay_tasks:
+                    self.llm_delay_tasks -= 1
+                    delay = self.llm_delay
+                    await asyncio.sleep(delay)
+                
+                # Safe point: cancelled while paused or delayed, before any model call
+                if self._revoke_if_cancelled(agent, context, "before_model"):
+                    continue
+                self._hold(task_type, context)
+                self._station_samples.append(
+                    {"simulated_time": round(self._simulated_clock(), 1), "load": dict(self._station_load())}
+                )
+                queued_at = self._queued_at.get(context['task_id'])
+                self.record_event(
+                    "task_started",
+                    agent_name=agent_name,
+                    task_id=context['task_id'],
+                    caused_by=self._task_events.get(context['task_id']),
+                    task_type=task_type.function_name,
+                    station=station_for(task_type),
+                    waited_seconds=round(self._simulated_clock() - queued_at, 1) if queued_at is not None else None
+                )
                 
-                # Execute task
-                execution = agent.process_task(task_type, context, device=agent.device)
+                # Execute task off the event loop so the API stays responsive
+                quality_factor = agent.quality_factor(task_type)
+                skill_before = agent.skill(task_type)
+                try:
+                    execution = await self._process_with_recovery(agent, task_type, context)
+                except AgentPaused as e:
+                    skipped = [context] + [c for name, _, c in self._queue if name == agent_name]
+                    self._queue = deque(item for item in self._queue if item[0] != agent_name)
+                    self._pause_agent(agent_name, context['task_id'], str(e), len(skipped))
+                    self._settled.update(c['task_id'] for c in skipped)
+                    self._revoked.pop(context['task_id'], None)
+                    self._release(context['task_id'])
+                    self._checkpoint()
+                    continue
+                # Safe point: cancelled while the model was working, so its answer is discarded
+                if self._revoke_if_cancelled(agent, context, "after_model", execution):
+                    continue
+                self._release(context['task_id'])
+                execution.reasoning_time += delay
+                execution.ingredients = list(context.get('ingredients', []))
+                execution.complexity = complexity_level(task_type, context)
+                if execution.success and execution.response:
+                    quality, execution.quality_breakdown = await asyncio.to_thread(
+                        self.rubric.grade,
+                        Submission(task_type, execution.response, context, agent.role, self.judge)
+                    )
+                    if quality is not None:
+                        execution.quality_score = quality * quality_factor
+                if outages and execution.success:
+                    self.equipment.blocked_tasks += 1
+                    execution.quality_score *= EQUIPMENT_OUTAGE_PENALTY
+                execution.invalid_references.extend(
+                    f"agent:{name}" for name in execution.collaboration_agents if name not in self.agents
+                )
+                if task_type == TaskType.MENU_PLANNING and execution.success and self.menu_sink:
+                    unknown = self._write_menu(agent_name, execution.response.parameters, context)
+                    execution.invalid_references.extend(f"recipe:{recipe_id}" for recipe_id in unknown)
                 self.execution_history.append(execution)
                 results.append(execution)
+                if task_type.function_name in ORDER_TASKS and context['task_id'] in self._queued_at:
+                    self._ticket_seconds[context['task_id']] = (
+                        self._simulated_clock() - self._queued_at[context['task_id']]
+                    )
+                if task_type.function_name in ORDER_TASKS:
+                    self._record_order_completion(context, execution)
+                execution_event = self._record_execution(execution, context)
+                # With an expeditor, ticketed orders go to the pass and out with the rest of their course
+                to_the_pass = self.expo is not None and task_type.function_name in ORDER_TASKS and 'ticket' in context
+                if task_type.function_name in ORDER_TASKS and execution.success and not to_the_pass:
+                    self._serve(context, execution_event)
+                for note in execution.notes:
+                    severity = note["severity"] if note["severity"] in NOTE_SEVERITIES else "warning"
+                    self.annotate_order(context['task_id'], agent_name, note["reason"], severity)
+                span.set_attributes({
+                    "chefbench.task.success": execution.success,
+                    "chefbench.task.quality": execution.quality_score
+                })
+                if agent.skills.trainee:
+                    self._skill_gains[agent_name] += agent.skill(task_type) - skill_before
+                    self.skill_store.update(agent)
+                    self.record_event(
+                        "skill_updated",
+                        agent_name=agent_name,
+                        task_id=context['task_id'],
+                        caused_by=execution_event,
+                        task_type=task_type.function_name,
+                        skill=round(agent.skill(task_type), 4),
+                        level=agent.skills.level
+                    )
+                if execution.degraded:
+                    self.record_event(
+                        "task_degraded",
+                        agent_name=agent_name,
+                        task_id=context['task_id'],
+                        caused_by=execution_event,
+                        reason=execution.degradation,
+                        policy=agent.fallback_policy
+                    )
+                if execution.invalid_references:
+                    self.record_event(
+                        "invalid_action",
+                        agent_name=agent_name,
+                        task_id=context['task_id'],
+                        caused_by=execution_event,
+                        references=execution.invalid_references
+                    )
+                if execution.restricted_ingredients_used:
+                    self.record_event(
+                        "restriction_violated",
+                        agent_name=agent_name,
+                        task_id=context['task_id'],
+                        caused_by=execution_event,
+                        ingredients=execution.restricted_ingredients_used,
+                        restrictions=context.get('dietary_restrictions', [])
+                    )
+                
+                if self.equipment:
+                    await self._advance_equipment(execution.execution_time, execution_event)
                 
+                # Late table orders go back to the floor through the table's server
+                if 'table' in context:
+                    if execution.execution_time > context.get('time_limit', float('inf')):
+                        self._report_delay(agent, task_type, context, "over its time limit", execution_event)
+                    elif any(h['table'] == context['table'] and h['pace'] == "behind" for h in context.get('pacing', [])):
+                        self._report_delay(agent, task_type, context, "table is behind its course pacing", execution_event)
+            
+                # Periodically check what the agent still remembers
+                if self.probe_interval and len(self.execution_history) % self.probe_interval == 0:
+                    await self._run_memory_probe(agent)
+            
                 # Send collaboration messages if needed
                 if execution.collaboration_agents:
                     for collab_agent in execution.collaboration_agents:
                         if collab_agent in self.agents:
+                            violation = self.permissions.check_delegation(agent, self.agents[collab_agent], task_type)
+                            if self._deny(violation, context['task_id'], execution_event):
+                                continue
                             message = agent.send_message(
                                 collab_agent,
                                 f"Need assistance with {task_type.function_name}",
                                 task_type
                             )
-                            self.message_bus.append(message)
-                            self.agents[collab_agent].receive_message(message)
-                
+                            self._deliver(message, execution_event)
+            
                 # Head chef quality check
                 if head_chef and agent_name != head_chef.name:
                     if execution.quality_score < 0.7:
@@ -196,18 +1927,571 @@ class MultiAgentCoordinator:
                             agent_name,
                             f"Quality issue with {task_type.function_name}. Score: {execution.quality_score:.2f}"
                         )
-                        self.message_bus.append(message)
-                        agent.receive_message(message)
-                    
+                        self._deliver(message, execution_event)
+                
                         agent.authority_compliance *= 0.95
-   
-        
+                
+                self._check_budget(execution_event)
+                self._settled.add(context['task_id'])
+                if to_the_pass:
+                    # Settled first, so its ticket shows it done
+                    self._to_the_pass(context, execution.success, execution_event)
+                self._checkpoint()
+        self._in_flight = None
+    
         return results
     
+    async def _process_with_recovery(
+        self,
+        agent: LLMAgent,
+        task_type: TaskType,
+        context: Dict[str, Any]
+    ) -> TaskExecution:
+        """Work a task off the event loop, retrying errors as their class's policy allows
+
+        An error that isn't retried fails the task rather than the run. Every
+        error, and every answer the fallback heuristics gave after a model
+        failure, is spent from the agent's error budget. AgentPaused is left
+        to the caller.
+        """
+        attempt = 0
+        while True:
+            try:
+                execution = await asyncio.to_thread(agent.process_task, task_type, context, device=agent.device)
+            except AgentPaused:
+                raise
+            except Exception as e:
+                error_class = classify_error(e)
+                policy = RETRY_POLICIES[error_class]
+                retry = (
+                    attempt < policy.retries
+                    and not self.error_budget.exhausted(agent.name)
+                    and context['task_id'] not in self._revoked
+                )
+                self._record_task_error(
+                    agent, task_type, context, error_class, str(e) or type(e).__name__, attempt,
+                    RETRIED if retry else FAILED
+                )
+                if not retry:
+                    logger.exception(f"{agent.name} failed {context['task_id']} on a {error_class} error")
+                    execution = TaskExecution(
+                        agent_name=agent.name,
+                        task_type=task_type,
+                        start_time=time.time(),
+                        reasoning_time=0,
+                        execution_time=0,
+                        chosen_approach="ERROR",
+                        resources_used=[],
+                        collaboration_agents=[],
+                        success=False,
+                        quality_score=0,
+                        device=agent.device
+                    )
+                    agent.record_execution(execution)
+                    return execution
+                logger.warning(f"{agent.name} hit a {error_class} error on {context['task_id']}, retrying: {e}")
+                await asyncio.sleep(policy.delay(attempt))
+                attempt += 1
+                continue
+            
+            if execution.degraded:
+                self._record_task_error(agent, task_type, context, TRANSIENT, execution.degradation, attempt, FALLBACK)
+            return execution
+    
+    def _record_task_error(
+        self,
+        agent: LLMAgent,
+        task_type: TaskType,
+        context: Dict[str, Any],
+        error_class: str,
+        error: str,
+        attempt: int,
+        outcome: str
+    ):
+        task_error = TaskError(
+            agent_name=agent.name,
+            task_id=context['task_id'],
+            task_type=task_type.function_name,
+            error_class=error_class,
+            error=error,
+            attempt=attempt,
+            outcome=outcome,
+            simulated_time=round(self._simulated_clock(), 1)
+        )
+        self.error_budget.record(task_error)
+        self.record_event(
+            "task_error",
+            agent_name=agent.name,
+            task_id=context['task_id'],
+            caused_by=self._task_events.get(context['task_id']),
+            **{k: v for k, v in task_error.to_dict().items() if k not in ("agent_name", "task_id")},
+            budget_remaining=max(0, self.error_budget.budget - self.error_budget.spent(agent.name))
+        )
+    
+    async def _plan_pacing(self, head_chef: Optional[LLMAgent]):
+        """Reorder the queued orders to the planner's firing order, once the head chef has reviewed it"""
+        items = list(self._queue)
+        planned, plan = self.pacing.plan(items)
+        if not plan.sequence:
+            return
+        if head_chef:
+            answer = await asyncio.to_thread(head_chef.answer_question, self.pacing.review_question(items, plan))
+            try:
+                answer = str(json.loads(answer).get("answer", ""))
+            except (json.JSONDecodeError, AttributeError):
+                pass
+            planned = self.pacing.review(items, planned, plan, head_chef.name, answer)
+        self._queue = deque(planned)
+        self.pacing_plan = plan
+        self.record_event(
+            "pacing_planned",
+            agent_name=plan.reviewer,
+            decision=plan.decision,
+            sequence=plan.sequence,
+            batches=plan.batches,
+            fire_first=plan.fire_first,
+            efficiency=plan.efficiency
+        )
+        logger.info(
+            f"Paced {len(plan.sequence)} orders ({plan.decision}): "
+            f"estimated average ticket {plan.baseline_ticket_seconds:.0f}s -> {plan.planned_ticket_seconds:.0f}s"
+        )
+    
+    def _simulated_clock(self) -> float:
+        """Simulated seconds into the run; tasks run back to back, so the sum of their execution times"""
+        return sum(e.execution_time for e in self.execution_history)
+    
+    def _escalate(self):
+        """Expedite, and if need be reassign, tasks that have waited past the escalation thresholds"""
+        clock = self._simulated_clock()
+        if not self.escalation.due(clock):
+            return
+        escalated = []
+        # Portions held at the pass aren't late, however long they wait
+        waiting = [item for item in self._queue if item[2]['task_id'] not in self._holding]
+        for item, level, waited in self.escalation.scan(waiting, clock, self._queued_at):
+            agent_name, task_type, context = item
+            self._queue.remove(item)
+            reassigned_to = self._reassign(item) if level == REASSIGN else None
+            assignee = reassigned_to or agent_name
+            escalated.append((assignee, task_type, context))
+            
+            escalation = Escalation(
+                task_id=context['task_id'],
+                task_type=task_type.function_name,
+                level=level,
+                agent_name=agent_name,
+                waited_seconds=round(waited, 1),
+                time_limit=context.get('time_limit') or DEFAULT_TIME_LIMIT,
+                simulated_time=round(clock, 1),
+                reassigned_to=reassigned_to
+            )
+            self.escalation.escalations.append(escalation)
+            event_id = self.record_event(
+                "order_escalated",
+                agent_name=assignee,
+                task_id=context['task_id'],
+                caused_by=self._task_events.get(context['task_id']),
+                **{k: v for k, v in escalation.to_dict().items() if k not in ("task_id", "agent_name")},
+                previous_agent=agent_name
+            )
+            self._task_events[context['task_id']] = event_id
+            
+            head_chef = self._get_head_chef()
+            self.annotate_order(
+                context['task_id'],
+                head_chef.name if head_chef else "escalation",
+                f"Waited {waited:.0f}s against a {escalation.time_limit:.0f}s limit"
+                + (f", reassigned from {agent_name} to {reassigned_to}" if reassigned_to else ", expedited"),
+                severity="warning" if level == REASSIGN else "info",
+                source="escalation"
+            )
+            if head_chef and head_chef.name != assignee:
+                message = head_chef.send_message(
+                    assignee,
+                    f"{task_type.function_name} ({context['task_id']}) has waited {waited:.0f}s "
+                    f"against a {escalation.time_limit:.0f}s limit; fire it next"
+                )
+                message.priority = 1
+                self._deliver(message, event_id)
+        
+        # Escalated tasks jump the queue, longest waiting first
+        self._queue.extendleft(reversed(escalated))
+    
+    def _reassign(self, item: Tuple[str, TaskType, Dict[str, Any]]) -> Optional[str]:
+        """Hand a queued task to the on-shift agent with the least queued work, if they have less than its owner"""
+        agent_name, task_type, context = item
+        load = defaultdict(int)
+        for name, _, _ in self._queue:
+            load[name] += 1
+        scheduled = set(self.schedule.scheduled_agents(self.agents))
+        candidates = [
+            name for name, agent in self.agents.items()
+            if name != agent_name and name in scheduled and name not in self.paused_agents
+            and self.permissions.can_perform(agent, task_type, context.get('techniques', ()))
+        ]
+        if not candidates:
+            return None
+        target = min(candidates, key=lambda name: (load[name], name))
+        if load[target] >= load[agent_name]:
+            return None
+        
+        owned = self._assignments.get(agent_name, [])
+        if (task_type, context) in owned:
+            owned.remove((task_type, context))
+        self._assignments.setdefault(target, []).append((task_type, context))
+        return target
+    
+    def _delegator_for(self, recipient: LLMAgent) -> Optional[LLMAgent]:
+        """Who hands a task to this agent: the most junior sous chef or head chef above them, else the head chef"""
+        scheduled = set(self.schedule.scheduled_agents(self.agents))
+        seniors = [
+            agent for name, agent in self.agents.items()
+            if name in scheduled and name not in self.paused_agents
+            and agent.role.value > recipient.role.value and agent.role.value >= AgentRole.SOUS_CHEF.value
+        ]
+        if seniors:
+            return min(seniors, key=lambda agent: (agent.role.value, agent.name))
+        return self._get_head_chef()
+    
+    def _hand_off(
+        self,
+        delegator: LLMAgent,
+        agent_name: str,
+        task_type: TaskType,
+        context: Dict[str, Any],
+        task_assignments: Dict[str, List[Tuple[TaskType, Dict]]]
+    ) -> Handoff:
+        """Offer a task to its station, then to the other agents who can do it until one accepts"""
+        scheduled = set(self.schedule.scheduled_agents(self.agents))
+        recipients = [self.agents[agent_name]] + [
+            self.agents[name] for name in context.get('other_agents', [])
+            if name in self.agents and name in scheduled and name not in self.paused_agents
+            and name != delegator.name
+        ]
+        handoff = self.handoff.offer(
+            delegator, recipients, task_type, context['task_id'], self.equipment, context.get('techniques', ())
+        )
+        
+        caused_by = self._task_events.get(context['task_id'])
+        for offer in handoff.offers:
+            self._deliver(delegator.send_message(
+                offer.recipient,
+                f"Please execute {task_type.function_name}",
+                task_type
+            ), caused_by)
+            reply = (
+                f"Accepted {task_type.function_name}" if offer.status == ACCEPTED
+                else f"Rejected {task_type.function_name}: {offer.reason.replace('_', ' ')}"
+            )
+            self._deliver(self.agents[offer.recipient].send_message(delegator.name, reply, task_type), caused_by)
+        
+        if handoff.assigned_to != agent_name:
+            task_assignments[agent_name].remove((task_type, context))
+            task_assignments.setdefault(handoff.assigned_to, []).append((task_type, context))
+            context['other_agents'] = [
+                name for name in [agent_name] + context.get('other_agents', []) if name != handoff.assigned_to
+            ]
+        self._task_events[context['task_id']] = self.record_event(
+            "task_handed_off",
+            agent_name=handoff.assigned_to,
+            task_id=context['task_id'],
+            caused_by=caused_by,
+            **{k: v for k, v in handoff.to_dict().items() if k not in ("task_id", "assigned_to")}
+        )
+        if not handoff.routed:
+            logger.warning(f"No station accepted {context['task_id']}; it stays with {agent_name}")
+        return handoff
+    
+    def _deny(
+        self,
+        violation: Optional[PermissionViolation],
+        task_id: str,
+        caused_by: Optional[int] = None
+    ) -> bool:
+        """Record a permission violation, returning whether one occurred"""
+        if violation is None:
+            return False
+        self.record_event(
+            "permission_violation",
+            task_id=task_id,
+            caused_by=caused_by if caused_by is not None else self._task_events.get(task_id),
+            **violation.to_dict()
+        )
+        logger.warning(f"Permission violation by {violation.agent_name}: {violation.reason}")
+        return True
+    
+    def _pause_agent(self, agent_name: str, task_id: str, reason: str, skipped_tasks: int):
+        """Take an agent whose model failed out of the rest of the run and raise an alert"""
+        self.paused_agents.append(agent_name)
+        self.record_event(
+            "agent_paused",
+            agent_name=agent_name,
+            task_id=task_id,
+            caused_by=self._task_events.get(task_id),
+            reason=reason,
+            skipped_tasks=skipped_tasks
+        )
+        logger.error(f"{agent_name} paused, skipping {skipped_tasks} tasks: {reason}")
+    
+    def _write_menu(self, agent_name: str, parameters: Dict[str, Any], context: Dict[str, Any]) -> List[Any]:
+        """Put the menu a planner chose on through the menu sink, returning the recipe ids it didn't know
+
+        Items are {"recipe_id", "specialty", "seasonal"}, or bare recipe ids.
+        A plan with no menu in its parameters changes nothing.
+        """
+        items = [
+            item if isinstance(item, dict) else {"recipe_id": item}
+            for item in parameters.get("menu") or [] if isinstance(item, (dict, int))
+        ]
+        if not items:
+            return []
+        unknown = self.menu_sink(agent_name, items)
+        planned = [item for item in items if item.get("recipe_id") not in unknown]
+        self.record_event(
+            "menu_planned",
+            agent_name=agent_name,
+            task_id=context['task_id'],
+            caused_by=self._task_events.get(context['task_id']),
+            items=[item.get("recipe_id") for item in planned],
+            specialties=[item.get("recipe_id") for item in planned if item.get("specialty")],
+            unknown=unknown
+        )
+        return unknown
+    
+    def _record_order_completion(self, context: Dict[str, Any], execution: TaskExecution):
+        """Keep a finished order's outcome under the complexity it was accepted with"""
+        self._order_completions.append({
+            "task_id": context['task_id'],
+            "complexity": execution.complexity,
+            "success": execution.success,
+            "quality_score": execution.quality_score,
+            "ticket_seconds": self._ticket_seconds.get(context['task_id'])
+        })
+    
+    def _serve(self, context: Dict[str, Any], caused_by: Optional[int]):
+        """Send a cooked order out to its guests, carried by the table's server when it has one"""
+        server = self.front_of_house.server_for(context['table']) if 'table' in context else None
+        self.record_event(
+            "order_served",
+            agent_name=server.name if server else None,
+            task_id=context['task_id'],
+            caused_by=caused_by,
+            table=context.get('table')
+        )
+    
+    def _to_the_pass(self, context: Dict[str, Any], success: bool, caused_by: Optional[int]):
+        """Put a cooked portion up at the pass, sending its course out if it was the last of it"""
+        course = course_of(context)
+        self.expo.receive(context['task_id'], context['ticket'], course, context.get('table'), success, self._simulated_clock())
+        arrived = self.record_event(
+            "order_at_pass",
+            agent_name=self.expo.name,
+            task_id=context['task_id'],
+            caused_by=caused_by,
+            ticket=context['ticket'],
+            course=course,
+            table=context.get('table'),
+            success=success
+        )
+        self._send_ready(context['ticket'], arrived)
+    
+    def _work_the_pass(self):
+        """Between tasks, chase courses left waiting at the pass and send out those whose missing portions were cancelled
+
+        Courses for tables behind their floor pacing are chased sooner.
+        """
+        clock = self._simulated_clock()
+        behind = {h['table'] for h in self.floor.pacing() if h['pace'] == "behind"} if self.floor.tables else set()
+        for ticket, course, waited in self.expo.overdue(clock, behind):
+            self._call_back(ticket, course, waited)
+        for ticket in list(self.tickets):
+            self._send_ready(ticket, None)
+    
+    def _send_ready(self, name: str, caused_by: Optional[int]):
+        """Send out a ticket's courses that are done, in order, then fire the next if the table is holding it"""
+        ticket = self.order_ticket(self.tickets[name][0][1]['task_id'])
+        course = self.expo.current_course(ticket)
+        while course:
+            sent = self.expo.check(ticket, course, self._simulated_clock())
+            if sent is None:
+                break
+            self._send_course(sent, caused_by)
+            course = self.expo.current_course(ticket)
+        if course and ticket['table'] is not None and self.holds.holding({'course': course, 'table': ticket['table']}):
+            self.fire_course(course, ticket['table'], author=self.expo.name)
+    
+    def _send_course(self, sent: CourseSent, caused_by: Optional[int]):
+        """Serve a course's portions together, and move its table on to the next stage of the meal"""
+        event_id = self.record_event("course_sent", agent_name=self.expo.name, caused_by=caused_by, **sent.to_dict())
+        contexts = {context['task_id']: context for _, context in self.tickets.get(sent.ticket, [])}
+        for task_id in sent.task_ids:
+            served = contexts.get(task_id) or {'task_id': task_id}
+            if sent.table is not None:
+                served = {**served, 'table': sent.table}
+            self._serve(served, event_id)
+        # Starters out puts the table on its mains, and mains out on dessert
+        stage = {"starter": "fired", "main": "dessert"}.get(sent.course)
+        if stage and sent.table in self.floor.tables:
+            try:
+                self.floor.advance(sent.table, stage)
+            except ValueError:
+                pass  # already there, or no longer dining
+        logger.info(f"{self.expo.name} sent {hold_label(sent.course, sent.table)} on {sent.ticket}")
+    
+    def _call_back(self, name: str, course: str, waited: float):
+        """Chase the portions a course waiting at the pass is short of: they're fired and cooked next"""
+        ticket = self.order_ticket(self.tickets[name][0][1]['task_id'])
+        table = ticket['table']
+        late = [
+            p['task_id'] for line in ticket['lines'] if line['course'] == course
+            for p in line['portions'] if p['state'] in ("queued", "held", "in_progress")
+        ]
+        self.expo.call_back(name, course, table, late, waited, self._simulated_clock())
+        event_id = self.record_event(
+            "course_called_back",
+            agent_name=self.expo.name,
+            ticket=name,
+            course=course,
+            table=table,
+            task_ids=late,
+            waited_seconds=round(waited, 1)
+        )
+        if table is not None and self.holds.holding({'course': course, 'table': table}):
+            self.fire_course(course, table, author=self.expo.name)
+        
+        # Called-back portions jump the queue, as if escalation had expedited them
+        called = [item for item in self._queue if item[2]['task_id'] in late]
+        for item in called:
+            self._queue.remove(item)
+            task_id = item[2]['task_id']
+            self.escalation.levels[task_id] = max(self.escalation.levels.get(task_id, 0), EXPEDITE)
+        self._queue.extendleft(reversed(called))
+        for agent_name, task_type, context in called:
+            self.record_event(
+                "order_called_back",
+                agent_name=agent_name,
+                task_id=context['task_id'],
+                caused_by=event_id,
+                course=course,
+                table=table
+            )
+            if agent_name in self.agents:
+                content = (
+                    f"The pass is waiting on {context.get('dish') or task_type.function_name} ({context['task_id']}) "
+                    f"for {hold_label(course, table)}; fire it next"
+                )
+                self._deliver(Message(self.expo.name, agent_name, AgentRole.HEAD_CHEF, content, task_type, priority=1), event_id)
+    
+    def _record_execution(self, execution: TaskExecution, context: Dict[str, Any]) -> Optional[int]:
+        return self.record_event(
+            "task_executed",
+            agent_name=execution.agent_name,
+            task_id=context['task_id'],
+            caused_by=self._task_events.get(context['task_id']),
+            task_type=execution.task_type.function_name,
+            success=execution.success,
+            quality_score=execution.quality_score,
+            reasoning_time=execution.reasoning_time,
+            execution_time=execution.execution_time,
+            chosen_approach=execution.chosen_approach,
+            degraded=execution.degraded
+        )
+    
+    async def _advance_equipment(self, seconds: float, caused_by: Optional[int]):
+        """Advance simulated equipment time and send out repairs for broken items"""
+        for change in self.equipment.advance(seconds):
+            event_id = self.record_event(
+                change.event_type,
+                caused_by=caused_by,
+                equipment=change.equipment,
+                simulated_time=change.time,
+                **change.details
+            )
+            if change.event_type == "temperature_alert":
+                self._send_temperature_alert(change, event_id)
+        
+        for item in list(self.equipment.items.values()):
+            if item.status == BROKEN:
+                await self._dispatch_repair(item.name, caused_by)
+    
+    def _send_temperature_alert(self, change, caused_by: Optional[int]):
+        """Tell whoever runs the equipment's station, or the nearest senior agent on shift"""
+        station_role = STATION_ROLES[change.details["station"]]
+        scheduled = set(self.schedule.scheduled_agents(self.agents))
+        on_shift = [
+            a for name, a in self.agents.items()
+            if name in scheduled and name not in self.paused_agents and a.role.value >= station_role.value
+        ]
+        if not on_shift:
+            return
+        
+        recipient = min(on_shift, key=lambda a: a.role.value)
+        message = Message(
+            sender="temperature_monitor",
+            recipient=recipient.name,
+            role=AgentRole.KITCHEN_PORTER,  # no authority over the recipient
+            content=(
+                f"{change.equipment} is {change.details['threshold']} at {change.details['temperature']}C "
+                f"(safe range {change.details['low']}-{change.details['high']}C)"
+            ),
+            task_type=TaskType.TEMPERATURE_MONITORING,
+            priority=1
+        )
+        self._deliver(message, caused_by)
+    
+    async def _dispatch_repair(self, equipment_name: str, caused_by: Optional[int]):
+        """Have the most junior capable agent (normally the kitchen porter) repair equipment"""
+        candidates = [
+            a for a in self.agents.values()
+            if TaskType.EQUIPMENT_MAINTENANCE in a.available_tasks and a.name not in self.paused_agents
+        ]
+        if not candidates:
+            return
+        
+        porter = min(candidates, key=lambda a: a.role.value)
+        context = {
+            'task_id': f"repair-{equipment_name}-{len(self.execution_history) + 1}",
+            'equipment': equipment_name,
+            'equipment_unavailable': [i['name'] for i in self.equipment.unavailable()]
+        }
+        self._task_events[context['task_id']] = self.record_event(
+            "task_assigned",
+            agent_name=porter.name,
+            task_id=context['task_id'],
+            caused_by=caused_by,
+            task_type=TaskType.EQUIPMENT_MAINTENANCE.function_name,
+            policy="equipment_repair"
+        )
+        
+        with log_context(agent_name=porter.name, agent_role=porter.role.name, task_id=context['task_id']):
+            self._deny(self.permissions.check_task(porter, TaskType.EQUIPMENT_MAINTENANCE), context['task_id'])
+            try:
+                execution = await asyncio.to_thread(
+                    porter.process_task, TaskType.EQUIPMENT_MAINTENANCE, context, device=porter.device
+                )
+            except AgentPaused as e:
+                self._pause_agent(porter.name, context['task_id'], str(e), 1)
+                return
+            self.execution_history.append(execution)
+            repair_event = self._record_execution(execution, context)
+            
+            if execution.success:
+                restored = self.equipment.repair(equipment_name)
+                self.record_event(
+                    restored.event_type,
+                    agent_name=porter.name,
+                    caused_by=repair_event,
+                    equipment=equipment_name,
+                    simulated_time=restored.time,
+                    **restored.details
+                )
+            else:
+                logger.warning(f"{porter.name} failed to repair {equipment_name}")
+    
     def _process_agent_messages(self, agent: LLMAgent):
         """Process messages in agent's queue"""
-        while agent.message_queue:
-            message = agent.message_queue.pop(0)
+        for message in agent.take_messages():
      
             if message.role.value > agent.role.value:
                 agent.authority_compliance = min(1.0, agent.authority_compliance * 1.02)
@@ -218,11 +2502,57 @@ class MultiAgentCoordinator:
                     message.sender,
                     f"Acknowledged {message.content}"
                 )
-                self.message_bus.append(response)
-                
+                self._deliver(response, self._message_events.get(id(message)))
+    
+    def _deliver(self, message: Message, caused_by: Optional[int] = None) -> Optional[int]:
+        """Put a message on the bus, hand it to its recipient and record it"""
+        self.message_bus.append(message)
+        if message.recipient in self.agents:
+            self.agents[message.recipient].receive_message(message)
+        
+        event_id = self.record_event(
+            "message_sent",
+            agent_name=message.sender,
+            caused_by=caused_by,
+            **message.to_dict()
+        )
+        self._message_events[id(message)] = event_id
+        return event_id
+    
+    def record_event(
+        self,
+        event_type: str,
+        agent_name: Optional[str] = None,
+        task_id: Optional[str] = None,
+        caused_by: Optional[int] = None,
+        **payload: Any
+    ) -> Optional[int]:
+        """Record a kitchen event, returning its id when an event store is attached"""
+        event = KitchenEvent(
+            event_type=event_type,
+            run_id=self.run_id,
+            agent_name=agent_name,
+            task_id=task_id,
+            caused_by=caused_by,
+            payload=payload
+        )
+        if self.event_store:
+            self.event_store.append(event)
+        self.event_log.append(event)
+        return event.event_id
     
-                if message.sender in self.agents:
-                    self.agents[message.sender].receive_message(response)
+    async def _run_memory_probe(self, agent: LLMAgent):
+        """Ask the agent one recall question, rotating through probe kinds"""
+        probes = build_probes(agent)
+        if not probes:
+            return
+        
+        probe = probes[len(self.probe_results) % len(probes)]
+        self.probe_results.append(await asyncio.to_thread(ask_probe, agent, probe))
+    
+    def _station_keeper(self) -> Optional[str]:
+        """The sous chef, who holds tasks back from full stations; None on a team without one"""
+        return next((name for name, agent in self.agents.items() if agent.role == AgentRole.SOUS_CHEF), None)
     
     def _get_head_chef(self) -> Optional[LLMAgent]:
         """Get the head chef agent if exists"""
@@ -235,7 +2565,7 @@ class MultiAgentCoordinator:
         """Collect comprehensive metrics from scenario execution"""
         agent_metrics = {}
         
-        for name, agent in self.agents.items():
+        for name, agent in list(self.agents.items()):
             agent_metrics[name] = agent.get_metrics()
         
     
@@ -256,7 +2586,7 @@ class MultiAgentCoordinator:
         
 
         authority_scores = [a.authority_compliance for a in self.agents.values()]
-        team_metrics["hierarchy_compliance"] = sum(authority_scores) / len(authority_scores)
+        team_metrics["hierarchy_compliance"] = sum(authority_scores) / max(len(authority_scores), 1)
         
 
         messages_by_role = defaultdict(int)
@@ -265,24 +2595,263 @@ class MultiAgentCoordinator:
         
         team_metrics["communication_by_role"] = dict(messages_by_role)
         
+        # Labor cost over simulated time; tasks run back to back, so elapsed
+        # simulated time is the sum of their execution times
+        simulated_elapsed = sum(e.execution_time for e in self.execution_history)
+        labor = self.schedule.labor_cost(self.agents, simulated_elapsed, max(self.scenario_duration, simulated_elapsed))
+        team_metrics["labor_cost"] = labor["total_cost"]
+        team_metrics["cost_per_successful_task"] = labor["total_cost"] / max(len(successful_tasks), 1)
+        team_metrics["labor_efficiency"] = (
+            sum(e.quality_score for e in successful_tasks) / labor["total_cost"]
+            if labor["total_cost"] > 0 else 0.0
+        )
+        for name, cost in labor["by_agent"].items():
+            agent_metrics[name]["labor_hours"] = cost["hours"]
+            agent_metrics[name]["labor_cost"] = cost["cost"]
+        
+        # Tasks decided without a working model, and agents taken out of the run
+        team_metrics["degraded_tasks"] = sum(1 for e in self.execution_history if e.degraded)
+        team_metrics["paused_agents"] = list(self.paused_agents)
+        team_metrics["degraded"] = bool(team_metrics["degraded_tasks"] or self.paused_agents)
+        
+        # Mean score per quality rubric category, over the tasks it graded
+        graded: Dict[str, List[float]] = defaultdict(list)
+        for execution in successful_tasks:
+            for category, score in execution.quality_breakdown.items():
+                graded[category].append(score)
+        team_metrics["quality_by_category"] = {
+            category: sum(scores) / len(scores) for category, scores in graded.items()
+        }
+        
+        # Tasks that used an ingredient the guests' dietary restrictions rule out
+        team_metrics["restriction_violations"] = sum(
+            1 for e in successful_tasks if e.restricted_ingredients_used
+        )
+        
+        # Notes agents and people put on orders, by severity; escalations' own
+        # notes are left out, as waiting already costs on speed
+        team_metrics["order_notes"] = {
+            severity: sum(
+                1 for n in self.order_notes if n["severity"] == severity and n["source"] != "escalation"
+            )
+            for severity in NOTE_SEVERITIES
+        }
+        
+        # Actions checked against each agent's role
+        permissions = self.permissions.summary()
+        team_metrics["role_coherence"] = permissions["role_coherence"]
+        team_metrics["permission_violations"] = len(permissions["violations"])
+        for name in agent_metrics:
+            agent_metrics[name]["permission_violations"] = permissions["violations_by_agent"].get(name, 0)
+        
+        # Hallucinated references plus out-of-role actions, per executed task
+        invalid_by_agent: Dict[str, int] = defaultdict(int)
+        tasks_by_agent: Dict[str, int] = defaultdict(int)
+        for execution in self.execution_history:
+            invalid_by_agent[execution.agent_name] += len(execution.invalid_references)
+            tasks_by_agent[execution.agent_name] += 1
+        for name, count in permissions["violations_by_agent"].items():
+            invalid_by_agent[name] += count
+        team_metrics["invalid_actions"] = sum(invalid_by_agent.values())
+        team_metrics["invalid_action_rate"] = team_metrics["invalid_actions"] / max(total_tasks, 1)
+        by_model: Dict[str, Dict[str, float]] = {}
+        for name, agent in self.agents.items():
+            model = by_model.setdefault(agent.model_name, {"tasks": 0, "invalid_actions": 0})
+            model["tasks"] += tasks_by_agent[name]
+            model["invalid_actions"] += invalid_by_agent[name]
+            agent_metrics[name]["invalid_actions"] = invalid_by_agent[name]
+            agent_metrics[name]["invalid_action_rate"] = invalid_by_agent[name] / max(tasks_by_agent[name], 1)
+        for model in by_model.values():
+            model["invalid_action_rate"] = model["invalid_actions"] / max(model["tasks"], 1)
+        team_metrics["invalid_actions_by_model"] = by_model
+        
+        # Long-term consistency measured directly by recall probes
+        probe_summary = summarize_probes(self.probe_results)
+        team_metrics["memory_consistency"] = probe_summary["recall_accuracy"]
+        for name, accuracy in probe_summary["by_agent"].items():
+            if name in agent_metrics:  # probed agents may have been released since
+                agent_metrics[name]["memory_consistency"] = accuracy
+        
+        # Trainee progression: skill gained this run, and whether their work got
+        # better from the first half of their tasks to the second
+        gains = []
+        for name, agent in self.agents.items():
+            if not agent.skills.trainee:
+                continue
+            executions = [e for e in self.execution_history if e.agent_name == name]
+            half = len(executions) // 2
+            agent_metrics[name]["skill_gain"] = self._skill_gains[name]
+            agent_metrics[name]["quality_trend"] = (
+                performance(executions[half:]) - performance(executions[:half]) if half else None
+            )
+            gains.append(self._skill_gains[name])
+        team_metrics["learning_progression"] = sum(gains) / len(gains) if gains else None
+        
+        # How well performance recovered from injected disruptions
+        adaptation = adaptation_capability(self.execution_history, self.chaos)
+        team_metrics["chaos_injections"] = len(self.chaos)
+        team_metrics["adaptation_capability"] = adaptation["score"]
+        
+        # Tasks the escalation worker had to step in on
+        escalation = self.escalation.summary()
+        team_metrics["escalations"] = len(self.escalation.escalations)
+        team_metrics["station_deferrals"] = sum(self._deferrals.values())
+        
+        # Errors spent from each agent's budget; reliability is the share of budget left
+        reliability = self.error_budget.summary(list(self.agents))
+        team_metrics["task_errors"] = reliability["errors"]
+        team_metrics["reliability"] = reliability["reliability"]
+        for name, budget in reliability["by_agent"].items():
+            agent_metrics[name]["task_errors"] = budget["errors"]
+            agent_metrics[name]["reliability"] = budget["reliability"]
+        
+        # Front and back of house: guest requests relayed in time, and delays told to the table
+        service = service_summary(self.guest_requests, self.delays)
+        team_metrics["foh_coordination"] = service["score"]
+        
+        # How smoothly delegated work changed hands, and how long stations took to answer
+        handoffs = self.handoff.summary()
+        # Held portions fired anyway count against it, and the expo's courses sent short or chased are averaged in
+        coordination = self.holds.coordination(handoffs["coordination_score"])
+        team_metrics["coordination_score"] = self.expo.coordination(coordination) if self.expo else coordination
+        team_metrics["hold_violations"] = len(self.holds.violations)
+        team_metrics["handoff_latency"] = handoffs["average_latency_seconds"]
+        team_metrics["handoff_rejections"] = sum(handoffs["rejections"].values())
+        
+        # Simulated time from an order being queued to it being done, and what pacing saved on the plan
+        tickets = list(self._ticket_seconds.values())
+        team_metrics["average_ticket_seconds"] = sum(tickets) / len(tickets) if tickets else None
+        
+        # Finished orders by the complexity they were accepted with, so harder orders aren't read as worse cooking
+        orders_by_complexity = {}
+        for level in COMPLEXITY_LEVELS:
+            done = [o for o in self._order_completions if o["complexity"] == level]
+            level_tickets = [o["ticket_seconds"] for o in done if o["ticket_seconds"] is not None]
+            orders_by_complexity[level] = {
+                "orders": len(done),
+                "success_rate": sum(1 for o in done if o["success"]) / len(done) if done else None,
+                "average_quality": sum(o["quality_score"] for o in done) / len(done) if done else None,
+                "average_ticket_seconds": sum(level_tickets) / len(level_tickets) if level_tickets else None
+            }
+        team_metrics["orders_by_complexity"] = orders_by_complexity
+        
+        # Intake: of every portion offered at the door, the share accepted and then cooked within its time limit
+        accepted = [
+            context for tasks in self.tickets.values() for _, context in tasks
+            if context['task_id'] not in self._cancelled
+        ]
+        on_time = sum(
+            1 for context in accepted
+            if self._ticket_seconds.get(context['task_id']) is not None
+            and self._ticket_seconds[context['task_id']] <= (context.get('time_limit') or DEFAULT_TIME_LIMIT)
+        )
+        offered = len(accepted) + sum(t["portions"] for t in self.throttled_orders)
+        intake = {
+            "offered": offered,
+            "accepted": len(accepted),
+            "throttled": sum(t["portions"] for t in self.throttled_orders),
+            "throttled_orders": len(self.throttled_orders),
+            "on_time": on_time,
+            "score": on_time / offered if offered else None
+        }
+        team_metrics["intake_score"] = intake["score"]
+        if self.pacing_plan:
+            team_metrics["pacing_efficiency"] = self.pacing_plan.efficiency
+        
         return {
             "agents": agent_metrics,
-            "team": team_metrics
+            "team": team_metrics,
+            "adaptation": adaptation,
+            "equipment": self.equipment.summary() if self.equipment else None,
+            "stations": utilization_summary(self._station_samples, self.station_capacity, self._deferrals),
+            "front_of_house": service,
+            "pacing": self.pacing_plan.to_dict() if self.pacing_plan else None,
+            "tickets": dict(self._ticket_seconds),
+            "intake": intake,
+            "handoffs": handoffs,
+            "holds": self.holds.summary(),
+            "expo": self.expo.summary() if self.expo else None,
+            "escalation": escalation,
+            "reliability": reliability,
+            "labor": labor,
+            "permissions": permissions,
+            "memory_probes": {
+                **probe_summary,
+                "probes": [p.to_dict() for p in self.probe_results]
+            },
+            "memory": {
+                "settings": self.memory_settings.to_dict(),
+                "agents": {
+                    name: {"compactions": agent.memory.compactions, "summaries": len(agent.memory.summaries),
+                           "summarized_events": sum(s.events for s in agent.memory.summaries)}
+                    for name, agent in self.agents.items()
+                }
+            }
         }
     
+    def set_assignment_policy(self, name: str):
+        """Switch the policy used to assign tasks to agents"""
+        self.assignment_policy = get_assignment_policy(name)
+        self.assignment_policy_name = name
+    
     def reset(self):
         """Reset coordinator for new scenario"""
         self.message_bus.clear()
         self.task_queue.clear()
         self.execution_history.clear()
+        self.probe_results.clear()
+        self.event_log.clear()
+        self._task_events.clear()
+        self._message_events.clear()
+        self.run_id = None
         self.scenario_start_time = None
         self.scenario_end_time = None
+        self.equipment = None
+        self.paused_agents.clear()
+        self.permissions.reset()
+        self._assignments = {}
+        self._settled = set()
+        self._unpaused.set()
+        self.pause_reason = None
+        self._budget_state = BUDGET_OK
+        self._queue.clear()
+        self.chaos.clear()
+        self._skill_gains.clear()
+        self.spoiled.clear()
+        self.llm_delay = 0.0
+        self.llm_delay_tasks = 0
+        self.escalation = EscalationWorker(self.escalation.thresholds)
+        self.handoff = HandoffProtocol(self.handoff.settings)
+        self.error_budget = ErrorBudget(self.error_budget.budget)
+        self._queued_at = {}
+        self._deferred.clear()
+        self._deferrals = defaultdict(int)
+        self._station_samples = []
+        self.throttled_orders = []
+        self.eighty_sixed = {}
+        self.holds = CourseHolds()
+        self._holding = set()
+        self._orders_due_until = 0.0
+        self._orders_submitted = 0
+        self._in_flight = None
+        self._revoked.clear()
+        self._cancelled = set()
+        self.tickets = {}
+        self.held_ingredients.clear()
+        self.guests = None
+        self.guest_requests = []
+        self.pacing = None
+        self.pacing_plan = None
+        self.expo = None
+        self._ticket_seconds = {}
+        self._order_completions = []
+        self.delays = []
+        self.order_notes = []
+        self.front_of_house.reset_counts()
         
         # Reset agent states
         for agent in self.agents.values():
-            agent.message_queue.clear()
-            agent.sent_messages.clear()
-            agent.task_history.clear()
+            agent.clear_state()
             agent.authority_compliance = 1.0
             agent.collaboration_score = 0.0
     
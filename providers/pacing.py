@@ -0,0 +1,211 @@
+"""
+Meal Pacing for ChefBench
+Sequences a run's orders across stations, batching identical items and firing long cooks first, to cut ticket times
+"""
+
+import re
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Tuple, Any
+
+from models.models import TaskType
+from equipment import TASK_EQUIPMENT, station_for
+from dining import ORDER_TASKS
+
+# A follow-on item in a batch skips this share of its time: the station is already set up for it
+BATCH_SAVINGS = 0.25
+
+# Ovens cook unattended, so orders needing one fire first and finish while the rest of the line works
+LONG_COOK_EQUIPMENT = ("oven",)
+
+# Planning estimate when nothing better is known, as the fallback heuristics estimate
+SECONDS_PER_ROLE_LEVEL = 30
+
+QueueItem = Tuple[str, TaskType, Dict[str, Any]]
+
+
+def batch_key(task_type: TaskType, context: Dict[str, Any]) -> Tuple[str, str]:
+    """Orders with the same key are the same item and can be cooked as one batch"""
+    dish = context.get('dish') or ",".join(sorted(context.get('ingredients', [])))
+    return task_type.function_name, dish
+
+
+def is_long_cook(task_type: TaskType) -> bool:
+    return any(kind in LONG_COOK_EQUIPMENT for kind in TASK_EQUIPMENT.get(task_type, []))
+
+
+def estimate_seconds(task_type: TaskType) -> float:
+    return float(SECONDS_PER_ROLE_LEVEL * task_type.min_role_level)
+
+
+def estimate_tickets(items: List[QueueItem], batch_savings: float = BATCH_SAVINGS) -> Dict[str, float]:
+    """When each order would be up if fired in this sequence, with the stations working in parallel"""
+    lanes: Dict[str, float] = {}
+    last_batch: Dict[str, Tuple[str, str]] = {}
+    finished = {}
+    for _, task_type, context in items:
+        station = station_for(task_type)
+        seconds = estimate_seconds(task_type)
+        key = batch_key(task_type, context)
+        if last_batch.get(station) == key:
+            seconds *= 1 - batch_savings
+        lanes[station] = lanes.get(station, 0.0) + seconds
+        last_batch[station] = key
+        finished[context['task_id']] = lanes[station]
+    return finished
+
+
+@dataclass
+class PacingPlan:
+    """The firing order chosen for a run's orders, and what it is expected to save"""
+    sequence: List[str]  # order task ids, in firing order
+    baseline: List[str]  # the same orders as assigned
+    batches: List[List[str]]  # identical items fired back to back at one station
+    long_cooks: List[str]
+    baseline_ticket_seconds: float
+    planned_ticket_seconds: float
+    decision: str = "planned"  # planned with no one to review it, or accepted or modified by the head chef
+    reviewer: Optional[str] = None
+    fire_first: List[str] = field(default_factory=list)  # orders the reviewer moved to the front
+
+    @property
+    def efficiency(self) -> float:
+        """Share of the average ticket time the plan saves over firing orders as assigned"""
+        if not self.baseline_ticket_seconds:
+            return 0.0
+        return round(1 - self.planned_ticket_seconds / self.baseline_ticket_seconds, 4)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "sequence": self.sequence,
+            "baseline": self.baseline,
+            "batches": self.batches,
+            "long_cooks": self.long_cooks,
+            "baseline_ticket_seconds": self.baseline_ticket_seconds,
+            "planned_ticket_seconds": self.planned_ticket_seconds,
+            "efficiency": self.efficiency,
+            "decision": self.decision,
+            "reviewer": self.reviewer,
+            "fire_first": self.fire_first
+        }
+
+
+class MealPlanner:
+    """Plans the firing order of every order in a run at once, instead of one order at a time
+
+    Only orders move, and only among the queue positions orders already hold, so
+    prep and management tasks keep their place. At each station, long cooks fire
+    first and identical items go back to back; after the long cooks, smaller
+    batches go before bigger ones so more tickets finish early. The stations'
+    sequences are then interleaved so every station starts working straight away.
+    """
+
+    def __init__(self, batch_savings: float = BATCH_SAVINGS):
+        self.batch_savings = batch_savings
+
+    def plan(self, items: List[QueueItem]) -> Tuple[List[QueueItem], PacingPlan]:
+        slots = [i for i, (_, task_type, _) in enumerate(items) if task_type.function_name in ORDER_TASKS]
+        orders = [items[i] for i in slots]
+
+        # Station -> batches of identical items, in the order each batch first appears
+        stations: Dict[str, Dict[Tuple[str, str], List[QueueItem]]] = {}
+        for item in orders:
+            _, task_type, context = item
+            stations.setdefault(station_for(task_type), {}).setdefault(batch_key(task_type, context), []).append(item)
+
+        def batch_seconds(batch: List[QueueItem]) -> float:
+            return sum(estimate_seconds(task_type) for _, task_type, _ in batch)
+
+        def long_cook(batch: List[QueueItem]) -> bool:
+            return is_long_cook(batch[0][1])
+
+        # Long cooks longest first, then everything else shortest first
+        lanes = {
+            station: sorted(
+                batches.values(),
+                key=lambda b: (not long_cook(b), -batch_seconds(b) if long_cook(b) else batch_seconds(b))
+            )
+            for station, batches in stations.items()
+        }
+        station_order = sorted(
+            lanes,
+            key=lambda s: (not any(long_cook(b) for b in lanes[s]), -sum(batch_seconds(b) for b in lanes[s]))
+        )
+
+        sequence: List[QueueItem] = []
+        batches: List[List[str]] = []
+        while any(lanes.values()):
+            for station in station_order:
+                if lanes[station]:
+                    batch = lanes[station].pop(0)
+                    sequence.extend(batch)
+                    if len(batch) > 1:
+                        batches.append([context['task_id'] for _, _, context in batch])
+
+        planned = self._fill(items, slots, sequence)
+        plan = PacingPlan(
+            sequence=[context['task_id'] for _, _, context in sequence],
+            baseline=[context['task_id'] for _, _, context in orders],
+            batches=batches,
+            long_cooks=[
+                context['task_id'] for _, task_type, context in sequence
+                if is_long_cook(task_type)
+            ],
+            baseline_ticket_seconds=self.average_ticket(orders),
+            planned_ticket_seconds=self.average_ticket(sequence)
+        )
+        return planned, plan
+
+    def average_ticket(self, orders: List[QueueItem]) -> float:
+        tickets = estimate_tickets(orders, self.batch_savings)
+        return round(sum(tickets.values()) / len(tickets), 2) if tickets else 0.0
+
+    def review_question(self, items: List[QueueItem], plan: PacingPlan) -> str:
+        """What the head chef is asked about the plan"""
+        by_id = {context['task_id']: (task_type, context) for _, task_type, context in items}
+        fired = ", ".join(
+            f"{task_id} ({by_id[task_id][0].function_name} at {station_for(by_id[task_id][0])})"
+            for task_id in plan.sequence
+        )
+        return (
+            f"The planned firing order for this service is: {fired}. "
+            f"It batches {len(plan.batches)} sets of identical items and should bring the average ticket "
+            f"from {plan.baseline_ticket_seconds:.0f}s to {plan.planned_ticket_seconds:.0f}s. "
+            "Answer 'accept', or list the task ids of orders to fire first."
+        )
+
+    def review(
+        self,
+        items: List[QueueItem],
+        planned: List[QueueItem],
+        plan: PacingPlan,
+        reviewer: str,
+        answer: str
+    ) -> List[QueueItem]:
+        """Apply the head chef's answer: task ids it names fire first, in the order named"""
+        plan.reviewer = reviewer
+        mentioned = sorted(
+            (match.start(), task_id)
+            for task_id in plan.sequence
+            for match in [re.search(rf"(?<![\w-]){re.escape(task_id)}(?![\w-])", answer)]
+            if match
+        )
+        plan.fire_first = [task_id for _, task_id in mentioned]
+        if not plan.fire_first:
+            plan.decision = "accepted"
+            return planned
+
+        plan.decision = "modified"
+        first = set(plan.fire_first)
+        plan.sequence = plan.fire_first + [task_id for task_id in plan.sequence if task_id not in first]
+        by_id = {item[2]['task_id']: item for item in planned}
+        sequence = [by_id[task_id] for task_id in plan.sequence]
+        plan.planned_ticket_seconds = self.average_ticket(sequence)
+        slots = [i for i, (_, task_type, _) in enumerate(items) if task_type.function_name in ORDER_TASKS]
+        return self._fill(items, slots, sequence)
+
+    @staticmethod
+    def _fill(items: List[QueueItem], slots: List[int], sequence: List[QueueItem]) -> List[QueueItem]:
+        planned = list(items)
+        for slot, item in zip(slots, sequence):
+            planned[slot] = item
+        return planned
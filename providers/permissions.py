@@ -0,0 +1,101 @@
+"""
+Permission Enforcement for ChefBench
+Central checks that every task dispatch and delegation respects the kitchen hierarchy
+"""
+
+from dataclasses import dataclass, asdict
+from typing import Dict, Iterable, List, Optional, Any
+
+from models.models import LLMAgent, TaskType
+from recipes.techniques import supports
+
+
+@dataclass
+class PermissionViolation:
+    """An action an agent attempted outside its role"""
+    agent_name: str
+    action: str  # "task" or "delegation"
+    task_type: str
+    reason: str
+    target: Optional[str] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class PermissionGuard:
+    """Validate dispatches against agents' permitted tasks and tally role coherence"""
+
+    def __init__(self):
+        self.checks = 0
+        self.violations: List[PermissionViolation] = []
+
+    @staticmethod
+    def can_perform(agent: LLMAgent, task_type: TaskType, techniques: Iterable[str] = ()) -> bool:
+        """The agent's role permits the task, and its role and certifications every cooking technique the dish takes"""
+        return task_type in agent.available_tasks and supports(
+            agent.role_level, agent.skills.certifications, techniques
+        )
+
+    @staticmethod
+    def _refusal(agent: LLMAgent, task_type: TaskType, techniques: Iterable[str]) -> str:
+        if task_type not in agent.available_tasks:
+            return f"may not perform {task_type.function_name}"
+        return f"may not {task_type.function_name} by {', '.join(techniques)}"
+
+    def check_task(
+        self,
+        agent: LLMAgent,
+        task_type: TaskType,
+        techniques: Iterable[str] = ()
+    ) -> Optional[PermissionViolation]:
+        """An agent may only execute tasks, and cook by techniques, its role permits"""
+        self.checks += 1
+        techniques = list(techniques)
+        if self.can_perform(agent, task_type, techniques):
+            return None
+        return self._violation(PermissionViolation(
+            agent.name, "task", task_type.function_name,
+            f"{agent.role.name} {self._refusal(agent, task_type, techniques)}"
+        ))
+
+    def check_delegation(
+        self,
+        sender: LLMAgent,
+        recipient: LLMAgent,
+        task_type: TaskType,
+        techniques: Iterable[str] = ()
+    ) -> Optional[PermissionViolation]:
+        """Work may only be handed to an agent whose role permits it"""
+        self.checks += 1
+        techniques = list(techniques)
+        if self.can_perform(recipient, task_type, techniques):
+            return None
+        return self._violation(PermissionViolation(
+            sender.name, "delegation", task_type.function_name,
+            f"{recipient.role.name} {recipient.name} {self._refusal(recipient, task_type, techniques)}",
+            target=recipient.name
+        ))
+
+    def _violation(self, violation: PermissionViolation) -> PermissionViolation:
+        self.violations.append(violation)
+        return violation
+
+    def role_coherence(self) -> float:
+        """Share of checked actions that stayed within role"""
+        return 1.0 - len(self.violations) / self.checks if self.checks else 1.0
+
+    def reset(self):
+        self.checks = 0
+        self.violations.clear()
+
+    def summary(self) -> Dict[str, Any]:
+        by_agent: Dict[str, int] = {}
+        for violation in self.violations:
+            by_agent[violation.agent_name] = by_agent.get(violation.agent_name, 0) + 1
+        return {
+            "checks": self.checks,
+            "role_coherence": self.role_coherence(),
+            "violations_by_agent": by_agent,
+            "violations": [v.to_dict() for v in self.violations]
+        }
@@ -0,0 +1,72 @@
+"""
+Task Assignment Policies for ChefBench
+Decide which capable agent receives each task during scenario execution
+"""
+
+from typing import Callable, Dict, List, Tuple, Any
+
+from models.models import LLMAgent, TaskType, at_role_level
+
+# Score multiplier for an agent missing a certification the task calls for
+UNCERTIFIED_PENALTY = 0.5
+
+# (task_type, capable agents sorted by rank descending, assignments so far) -> agent name
+AssignmentPolicy = Callable[
+    [TaskType, List[Tuple[str, LLMAgent]], Dict[str, List[Tuple[TaskType, Dict[str, Any]]]]],
+    str
+]
+
+
+def assignment_score(task_type: TaskType, agent: LLMAgent, load: int) -> float:
+    """Skill at the task, discounted for a missing certification and for tasks already assigned"""
+    certified = 1.0 if agent.skills.certified_for(task_type) else UNCERTIFIED_PENALTY
+    return agent.skill(task_type) * certified / (1 + load)
+
+
+def _best_scored(task_type, candidates, assignments) -> str:
+    """Highest assignment score, seniority breaking ties"""
+    return max(candidates, key=lambda c: assignment_score(task_type, c[1], len(assignments.get(c[0], []))))[0]
+
+
+def highest_rank(task_type, candidates, assignments) -> str:
+    """Assign to the most senior agent able to perform the task"""
+    return candidates[0][0]
+
+
+def lowest_capable(task_type, candidates, assignments) -> str:
+    """Assign to the most junior agent able to perform the task"""
+    return candidates[-1][0]
+
+
+def least_loaded(task_type, candidates, assignments) -> str:
+    """Assign to the capable agent with the fewest tasks, seniority breaking ties"""
+    return min(candidates, key=lambda c: len(assignments.get(c[0], [])))[0]
+
+
+def role_match(task_type, candidates, assignments) -> str:
+    """Prefer agents whose role level equals the task's minimum level"""
+    exact = [c for c in candidates if at_role_level(task_type, c[1].role)]
+    return _best_scored(task_type, exact or candidates, assignments)
+
+
+def skill_match(task_type, candidates, assignments) -> str:
+    """Best assignment score across every capable agent, so trainees get work as they improve"""
+    return _best_scored(task_type, candidates, assignments)
+
+
+ASSIGNMENT_POLICIES: Dict[str, AssignmentPolicy] = {
+    "highest_rank": highest_rank,
+    "lowest_capable": lowest_capable,
+    "least_loaded": least_loaded,
+    "role_match": role_match,
+    "skill_match": skill_match,
+}
+
+
+def get_assignment_policy(name: str) -> AssignmentPolicy:
+    """Look up a registered assignment policy by name"""
+    if name not in ASSIGNMENT_POLICIES:
+        raise ValueError(
+            f"Unknown assignment policy '{name}', expected one of {sorted(ASSIGNMENT_POLICIES)}"
+        )
+    return ASSIGNMENT_POLICIES[name]
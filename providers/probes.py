@@ -0,0 +1,106 @@
+"""
+Memory Consistency Probes for ChefBench
+Asks agents mid-run about facts whose answers are known from the run history
+"""
+
+import json
+import time
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Any
+
+from models.models import LLMAgent, AgentRole
+
+
+@dataclass
+class MemoryProbe:
+    """A recall question with a known correct answer"""
+    agent_name: str
+    kind: str
+    question: str
+    expected: str
+    answer: str = ""
+    correct: bool = False
+    timestamp: float = field(default_factory=time.time)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "agent_name": self.agent_name,
+            "kind": self.kind,
+            "question": self.question,
+            "expected": self.expected,
+            "answer": self.answer,
+            "correct": self.correct,
+            "timestamp": self.timestamp
+        }
+
+
+def build_probes(agent: LLMAgent) -> List[MemoryProbe]:
+    """Build every probe whose answer can be derived from the agent's history"""
+    probes = []
+    history = agent.history()
+
+    if history:
+        probes.append(MemoryProbe(
+            agent.name, "first_task",
+            "What was the first task you executed in this scenario?",
+            history[0].task_type.function_name
+        ))
+        probes.append(MemoryProbe(
+            agent.name, "last_task",
+            "Which task did you execute most recently?",
+            history[-1].task_type.function_name
+        ))
+
+    messages = agent.messages()
+    if messages:
+        probes.append(MemoryProbe(
+            agent.name, "last_sender",
+            "Who sent you the most recent message?",
+            messages[-1].sender
+        ))
+
+    flagged = [
+        m for m in messages
+        if m.role == AgentRole.HEAD_CHEF and m.content.startswith("Quality issue with")
+    ]
+    if flagged:
+        probes.append(MemoryProbe(
+            agent.name, "quality_flag",
+            "Which task did the head chef most recently flag for a quality issue?",
+            flagged[-1].content[len("Quality issue with "):].split(".")[0].strip()
+        ))
+
+    return probes
+
+
+def ask_probe(agent: LLMAgent, probe: MemoryProbe) -> MemoryProbe:
+    """Ask the agent a probe question and score its answer"""
+    response = agent.answer_question(probe.question)
+
+    try:
+        answer = str(json.loads(response).get("answer", ""))
+    except (json.JSONDecodeError, AttributeError):
+        answer = response
+
+    probe.answer = answer
+    probe.correct = probe.expected.lower() in answer.lower()
+    return probe
+
+
+def summarize_probes(probes: List[MemoryProbe]) -> Dict[str, Any]:
+    """Recall accuracy overall, per agent and per probe kind"""
+    def accuracy(items: List[MemoryProbe]) -> float:
+        return sum(p.correct for p in items) / len(items) if items else 0.0
+
+    by_agent: Dict[str, List[MemoryProbe]] = {}
+    by_kind: Dict[str, List[MemoryProbe]] = {}
+    for probe in probes:
+        by_agent.setdefault(probe.agent_name, []).append(probe)
+        by_kind.setdefault(probe.kind, []).append(probe)
+
+    return {
+        "total_probes": len(probes),
+        "recall_accuracy": accuracy(probes),
+        "by_agent": {name: accuracy(items) for name, items in by_agent.items()},
+        "by_kind": {kind: accuracy(items) for kind, items in by_kind.items()}
+    }
@@ -0,0 +1,141 @@
+"""
+Task Error Recovery for ChefBench
+Classifies errors raised while agents work tasks, retries the ones worth retrying and tracks each agent's error budget
+"""
+
+import errno
+import os
+from dataclasses import dataclass, asdict
+from typing import Dict, List, Any
+import logging
+
+from models.models import GenerationError
+
+logger = logging.getLogger(__name__)
+
+TRANSIENT = "transient"    # timeouts and dropped connections, likely to clear up
+PERMANENT = "permanent"    # bugs and bad input, failing the same way every time
+PERMISSION = "permission"  # refused by the filesystem or a provider
+RESOURCE = "resource"      # out of memory, disk or file handles
+
+ERROR_CLASSES = (TRANSIENT, PERMANENT, PERMISSION, RESOURCE)
+
+# How a task attempt ended after an error: tried again, decided by fallback heuristics, or given up
+RETRIED = "retried"
+FALLBACK = "fallback"
+FAILED = "failed"
+
+DEFAULT_ERROR_BUDGET = 5
+
+RESOURCE_ERRNOS = (errno.ENOMEM, errno.ENOSPC, errno.EMFILE, errno.ENFILE)
+
+
+@dataclass(frozen=True)
+class RetryPolicy:
+    """How many times a task is tried again after an error of one class, with exponential backoff"""
+    retries: int
+    backoff_seconds: float = 0.0
+
+    def delay(self, attempt: int) -> float:
+        return self.backoff_seconds * 2 ** attempt
+
+
+RETRY_POLICIES: Dict[str, RetryPolicy] = {
+    TRANSIENT: RetryPolicy(2, 0.5),
+    RESOURCE: RetryPolicy(1, 2.0),  # once, after giving memory a moment to free up
+    PERMISSION: RetryPolicy(0),
+    PERMANENT: RetryPolicy(0),
+}
+
+
+def classify_error(error: BaseException) -> str:
+    """The class of an error raised while working a task; anything unrecognized is permanent"""
+    if isinstance(error, PermissionError):
+        return PERMISSION
+    if isinstance(error, MemoryError) or "out of memory" in str(error).lower():
+        return RESOURCE
+    if isinstance(error, (GenerationError, TimeoutError, ConnectionError)):
+        return TRANSIENT
+    if isinstance(error, OSError) and error.errno in RESOURCE_ERRNOS:
+        return RESOURCE
+    return PERMANENT
+
+
+@dataclass
+class TaskError:
+    """One error an agent ran into on a task"""
+    agent_name: str
+    task_id: str
+    task_type: str
+    error_class: str
+    error: str
+    attempt: int  # 0 for the first try
+    outcome: str  # retried, fallback or failed
+    simulated_time: float
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class ErrorBudget:
+    """Errors each agent ran into during one run, against a per-agent budget
+
+    Every error spends budget, including ones a retry or the fallback
+    heuristics got past, so an agent that only gets through its tasks on
+    retries still reads as unreliable. Once an agent's budget is spent its
+    errors are no longer retried.
+    """
+
+    def __init__(self, budget: int = DEFAULT_ERROR_BUDGET):
+        if budget < 1:
+            raise ValueError("Error budget must be at least 1")
+        self.budget = budget
+        self.errors: List[TaskError] = []
+
+    @classmethod
+    def from_env(cls) -> "ErrorBudget":
+        """The default budget, or CHEFBENCH_ERROR_BUDGET errors per agent"""
+        raw = os.environ.get("CHEFBENCH_ERROR_BUDGET")
+        if not raw:
+            return cls()
+        try:
+            return cls(int(raw))
+        except ValueError as e:
+            logger.error(f"Ignoring invalid CHEFBENCH_ERROR_BUDGET: {e}")
+            return cls()
+
+    def record(self, error: TaskError):
+        self.errors.append(error)
+
+    def spent(self, agent_name: str) -> int:
+        return sum(1 for e in self.errors if e.agent_name == agent_name)
+
+    def exhausted(self, agent_name: str) -> bool:
+        return self.spent(agent_name) >= self.budget
+
+    def reliability(self, agent_name: str) -> float:
+        """1 for an agent without errors, down to 0 once its budget is spent"""
+        return max(0.0, 1.0 - self.spent(agent_name) / self.budget)
+
+    def summary(self, agent_names: List[str]) -> Dict[str, Any]:
+        by_agent = {}
+        for name in agent_names:
+            errors = [e for e in self.errors if e.agent_name == name]
+            by_agent[name] = {
+                "errors": len(errors),
+                "by_class": {c: sum(1 for e in errors if e.error_class == c) for c in ERROR_CLASSES},
+                "failed_tasks": sum(1 for e in errors if e.outcome == FAILED),
+                "remaining": max(0, self.budget - len(errors)),
+                "exhausted": len(errors) >= self.budget,
+                "reliability": self.reliability(name)
+            }
+        return {
+            "budget": self.budget,
+            "errors": len(self.errors),
+            "by_class": {c: sum(1 for e in self.errors if e.error_class == c) for c in ERROR_CLASSES},
+            "reliability": (
+                sum(a["reliability"] for a in by_agent.values()) / len(by_agent) if by_agent else 1.0
+            ),
+            "by_agent": by_agent,
+            "task_errors": [e.to_dict() for e in self.errors]
+        }
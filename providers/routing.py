@@ -0,0 +1,82 @@
+"""
+Model Routing for ChefBench
+Which model each kitchen role runs on, so a team can pair strong models with senior roles
+"""
+
+import os
+from dataclasses import dataclass, field, asdict
+from typing import Any, Dict, Iterable, Optional, Union
+import logging
+
+from models.models import AgentRole
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_CONFIG_PATH = "configs/config.yaml"
+CONFIG_SECTION = "model_routing"
+
+
+@dataclass
+class ModelRouting:
+    """Models by role name, e.g. {"HEAD_CHEF": "gpt-4", "LINE_COOK": "llama3.2:1b"}, and one for the rest"""
+    roles: Dict[str, str] = field(default_factory=dict)
+    default: Optional[str] = None  # model for roles not listed; without one they can't be routed
+
+    def validate(self):
+        unknown = sorted(set(self.roles) - {r.name for r in AgentRole})
+        if unknown:
+            raise ValueError(f"Unknown roles {unknown}, expected some of {[r.name for r in AgentRole]}")
+        for role, model in self.roles.items():
+            if not isinstance(model, str) or not model.strip():
+                raise ValueError(f"{role} needs a model name")
+        if self.default is not None and not self.default.strip():
+            raise ValueError("default must be a model name")
+
+    def model_for(self, role: AgentRole, default: Optional[str] = None) -> str:
+        """The role's model: its own, else the given default, else the routing's"""
+        model = self.roles.get(role.name) or default or self.default
+        if not model:
+            raise ValueError(f"No model is routed for {role.name} and there is no default")
+        return model
+
+    @classmethod
+    def from_config(cls, path: Optional[str] = None) -> "ModelRouting":
+        """The model_routing section of the config file, CHEFBENCH_CONFIG or configs/config.yaml"""
+        path = path or os.environ.get("CHEFBENCH_CONFIG", DEFAULT_CONFIG_PATH)
+        if not os.path.exists(path):
+            return cls()
+        try:
+            import yaml
+        except ImportError:
+            logger.warning(f"PyYAML is not installed; no model routing is read from {path}")
+            return cls()
+        with open(path, 'r', encoding='utf-8') as f:
+            try:
+                section = (yaml.safe_load(f) or {}).get(CONFIG_SECTION) or {}
+            except yaml.YAMLError as e:
+                logger.error(f"Ignoring model routing in {path}: {e}")
+                return cls()
+
+        try:
+            routing = cls(roles=dict(section.get("roles") or {}), default=section.get("default"))
+            routing.validate()
+        except (ValueError, TypeError, AttributeError) as e:
+            logger.error(f"Ignoring model routing in {path}: {e}")
+            return cls()
+        if routing.roles:
+            logger.info(f"Routing {len(routing.roles)} roles to their own models from {path}")
+        return routing
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def role_models(agents: Iterable[Any]) -> Dict[str, Union[str, list]]:
+    """The model each role on a team runs, or the sorted models when its agents differ"""
+    models: Dict[str, set] = {}
+    for agent in agents:
+        models.setdefault(agent.role.name, set()).add(agent.model_name)
+    return {
+        role: next(iter(names)) if len(names) == 1 else sorted(names)
+        for role, names in sorted(models.items(), key=lambda item: -AgentRole[item[0]].value)
+    }
@@ -0,0 +1,238 @@
+"""
+Quality Rubrics for ChefBench
+Weighted quality categories with tolerances, each scored by a pluggable grader
+"""
+
+import json
+import re
+from dataclasses import dataclass, field, asdict
+from typing import Callable, Dict, List, Optional, Tuple, Union, Any
+
+from models.models import AgentResponse, AgentRole, TaskType, at_role_level
+from .judge import LLMJudge
+
+
+@dataclass
+class Submission:
+    """What a grader sees: the task, the agent's structured response and the task context"""
+    task_type: TaskType
+    response: AgentResponse
+    context: Dict[str, Any]
+    role: AgentRole
+    judge: Optional[LLMJudge] = None
+
+    def describe(self) -> str:
+        return (
+            f"Task: {self.task_type.function_name}\n"
+            f"Reasoning: {self.response.reasoning}\n"
+            f"Action: {self.response.action}\n"
+            f"Parameters: {json.dumps(self.response.parameters, default=str)}"
+        )
+
+
+@dataclass
+class RubricCategory:
+    """One aspect of quality, its weight, and how close to target counts as right"""
+    name: str
+    grader: str
+    weight: float = 1.0
+    target: Optional[float] = None
+    tolerance: float = 0.0
+    criteria: str = ""  # what the judge grader looks for
+    task_types: List[str] = field(default_factory=list)  # empty applies to every task
+
+    def applies_to(self, task_type: TaskType) -> bool:
+        return not self.task_types or task_type.function_name in self.task_types
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+# A grader returns a score in [0, 1], or None when there is nothing to grade
+Grader = Callable[[RubricCategory, Submission], Optional[float]]
+
+
+def _within(value: float, target: float, tolerance: float) -> float:
+    """1 inside the tolerance band, falling linearly to 0 one band-width beyond it"""
+    deviation = abs(value - target)
+    if deviation <= tolerance:
+        return 1.0
+    band = tolerance or max(abs(target) * 0.1, 1.0)
+    return max(0.0, 1.0 - (deviation - tolerance) / band)
+
+
+def _reported(parameters: Dict[str, Any], keys: Tuple[str, ...]) -> Optional[float]:
+    """First number in a response parameter whose name contains one of the keys"""
+    for name, value in parameters.items():
+        if any(key in name.lower() for key in keys):
+            match = re.search(r"-?\d+(?:\.\d+)?", str(value))
+            if match:
+                return float(match.group())
+    return None
+
+
+def grade_confidence(category: RubricCategory, submission: Submission) -> Optional[float]:
+    """The agent's own confidence, discounted for tasks below its role level"""
+    role_match = 1.0 if at_role_level(submission.task_type, submission.role) else 0.8
+    return submission.response.confidence * role_match
+
+
+def grade_temperature(category: RubricCategory, submission: Submission) -> Optional[float]:
+    """Reported temperature against the task's target_temperature, else the rubric's"""
+    value = _reported(submission.response.parameters, ("temp",))
+    target = submission.context.get("target_temperature", category.target)
+    if value is None or target is None:
+        return None
+    return _within(value, target, category.tolerance)
+
+
+def grade_portion(category: RubricCategory, submission: Submission) -> Optional[float]:
+    """Reported portion against the task's target_portion, else the rubric's; tolerance is a fraction"""
+    value = _reported(submission.response.parameters, ("portion", "serving", "weight"))
+    target = submission.context.get("target_portion", category.target)
+    if value is None or target is None:
+        return None
+    return _within(value, target, category.tolerance * abs(target))
+
+
+def grade_timing(category: RubricCategory, submission: Submission) -> Optional[float]:
+    """Estimated time against the task's time limit, allowing tolerance as a fraction over it"""
+    limit = submission.context.get("time_limit")
+    if not isinstance(limit, (int, float)) or limit <= 0:
+        return None
+    over = submission.response.estimated_time - limit * (1 + category.tolerance)
+    return 1.0 if over <= 0 else max(0.0, 1.0 - over / limit)
+
+
+def grade_judge(category: RubricCategory, submission: Submission) -> Optional[float]:
+    """Ask the judge model to score the response against the category's criteria"""
+    if submission.judge is None:
+        return None
+    criteria = category.criteria or f"Quality of the {category.name}"
+    return submission.judge.score(criteria, submission.describe())
+
+
+GRADERS: Dict[str, Grader] = {
+    "confidence": grade_confidence,
+    "temperature": grade_temperature,
+    "portion": grade_portion,
+    "timing": grade_timing,
+    "judge": grade_judge,
+}
+
+
+@dataclass
+class QualityRubric:
+    """What quality means for a benchmark: a weighted set of graded categories"""
+    name: str
+    categories: List[RubricCategory]
+    description: str = ""
+    judge_model: Optional[str] = None  # defaults to DEFAULT_JUDGE_MODEL when a category uses the judge
+
+    def __post_init__(self):
+        if not self.categories:
+            raise ValueError(f"Rubric '{self.name}' has no categories")
+        for category in self.categories:
+            if category.grader not in GRADERS:
+                raise ValueError(f"Unknown grader '{category.grader}', expected one of {sorted(GRADERS)}")
+            if category.weight < 0 or category.tolerance < 0:
+                raise ValueError(f"Category '{category.name}' has a negative weight or tolerance")
+
+    @property
+    def uses_judge(self) -> bool:
+        return any(c.grader == "judge" for c in self.categories)
+
+    def grade(self, submission: Submission) -> Tuple[Optional[float], Dict[str, float]]:
+        """Weighted mean of the categories that apply and could be graded, with each category's score
+
+        The overall score is None when no category produced one, leaving the
+        task's quality as it was.
+        """
+        scores: Dict[str, float] = {}
+        weights: Dict[str, float] = {}
+        for category in self.categories:
+            if not category.applies_to(submission.task_type):
+                continue
+            score = GRADERS[category.grader](category, submission)
+            if score is not None:
+                scores[category.name] = round(min(1.0, max(0.0, score)), 4)
+                weights[category.name] = category.weight
+
+        total = sum(weights.values())
+        if not total:
+            return None, scores
+        return sum(scores[name] * weight for name, weight in weights.items()) / total, scores
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "name": self.name,
+            "description": self.description,
+            "judge_model": self.judge_model,
+            "categories": [c.to_dict() for c in self.categories]
+        }
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "QualityRubric":
+        try:
+            categories = [RubricCategory(**c) for c in data.get("categories", [])]
+            return cls(
+                name=data.get("name", "custom"),
+                categories=categories,
+                description=data.get("description", ""),
+                judge_model=data.get("judge_model")
+            )
+        except TypeError as e:
+            raise ValueError(f"Invalid rubric: {e}")
+
+
+QUALITY_RUBRICS: Dict[str, QualityRubric] = {
+    rubric.name: rubric for rubric in (
+        QualityRubric(
+            "default",
+            [RubricCategory("confidence", "confidence")],
+            "The agent's confidence, discounted off its role level"
+        ),
+        QualityRubric(
+            "line_check",
+            [
+                RubricCategory("confidence", "confidence"),
+                RubricCategory(
+                    "temperature", "temperature", weight=2.0, target=74.0, tolerance=2.0,
+                    task_types=["cooking_execution", "temperature_monitoring", "sauce_preparation"]
+                ),
+                RubricCategory(
+                    "portion", "portion", weight=1.0, tolerance=0.1,
+                    task_types=["cooking_execution", "plating_design", "ingredient_preparation"]
+                ),
+                RubricCategory("timing", "timing", weight=1.0, tolerance=0.1),
+            ],
+            "Hot-holding temperature (74C +/- 2), portion size and timing checks"
+        ),
+        QualityRubric(
+            "tasting_panel",
+            [
+                RubricCategory("confidence", "confidence", weight=0.5),
+                RubricCategory(
+                    "presentation", "judge", weight=1.0,
+                    criteria="Plating and presentation: composition, color, garnish and cleanliness of the plate",
+                    task_types=["plating_design", "cooking_execution", "quality_control"]
+                ),
+                RubricCategory(
+                    "taste", "judge", weight=1.5,
+                    criteria="Taste as described: seasoning, balance, texture and doneness",
+                    task_types=["cooking_execution", "sauce_preparation", "basic_cooking", "quality_control"]
+                ),
+            ],
+            "A judge model scores plating and taste descriptions"
+        ),
+    )
+}
+
+
+def get_quality_rubric(spec: Union[str, Dict[str, Any]]) -> QualityRubric:
+    """Look up a built-in rubric by name, or build one from its definition"""
+    if isinstance(spec, dict):
+        return QualityRubric.from_dict(spec)
+    if spec not in QUALITY_RUBRICS:
+        raise ValueError(f"Unknown quality rubric '{spec}', expected one of {sorted(QUALITY_RUBRICS)}")
+    return QUALITY_RUBRICS[spec]
@@ -6,21 +6,41 @@ Loads and processes Kaggle recipe dataset for scenario generation
 import json
 import pandas as pd
 import numpy as np
-from typing import Dict, List, Optional, Tuple, Any
+from typing import Dict, List, Optional, Tuple, Any, TypedDict, NotRequired
 from pathlib import Path
 import logging
 import random
 from collections import Counter
 
+from .substitutions import SubstitutionKnowledgeBase
+from .ingredients import IngredientCatalog
+
 logger = logging.getLogger(__name__)
 
 
+class Recipe(TypedDict):
+    """A recipe as loaded from the dataset"""
+    id: int
+    cuisine: str
+    ingredients: List[str]
+    techniques: NotRequired[List[str]]  # registry techniques its steps call for, when known
+
+
 class RecipeDatasetParser:
     """Parse and manage Kaggle recipe dataset"""
     
-    def __init__(self, data_path: str = "data"):
+    def __init__(
+        self,
+        data_path: str = "data",
+        substitutions: Optional[SubstitutionKnowledgeBase] = None,
+        seed: Optional[int] = None,
+        catalog: Optional[IngredientCatalog] = None
+    ):
         self.data_path = Path(data_path)
-        self.recipes: List[Dict[str, str]] = []
+        self.rng = random.Random(seed)
+        self.substitutions = substitutions or SubstitutionKnowledgeBase()
+        self.catalog = catalog or IngredientCatalog()
+        self.recipes: List[Recipe] = []
         self.ingredients: Dict[str, int] = {}  # ingredient -> frequency
         self.cuisines: List[str] = []
         self.loaded = False
@@ -71,6 +91,26 @@ class RecipeDatasetParser:
             logger.error(f"Failed to load dataset: {e}")
             return False
     
+    def add_recipes(self, recipes: List[Recipe]) -> List[Recipe]:
+        """Add recipes from another source, giving each a fresh id"""
+        next_id = max((r['id'] for r in self.recipes if isinstance(r['id'], int)), default=-1) + 1
+        added = []
+        for offset, recipe in enumerate(recipes):
+            recipe = {**recipe, 'id': next_id + offset}
+            self.recipes.append(recipe)
+            added.append(recipe)
+            for ingredient in recipe['ingredients']:
+                self.ingredients[ingredient.lower()] = self.ingredients.get(ingredient.lower(), 0) + 1
+
+        self.cuisines = sorted(set(self.cuisines) | {r['cuisine'] for r in added})
+        self.loaded = bool(self.recipes)
+        logger.info(f"Added {len(added)} recipes, {len(self.recipes)} total")
+        return added
+
+    def reseed(self, seed: Optional[int]):
+        """Reset the parser's random stream so sampling is reproducible"""
+        self.rng.seed(seed)
+    
     def get_random_ingredients(self, count: int = 10, min_frequency: int = 5) -> List[str]:
         """Get random ingredients above minimum frequency threshold"""
         if not self.loaded:
@@ -86,7 +126,7 @@ class RecipeDatasetParser:
         if len(common_ingredients) < count:
             return common_ingredients
         
-        return random.sample(common_ingredients, count)
+        return self.rng.sample(common_ingredients, count)
     
     def get_recipe_by_ingredients(self, available_ingredients: List[str]) -> Optional[Dict]:
         """Find a recipe that can be made with available ingredients"""
@@ -103,23 +143,41 @@ class RecipeDatasetParser:
                 matching_recipes.append(recipe)
         
         if matching_recipes:
-            return random.choice(matching_recipes)
+            return self.rng.choice(matching_recipes)
         
-        # If no perfect match, find recipe with most ingredients available
+        # If no perfect match, find recipe with most ingredients covered,
+        # counting known substitutions as partial coverage
         best_match = None
         best_score = 0
         
         for recipe in self.recipes:
-            recipe_ingredients = set(ing.lower() for ing in recipe['ingredients'])
-            overlap = len(recipe_ingredients.intersection(available_set))
-            score = overlap / len(recipe_ingredients) if recipe_ingredients else 0
+            validation = self.validate_recipe(recipe, available_set)
+            required = len(recipe['ingredients'])
+            if not required:
+                continue
+            covered = required - len(validation['missing'])
+            score = (covered / required) * (1.0 - validation['quality_penalty'])
             
             if score > best_score:
                 best_score = score
-                best_match = recipe
+                best_match = {**recipe, 'substitutions': validation['substitutions']}
         
         return best_match
     
+    def validate_recipe(self, recipe: Dict, available_ingredients: List[str]) -> Dict[str, Any]:
+        """Check a recipe against available ingredients using the substitution table"""
+        plan = self.substitutions.plan_substitutions(recipe['ingredients'], available_ingredients)
+        
+        return {
+            'valid': not plan['missing'],
+            'missing': plan['missing'],
+            'substitutions': {
+                ingredient: substitution.to_dict()
+                for ingredient, substitution in plan['substitutions'].items()
+            },
+            'quality_penalty': plan['quality_penalty']
+        }
+    
     def get_recipes_by_cuisine(self, cuisine: str, count: int = 5) -> List[Dict]:
         """Get random recipes from specific cuisine"""
         if not self.loaded:
@@ -133,7 +191,7 @@ class RecipeDatasetParser:
         if len(cuisine_recipes) <= count:
             return cuisine_recipes
         
-        return random.sample(cuisine_recipes, count)
+        return self.rng.sample(cuisine_recipes, count)
     
     def generate_kitchen_inventory(self, size: str = "medium") -> Dict[str, Any]:
         """Generate a realistic kitchen inventory"""
@@ -148,7 +206,7 @@ class RecipeDatasetParser:
         }
         
         min_items, max_items = sizes.get(size, (40, 60))
-        num_ingredients = random.randint(min_items, max_items)
+        num_ingredients = self.rng.randint(min_items, max_items)
         
         # Get mix of common and uncommon ingredients
         common = self.get_random_ingredients(int(num_ingredients * 0.7), min_frequency=20)
@@ -162,22 +220,33 @@ class RecipeDatasetParser:
             # Common ingredients have higher quantities
             frequency = self.ingredients.get(ingredient.lower(), 1)
             if frequency > 50:
-                quantity = random.randint(5, 20)
+                quantity = self.rng.randint(5, 20)
             elif frequency > 20:
-                quantity = random.randint(2, 10)
+                quantity = self.rng.randint(2, 10)
             else:
-                quantity = random.randint(1, 5)
+                quantity = self.rng.randint(1, 5)
             
             inventory[ingredient] = {
                 "quantity": quantity,
                 "unit": self._get_unit(ingredient),
-                "freshness": random.uniform(0.7, 1.0)
+                "freshness": self.rng.uniform(0.7, 1.0)
             }
+            info = self.catalog.get(ingredient)
+            if info:
+                inventory[ingredient].update(
+                    cost_per_unit=info.cost_per_unit,
+                    shelf_life_days=info.shelf_life_days,
+                    allergens=info.allergens
+                )
         
         return inventory
     
     def _get_unit(self, ingredient: str) -> str:
         """Get appropriate unit for ingredient"""
+        info = self.catalog.get(ingredient)
+        if info:
+            return info.unit
+        
         ingredient_lower = ingredient.lower()
         
         if any(x in ingredient_lower for x in ['oil', 'sauce', 'milk', 'cream', 'broth', 'wine']):
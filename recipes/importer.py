@@ -0,0 +1,354 @@
+"""
+Recipe Importer for ChefBench
+Maps Schema.org Recipe JSON-LD and Paprika exports into dataset recipes
+"""
+
+import base64
+import gzip
+import io
+import json
+import re
+import zipfile
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Iterable, Tuple, Any
+
+from .dataset_parser import Recipe
+from .normalization import convert, get_normalizer, normalize_unit
+from .techniques import infer_techniques, resolve_technique, technique_equipment
+
+IMPORT_FORMATS = ("schema_org", "paprika")
+
+# Assumed length of an instruction that doesn't say how long it takes
+DEFAULT_STEP_SECONDS = 300
+
+# Instruction keywords -> equipment kinds used by the equipment simulator
+EQUIPMENT_KEYWORDS: Dict[str, List[str]] = {
+    "oven": ["oven", "bake", "roast", "broil", "preheat"],
+    "stove": ["stove", "skillet", "saucepan", "pan", "pot", "simmer", "boil", "saute", "sauté", "fry", "sear"],
+    "refrigerator": ["refrigerate", "fridge", "chill", "freeze"],
+    "mixer": ["mixer", "blender", "food processor", "knead", "whip", "beat"],
+}
+
+_UNITS = {
+    "cup", "cups", "c", "tablespoon", "tablespoons", "tbsp", "tbs", "tb", "teaspoon", "teaspoons",
+    "tsp", "ts", "g", "gram", "grams", "kg", "ml", "l", "liter", "liters", "litre", "litres",
+    "oz", "ounce", "ounces", "lb", "lbs", "pound", "pounds", "pinch", "dash", "can", "cans",
+    "package", "packages", "pkg", "jar", "jars", "bunch", "bunches", "stick", "sticks",
+    "slice", "slices", "piece", "pieces", "clove", "cloves", "handful", "quart", "quarts", "pint", "pints",
+}
+_PREPARATION = {
+    "of", "fresh", "freshly", "chopped", "finely", "roughly", "coarsely", "minced", "diced",
+    "sliced", "thinly", "grated", "shredded", "peeled", "large", "medium", "small", "whole",
+    "softened", "melted", "about", "to", "taste", "optional", "divided", "packed", "heaping",
+}
+_QUANTITY = re.compile(r"^[\d/.,\-–½⅓⅔¼¾⅛]+$")
+_FRACTIONS = {"½": 0.5, "⅓": 1 / 3, "⅔": 2 / 3, "¼": 0.25, "¾": 0.75, "⅛": 0.125}
+_ISO_DURATION = re.compile(r"^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$", re.IGNORECASE)
+_TEXT_DURATION = re.compile(r"(\d+(?:\.\d+)?)\s*(hours?|hrs?|h|minutes?|mins?|m)\b", re.IGNORECASE)
+_JSON_LD_SCRIPT = re.compile(
+    r"<script[^>]*type=[\"']application/ld\+json[\"'][^>]*>(.*?)</script>",
+    re.IGNORECASE | re.DOTALL
+)
+
+
+@dataclass
+class RecipeStep:
+    """One instruction with its inferred timing, equipment and techniques"""
+    text: str
+    duration_seconds: float
+    equipment: List[str] = field(default_factory=list)
+    techniques: List[str] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "text": self.text,
+            "duration_seconds": self.duration_seconds,
+            "equipment": self.equipment,
+            "techniques": self.techniques
+        }
+
+
+@dataclass
+class ImportedRecipe:
+    """A recipe mapped from an external format, with what could be inferred about it"""
+    name: str
+    recipe: Recipe
+    source: str
+    steps: List[RecipeStep] = field(default_factory=list)
+    duration_seconds: float = 0.0
+    quantities: Dict[str, Dict[str, Any]] = field(default_factory=dict)  # ingredient -> quantity, unit
+    errors: List[str] = field(default_factory=list)  # why the recipe can't be taken in as it stands
+
+    @property
+    def equipment(self) -> List[str]:
+        return sorted({kind for step in self.steps for kind in step.equipment})
+
+    @property
+    def techniques(self) -> List[str]:
+        return self.recipe.get("techniques", [])
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "name": self.name,
+            "source": self.source,
+            **self.recipe,
+            "duration_seconds": self.duration_seconds,
+            "equipment": self.equipment,
+            "quantities": self.quantities,
+            "steps": [s.to_dict() for s in self.steps],
+            "errors": self.errors
+        }
+
+
+def parse_ingredient(line: str) -> str:
+    """Reduce a free-text ingredient line ("2 cups chopped onions, divided") to its name"""
+    text = re.sub(r"\([^)]*\)", " ", line.lower()).split(",")[0]
+    words = [w.strip(".") for w in text.split()]
+    words = [w for w in words if w and not _QUANTITY.match(w) and w not in _UNITS and w not in _PREPARATION]
+    return " ".join(words)
+
+
+def parse_quantity(line: str) -> Tuple[Optional[float], Optional[str]]:
+    """Leading amount and unit of an ingredient line ("1 1/2 cups flour" -> 1.5, "cup"), None where missing"""
+    amount, unit = None, None
+    for word in re.sub(r"\([^)]*\)", " ", line.lower()).split():
+        word = word.strip(".,")
+        if _QUANTITY.match(word) and unit is None:
+            value = _amount(word)
+            if value is None:
+                break
+            amount = (amount or 0.0) + value
+        elif word in _UNITS and amount is not None and unit is None:
+            unit = normalize_unit(word)
+        else:
+            break
+    return amount, unit
+
+
+def _amount(word: str) -> Optional[float]:
+    word = word.replace(",", ".")
+    for glyph, value in _FRACTIONS.items():
+        if word.endswith(glyph):
+            whole = word[:-1]
+            return (float(whole) if whole else 0.0) + value
+    try:
+        if "/" in word:
+            numerator, denominator = word.split("/", 1)
+            return float(numerator) / float(denominator)
+        return float(word.split("-")[0].split("–")[0])
+    except (ValueError, ZeroDivisionError):
+        return None
+
+
+def parse_duration(value: Optional[str]) -> float:
+    """Seconds in an ISO 8601 duration ("PT1H30M") or free text ("1 hr 30 mins"); 0 if unknown"""
+    if not value:
+        return 0.0
+    value = str(value).strip()
+    match = _ISO_DURATION.match(value)
+    if match and any(match.groups()):
+        days, hours, minutes, seconds = (int(g or 0) for g in match.groups())
+        return float(((days * 24 + hours) * 60 + minutes) * 60 + seconds)
+
+    total = 0.0
+    for amount, unit in _TEXT_DURATION.findall(value):
+        total += float(amount) * (3600 if unit.lower().startswith("h") else 60)
+    return total
+
+
+def infer_step(text: str) -> RecipeStep:
+    """Guess a step's duration from times it mentions, and its equipment and techniques from keywords
+
+    A step needs the equipment its techniques do even when it doesn't name it
+    ("braise the shanks" takes the stove and the oven).
+    """
+    lowered = text.lower()
+    equipment = [
+        kind for kind, keywords in EQUIPMENT_KEYWORDS.items()
+        if any(re.search(rf"\b{re.escape(k)}", lowered) for k in keywords)
+    ]
+    techniques = infer_techniques(text)
+    equipment += [kind for kind in technique_equipment(techniques) if kind not in equipment]
+    return RecipeStep(text, parse_duration(text) or DEFAULT_STEP_SECONDS, equipment, techniques)
+
+
+def _build(
+    name: str,
+    cuisine: str,
+    ingredient_lines: Iterable[str],
+    instructions: Iterable[str],
+    stated_duration: float,
+    source: str,
+    methods: Iterable[str] = ()
+) -> ImportedRecipe:
+    # Catalog ingredients take their catalog name, and lines naming the same
+    # ingredient ("tomato", "2 tomatoes") collapse into one
+    normalizer = get_normalizer()
+    ingredients, seen, quantities = [], set(), {}
+    for line in ingredient_lines:
+        ingredient = parse_ingredient(line)
+        if not ingredient:
+            continue
+        resolved = normalizer.resolve(ingredient)
+        if resolved["id"] in seen:
+            continue
+        seen.add(resolved["id"])
+        ingredient = resolved["id"] if resolved["known"] else ingredient
+        ingredients.append(ingredient)
+
+        amount, unit = parse_quantity(line)
+        if amount is None:
+            continue
+        quantity = {"quantity": amount, "unit": unit or "pieces"}
+        if resolved["known"]:
+            try:
+                quantity = {"quantity": round(convert(amount, quantity["unit"], resolved["unit"]), 4), "unit": resolved["unit"]}
+            except ValueError:
+                pass  # e.g. cups of flour, stocked by weight; kept as written
+        quantities[ingredient] = quantity
+    steps = [infer_step(text) for text in instructions if text.strip()]
+
+    # Techniques the recipe states must be ones the kitchen knows; those its
+    # steps call for are known by construction
+    techniques, errors = [], []
+    for method in methods:
+        technique = resolve_technique(method)
+        if technique is None:
+            errors.append(f"Unknown cooking technique '{method}'")
+        elif technique not in techniques:
+            techniques.append(technique)
+    for step in steps:
+        techniques += [t for t in step.techniques if t not in techniques]
+
+    recipe: Recipe = {"id": 0, "cuisine": cuisine, "ingredients": ingredients}
+    if techniques:
+        recipe["techniques"] = techniques
+    return ImportedRecipe(
+        name=name,
+        recipe=recipe,
+        source=source,
+        steps=steps,
+        duration_seconds=stated_duration or sum(s.duration_seconds for s in steps),
+        quantities=quantities,
+        errors=errors
+    )
+
+
+def _pick_cuisine(candidates: Iterable[str], known_cuisines: Optional[Iterable[str]] = None) -> str:
+    """First candidate, or the first that is a known cuisine when those are given"""
+    candidates = [c.strip().lower() for c in candidates if isinstance(c, str) and c.strip()]
+    known = {c.lower() for c in known_cuisines} if known_cuisines is not None else None
+    for candidate in candidates:
+        if known is None or candidate in known:
+            return candidate
+    return "unknown"
+
+
+def _as_list(value: Any) -> List[Any]:
+    if value is None:
+        return []
+    return value if isinstance(value, list) else [value]
+
+
+def _schema_instructions(value: Any) -> List[str]:
+    """Flatten recipeInstructions: text, lists of text, HowToStep and HowToSection"""
+    steps = []
+    for item in _as_list(value):
+        if isinstance(item, str):
+            steps.extend(line.strip() for line in item.splitlines())
+        elif isinstance(item, dict):
+            if "itemListElement" in item:
+                steps.extend(_schema_instructions(item["itemListElement"]))
+            elif item.get("text"):
+                steps.append(item["text"].strip())
+    return [s for s in steps if s]
+
+
+def _schema_nodes(data: Any) -> Iterable[Dict[str, Any]]:
+    """Every Recipe node in a JSON-LD document, including ones inside @graph"""
+    for node in _as_list(data):
+        if not isinstance(node, dict):
+            continue
+        if "Recipe" in _as_list(node.get("@type")):
+            yield node
+        yield from _schema_nodes(node.get("@graph"))
+
+
+def parse_schema_org(content: str) -> List[ImportedRecipe]:
+    """Import Recipe nodes from JSON-LD, or from an HTML page embedding it"""
+    documents = _JSON_LD_SCRIPT.findall(content) or [content]
+    recipes = []
+    for document in documents:
+        try:
+            data = json.loads(document)
+        except ValueError as e:
+            if len(documents) == 1:
+                raise ValueError(f"Invalid JSON-LD: {e}")
+            continue
+
+        for node in _schema_nodes(data):
+            stated = parse_duration(node.get("totalTime")) or (
+                parse_duration(node.get("prepTime")) + parse_duration(node.get("cookTime"))
+            )
+            recipes.append(_build(
+                name=node.get("name") or "Untitled recipe",
+                cuisine=_pick_cuisine(_as_list(node.get("recipeCuisine"))),
+                ingredient_lines=_as_list(node.get("recipeIngredient") or node.get("ingredients")),
+                instructions=_schema_instructions(node.get("recipeInstructions")),
+                stated_duration=stated,
+                source="schema_org",
+                methods=[m for m in _as_list(node.get("cookingMethod")) if isinstance(m, str) and m.strip()]
+            ))
+    return recipes
+
+
+def _paprika_documents(data: bytes) -> List[Dict[str, Any]]:
+    """Decode a .paprikarecipes archive, a single gzipped .paprikarecipe, or plain JSON"""
+    if data[:2] == b"PK":
+        with zipfile.ZipFile(io.BytesIO(data)) as archive:
+            return [json.loads(gzip.decompress(archive.read(name))) for name in archive.namelist()]
+    if data[:2] == b"\x1f\x8b":
+        data = gzip.decompress(data)
+    document = json.loads(data)
+    return _as_list(document)
+
+
+def parse_paprika(content: str, known_cuisines: Iterable[str] = ()) -> List[ImportedRecipe]:
+    """Import a base64-encoded Paprika export (or the recipe JSON itself)
+
+    Paprika has no cuisine field, so the first category naming a known cuisine is used.
+    """
+    try:
+        data = base64.b64decode(content, validate=True)
+    except ValueError:
+        data = content.encode("utf-8")
+    try:
+        documents = _paprika_documents(data)
+    except (ValueError, OSError, zipfile.BadZipFile) as e:
+        raise ValueError(f"Invalid Paprika export: {e}")
+
+    recipes = []
+    for document in documents:
+        if not isinstance(document, dict):
+            continue
+        stated = parse_duration(document.get("total_time")) or (
+            parse_duration(document.get("prep_time")) + parse_duration(document.get("cook_time"))
+        )
+        recipes.append(_build(
+            name=document.get("name") or "Untitled recipe",
+            cuisine=_pick_cuisine(_as_list(document.get("categories")), known_cuisines),
+            ingredient_lines=(document.get("ingredients") or "").splitlines(),
+            instructions=(document.get("directions") or "").splitlines(),
+            stated_duration=stated,
+            source="paprika"
+        ))
+    return recipes
+
+
+def import_recipes(format: str, content: str, known_cuisines: Iterable[str] = ()) -> List[ImportedRecipe]:
+    """Parse recipes in one of IMPORT_FORMATS"""
+    if format == "schema_org":
+        return parse_schema_org(content)
+    if format == "paprika":
+        return parse_paprika(content, known_cuisines)
+    raise ValueError(f"Unknown import format '{format}', expected one of {list(IMPORT_FORMATS)}")
@@ -0,0 +1,213 @@
+"""
+Ingredient Catalog for ChefBench
+Master data per ingredient: allergens, dietary tags, unit, cost and shelf life
+"""
+
+import json
+import re
+from dataclasses import dataclass, field, asdict
+from typing import Dict, List, Optional, Iterable, Any
+from pathlib import Path
+import logging
+
+logger = logging.getLogger(__name__)
+
+# Major allergens (US FALCPA plus sesame)
+ALLERGENS = ("milk", "eggs", "fish", "shellfish", "tree_nuts", "peanuts", "wheat", "soy", "sesame")
+
+DIETARY_TAGS = ("vegan", "vegetarian", "gluten_free", "dairy_free")
+
+_PLANT = ["vegan", "vegetarian", "gluten_free", "dairy_free"]
+
+
+@dataclass
+class IngredientInfo:
+    """Catalog entry for one ingredient"""
+    name: str
+    unit: str = "units"
+    cost_per_unit: float = 0.0
+    shelf_life_days: Optional[int] = None
+    allergens: List[str] = field(default_factory=list)
+    dietary: List[str] = field(default_factory=list)  # tags the ingredient is compatible with
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+DEFAULT_INGREDIENTS = [
+    IngredientInfo("salt", "g", 0.002, None, [], _PLANT),
+    IngredientInfo("pepper", "g", 0.03, 730, [], _PLANT),
+    IngredientInfo("olive oil", "ml", 0.012, 540, [], _PLANT),
+    IngredientInfo("vegetable oil", "ml", 0.004, 365, [], _PLANT),
+    IngredientInfo("butter", "g", 0.01, 60, ["milk"], ["vegetarian", "gluten_free"]),
+    IngredientInfo("margarine", "g", 0.006, 120, [], _PLANT),
+    IngredientInfo("milk", "ml", 0.001, 7, ["milk"], ["vegetarian", "gluten_free"]),
+    IngredientInfo("cream", "ml", 0.004, 10, ["milk"], ["vegetarian", "gluten_free"]),
+    IngredientInfo("soy milk", "ml", 0.002, 10, ["soy"], _PLANT),
+    IngredientInfo("eggs", "pieces", 0.25, 28, ["eggs"], ["vegetarian", "gluten_free", "dairy_free"]),
+    IngredientInfo("flour", "g", 0.001, 240, ["wheat"], ["vegan", "vegetarian", "dairy_free"]),
+    IngredientInfo("cornstarch", "g", 0.003, 730, [], _PLANT),
+    IngredientInfo("sugar", "g", 0.001, 730, [], _PLANT),
+    IngredientInfo("honey", "g", 0.012, 730, [], ["vegetarian", "gluten_free", "dairy_free"]),
+    IngredientInfo("onions", "pieces", 0.4, 30, [], _PLANT),
+    IngredientInfo("shallots", "pieces", 0.5, 30, [], _PLANT),
+    IngredientInfo("garlic", "cloves", 0.1, 90, [], _PLANT),
+    IngredientInfo("tomatoes", "pieces", 0.5, 7, [], _PLANT),
+    IngredientInfo("chicken breast", "g", 0.009, 2, [], ["gluten_free", "dairy_free"]),
+    IngredientInfo("chicken thigh", "g", 0.007, 2, [], ["gluten_free", "dairy_free"]),
+    IngredientInfo("ground beef", "g", 0.011, 2, [], ["gluten_free", "dairy_free"]),
+    IngredientInfo("ground turkey", "g", 0.01, 2, [], ["gluten_free", "dairy_free"]),
+    IngredientInfo("salmon", "g", 0.02, 2, ["fish"], ["gluten_free", "dairy_free"]),
+    IngredientInfo("shrimp", "g", 0.022, 2, ["shellfish"], ["gluten_free", "dairy_free"]),
+    IngredientInfo("pasta", "g", 0.003, 730, ["wheat"], ["vegan", "vegetarian", "dairy_free"]),
+    IngredientInfo("rice", "g", 0.002, 730, [], _PLANT),
+    IngredientInfo("soy sauce", "ml", 0.01, 730, ["soy", "wheat"], ["vegan", "vegetarian", "dairy_free"]),
+    IngredientInfo("peanuts", "g", 0.01, 180, ["peanuts"], _PLANT),
+    IngredientInfo("almonds", "g", 0.02, 365, ["tree_nuts"], _PLANT),
+    IngredientInfo("sesame oil", "ml", 0.02, 365, ["sesame"], _PLANT),
+    IngredientInfo("parmesan cheese", "g", 0.03, 60, ["milk"], ["vegetarian", "gluten_free"]),
+    IngredientInfo("white wine", "ml", 0.012, 5, [], _PLANT),
+    IngredientInfo("chicken broth", "ml", 0.003, 5, [], ["gluten_free", "dairy_free"]),
+]
+
+
+class IngredientCatalog:
+    """Ingredient master data, persisted as JSON like the substitution table"""
+
+    def __init__(
+        self,
+        path: Optional[str] = None,
+        defaults: Optional[Iterable[IngredientInfo]] = None
+    ):
+        self.path = Path(path) if path else None
+        self.ingredients: Dict[str, IngredientInfo] = {}
+
+        if self.path and self.path.exists():
+            self.load()
+        else:
+            for info in (DEFAULT_INGREDIENTS if defaults is None else defaults):
+                self.add(info, persist=False)
+
+    @staticmethod
+    def _key(ingredient: str) -> str:
+        return ingredient.strip().lower()
+
+    def add(self, info: IngredientInfo, persist: bool = True) -> IngredientInfo:
+        """Add or replace a catalog entry"""
+        unknown = set(info.allergens) - set(ALLERGENS) | set(info.dietary) - set(DIETARY_TAGS)
+        if unknown:
+            raise ValueError(f"Unknown allergens or dietary tags: {sorted(unknown)}")
+
+        info.name = self._key(info.name)
+        info.allergens = sorted(set(info.allergens))
+        info.dietary = sorted(set(info.dietary))
+        self.ingredients[info.name] = info
+
+        if persist:
+            self.save()
+        return info
+
+    def remove(self, ingredient: str) -> bool:
+        """Remove an entry, returning whether it existed"""
+        if self.ingredients.pop(self._key(ingredient), None) is None:
+            return False
+        self.save()
+        return True
+
+    def get(self, ingredient: str) -> Optional[IngredientInfo]:
+        """Look up an ingredient, falling back to the longest catalog name it contains
+
+        Dataset names are free text, so "large eggs" resolves to "eggs" and
+        "all-purpose flour" to "flour".
+        """
+        key = self._key(ingredient)
+        if key in self.ingredients:
+            return self.ingredients[key]
+        for variant in (key + "s", key + "es", key[:-1], key[:-2]):
+            if variant in self.ingredients:
+                return self.ingredients[variant]
+
+        matches = [name for name in self.ingredients if re.search(rf"\b{re.escape(name)}\b", key)]
+        return self.ingredients[max(matches, key=len)] if matches else None
+
+    def profile(self, ingredients: Iterable[str]) -> Dict[str, Any]:
+        """Allergens and dietary tags of a dish made from these ingredients
+
+        A dish only carries a dietary tag when every ingredient is known and
+        compatible with it; unknown ingredients are listed so callers can tell
+        "not vegan" from "can't tell".
+        """
+        allergens = set()
+        dietary = set(DIETARY_TAGS)
+        unknown = []
+        for ingredient in ingredients:
+            info = self.get(ingredient)
+            if info is None:
+                unknown.append(self._key(ingredient))
+                dietary.clear()
+                continue
+            allergens.update(info.allergens)
+            dietary &= set(info.dietary)
+
+        return {
+            "allergens": sorted(allergens),
+            "dietary": sorted(dietary),
+            "unknown_ingredients": unknown
+        }
+
+    def check_restrictions(
+        self,
+        ingredients: Iterable[str],
+        restrictions: Iterable[str]
+    ) -> Dict[str, Any]:
+        """Check ingredients against allergen and dietary restrictions
+
+        Conflicts are known violations; unverified restrictions couldn't be
+        confirmed because some ingredients aren't in the catalog.
+        """
+        restrictions = [self._key(r) for r in restrictions]
+        invalid = [r for r in restrictions if r not in ALLERGENS and r not in DIETARY_TAGS]
+        if invalid:
+            raise ValueError(f"Unknown restrictions {invalid}, expected allergens {list(ALLERGENS)} "
+                             f"or dietary tags {list(DIETARY_TAGS)}")
+
+        conflicts = []
+        unknown = []
+        for ingredient in ingredients:
+            info = self.get(ingredient)
+            if info is None:
+                unknown.append(self._key(ingredient))
+                continue
+            for restriction in restrictions:
+                if restriction in info.allergens or (restriction in DIETARY_TAGS and restriction not in info.dietary):
+                    conflicts.append({"ingredient": self._key(ingredient), "restriction": restriction})
+
+        return {
+            "compliant": not conflicts and not unknown,
+            "conflicts": conflicts,
+            "unverified": sorted(set(restrictions) - {c["restriction"] for c in conflicts}) if unknown else [],
+            "unknown_ingredients": unknown
+        }
+
+    def to_dict(self) -> Dict[str, Dict]:
+        return {name: self.ingredients[name].to_dict() for name in sorted(self.ingredients)}
+
+    def save(self):
+        """Persist the catalog to disk if a path is configured"""
+        if not self.path:
+            return
+
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        with open(self.path, 'w') as f:
+            json.dump(self.to_dict(), f, indent=2)
+
+    def load(self):
+        """Load the catalog from disk"""
+        with open(self.path, 'r', encoding='utf-8') as f:
+            data = json.load(f)
+
+        self.ingredients = {}
+        for entry in data.values():
+            self.add(IngredientInfo(**entry), persist=False)
+
+        logger.info(f"Loaded {len(self.ingredients)} catalog ingredients from {self.path}")
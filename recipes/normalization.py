@@ -0,0 +1,244 @@
+"""
+Ingredient Normalization for ChefBench
+Canonical ingredient ids, an alias table and unit conversion, so "Tomatoes" and "tomato" are the same thing
+"""
+
+import json
+import re
+from typing import Dict, List, Optional, Iterable, Tuple, Any
+from pathlib import Path
+import logging
+
+from .ingredients import IngredientCatalog
+
+logger = logging.getLogger(__name__)
+
+# Alias -> catalog name, for names the catalog's plural and word matching can't work out
+DEFAULT_ALIASES = {
+    "all-purpose flour": "flour",
+    "plain flour": "flour",
+    "corn starch": "cornstarch",
+    "cornflour": "cornstarch",
+    "caster sugar": "sugar",
+    "granulated sugar": "sugar",
+    "kosher salt": "salt",
+    "sea salt": "salt",
+    "black pepper": "pepper",
+    "extra virgin olive oil": "olive oil",
+    "evoo": "olive oil",
+    "canola oil": "vegetable oil",
+    "heavy cream": "cream",
+    "double cream": "cream",
+    "parmesan": "parmesan cheese",
+    "parmigiano-reggiano": "parmesan cheese",
+    "prawns": "shrimp",
+    "chicken stock": "chicken broth",
+    "minced beef": "ground beef",
+}
+
+# Units to their canonical spelling
+UNIT_ALIASES = {
+    "gram": "g", "grams": "g", "kilogram": "kg", "kilograms": "kg",
+    "ounce": "oz", "ounces": "oz", "pound": "lb", "pounds": "lb", "lbs": "lb",
+    "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+    "liter": "l", "liters": "l", "litre": "l", "litres": "l",
+    "teaspoon": "tsp", "teaspoons": "tsp", "ts": "tsp",
+    "tablespoon": "tbsp", "tablespoons": "tbsp", "tbs": "tbsp", "tb": "tbsp",
+    "cups": "cup", "c": "cup", "pints": "pint", "quarts": "quart",
+    "piece": "pieces", "pcs": "pieces", "units": "pieces", "unit": "pieces", "whole": "pieces",
+    "clove": "cloves",
+}
+
+# Each unit as (dimension, amount of the dimension's base unit: g, ml or pieces)
+UNITS: Dict[str, Tuple[str, float]] = {
+    "g": ("mass", 1.0),
+    "kg": ("mass", 1000.0),
+    "oz": ("mass", 28.3495),
+    "lb": ("mass", 453.592),
+    "ml": ("volume", 1.0),
+    "l": ("volume", 1000.0),
+    "tsp": ("volume", 4.92892),
+    "tbsp": ("volume", 14.7868),
+    "fl oz": ("volume", 29.5735),
+    "cup": ("volume", 236.588),
+    "pint": ("volume", 473.176),
+    "quart": ("volume", 946.353),
+    "pieces": ("count", 1.0),
+    "cloves": ("count", 1.0),
+}
+
+
+def normalize_unit(unit: str) -> str:
+    """Canonical spelling of a unit; unknown units come back lowercased"""
+    key = unit.strip().lower().rstrip(".")
+    return UNIT_ALIASES.get(key, key)
+
+
+def convert(quantity: float, from_unit: str, to_unit: str) -> float:
+    """Convert a quantity between units of the same dimension
+
+    Raises ValueError for an unknown unit or across dimensions (grams to
+    millilitres needs a density this table doesn't have).
+    """
+    source, target = normalize_unit(from_unit), normalize_unit(to_unit)
+    if source == target:
+        return quantity
+    for unit in (source, target):
+        if unit not in UNITS:
+            raise ValueError(f"Unknown unit '{unit}', expected one of {sorted(UNITS)}")
+    (source_dimension, source_factor), (target_dimension, target_factor) = UNITS[source], UNITS[target]
+    if source_dimension != target_dimension:
+        raise ValueError(f"Can't convert {source_dimension} ({source}) to {target_dimension} ({target})")
+    return quantity * source_factor / target_factor
+
+
+def _clean(name: str) -> str:
+    text = re.sub(r"[^\w\s\-']", " ", name.lower())
+    return " ".join(text.split())
+
+
+def _singular(word: str) -> str:
+    if word.endswith("ies") and len(word) > 4:
+        return word[:-3] + "y"
+    if word.endswith(("oes", "ches", "shes", "sses", "xes")):
+        return word[:-2]
+    if word.endswith("s") and not word.endswith(("ss", "us")) and len(word) > 3:
+        return word[:-1]
+    return word
+
+
+class IngredientNormalizer:
+    """Resolves free-text ingredient names to canonical ids
+
+    A name resolves, in order, through the alias table, then the catalog
+    (which also tries plurals and the longest catalog name it contains). Its
+    id is the catalog name. Names the catalog doesn't know get their cleaned,
+    singular form as id, so "Red Tomatoes" and "red tomato" still match.
+    Aliases are persisted as JSON like the catalog.
+    """
+
+    def __init__(
+        self,
+        catalog: Optional[IngredientCatalog] = None,
+        path: Optional[str] = None,
+        defaults: Optional[Dict[str, str]] = None
+    ):
+        self.catalog = catalog or IngredientCatalog()
+        self.path = Path(path) if path else None
+        self.aliases: Dict[str, str] = {}
+
+        if self.path and self.path.exists():
+            self.load()
+        else:
+            for alias, name in (DEFAULT_ALIASES if defaults is None else defaults).items():
+                self.add_alias(alias, name, persist=False)
+
+    def add_alias(self, alias: str, name: str, persist: bool = True) -> Tuple[str, str]:
+        """Map an alias to a catalog ingredient"""
+        info = self.catalog.get(name)
+        if info is None:
+            raise ValueError(f"Ingredient '{name}' is not in the catalog")
+        alias = _clean(alias)
+        if not alias:
+            raise ValueError("Alias is empty")
+        self.aliases[alias] = info.name
+
+        if persist:
+            self.save()
+        return alias, info.name
+
+    def remove_alias(self, alias: str) -> bool:
+        """Remove an alias, returning whether it existed"""
+        if self.aliases.pop(_clean(alias), None) is None:
+            return False
+        self.save()
+        return True
+
+    def resolve(self, name: str) -> Dict[str, Any]:
+        """Canonical id of a name, and how it was matched: alias, catalog or normalized"""
+        cleaned = _clean(name)
+        singular = " ".join(_singular(w) for w in cleaned.split())
+        info, matched_by = None, "normalized"
+        for key in (cleaned, singular):
+            if key in self.aliases:
+                info, matched_by = self.catalog.get(self.aliases[key]), "alias"
+                break
+        if info is None and cleaned:
+            info = self.catalog.get(cleaned) or self.catalog.get(singular)
+            matched_by = "catalog" if info else matched_by
+        return {
+            "input": name,
+            "id": info.name if info else singular,
+            "known": info is not None,
+            "matched_by": matched_by,
+            "unit": info.unit if info else None
+        }
+
+    def canonical_id(self, name: str) -> str:
+        return self.resolve(name)["id"]
+
+    def same(self, a: str, b: str) -> bool:
+        return self.canonical_id(a) == self.canonical_id(b)
+
+    def dedupe(self, names: Iterable[str]) -> List[str]:
+        """Canonical ids of names, first occurrence kept, blanks dropped"""
+        ids = []
+        for name in names:
+            canonical = self.canonical_id(name)
+            if canonical and canonical not in ids:
+                ids.append(canonical)
+        return ids
+
+    def to_catalog_unit(self, name: str, quantity: float, unit: str) -> Dict[str, Any]:
+        """A quantity of an ingredient in the unit the catalog stocks it in
+
+        Raises ValueError when the ingredient isn't in the catalog or the units
+        can't be converted.
+        """
+        resolved = self.resolve(name)
+        if not resolved["known"]:
+            raise ValueError(f"Ingredient '{name}' is not in the catalog")
+        return {
+            **resolved,
+            "quantity": round(convert(quantity, unit, resolved["unit"]), 4),
+            "from": {"quantity": quantity, "unit": normalize_unit(unit)}
+        }
+
+    def save(self):
+        """Persist the alias table to disk if a path is configured"""
+        if not self.path:
+            return
+
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        with open(self.path, 'w') as f:
+            json.dump(dict(sorted(self.aliases.items())), f, indent=2)
+
+    def load(self):
+        """Load the alias table from disk, dropping aliases to ingredients no longer in the catalog"""
+        with open(self.path, 'r', encoding='utf-8') as f:
+            data = json.load(f)
+
+        self.aliases = {}
+        for alias, name in data.items():
+            try:
+                self.add_alias(alias, name, persist=False)
+            except ValueError as e:
+                logger.warning(f"Skipping alias '{alias}': {e}")
+
+        logger.info(f"Loaded {len(self.aliases)} ingredient aliases from {self.path}")
+
+
+_normalizer: Optional[IngredientNormalizer] = None
+
+
+def get_normalizer() -> IngredientNormalizer:
+    """The process-wide normalizer, over the default catalog until the API sets its own"""
+    global _normalizer
+    if _normalizer is None:
+        _normalizer = IngredientNormalizer()
+    return _normalizer
+
+
+def set_normalizer(normalizer: IngredientNormalizer):
+    global _normalizer
+    _normalizer = normalizer
@@ -0,0 +1,165 @@
+"""
+Ingredient Substitution Knowledge Base for ChefBench
+Grounds substitution decisions in a curated table instead of model guesses
+"""
+
+import json
+from dataclasses import dataclass, asdict
+from typing import Dict, List, Optional, Iterable
+from pathlib import Path
+import logging
+
+logger = logging.getLogger(__name__)
+
+
+@dataclass
+class Substitution:
+    """An acceptable replacement for an ingredient"""
+    ingredient: str
+    substitute: str
+    ratio: float = 1.0            # substitute units per unit of ingredient
+    quality_penalty: float = 0.0  # 0-1, subtracted from dish quality
+    notes: str = ""
+
+    def to_dict(self) -> Dict:
+        return asdict(self)
+
+
+DEFAULT_SUBSTITUTIONS = [
+    Substitution("butter", "olive oil", 0.75, 0.10, "Savory cooking only"),
+    Substitution("butter", "margarine", 1.0, 0.05),
+    Substitution("milk", "cream", 0.5, 0.05, "Dilute with equal water"),
+    Substitution("milk", "soy milk", 1.0, 0.10),
+    Substitution("cream", "milk", 1.0, 0.15, "Add butter for richness"),
+    Substitution("eggs", "flax egg", 1.0, 0.25, "Binding only"),
+    Substitution("flour", "cornstarch", 0.5, 0.20, "Thickening only"),
+    Substitution("sugar", "honey", 0.75, 0.10, "Reduce other liquids"),
+    Substitution("salt", "soy sauce", 2.0, 0.15),
+    Substitution("onions", "shallots", 1.0, 0.05),
+    Substitution("garlic", "garlic powder", 0.125, 0.15),
+    Substitution("chicken breast", "chicken thigh", 1.0, 0.05),
+    Substitution("ground beef", "ground turkey", 1.0, 0.15),
+    Substitution("pasta", "rice", 1.0, 0.30),
+    Substitution("olive oil", "vegetable oil", 1.0, 0.10),
+    Substitution("tomatoes", "canned tomatoes", 1.0, 0.10),
+    Substitution("white wine", "chicken broth", 1.0, 0.15, "Add a splash of vinegar"),
+]
+
+
+class SubstitutionKnowledgeBase:
+    """Lookup table of ingredient substitutions with ratios and quality penalties"""
+
+    def __init__(
+        self,
+        path: Optional[str] = None,
+        defaults: Optional[Iterable[Substitution]] = None
+    ):
+        self.path = Path(path) if path else None
+        self.substitutions: Dict[str, Dict[str, Substitution]] = {}
+
+        if self.path and self.path.exists():
+            self.load()
+        else:
+            for substitution in (DEFAULT_SUBSTITUTIONS if defaults is None else defaults):
+                self.add(substitution, persist=False)
+
+    @staticmethod
+    def _key(ingredient: str) -> str:
+        return ingredient.strip().lower()
+
+    def add(self, substitution: Substitution, persist: bool = True) -> Substitution:
+        """Add or replace a substitution"""
+        substitution.ingredient = self._key(substitution.ingredient)
+        substitution.substitute = self._key(substitution.substitute)
+        self.substitutions.setdefault(substitution.ingredient, {})[substitution.substitute] = substitution
+
+        if persist:
+            self.save()
+        return substitution
+
+    def remove(self, ingredient: str, substitute: str) -> bool:
+        """Remove a substitution, returning whether it existed"""
+        options = self.substitutions.get(self._key(ingredient), {})
+        if options.pop(self._key(substitute), None) is None:
+            return False
+
+        if not options:
+            self.substitutions.pop(self._key(ingredient), None)
+        self.save()
+        return True
+
+    def get_substitutes(self, ingredient: str) -> List[Substitution]:
+        """Get substitutes for an ingredient, best quality first"""
+        options = self.substitutions.get(self._key(ingredient), {}).values()
+        return sorted(options, key=lambda s: s.quality_penalty)
+
+    def find_substitute(
+        self,
+        ingredient: str,
+        available_ingredients: Iterable[str]
+    ) -> Optional[Substitution]:
+        """Get the best substitute that is actually available"""
+        available = set(self._key(i) for i in available_ingredients)
+
+        for substitution in self.get_substitutes(ingredient):
+            if substitution.substitute in available:
+                return substitution
+        return None
+
+    def plan_substitutions(
+        self,
+        required_ingredients: Iterable[str],
+        available_ingredients: Iterable[str]
+    ) -> Dict[str, object]:
+        """Resolve missing ingredients against the table
+
+        Returns the substitutions to apply, the ingredients that cannot be
+        covered and the total quality penalty incurred.
+        """
+        available = set(self._key(i) for i in available_ingredients)
+        substitutions = {}
+        missing = []
+
+        for ingredient in required_ingredients:
+            key = self._key(ingredient)
+            if key in available:
+                continue
+
+            substitution = self.find_substitute(key, available)
+            if substitution:
+                substitutions[key] = substitution
+            else:
+                missing.append(key)
+
+        return {
+            "substitutions": substitutions,
+            "missing": missing,
+            "quality_penalty": min(1.0, sum(s.quality_penalty for s in substitutions.values()))
+        }
+
+    def to_dict(self) -> Dict[str, List[Dict]]:
+        return {
+            ingredient: [s.to_dict() for s in self.get_substitutes(ingredient)]
+            for ingredient in sorted(self.substitutions)
+        }
+
+    def save(self):
+        """Persist the table to disk if a path is configured"""
+        if not self.path:
+            return
+
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        with open(self.path, 'w') as f:
+            json.dump(self.to_dict(), f, indent=2)
+
+    def load(self):
+        """Load the table from disk"""
+        with open(self.path, 'r', encoding='utf-8') as f:
+            data = json.load(f)
+
+        self.substitutions = {}
+        for entries in data.values():
+            for entry in entries:
+                self.add(Substitution(**entry), persist=False)
+
+        logger.info(f"Loaded substitutions for {len(self.substitutions)} ingredients from {self.path}")
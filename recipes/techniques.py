@@ -0,0 +1,92 @@
+"""
+Cooking Technique Registry for ChefBench
+The techniques a recipe's steps call for, with the equipment each needs and who in the brigade may do it
+"""
+
+import re
+from dataclasses import dataclass
+from typing import Dict, Iterable, List, Optional, Tuple, Any
+
+
+@dataclass(frozen=True)
+class Technique:
+    """A way of cooking, and what doing it takes"""
+    name: str
+    equipment: Tuple[str, ...]  # equipment kinds it needs, as the equipment simulator names them
+    min_role_level: int  # the least senior role that may do it, as an AgentRole value
+    certifications: Tuple[str, ...] = ()  # skills it needs beyond the role
+    keywords: Tuple[str, ...] = ()  # instruction words that call for it
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "name": self.name,
+            "equipment": list(self.equipment),
+            "min_role_level": self.min_role_level,
+            "certifications": list(self.certifications),
+            "keywords": list(self.keywords)
+        }
+
+
+# Line cooks grill, saute, fry and bake; slow and exacting work is a chef de partie's
+TECHNIQUES: Dict[str, Technique] = {t.name: t for t in (
+    Technique("grill", ("stove",), 3, keywords=("grill", "char")),
+    Technique("saute", ("stove",), 3, keywords=("saute", "sauté", "stir-fry")),
+    Technique("fry", ("stove",), 3, keywords=("fry", "fried", "deep-fry")),
+    Technique("sear", ("stove",), 3, keywords=("sear",)),
+    Technique("bake", ("oven",), 3, keywords=("bake",)),
+    Technique("roast", ("oven",), 3, keywords=("roast",)),
+    Technique("simmer", ("stove",), 2, keywords=("simmer", "boil", "blanch")),
+    Technique("poach", ("stove",), 3, keywords=("poach",)),
+    Technique("emulsify", ("mixer",), 3, keywords=("emulsif",)),
+    Technique("whip", ("mixer",), 2, keywords=("whip", "knead")),
+    Technique("proof", ("oven",), 2, keywords=("proof", "let rise", "until doubled")),
+    Technique("chill", ("refrigerator",), 1, keywords=("chill", "refrigerate", "freeze")),
+    Technique("braise", ("stove", "oven"), 4, keywords=("braise",)),
+    Technique("temper", ("stove",), 4, keywords=("temper",)),
+    Technique("sous_vide", ("stove",), 4, ("food_safety",), keywords=("sous vide", "sous-vide", "water bath")),
+)}
+
+# Other names recipes give techniques, e.g. a Schema.org cookingMethod
+ALIASES: Dict[str, str] = {
+    "grilling": "grill", "sauteing": "saute", "sautéing": "saute", "sauté": "saute", "stir frying": "saute",
+    "frying": "fry", "deep frying": "fry", "pan frying": "fry", "searing": "sear", "baking": "bake",
+    "roasting": "roast", "simmering": "simmer", "boiling": "simmer", "blanching": "simmer", "poaching": "poach",
+    "emulsifying": "emulsify", "whipping": "whip", "kneading": "whip", "proofing": "proof", "chilling": "chill",
+    "braising": "braise", "tempering": "temper", "sous vide": "sous_vide",
+}
+
+
+def resolve_technique(name: str) -> Optional[str]:
+    """The registry name of a technique as a recipe names it ("Deep-frying" -> "fry"), or None if unknown"""
+    key = " ".join(name.strip().lower().replace("-", " ").replace("_", " ").split())
+    if key.replace(" ", "_") in TECHNIQUES:
+        return key.replace(" ", "_")
+    return ALIASES.get(key)
+
+
+def infer_techniques(text: str) -> List[str]:
+    """Techniques an instruction calls for, from their keywords, in registry order"""
+    lowered = text.lower()
+    return [
+        name for name, technique in TECHNIQUES.items()
+        if any(re.search(rf"\b{re.escape(k)}", lowered) for k in technique.keywords)
+    ]
+
+
+def technique_equipment(techniques: Iterable[str]) -> List[str]:
+    """Equipment kinds the techniques need between them, in the order first needed"""
+    kinds: List[str] = []
+    for name in techniques:
+        for kind in TECHNIQUES[name].equipment:
+            if kind not in kinds:
+                kinds.append(kind)
+    return kinds
+
+
+def supports(role_level: int, certifications: Iterable[str], techniques: Iterable[str]) -> bool:
+    """Whether someone of a role level holding the certifications may do every one of the techniques"""
+    held = set(certifications)
+    return all(
+        role_level >= TECHNIQUES[name].min_role_level and held.issuperset(TECHNIQUES[name].certifications)
+        for name in techniques
+    )
@@ -0,0 +1,118 @@
+"""
+Export the ChefBench OpenAPI document
+Writes the spec generated from the FastAPI routes, plus the model JSON Schemas, so the client package can be checked against them
+
+With --check nothing is written: the documents are regenerated and compared with the
+checked-in copies, and every route the client calls must exist in the spec. The client
+stays hand-written (retries, circuit breaker, idempotency keys and SSE resumption are not
+expressible in the spec), so this check is what keeps it in step with the server.
+"""
+
+import argparse
+import ast
+import json
+import re
+import sys
+from pathlib import Path
+from typing import Any, Dict, List, Set, Tuple
+
+ROOT = Path(__file__).resolve().parent.parent
+sys.path.insert(0, str(ROOT))
+
+from kitchen.api import create_app
+from kitchen.schema import all_schemas
+
+DEFAULT_OUTPUT = ROOT / "docs" / "openapi.json"
+CLIENT_SOURCE = ROOT / "client" / "api_client.py"
+
+
+def generate() -> Tuple[Dict[str, Any], Dict[str, Any]]:
+    """The OpenAPI document and the model schemas, as the code defines them now"""
+    return create_app().openapi(), all_schemas()
+
+
+def _dump(document: Dict[str, Any]) -> str:
+    return json.dumps(document, indent=2) + "\n"
+
+
+def _template(path: str) -> str:
+    """A route path with its parameter names dropped, so /runs/{id} matches /runs/{run_id}"""
+    return re.sub(r"\{[^}]*\}", "{}", path)
+
+
+def client_routes(source: Path = CLIENT_SOURCE) -> Set[Tuple[str, str]]:
+    """(method, path template) of every request the client sends with a literal path"""
+    routes = set()
+    for node in ast.walk(ast.parse(source.read_text())):
+        if not isinstance(node, ast.Call) or not isinstance(node.func, ast.Attribute):
+            continue
+        if node.func.attr in ("_request", "request") and len(node.args) >= 2:
+            method, path = node.args[0], node.args[1]
+        elif node.func.attr == "_stream_frames" and node.args:
+            method, path = ast.Constant("GET"), node.args[0]
+        else:
+            continue
+        if not isinstance(method, ast.Constant):
+            continue
+        if isinstance(path, ast.Constant) and isinstance(path.value, str):
+            routes.add((method.value, _template(path.value)))
+        elif isinstance(path, ast.JoinedStr):
+            text = "".join(
+                part.value if isinstance(part, ast.Constant) else "{}"
+                for part in path.values
+            )
+            routes.add((method.value, _template(text)))
+    return routes
+
+
+def check(output_path: Path = DEFAULT_OUTPUT) -> List[str]:
+    """Problems with the checked-in documents and the client; empty when both are current"""
+    spec, schemas = generate()
+    problems = []
+
+    for path, document in ((output_path, spec), (output_path.with_name("schema.json"), schemas)):
+        if not path.exists():
+            problems.append(f"{path} is missing; run scripts/export_openapi.py")
+        elif path.read_text() != _dump(document):
+            problems.append(f"{path} is out of date; run scripts/export_openapi.py")
+
+    served = {
+        (method.upper(), _template(path))
+        for path, operations in spec.get("paths", {}).items()
+        for method in operations
+    }
+    for method, path in sorted(client_routes() - served):
+        problems.append(f"client calls {method} {path}, which the API does not serve")
+
+    return problems
+
+
+def main(argv: List[str] = None) -> int:
+    parser = argparse.ArgumentParser(description=__doc__.strip().splitlines()[0])
+    parser.add_argument("output_path", nargs="?", default=str(DEFAULT_OUTPUT))
+    parser.add_argument("--check", action="store_true",
+                        help="fail if the checked-in documents or the client drifted from the routes")
+    args = parser.parse_args(argv)
+    path = Path(args.output_path)
+
+    if args.check:
+        problems = check(path)
+        for problem in problems:
+            print(problem, file=sys.stderr)
+        if not problems:
+            print(f"{path} and the client match the API routes")
+        return 1 if problems else 0
+
+    spec, schemas = generate()
+    path.parent.mkdir(parents=True, exist_ok=True)
+    path.write_text(_dump(spec))
+    print(f"Wrote OpenAPI spec with {len(spec.get('paths', {}))} paths to {path}")
+
+    schema_path = path.with_name("schema.json")
+    schema_path.write_text(_dump(schemas))
+    print(f"Wrote {len(schemas['$defs'])} model schemas to {schema_path}")
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -0,0 +1,44 @@
+"""
+Replay a recorded ChefBench run from the event store
+Prints the reconstructed kitchen state at a point in time for debugging
+"""
+
+import argparse
+import json
+import sys
+from datetime import datetime
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).resolve().parent.parent))
+
+from database.event_store import EventStore
+
+
+def main():
+    parser = argparse.ArgumentParser(description="Reconstruct run state from the event log")
+    parser.add_argument("run_id", help="Evaluation ID of the run")
+    parser.add_argument("--at", default=None,
+                        help="Unix timestamp or ISO datetime to replay up to (default: end of run)")
+    parser.add_argument("--db", default="data/events.db", help="Event store path")
+    parser.add_argument("--events", action="store_true", help="Also print every applied event")
+    args = parser.parse_args()
+
+    until = None
+    if args.at:
+        try:
+            until = float(args.at)
+        except ValueError:
+            until = datetime.fromisoformat(args.at).timestamp()
+
+    store = EventStore(args.db)
+    try:
+        if args.events:
+            for event in store.query(run_id=args.run_id, until=until):
+                print(json.dumps(event.to_dict()))
+        print(json.dumps(store.replay(args.run_id, until=until), indent=2))
+    finally:
+        store.close()
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,17 @@
+"""
+Staff shift scheduling, labor cost and staffing requests
+"""
+
+from .shifts import DEFAULT_HOURLY_WAGES, Shift, ShiftSchedule
+from .hr import URGENCY_LEVELS, StaffRequest, HRSystem
+from .skills import SkillStore
+
+__all__ = [
+    "DEFAULT_HOURLY_WAGES",
+    "Shift",
+    "ShiftSchedule",
+    "URGENCY_LEVELS",
+    "StaffRequest",
+    "HRSystem",
+    "SkillStore"
+]
@@ -0,0 +1,204 @@
+"""
+HR System for ChefBench
+Pool of idle agents and an urgency-ordered staffing request queue with escalation
+"""
+
+import json
+import time
+import uuid
+from dataclasses import dataclass, field, asdict
+from pathlib import Path
+from typing import Callable, Dict, List, Optional, Tuple, Any
+import logging
+
+from models.models import LLMAgent, AgentRole
+
+logger = logging.getLogger(__name__)
+
+URGENCY_LEVELS = ["low", "normal", "high", "critical"]
+OPEN_STATUSES = ("pending", "partial")
+
+
+@dataclass
+class StaffRequest:
+    """A request for additional agents of a role"""
+    role: str  # AgentRole name
+    count: int = 1
+    urgency: str = "normal"
+    reason: str = ""
+    requested_by: Optional[str] = None
+    status: str = "pending"  # pending | partial | fulfilled | cancelled
+    assigned: List[str] = field(default_factory=list)
+    escalations: int = 0
+    request_id: str = field(default_factory=lambda: uuid.uuid4().hex[:12])
+    created_at: float = field(default_factory=time.time)
+    updated_at: float = field(default_factory=time.time)
+
+    @property
+    def remaining(self) -> int:
+        return self.count - len(self.assigned)
+
+    @property
+    def is_open(self) -> bool:
+        return self.status in OPEN_STATUSES
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class HRSystem:
+    """Staffing pool and request queue
+
+    Fulfillment prefers idle agents of exactly the requested role and falls
+    back to more senior ones. Open requests not served within
+    ``escalate_after`` seconds move up one urgency level.
+    """
+
+    def __init__(
+        self,
+        path: Optional[str] = None,
+        escalate_after: float = 120.0,
+        agent_factory: Callable[[str, AgentRole, str], LLMAgent] = LLMAgent
+    ):
+        self.path = Path(path) if path else None
+        self.escalate_after = escalate_after
+        self.agent_factory = agent_factory
+        self.pool: Dict[str, LLMAgent] = {}
+        self.requests: Dict[str, StaffRequest] = {}
+        if self.path and self.path.exists():
+            self.load()
+
+    # Pool
+
+    def add_to_pool(self, agent: LLMAgent):
+        self.pool[agent.name] = agent
+        self.save()
+
+    def hire(self, name: str, role: AgentRole, model_name: str) -> LLMAgent:
+        """Create an idle agent in the pool"""
+        agent = self.agent_factory(name, role, model_name)
+        self.add_to_pool(agent)
+        return agent
+
+    def dismiss(self, name: str) -> bool:
+        if self.pool.pop(name, None) is None:
+            return False
+        self.save()
+        return True
+
+    # Requests
+
+    def submit(
+        self,
+        role: AgentRole,
+        count: int = 1,
+        urgency: str = "normal",
+        reason: str = "",
+        requested_by: Optional[str] = None
+    ) -> StaffRequest:
+        if urgency not in URGENCY_LEVELS:
+            raise ValueError(f"Unknown urgency '{urgency}', expected one of {URGENCY_LEVELS}")
+        if count < 1:
+            raise ValueError("Staff requests need a count of at least 1")
+
+        request = StaffRequest(role.name, count, urgency, reason, requested_by)
+        self.requests[request.request_id] = request
+        logger.info(f"Staff request {request.request_id}: {count} x {role.name} ({urgency}) - {reason}")
+        self.save()
+        return request
+
+    def cancel(self, request_id: str) -> StaffRequest:
+        request = self.requests[request_id]
+        if request.is_open:
+            request.status = "cancelled"
+            request.updated_at = time.time()
+            self.save()
+        return request
+
+    def open_requests(self) -> List[StaffRequest]:
+        """Open requests, most urgent and oldest first"""
+        return sorted(
+            (r for r in self.requests.values() if r.is_open),
+            key=lambda r: (-URGENCY_LEVELS.index(r.urgency), r.created_at)
+        )
+
+    def escalate(self, now: Optional[float] = None) -> List[StaffRequest]:
+        """Raise the urgency of open requests that have waited too long"""
+        now = now or time.time()
+        escalated = []
+        for request in self.open_requests():
+            level = URGENCY_LEVELS.index(request.urgency)
+            if now - request.updated_at >= self.escalate_after and level < len(URGENCY_LEVELS) - 1:
+                request.urgency = URGENCY_LEVELS[level + 1]
+                request.escalations += 1
+                request.updated_at = now
+                escalated.append(request)
+        if escalated:
+            self.save()
+        return escalated
+
+    def _take_from_pool(self, role: AgentRole) -> Optional[LLMAgent]:
+        exact = [a for a in self.pool.values() if a.role == role]
+        senior = sorted(
+            (a for a in self.pool.values() if a.role.value > role.value),
+            key=lambda a: a.role.value
+        )
+        candidates = exact or senior
+        if not candidates:
+            return None
+        return self.pool.pop(candidates[0].name)
+
+    def fulfill(self) -> List[Tuple[StaffRequest, LLMAgent]]:
+        """Assign idle agents to open requests in priority order"""
+        assignments = []
+        for request in self.open_requests():
+            while request.remaining > 0:
+                agent = self._take_from_pool(AgentRole[request.role])
+                if agent is None:
+                    break
+                request.assigned.append(agent.name)
+                assignments.append((request, agent))
+
+            if request.assigned:
+                request.status = "fulfilled" if request.remaining == 0 else "partial"
+                request.updated_at = time.time()
+
+        if assignments:
+            self.save()
+        return assignments
+
+    # Persistence
+
+    def save(self):
+        if not self.path:
+            return
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        with open(self.path, 'w') as f:
+            json.dump({
+                "pool": [
+                    {"name": a.name, "role": a.role.name, "model_name": a.model_name}
+                    for a in self.pool.values()
+                ],
+                "requests": [r.to_dict() for r in self.requests.values()]
+            }, f, indent=2)
+
+    def load(self):
+        """Restore requests and recreate pooled agents"""
+        with open(self.path, 'r', encoding='utf-8') as f:
+            data = json.load(f)
+        self.requests = {r["request_id"]: StaffRequest(**r) for r in data.get("requests", [])}
+        self.pool = {
+            spec["name"]: self.agent_factory(spec["name"], AgentRole[spec["role"]], spec["model_name"])
+            for spec in data.get("pool", [])
+        }
+        logger.info(f"Loaded {len(self.pool)} pooled agents and {len(self.requests)} staff requests")
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "pool": [
+                {"name": a.name, "role": a.role.name, "model": a.model_name}
+                for a in self.pool.values()
+            ],
+            "open_requests": [r.to_dict() for r in self.open_requests()],
+            "requests": len(self.requests)
+        }
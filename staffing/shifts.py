@@ -0,0 +1,135 @@
+"""
+Shift Scheduling for ChefBench
+Agent shifts, hourly wages and labor cost accrued over simulated scenario time
+"""
+
+from dataclasses import dataclass, asdict
+from typing import Dict, List, Optional, Any
+
+from models.models import LLMAgent, AgentRole
+
+# Hourly wages by role, used when a shift doesn't set its own
+DEFAULT_HOURLY_WAGES: Dict[AgentRole, float] = {
+    AgentRole.HEAD_CHEF: 45.0,
+    AgentRole.SOUS_CHEF: 32.0,
+    AgentRole.CHEF_DE_PARTIE: 25.0,
+    AgentRole.LINE_COOK: 20.0,
+    AgentRole.PREP_COOK: 17.0,
+    AgentRole.KITCHEN_PORTER: 15.0,
+    AgentRole.COMMIS: 14.0,
+}
+
+
+@dataclass
+class Shift:
+    """A block of simulated time an agent is paid to work"""
+    agent_name: str
+    start: float  # simulated seconds from scenario start
+    end: float
+    hourly_wage: Optional[float] = None
+
+    def paid_seconds(self, elapsed: float) -> float:
+        """Seconds of this shift that have elapsed"""
+        return max(0.0, min(self.end, elapsed) - self.start)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class ShiftSchedule:
+    """Shifts for the current team; without explicit shifts every agent works the whole run"""
+
+    def __init__(self, wages: Optional[Dict[AgentRole, float]] = None):
+        self.wages = dict(DEFAULT_HOURLY_WAGES, **(wages or {}))
+        self.shifts: List[Shift] = []
+
+    @property
+    def custom(self) -> bool:
+        return bool(self.shifts)
+
+    def set_shifts(self, shifts: List[Shift], agents: Dict[str, LLMAgent]):
+        """Replace the schedule, validating agents and times"""
+        for shift in shifts:
+            if shift.agent_name not in agents:
+                raise ValueError(f"Unknown agent '{shift.agent_name}'")
+            if shift.end <= shift.start or shift.start < 0:
+                raise ValueError(f"Invalid shift {shift.start}-{shift.end} for {shift.agent_name}")
+        self.shifts = list(shifts)
+
+    def clear(self):
+        self.shifts.clear()
+
+    def auto_schedule(
+        self,
+        agents: Dict[str, LLMAgent],
+        duration: float,
+        shift_seconds: float
+    ) -> List[Shift]:
+        """Rotate agents of the same role through back-to-back shifts covering the run
+
+        Each role is staffed continuously by one agent at a time, so extra
+        agents in a role lower cost per hour of coverage rather than adding it.
+        """
+        by_role: Dict[AgentRole, List[str]] = {}
+        for name in sorted(agents):
+            by_role.setdefault(agents[name].role, []).append(name)
+
+        shifts = []
+        for names in by_role.values():
+            start, turn = 0.0, 0
+            while start < duration:
+                end = min(start + shift_seconds, duration)
+                shifts.append(Shift(names[turn % len(names)], start, end))
+                start, turn = end, turn + 1
+
+        self.set_shifts(shifts, agents)
+        return shifts
+
+    def scheduled_agents(self, agents: Dict[str, LLMAgent]) -> List[str]:
+        """Agents that will work this run"""
+        if not self.custom:
+            return list(agents)
+        return sorted({s.agent_name for s in self.shifts if s.agent_name in agents})
+
+    def effective_shifts(self, agents: Dict[str, LLMAgent], duration: float) -> List[Shift]:
+        if self.custom:
+            return [s for s in self.shifts if s.agent_name in agents]
+        return [Shift(name, 0.0, duration) for name in agents]
+
+    def wage_for(self, shift: Shift, agents: Dict[str, LLMAgent]) -> float:
+        if shift.hourly_wage is not None:
+            return shift.hourly_wage
+        return self.wages[agents[shift.agent_name].role]
+
+    def labor_cost(
+        self,
+        agents: Dict[str, LLMAgent],
+        elapsed: float,
+        duration: float
+    ) -> Dict[str, Any]:
+        """Labor cost for the simulated time that has passed, by agent"""
+        by_agent: Dict[str, Dict[str, float]] = {}
+        for shift in self.effective_shifts(agents, duration):
+            seconds = shift.paid_seconds(elapsed)
+            entry = by_agent.setdefault(shift.agent_name, {"hours": 0.0, "cost": 0.0})
+            entry["hours"] += seconds / 3600
+            entry["cost"] += seconds / 3600 * self.wage_for(shift, agents)
+
+        return {
+            "simulated_seconds": round(elapsed, 1),
+            "total_cost": round(sum(e["cost"] for e in by_agent.values()), 2),
+            "total_hours": round(sum(e["hours"] for e in by_agent.values()), 3),
+            "by_agent": {
+                name: {"hours": round(e["hours"], 3), "cost": round(e["cost"], 2)}
+                for name, e in by_agent.items()
+            }
+        }
+
+    def to_dict(self, agents: Dict[str, LLMAgent], duration: Optional[float] = None) -> Dict[str, Any]:
+        return {
+            "custom": self.custom,
+            "wages": {role.name: wage for role, wage in self.wages.items()},
+            "shifts": [s.to_dict() for s in self.effective_shifts(agents, duration or 0.0)]
+            if self.custom or duration else [],
+            "scheduled_agents": self.scheduled_agents(agents)
+        }
@@ -0,0 +1,61 @@
+"""
+Skill Store for ChefBench
+Skill profiles and certifications kept per agent name, so they outlast a run
+"""
+
+import json
+from pathlib import Path
+from typing import Dict, Optional, Any
+import logging
+
+from models.models import LLMAgent, SkillProfile
+
+logger = logging.getLogger(__name__)
+
+
+class SkillStore:
+    """Skill profiles by agent name, saved to a JSON file when a path is given
+
+    Only profiles that changed are kept: edited ones, and trainees' as they learn.
+    """
+
+    def __init__(self, path: Optional[str] = None):
+        self.path = Path(path) if path else None
+        self.profiles: Dict[str, SkillProfile] = {}
+        if self.path and self.path.exists():
+            self.load()
+
+    def attach(self, agent: LLMAgent):
+        """Give an agent the profile saved under its name, if it's for the same kind of agent"""
+        profile = self.profiles.get(agent.name)
+        if profile is not None and profile.trainee == agent.skills.trainee:
+            agent.skills = profile
+
+    def update(self, agent: LLMAgent):
+        """Remember an agent's current profile"""
+        self.profiles[agent.name] = agent.skills
+        self.save()
+
+    def forget(self, name: str) -> bool:
+        if self.profiles.pop(name, None) is None:
+            return False
+        self.save()
+        return True
+
+    # Persistence
+
+    def save(self):
+        if not self.path:
+            return
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        with open(self.path, 'w') as f:
+            json.dump({name: p.to_dict() for name, p in self.profiles.items()}, f, indent=2)
+
+    def load(self):
+        with open(self.path, 'r', encoding='utf-8') as f:
+            data = json.load(f)
+        self.profiles = {name: SkillProfile.from_dict(p) for name, p in data.items()}
+        logger.info(f"Loaded skill profiles for {len(self.profiles)} agents")
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {name: p.to_dict() for name, p in self.profiles.items()}
@@ -0,0 +1,25 @@
+"""
+Shared fixtures: a kitchen with one line cook on the mock model, and orders to put through it
+"""
+
+import pytest
+
+from models.models import AgentRole, TaskType, MOCK_MODEL
+from providers import MultiAgentCoordinator
+
+
+def _order(count: int = 1, task_type: TaskType = TaskType.COOKING_EXECUTION, **context):
+    return [(task_type, {"ingredients": ["salt", "eggs"], "time_limit": 300, **context}) for _ in range(count)]
+
+
+@pytest.fixture
+def coordinator() -> MultiAgentCoordinator:
+    coordinator = MultiAgentCoordinator(probe_interval=0)
+    coordinator.create_agent("cook", AgentRole.LINE_COOK, MOCK_MODEL)
+    return coordinator
+
+
+@pytest.fixture
+def order():
+    """Builds count tasks of one type, with any extra context (e.g. course="dessert") on each"""
+    return _order
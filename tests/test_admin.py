@@ -0,0 +1,78 @@
+"""
+Admin routes: off unless the server has a token, and then only for callers who send it
+"""
+
+import pytest
+
+pytest.importorskip("fastapi")
+# kitchen.api loads the model stack through models.models
+pytest.importorskip("torch")
+pytest.importorskip("transformers")
+
+from fastapi import HTTPException
+
+from kitchen.api import ChefBenchAPI, ChaosRequest, FaultConfigRequest
+
+
+def _endpoint(api: ChefBenchAPI, method: str, path: str):
+    return next(
+        route.endpoint for route in api.app.routes
+        if getattr(route, "path", None) == path and method in route.methods
+    )
+
+
+@pytest.fixture
+def server(tmp_path, monkeypatch):
+    """Builds servers that keep their stores under a scratch directory"""
+    monkeypatch.chdir(tmp_path)
+    monkeypatch.delenv("CHEFBENCH_ADMIN_TOKEN", raising=False)
+    return ChefBenchAPI
+
+
+@pytest.mark.asyncio
+async def test_admin_routes_are_off_without_a_token(server):
+    api = server()
+    configure = _endpoint(api, "PUT", "/admin/faults")
+
+    for token in (None, "", "guess"):
+        with pytest.raises(HTTPException) as error:
+            await configure(FaultConfigRequest(enabled=True, error_rate=1.0), x_admin_token=token)
+        assert error.value.status_code == 403
+        assert "disabled" in error.value.detail
+    assert not api.faults.config.enabled
+
+
+@pytest.mark.asyncio
+async def test_admin_routes_need_the_configured_token(server):
+    api = server(admin_token="s3cret")
+    configure = _endpoint(api, "PUT", "/admin/faults")
+
+    for token in (None, "", "s3cre", "s3cret "):
+        with pytest.raises(HTTPException) as error:
+            await configure(FaultConfigRequest(enabled=True, error_rate=1.0), x_admin_token=token)
+        assert error.value.status_code == 403
+        assert error.value.detail == "Invalid admin token"
+
+    faults = await configure(FaultConfigRequest(enabled=True, error_rate=1.0), x_admin_token="s3cret")
+    assert faults["config"]["enabled"]
+
+
+
+@pytest.mark.asyncio
+async def test_destructive_actions_need_the_token(server):
+    api = server(admin_token="s3cret")
+    inject = _endpoint(api, "POST", "/chaos")
+    break_equipment = _endpoint(api, "POST", "/equipment/{name}/break")
+
+    for token in (None, "guess"):
+        with pytest.raises(HTTPException) as error:
+            await inject(ChaosRequest(action="kill_agent", agent="cook"), x_admin_token=token)
+        assert error.value.status_code == 403
+        with pytest.raises(HTTPException) as error:
+            await break_equipment("oven", x_admin_token=token)
+        assert error.value.status_code == 403
+
+    # With the token, both go on to their own checks
+    with pytest.raises(HTTPException) as error:
+        await inject(ChaosRequest(action="kill_agent", agent="cook"), x_admin_token="s3cret")
+    assert error.value.detail == "No run is executing"
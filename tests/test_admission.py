@@ -0,0 +1,166 @@
+"""
+Order admission: the kitchen turns orders away while it's overloaded, saying how long until it could take them
+"""
+
+import pytest
+
+from kitchen.admission import AdmissionPolicy, admit
+from kitchen.orders import OrderPlan, PlannedItem
+from models.models import AgentRole, TaskType, MOCK_MODEL
+
+
+def _plan(tasks, queued_seconds: float = 0.0, expected_seconds: float = 30.0) -> OrderPlan:
+    item = PlannedItem(0, "cooking_execution", "omelette", None, len(tasks), ["salt", "eggs"],
+                       station="hot_line", expected_seconds=expected_seconds)
+    plan = OrderPlan(items=[item], table=None, queued_seconds=queued_seconds)
+    plan._tasks.extend(tasks)
+    return plan
+
+
+def test_an_idle_kitchen_admits(coordinator, order):
+    decision = admit(AdmissionPolicy(), coordinator, _plan(order(2)))
+    assert decision.admitted
+    assert decision.reasons == []
+
+
+def test_past_max_active_orders_the_wait_is_until_enough_have_cleared(coordinator, order):
+    coordinator.submit_orders(order(4))
+    policy = AdmissionPolicy(max_active_orders=5, max_station_load=None, max_wait_seconds=None)
+
+    decision = admit(policy, coordinator, _plan(order(3), queued_seconds=120.0))
+    assert not decision.admitted
+    assert decision.active_orders == 4
+    # Two of the four queued, at 30s each, must finish before three more fit
+    assert decision.wait_estimate == 60.0
+    assert decision.retryable
+
+
+def test_station_load_counts_deferred_tasks(coordinator, order):
+    coordinator.station_capacity["hot_line"] = 2
+    coordinator.submit_orders(order(3))
+    policy = AdmissionPolicy(max_active_orders=None, max_station_load=1.5, max_wait_seconds=None)
+
+    decision = admit(policy, coordinator, _plan(order(1), queued_seconds=60.0))
+    assert not decision.admitted
+    assert decision.reasons == ["hot_line would have 4 tasks on it, past 3 for its 2 slots"]
+    # Cleaning goes to the dish pit, which has room
+    assert admit(policy, coordinator, _plan(order(1, TaskType.CLEANING), queued_seconds=60.0)).admitted
+
+
+def test_expected_wait_past_the_limit(coordinator, order):
+    policy = AdmissionPolicy(max_active_orders=None, max_station_load=None, max_wait_seconds=100.0)
+    decision = admit(policy, coordinator, _plan(order(1), queued_seconds=250.0))
+    assert not decision.admitted
+    assert decision.wait_estimate == 150.0
+
+
+def test_an_order_bigger_than_the_limits_is_never_admitted(coordinator, order):
+    policy = AdmissionPolicy(max_active_orders=3, max_station_load=None, max_wait_seconds=None)
+    decision = admit(policy, coordinator, _plan(order(4)))
+    assert not decision.admitted
+    assert decision.wait_estimate is None
+    assert not decision.retryable
+
+
+def test_a_disabled_policy_admits_everything(coordinator, order):
+    coordinator.submit_orders(order(10))
+    policy = AdmissionPolicy(enabled=False, max_active_orders=1)
+    assert admit(policy, coordinator, _plan(order(5), queued_seconds=10_000.0)).admitted
+
+
+def test_throttled_orders_count_against_intake(coordinator):
+    coordinator.throttle_order(3, ["busy"], 45.0, table=None)
+
+    assert coordinator.throttled_orders[0]["portions"] == 3
+    throttled = [e for e in coordinator.event_log if e.event_type == "order_throttled"]
+    assert throttled and throttled[0].payload["wait_estimate"] == 45.0
+
+
+def test_policy_overrides_from_the_environment(monkeypatch):
+    monkeypatch.setenv("CHEFBENCH_ADMISSION", '{"max_active_orders": 12, "max_wait_seconds": null}')
+    policy = AdmissionPolicy.from_env()
+    assert policy.max_active_orders == 12
+    assert policy.max_wait_seconds is None
+
+    monkeypatch.setenv("CHEFBENCH_ADMISSION", '{"max_active_orders": 0}')
+    assert AdmissionPolicy.from_env() == AdmissionPolicy()
+
+
+@pytest.mark.asyncio
+async def test_api_answers_429_with_retry_after(tmp_path, monkeypatch):
+    pytest.importorskip("fastapi")
+    from fastapi import HTTPException
+    from kitchen.api import ChefBenchAPI, OrderSubmissionRequest
+
+    monkeypatch.chdir(tmp_path)
+    api = ChefBenchAPI()
+    api.coordinator.create_agent("cook", AgentRole.LINE_COOK, MOCK_MODEL)
+    api.admission = AdmissionPolicy(max_active_orders=2, max_station_load=None, max_wait_seconds=None)
+    submit = next(
+        route.endpoint for route in api.app.routes
+        if getattr(route, "path", None) == "/orders" and "POST" in route.methods
+    )
+    order = OrderSubmissionRequest(items=[{"dish": "omelette", "ingredients": ["eggs", "butter"]}])
+
+    async with api.coordinator.run_lock:
+        api.coordinator.run_id = "busy"
+        api.active_evaluations["busy"] = {"status": "running", "tasks": []}
+        assert (await submit(order))["committed"]
+        assert (await submit(order))["committed"]
+        with pytest.raises(HTTPException) as error:
+            await submit(order)
+        assert error.value.status_code == 429
+        assert error.value.detail["wait_estimate"] > 0
+        assert int(error.value.headers["Retry-After"]) >= 1
+
+        review = await submit(order, dry_run=True)
+        assert not review["admission"]["admitted"]
+    assert len(api.coordinator.throttled_orders) == 1
+
+
+@pytest.mark.asyncio
+async def test_a_retry_after_a_refusal_is_admitted():
+    pytest.importorskip("fastapi")
+    from starlette.requests import Request
+    from starlette.responses import Response
+    from kitchen.idempotency import IdempotencyStore
+
+    store = IdempotencyStore()
+    statuses = iter([409, 429, 200])
+    calls = []
+
+    def request():
+        async def receive():
+            return {"type": "http.request", "body": b'{"items": []}', "more_body": False}
+        return Request({
+            "type": "http", "method": "POST", "path": "/orders", "query_string": b"",
+            "headers": [(b"idempotency-key", b"order-1")]
+        }, receive)
+
+    async def call_next(_):
+        calls.append(1)
+        response = Response(b"{}", status_code=next(statuses))
+
+        async def body():
+            yield response.body
+        response.body_iterator = body()
+        return response
+
+    # No run yet, then the kitchen is overloaded: neither refusal is kept for the key
+    assert (await store.middleware(request(), call_next)).status_code == 409
+    assert (await store.middleware(request(), call_next)).status_code == 429
+    assert (await store.middleware(request(), call_next)).status_code == 200
+    # Once admitted, retries replay the order instead of placing it again
+    replay = await store.middleware(request(), call_next)
+    assert replay.status_code == 200 and replay.headers["Idempotency-Replayed"] == "true"
+    assert len(calls) == 3
+    assert store.stats["stored"] == 1
+
+
+def test_queue_depth_counts_only_tasks_queued_to_agents(coordinator, order):
+    coordinator.station_capacity["hot_line"] = 2
+    coordinator.submit_orders(order(3))
+    assert coordinator.queue_depth() == 2
+    assert [context["task_id"] for _, _, context in coordinator.queued_tasks()] == [
+        context["task_id"] for _, context in coordinator.active_tasks()[:2]
+    ]
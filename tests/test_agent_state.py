@@ -0,0 +1,126 @@
+"""
+Agent state under concurrency: tasks run on worker threads while the event loop reads and changes the team
+"""
+
+import asyncio
+import threading
+
+import pytest
+
+from models.models import AgentRole, LLMAgent, Message, TaskType, MOCK_MODEL
+from providers import MultiAgentCoordinator
+
+WORKERS = 8
+TASKS_PER_WORKER = 25
+
+
+def _agent(name: str = "cook", seed: int = 7) -> LLMAgent:
+    return LLMAgent(name, AgentRole.LINE_COOK, MOCK_MODEL, device="cpu", seed=seed)
+
+
+def _tasks(count: int, start: int = 1):
+    return [
+        (task_type, {"task_number": start + i, "time_limit": 300, "ingredients": ["salt", "beef", "onion"]})
+        for i, task_type in enumerate([TaskType.COOKING_EXECUTION, TaskType.BASIC_COOKING, TaskType.CLEANING] * count)
+    ][:count]
+
+
+@pytest.mark.asyncio
+async def test_tasks_on_worker_threads_while_the_loop_reads():
+    """Concurrent tasks on one agent all land in its history, and reads never see it half-written"""
+    agent = _agent()
+    sender = _agent("chef")
+    stop = threading.Event()
+    reads = []
+
+    async def read():
+        while not stop.is_set():
+            metrics = agent.get_metrics()
+            assert metrics["tasks_completed"] <= len(agent.history())
+            reads.append(metrics["tasks_completed"])
+            await asyncio.to_thread(agent.memory_snapshot)
+            agent.receive_message(Message(sender.name, agent.name, sender.role, "Fire table 4"))
+            await asyncio.sleep(0)
+
+    def work():
+        for _ in range(TASKS_PER_WORKER):
+            agent.process_task(TaskType.COOKING_EXECUTION, {"task_id": "t", "ingredients": ["salt"]}, "cpu")
+
+    reader = asyncio.create_task(read())
+    await asyncio.gather(*(asyncio.to_thread(work) for _ in range(WORKERS)))
+    stop.set()
+    await reader
+
+    assert len(agent.history()) == WORKERS * TASKS_PER_WORKER
+    assert agent.get_metrics()["tasks_completed"] == WORKERS * TASKS_PER_WORKER
+    assert reads == sorted(reads)
+    assert len(agent.take_messages()) == len(agent.received_messages)
+    assert agent.take_messages() == []
+
+
+@pytest.mark.asyncio
+async def test_discard_only_takes_back_the_latest_execution():
+    agent = _agent()
+    first = await asyncio.to_thread(agent.process_task, TaskType.COOKING_EXECUTION, {"ingredients": []}, "cpu")
+    second = await asyncio.to_thread(agent.process_task, TaskType.CLEANING, {"ingredients": []}, "cpu")
+
+    assert not agent.discard_execution(first)
+    assert agent.discard_execution(second)
+    assert agent.history() == [first]
+
+
+@pytest.mark.asyncio
+async def test_each_execution_carries_its_own_response():
+    """Concurrent tasks can't overwrite each other's parsed response or degradation"""
+    agent = _agent()
+    task_types = [TaskType.COOKING_EXECUTION, TaskType.BASIC_COOKING, TaskType.CLEANING] * 10
+    executions = await asyncio.gather(*(
+        asyncio.to_thread(agent.process_task, task_type, {"ingredients": ["salt"]}, "cpu")
+        for task_type in task_types
+    ))
+
+    for task_type, execution in zip(task_types, executions):
+        assert execution.response is not None
+        assert execution.response.response_to == task_type.function_name
+        assert execution.degraded == (execution.degradation is not None)
+        assert "response" not in execution.to_dict()
+
+
+@pytest.mark.asyncio
+async def test_concurrent_assignments_during_a_run():
+    """Orders, releases and metric reads from the API while the run works tasks keep the books straight"""
+    coordinator = MultiAgentCoordinator(probe_interval=0)
+    coordinator.create_agent_team(MOCK_MODEL, 6)
+    coordinator.set_seed(11)
+    submitted = []
+    released = []
+
+    async def run():
+        # Held for the run, as the API does, so submissions see it executing
+        async with coordinator.run_lock:
+            return await coordinator.execute_scenario(_tasks(30), 60, run_id="race")
+
+    async def meddle():
+        while not coordinator.execution_history:
+            await asyncio.sleep(0)
+        for batch in range(5):
+            submitted.extend(coordinator.submit_orders(_tasks(3, start=100 + 3 * batch)))
+            for agent in list(coordinator.agents.values()):
+                agent.get_metrics()
+            if batch == 2:
+                # Someone goes home mid-run, perhaps while their task is on a worker thread
+                name = min(coordinator.agents, key=lambda n: coordinator.agents[n].role.value)
+                released.append(coordinator.release_staff(name))
+            await asyncio.sleep(0)
+
+    result, _ = await asyncio.gather(run(), meddle())
+
+    assert len(submitted) == 15
+    assert len(set(submitted)) == 15
+    assert result["total_tasks"] == 30 + 15
+    executed = sum(len(agent.history()) for agent in [*coordinator.agents.values(), *released])
+    assert executed == len(result["execution_history"])
+    assert result["tasks_completed"] == len(result["execution_history"])
+    for agent in coordinator.agents.values():
+        metrics = agent.get_metrics()
+        assert metrics["tasks_completed"] == len(agent.history())
@@ -0,0 +1,36 @@
+"""
+Commis: a trainee doing its own entry-level work is at its role's level, not discounted as a mismatch
+"""
+
+import pytest
+
+from models.models import AgentRole, LLMAgent, TaskType, MOCK_MODEL, at_role_level
+from providers.policies import role_match
+
+
+def test_entry_level_work_is_a_commis_own_level():
+    assert at_role_level(TaskType.CLEANING, AgentRole.COMMIS)
+    assert at_role_level(TaskType.CLEANING, AgentRole.KITCHEN_PORTER)
+    assert not at_role_level(TaskType.CLEANING, AgentRole.LINE_COOK)
+    assert not at_role_level(TaskType.BASIC_COOKING, AgentRole.COMMIS)
+
+
+def test_a_commis_cleaning_is_scored_as_role_matched():
+    commis = LLMAgent("commis", AgentRole.COMMIS, MOCK_MODEL, device="cpu", seed=3)
+    commis.skills.set_skill(TaskType.CLEANING, 1.0)
+    cook = LLMAgent("cook", AgentRole.LINE_COOK, MOCK_MODEL, device="cpu", seed=3)
+    context = {"ingredients": ["salt"], "time_limit": 300}
+
+    by_commis = commis.process_task(TaskType.CLEANING, dict(context), device="cpu")
+    by_cook = cook.process_task(TaskType.CLEANING, dict(context), device="cpu")
+
+    assert by_commis.success
+    assert by_commis.quality_score == pytest.approx(by_commis.response.confidence)
+    assert by_cook.quality_score == pytest.approx(by_cook.response.confidence * 0.8)
+
+
+def test_role_match_assigns_entry_level_work_to_the_commis():
+    commis = LLMAgent("commis", AgentRole.COMMIS, MOCK_MODEL, device="cpu")
+    cook = LLMAgent("cook", AgentRole.LINE_COOK, MOCK_MODEL, device="cpu")
+    candidates = [("cook", cook), ("commis", commis)]
+    assert role_match(TaskType.CLEANING, candidates, {}) == "commis"
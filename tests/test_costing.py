@@ -0,0 +1,110 @@
+"""
+Order costing and menu engineering: tickets costed from the catalog, sales kept per run, and the menu sorted into
+stars, plowhorses, puzzles and dogs for the planner
+"""
+
+import pytest
+
+from database.menu_store import MenuStore
+from kitchen.costing import cost_ticket, item_sales, menu_engineering
+from kitchen.menu import Menu, MenuItem
+from models.models import TaskType
+from recipes.dataset_parser import RecipeDatasetParser
+from recipes.ingredients import IngredientCatalog
+
+
+@pytest.fixture
+def parser() -> RecipeDatasetParser:
+    parser = RecipeDatasetParser()
+    parser.recipes = [
+        {"id": 1, "cuisine": "french", "ingredients": ["eggs", "butter"]},
+        {"id": 2, "cuisine": "french", "ingredients": ["flour", "salt"]},
+    ]
+    return parser
+
+
+def test_a_ticket_is_costed_line_by_line(parser):
+    menu = Menu(parser)
+    menu.plan([MenuItem(1, price=12.0), MenuItem(2)])
+    ticket = {"ticket": "order-1", "table": 4, "lines": [
+        {"line": 0, "recipe_id": 1, "dish": "french recipe 1", "ingredients": ["eggs", "butter"], "portions": [
+            {"task_id": "order-1", "state": "finished"}, {"task_id": "order-2", "state": "finished"}
+        ]},
+        {"line": 1, "recipe_id": None, "dish": "omelette", "ingredients": ["eggs"], "portions": [
+            {"task_id": "order-3", "state": "finished"}, {"task_id": "order-4", "state": "cancelled"}
+        ]},
+    ]}
+
+    # order-2 failed: it cost its ingredients but wasn't sold
+    costed = cost_ticket(ticket, {"order-1", "order-3"}, IngredientCatalog(), menu)
+    sold, off_menu = costed["lines"]
+    assert (sold["plate_cost"], sold["price"], sold["portions"], sold["sold"]) == (1.25, 12.0, 2, 1)
+    assert (sold["food_cost"], sold["revenue"]) == (2.5, 12.0)
+    assert (off_menu["plate_cost"], off_menu["price"], off_menu["portions"], off_menu["revenue"]) == (0.25, 5.0, 1, 5.0)
+    assert (costed["food_cost"], costed["revenue"], costed["margin"]) == (2.75, 17.0, 14.25)
+
+    assert item_sales([costed]) == {1: {"sold": 1, "food_cost": 1.25, "revenue": 12.0}}
+
+
+def test_items_are_classified_by_popularity_and_margin():
+    recipes = [{"recipe_id": i, "dish": f"dish {i}"} for i in range(1, 6)]
+    sales = {
+        1: {"sold": 50, "food_cost": 100.0, "revenue": 600.0},
+        2: {"sold": 40, "food_cost": 120.0, "revenue": 240.0},
+        3: {"sold": 5, "food_cost": 20.0, "revenue": 100.0},
+        4: {"sold": 5, "food_cost": 15.0, "revenue": 25.0},
+    }
+    prices = {i: 10.0 for i in range(1, 6)}
+    costs = {i: 2.0 for i in range(1, 6)}
+
+    report = menu_engineering(recipes, sales, prices, costs)
+    assert (report["total_sold"], report["popularity_threshold"], report["average_margin"]) == (100, 0.14, 7.1)
+    # Dish 5 hasn't sold, so it's margined at its price and plate cost
+    assert report["classes"] == {"star": [1], "plowhorse": [2], "puzzle": [3, 5], "dog": [4]}
+    assert [i["margin"] for i in report["items"]] == [10.0, 3.0, 16.0, 8.0, 2.0]
+
+    unsold = menu_engineering(recipes, {}, prices, costs)
+    assert unsold["average_margin"] is None
+    assert all(i["class"] is None for i in unsold["items"])
+
+
+def test_sales_are_kept_per_run(tmp_path):
+    store = MenuStore(str(tmp_path / "menu.db"))
+    store.record_sales("run-1", {1: {"sold": 3, "food_cost": 3.75, "revenue": 36.0}})
+    store.record_sales("run-2", {
+        1: {"sold": 1, "food_cost": 1.25, "revenue": 12.0}, 2: {"sold": 2, "food_cost": 0.6, "revenue": 10.0}
+    })
+    # Recording a run again replaces it
+    store.record_sales("run-1", {1: {"sold": 2, "food_cost": 2.5, "revenue": 24.0}})
+
+    assert store.sales() == {
+        1: {"sold": 3, "food_cost": 3.75, "revenue": 36.0, "runs": 2},
+        2: {"sold": 2, "food_cost": 0.6, "revenue": 10.0, "runs": 1}
+    }
+
+
+@pytest.mark.asyncio
+async def test_the_planner_is_shown_how_each_recipe_sold(parser, tmp_path, monkeypatch):
+    pytest.importorskip("fastapi")
+    from kitchen.api import ChefBenchAPI
+
+    monkeypatch.chdir(tmp_path)
+    api = ChefBenchAPI()
+    api.dataset_parser.recipes = parser.recipes
+    api.menu.plan([MenuItem(1, price=12.0), MenuItem(2)])
+    api.menu.store.record_sales("run-1", {
+        1: {"sold": 9, "food_cost": 11.25, "revenue": 108.0}, 2: {"sold": 1, "food_cost": 0.3, "revenue": 5.0}
+    })
+
+    get_report = next(
+        route.endpoint for route in api.app.routes
+        if getattr(route, "path", None) == "/reports/menu-engineering"
+    )
+    report = await get_report(days=None)
+    assert report["classes"] == {"star": [1], "plowhorse": [], "puzzle": [], "dog": [2]}
+
+    tasks = api._generate_scenario_tasks("standard", 1, use_dataset=False)
+    planning = [context for task_type, context in tasks if task_type == TaskType.MENU_PLANNING]
+    candidates = {c["recipe_id"]: c for c in planning[0]["menu_candidates"]}
+    assert (candidates[1]["class"], candidates[1]["sold"], candidates[1]["margin"]) == ("star", 9, 10.75)
+    assert candidates[2]["class"] == "dog"
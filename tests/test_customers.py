@@ -0,0 +1,155 @@
+"""
+Customers: seeded parties who order from the menu through a run, following a service day's demand curves
+"""
+
+import asyncio
+
+import pytest
+
+from dining import CustomerSimulator, DemandConfig, demand_summary
+from recipes.ingredients import ALLERGENS, DIETARY_TAGS
+
+MENU = [{"id": i, "cuisine": "french", "ingredients": ["eggs", "butter"]} for i in range(1, 9)]
+
+
+def _hours(config: DemandConfig, seed: int = 1):
+    return CustomerSimulator(seed, config).arrivals()
+
+
+def test_demand_peaks_at_lunch_and_dinner():
+    config = DemandConfig()
+    assert config.rate(config.lunch_peak) > config.rate(16.0)
+    assert config.rate(config.dinner_peak) > config.rate(config.lunch_peak)
+    assert config.rate(9.0) == 0.0 and config.rate(23.5) == 0.0
+
+    saturday = config.with_overrides({"day": "saturday"})
+    monday = config.with_overrides({"day": "monday"})
+    assert saturday.rate(16.0) / monday.rate(16.0) == pytest.approx(1.5 / 0.6)
+
+
+def test_arrivals_follow_the_curve():
+    hours = _hours(DemandConfig(orders_per_hour=20, max_orders=5000))
+    dinner = sum(1 for h in hours if 19 <= h < 20)
+    afternoon = sum(1 for h in hours if 15 <= h < 16)
+    assert dinner > 2 * afternoon
+    assert all(11 <= h <= 23 for h in hours)
+
+    # A busier day brings more customers
+    quiet = _hours(DemandConfig(orders_per_hour=20, max_orders=5000, day="monday"))
+    assert len(hours) > len(quiet)
+
+
+def test_orders_are_spread_over_the_run_and_seeded():
+    orders = CustomerSimulator(4).orders(MENU, 300, tables=[7, 8])
+    assert orders == CustomerSimulator(4).orders(MENU, 300, tables=[7, 8])
+    assert orders != CustomerSimulator(5).orders(MENU, 300, tables=[7, 8])
+
+    assert all(0 <= o.arrive_at <= 300 for o in orders)
+    assert [o.arrive_at for o in orders] == sorted(o.arrive_at for o in orders)
+    assert [o.table for o in orders[:3]] == [7, 8, 7]
+    # Every guest has a main
+    for order in orders:
+        mains = sum(item["quantity"] for item in order.items if item["course"] == "main")
+        assert mains == order.party_size
+
+
+def test_popular_dishes_get_most_of_the_orders():
+    def counts(config):
+        orders = CustomerSimulator(2, config).orders(MENU, 300)
+        tally = {}
+        for item in (item for order in orders for item in order.items):
+            tally[item["recipe_id"]] = tally.get(item["recipe_id"], 0) + item["quantity"]
+        return sorted(tally.values(), reverse=True)
+
+    busy = {"orders_per_hour": 10, "max_orders": 500}
+    skewed, flat = counts(DemandConfig(popularity_skew=2, **busy)), counts(DemandConfig(popularity_skew=0, **busy))
+    assert skewed[0] / sum(skewed) > 2 * flat[0] / sum(flat)
+
+    # Weights given by recipe stand in for the curve
+    only = counts(DemandConfig(item_weights={1: 1.0, **{i: 0.0 for i in range(2, 9)}}, **busy))
+    assert len(only) == 1
+
+
+def test_special_requests_and_courses_follow_their_rates():
+    config = DemandConfig(special_request_rate=1.0, starter_rate=0.0, dessert_rate=0.0)
+    orders = CustomerSimulator(3, config).orders(MENU, 300)
+    assert all(len(o.dietary_restrictions) == 1 for o in orders)
+    assert {r for o in orders for r in o.dietary_restrictions} <= set(ALLERGENS + DIETARY_TAGS)
+    assert {item["course"] for o in orders for item in o.items} == {"main"}
+
+    summary = demand_summary(orders)
+    assert summary["special_requests"] == summary["orders"] == len(orders)
+    assert summary["busiest_hour"] in summary["by_hour"]
+
+
+def test_demand_overrides_are_checked(monkeypatch):
+    with pytest.raises(ValueError):
+        DemandConfig().with_overrides({"day": "someday"})
+    with pytest.raises(ValueError):
+        DemandConfig().with_overrides({"rush": True})
+    with pytest.raises(ValueError):
+        DemandConfig().with_overrides({"party_size": [4, 2]})
+
+    monkeypatch.setenv("CHEFBENCH_DEMAND", '{"day": "sunday", "item_weights": {"3": 2}}')
+    config = DemandConfig.from_env()
+    assert config.day == "sunday"
+    assert config.item_weights == {3: 2.0}
+
+    monkeypatch.setenv("CHEFBENCH_DEMAND", '{"modification_rate": 2}')
+    assert DemandConfig.from_env() == DemandConfig()
+
+
+@pytest.mark.asyncio
+async def test_the_run_stays_open_for_customers_still_due(coordinator, order):
+    coordinator.floor.add_table(7, 4)
+    coordinator.floor.seat(party_size=4)
+    coordinator.enable_guests(request_rate=1.0, cancel_share=0.0)
+    coordinator.expect_orders(0.5)
+
+    async def customer():
+        await asyncio.sleep(0.3)
+        return coordinator.submit_orders(order(), table=7)
+
+    arriving = asyncio.create_task(customer())
+    result = await coordinator.execute_scenario([], 30, run_id="customers")
+    task_ids = await arriving
+
+    assert result["tasks_completed"] == 1
+    assert result["duration"] < 5
+    # The guests at the table can change the order once it's placed
+    assert [r.task_id for r in coordinator.guest_requests] == task_ids
+    assert coordinator.guest_requests[0].request_id == "guest-1"
+
+
+@pytest.mark.asyncio
+async def test_customers_order_what_the_menu_can_make(tmp_path, monkeypatch):
+    pytest.importorskip("fastapi")
+    from kitchen.api import ChefBenchAPI
+
+    monkeypatch.chdir(tmp_path)
+    api = ChefBenchAPI()
+    api.dataset_parser.recipes = [
+        {"id": 1, "cuisine": "french", "ingredients": ["eggs", "butter"]},
+        {"id": 2, "cuisine": "persian", "ingredients": ["saffron", "salt"]},
+    ]
+    coordinator = api.coordinator
+    async with coordinator.run_lock:
+        coordinator.run_id = "busy"
+        coordinator.scenario_start_time = None
+        evaluation = api.active_evaluations["busy"] = {
+            "status": "running", "seed": 3, "config": {},
+            "tasks": [{"task_type": "cooking_execution", "context": {"ingredients": ["eggs", "butter"]}}]
+        }
+        api._seat_customers(coordinator, "busy", CustomerSimulator(3), 60)
+        await asyncio.sleep(0.1)
+
+        customers = evaluation["customers"]
+        batch = evaluation["order_batches"][customers["batch_id"]]
+        assert customers["orders"] == len(batch["orders"]) > 0
+        assert customers["demand"]["day"] == "friday"
+        assert coordinator._orders_due_until == max(o["arrive_at"] for o in batch["orders"])
+        scheduled = [e for e in coordinator.event_log if e.event_type == "orders_scheduled"]
+        assert scheduled[-1].payload["source"] == "customers"
+    # What was still to come is dropped with the run
+    await asyncio.sleep(0.6)
+    assert {e["status"] for e in batch["orders"]} <= {"queued", "dropped"}
@@ -0,0 +1,145 @@
+"""
+Expeditor: table orders wait at the pass for the rest of their course, later courses fire as earlier ones go out, and slow ones are called back
+"""
+
+import asyncio
+
+import pytest
+
+from providers import MultiAgentCoordinator, Expeditor
+from providers.escalation import EXPEDITE
+
+
+@pytest.fixture
+def coordinator(coordinator) -> MultiAgentCoordinator:
+    coordinator.floor.add_table(7, 4)
+    coordinator.floor.seat(party_size=4)
+    coordinator.front_of_house.add_server("sam", [7])
+    coordinator.enable_expeditor()
+    return coordinator
+
+
+def _events(coordinator, event_type):
+    return [e for e in coordinator.event_log if e.event_type == event_type]
+
+
+async def _run(coordinator, place_orders):
+    """Run the kitchen with the orders place_orders submits once service has started"""
+    coordinator.expect_orders(0.2)
+
+    async def customers():
+        await asyncio.sleep(0.05)
+        return place_orders()
+
+    placing = asyncio.create_task(customers())
+    result = await coordinator.execute_scenario([], 30, run_id="expo")
+    return result, await placing
+
+
+def _ticket(portions: int):
+    return {"ticket": "order-1", "table": 7, "lines": [
+        {"course": "main", "portions": [{"task_id": f"order-{i + 1}", "state": "finished"} for i in range(portions)]}
+    ]}
+
+
+def test_a_course_goes_out_once_every_portion_is_done():
+    expo = Expeditor(callback_after=60)
+    ticket = _ticket(2)
+    ticket["lines"][0]["portions"][1]["state"] = "queued"
+    expo.receive("order-1", "order-1", "main", 7, True, now=10)
+    assert expo.check(ticket, "main", now=20) is None
+    assert expo.waiting() == [("order-1", "main")]
+
+    # Chased at half the limit once the table is behind its pacing
+    assert expo.overdue(now=50) == []
+    assert [(t, c) for t, c, _ in expo.overdue(now=50, behind={7})] == [("order-1", "main")]
+
+    expo.receive("order-2", "order-1", "main", 7, False, now=80)
+    sent = expo.check(_ticket(2), "main", now=80)
+    assert (sent.task_ids, sent.short, sent.waited_seconds) == (["order-1"], ["order-2"], 70)
+    assert not sent.clean and not expo.window
+    assert expo.score() == 0.0
+    assert expo.coordination(1.0) == 0.5
+    assert expo.current_course(_ticket(2)) is None
+
+    restored = Expeditor.from_dict(expo.to_dict())
+    assert restored.summary() == expo.summary()
+    with pytest.raises(ValueError):
+        Expeditor(callback_after=0)
+
+
+@pytest.mark.asyncio
+async def test_courses_go_out_in_order_as_the_expo_fires_them(coordinator, order):
+    result, task_ids = await _run(
+        coordinator, lambda: coordinator.submit_orders(
+            order(course="dessert") + order(2, course="main") + order(course="starter"), table=7
+        )
+    )
+    dessert, main_1, main_2, starter = task_ids
+
+    assert result["tasks_completed"] == 4
+    held = [(e.payload["course"], e.payload["author"]) for e in _events(coordinator, "course_held")]
+    assert held == [("main", "expo"), ("dessert", "expo")]
+    started = [e.task_id for e in _events(coordinator, "task_started")]
+    assert started == [starter, main_1, main_2, dessert]
+
+    sent = [(e.payload["course"], e.payload["task_ids"]) for e in _events(coordinator, "course_sent")]
+    assert sent == [("starter", [starter]), ("main", [main_1, main_2]), ("dessert", [dessert])]
+    served = _events(coordinator, "order_served")
+    assert [e.task_id for e in served] == [starter, main_1, main_2, dessert]
+    assert {e.agent_name for e in served} == {"sam"}
+    assert [e.payload["author"] for e in _events(coordinator, "course_fired")] == ["expo", "expo"]
+    assert coordinator.floor.tables[7].status == "dessert"
+
+    expo = result["agent_metrics"]["expo"]
+    assert (expo["courses_sent"], expo["clean"], expo["score"]) == (3, 3, 1.0)
+    assert not expo["callbacks"]
+
+
+@pytest.mark.asyncio
+async def test_a_course_left_waiting_is_called_back(coordinator, order):
+    coordinator.enable_expeditor(callback_after=0.001)
+
+    def place_orders():
+        first = coordinator.submit_orders(order(), table=7)
+        second = coordinator.submit_orders(order(), table=7)
+        # A second main joins the first ticket after the other table's order
+        late = coordinator.submit_orders(order(), table=7, ticket=first[0])
+        return first + second + late
+
+    result, (first, second, late) = await _run(coordinator, place_orders)
+
+    assert result["tasks_completed"] == 3
+    called = _events(coordinator, "course_called_back")
+    assert [(e.payload["ticket"], e.payload["task_ids"]) for e in called] == [(first, [late])]
+    assert [e.task_id for e in _events(coordinator, "order_called_back")] == [late]
+    assert coordinator.escalation.levels[late] == EXPEDITE
+    chased = [m for m in coordinator.message_bus if m.sender == "expo"]
+    assert chased and chased[0].recipient == "cook" and "fire it next" in chased[0].content
+
+    expo = result["agent_metrics"]["expo"]
+    assert [(s["ticket"], s["clean"]) for s in expo["sent"]] == [(second, True), (first, False)]
+    assert expo["score"] == 0.5
+    assert result["agent_metrics"]["team"]["coordination_score"] < 1.0
+
+
+@pytest.mark.asyncio
+async def test_a_held_course_fires_once_the_one_before_is_cancelled(coordinator, order):
+    def place_orders():
+        task_ids = coordinator.submit_orders(order(course="starter") + order(course="main"), table=7)
+        coordinator.cancel_order(task_ids[0])
+        return task_ids
+
+    result, (starter, main) = await _run(coordinator, place_orders)
+
+    assert result["tasks_completed"] == 1
+    assert [e.payload["course"] for e in _events(coordinator, "course_fired")] == ["main"]
+    assert [e.payload["task_ids"] for e in _events(coordinator, "course_sent")] == [[main]]
+
+
+def test_the_expo_survives_a_checkpoint(coordinator):
+    coordinator.expo.receive("order-1", "order-1", "main", 7, True, now=5)
+    checkpoint = coordinator.checkpoint_state()
+
+    coordinator.restore_checkpoint(checkpoint)
+    assert coordinator.expo.window == {"order-1": {"ticket": "order-1", "course": "main", "table": 7, "arrived": 5}}
@@ -0,0 +1,117 @@
+"""
+Golden runs: a recorded run replays from its cached responses, and a metric outside tolerance fails the check
+"""
+
+import copy
+import json
+
+import pytest
+
+import observability.transcripts
+from kitchen.golden import GoldenRun, ResponseCache, compare_metrics
+from metrics.scoring import score_run
+from models.models import AgentRole, GenerationError, TaskType, MOCK_MODEL
+from providers import MultiAgentCoordinator
+
+SEED = 5
+DURATION = 30
+
+
+def _tasks():
+    return [
+        (TaskType.COOKING_EXECUTION, {"ingredients": ["eggs", "butter"], "time_limit": 300}),
+        (TaskType.COOKING_EXECUTION, {"ingredients": ["rice", "saffron"], "time_limit": 300}),
+        (TaskType.MENU_PLANNING, {"ingredients": ["eggs", "rice"], "time_limit": 300}),
+    ]
+
+
+async def _record(monkeypatch, name: str = "dinner") -> GoldenRun:
+    """A completed evaluation of a seeded mock-model run, recorded with its transcripts"""
+    transcripts = []
+    monkeypatch.setattr(observability.transcripts, "_sink", transcripts.append)
+    coordinator = MultiAgentCoordinator(probe_interval=0)
+    coordinator.create_agent("chef", AgentRole.HEAD_CHEF, MOCK_MODEL)
+    coordinator.create_agent("cook", AgentRole.LINE_COOK, MOCK_MODEL)
+    coordinator.set_assignment_policy("role_match")
+    coordinator.set_seed(SEED)
+
+    tasks = _tasks()
+    kept = [{"task_type": t.function_name, "context": copy.deepcopy(c)} for t, c in tasks]
+    result = await coordinator.execute_scenario(tasks, DURATION, run_id="recorded")
+    result["scores"] = score_run(result, DURATION)
+    monkeypatch.setattr(observability.transcripts, "_sink", None)
+
+    evaluation = {
+        "id": "recorded",
+        "status": "completed",
+        "seed": SEED,
+        "config": {"duration_seconds": DURATION, "assignment_policy": "role_match"},
+        "tasks": kept,
+        "result": result
+    }
+    return GoldenRun.record(name, evaluation, transcripts)
+
+
+@pytest.mark.asyncio
+async def test_a_recorded_run_replays_within_tolerance(monkeypatch, tmp_path):
+    golden = await _record(monkeypatch)
+    assert len(golden.responses) == 3
+    assert {a["name"]: a["model_name"] for a in golden.team} == {"chef": MOCK_MODEL, "cook": MOCK_MODEL}
+    assert golden.metrics["tasks_completed"] == 3
+
+    golden = GoldenRun.load(golden.save(tmp_path / "dinner.json"))
+    report = await golden.verify()
+
+    assert report["passed"], [c for c in report["checks"] if not c["passed"]]
+    assert report["total"] == len(golden.metrics)
+    assert report["responses"] == {"hits": 3, "drift": 0, "misses": 0, "unused": 0}
+
+
+@pytest.mark.asyncio
+async def test_a_metric_outside_tolerance_fails(monkeypatch):
+    golden = await _record(monkeypatch)
+    # Cached answers that have lost their confidence, as a regressed model's would
+    for response in golden.responses:
+        answer = json.loads(response["response"])
+        response["response"] = json.dumps({**answer, "confidence": 0.1})
+
+    report = await golden.verify()
+
+    assert not report["passed"]
+    failed = {c["metric"]: c for c in report["checks"] if not c["passed"]}
+    assert "team.average_quality" in failed
+    check = failed["team.average_quality"]
+    assert check["delta"] < 0 and abs(check["delta"]) > check["tolerance"]
+    assert report["failed"] == len(failed)
+
+    # Tolerances as wide as the changes let the same replay through
+    golden.tolerances = {metric: abs(c["delta"]) for metric, c in failed.items()}
+    report = await golden.verify()
+    assert report["passed"], [c for c in report["checks"] if not c["passed"]]
+
+
+def test_a_metric_the_replay_no_longer_produces_fails():
+    checks = compare_metrics({"team.average_quality": 0.8, "tasks_completed": 3.0}, {"tasks_completed": 3.0})
+    assert [(c["metric"], c["passed"]) for c in checks] == [("tasks_completed", True), ("team.average_quality", False)]
+    assert checks[1]["actual"] is None
+
+
+def test_the_cache_answers_by_prompt_then_in_order():
+    cache = ResponseCache([
+        {"agent": "cook", "prompt_hash": "a" * 16, "response": "first"},
+        {"agent": "cook", "prompt_hash": "b" * 16, "response": "second"},
+    ])
+    assert cache.respond("cook", "a changed prompt") == "first"
+    assert cache.respond("cook", "another") == "second"
+    with pytest.raises(GenerationError):
+        cache.respond("cook", "one too many")
+    assert cache.stats() == {"hits": 0, "drift": 2, "misses": 1, "unused": 0}
+
+
+def test_only_completed_runs_are_recorded():
+    with pytest.raises(ValueError):
+        GoldenRun.record("early", {"status": "running", "tasks": [{}]}, [])
+    with pytest.raises(ValueError):
+        GoldenRun.record("resumed", {"status": "completed", "tasks": []}, [])
+    with pytest.raises(ValueError):
+        GoldenRun.from_dict({"version": 99})
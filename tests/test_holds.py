@@ -0,0 +1,123 @@
+"""
+Course holds: the pass holds and fires courses, and cooks who fire a held portion anyway cost the team coordination
+"""
+
+import asyncio
+import json
+
+import pytest
+
+from models.models import AgentRole
+from providers import MultiAgentCoordinator, CourseHolds
+
+
+@pytest.fixture
+def coordinator(coordinator) -> MultiAgentCoordinator:
+    coordinator.floor.add_table(7, 4)
+    coordinator.floor.add_table(8, 2)
+    coordinator.floor.seat(party_size=4)
+    coordinator.floor.seat(party_size=2)
+    return coordinator
+
+
+def test_a_course_held_everywhere_can_be_fired_at_one_table():
+    holds = CourseHolds()
+    assert holds.hold("dessert")
+    assert not holds.hold("dessert", 7)
+    assert holds.holding({"course": "dessert", "table": 7}) == "desserts"
+    assert holds.holding({"course": "main", "table": 7}) is None
+
+    assert holds.fire("dessert", 7)
+    assert holds.holding({"course": "dessert", "table": 7}) is None
+    assert holds.holding({"course": "dessert", "table": 8}) == "desserts"
+    assert holds.active()[0]["fired_at"] == [7]
+
+    # Holding it everywhere again takes back table 7
+    assert holds.hold("dessert")
+    assert holds.holding({"course": "dessert", "table": 7}) == "desserts"
+    assert holds.fire("dessert") and not holds.fire("dessert")
+    with pytest.raises(ValueError):
+        holds.hold("soup")
+
+
+def test_holding_a_table_tells_its_cooks_and_shows_on_the_ticket(coordinator, order):
+    mains = coordinator.submit_orders(order(2, course="main"), table=7)
+    coordinator.submit_orders(order(course="main"), table=8)
+
+    result = coordinator.hold_course("main", table=7, author="expo")
+    assert result["changed"]
+    assert result["task_ids"] == mains
+    assert result["holding"][0]["hold"] == "mains for table 7"
+    told = [m for m in coordinator.message_bus if m.recipient == "cook"]
+    assert told[-1].content.startswith("Hold mains for table 7")
+    assert told[-1].role == AgentRole.HEAD_CHEF
+
+    lines = coordinator.order_ticket(mains[0])["lines"]
+    assert [line["course"] for line in lines] == ["main", "main"]
+    assert [line["portions"][0]["state"] for line in lines] == ["held", "held"]
+    assert [e.task_id for e in coordinator.event_log if e.event_type == "order_held"] == mains
+
+    assert coordinator.fire_course("main", table=7)["task_ids"] == mains
+    assert coordinator.order_ticket(mains[0])["lines"][0]["portions"][0]["state"] == "queued"
+
+
+def test_orders_submitted_during_a_hold_wait_from_the_start(coordinator, order):
+    coordinator.hold_course("dessert")
+    task_ids = coordinator.submit_orders(order(course="dessert"), table=8)
+    assert [e.task_id for e in coordinator.event_log if e.event_type == "order_held"] == task_ids
+
+
+@pytest.mark.asyncio
+async def test_cooks_hold_until_the_pass_fires(coordinator, order):
+    coordinator.hold_course("dessert")
+
+    async def fire_later():
+        # Fired once the cook has put both desserts aside and the run is waiting on the pass
+        while len([e for e in coordinator.event_log if e.event_type == "hold_respected"]) < 2:
+            await asyncio.sleep(0.01)
+        coordinator.fire_course("dessert")
+
+    firing = asyncio.create_task(fire_later())
+    tasks = order(2, course="dessert") + order(course="main")
+    result = await coordinator.execute_scenario(tasks, 60, run_id="holds")
+    await firing
+
+    assert result["tasks_completed"] == 3
+    started = [e.task_id for e in coordinator.event_log if e.event_type == "task_started"]
+    assert started[0] == tasks[2][1]["task_id"]  # the main went first
+    assert result["agent_metrics"]["team"]["hold_violations"] == 0
+    assert result["agent_metrics"]["holds"]["calls"][-1]["call"] == "fire"
+
+
+@pytest.mark.asyncio
+async def test_firing_a_held_portion_is_a_violation(coordinator, monkeypatch, order):
+    coordinator.hold_course("starter")
+    cook = coordinator.agents["cook"]
+    monkeypatch.setattr(cook, "answer_question", lambda question: json.dumps({"answer": "FIRE"}))
+
+    result = await coordinator.execute_scenario(order(course="starter"), 60, run_id="violation")
+
+    assert result["tasks_completed"] == 1
+    violations = result["agent_metrics"]["holds"]["violations"]
+    assert [v["hold"] for v in violations] == ["starters"]
+    team = result["agent_metrics"]["team"]
+    assert team["hold_violations"] == 1
+    assert team["coordination_score"] == pytest.approx(0.9)
+
+
+@pytest.mark.asyncio
+async def test_a_run_held_to_the_end_stops_at_its_time_limit(coordinator, order):
+    coordinator.hold_course("dessert")
+    result = await coordinator.execute_scenario(order(course="dessert"), 0.2, run_id="held")
+    assert result["tasks_completed"] == 0
+    assert not coordinator.execution_history
+
+
+def test_holds_survive_a_checkpoint(coordinator):
+    coordinator.hold_course("dessert")
+    coordinator.fire_course("dessert", table=7)
+    checkpoint = coordinator.checkpoint_state()
+
+    coordinator.restore_checkpoint(checkpoint)
+    assert coordinator.holds.holding({"course": "dessert", "table": 8}) == "desserts"
+    assert coordinator.holds.holding({"course": "dessert", "table": 7}) is None
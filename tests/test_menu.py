@@ -0,0 +1,154 @@
+"""
+The menu and its 86-list: the planned items and how they're billed, and those the kitchen can't make,
+derived from stock and spoilage, and refused at the door
+"""
+
+import pytest
+
+from database.menu_store import MenuStore
+from eta import ETAEstimator
+from kitchen.menu import Menu, MenuItem
+from kitchen.orders import OrderItem, OrderPlanner
+from models.models import AgentRole, TaskType, MOCK_MODEL
+from providers import MultiAgentCoordinator
+from recipes.dataset_parser import RecipeDatasetParser
+from recipes.ingredients import IngredientCatalog
+
+ON_HAND = ["salt", "eggs", "butter", "flour"]
+
+
+@pytest.fixture
+def parser() -> RecipeDatasetParser:
+    parser = RecipeDatasetParser()
+    parser.recipes = [
+        {"id": 1, "cuisine": "french", "ingredients": ["eggs", "butter"]},
+        {"id": 2, "cuisine": "persian", "ingredients": ["saffron", "salt"]},
+        {"id": 3, "cuisine": "french", "ingredients": ["flour", "salt"]},
+    ]
+    return parser
+
+
+@pytest.fixture
+def coordinator(coordinator) -> MultiAgentCoordinator:
+    coordinator.create_agent("chef", AgentRole.HEAD_CHEF, MOCK_MODEL)
+    return coordinator
+
+
+def test_items_out_of_stock_are_86d(parser):
+    eighty_sixed = Menu(parser).eighty_six_list(ON_HAND)
+    assert [item.recipe_id for item in eighty_sixed] == [2]
+    assert eighty_sixed[0].reasons == ["out of saffron"]
+
+
+def test_spoiled_stock_86s_what_needs_it(parser):
+    # Matched by catalog id, so "Eggs" spoils the eggs on hand
+    eighty_sixed = Menu(parser).eighty_six_list(ON_HAND, spoiled={"Eggs"})
+    assert [item.recipe_id for item in eighty_sixed] == [1, 2]
+    assert eighty_sixed[0].spoiled == ["eggs"]
+    assert eighty_sixed[0].reasons == ["spoiled: eggs"]
+
+
+def test_86_by_hand_until_restored(parser):
+    menu = Menu(parser)
+    menu.eighty_six(3, "Fryer oil changeover")
+    assert menu.check(parser.recipes[2], ON_HAND).reasons == ["Fryer oil changeover"]
+
+    assert menu.restore(3)
+    assert menu.check(parser.recipes[2], ON_HAND) is None
+    assert not menu.restore(3)
+    with pytest.raises(KeyError):
+        menu.eighty_six(99)
+
+
+def test_orders_for_86d_items_are_refused_up_front(parser, coordinator):
+    menu = Menu(parser)
+    planner = OrderPlanner(coordinator, parser, IngredientCatalog(), ETAEstimator(), menu)
+    coordinator.spoiled.add("eggs")
+
+    plan = planner.plan([OrderItem(recipe_id=1), OrderItem(recipe_id=3, quantity=2)], ON_HAND)
+    assert not plan.feasible
+    refused, ok = plan.items
+    assert refused.errors == ["french recipe 1 is 86'd: spoiled: eggs"]
+    assert refused.eighty_sixed == ["spoiled: eggs"]
+    assert refused.agent is None  # nothing past the 86-list was checked
+    assert ok.errors == []
+    assert len(plan.tasks()) == 2
+
+
+def test_the_team_hears_of_an_86_once_per_run(coordinator):
+    assert coordinator.announce_86(2, "persian recipe 2", ["out of saffron"])
+    assert not coordinator.announce_86(2, "persian recipe 2", ["out of saffron"])
+
+    messages = {m.recipient: m for m in coordinator.message_bus}
+    assert set(messages) == {"chef", "cook"}
+    assert "special" in messages["chef"].content
+    assert messages["chef"].requires_response
+    assert [e.event_type for e in coordinator.event_log].count("item_86d") == 1
+
+    assert coordinator.announce_restored(2, "persian recipe 2")
+    assert not coordinator.announce_restored(2, "persian recipe 2")
+    assert coordinator.eighty_sixed == {}
+
+
+def test_only_the_planned_menu_can_be_ordered(parser, coordinator, tmp_path):
+    menu = Menu(parser, MenuStore(str(tmp_path / "menu.db")))
+    assert [r['id'] for r in menu.recipes()] == [1, 2, 3]
+
+    menu.plan([MenuItem(1, specialty=True), MenuItem(3, seasonal=True)])
+    assert [r['id'] for r in menu.recipes()] == [1, 3]
+    assert [r['id'] for r in menu.specialties()] == [1]
+    planner = OrderPlanner(coordinator, parser, IngredientCatalog(), ETAEstimator(), menu)
+    refused, ok = planner.plan([OrderItem(recipe_id=2), OrderItem(recipe_id=3)], ON_HAND).items
+    assert refused.errors == ["Recipe 2 is not on the menu"]
+    assert ok.errors == []
+
+    with pytest.raises(KeyError):
+        menu.plan([MenuItem(99)])
+    with pytest.raises(KeyError):
+        menu.eighty_six(2)
+    assert [r['id'] for r in menu.recipes()] == [1, 3]
+
+    # The plan, its marks and the 86s by hand are read back after a restart
+    menu.update(3, seasonal=False, specialty=True)
+    menu.eighty_six(1, "Out of the good butter")
+    menu = Menu(parser, MenuStore(str(tmp_path / "menu.db")))
+    assert menu.item(3) == MenuItem(3, specialty=True, seasonal=False)
+    assert menu.check(parser.recipes[0], ON_HAND).reasons == ["Out of the good butter"]
+
+    assert menu.clear()
+    assert [r['id'] for r in menu.recipes()] == [1, 2, 3]
+    assert not menu.clear()
+
+
+@pytest.mark.asyncio
+async def test_a_menu_planning_task_writes_the_menu_through_the_api(parser, coordinator, tmp_path, monkeypatch):
+    pytest.importorskip("fastapi")
+    from kitchen.api import ChefBenchAPI
+
+    monkeypatch.chdir(tmp_path)
+    api = ChefBenchAPI()
+    api.dataset_parser.recipes = parser.recipes
+    coordinator.menu_sink = api._write_menu
+    coordinator.set_assignment_policy("role_match")
+    coordinator.set_seed(3)
+    candidates = [{"recipe_id": r['id'], "dish": f"dish {r['id']}", "ingredients": r['ingredients']} for r in parser.recipes]
+    context = {"ingredients": ON_HAND, "time_limit": 300, "menu_candidates": candidates}
+
+    await coordinator.execute_scenario([(TaskType.MENU_PLANNING, context)], 30, run_id="planning")
+
+    planned = next(e for e in coordinator.event_log if e.event_type == "menu_planned")
+    assert planned.agent_name == "chef"
+    assert sorted(api.menu.planned) == sorted(planned.payload["items"])
+    assert planned.payload["specialties"] == [r['id'] for r in api.menu.specialties()]
+    get_menu = next(
+        route.endpoint for route in api.app.routes
+        if getattr(route, "path", None) == "/menu" and "GET" in route.methods
+    )
+    listed = await get_menu(cuisine=None, available=None, specialty=None, seasonal=None, limit=50, offset=0)
+    assert listed["planned"]
+    assert [item["recipe_id"] for item in listed["items"]] == sorted(planned.payload["items"])
+
+    # Recipes the kitchen doesn't have are left off, and count against the planner
+    assert api._write_menu("chef", [{"recipe_id": 1}, {"recipe_id": "white truffle risotto"}]) == ["white truffle risotto"]
+    assert list(api.menu.planned) == [1]
+    assert api.menu.planned[1].planned_by == "chef"
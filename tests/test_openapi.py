@@ -0,0 +1,22 @@
+"""
+The checked-in OpenAPI document and the hand-written client against the live routes
+"""
+
+import pytest
+
+pytest.importorskip("fastapi")
+# kitchen.api loads the model stack through models.models
+pytest.importorskip("torch")
+pytest.importorskip("transformers")
+
+from scripts.export_openapi import check, client_routes
+
+
+def test_openapi_document_and_client_match_the_routes():
+    assert check() == []
+
+
+def test_client_routes_are_collected_with_their_methods():
+    routes = client_routes()
+    assert ("GET", "/healthz") in routes
+    assert ("POST", "/admin/webhooks/{}/test") in routes
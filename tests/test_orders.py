@@ -0,0 +1,71 @@
+"""
+Order submission into a run: task ids, tickets and the tables they're served to
+"""
+
+import pytest
+
+from models.models import AgentRole, TaskType, MOCK_MODEL
+
+
+def test_order_ids_stay_unique_when_tasks_are_dropped(coordinator, order):
+    """Tasks no one on shift can cook never reach the queue, but their ids are still taken"""
+    dropped = coordinator.submit_orders(order(2, TaskType.MENU_PLANNING))
+    queued = coordinator.submit_orders(order(2))
+
+    assert dropped == ["order-1", "order-2"]
+    assert queued == ["order-3", "order-4"]
+    assert set(coordinator.tickets) == {"order-1", "order-3"}
+
+
+def test_order_counter_survives_a_checkpoint(coordinator, order):
+    coordinator.submit_orders(order(3))
+    checkpoint = coordinator.checkpoint_state()
+
+    coordinator.restore_checkpoint(checkpoint)
+    assert coordinator.submit_orders(order()) == ["order-4"]
+
+    # Checkpoints taken before the counter was kept number on from the pending orders
+    del checkpoint["orders_submitted"]
+    coordinator.restore_checkpoint(checkpoint)
+    assert coordinator.submit_orders(order()) == ["order-4"]
+
+
+def test_order_for_a_removed_table_is_refused(coordinator, order):
+    coordinator.floor.add_table(4, 2)
+    coordinator.floor.remove_table(4)
+
+    with pytest.raises(ValueError, match="Table 4"):
+        coordinator.submit_orders(order(), table=4)
+    assert coordinator.tickets == {}
+    assert not coordinator._queue
+    assert coordinator.submit_orders(order()) == ["order-1"]
+
+
+@pytest.mark.asyncio
+async def test_notes_reach_the_cook_and_priority_moves_the_order_up(tmp_path, monkeypatch):
+    pytest.importorskip("fastapi")
+    from kitchen.api import ChefBenchAPI, OrderSubmissionRequest
+
+    monkeypatch.chdir(tmp_path)
+    api = ChefBenchAPI()
+    api.coordinator.create_agent("cook", AgentRole.LINE_COOK, MOCK_MODEL)
+    submit = next(
+        route.endpoint for route in api.app.routes
+        if getattr(route, "path", None) == "/orders" and "POST" in route.methods
+    )
+    item = {"dish": "omelette", "ingredients": ["eggs", "butter"]}
+
+    async with api.coordinator.run_lock:
+        api.coordinator.run_id = "busy"
+        api.active_evaluations["busy"] = {"status": "running", "tasks": []}
+        await submit(OrderSubmissionRequest(items=[item]))
+        rushed = await submit(OrderSubmissionRequest(items=[{**item, "notes": ["no butter"]}], priority="expedite"))
+
+        review = await submit(OrderSubmissionRequest(items=[{**item, "notes": ["x" * 201]}]), dry_run=True)
+        assert not review["feasible"]
+        assert review["items"][0]["errors"] == ["Notes must be 1-200 characters"]
+
+    _, _, first = api.coordinator._queue[0]
+    assert [first["task_id"]] == rushed["task_ids"]
+    assert first["modifications"] == ["no butter"]
+    assert rushed["priority"] == "expedite"
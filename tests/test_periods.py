@@ -0,0 +1,100 @@
+"""
+Service periods: each ticket is reported under the part of the service day it was placed in, with its covers, revenue and table turn
+"""
+
+import asyncio
+
+import pytest
+
+from database.menu_store import MenuStore
+from dining import DemandConfig, service_report, combine_periods
+from kitchen.menu import Menu, MenuItem, list_price
+from providers import MultiAgentCoordinator
+from recipes.dataset_parser import RecipeDatasetParser
+
+
+def _ticket(name, placed_at, portions, table=None, covers=1, turn_seconds=None):
+    return {
+        "ticket": name, "table": table, "covers": covers, "placed_at": placed_at, "turn_seconds": turn_seconds,
+        "portions": [
+            {"task_id": f"{name}-{i}", "recipe_id": 1, "ingredients": [], "served": served}
+            for i, served in enumerate(portions)
+        ]
+    }
+
+
+def test_the_run_spans_the_service_day():
+    demand = DemandConfig()
+    assert demand.hour_at(0, 120) == 11.0
+    assert demand.hour_at(60, 120) == 17.0
+    assert demand.hour_at(500, 120) == 23.0
+    assert [demand.period_at(h) for h in (11.0, 15.9, 16.0, 23.0, 10.0)] == ["lunch", "lunch", "dinner", "dinner", None]
+
+    with pytest.raises(ValueError):
+        demand.with_overrides({"service_periods": {"lunch": [12, 17], "dinner": [16, 23]}})
+    with pytest.raises(ValueError):
+        demand.with_overrides({"service_periods": {"brunch": [10]}})
+    late = demand.with_overrides({"service_periods": {"dinner": [18, 23]}})
+    assert late.period_at(12.0) is None
+    assert late.to_dict()["service_periods"] == {"dinner": [18.0, 23.0]}
+
+
+def test_periods_add_up_covers_revenue_and_turns():
+    demand = DemandConfig()
+    tickets = [
+        _ticket("a", 10, [True, True], table=1, covers=2, turn_seconds=40.0),
+        _ticket("b", 20, [True, False], table=2, covers=4, turn_seconds=None),
+        _ticket("c", 90, [False]),
+        _ticket("d", 100, [True], table=1, covers=3, turn_seconds=60.0),
+    ]
+    report = service_report(tickets, demand, 120, price=lambda portion: 12.5, seats=10)
+
+    lunch, dinner = report["periods"]
+    assert (lunch["period"], lunch["tickets"], lunch["tickets_served"], lunch["covers"]) == ("lunch", 2, 2, 6)
+    assert (lunch["revenue"], lunch["average_ticket"], lunch["portions_served"]) == (37.5, 18.75, 3)
+    assert (lunch["tables_turned"], lunch["table_turn_seconds"], lunch["seat_turns"]) == (1, 40.0, 0.6)
+    assert (dinner["tickets"], dinner["tickets_served"], dinner["covers"], dinner["revenue"]) == (2, 1, 3, 12.5)
+    total = report["total"]
+    assert (total["covers"], total["revenue"], total["table_turn_seconds"]) == (9, 50.0, 50.0)
+
+    days = combine_periods([report, report])
+    assert [(p["period"], p["covers"], p["revenue"], p["table_turn_seconds"]) for p in days] == [
+        ("lunch", 12, 75.0, 40.0), ("dinner", 6, 25.0, 60.0)
+    ]
+
+
+def test_items_sell_at_their_price_or_list_price(tmp_path):
+    parser = RecipeDatasetParser()
+    parser.recipes = [{"id": 1, "cuisine": "french", "ingredients": ["eggs"]}]
+    assert list_price(1.0) == 5.0
+    assert list_price(4.2) == 14.0
+
+    menu = Menu(parser, MenuStore(str(tmp_path / "menu.db")))
+    assert menu.price(1, 4.2) == 14.0
+    menu.plan([MenuItem(1, price=18.0)])
+    menu.update(1, specialty=True)
+    assert Menu(parser, MenuStore(str(tmp_path / "menu.db"))).price(1, 4.2) == 18.0
+
+
+@pytest.mark.asyncio
+async def test_tickets_carry_their_covers_and_turn(coordinator: MultiAgentCoordinator, order):
+    coordinator.floor.add_table(7, 4)
+    coordinator.floor.seat(party_size=3)
+    coordinator.expect_orders(0.2)
+
+    async def place_orders():
+        await asyncio.sleep(0.05)
+        seated = coordinator.submit_orders(order(2), table=7)
+        walk_in = coordinator.submit_orders(order(), covers=2)
+        return seated, walk_in
+
+    placing = asyncio.create_task(place_orders())
+    await coordinator.execute_scenario([], 30, run_id="periods")
+    seated, walk_in = await placing
+
+    ledger = {t["ticket"]: t for t in coordinator.ticket_ledger()}
+    at_table, to_go = ledger[seated[0]], ledger[walk_in[0]]
+    assert (at_table["table"], at_table["covers"], to_go["covers"]) == (7, 3, 2)
+    assert at_table["placed_at"] > 0
+    assert [p["served"] for p in at_table["portions"]] == [True, True]
+    assert at_table["turn_seconds"] > 0
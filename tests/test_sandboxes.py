@@ -0,0 +1,48 @@
+"""
+Session sandboxes: each session's kitchen keeps its own staffing, skills and floor plan
+"""
+
+import pytest
+
+pytest.importorskip("fastapi")
+# kitchen.api loads the model stack through models.models
+pytest.importorskip("torch")
+pytest.importorskip("transformers")
+
+from kitchen.api import ChefBenchAPI, AgentCreationRequest, TableRequest
+
+
+def _endpoint(api: ChefBenchAPI, method: str, path: str):
+    return next(
+        route.endpoint for route in api.app.routes
+        if getattr(route, "path", None) == path and method in route.methods
+    )
+
+
+@pytest.mark.asyncio
+async def test_a_session_sandbox_has_its_own_saved_floor_and_staff(tmp_path, monkeypatch):
+    monkeypatch.chdir(tmp_path)
+    api = ChefBenchAPI()
+    add_table = _endpoint(api, "POST", "/tables")
+    list_tables = _endpoint(api, "GET", "/tables")
+    hire = _endpoint(api, "POST", "/staff/pool")
+
+    with api.sandboxes.bind("alice"):
+        await add_table(TableRequest(number=7, capacity=4))
+        await hire(AgentCreationRequest(name="relief", role="LINE_COOK", device="cpu"))
+        assert str(api.coordinator.skill_store.path) == "data/sessions/alice/skills.json"
+    assert (tmp_path / "data/sessions/alice/floor.json").exists()
+    assert (tmp_path / "data/sessions/alice/staffing.json").exists()
+
+    # The default kitchen doesn't see alice's table or hire
+    assert 7 not in api.coordinator.floor.tables
+    assert "relief" not in api.coordinator.hr.pool
+    assert str(api.coordinator.floor.path) == "data/floor.json"
+    await add_table(TableRequest(number=7, capacity=2))
+
+    # A discarded sandbox gets its floor and staff back when the session returns
+    assert api.sandboxes.remove("alice")
+    with api.sandboxes.bind("alice"):
+        tables = (await list_tables())["tables"]
+        assert [(t["number"], t["capacity"]) for t in tables] == [(7, 4)]
+        assert "relief" in api.coordinator.hr.pool
@@ -0,0 +1,131 @@
+"""
+Scripted simulator: mock-model agents run whole scenarios offline, with failures at the rates the script sets
+"""
+
+import pytest
+
+import models.simulator
+from models.models import AgentRole, TaskType, MOCK_MODEL
+from models.simulator import HALLUCINATED_INGREDIENT, ScriptedSimulator, SimulatorScript
+from providers import MultiAgentCoordinator
+
+
+def _tasks(count: int = 4, restricted=()):
+    return [
+        (TaskType.COOKING_EXECUTION, {
+            "ingredients": ["eggs", "butter", "milk", "chives"],
+            "restricted_ingredients": list(restricted),
+            "time_limit": 300
+        })
+        for _ in range(count)
+    ]
+
+
+def _scripted(monkeypatch, **rates) -> ScriptedSimulator:
+    simulator = ScriptedSimulator(SimulatorScript(**rates))
+    monkeypatch.setattr(models.simulator, "_simulator", simulator)
+    return simulator
+
+
+def _coordinator(seed: int = 7, fallback_policy: str = "retry") -> MultiAgentCoordinator:
+    coordinator = MultiAgentCoordinator(probe_interval=0)
+    coordinator.create_agent("chef", AgentRole.HEAD_CHEF, MOCK_MODEL, fallback_policy)
+    coordinator.create_agent("cook", AgentRole.LINE_COOK, MOCK_MODEL, fallback_policy)
+    coordinator.set_seed(seed)
+    return coordinator
+
+
+def _events(coordinator, event_type):
+    return [e for e in coordinator.event_log if e.event_type == event_type]
+
+
+@pytest.mark.asyncio
+async def test_a_clean_script_cooks_around_the_restrictions(monkeypatch):
+    simulator = _scripted(monkeypatch)
+    coordinator = _coordinator()
+
+    result = await coordinator.execute_scenario(_tasks(restricted=["butter"]), 30, run_id="clean")
+
+    assert result["tasks_completed"] == 4
+    assert simulator.stats["calls"] == 4
+    assert not _events(coordinator, "restriction_violated")
+    assert not _events(coordinator, "invalid_action")
+    for execution in coordinator.execution_history:
+        parameters = execution.response.parameters
+        assert "butter" not in parameters["ingredients"]
+        assert parameters["dietary"] == "without butter"
+        assert execution.response.action == "cook_to_order"
+        assert not execution.degraded
+
+
+@pytest.mark.asyncio
+async def test_seeded_runs_decide_the_same(monkeypatch):
+    _scripted(monkeypatch)
+
+    async def decisions():
+        coordinator = _coordinator(seed=11)
+        await coordinator.execute_scenario(_tasks(), 30, run_id="seeded")
+        return [(e.agent_name, e.response.parameters, e.response.estimated_time) for e in coordinator.execution_history]
+
+    assert await decisions() == await decisions()
+
+
+@pytest.mark.asyncio
+async def test_hallucinations_and_violations_are_caught(monkeypatch):
+    simulator = _scripted(monkeypatch, hallucination_rate=1.0, violation_rate=1.0)
+    coordinator = _coordinator()
+
+    await coordinator.execute_scenario(_tasks(2, restricted=["butter"]), 30, run_id="caught")
+
+    assert simulator.stats["hallucinations"] == simulator.stats["violations"] == 2
+    invalid = _events(coordinator, "invalid_action")
+    assert [e.payload["references"] for e in invalid] == [[f"ingredient:{HALLUCINATED_INGREDIENT}"]] * 2
+    violated = _events(coordinator, "restriction_violated")
+    assert [e.payload["ingredients"] for e in violated] == [["butter"], ["butter"]]
+
+
+@pytest.mark.asyncio
+async def test_a_failing_role_falls_back_while_the_rest_keep_to_the_script(monkeypatch):
+    simulator = _scripted(monkeypatch, roles={"LINE_COOK": {"error_rate": 1.0}})
+    coordinator = _coordinator(fallback_policy="heuristic")
+    coordinator.set_assignment_policy("role_match")
+
+    tasks = _tasks(2) + [(TaskType.MENU_PLANNING, {"ingredients": ["eggs"], "time_limit": 300})]
+    result = await coordinator.execute_scenario(tasks, 30, run_id="fallback")
+
+    assert simulator.stats["errors"] == 2
+    degraded = _events(coordinator, "task_degraded")
+    assert {e.agent_name for e in degraded} == {"cook"}
+    assert all(e.payload["policy"] == "heuristic" for e in degraded)
+    chef = [e for e in coordinator.execution_history if e.agent_name == "chef"]
+    assert chef and chef[0].response.action == "plan_menu" and not chef[0].degraded
+    assert result["agent_metrics"]["team"]["degraded_tasks"] == 2
+
+
+@pytest.mark.asyncio
+async def test_malformed_answers_fail_their_tasks(monkeypatch):
+    simulator = _scripted(monkeypatch, malformed_rate=1.0)
+    coordinator = _coordinator()
+
+    await coordinator.execute_scenario(_tasks(2), 30, run_id="malformed")
+
+    assert simulator.stats["malformed"] == 2
+    assert [e.success for e in coordinator.execution_history] == [False, False]
+    assert all(e.chosen_approach == "FAILED" for e in coordinator.execution_history)
+
+
+def test_scripts_are_checked(monkeypatch):
+    with pytest.raises(ValueError):
+        ScriptedSimulator().configure(error_rate=1.5)
+    with pytest.raises(ValueError):
+        ScriptedSimulator().configure(roles={"LINE_COOK": {"typo_rate": 0.5}})
+
+    monkeypatch.setenv("CHEFBENCH_MOCK_SCRIPT", '{"malformed_rate": 0.25, "roles": {"HEAD_CHEF": {"error_rate": 1}}}')
+    script = SimulatorScript.from_env()
+    assert script.rates_for("HEAD_CHEF")["error_rate"] == 1
+    assert script.rates_for("LINE_COOK") == {
+        "error_rate": 0.0, "malformed_rate": 0.25, "hallucination_rate": 0.0, "violation_rate": 0.0
+    }
+
+    monkeypatch.setenv("CHEFBENCH_MOCK_SCRIPT", '{"violation_rate": -1}')
+    assert SimulatorScript.from_env() == SimulatorScript()
@@ -0,0 +1,75 @@
+"""
+Station capacity: tasks past a station's slots wait unassigned until one frees up
+"""
+
+import pytest
+
+from equipment import STATION_CAPACITY, station_capacity_from_env, station_for
+from models.models import TaskType
+from providers import MultiAgentCoordinator
+
+
+@pytest.fixture
+def coordinator(coordinator) -> MultiAgentCoordinator:
+    coordinator.station_capacity["hot_line"] = 2
+    return coordinator
+
+
+def test_orders_past_capacity_are_deferred(coordinator, order):
+    task_ids = coordinator.submit_orders(order(5))
+
+    assert [c['task_id'] for _, _, c in coordinator._queue] == task_ids[:2]
+    assert [c['task_id'] for _, _, c in coordinator._deferred] == task_ids[2:]
+    assert coordinator._deferrals == {"hot_line": 3}
+    deferred = [e for e in coordinator.event_log if e.event_type == "task_deferred"]
+    assert [e.task_id for e in deferred] == task_ids[2:]
+    # Other stations still take work
+    coordinator.submit_orders(order(1, TaskType.CLEANING))
+    assert len(coordinator._queue) == 3
+
+
+def test_a_deferred_order_can_be_cancelled(coordinator, order):
+    task_ids = coordinator.submit_orders(order(3))
+
+    result = coordinator.cancel_order(task_ids[2])
+    assert result == {"task_id": task_ids[2], "status": "cancelled", "agent_name": None}
+    assert not coordinator._deferred
+    lines = coordinator.order_ticket(task_ids[0])["lines"]
+    assert [line["portions"][0]["state"] for line in lines] == ["queued", "queued", "cancelled"]
+
+
+def test_deferred_orders_survive_a_checkpoint(coordinator, order):
+    task_ids = coordinator.submit_orders(order(4))
+    checkpoint = coordinator.checkpoint_state()
+
+    coordinator.restore_checkpoint(checkpoint)
+    assert [c['task_id'] for _, _, c in coordinator._deferred] == task_ids[2:]
+
+
+@pytest.mark.asyncio
+async def test_a_run_never_puts_more_on_a_station_than_it_holds(coordinator, order):
+    result = await coordinator.execute_scenario(order(6) + order(2, TaskType.CLEANING), 60, run_id="capacity")
+
+    assert result["tasks_completed"] == 8
+    stations = result["agent_metrics"]["stations"]
+    assert stations["hot_line"]["peak_utilization"] == 1.0
+    assert stations["hot_line"]["deferred"] == 4
+    assert len(stations["hot_line"]["timeline"]) == 8
+    assert result["agent_metrics"]["team"]["station_deferrals"] == 4
+    admitted = [e for e in coordinator.event_log if e.event_type == "task_admitted"]
+    assert len(admitted) == 4
+    for sample in coordinator._station_samples:
+        for station, load in sample["load"].items():
+            assert load <= coordinator.station_capacity[station]
+
+
+def test_capacity_overrides_from_the_environment(monkeypatch):
+    monkeypatch.setenv("CHEFBENCH_STATION_CAPACITY", '{"roast": 2}')
+    assert station_capacity_from_env() == {**STATION_CAPACITY, "roast": 2}
+
+    # One bad station throws out the whole override
+    monkeypatch.setenv("CHEFBENCH_STATION_CAPACITY", '{"roast": 2, "grill": 3}')
+    assert station_capacity_from_env() == STATION_CAPACITY
+    monkeypatch.setenv("CHEFBENCH_STATION_CAPACITY", '{"roast": 0}')
+    assert station_capacity_from_env() == STATION_CAPACITY
+    assert station_for(TaskType.COOKING_EXECUTION) == "hot_line"
@@ -0,0 +1,110 @@
+"""
+Cooking techniques: the registry of what each takes, recipes checked against it on import, and orders routed only to
+cooks whose role supports their recipe's techniques
+"""
+
+import json
+
+import pytest
+
+from eta import ETAEstimator
+from kitchen.menu import Menu
+from kitchen.orders import OrderItem, OrderPlanner
+from models.models import AgentRole, MOCK_MODEL
+from providers import MultiAgentCoordinator
+from recipes.dataset_parser import RecipeDatasetParser
+from recipes.importer import infer_step, parse_schema_org
+from recipes.ingredients import IngredientCatalog
+from recipes.techniques import resolve_technique, infer_techniques, technique_equipment, supports
+
+
+def test_the_registry_knows_each_technique_and_who_may_do_it():
+    assert [resolve_technique(n) for n in ("Braising", "sous-vide", "Deep-frying", "sauté", "poach", "flambé")] == [
+        "braise", "sous_vide", "fry", "saute", "poach", None
+    ]
+    assert infer_techniques("Temper the chocolate, then let rise until doubled") == ["proof", "temper"]
+    assert technique_equipment(["braise", "sous_vide", "emulsify"]) == ["stove", "oven", "mixer"]
+
+    line_cook = (AgentRole.LINE_COOK.value, ["food_safety"])
+    assert supports(*line_cook, ["grill", "saute", "fry", "bake"])
+    assert not supports(*line_cook, ["braise"])
+    assert supports(AgentRole.CHEF_DE_PARTIE.value, ["food_safety"], ["braise", "sous_vide"])
+    # Sous vide takes food safety as well as the role
+    assert not supports(AgentRole.CHEF_DE_PARTIE.value, [], ["sous_vide"])
+    assert not supports(AgentRole.COMMIS.value, [], ["chill"])
+
+
+def test_imported_recipes_are_checked_against_the_registry():
+    step = infer_step("Braise the short ribs for 3 hours")
+    assert (step.techniques, step.equipment) == (["braise"], ["stove", "oven"])
+
+    document = {"@graph": [
+        {
+            "@type": "Recipe", "name": "Short ribs", "cookingMethod": "Braising",
+            "recipeIngredient": ["2 lb short ribs"], "recipeInstructions": ["Sear the ribs.", "Braise for 3 hours."]
+        },
+        {
+            "@type": "Recipe", "name": "Bananas Foster", "cookingMethod": ["Flambé", "Sautéing"],
+            "recipeIngredient": ["4 bananas"], "recipeInstructions": ["Slice the bananas."]
+        },
+    ]}
+    ribs, bananas = parse_schema_org(json.dumps(document))
+    assert ribs.recipe["techniques"] == ["braise", "sear"]
+    assert ribs.errors == []
+    assert bananas.recipe["techniques"] == ["saute"]
+    assert bananas.errors == ["Unknown cooking technique 'Flambé'"]
+
+
+@pytest.mark.asyncio
+async def test_an_import_with_an_unknown_technique_is_refused(tmp_path, monkeypatch):
+    pytest.importorskip("fastapi")
+    from fastapi import HTTPException
+    from kitchen.api import ChefBenchAPI, RecipeImportRequest
+
+    monkeypatch.chdir(tmp_path)
+    api = ChefBenchAPI()
+    import_recipes = next(
+        route.endpoint for route in api.app.routes if getattr(route, "path", None) == "/recipes/import"
+    )
+    content = json.dumps({
+        "@type": "Recipe", "name": "Cherries jubilee", "cookingMethod": "Flambé",
+        "recipeIngredient": ["cherries"], "recipeInstructions": ["Flambé the cherries."]
+    })
+
+    parsed = await import_recipes(RecipeImportRequest(format="schema_org", content=content, dry_run=True))
+    assert parsed["recipes"][0]["errors"] == ["Unknown cooking technique 'Flambé'"]
+    with pytest.raises(HTTPException) as refused:
+        await import_recipes(RecipeImportRequest(format="schema_org", content=content))
+    assert refused.value.status_code == 422
+    assert refused.value.detail == {"errors": ["Cherries jubilee: Unknown cooking technique 'Flambé'"]}
+    assert api.dataset_parser.recipes == []
+
+
+def test_orders_are_planned_for_cooks_who_can_use_the_technique(coordinator: MultiAgentCoordinator):
+    parser = RecipeDatasetParser()
+    parser.recipes = [
+        {"id": 1, "cuisine": "french", "ingredients": ["eggs", "butter"], "techniques": ["braise"]},
+        {"id": 2, "cuisine": "french", "ingredients": ["eggs", "butter"], "techniques": ["fry"]},
+    ]
+    planner = OrderPlanner(coordinator, parser, IngredientCatalog(), ETAEstimator(), Menu(parser))
+
+    braised, fried = planner.plan([OrderItem(recipe_id=1), OrderItem(recipe_id=2)], ["eggs", "butter"]).items
+    assert braised.errors == ["No one on shift can do cooking_execution by braise"]
+    assert (fried.agent, fried.techniques, fried.errors) == ("cook", ["fry"], [])
+
+    coordinator.create_agent("chef", AgentRole.CHEF_DE_PARTIE, MOCK_MODEL)
+    plan = planner.plan([OrderItem(recipe_id=1)], ["eggs", "butter"])
+    assert plan.items[0].agent == "chef"
+    assert [context["techniques"] for _, context in plan.tasks()] == [["braise"]]
+
+
+@pytest.mark.asyncio
+async def test_technique_orders_go_to_a_cook_whose_role_supports_them(coordinator: MultiAgentCoordinator, order):
+    coordinator.create_agent("prep", AgentRole.PREP_COOK, MOCK_MODEL)
+    coordinator.create_agent("chef", AgentRole.CHEF_DE_PARTIE, MOCK_MODEL)
+
+    await coordinator.execute_scenario(order(3, techniques=["braise"]), 30, run_id="braise")
+
+    assert {execution.agent_name for execution in coordinator.execution_history} == {"chef"}
+    assert coordinator.permissions.summary()["role_coherence"] == 1.0
+    assert coordinator.preview_assignment(order()[0][0], ("temper",)) == "chef"
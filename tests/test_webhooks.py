@@ -0,0 +1,159 @@
+"""
+Webhooks: what the event log sends them, and where they may deliver: public addresses, and private ones only when allowed by name
+"""
+
+import json
+import socket
+
+import pytest
+
+import kitchen.webhooks
+from kitchen.webhooks import ORDER_COMPLETED, QUALITY_FAILED, WebhookDispatcher, check_destination, webhook_events
+from models.models import KitchenEvent
+
+
+def _executed(task_type: str, success: bool = True, quality_score: float = 0.9) -> KitchenEvent:
+    return KitchenEvent(
+        "task_executed", run_id="run", agent_name="cook", task_id="task-1",
+        payload={"task_type": task_type, "success": success, "quality_score": quality_score}
+    )
+
+
+def _resolving_to(monkeypatch, address: str):
+    monkeypatch.setattr(socket, "getaddrinfo", lambda *a, **k: [(socket.AF_INET, socket.SOCK_STREAM, 6, "", (address, 0))])
+
+
+@pytest.mark.parametrize("url", [
+    "http://127.0.0.1:8000/admin/faults",
+    "http://localhost/hook",
+    "http://10.0.0.5/hook",
+    "https://192.168.1.20/hook",
+    "http://169.254.169.254/latest/meta-data",
+    "http://[::1]/hook",
+    "http://[::ffff:127.0.0.1]/hook",
+    "http://0.0.0.0/hook",
+])
+def test_private_destinations_are_refused(url):
+    with pytest.raises(ValueError, match="loopback, private or reserved"):
+        check_destination(url)
+
+
+def test_public_and_allowed_destinations_pass():
+    check_destination("https://93.184.216.34/hook")
+    check_destination("http://LocalHost:9000/hook", allowed_hosts=["localhost"])
+    check_destination("http://10.0.0.5/hook", allowed_hosts=["10.0.0.5"])
+
+
+def test_dispatcher_refuses_private_urls_on_add_and_update(tmp_path):
+    dispatcher = WebhookDispatcher(str(tmp_path / "webhooks.json"), allowed_hosts=["receiver.internal"])
+
+    with pytest.raises(ValueError):
+        dispatcher.add("http://127.0.0.1:8000/hook")
+    assert dispatcher.webhooks == {}
+
+    webhook = dispatcher.add("http://receiver.internal/hook", secret="s3cret")
+    with pytest.raises(ValueError):
+        dispatcher.update(webhook.webhook_id, url="http://192.168.0.1/hook")
+    assert dispatcher.webhooks[webhook.webhook_id].url == "http://receiver.internal/hook"
+
+    # Changing anything but the URL doesn't resolve it again
+    assert not dispatcher.update(webhook.webhook_id, enabled=False).enabled
+
+
+def test_only_cooked_orders_are_reported_as_completed():
+    assert [name for name, _ in webhook_events(_executed("cooking_execution"))] == [ORDER_COMPLETED]
+    # A failed order is a quality failure, not a completion
+    assert [name for name, _ in webhook_events(_executed("cooking_execution", success=False))] == [QUALITY_FAILED]
+    assert webhook_events(_executed("cleaning")) == []
+    assert webhook_events(_executed("menu_planning", success=False)) == []
+
+    low = webhook_events(_executed("plating_design", quality_score=0.2))
+    assert [name for name, _ in low] == [ORDER_COMPLETED, QUALITY_FAILED]
+
+
+@pytest.mark.asyncio
+async def test_failed_and_non_order_tasks_deliver_no_completion(tmp_path, monkeypatch):
+    sent = []
+
+    async def post(url, body, headers, transport=None, backoff_seconds=0, address=None):
+        sent.append(json.loads(body)["event"])
+        return 1, 200, None
+
+    monkeypatch.setattr(kitchen.webhooks, "post_with_retries", post)
+    dispatcher = WebhookDispatcher(str(tmp_path / "webhooks.json"))
+    dispatcher.add("https://93.184.216.34/hook", events=[ORDER_COMPLETED])
+
+    dispatcher.dispatch(_executed("cooking_execution", success=False))
+    dispatcher.dispatch(_executed("cleaning"))
+    dispatcher.dispatch(_executed("cooking_execution"))
+    for task in list(dispatcher._pending):
+        await task
+    assert sent == [ORDER_COMPLETED]
+    assert len(dispatcher.history()) == 1
+
+
+@pytest.mark.asyncio
+async def test_deliveries_resolve_again_and_connect_to_the_address_checked(tmp_path, monkeypatch):
+    posted = []
+
+    async def post(url, body, headers, transport=None, backoff_seconds=0, address=None):
+        posted.append(address)
+        return 1, 200, None
+
+    monkeypatch.setattr(kitchen.webhooks, "post_with_retries", post)
+    _resolving_to(monkeypatch, "93.184.216.34")
+    dispatcher = WebhookDispatcher(str(tmp_path / "webhooks.json"))
+    webhook = dispatcher.add("https://hooks.example.com/chefbench", secret="s3cret")
+
+    delivery = await dispatcher.deliver(webhook, ORDER_COMPLETED, {})
+    assert delivery.delivered and posted == ["93.184.216.34"]
+
+    # The host now resolves to the metadata service
+    _resolving_to(monkeypatch, "169.254.169.254")
+    delivery = await dispatcher.deliver(webhook, ORDER_COMPLETED, {})
+    assert not delivery.delivered and delivery.attempts == 0
+    assert delivery.error.startswith("Refused:")
+    assert posted == ["93.184.216.34"]
+
+
+def test_webhooks_loaded_from_disk_are_checked(tmp_path):
+    path = tmp_path / "webhooks.json"
+    good = {"url": "https://93.184.216.34/hook", "secret": "s3cret", "webhook_id": "good"}
+    path.write_text(json.dumps([
+        good,
+        {"url": "http://127.0.0.1:8000/admin/faults", "secret": "s3cret", "webhook_id": "internal"},
+        {"url": "ftp://93.184.216.34/hook", "secret": "s3cret", "webhook_id": "scheme"},
+    ]))
+
+    assert list(WebhookDispatcher(str(path)).webhooks) == ["good"]
+    # Unless the host is allowed by name
+    assert set(WebhookDispatcher(str(path), allowed_hosts=["127.0.0.1"]).webhooks) == {"good", "internal"}
+
+
+@pytest.mark.asyncio
+async def test_a_pinned_post_names_the_host_but_connects_to_the_address(monkeypatch):
+    requests = []
+
+    class Client:
+        def __init__(self, **kwargs):
+            pass
+
+        async def __aenter__(self):
+            return self
+
+        async def __aexit__(self, *exc):
+            return False
+
+        async def post(self, url, content, headers, extensions):
+            requests.append((url, headers["Host"], extensions))
+            return type("Response", (), {"status_code": 204, "is_success": True})()
+
+    monkeypatch.setattr(kitchen.webhooks.httpx, "AsyncClient", Client)
+    result = await kitchen.webhooks.post_with_retries(
+        "https://hooks.example.com:8443/chefbench?x=1", b"{}", lambda: {}, address="93.184.216.34"
+    )
+
+    assert result == (1, 204, None)
+    assert requests == [(
+        "https://93.184.216.34:8443/chefbench?x=1", "hooks.example.com:8443", {"sni_hostname": "hooks.example.com"}
+    )]